@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+
+	"finalgo/internal/armado"
+	"finalgo/internal/auditoria"
+	"finalgo/internal/odontologo"
+	"finalgo/internal/paciente"
+	"finalgo/internal/turno"
+	"finalgo/internal/usuario"
+	"finalgo/pkg/auth"
+	"finalgo/pkg/basedatos"
+	"finalgo/pkg/config"
+	"finalgo/pkg/transaccion"
+)
+
+// clinicctl administra la clínica desde la línea de comandos. Usa la misma configuración que el servidor
+// (config.yaml, .env y variables de entorno) y llama directamente a los services contra esa base, sin pasar por la
+// API. Los cambios quedan en la auditoría como hechos por el sistema.
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		uso()
+	}
+	cmd, ok := comandos[os.Args[1]]
+	if !ok {
+		uso()
+	}
+
+	cfg, err := config.Cargar()
+	if err != nil {
+		log.Fatalf("Error en la configuración: %v", err)
+	}
+	db, err := basedatos.Abrir(cfg.DB)
+	if err != nil {
+		log.Fatalf("Error al conectar con la base de datos: %v", err)
+	}
+	defer db.Close()
+
+	a, err := nuevaApp(db, cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := cmd.ejecutar(context.Background(), a, os.Args[2:]); err != nil {
+		log.Fatalf("%s: %v", os.Args[1], err)
+	}
+}
+
+// comando es un subcomando de clinicctl; los argumentos que recibe son los que siguen a su nombre
+type comando struct {
+	descripcion string
+	ejecutar    func(ctx context.Context, a *app, args []string) error
+}
+
+var comandos = map[string]comando{
+	"migrate":   {"aplica, deshace o lista las migraciones del esquema (up, down [n], status)", migrar},
+	"seed":      {"carga odontólogos, pacientes y turnos de ejemplo", sembrar},
+	"usuarios":  {"crea o lista los usuarios del personal (crear, listar)", usuarios},
+	"pacientes": {"exporta o importa pacientes en JSON (exportar, importar)", pacientes},
+	"turnos":    {"exporta o importa turnos en JSON (exportar, importar)", turnos},
+	"reportes":  {"muestra reportes de turnos, agenda y asistencia (turnos, agenda, asistencia)", reportes},
+}
+
+// uso muestra los comandos disponibles y termina
+func uso() {
+	nombres := []string{}
+	for nombre := range comandos {
+		nombres = append(nombres, nombre)
+	}
+	sort.Strings(nombres)
+
+	fmt.Fprintln(os.Stderr, "uso: clinicctl <comando> [argumentos]\n\ncomandos:")
+	for _, nombre := range nombres {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", nombre, comandos[nombre].descripcion)
+	}
+	fmt.Fprintln(os.Stderr, "\nlos subcomandos con opciones las muestran con -h")
+	os.Exit(2)
+}
+
+// app reúne la base y los services que usan los comandos, armados igual que en el servidor
+type app struct {
+	db          *sql.DB
+	cfg         config.Config
+	pacientes   paciente.Service
+	odontologos odontologo.Service
+	turnos      turno.Service
+	usuarios    usuario.Service
+}
+
+func nuevaApp(db *sql.DB, cfg config.Config) (*app, error) {
+	claves, err := auth.ParseClaves(cfg.Tokens.Claves, cfg.Tokens.ClaveActual)
+	if err != nil {
+		return nil, fmt.Errorf("error en las claves de JWT: %w", err)
+	}
+	tokens := auth.NewTokens(claves, cfg.Tokens.Emisor, cfg.Tokens.Vigencia())
+
+	repos, err := armado.NuevosRepositorios(db, cfg.DB.Motor)
+	if err != nil {
+		return nil, err
+	}
+	configTurnos, err := armado.ConfigTurnos(cfg.Turnos)
+	if err != nil {
+		return nil, err
+	}
+	auditoriaService := auditoria.NewService(repos.Auditoria)
+	uow := transaccion.NewUnitOfWork(db)
+	pacienteService := paciente.NewService(repos.Paciente, uow, auditoriaService)
+	odontologoService := odontologo.NewService(repos.Odontologo, uow, auditoriaService)
+	turnoService := turno.NewService(repos.Turno, uow, pacienteService, odontologoService, nil, nil, auditoriaService, configTurnos)
+	usuarioService := usuario.NewService(repos.Usuario, tokens, odontologoService, cfg.Tokens.VigenciaRefresh())
+
+	return &app{db, cfg, pacienteService, odontologoService, turnoService, usuarioService}, nil
+}
+
+// formato de fechas y horas en las salidas y los argumentos
+const (
+	formatoFecha     = "2006-01-02"
+	formatoFechaHora = "2006-01-02 15:04"
+)
+
+// abrirEntrada abre el archivo a importar; sin archivo se lee la entrada estándar
+func abrirEntrada(archivo string) (io.ReadCloser, error) {
+	if archivo == "" || archivo == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(archivo)
+}
+
+// escribirJSON guarda los datos exportados en el archivo, o los muestra si no se indicó uno
+func escribirJSON(archivo string, datos interface{}) error {
+	salida := os.Stdout
+	if archivo != "" && archivo != "-" {
+		f, err := os.Create(archivo)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		salida = f
+	}
+	encoder := json.NewEncoder(salida)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(datos)
+}
+
+// resumenImportacion cuenta el resultado de una importación. Un registro que falla no corta la importación: se
+// informa y se sigue con el próximo, y al final el comando termina con error si hubo alguno.
+type resumenImportacion struct {
+	creados    int
+	existentes int
+	errores    int
+}
+
+func (r resumenImportacion) cerrar() error {
+	fmt.Printf("%d creados, %d ya existían, %d con error\n", r.creados, r.existentes, r.errores)
+	if r.errores > 0 {
+		return fmt.Errorf("%d registros no se pudieron importar", r.errores)
+	}
+	return nil
+}
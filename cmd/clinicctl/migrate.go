@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"finalgo/pkg/migraciones"
+)
+
+// migrar aplica (up), deshace (down [n], por defecto la última) o lista (status) las migraciones de pkg/migraciones
+func migrar(ctx context.Context, a *app, args []string) error {
+	if len(args) == 0 {
+		return errors.New("falta el subcomando: up, down [n] o status")
+	}
+	migrador, err := migraciones.NewMigrador(a.db, a.cfg.DB.Motor)
+	if err != nil {
+		return fmt.Errorf("al leer las migraciones: %w", err)
+	}
+
+	switch args[0] {
+	case "up":
+		hechas, err := migrador.Up(ctx)
+		informarMigraciones("aplicada", hechas)
+		if err != nil {
+			return err
+		}
+		if len(hechas) == 0 {
+			fmt.Println("no hay migraciones pendientes")
+		}
+	case "down":
+		pasos := 1
+		if len(args) > 1 {
+			pasos, err = strconv.Atoi(args[1])
+			if err != nil || pasos < 1 {
+				return fmt.Errorf("la cantidad de migraciones a deshacer debe ser un número positivo: %q", args[1])
+			}
+		}
+		hechas, err := migrador.Down(ctx, pasos)
+		informarMigraciones("deshecha", hechas)
+		if err != nil {
+			return err
+		}
+		if len(hechas) == 0 {
+			fmt.Println("no hay migraciones aplicadas")
+		}
+	case "status":
+		estados, err := migrador.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, e := range estados {
+			estado := "pendiente"
+			if e.Aplicada != nil {
+				estado = "aplicada el " + e.Aplicada.Format(formatoFechaHora)
+			}
+			fmt.Printf("%04d_%-30s %s\n", e.Version, e.Nombre, estado)
+		}
+	default:
+		return fmt.Errorf("subcomando desconocido %q: up, down [n] o status", args[0])
+	}
+	return nil
+}
+
+// informarMigraciones muestra las migraciones que se aplicaron o deshicieron, aunque la corrida haya fallado después
+func informarMigraciones(accion string, hechas []migraciones.Migracion) {
+	for _, m := range hechas {
+		fmt.Printf("%s %04d_%s\n", accion, m.Version, m.Nombre)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+
+	"finalgo/internal/paciente"
+)
+
+// pacientes exporta (exportar) o importa (importar) los pacientes en JSON
+func pacientes(ctx context.Context, a *app, args []string) error {
+	if len(args) == 0 {
+		return errors.New("falta el subcomando: exportar o importar")
+	}
+	flags := flag.NewFlagSet("pacientes "+args[0], flag.ExitOnError)
+	archivo := flags.String("archivo", "", "archivo JSON (por defecto la salida o la entrada estándar)")
+	flags.Parse(args[1:])
+
+	switch args[0] {
+	case "exportar":
+		return exportarPacientes(ctx, a, *archivo)
+	case "importar":
+		return importarPacientes(ctx, a, *archivo)
+	default:
+		return fmt.Errorf("subcomando desconocido %q: exportar o importar", args[0])
+	}
+}
+
+// exportarPacientes guarda los pacientes activos con sus datos de contacto, en el mismo formato que devuelve la API
+func exportarPacientes(ctx context.Context, a *app, archivo string) error {
+	lista, err := a.pacientes.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	return escribirJSON(archivo, lista)
+}
+
+// importarPacientes da de alta los pacientes de una exportación (o cualquier lista con los campos del alta por API).
+// Los que ya existen con el mismo DNI se saltean; los IDs no se conservan.
+func importarPacientes(ctx context.Context, a *app, archivo string) error {
+	entrada, err := abrirEntrada(archivo)
+	if err != nil {
+		return err
+	}
+	defer entrada.Close()
+
+	var lista []paciente.PacienteRequest
+	if err := json.NewDecoder(entrada).Decode(&lista); err != nil {
+		return fmt.Errorf("el archivo no es una lista de pacientes: %w", err)
+	}
+
+	var resumen resumenImportacion
+	for _, p := range lista {
+		if _, err := a.pacientes.GetPacienteIDByDNI(ctx, p.DNI); err == nil {
+			resumen.existentes++
+			continue
+		}
+		if _, err := a.pacientes.CreatePaciente(ctx, p); err != nil {
+			fmt.Printf("paciente %s: %v\n", p.DNI, err)
+			resumen.errores++
+			continue
+		}
+		resumen.creados++
+	}
+	return resumen.cerrar()
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"finalgo/internal/turno"
+)
+
+// reportes muestra el resumen de turnos de un período (turnos), la agenda de un odontólogo (agenda) o el historial
+// de asistencia de un paciente (asistencia)
+func reportes(ctx context.Context, a *app, args []string) error {
+	if len(args) == 0 {
+		return errors.New("falta el reporte: turnos, agenda o asistencia")
+	}
+	switch args[0] {
+	case "turnos":
+		return reporteTurnos(ctx, a, args[1:])
+	case "agenda":
+		return reporteAgenda(ctx, a, args[1:])
+	case "asistencia":
+		return reporteAsistencia(ctx, a, args[1:])
+	default:
+		return fmt.Errorf("reporte desconocido %q: turnos, agenda o asistencia", args[0])
+	}
+}
+
+// estados en el orden en que se muestran las columnas del resumen
+var estadosTurno = []string{turno.EstadoPendiente, turno.EstadoConfirmado, turno.EstadoAtendido, turno.EstadoCancelado, turno.EstadoAusente}
+
+// reporteTurnos cuenta los turnos de cada odontólogo por estado entre dos fechas (por defecto, el mes en curso)
+func reporteTurnos(ctx context.Context, a *app, args []string) error {
+	hoy := time.Now()
+	flags := flag.NewFlagSet("reportes turnos", flag.ExitOnError)
+	desde := flags.String("desde", time.Date(hoy.Year(), hoy.Month(), 1, 0, 0, 0, 0, time.Local).Format(formatoFecha), "primer día (AAAA-MM-DD)")
+	hasta := flags.String("hasta", hoy.Format(formatoFecha), "último día, incluido (AAAA-MM-DD)")
+	flags.Parse(args)
+
+	inicio, err := time.ParseInLocation(formatoFecha, *desde, time.Local)
+	if err != nil {
+		return fmt.Errorf("fecha desde inválida: %q", *desde)
+	}
+	fin, err := time.ParseInLocation(formatoFecha, *hasta, time.Local)
+	if err != nil {
+		return fmt.Errorf("fecha hasta inválida: %q", *hasta)
+	}
+	fin = fin.AddDate(0, 0, 1)
+
+	lista, err := a.turnos.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	odontologos, err := a.odontologos.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	nombres := map[int]string{}
+	for _, o := range odontologos {
+		nombres[o.ID] = o.Apellido + ", " + o.Nombre
+	}
+
+	porOdontologo := map[int]map[string]int{}
+	for _, t := range lista {
+		if t.FechaHora.Before(inicio) || !t.FechaHora.Before(fin) {
+			continue
+		}
+		if porOdontologo[t.IdOdontologo] == nil {
+			porOdontologo[t.IdOdontologo] = map[string]int{}
+		}
+		porOdontologo[t.IdOdontologo][t.Estado]++
+	}
+	ids := []int{}
+	for id := range porOdontologo {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(w, "ID\tODONTÓLOGO\t")
+	for _, estado := range estadosTurno {
+		fmt.Fprintf(w, "%s\t", estado)
+	}
+	fmt.Fprintln(w, "total\t")
+
+	totales := map[string]int{}
+	for _, id := range ids {
+		nombre := nombres[id]
+		if nombre == "" {
+			nombre = "(dado de baja)"
+		}
+		fmt.Fprintf(w, "%d\t%s\t", id, nombre)
+		total := 0
+		for _, estado := range estadosTurno {
+			cantidad := porOdontologo[id][estado]
+			fmt.Fprintf(w, "%d\t", cantidad)
+			totales[estado] += cantidad
+			total += cantidad
+		}
+		fmt.Fprintf(w, "%d\t\n", total)
+	}
+
+	fmt.Fprint(w, "\ttotal\t")
+	total := 0
+	for _, estado := range estadosTurno {
+		fmt.Fprintf(w, "%d\t", totales[estado])
+		total += totales[estado]
+	}
+	fmt.Fprintf(w, "%d\t\n", total)
+	return w.Flush()
+}
+
+// reporteAgenda muestra los turnos de un odontólogo en un día (por defecto, hoy)
+func reporteAgenda(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("reportes agenda", flag.ExitOnError)
+	idOdontologo := flags.Int("odontologo", 0, "ID del odontólogo")
+	dia := flags.String("fecha", time.Now().Format(formatoFecha), "día de la agenda (AAAA-MM-DD)")
+	flags.Parse(args)
+
+	fecha, err := time.ParseInLocation(formatoFecha, *dia, time.Local)
+	if err != nil {
+		return fmt.Errorf("fecha inválida: %q", *dia)
+	}
+	agenda, err := a.turnos.GetAgenda(ctx, *idOdontologo, fecha)
+	if err != nil {
+		return err
+	}
+	_, dnis, err := identificadores(ctx, a)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "HORA\tTURNO\tDNI PACIENTE\tESTADO\tDESCRIPCIÓN")
+	for _, t := range agenda {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n", t.FechaHora.Format("15:04"), t.ID, dnis[t.IdPaciente], t.Estado, t.Descripcion)
+	}
+	return w.Flush()
+}
+
+// reporteAsistencia muestra las ausencias y cancelaciones tardías de un paciente según la política vigente
+func reporteAsistencia(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("reportes asistencia", flag.ExitOnError)
+	idPaciente := flags.Int("paciente", 0, "ID del paciente")
+	flags.Parse(args)
+
+	asistencia, err := a.turnos.GetAsistencia(ctx, *idPaciente)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("ausencias: %d\ncancelaciones tardías: %d\nrestringido: %s\n", asistencia.Ausencias, asistencia.CancelacionesTardias, siNo(asistencia.Restringido))
+
+	if len(asistencia.Incidencias) == 0 {
+		return nil
+	}
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FECHA\tTURNO\tTIPO\tPENALIDAD")
+	for _, i := range asistencia.Incidencias {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%.2f\n", i.Fecha.Format(formatoFechaHora), i.IdTurno, i.Tipo, i.Penalidad)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"finalgo/internal/odontologo"
+	"finalgo/internal/paciente"
+	"finalgo/internal/turno"
+)
+
+// datos de ejemplo para desarrollo y demos
+var (
+	odontologosDemo = []odontologo.OdontologoRequest{
+		{Apellido: "Pérez", Nombre: "Juan", Matricula: "12345", Especialidades: []string{"general"}},
+		{Apellido: "Gómez", Nombre: "María", Matricula: "67890", Especialidades: []string{"ortodoncia"}},
+		{Apellido: "López", Nombre: "Carlos", Matricula: "54321", Especialidades: []string{"cirugia", "implantes"}},
+	}
+	pacientesDemo = []paciente.PacienteRequest{
+		{Nombre: "Ana", Apellido: "Martínez", Domicilio: "Calle 123", DNI: "12345678"},
+		{Nombre: "Pedro", Apellido: "González", Domicilio: "Avenida XYZ", DNI: "87654321"},
+		{Nombre: "Laura", Apellido: "Díaz", Domicilio: "Calle ABC", DNI: "98765432"},
+	}
+	// los turnos se dan a partir de mañana: dias es a cuántos días de hoy y hora el horario en minutos
+	turnosDemo = []struct {
+		matricula   string
+		dni         string
+		dias        int
+		minutos     int
+		descripcion string
+	}{
+		{"12345", "12345678", 1, 10 * 60, "Limpieza dental"},
+		{"67890", "87654321", 2, 15*60 + 30, "Control de ortodoncia"},
+		{"54321", "98765432", 3, 9*60 + 15, "Extracción de muelas"},
+	}
+)
+
+// sembrar carga los datos de ejemplo. Los odontólogos y pacientes que ya existen (por matrícula o DNI) no se vuelven
+// a crear, y los turnos sólo se dan si se creó alguno de los dos en esta corrida; así se puede correr más de una vez.
+func sembrar(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	flags.Parse(args)
+
+	nuevos := map[string]bool{}
+	for _, o := range odontologosDemo {
+		if _, err := a.odontologos.GetOdontologoIdByMatricula(ctx, o.Matricula); err == nil {
+			fmt.Printf("odontólogo %s ya existe\n", o.Matricula)
+			continue
+		}
+		creado, err := a.odontologos.CreateOdontologo(ctx, o)
+		if err != nil {
+			return fmt.Errorf("al crear el odontólogo %s: %w", o.Matricula, err)
+		}
+		nuevos[o.Matricula] = true
+		fmt.Printf("odontólogo %d: %s, %s\n", creado.ID, creado.Apellido, creado.Nombre)
+	}
+
+	alta := time.Now()
+	for _, p := range pacientesDemo {
+		if _, err := a.pacientes.GetPacienteIDByDNI(ctx, p.DNI); err == nil {
+			fmt.Printf("paciente %s ya existe\n", p.DNI)
+			continue
+		}
+		p.Alta = alta
+		creado, err := a.pacientes.CreatePaciente(ctx, p)
+		if err != nil {
+			return fmt.Errorf("al crear el paciente %s: %w", p.DNI, err)
+		}
+		nuevos[p.DNI] = true
+		fmt.Printf("paciente %d: %s, %s\n", creado.ID, creado.Apellido, creado.Nombre)
+	}
+
+	hoy := time.Now()
+	inicio := time.Date(hoy.Year(), hoy.Month(), hoy.Day(), 0, 0, 0, 0, time.Local)
+	for _, t := range turnosDemo {
+		if !nuevos[t.matricula] && !nuevos[t.dni] {
+			continue
+		}
+		creado, err := a.turnos.CreateTurnoByDniAndMatricula(ctx, turno.TurnoDniMatriculaRequest{
+			MatriculaOdontologo: t.matricula,
+			DniPaciente:         t.dni,
+			FechaHora:           inicio.AddDate(0, 0, t.dias).Add(time.Duration(t.minutos) * time.Minute),
+			Descripcion:         t.descripcion,
+		})
+		if err != nil {
+			return fmt.Errorf("al crear el turno de %s: %w", t.dni, err)
+		}
+		fmt.Printf("turno %d: %s\n", creado.ID, creado.FechaHora.Format(formatoFechaHora))
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"finalgo/internal/turno"
+)
+
+// turnoExportado identifica al odontólogo por matrícula y al paciente por DNI, así el archivo se puede importar en
+// otra base donde los IDs son distintos
+type turnoExportado struct {
+	MatriculaOdontologo string    `json:"matricula_odontologo"`
+	DniPaciente         string    `json:"dni_paciente"`
+	FechaHora           time.Time `json:"fecha_hora"`
+	Descripcion         string    `json:"descripcion"`
+	CodigoPrestacion    string    `json:"codigo_prestacion"`
+	Estado              string    `json:"estado"`
+}
+
+// turnos exporta (exportar) o importa (importar) los turnos en JSON
+func turnos(ctx context.Context, a *app, args []string) error {
+	if len(args) == 0 {
+		return errors.New("falta el subcomando: exportar o importar")
+	}
+	flags := flag.NewFlagSet("turnos "+args[0], flag.ExitOnError)
+	archivo := flags.String("archivo", "", "archivo JSON (por defecto la salida o la entrada estándar)")
+	flags.Parse(args[1:])
+
+	switch args[0] {
+	case "exportar":
+		return exportarTurnos(ctx, a, *archivo)
+	case "importar":
+		return importarTurnos(ctx, a, *archivo)
+	default:
+		return fmt.Errorf("subcomando desconocido %q: exportar o importar", args[0])
+	}
+}
+
+// exportarTurnos guarda los turnos activos con la matrícula del odontólogo y el DNI del paciente
+func exportarTurnos(ctx context.Context, a *app, archivo string) error {
+	lista, err := a.turnos.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	matriculas, dnis, err := identificadores(ctx, a)
+	if err != nil {
+		return err
+	}
+
+	exportados := []turnoExportado{}
+	for _, t := range lista {
+		matricula, dni := matriculas[t.IdOdontologo], dnis[t.IdPaciente]
+		if matricula == "" || dni == "" {
+			// el paciente o el odontólogo está dado de baja
+			fmt.Fprintf(os.Stderr, "turno %d: se omite, su paciente u odontólogo está dado de baja\n", t.ID)
+			continue
+		}
+		exportados = append(exportados, turnoExportado{
+			MatriculaOdontologo: matricula,
+			DniPaciente:         dni,
+			FechaHora:           t.FechaHora,
+			Descripcion:         t.Descripcion,
+			CodigoPrestacion:    t.CodigoPrestacion,
+			Estado:              t.Estado,
+		})
+	}
+	return escribirJSON(archivo, exportados)
+}
+
+// identificadores devuelve la matrícula de cada odontólogo y el DNI de cada paciente activos, por ID
+func identificadores(ctx context.Context, a *app) (map[int]string, map[int]string, error) {
+	odontologos, err := a.odontologos.GetAll(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	pacientes, err := a.pacientes.GetAll(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	matriculas := map[int]string{}
+	for _, o := range odontologos {
+		matriculas[o.ID] = o.Matricula
+	}
+	dnis := map[int]string{}
+	for _, p := range pacientes {
+		dnis[p.ID] = p.DNI
+	}
+	return matriculas, dnis, nil
+}
+
+// importarTurnos da de alta los turnos de una exportación. El odontólogo y el paciente tienen que existir (se buscan
+// por matrícula y DNI) y se saltean los turnos que ya están en la base para el mismo odontólogo, paciente y horario.
+// Todos se crean pendientes: el estado exportado es sólo informativo.
+func importarTurnos(ctx context.Context, a *app, archivo string) error {
+	entrada, err := abrirEntrada(archivo)
+	if err != nil {
+		return err
+	}
+	defer entrada.Close()
+
+	var lista []turnoExportado
+	if err := json.NewDecoder(entrada).Decode(&lista); err != nil {
+		return fmt.Errorf("el archivo no es una lista de turnos: %w", err)
+	}
+
+	var resumen resumenImportacion
+	for _, t := range lista {
+		existe, err := turnoExistente(ctx, a, t)
+		if err != nil {
+			fmt.Printf("turno de %s del %s: %v\n", t.DniPaciente, t.FechaHora.Format(formatoFechaHora), err)
+			resumen.errores++
+			continue
+		}
+		if existe {
+			resumen.existentes++
+			continue
+		}
+		_, err = a.turnos.CreateTurnoByDniAndMatricula(ctx, turno.TurnoDniMatriculaRequest{
+			MatriculaOdontologo: t.MatriculaOdontologo,
+			DniPaciente:         t.DniPaciente,
+			FechaHora:           t.FechaHora,
+			Descripcion:         t.Descripcion,
+			CodigoPrestacion:    t.CodigoPrestacion,
+		})
+		if err != nil {
+			fmt.Printf("turno de %s del %s: %v\n", t.DniPaciente, t.FechaHora.Format(formatoFechaHora), err)
+			resumen.errores++
+			continue
+		}
+		resumen.creados++
+	}
+	return resumen.cerrar()
+}
+
+// turnoExistente indica si el paciente ya tiene un turno con ese odontólogo en ese horario
+func turnoExistente(ctx context.Context, a *app, t turnoExportado) (bool, error) {
+	idOdontologo, err := a.odontologos.GetOdontologoIdByMatricula(ctx, t.MatriculaOdontologo)
+	if err != nil {
+		return false, err
+	}
+	delPaciente, err := a.turnos.GetTurnoByPaciente(ctx, t.DniPaciente)
+	if err != nil {
+		return false, err
+	}
+	for _, existente := range delPaciente {
+		if existente.IdOdontologo == idOdontologo && existente.FechaHora.Equal(t.FechaHora) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"finalgo/internal/usuario"
+	"finalgo/pkg/auth"
+)
+
+// usuarios crea (crear) o lista (listar) los usuarios del personal
+func usuarios(ctx context.Context, a *app, args []string) error {
+	if len(args) == 0 {
+		return errors.New("falta el subcomando: crear o listar")
+	}
+	switch args[0] {
+	case "crear":
+		return crearUsuario(ctx, a, args[1:])
+	case "listar":
+		return listarUsuarios(ctx, a)
+	default:
+		return fmt.Errorf("subcomando desconocido %q: crear o listar", args[0])
+	}
+}
+
+// crearUsuario da de alta un usuario. Si no viene -password la contraseña se lee de la entrada estándar, así no
+// queda en el historial de la terminal.
+func crearUsuario(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("usuarios crear", flag.ExitOnError)
+	email := flags.String("email", "", "email con el que entra el usuario")
+	nombre := flags.String("nombre", "", "nombre para mostrar")
+	rol := flags.String("rol", auth.RolRecepcionista, "admin, recepcionista u odontologo")
+	idOdontologo := flags.Int("odontologo", 0, "ID del odontólogo, para el rol odontologo")
+	password := flags.String("password", "", "contraseña (si no viene se lee de la entrada estándar)")
+	flags.Parse(args)
+
+	if *password == "" {
+		fmt.Fprint(os.Stderr, "contraseña: ")
+		linea, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && linea == "" {
+			return fmt.Errorf("al leer la contraseña: %w", err)
+		}
+		*password = strings.TrimRight(linea, "\r\n")
+	}
+
+	u, err := a.usuarios.CreateUsuario(ctx, usuario.UsuarioRequest{
+		Email:        *email,
+		Nombre:       *nombre,
+		Password:     *password,
+		Rol:          *rol,
+		IdOdontologo: *idOdontologo,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("usuario %d: %s (%s)\n", u.ID, u.Email, u.Rol)
+	return nil
+}
+
+func listarUsuarios(ctx context.Context, a *app) error {
+	lista, err := a.usuarios.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tEMAIL\tNOMBRE\tROL\tODONTÓLOGO\tACTIVO")
+	for _, u := range lista {
+		odontologo := ""
+		if u.IdOdontologo != 0 {
+			odontologo = fmt.Sprint(u.IdOdontologo)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", u.ID, u.Email, u.Nombre, u.Rol, odontologo, siNo(u.Activo))
+	}
+	return w.Flush()
+}
+
+// siNo muestra un bool para las tablas
+func siNo(valor bool) string {
+	if valor {
+		return "sí"
+	}
+	return "no"
+}
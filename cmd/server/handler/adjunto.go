@@ -0,0 +1,234 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"finalgo/internal/adjunto"
+	"finalgo/internal/paciente"
+	"finalgo/pkg/web"
+
+	"github.com/gin-gonic/gin"
+)
+
+// margen para los otros campos del formulario multipart además del archivo
+const margenMultipart = 1 << 20
+
+// creo la estructura del controlador, inyectando el service
+type adjuntoHandler struct {
+	s             adjunto.Service
+	tamanioMaximo int64
+}
+
+// funcion para instanciar el controlador. tamanioMaximo es el tamaño de archivo admitido en bytes.
+func NewAdjuntoHandler(s adjunto.Service, tamanioMaximo int64) *adjuntoHandler {
+	return &adjuntoHandler{
+		s:             s,
+		tamanioMaximo: tamanioMaximo,
+	}
+}
+
+// GET --> adjuntos del paciente
+// Adjunto godoc
+// @Summary get adjuntos del paciente
+// @Description Get metadata de radiografías, consentimientos y fotos del paciente
+// @Tags adjuntos
+// @Param id path int true "id del paciente"
+// @Produce json
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 500 {object} web.errorResponse
+// @Router /pacientes/:id/adjuntos [get]
+func (h *adjuntoHandler) GetAdjuntosByPaciente() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		adjuntos, err := h.s.GetAdjuntosByPaciente(c, id)
+		if err != nil {
+			web.ErrorResponse(c, http.StatusInternalServerError)
+			return
+		}
+		web.OkResponse(c, http.StatusOK, adjuntos)
+	}
+}
+
+// GET --> descarga de un adjunto
+// Adjunto godoc
+// @Summary descargar adjunto
+// @Description Devuelve el archivo con su content type original
+// @Tags adjuntos
+// @Param id path int true "id del paciente"
+// @Param idAdjunto path int true "id del adjunto"
+// @Produce octet-stream
+// @Success 200 {file} file
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 500 {object} web.errorResponse
+// @Router /pacientes/:id/adjuntos/:idAdjunto [get]
+func (h *adjuntoHandler) DescargarAdjunto() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+		idAdjunto, err := strconv.Atoi(c.Param("idAdjunto"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		a, contenido, err := h.s.DescargarAdjunto(c, id, idAdjunto)
+		if err != nil {
+			if errors.Is(err, adjunto.ErrArchivo) {
+				web.ErrorResponse(c, http.StatusInternalServerError)
+				return
+			}
+			web.ErrorResponse(c, http.StatusNotFound)
+			return
+		}
+		defer contenido.Close()
+
+		web.ETag(c, a.Version)
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", a.Nombre))
+		c.DataFromReader(http.StatusOK, a.Tamanio, a.ContentType, contenido, nil)
+	}
+}
+
+// POST --> subida multipart de un adjunto
+// Adjunto godoc
+// @Summary subir adjunto
+// @Description Sube una radiografía, consentimiento o foto del paciente (campos archivo, tipo y descripcion)
+// @Tags adjuntos
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "id del paciente"
+// @Param archivo formData file true "archivo"
+// @Param tipo formData string true "radiografia, consentimiento, foto u otro"
+// @Param descripcion formData string false "descripción"
+// @Success 201 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 413 {object} web.errorResponse
+// @Failure 415 {object} web.errorResponse
+// @Failure 500 {object} web.errorResponse
+// @Router /pacientes/:id/adjuntos [post]
+func (h *adjuntoHandler) SubirAdjunto() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		archivo, contenido, status := leerArchivo(c, h.tamanioMaximo)
+		if status != 0 {
+			web.ErrorResponse(c, status)
+			return
+		}
+		defer contenido.Close()
+
+		request := adjunto.AdjuntoRequest{
+			Tipo:        c.PostForm("tipo"),
+			Nombre:      archivo.Filename,
+			Descripcion: c.PostForm("descripcion"),
+			Tamanio:     archivo.Size,
+			Contenido:   contenido,
+		}
+		a, err := h.s.SubirAdjunto(c, request, id)
+		if err != nil {
+			responderErrorAdjunto(c, err)
+			return
+		}
+		web.OkResponse(c, http.StatusCreated, a)
+	}
+}
+
+// leerArchivo toma el campo "archivo" del formulario multipart. El cuerpo se corta antes de parsear para no aceptar
+// subidas gigantes; si algo falla devuelve el status a responder.
+func leerArchivo(c *gin.Context, tamanioMaximo int64) (*multipart.FileHeader, multipart.File, int) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, tamanioMaximo+margenMultipart)
+	archivo, err := c.FormFile("archivo")
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			return nil, nil, http.StatusRequestEntityTooLarge
+		}
+		return nil, nil, http.StatusBadRequest
+	}
+
+	contenido, err := archivo.Open()
+	if err != nil {
+		return nil, nil, http.StatusBadRequest
+	}
+	return archivo, contenido, 0
+}
+
+// responderErrorAdjunto traduce los errores de la subida de archivos
+func responderErrorAdjunto(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, adjunto.ErrTipo):
+		web.ErrorResponse(c, http.StatusBadRequest)
+	case errors.Is(err, adjunto.ErrTamanio):
+		web.ErrorResponse(c, http.StatusRequestEntityTooLarge)
+	case errors.Is(err, adjunto.ErrContentType):
+		web.ErrorResponse(c, http.StatusUnsupportedMediaType)
+	case errors.Is(err, paciente.ErrNotFound):
+		web.ErrorResponse(c, http.StatusNotFound)
+	default:
+		web.ErrorResponse(c, http.StatusInternalServerError)
+	}
+}
+
+// DELETE --> borra un adjunto y su archivo
+// Adjunto godoc
+// @Summary delete adjunto
+// @Description Borra la metadata y el archivo del adjunto
+// @Tags adjuntos
+// @Param id path int true "id del paciente"
+// @Param idAdjunto path int true "id del adjunto"
+// @Param If-Match header string true "ETag del adjunto leído"
+// @Produce json
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 412 {object} web.errorResponse
+// @Failure 428 {object} web.errorResponse
+// @Router /pacientes/:id/adjuntos/:idAdjunto [delete]
+func (h *adjuntoHandler) DeleteAdjunto() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+		idAdjunto, err := strconv.Atoi(c.Param("idAdjunto"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		version, ok := web.IfMatch(c)
+		if !ok {
+			return
+		}
+
+		if err := h.s.DeleteAdjunto(c, id, idAdjunto, version); err != nil {
+			if errors.Is(err, adjunto.ErrVersion) {
+				web.ErrorResponse(c, http.StatusPreconditionFailed)
+				return
+			}
+			web.ErrorResponse(c, http.StatusNotFound)
+			return
+		}
+		respuesta := "Adjunto de ID " + c.Param("idAdjunto") + " eliminado"
+		web.OkResponse(c, http.StatusOK, respuesta)
+	}
+}
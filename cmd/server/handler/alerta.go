@@ -0,0 +1,242 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"finalgo/internal/paciente"
+	"finalgo/pkg/web"
+
+	"github.com/gin-gonic/gin"
+)
+
+// creo la estructura del controlador, inyectando el service de pacientes que es el dueño de las alertas
+type alertaHandler struct {
+	s paciente.Service
+}
+
+// funcion para instanciar el controlador
+func NewAlertaHandler(s paciente.Service) *alertaHandler {
+	return &alertaHandler{
+		s: s,
+	}
+}
+
+// GET --> alertas médicas del paciente
+// Alerta godoc
+// @Summary get alertas del paciente
+// @Description Get alergias, condiciones crónicas, medicación y embarazo del paciente; con activas=true sólo las vigentes
+// @Tags alertas
+// @Param id path int true "id del paciente"
+// @Param activas query bool false "sólo alertas vigentes"
+// @Produce json
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 500 {object} web.errorResponse
+// @Router /pacientes/:id/alertas [get]
+func (h *alertaHandler) GetAlertasByPaciente() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		var alertas []paciente.AlertaMedica
+		if c.Query("activas") == "true" {
+			alertas, err = h.s.GetAlertasActivas(c, id)
+		} else {
+			alertas, err = h.s.GetAlertasByPaciente(c, id)
+		}
+		if err != nil {
+			web.ErrorResponse(c, http.StatusInternalServerError)
+			return
+		}
+		web.OkResponse(c, http.StatusOK, alertas)
+	}
+}
+
+// POST --> carga una alerta médica
+// Alerta godoc
+// @Summary crear alerta
+// @Description Carga una alerta médica del paciente
+// @Tags alertas
+// @Accept json
+// @Produce json
+// @Param id path int true "id del paciente"
+// @Param	Alerta	body	paciente.AlertaMedicaRequest	true	"Add alerta"
+// @Success 201 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 500 {object} web.errorResponse
+// @Router /pacientes/:id/alertas [post]
+func (h *alertaHandler) CreateAlerta() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		var alerta paciente.AlertaMedicaRequest
+		err = c.ShouldBindJSON(&alerta)
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		// valido la existencia de datos clave
+		valid, err := validateAlertaEmptys(alerta)
+		if !valid {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		a, err := h.s.CreateAlerta(c, alerta, id)
+		if err != nil {
+			if errors.Is(err, paciente.ErrNotFound) {
+				web.ErrorResponse(c, http.StatusNotFound)
+				return
+			}
+			web.ErrorResponse(c, http.StatusInternalServerError)
+			return
+		}
+		web.OkResponse(c, 201, a)
+	}
+}
+
+// PUT --> actualiza una alerta médica (por ejemplo, para darla de baja con activa=false)
+// Alerta godoc
+// @Summary update alerta
+// @Description Update alerta médica del paciente
+// @Tags alertas
+// @Accept json
+// @Produce json
+// @Param id path int true "id del paciente"
+// @Param idAlerta path int true "id de la alerta"
+// @Param	If-Match	header	string	true	"versión de la alerta leída"
+// @Param	Alerta	body	paciente.AlertaMedicaRequest	true	"Update alerta"
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 412 {object} web.errorResponse
+// @Failure 428 {object} web.errorResponse
+// @Failure 500 {object} web.errorResponse
+// @Router /pacientes/:id/alertas/:idAlerta [put]
+func (h *alertaHandler) UpdateAlerta() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+		idAlerta, err := strconv.Atoi(c.Param("idAlerta"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+		version, ok := web.IfMatch(c)
+		if !ok {
+			return
+		}
+
+		var alerta paciente.AlertaMedicaRequest
+		err = c.ShouldBindJSON(&alerta)
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		valid, err := validateAlertaEmptys(alerta)
+		if !valid {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		a, err := h.s.UpdateAlerta(c, alerta, id, idAlerta, version)
+		if err != nil {
+			web.ErrorResponse(c, statusErrorAlerta(err, http.StatusInternalServerError))
+			return
+		}
+		web.ETag(c, a.Version)
+		web.OkResponse(c, http.StatusOK, a)
+	}
+}
+
+// DELETE --> elimina una alerta cargada por error (para una alerta que dejó de aplicar conviene desactivarla)
+// Alerta godoc
+// @Summary delete alerta
+// @Description Delete alerta médica del paciente
+// @Tags alertas
+// @Param id path int true "id del paciente"
+// @Param idAlerta path int true "id de la alerta"
+// @Param If-Match header string true "versión de la alerta leída"
+// @Produce json
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 412 {object} web.errorResponse
+// @Failure 428 {object} web.errorResponse
+// @Router /pacientes/:id/alertas/:idAlerta [delete]
+func (h *alertaHandler) DeleteAlerta() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+		idAlerta, err := strconv.Atoi(c.Param("idAlerta"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		version, ok := web.IfMatch(c)
+		if !ok {
+			return
+		}
+
+		err = h.s.DeleteAlerta(c, id, idAlerta, version)
+		if err != nil {
+			web.ErrorResponse(c, statusErrorAlerta(err, http.StatusNotFound))
+			return
+		}
+		respuesta := "Alerta de ID " + c.Param("idAlerta") + " eliminada"
+		web.OkResponse(c, http.StatusOK, respuesta)
+	}
+}
+
+// statusErrorAlerta devuelve 404 si la alerta no existe, 412 si cambió desde que el cliente la leyó y el código por
+// defecto de la operación para el resto
+func statusErrorAlerta(err error, porDefecto int) int {
+	switch {
+	case errors.Is(err, paciente.ErrAlertaNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, paciente.ErrVersionAlerta):
+		return http.StatusPreconditionFailed
+	default:
+		return porDefecto
+	}
+}
+
+// validateAlertaEmptys valida que la alerta tenga tipo, descripción y severidad válidos
+func validateAlertaEmptys(alerta paciente.AlertaMedicaRequest) (bool, error) {
+	switch alerta.Tipo {
+	case paciente.AlertaAlergia, paciente.AlertaCondicionCronica, paciente.AlertaMedicacion, paciente.AlertaEmbarazo:
+	default:
+		return false, errors.New("El tipo debe ser alergia, condicion_cronica, medicacion o embarazo")
+	}
+	switch alerta.Severidad {
+	case paciente.SeveridadBaja, paciente.SeveridadMedia, paciente.SeveridadAlta:
+	default:
+		return false, errors.New("La severidad debe ser baja, media o alta")
+	}
+	if alerta.Descripcion == "" {
+		return false, errors.New("No se permite la descripción vacía")
+	}
+	if !alerta.FechaHasta.IsZero() && alerta.FechaHasta.Before(alerta.FechaDesde) {
+		return false, errors.New("La fecha hasta no puede ser anterior a la fecha desde")
+	}
+	return true, nil
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"finalgo/internal/auditoria"
+	"finalgo/pkg/web"
+
+	"github.com/gin-gonic/gin"
+)
+
+// creo la estructura del controlador de la auditoría, inyectando su service
+type auditoriaHandler struct {
+	s auditoria.Service
+}
+
+// funcion para instanciar el controlador
+func NewAuditoriaHandler(s auditoria.Service) *auditoriaHandler {
+	return &auditoriaHandler{
+		s: s,
+	}
+}
+
+// GET --> registro de cambios
+// Auditoria godoc
+// @Summary auditoría
+// @Description Get los altas, modificaciones y bajas de pacientes, odontólogos y turnos, con quién los hizo y el antes y después, del más nuevo al más viejo (hasta 1000)
+// @Tags auditoria
+// @Produce json
+// @Security BearerAuth
+// @Param entidad query string false "paciente, alerta_medica, responsable, odontologo, turno o incidencia_turno"
+// @Param id_entidad query int false "id de la entidad"
+// @Param usuario query int false "id del usuario que hizo el cambio"
+// @Param desde query string false "fecha desde (YYYY-MM-DD)"
+// @Param hasta query string false "fecha hasta, inclusive (YYYY-MM-DD)"
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 500 {object} web.errorResponse
+// @Router /auditoria [get]
+func (h *auditoriaHandler) Buscar() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filtro := auditoria.Filtro{Entidad: c.Query("entidad")}
+		var err error
+		if valor := c.Query("id_entidad"); valor != "" {
+			filtro.IdEntidad, err = strconv.Atoi(valor)
+			if err != nil {
+				web.ErrorResponse(c, http.StatusBadRequest)
+				return
+			}
+		}
+		if valor := c.Query("usuario"); valor != "" {
+			filtro.IdUsuario, err = strconv.Atoi(valor)
+			if err != nil {
+				web.ErrorResponse(c, http.StatusBadRequest)
+				return
+			}
+		}
+		if valor := c.Query("desde"); valor != "" {
+			filtro.Desde, err = time.ParseInLocation("2006-01-02", valor, time.Local)
+			if err != nil {
+				web.ErrorResponse(c, http.StatusBadRequest)
+				return
+			}
+		}
+		if valor := c.Query("hasta"); valor != "" {
+			hasta, err := time.ParseInLocation("2006-01-02", valor, time.Local)
+			if err != nil {
+				web.ErrorResponse(c, http.StatusBadRequest)
+				return
+			}
+			// el día de hasta se incluye completo
+			filtro.Hasta = hasta.AddDate(0, 0, 1)
+		}
+
+		registros, err := h.s.Buscar(c, filtro)
+		if err != nil {
+			if errors.Is(err, auditoria.ErrFiltro) {
+				web.ErrorResponse(c, http.StatusBadRequest)
+				return
+			}
+			web.ErrorResponse(c, http.StatusInternalServerError)
+			return
+		}
+		web.OkResponse(c, http.StatusOK, registros)
+	}
+}
@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"finalgo/internal/comprobante"
+	"finalgo/internal/facturacion"
+	"finalgo/pkg/web"
+
+	"github.com/gin-gonic/gin"
+)
+
+// creo la estructura del controlador, inyectando el service
+type comprobanteHandler struct {
+	s comprobante.Service
+}
+
+// funcion para instanciar el controlador
+func NewComprobanteHandler(s comprobante.Service) *comprobanteHandler {
+	return &comprobanteHandler{
+		s: s,
+	}
+}
+
+// GET --> comprobante de turno en PDF
+// Comprobante godoc
+// @Summary comprobante de turno
+// @Description Genera el PDF de confirmación del turno con los datos del paciente y del odontólogo
+// @Tags comprobantes
+// @Param id path int true "id del turno"
+// @Produce application/pdf
+// @Success 200 {file} file
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 500 {object} web.errorResponse
+// @Router /turnos/:id/comprobante [get]
+func (h *comprobanteHandler) ConfirmacionTurno() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		pdf, err := h.s.ConfirmacionTurno(c, id)
+		if err != nil {
+			responderErrorComprobante(c, err)
+			return
+		}
+		enviarPDF(c, fmt.Sprintf("turno-%d.pdf", id), pdf)
+	}
+}
+
+// POST --> presupuesto de tratamiento en PDF
+// Comprobante godoc
+// @Summary presupuesto de tratamiento
+// @Description Genera el PDF del presupuesto valorizado con el catálogo y la cobertura del paciente
+// @Tags comprobantes
+// @Accept json
+// @Produce application/pdf
+// @Param id path int true "id del paciente"
+// @Param	Presupuesto	body	comprobante.PresupuestoRequest	true	"Presupuesto"
+// @Success 200 {file} file
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 500 {object} web.errorResponse
+// @Router /pacientes/:id/presupuesto [post]
+func (h *comprobanteHandler) Presupuesto() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		var presupuesto comprobante.PresupuestoRequest
+		err = c.ShouldBindJSON(&presupuesto)
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		pdf, err := h.s.Presupuesto(c, id, presupuesto)
+		if err != nil {
+			responderErrorComprobante(c, err)
+			return
+		}
+		enviarPDF(c, fmt.Sprintf("presupuesto-paciente-%d.pdf", id), pdf)
+	}
+}
+
+// POST --> receta en PDF firmada por el odontólogo
+// Comprobante godoc
+// @Summary receta
+// @Description Genera el PDF de la receta con el nombre y la matrícula del odontólogo. El odontólogo firma con su propia matrícula; sólo el admin elige el firmante.
+// @Tags comprobantes
+// @Accept json
+// @Produce application/pdf
+// @Param id path int true "id del paciente"
+// @Param	Receta	body	comprobante.RecetaRequest	true	"Receta"
+// @Success 200 {file} file
+// @Failure 400 {object} web.errorResponse
+// @Failure 403 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 500 {object} web.errorResponse
+// @Router /pacientes/:id/receta [post]
+func (h *comprobanteHandler) Receta() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		var receta comprobante.RecetaRequest
+		err = c.ShouldBindJSON(&receta)
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		pdf, err := h.s.Receta(c, id, receta)
+		if err != nil {
+			responderErrorComprobante(c, err)
+			return
+		}
+		enviarPDF(c, fmt.Sprintf("receta-paciente-%d.pdf", id), pdf)
+	}
+}
+
+// los datos faltantes son 400, firmar por otro odontólogo es 403, el error al generar el PDF es 500 y el resto son
+// elementos inexistentes
+func responderErrorComprobante(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, comprobante.ErrSinItems), errors.Is(err, comprobante.ErrSinMedicamentos):
+		web.ErrorResponse(c, http.StatusBadRequest)
+	case errors.Is(err, comprobante.ErrFirmante):
+		web.ErrorResponse(c, http.StatusForbidden)
+	case errors.Is(err, comprobante.ErrRender), errors.Is(err, facturacion.ErrExec):
+		web.ErrorResponse(c, http.StatusInternalServerError)
+	default:
+		web.ErrorResponse(c, http.StatusNotFound)
+	}
+}
+
+// enviarPDF devuelve el documento como adjunto para que el navegador lo descargue o lo abra
+func enviarPDF(c *gin.Context, nombre string, pdf []byte) {
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", nombre))
+	c.Data(http.StatusOK, "application/pdf", pdf)
+}
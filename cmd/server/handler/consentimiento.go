@@ -0,0 +1,235 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"finalgo/internal/adjunto"
+	"finalgo/internal/consentimiento"
+	"finalgo/internal/prestacion"
+	"finalgo/pkg/web"
+
+	"github.com/gin-gonic/gin"
+)
+
+// creo la estructura del controlador, inyectando el service
+type consentimientoHandler struct {
+	s             consentimiento.Service
+	tamanioMaximo int64
+}
+
+// funcion para instanciar el controlador. tamanioMaximo es el tamaño admitido para el archivo de la firma.
+func NewConsentimientoHandler(s consentimiento.Service, tamanioMaximo int64) *consentimientoHandler {
+	return &consentimientoHandler{
+		s:             s,
+		tamanioMaximo: tamanioMaximo,
+	}
+}
+
+// GET --> plantillas de consentimiento, se puede filtrar por prestación
+// Consentimiento godoc
+// @Summary get plantillas de consentimiento
+// @Description Get plantillas de consentimiento informado con todas sus versiones
+// @Tags consentimientos
+// @Param prestacion query string false "código de prestación"
+// @Produce json
+// @Success 200 {object} web.response
+// @Failure 500 {object} web.errorResponse
+// @Router /consentimientos/plantillas [get]
+func (h *consentimientoHandler) GetPlantillas() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		plantillas, err := h.s.GetPlantillas(c, c.Query("prestacion"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusInternalServerError)
+			return
+		}
+		web.OkResponse(c, http.StatusOK, plantillas)
+	}
+}
+
+// GET --> plantilla de consentimiento por id
+// Consentimiento godoc
+// @Summary get plantilla de consentimiento
+// @Description Get plantilla de consentimiento by id
+// @Tags consentimientos
+// @Param id path int true "id de la plantilla"
+// @Produce json
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Router /consentimientos/plantillas/:id [get]
+func (h *consentimientoHandler) GetPlantillaByID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		plantilla, err := h.s.GetPlantillaByID(c, id)
+		if err != nil {
+			web.ErrorResponse(c, http.StatusNotFound)
+			return
+		}
+		web.OkResponse(c, http.StatusOK, plantilla)
+	}
+}
+
+// POST --> nueva versión de la plantilla de consentimiento de una prestación
+// Consentimiento godoc
+// @Summary crear plantilla de consentimiento
+// @Description Carga una versión nueva del consentimiento de la prestación; las firmas de versiones anteriores dejan de valer
+// @Tags consentimientos
+// @Accept json
+// @Produce json
+// @Param	Plantilla	body	consentimiento.PlantillaConsentimientoRequest	true	"Add plantilla"
+// @Success 201 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 500 {object} web.errorResponse
+// @Router /consentimientos/plantillas [post]
+func (h *consentimientoHandler) CreatePlantilla() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var plantilla consentimiento.PlantillaConsentimientoRequest
+		err := c.ShouldBindJSON(&plantilla)
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		// valido la existencia de datos clave
+		valid, err := validatePlantillaConsentimientoEmptys(plantilla)
+		if !valid {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		p, err := h.s.CreatePlantilla(c, plantilla)
+		if err != nil {
+			if errors.Is(err, prestacion.ErrNotFound) {
+				web.ErrorResponse(c, http.StatusNotFound)
+				return
+			}
+			web.ErrorResponse(c, http.StatusInternalServerError)
+			return
+		}
+		web.OkResponse(c, 201, p)
+	}
+}
+
+// validatePlantillaConsentimientoEmptys valida que los campos claves no esten vacios
+func validatePlantillaConsentimientoEmptys(plantilla consentimiento.PlantillaConsentimientoRequest) (bool, error) {
+	if plantilla.CodigoPrestacion == "" || plantilla.Titulo == "" || plantilla.Texto == "" {
+		return false, errors.New("No se permiten los campos codigo_prestacion, titulo y texto vacíos")
+	}
+	if plantilla.VigenciaDias < 0 {
+		return false, errors.New("La vigencia no puede ser negativa")
+	}
+	return true, nil
+}
+
+// GET --> consentimientos firmados por el paciente
+// Consentimiento godoc
+// @Summary get consentimientos del paciente
+// @Description Get consentimientos firmados por el paciente con la versión y la fecha de firma
+// @Tags consentimientos
+// @Param id path int true "id del paciente"
+// @Produce json
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 500 {object} web.errorResponse
+// @Router /pacientes/:id/consentimientos [get]
+func (h *consentimientoHandler) GetConsentimientosByPaciente() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		consentimientos, err := h.s.GetConsentimientosByPaciente(c, id)
+		if err != nil {
+			web.ErrorResponse(c, http.StatusInternalServerError)
+			return
+		}
+		web.OkResponse(c, http.StatusOK, consentimientos)
+	}
+}
+
+// POST --> registra la firma de un consentimiento con la imagen de la firma o el escaneo
+// Consentimiento godoc
+// @Summary registrar consentimiento
+// @Description Registra que el paciente firmó la versión activa de la plantilla (campos id_plantilla, firmado_por, id_responsable y archivo). Por un menor firma su responsable.
+// @Tags consentimientos
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "id del paciente"
+// @Param id_plantilla formData int true "id de la plantilla firmada"
+// @Param firmado_por formData string false "quién firmó, por defecto el paciente o el responsable"
+// @Param id_responsable formData int false "responsable que firma, obligatorio si el paciente es menor"
+// @Param archivo formData file true "imagen de la firma o escaneo"
+// @Success 201 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 409 {object} web.errorResponse
+// @Failure 413 {object} web.errorResponse
+// @Failure 415 {object} web.errorResponse
+// @Failure 500 {object} web.errorResponse
+// @Router /pacientes/:id/consentimientos [post]
+func (h *consentimientoHandler) RegistrarConsentimiento() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		archivo, contenido, status := leerArchivo(c, h.tamanioMaximo)
+		if status != 0 {
+			web.ErrorResponse(c, status)
+			return
+		}
+		defer contenido.Close()
+
+		idPlantilla, err := strconv.Atoi(c.PostForm("id_plantilla"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		// el responsable es opcional, pero si viene tiene que ser un número
+		var idResponsable int
+		if valor := c.PostForm("id_responsable"); valor != "" {
+			idResponsable, err = strconv.Atoi(valor)
+			if err != nil {
+				web.ErrorResponse(c, http.StatusBadRequest)
+				return
+			}
+		}
+
+		request := consentimiento.ConsentimientoRequest{
+			IdPlantilla:   idPlantilla,
+			FirmadoPor:    c.PostForm("firmado_por"),
+			IdResponsable: idResponsable,
+			Archivo: adjunto.AdjuntoRequest{
+				Nombre:    archivo.Filename,
+				Tamanio:   archivo.Size,
+				Contenido: contenido,
+			},
+		}
+		cons, err := h.s.RegistrarConsentimiento(c, request, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, consentimiento.ErrPlantillaNotFound):
+				web.ErrorResponse(c, http.StatusNotFound)
+			case errors.Is(err, consentimiento.ErrPlantillaInactiva), errors.Is(err, consentimiento.ErrFirmaResponsable):
+				web.ErrorResponse(c, http.StatusConflict)
+			default:
+				responderErrorAdjunto(c, err)
+			}
+			return
+		}
+		web.OkResponse(c, http.StatusCreated, cons)
+	}
+}
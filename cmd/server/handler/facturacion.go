@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"finalgo/internal/facturacion"
+	"finalgo/internal/paciente"
+	"finalgo/pkg/web"
+
+	"github.com/gin-gonic/gin"
+)
+
+// creo la estructura del controlador, inyectando el service
+type facturacionHandler struct {
+	s               facturacion.Service
+	pacienteService paciente.Service
+}
+
+// funcion para instanciar el controlador
+func NewFacturacionHandler(s facturacion.Service, p paciente.Service) *facturacionHandler {
+	return &facturacionHandler{
+		s:               s,
+		pacienteService: p,
+	}
+}
+
+// POST --> genera el cargo de un turno atendido
+// Facturacion godoc
+// @Summary generar cargo
+// @Description Genera el cargo de un turno atendido según el catálogo y la cobertura del paciente, o la penalidad de un turno ausente o cancelado tarde
+// @Tags facturacion
+// @Param id path int true "id del turno"
+// @Produce json
+// @Success 201 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 409 {object} web.errorResponse
+// @Router /turnos/:id/cargo [post]
+func (h *facturacionHandler) GenerarCargo() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		cargo, err := h.s.GenerarCargo(c, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, facturacion.ErrCargoExistente), errors.Is(err, facturacion.ErrTurnoNoAtendido):
+				web.ErrorResponse(c, http.StatusConflict)
+			case errors.Is(err, facturacion.ErrSinPrestacion):
+				web.ErrorResponse(c, http.StatusBadRequest)
+			case errors.Is(err, facturacion.ErrExec):
+				web.ErrorResponse(c, http.StatusInternalServerError)
+			default:
+				web.ErrorResponse(c, http.StatusNotFound)
+			}
+			return
+		}
+		web.OkResponse(c, 201, cargo)
+	}
+}
+
+// GET --> estado de cuenta del paciente
+// Facturacion godoc
+// @Summary get cuenta del paciente
+// @Description Get cargos, pagos y saldo del paciente
+// @Tags facturacion
+// @Param id path int true "id del paciente"
+// @Produce json
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 500 {object} web.errorResponse
+// @Router /pacientes/:id/cuenta [get]
+func (h *facturacionHandler) GetCuenta() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			web.ErrorResponse(ctx, http.StatusBadRequest)
+			return
+		}
+
+		// el paciente tiene que existir
+		if _, err := h.pacienteService.GetPacienteByID(ctx, id); err != nil {
+			web.ErrorResponse(ctx, http.StatusNotFound)
+			return
+		}
+
+		cuenta, err := h.s.GetCuenta(ctx, id)
+		if err != nil {
+			web.ErrorResponse(ctx, http.StatusInternalServerError)
+			return
+		}
+		web.OkResponse(ctx, http.StatusOK, cuenta)
+	}
+}
+
+// POST --> registra un pago total o parcial del paciente
+// Facturacion godoc
+// @Summary registrar pago
+// @Description Registra un pago en efectivo, tarjeta o transferencia en la cuenta del paciente
+// @Tags facturacion
+// @Accept json
+// @Produce json
+// @Param id path int true "id del paciente"
+// @Param	Pago	body	facturacion.PagoRequest	true	"Add pago"
+// @Success 201 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 500 {object} web.errorResponse
+// @Router /pacientes/:id/cuenta/pagos [post]
+func (h *facturacionHandler) RegistrarPago() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		var pago facturacion.PagoRequest
+		err = c.ShouldBindJSON(&pago)
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		if _, err := h.pacienteService.GetPacienteByID(c, id); err != nil {
+			web.ErrorResponse(c, http.StatusNotFound)
+			return
+		}
+
+		p, err := h.s.RegistrarPago(c, pago, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, facturacion.ErrNotFound):
+				web.ErrorResponse(c, http.StatusNotFound)
+			case errors.Is(err, facturacion.ErrExec):
+				web.ErrorResponse(c, http.StatusInternalServerError)
+			default:
+				web.ErrorResponse(c, http.StatusBadRequest)
+			}
+			return
+		}
+		web.OkResponse(c, 201, p)
+	}
+}
@@ -0,0 +1,388 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"finalgo/internal/liquidacion"
+	"finalgo/pkg/web"
+
+	"github.com/gin-gonic/gin"
+)
+
+// creo la estructura del controlador, inyectando el service
+type liquidacionHandler struct {
+	s liquidacion.Service
+}
+
+// funcion para instanciar el controlador
+func NewLiquidacionHandler(s liquidacion.Service) *liquidacionHandler {
+	return &liquidacionHandler{
+		s: s,
+	}
+}
+
+// POST --> genera el lote de liquidación de una obra social para un período
+// Liquidacion godoc
+// @Summary generar lote
+// @Description Agrupa las prestaciones cubiertas del período en un lote a presentar a la obra social
+// @Tags liquidacion
+// @Accept json
+// @Produce json
+// @Param	Lote	body	liquidacion.LoteRequest	true	"Obra social y período (YYYY-MM)"
+// @Success 201 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 409 {object} web.errorResponse
+// @Failure 500 {object} web.errorResponse
+// @Router /liquidaciones [post]
+func (h *liquidacionHandler) GenerarLote() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var lote liquidacion.LoteRequest
+
+		err := c.ShouldBindJSON(&lote)
+		if err != nil || lote.IdObraSocial < 1 {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		l, err := h.s.GenerarLote(c, lote)
+		if err != nil {
+			switch {
+			case errors.Is(err, liquidacion.ErrPeriodo):
+				web.ErrorResponse(c, http.StatusBadRequest)
+			case errors.Is(err, liquidacion.ErrCargoReclamado):
+				web.ErrorResponse(c, http.StatusConflict)
+			case errors.Is(err, liquidacion.ErrExec):
+				web.ErrorResponse(c, http.StatusInternalServerError)
+			default:
+				web.ErrorResponse(c, http.StatusNotFound)
+			}
+			return
+		}
+		web.ETag(c, l.Version)
+		web.OkResponse(c, 201, l)
+	}
+}
+
+// GET --> lista los lotes, opcionalmente filtrados por obra social y período
+// Liquidacion godoc
+// @Summary get lotes
+// @Description Get lotes de liquidación
+// @Tags liquidacion
+// @Param id_obra_social query int false "id de la obra social"
+// @Param periodo query string false "período YYYY-MM"
+// @Produce json
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 500 {object} web.errorResponse
+// @Router /liquidaciones [get]
+func (h *liquidacionHandler) GetLotes() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		idObraSocial := 0
+		if obraSocialQuery := ctx.Query("id_obra_social"); obraSocialQuery != "" {
+			id, err := strconv.Atoi(obraSocialQuery)
+			if err != nil {
+				web.ErrorResponse(ctx, http.StatusBadRequest)
+				return
+			}
+			idObraSocial = id
+		}
+
+		lotes, err := h.s.GetLotes(ctx, idObraSocial, ctx.Query("periodo"))
+		if err != nil {
+			web.ErrorResponse(ctx, http.StatusInternalServerError)
+			return
+		}
+		web.OkResponse(ctx, http.StatusOK, lotes)
+	}
+}
+
+// GET --> trae un lote con sus ítems
+// Liquidacion godoc
+// @Summary get lote
+// @Description Get lote by id. La versión del lote vuelve en el encabezado ETag.
+// @Tags liquidacion
+// @Param id path int true "id del lote"
+// @Produce json
+// @Success 200 {object} web.response
+// @Header 200 {string} ETag "versión del lote"
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Router /liquidaciones/:id [get]
+func (h *liquidacionHandler) GetLoteByID() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			web.ErrorResponse(ctx, http.StatusBadRequest)
+			return
+		}
+
+		lote, err := h.s.GetLoteByID(ctx, id)
+		if err != nil {
+			web.ErrorResponse(ctx, http.StatusNotFound)
+			return
+		}
+		web.ETag(ctx, lote.Version)
+		web.OkResponse(ctx, http.StatusOK, lote)
+	}
+}
+
+// POST --> marca el lote como enviado a la obra social
+// Liquidacion godoc
+// @Summary enviar lote
+// @Description Marca el lote como presentado a la obra social
+// @Tags liquidacion
+// @Param id path int true "id del lote"
+// @Param	If-Match	header	string	true	"ETag del lote leído"
+// @Produce json
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 409 {object} web.errorResponse
+// @Failure 412 {object} web.errorResponse
+// @Failure 428 {object} web.errorResponse
+// @Router /liquidaciones/:id/enviar [post]
+func (h *liquidacionHandler) MarcarEnviado() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		version, ok := web.IfMatch(c)
+		if !ok {
+			return
+		}
+		lote, err := h.s.MarcarEnviado(c, id, version)
+		if err != nil {
+			switch {
+			case errors.Is(err, liquidacion.ErrEstado):
+				web.ErrorResponse(c, http.StatusConflict)
+			case errors.Is(err, liquidacion.ErrVersion):
+				web.ErrorResponse(c, http.StatusPreconditionFailed)
+			case errors.Is(err, liquidacion.ErrNotFound):
+				web.ErrorResponse(c, http.StatusNotFound)
+			default:
+				web.ErrorResponse(c, http.StatusInternalServerError)
+			}
+			return
+		}
+		web.ETag(c, lote.Version)
+		web.OkResponse(c, http.StatusOK, lote)
+	}
+}
+
+// GET --> descarga el archivo del lote en el layout de la obra social
+// Liquidacion godoc
+// @Summary exportar lote
+// @Description Exporta el lote en CSV o ancho fijo según el layout configurado para la obra social. Si un valor no entra en el ancho de su campo no se genera el archivo.
+// @Tags liquidacion
+// @Param id path int true "id del lote"
+// @Produce plain
+// @Success 200 {file} file
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 500 {object} web.errorResponse
+// @Router /liquidaciones/:id/exportar [get]
+func (h *liquidacionHandler) Exportar() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			web.ErrorResponse(ctx, http.StatusBadRequest)
+			return
+		}
+
+		archivo, layout, err := h.s.Exportar(ctx, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, liquidacion.ErrLayoutInvalido), errors.Is(err, liquidacion.ErrDesborde):
+				web.ErrorResponse(ctx, http.StatusBadRequest)
+			case errors.Is(err, liquidacion.ErrExec):
+				web.ErrorResponse(ctx, http.StatusInternalServerError)
+			default:
+				web.ErrorResponse(ctx, http.StatusNotFound)
+			}
+			return
+		}
+
+		contentType, extension := "text/csv; charset=utf-8", "csv"
+		if layout.Formato == liquidacion.FormatoFijo {
+			contentType, extension = "text/plain; charset=utf-8", "txt"
+		}
+		ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=liquidacion-%d.%s", id, extension))
+		ctx.Data(http.StatusOK, contentType, archivo)
+	}
+}
+
+// POST --> registra el pago de la obra social con el detalle de ítems pagados y rechazados
+// Liquidacion godoc
+// @Summary registrar pago de lote
+// @Description Registra lo cobrado y lo rechazado por ítem y devuelve la conciliación del lote
+// @Tags liquidacion
+// @Accept json
+// @Produce json
+// @Param id path int true "id del lote"
+// @Param	Pago	body	liquidacion.PagoLoteRequest	true	"Pago recibido"
+// @Param	If-Match	header	string	true	"ETag del lote leído"
+// @Success 201 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 409 {object} web.errorResponse
+// @Failure 412 {object} web.errorResponse
+// @Failure 428 {object} web.errorResponse
+// @Router /liquidaciones/:id/pagos [post]
+func (h *liquidacionHandler) RegistrarPago() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		var pago liquidacion.PagoLoteRequest
+		err = c.ShouldBindJSON(&pago)
+		if err != nil || pago.Importe < 0 {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		version, ok := web.IfMatch(c)
+		if !ok {
+			return
+		}
+		conciliacion, err := h.s.RegistrarPago(c, pago, id, version)
+		if err != nil {
+			switch {
+			case errors.Is(err, liquidacion.ErrEstado):
+				web.ErrorResponse(c, http.StatusConflict)
+			case errors.Is(err, liquidacion.ErrVersion):
+				web.ErrorResponse(c, http.StatusPreconditionFailed)
+			case errors.Is(err, liquidacion.ErrItemNotFound), errors.Is(err, liquidacion.ErrImporte):
+				web.ErrorResponse(c, http.StatusBadRequest)
+			case errors.Is(err, liquidacion.ErrNotFound):
+				web.ErrorResponse(c, http.StatusNotFound)
+			default:
+				web.ErrorResponse(c, http.StatusInternalServerError)
+			}
+			return
+		}
+		web.ETag(c, conciliacion.Version)
+		web.OkResponse(c, 201, conciliacion)
+	}
+}
+
+// GET --> conciliación del lote contra los pagos recibidos
+// Liquidacion godoc
+// @Summary conciliacion de lote
+// @Description Compara lo facturado, lo liquidado y lo cobrado del lote
+// @Tags liquidacion
+// @Param id path int true "id del lote"
+// @Produce json
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Router /liquidaciones/:id/conciliacion [get]
+func (h *liquidacionHandler) Conciliar() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			web.ErrorResponse(ctx, http.StatusBadRequest)
+			return
+		}
+
+		conciliacion, err := h.s.Conciliar(ctx, id)
+		if err != nil {
+			web.ErrorResponse(ctx, http.StatusNotFound)
+			return
+		}
+		web.OkResponse(ctx, http.StatusOK, conciliacion)
+	}
+}
+
+// GET --> layout de exportación de la obra social
+// Liquidacion godoc
+// @Summary get layout de liquidación
+// @Description Get layout de exportación configurado para la obra social (o el layout por defecto). La versión vuelve en el encabezado ETag ("0" para el layout por defecto).
+// @Tags liquidacion
+// @Param id path int true "id de la obra social"
+// @Produce json
+// @Success 200 {object} web.response
+// @Header 200 {string} ETag "versión del layout"
+// @Failure 400 {object} web.errorResponse
+// @Router /obras-sociales/:id/layout [get]
+func (h *liquidacionHandler) GetLayout() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			web.ErrorResponse(ctx, http.StatusBadRequest)
+			return
+		}
+
+		layout, err := h.s.GetLayout(ctx, id)
+		if err != nil {
+			web.ErrorResponse(ctx, http.StatusInternalServerError)
+			return
+		}
+		web.ETag(ctx, layout.Version)
+		web.OkResponse(ctx, http.StatusOK, layout)
+	}
+}
+
+// PUT --> configura el layout de exportación de la obra social
+// Liquidacion godoc
+// @Summary update layout de liquidación
+// @Description Configura el layout CSV o de ancho fijo con el que la obra social recibe las liquidaciones
+// @Tags liquidacion
+// @Accept json
+// @Produce json
+// @Param id path int true "id de la obra social"
+// @Param	Layout	body	liquidacion.Layout	true	"Layout"
+// @Param	If-Match	header	string	true	"ETag del layout leído"
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 412 {object} web.errorResponse
+// @Failure 428 {object} web.errorResponse
+// @Router /obras-sociales/:id/layout [put]
+func (h *liquidacionHandler) SaveLayout() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		version, ok := web.IfMatch(c)
+		if !ok {
+			return
+		}
+
+		var layout liquidacion.Layout
+		err = c.ShouldBindJSON(&layout)
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		l, err := h.s.SaveLayout(c, layout, id, version)
+		if err != nil {
+			switch {
+			case errors.Is(err, liquidacion.ErrLayoutInvalido):
+				web.ErrorResponse(c, http.StatusBadRequest)
+			case errors.Is(err, liquidacion.ErrVersionLayout):
+				web.ErrorResponse(c, http.StatusPreconditionFailed)
+			case errors.Is(err, liquidacion.ErrExec):
+				web.ErrorResponse(c, http.StatusInternalServerError)
+			default:
+				web.ErrorResponse(c, http.StatusNotFound)
+			}
+			return
+		}
+		web.ETag(c, l.Version)
+		web.OkResponse(c, http.StatusOK, l)
+	}
+}
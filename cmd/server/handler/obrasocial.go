@@ -0,0 +1,531 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"finalgo/internal/obrasocial"
+	"finalgo/internal/paciente"
+	"finalgo/pkg/web"
+
+	"github.com/gin-gonic/gin"
+)
+
+// creo la estructura del controlador, inyectando el service
+type obraSocialHandler struct {
+	s               obrasocial.Service
+	pacienteService paciente.Service
+}
+
+// funcion para instanciar el controlador
+func NewObraSocialHandler(s obrasocial.Service, p paciente.Service) *obraSocialHandler {
+	return &obraSocialHandler{
+		s:               s,
+		pacienteService: p,
+	}
+}
+
+// POST --> agregar obra social
+// ObraSocial godoc
+// @Summary Create Obra Social
+// @Description Create a new obra social o prepaga
+// @Tags obra social
+// @Accept json
+// @Produce json
+// @Param	ObraSocial	body	obrasocial.ObraSocialRequest	true	"Add obra social"
+// @Success 201 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 500 {object} web.errorResponse
+// @Router /obras-sociales [post]
+func (h *obraSocialHandler) CreateObraSocial() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var obraSocial obrasocial.ObraSocialRequest
+
+		err := c.ShouldBindJSON(&obraSocial)
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		// valido la existencia de datos clave
+		valid, err := validateObraSocialEmptys(obraSocial)
+		if !valid {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		o, err := h.s.CreateObraSocial(c, obraSocial)
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+		web.OkResponse(c, 201, o)
+	}
+}
+
+// validateObraSocialEmptys valida que los campos claves no esten vacios y que el tipo sea válido
+func validateObraSocialEmptys(obraSocial obrasocial.ObraSocialRequest) (bool, error) {
+	if obraSocial.Nombre == "" || obraSocial.Sigla == "" {
+		return false, errors.New("No se permiten los campos nombre y sigla vacíos")
+	}
+	if obraSocial.Tipo != obrasocial.TipoObraSocial && obraSocial.Tipo != obrasocial.TipoPrepaga {
+		return false, errors.New("El tipo debe ser obra_social o prepaga")
+	}
+	return true, nil
+}
+
+// GET --> traer todas las obras sociales
+// ObraSocial godoc
+// @Summary get obras sociales
+// @Description Get all obras sociales
+// @Tags obra social
+// @Produce json
+// @Success 200 {object} web.response
+// @Failure 500 {object} web.errorResponse
+// @Router /obras-sociales [get]
+func (h *obraSocialHandler) GetAll() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		obrasSociales, err := h.s.GetAll(ctx)
+		if err != nil {
+			web.ErrorResponse(ctx, http.StatusInternalServerError)
+			return
+		}
+		web.OkResponse(ctx, http.StatusOK, obrasSociales)
+	}
+}
+
+// GET --> traer obra social por id
+// ObraSocial godoc
+// @Summary get obra social
+// @Description Get obra social by id. La versión de la obra social vuelve en el encabezado ETag.
+// @Tags obra social
+// @Param id path int true "id de la obra social"
+// @Produce json
+// @Success 200 {object} web.response
+// @Header 200 {string} ETag "versión de la obra social"
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Router /obras-sociales/:id [get]
+func (h *obraSocialHandler) GetObraSocialByID() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			web.ErrorResponse(ctx, http.StatusBadRequest)
+			return
+		}
+
+		obraSocial, err := h.s.GetObraSocialByID(ctx, id)
+		if err != nil {
+			web.ErrorResponse(ctx, http.StatusNotFound)
+			return
+		}
+		web.ETag(ctx, obraSocial.Version)
+		web.OkResponse(ctx, http.StatusOK, obraSocial)
+	}
+}
+
+// PUT --> actualiza completa una obra social
+// ObraSocial godoc
+// @Summary update obra social
+// @Description Update obra social by id
+// @Tags obra social
+// @Accept json
+// @Produce json
+// @Param	If-Match	header	string	true	"ETag de la obra social leída"
+// @Param	ObraSocial	body	obrasocial.ObraSocialRequest	true	"Update obra social"
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 412 {object} web.errorResponse
+// @Failure 428 {object} web.errorResponse
+// @Failure 500 {object} web.errorResponse
+// @Router /obras-sociales/:id [put]
+func (h *obraSocialHandler) UpdateObraSocial() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// valido id
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		// la versión que leyó el cliente
+		version, ok := web.IfMatch(c)
+		if !ok {
+			return
+		}
+
+		// verifico el json a enviar
+		var obraSocial obrasocial.ObraSocialRequest
+		err = c.ShouldBindJSON(&obraSocial)
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		valid, err := validateObraSocialEmptys(obraSocial)
+		if !valid {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		o, err := h.s.UpdateObraSocial(c, obraSocial, id, version)
+		if err != nil {
+			web.ErrorResponse(c, statusErrorObraSocial(err, http.StatusInternalServerError))
+			return
+		}
+		web.ETag(c, o.Version)
+
+		web.OkResponse(c, http.StatusOK, o)
+	}
+}
+
+// DELETE --> elimina una obra social
+// ObraSocial godoc
+// @Summary delete obra social
+// @Description Delete obra social by id
+// @Tags obra social
+// @Param id path int true "id de la obra social"
+// @Param If-Match header string true "ETag de la obra social leída"
+// @Produce json
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 412 {object} web.errorResponse
+// @Failure 428 {object} web.errorResponse
+// @Router /obras-sociales/:id [delete]
+func (h *obraSocialHandler) DeleteObraSocial() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		version, ok := web.IfMatch(c)
+		if !ok {
+			return
+		}
+
+		err = h.s.DeleteObraSocial(c, id, version)
+		if err != nil {
+			web.ErrorResponse(c, statusErrorObraSocial(err, http.StatusNotFound))
+			return
+		}
+		respuesta := "Obra social de ID " + c.Param("id") + " eliminada"
+		web.OkResponse(c, http.StatusOK, respuesta)
+	}
+}
+
+// statusErrorObraSocial devuelve 404 si la obra social no existe, 412 si cambió desde que el cliente la leyó, 500 si
+// falló la base y el código por defecto de la operación para el resto
+func statusErrorObraSocial(err error, porDefecto int) int {
+	switch {
+	case errors.Is(err, obrasocial.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, obrasocial.ErrVersion):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, obrasocial.ErrExec):
+		return http.StatusInternalServerError
+	default:
+		return porDefecto
+	}
+}
+
+// GET --> traer las reglas de cobertura de una obra social
+// ObraSocial godoc
+// @Summary get reglas de cobertura
+// @Description Get reglas de cobertura by obra social
+// @Tags obra social
+// @Param id path int true "id de la obra social"
+// @Produce json
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Router /obras-sociales/:id/reglas [get]
+func (h *obraSocialHandler) GetReglas() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			web.ErrorResponse(ctx, http.StatusBadRequest)
+			return
+		}
+
+		reglas, err := h.s.GetReglasByObraSocial(ctx, id)
+		if err != nil {
+			web.ErrorResponse(ctx, http.StatusNotFound)
+			return
+		}
+		web.OkResponse(ctx, http.StatusOK, reglas)
+	}
+}
+
+// POST --> agregar regla de cobertura a una obra social
+// ObraSocial godoc
+// @Summary Create regla de cobertura
+// @Description Create a new regla de cobertura por prestación
+// @Tags obra social
+// @Accept json
+// @Produce json
+// @Param id path int true "id de la obra social"
+// @Param	Regla	body	obrasocial.ReglaCoberturaRequest	true	"Add regla"
+// @Success 201 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Router /obras-sociales/:id/reglas [post]
+func (h *obraSocialHandler) CreateRegla() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		var regla obrasocial.ReglaCoberturaRequest
+		err = c.ShouldBindJSON(&regla)
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		// el porcentaje tiene que estar entre 0 y 100 y el copago no puede ser negativo
+		if regla.CodigoPrestacion == "" || regla.PorcentajeCubierto < 0 || regla.PorcentajeCubierto > 100 || regla.Copago < 0 {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		r, err := h.s.CreateRegla(c, regla, id)
+		if err != nil {
+			web.ErrorResponse(c, http.StatusNotFound)
+			return
+		}
+		web.OkResponse(c, 201, r)
+	}
+}
+
+// DELETE --> elimina una regla de cobertura
+// ObraSocial godoc
+// @Summary delete regla de cobertura
+// @Description Delete regla de cobertura by id
+// @Tags obra social
+// @Param id path int true "id de la obra social"
+// @Param idRegla path int true "id de la regla"
+// @Param If-Match header string true "versión de la regla leída"
+// @Produce json
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 412 {object} web.errorResponse
+// @Failure 428 {object} web.errorResponse
+// @Router /obras-sociales/:id/reglas/:idRegla [delete]
+func (h *obraSocialHandler) DeleteRegla() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+		idRegla, err := strconv.Atoi(c.Param("idRegla"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		version, ok := web.IfMatch(c)
+		if !ok {
+			return
+		}
+
+		err = h.s.DeleteRegla(c, id, idRegla, version)
+		if err != nil {
+			if errors.Is(err, obrasocial.ErrVersionRegla) {
+				web.ErrorResponse(c, http.StatusPreconditionFailed)
+				return
+			}
+			web.ErrorResponse(c, http.StatusNotFound)
+			return
+		}
+		respuesta := "Regla de ID " + c.Param("idRegla") + " eliminada"
+		web.OkResponse(c, http.StatusOK, respuesta)
+	}
+}
+
+// GET --> traer las coberturas de un paciente
+// ObraSocial godoc
+// @Summary get coberturas del paciente
+// @Description Get coberturas by paciente
+// @Tags obra social
+// @Param id path int true "id del paciente"
+// @Produce json
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Router /pacientes/:id/coberturas [get]
+func (h *obraSocialHandler) GetCoberturas() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			web.ErrorResponse(ctx, http.StatusBadRequest)
+			return
+		}
+
+		coberturas, err := h.s.GetCoberturasByPaciente(ctx, id)
+		if err != nil {
+			web.ErrorResponse(ctx, http.StatusNotFound)
+			return
+		}
+		web.OkResponse(ctx, http.StatusOK, coberturas)
+	}
+}
+
+// POST --> afiliar un paciente a una obra social
+// ObraSocial godoc
+// @Summary Create cobertura
+// @Description Create a new cobertura for paciente
+// @Tags obra social
+// @Accept json
+// @Produce json
+// @Param id path int true "id del paciente"
+// @Param	Cobertura	body	obrasocial.CoberturaRequest	true	"Add cobertura"
+// @Success 201 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 409 {object} web.errorResponse
+// @Router /pacientes/:id/coberturas [post]
+func (h *obraSocialHandler) CreateCobertura() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		var cobertura obrasocial.CoberturaRequest
+		err = c.ShouldBindJSON(&cobertura)
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		// valido la existencia de datos clave y que la vigencia sea coherente
+		if cobertura.IdObraSocial < 1 || cobertura.NumeroAfiliado == "" || cobertura.VigenciaDesde.IsZero() {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+		if !cobertura.VigenciaHasta.IsZero() && cobertura.VigenciaHasta.Before(cobertura.VigenciaDesde) {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		// el paciente tiene que existir
+		if _, err := h.pacienteService.GetPacienteByID(c, id); err != nil {
+			web.ErrorResponse(c, http.StatusNotFound)
+			return
+		}
+
+		co, err := h.s.CreateCobertura(c, cobertura, id)
+		if err != nil {
+			if errors.Is(err, obrasocial.ErrSuperpuesta) {
+				web.ErrorResponse(c, http.StatusConflict)
+				return
+			}
+			web.ErrorResponse(c, http.StatusNotFound)
+			return
+		}
+		web.OkResponse(c, 201, co)
+	}
+}
+
+// DELETE --> elimina una cobertura del paciente
+// ObraSocial godoc
+// @Summary delete cobertura
+// @Description Delete cobertura del paciente
+// @Tags obra social
+// @Param id path int true "id del paciente"
+// @Param idCobertura path int true "id de la cobertura"
+// @Param If-Match header string true "versión de la cobertura leída"
+// @Produce json
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 412 {object} web.errorResponse
+// @Failure 428 {object} web.errorResponse
+// @Router /pacientes/:id/coberturas/:idCobertura [delete]
+func (h *obraSocialHandler) DeleteCobertura() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+		idCobertura, err := strconv.Atoi(c.Param("idCobertura"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		version, ok := web.IfMatch(c)
+		if !ok {
+			return
+		}
+
+		err = h.s.DeleteCobertura(c, id, idCobertura, version)
+		if err != nil {
+			if errors.Is(err, obrasocial.ErrVersionCobertura) {
+				web.ErrorResponse(c, http.StatusPreconditionFailed)
+				return
+			}
+			web.ErrorResponse(c, http.StatusNotFound)
+			return
+		}
+		respuesta := "Cobertura de ID " + c.Param("idCobertura") + " eliminada"
+		web.OkResponse(c, http.StatusOK, respuesta)
+	}
+}
+
+// GET --> consulta qué cubre la obra social del paciente para una prestación
+// ObraSocial godoc
+// @Summary get cobertura por prestación
+// @Description Get cobertura vigente del paciente para una prestación
+// @Tags obra social
+// @Param id path int true "id del paciente"
+// @Param prestacion query string true "código de prestación"
+// @Param fecha query string false "fecha de la prestación (YYYY-MM-DD), por defecto hoy"
+// @Produce json
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Router /pacientes/:id/cobertura [get]
+func (h *obraSocialHandler) GetCoberturaPrestacion() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			web.ErrorResponse(ctx, http.StatusBadRequest)
+			return
+		}
+
+		codigo := ctx.Query("prestacion")
+		if codigo == "" {
+			web.ErrorResponse(ctx, http.StatusBadRequest)
+			return
+		}
+
+		fecha := time.Now()
+		if fechaQuery := ctx.Query("fecha"); fechaQuery != "" {
+			fecha, err = time.Parse("2006-01-02", fechaQuery)
+			if err != nil {
+				web.ErrorResponse(ctx, http.StatusBadRequest)
+				return
+			}
+		}
+
+		cobertura, err := h.s.GetCoberturaPrestacion(ctx, id, codigo, fecha)
+		if err != nil {
+			web.ErrorResponse(ctx, statusErrorObraSocial(err, http.StatusNotFound))
+			return
+		}
+		web.OkResponse(ctx, http.StatusOK, cobertura)
+	}
+}
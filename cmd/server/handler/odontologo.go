@@ -2,8 +2,8 @@ package handler
 
 import (
 	"errors"
+	"finalgo/internal/baja"
 	"finalgo/internal/odontologo"
-	"finalgo/internal/turno"
 	"finalgo/pkg/web"
 	"net/http"
 	"strconv"
@@ -13,15 +13,15 @@ import (
 
 // creo la estructura del controlador, inyectando el service
 type odontologoHandler struct {
-	s            odontologo.Service
-	turnoService turno.Service
+	s           odontologo.Service
+	bajaService baja.Service
 }
 
 // funcion para instanciar el controlador
-func NewodOntologoHandler(s odontologo.Service, t turno.Service) *odontologoHandler {
+func NewodOntologoHandler(s odontologo.Service, b baja.Service) *odontologoHandler {
 	return &odontologoHandler{
 		s: s,
-		turnoService: t,
+		bajaService: b,
 	}
 }
 
@@ -63,6 +63,20 @@ func (h *odontologoHandler) CreateOdontologo() gin.HandlerFunc {
 	}
 }
 
+// statusErrorOdontologo traduce los errores del servicio al modificar un odontologo
+func statusErrorOdontologo(err error) int {
+	switch {
+	case errors.Is(err, odontologo.ErrEspecialidad):
+		return http.StatusBadRequest
+	case errors.Is(err, odontologo.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, odontologo.ErrVersion):
+		return http.StatusPreconditionFailed
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 // validateEmptys valida que los campos claves no esten vacios
 func validateEmptys2(odontologo odontologo.OdontologoRequest) (bool, error) {
 	if odontologo.Apellido == "" || odontologo.Nombre == "" || odontologo.Matricula == "" {
@@ -74,12 +88,14 @@ func validateEmptys2(odontologo odontologo.OdontologoRequest) (bool, error) {
 // GET --> traer odontologo por id
 // Odontologo godoc
 // @Summary get odontologo
-// @Description Get odontologo by id
+// @Description Get odontologo by id. La versión del odontologo vuelve en el encabezado ETag.
 // @Tags odontologo
 // @Param id path int true "id del odontologo"
+// @Param especialidad query string false "sin id, filtra por código de especialidad"
 // @Accept json
 // @Produce json
 // @Success 200 {object} web.response
+// @Header 200 {string} ETag "versión del odontologo"
 // @Failure 400 {object} web.errorResponse
 // @Failure 500 {object} web.errorResponse
 // @Router /odontologos/:id [get]
@@ -106,12 +122,19 @@ func (h *odontologoHandler) GetOdontologoByID() gin.HandlerFunc {
 				web.ErrorResponse(ctx, http.StatusNotFound)
 				return
 			}
+			web.ETag(ctx, odontologo.Version)
 			web.OkResponse(ctx, http.StatusOK, odontologo)
 			return
 		}
 
-		// pero si no me pasaron id, devuelvo igual todos los odontologos
-		odontologos, err := h.s.GetAll(ctx)
+		// pero si no me pasaron id, devuelvo todos los odontologos o sólo los de la especialidad pedida
+		var odontologos []odontologo.Odontologo
+		var err error
+		if especialidad := ctx.Query("especialidad"); especialidad != "" {
+			odontologos, err = h.s.GetOdontologosByEspecialidad(ctx, especialidad)
+		} else {
+			odontologos, err = h.s.GetAll(ctx)
+		}
 		if err != nil {
 			web.ErrorResponse(ctx, http.StatusInternalServerError)
 			return
@@ -127,9 +150,12 @@ func (h *odontologoHandler) GetOdontologoByID() gin.HandlerFunc {
 // @Tags odontologo
 // @Accept json
 // @Produce json
+// @Param	If-Match	header	string	true	"ETag del odontologo leído"
 // @Param	Odontologo	body	odontologo.OdontologoRequest	true	"Update odontologo"
 // @Success 200 {object} web.response
 // @Failure 400 {object} web.errorResponse
+// @Failure 412 {object} web.errorResponse
+// @Failure 428 {object} web.errorResponse
 // @Failure 500 {object} web.errorResponse
 // @Router /odontologos/:id [put]
 func (h *odontologoHandler) UpdateOdontologo() gin.HandlerFunc {
@@ -142,6 +168,12 @@ func (h *odontologoHandler) UpdateOdontologo() gin.HandlerFunc {
 			return
 		}
 
+		// la versión que leyó el cliente
+		version, ok := web.IfMatch(c)
+		if !ok {
+			return
+		}
+
 		// verifico el json a enviar
 		var odontologo odontologo.OdontologoRequest
 		err = c.Bind(&odontologo)
@@ -158,12 +190,13 @@ func (h *odontologoHandler) UpdateOdontologo() gin.HandlerFunc {
 		}
 
 		// llamo al servicio para actualizar al odontologo
-		o, err := h.s.UpdateOdontologo(c, odontologo, id)
+		o, err := h.s.UpdateOdontologo(c, odontologo, id, version)
 		if err != nil {
-			web.ErrorResponse(c, http.StatusInternalServerError)
+			web.ErrorResponse(c, statusErrorOdontologo(err))
 			return
 		}
 
+		web.ETag(c, o.Version)
 		web.OkResponse(c, http.StatusOK, o)
 	}
 }
@@ -175,9 +208,12 @@ func (h *odontologoHandler) UpdateOdontologo() gin.HandlerFunc {
 // @Tags odontologo
 // @Accept json
 // @Produce json
+// @Param	If-Match	header	string	true	"ETag del odontologo leído"
 // @Param	Odontologo	body	odontologo.OdontologoRequest	true	"Update odontologo for field"
 // @Success 200 {object} web.response
 // @Failure 400 {object} web.errorResponse
+// @Failure 412 {object} web.errorResponse
+// @Failure 428 {object} web.errorResponse
 // @Failure 500 {object} web.errorResponse
 // @Router /odontologos/patch/:id [patch]
 func (h *odontologoHandler) UpdateOdontologoForField() gin.HandlerFunc {
@@ -189,6 +225,10 @@ func (h *odontologoHandler) UpdateOdontologoForField() gin.HandlerFunc {
 			web.ErrorResponse(c, http.StatusBadRequest)
 			return
 		}
+		version, ok := web.IfMatch(c)
+		if !ok {
+			return
+		}
 
 		// le paso los query params
 		apellidoQuery := c.Query("apellido")
@@ -221,12 +261,13 @@ func (h *odontologoHandler) UpdateOdontologoForField() gin.HandlerFunc {
 		}
 
 		// llamo al metodo de actualizar odontologo, usando el odontologoRequest
-		o, err := h.s.UpdateOdontologo(c, odontologoRequest, id)
+		o, err := h.s.UpdateOdontologo(c, odontologoRequest, id, version)
 		if err != nil {
-			web.ErrorResponse(c, http.StatusInternalServerError)
+			web.ErrorResponse(c, statusErrorOdontologo(err))
 			return
 		}
 
+		web.ETag(c, o.Version)
 		web.OkResponse(c, http.StatusOK, o)
 	}
 }
@@ -234,13 +275,18 @@ func (h *odontologoHandler) UpdateOdontologoForField() gin.HandlerFunc {
 // DELETE --> elimina un odontologo
 // Odontologo godoc
 // @Summary delete odontologo
-// @Description Delete odontologo by id
+// @Description Baja lógica del odontologo by id, junto con sus turnos. Con BAJA_TURNOS_FUTUROS=bloquear no se da de baja si tiene turnos pendientes a futuro.
 // @Tags odontologo
 // @Param id path int true "id del odontologo"
+// @Param If-Match header string true "ETag del odontologo leído"
 // @Accept json
 // @Produce json
 // @Success 200 {object} web.response
 // @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 409 {object} web.errorResponse
+// @Failure 412 {object} web.errorResponse
+// @Failure 428 {object} web.errorResponse
 // @Failure 500 {object} web.errorResponse
 // @Router /odontologos/:id [delete]
 func (h *odontologoHandler) DeleteOdontologo() gin.HandlerFunc {
@@ -253,25 +299,87 @@ func (h *odontologoHandler) DeleteOdontologo() gin.HandlerFunc {
 			return
 		}
 
-		// busco los turnos asociados y se los elimino tambien
-		turnos, errorT := h.turnoService.GetTurnoByOdontologo(c, id)
-		if errorT == nil && turnos != nil {
-			for _, turno := range turnos {
-				err := h.turnoService.DeleteTurno(c, turno.ID)
-				if err != nil {
-					web.ErrorResponse(c, http.StatusBadRequest)
-				}
-			}
+		version, ok := web.IfMatch(c)
+		if !ok {
+			return
 		}
-		// luego del borrado de todos los turnos, avanza con el borrado del odontologo
-
-		// si falla el delete, es porque el ID era invalido
-		err = h.s.DeleteOdontologo(c, id)
+		// la baja da de baja también los turnos en una sola transacción
+		err = h.bajaService.DeleteOdontologo(c, id, version)
 		if err != nil {
-			web.ErrorResponse(c, http.StatusNotFound)
+			web.ErrorResponse(c, statusErrorBaja(err))
 			return
 		}
 		respuesta := "Odontologo de ID " + c.Param("id") + " eliminado"
 		web.OkResponse(c, http.StatusOK, respuesta)
 	}
 }
+
+// POST --> restaura un odontologo dado de baja
+// Odontologo godoc
+// @Summary restaurar odontologo
+// @Description Vuelve a dar de alta al odontólogo y a los turnos que se dieron de baja junto con él
+// @Tags odontologo
+// @Param id path int true "id del odontologo"
+// @Produce json
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 500 {object} web.errorResponse
+// @Router /odontologos/:id/restaurar [post]
+func (h *odontologoHandler) RestaurarOdontologo() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// valido id
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		o, err := h.bajaService.RestaurarOdontologo(c, id)
+		if err != nil {
+			web.ErrorResponse(c, statusErrorBaja(err))
+			return
+		}
+		web.OkResponse(c, http.StatusOK, o)
+	}
+}
+
+// GET --> odontologos dados de baja
+// Odontologo godoc
+// @Summary get odontologos eliminados
+// @Description Get los odontólogos dados de baja, con la fecha y el usuario de la baja
+// @Tags odontologo
+// @Produce json
+// @Success 200 {object} web.response
+// @Failure 500 {object} web.errorResponse
+// @Router /eliminados/odontologos [get]
+func (h *odontologoHandler) GetEliminados() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		odontologos, err := h.s.GetEliminados(c)
+		if err != nil {
+			web.ErrorResponse(c, http.StatusInternalServerError)
+			return
+		}
+		web.OkResponse(c, http.StatusOK, odontologos)
+	}
+}
+
+// GET --> catálogo de especialidades
+// Odontologo godoc
+// @Summary get especialidades
+// @Description Get el catálogo de especialidades que se pueden asignar a los odontólogos
+// @Tags odontologo
+// @Produce json
+// @Success 200 {object} web.response
+// @Failure 500 {object} web.errorResponse
+// @Router /especialidades [get]
+func (h *odontologoHandler) GetEspecialidades() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		especialidades, err := h.s.GetEspecialidades(c)
+		if err != nil {
+			web.ErrorResponse(c, http.StatusInternalServerError)
+			return
+		}
+		web.OkResponse(c, http.StatusOK, especialidades)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"strconv"
 	"time"
 
+	"finalgo/internal/baja"
+	"finalgo/internal/odontologo"
 	"finalgo/internal/paciente"
 	"finalgo/internal/turno"
 	"finalgo/pkg/web"
@@ -15,15 +17,15 @@ import (
 
 // creo la estructura del controlador, inyectando el service
 type pacienteHandler struct {
-	s            paciente.Service
-	turnoService turno.Service
+	s           paciente.Service
+	bajaService baja.Service
 }
 
 // funcion para instanciar el controlador
-func NewPacienteHandler(s paciente.Service, t turno.Service) *pacienteHandler {
+func NewPacienteHandler(s paciente.Service, b baja.Service) *pacienteHandler {
 	return &pacienteHandler{
 		s: s,
-		turnoService: t,
+		bajaService: b,
 	}
 }
 
@@ -73,15 +75,45 @@ func validateEmptys(paciente paciente.PacienteRequest) (bool, error) {
 	return true, nil
 }
 
+// statusErrorPaciente traduce los errores del servicio al modificar un paciente
+func statusErrorPaciente(err error) int {
+	switch {
+	case errors.Is(err, paciente.ErrContacto), errors.Is(err, paciente.ErrFechaNacimiento):
+		return http.StatusBadRequest
+	case errors.Is(err, paciente.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, paciente.ErrVersion):
+		return http.StatusPreconditionFailed
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// statusErrorBaja traduce los errores de las bajas en cascada de pacientes y odontólogos. Si un turno cambió mientras
+// se daba de baja, la cascada también se rechaza por versión.
+func statusErrorBaja(err error) int {
+	switch {
+	case errors.Is(err, paciente.ErrNotFound), errors.Is(err, odontologo.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, baja.ErrTurnosFuturos):
+		return http.StatusConflict
+	case errors.Is(err, paciente.ErrVersion), errors.Is(err, odontologo.ErrVersion), errors.Is(err, turno.ErrVersion):
+		return http.StatusPreconditionFailed
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 // GET --> traer paciente por id
 // Paciente godoc
 // @Summary get paciente
-// @Description Get paciente by id
+// @Description Get paciente by id. La versión del paciente vuelve en el encabezado ETag.
 // @Tags paciente
 // @Param id path int true "id del paciente"
 // @Accept json
 // @Produce json
 // @Success 200 {object} web.response
+// @Header 200 {string} ETag "versión del paciente"
 // @Failure 400 {object} web.errorResponse
 // @Failure 500 {object} web.errorResponse
 // @Router /pacientes/:id [get]
@@ -108,6 +140,7 @@ func (h *pacienteHandler) GetPacienteByID() gin.HandlerFunc {
 				web.ErrorResponse(ctx, http.StatusNotFound)
 				return
 			}
+			web.ETag(ctx, paciente.Version)
 			web.OkResponse(ctx, http.StatusOK, paciente)
 			return
 		}
@@ -129,9 +162,12 @@ func (h *pacienteHandler) GetPacienteByID() gin.HandlerFunc {
 // @Tags paciente
 // @Accept json
 // @Produce json
+// @Param	If-Match	header	string	true	"ETag del paciente leído"
 // @Param	Paciente	body	paciente.PacienteRequest	true	"Update paciente"
 // @Success 200 {object} web.response
 // @Failure 400 {object} web.errorResponse
+// @Failure 412 {object} web.errorResponse
+// @Failure 428 {object} web.errorResponse
 // @Failure 500 {object} web.errorResponse
 // @Router /pacientes/:id [put]
 func (h *pacienteHandler) UpdatePaciente() gin.HandlerFunc {
@@ -144,6 +180,12 @@ func (h *pacienteHandler) UpdatePaciente() gin.HandlerFunc {
 			return
 		}
 
+		// la versión que leyó el cliente, para no pisar cambios de otro
+		version, ok := web.IfMatch(c)
+		if !ok {
+			return
+		}
+
 		// verifico el json a enviar
 		var paciente paciente.PacienteRequest
 		err = c.ShouldBindJSON(&paciente)
@@ -160,12 +202,13 @@ func (h *pacienteHandler) UpdatePaciente() gin.HandlerFunc {
 		}
 
 		// llamo al servicio para actualizar al paciente
-		p, err := h.s.UpdatePaciente(c, paciente, id)
+		p, err := h.s.UpdatePaciente(c, paciente, id, version)
 		if err != nil {
-			web.ErrorResponse(c, http.StatusInternalServerError)
+			web.ErrorResponse(c, statusErrorPaciente(err))
 			return
 		}
 
+		web.ETag(c, p.Version)
 		web.OkResponse(c, http.StatusOK, p)
 	}
 }
@@ -177,9 +220,12 @@ func (h *pacienteHandler) UpdatePaciente() gin.HandlerFunc {
 // @Tags paciente
 // @Accept json
 // @Produce json
+// @Param	If-Match	header	string	true	"ETag del paciente leído"
 // @Param	Paciente	body	paciente.PacienteRequest	true	"Add paciente for field"
 // @Success 200 {object} web.response
 // @Failure 400 {object} web.errorResponse
+// @Failure 412 {object} web.errorResponse
+// @Failure 428 {object} web.errorResponse
 // @Failure 500 {object} web.errorResponse
 // @Router /pacientes/patch/:id [patch]
 func (h *pacienteHandler) UpdatePacienteForField() gin.HandlerFunc {
@@ -191,6 +237,10 @@ func (h *pacienteHandler) UpdatePacienteForField() gin.HandlerFunc {
 			web.ErrorResponse(c, http.StatusBadRequest)
 			return
 		}
+		version, ok := web.IfMatch(c)
+		if !ok {
+			return
+		}
 
 		// le paso los query params
 		nombreQuery := c.Query("nombre")
@@ -198,6 +248,7 @@ func (h *pacienteHandler) UpdatePacienteForField() gin.HandlerFunc {
 		domicilioQuery := c.Query("domiclio")
 		dniQuery := c.Query("dni")
 		altaQuery := c.Query("fecha_alta")
+		nacimientoQuery := c.Query("fecha_nacimiento")
 
 		// obtengo los datos del paciente original
 		pacienteOriginal, err := h.s.GetPacienteByID(c, id)
@@ -213,6 +264,16 @@ func (h *pacienteHandler) UpdatePacienteForField() gin.HandlerFunc {
 			Domicilio: pacienteOriginal.Domicilio,
 			DNI:       pacienteOriginal.DNI,
 			Alta:      pacienteOriginal.Alta,
+
+			FechaNacimiento: pacienteOriginal.FechaNacimiento,
+
+			// los datos de contacto no se editan por query params, pero hay que conservarlos
+			Telefonos:           pacienteOriginal.Telefonos,
+			Email:               pacienteOriginal.Email,
+			CanalPreferido:      pacienteOriginal.CanalPreferido,
+			AceptaRecordatorios: pacienteOriginal.AceptaRecordatorios,
+			AceptaMarketing:     pacienteOriginal.AceptaMarketing,
+			ContactosEmergencia: pacienteOriginal.ContactosEmergencia,
 		}
 
 		// verifico si los campos tienen datos, los casteo y se los asigno al paciente request
@@ -236,14 +297,24 @@ func (h *pacienteHandler) UpdatePacienteForField() gin.HandlerFunc {
 			}
 			pacienteRequest.Alta = fecha
 		}
+		if nacimientoQuery != "" {
+			fecha, err := time.Parse("2006-01-02", nacimientoQuery)
+			if err != nil {
+				web.ErrorResponse(c, http.StatusBadRequest)
+				return
+			}
+			pacienteRequest.FechaNacimiento = fecha
+		}
 
-		// llamo al metodo de actualizar paciente, usando el pacienteRequest
-		p, err := h.s.UpdatePaciente(c, pacienteRequest, id)
+		// llamo al metodo de actualizar paciente, usando el pacienteRequest; si el original ya no es la versión que
+		// leyó el cliente, el repositorio lo rechaza
+		p, err := h.s.UpdatePaciente(c, pacienteRequest, id, version)
 		if err != nil {
-			web.ErrorResponse(c, http.StatusInternalServerError)
+			web.ErrorResponse(c, statusErrorPaciente(err))
 			return
 		}
 
+		web.ETag(c, p.Version)
 		web.OkResponse(c, http.StatusOK, p)
 	}
 }
@@ -251,13 +322,18 @@ func (h *pacienteHandler) UpdatePacienteForField() gin.HandlerFunc {
 // DELETE --> elimina un paciente
 // Paciente godoc
 // @Summary delete paciente
-// @Description Delete paciente by id
+// @Description Baja lógica del paciente by id, junto con sus turnos. Con BAJA_TURNOS_FUTUROS=bloquear no se da de baja si tiene turnos pendientes a futuro.
 // @Tags paciente
 // @Param id path int true "id del paciente"
+// @Param If-Match header string true "ETag del paciente leído"
 // @Accept json
 // @Produce json
 // @Success 200 {object} web.response
 // @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 409 {object} web.errorResponse
+// @Failure 412 {object} web.errorResponse
+// @Failure 428 {object} web.errorResponse
 // @Failure 500 {object} web.errorResponse
 // @Router /pacientes/:id [delete]
 func (h *pacienteHandler) DeletePaciente() gin.HandlerFunc {
@@ -269,35 +345,68 @@ func (h *pacienteHandler) DeletePaciente() gin.HandlerFunc {
 			web.ErrorResponse(c, http.StatusBadRequest)
 			return
 		}
-		// busco los turnos asociados y se los elimino tambien
-		// primero obtengo el DNI del paciente
-		paciente, err := h.s.GetPacienteByID(c, id)
+		version, ok := web.IfMatch(c)
+		if !ok {
+			return
+		}
+		// la baja da de baja también los turnos en una sola transacción
+		err = h.bajaService.DeletePaciente(c, id, version)
 		if err != nil {
-			web.ErrorResponse(c, http.StatusBadRequest)
+			web.ErrorResponse(c, statusErrorBaja(err))
 			return
 		}
-		dni := paciente.DNI
-		// luego busco todos los turnos que tiene ese paciente
-		turnos, errorT := h.turnoService.GetTurnoByPaciente(c, dni)
-		// como previamente ya validé que sea un paciente existente con ese DNI, la única posibilidad de error en este punto es que el paciente no tenga turnos asociados. Entonces, si no hay error, ejecuto el borrado de turnos, caso contrario, ejecuto directamente el borrado del paciente.
-		if errorT == nil || turnos != nil {
-			for _, turno := range turnos {
-				err := h.turnoService.DeleteTurno(c, turno.ID)
-				if err != nil {
-					web.ErrorResponse(c, http.StatusBadRequest)
-				}
-			}
+		respuesta := "Paciente de ID " + c.Param("id") + " eliminado"
+		web.OkResponse(c, http.StatusOK, respuesta)
+
+	}
+}
+
+// POST --> restaura un paciente dado de baja
+// Paciente godoc
+// @Summary restaurar paciente
+// @Description Vuelve a dar de alta al paciente y a los turnos que se dieron de baja junto con él
+// @Tags paciente
+// @Param id path int true "id del paciente"
+// @Produce json
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 500 {object} web.errorResponse
+// @Router /pacientes/:id/restaurar [post]
+func (h *pacienteHandler) RestaurarPaciente() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// valido id
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
 		}
-		// luego del borrado de todos los turnos, avanza con el borrado del paciente
 
-		// si falla el delete, es porque el ID era invalido
-		err = h.s.DeletePaciente(c, id)
+		p, err := h.bajaService.RestaurarPaciente(c, id)
 		if err != nil {
-			web.ErrorResponse(c, http.StatusNotFound)
+			web.ErrorResponse(c, statusErrorBaja(err))
 			return
 		}
-		respuesta := "Paciente de ID " + c.Param("id") + " eliminado"
-		web.OkResponse(c, http.StatusOK, respuesta)
+		web.OkResponse(c, http.StatusOK, p)
+	}
+}
 
+// GET --> pacientes dados de baja
+// Paciente godoc
+// @Summary get pacientes eliminados
+// @Description Get los pacientes dados de baja, con la fecha y el usuario de la baja
+// @Tags paciente
+// @Produce json
+// @Success 200 {object} web.response
+// @Failure 500 {object} web.errorResponse
+// @Router /eliminados/pacientes [get]
+func (h *pacienteHandler) GetEliminados() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pacientes, err := h.s.GetEliminados(c)
+		if err != nil {
+			web.ErrorResponse(c, http.StatusInternalServerError)
+			return
+		}
+		web.OkResponse(c, http.StatusOK, pacientes)
 	}
 }
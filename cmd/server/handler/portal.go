@@ -0,0 +1,269 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"finalgo/internal/paciente"
+	"finalgo/internal/portal"
+	"finalgo/internal/turno"
+	"finalgo/pkg/middleware"
+	"finalgo/pkg/web"
+
+	"github.com/gin-gonic/gin"
+)
+
+// creo la estructura del controlador del portal de pacientes, inyectando su service
+type portalHandler struct {
+	s portal.Service
+}
+
+// funcion para instanciar el controlador
+func NewPortalHandler(s portal.Service) *portalHandler {
+	return &portalHandler{
+		s: s,
+	}
+}
+
+// POST --> pide el código de acceso al portal
+// Portal godoc
+// @Summary solicitar código
+// @Description Envía un código de un solo uso por el canal preferido del paciente. Responde igual exista o no el DNI.
+// @Tags portal
+// @Accept json
+// @Produce json
+// @Param	Solicitud	body	portal.SolicitudCodigo	true	"DNI del paciente"
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 429 {object} web.errorResponse
+// @Failure 500 {object} web.errorResponse
+// @Router /portal/codigo [post]
+func (h *portalHandler) SolicitarCodigo() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var solicitud portal.SolicitudCodigo
+		err := c.ShouldBindJSON(&solicitud)
+		if err != nil || solicitud.DNI == "" {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		err = h.s.SolicitarCodigo(c, solicitud.DNI, c.ClientIP())
+		if err != nil {
+			web.ErrorResponse(c, statusErrorPortal(err))
+			return
+		}
+		web.OkResponse(c, http.StatusOK, "Si el DNI está registrado, se envió un código de acceso")
+	}
+}
+
+// POST --> ingresa al portal con DNI y código
+// Portal godoc
+// @Summary login portal
+// @Description Valida el código y devuelve el token de sesión del portal (usar como Authorization: Bearer)
+// @Tags portal
+// @Accept json
+// @Produce json
+// @Param	Login	body	portal.LoginRequest	true	"DNI y código"
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 403 {object} web.errorResponse
+// @Failure 429 {object} web.errorResponse
+// @Failure 500 {object} web.errorResponse
+// @Router /portal/login [post]
+func (h *portalHandler) IniciarSesion() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var login portal.LoginRequest
+		err := c.ShouldBindJSON(&login)
+		if err != nil || login.DNI == "" || login.Codigo == "" {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		sesion, err := h.s.IniciarSesion(c, login, c.ClientIP())
+		if err != nil {
+			web.ErrorResponse(c, statusErrorPortal(err))
+			return
+		}
+		web.OkResponse(c, http.StatusOK, sesion)
+	}
+}
+
+// GET --> turnos del paciente de la sesión
+// Portal godoc
+// @Summary mis turnos
+// @Description Get los turnos del paciente autenticado
+// @Tags portal
+// @Produce json
+// @Success 200 {object} web.response
+// @Failure 401 {object} web.errorResponse
+// @Failure 500 {object} web.errorResponse
+// @Router /portal/turnos [get]
+func (h *portalHandler) GetTurnos() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		turnos, err := h.s.GetTurnos(c, c.GetInt(middleware.ClavePaciente))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusInternalServerError)
+			return
+		}
+		web.OkResponse(c, http.StatusOK, turnos)
+	}
+}
+
+// GET --> horarios libres para reservar
+// Portal godoc
+// @Summary disponibilidad
+// @Description Get los próximos horarios libres de un odontólogo o de una especialidad, dentro de la ventana de reserva
+// @Tags portal
+// @Param odontologo query int false "id del odontologo"
+// @Param especialidad query string false "código de especialidad"
+// @Param desde query string false "fecha desde (YYYY-MM-DD)"
+// @Produce json
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Router /portal/disponibilidad [get]
+func (h *portalHandler) GetDisponibilidad() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var idOdontologo int
+		var desde time.Time
+		var err error
+		if valor := c.Query("odontologo"); valor != "" {
+			idOdontologo, err = strconv.Atoi(valor)
+			if err != nil {
+				web.ErrorResponse(c, http.StatusBadRequest)
+				return
+			}
+		}
+		if valor := c.Query("desde"); valor != "" {
+			desde, err = time.ParseInLocation("2006-01-02", valor, time.Local)
+			if err != nil {
+				web.ErrorResponse(c, http.StatusBadRequest)
+				return
+			}
+		}
+
+		bloques, err := h.s.GetDisponibilidad(c, idOdontologo, c.Query("especialidad"), desde)
+		if err != nil {
+			web.ErrorResponse(c, statusErrorPortal(err))
+			return
+		}
+		web.OkResponse(c, http.StatusOK, bloques)
+	}
+}
+
+// POST --> reserva un turno
+// Portal godoc
+// @Summary reservar turno
+// @Description Reserva un horario libre con el odontólogo indicado, o el primero libre de la especialidad
+// @Tags portal
+// @Accept json
+// @Produce json
+// @Param	Reserva	body	portal.ReservaRequest	true	"Reserva"
+// @Success 201 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 409 {object} web.errorResponse
+// @Router /portal/turnos [post]
+func (h *portalHandler) Reservar() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reserva portal.ReservaRequest
+		err := c.ShouldBindJSON(&reserva)
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+		if reserva.IdOdontologo > 0 && reserva.FechaHora.IsZero() {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		t, err := h.s.Reservar(c, c.GetInt(middleware.ClavePaciente), reserva)
+		if err != nil {
+			web.ErrorResponse(c, statusErrorPortal(err))
+			return
+		}
+		web.OkResponse(c, http.StatusCreated, t)
+	}
+}
+
+// POST --> confirma la asistencia a un turno
+// Portal godoc
+// @Summary confirmar turno
+// @Description Confirma un turno pendiente del paciente autenticado
+// @Tags portal
+// @Param id path int true "id del turno"
+// @Produce json
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 409 {object} web.errorResponse
+// @Router /portal/turnos/:id/confirmar [post]
+func (h *portalHandler) Confirmar() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		t, err := h.s.Confirmar(c, c.GetInt(middleware.ClavePaciente), id)
+		if err != nil {
+			web.ErrorResponse(c, statusErrorPortal(err))
+			return
+		}
+		web.OkResponse(c, http.StatusOK, t)
+	}
+}
+
+// POST --> cancela un turno
+// Portal godoc
+// @Summary cancelar turno
+// @Description Cancela un turno del paciente autenticado si respeta el aviso mínimo de la política de cancelación
+// @Tags portal
+// @Param id path int true "id del turno"
+// @Produce json
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 409 {object} web.errorResponse
+// @Router /portal/turnos/:id/cancelar [post]
+func (h *portalHandler) Cancelar() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		t, err := h.s.Cancelar(c, c.GetInt(middleware.ClavePaciente), id)
+		if err != nil {
+			web.ErrorResponse(c, statusErrorPortal(err))
+			return
+		}
+		web.OkResponse(c, http.StatusOK, t)
+	}
+}
+
+// statusErrorPortal traduce los errores del portal y de turnos a la respuesta para el paciente
+func statusErrorPortal(err error) int {
+	switch {
+	case errors.Is(err, portal.ErrDatosIncompletos):
+		return http.StatusBadRequest
+	case errors.Is(err, portal.ErrCredenciales):
+		return http.StatusForbidden
+	case errors.Is(err, portal.ErrDemasiados):
+		return http.StatusTooManyRequests
+	case errors.Is(err, portal.ErrTurnoNotFound), errors.Is(err, turno.ErrNotFound), errors.Is(err, turno.ErrEspecialidad),
+		errors.Is(err, paciente.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, portal.ErrFueraDeVentana), errors.Is(err, portal.ErrSinDisponible),
+		errors.Is(err, turno.ErrBloqueOcupado), errors.Is(err, turno.ErrEstado), errors.Is(err, turno.ErrResponsable),
+		errors.Is(err, turno.ErrSinDisponibilidad), errors.Is(err, turno.ErrCancelacionTardia), errors.Is(err, turno.ErrRestringido),
+		errors.Is(err, turno.ErrConcurrente), errors.Is(err, turno.ErrVersion):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
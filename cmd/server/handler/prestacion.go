@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"finalgo/internal/prestacion"
+	"finalgo/pkg/web"
+
+	"github.com/gin-gonic/gin"
+)
+
+// creo la estructura del controlador, inyectando el service
+type prestacionHandler struct {
+	s prestacion.Service
+}
+
+// funcion para instanciar el controlador
+func NewPrestacionHandler(s prestacion.Service) *prestacionHandler {
+	return &prestacionHandler{
+		s: s,
+	}
+}
+
+// POST --> agregar prestación al catálogo
+// Prestacion godoc
+// @Summary Create Prestacion
+// @Description Create a new prestación in the catalog
+// @Tags prestacion
+// @Accept json
+// @Produce json
+// @Param	Prestacion	body	prestacion.PrestacionRequest	true	"Add prestacion"
+// @Success 201 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 500 {object} web.errorResponse
+// @Router /prestaciones [post]
+func (h *prestacionHandler) CreatePrestacion() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var prestacion prestacion.PrestacionRequest
+
+		err := c.ShouldBindJSON(&prestacion)
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		// valido la existencia de datos clave
+		valid, err := validatePrestacionEmptys(prestacion)
+		if !valid {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		p, err := h.s.CreatePrestacion(c, prestacion)
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+		web.OkResponse(c, 201, p)
+	}
+}
+
+// validatePrestacionEmptys valida que los campos claves no esten vacios y que el precio no sea negativo
+func validatePrestacionEmptys(prestacion prestacion.PrestacionRequest) (bool, error) {
+	if prestacion.Codigo == "" || prestacion.Descripcion == "" || prestacion.Precio < 0 {
+		return false, errors.New("No se permiten los campos código y descripción vacíos ni precios negativos")
+	}
+	return true, nil
+}
+
+// statusErrorPrestacion devuelve 404 si la prestación no existe, 412 si cambió desde que el cliente la leyó y el
+// código por defecto de la operación para el resto
+func statusErrorPrestacion(err error, porDefecto int) int {
+	switch {
+	case errors.Is(err, prestacion.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, prestacion.ErrVersion):
+		return http.StatusPreconditionFailed
+	default:
+		return porDefecto
+	}
+}
+
+// GET --> traer el catálogo de prestaciones
+// Prestacion godoc
+// @Summary get prestaciones
+// @Description Get all prestaciones
+// @Tags prestacion
+// @Produce json
+// @Success 200 {object} web.response
+// @Failure 500 {object} web.errorResponse
+// @Router /prestaciones [get]
+func (h *prestacionHandler) GetAll() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		prestaciones, err := h.s.GetAll(ctx)
+		if err != nil {
+			web.ErrorResponse(ctx, http.StatusInternalServerError)
+			return
+		}
+		web.OkResponse(ctx, http.StatusOK, prestaciones)
+	}
+}
+
+// GET --> traer prestación por id
+// Prestacion godoc
+// @Summary get prestacion
+// @Description Get prestacion by id. La versión de la prestación vuelve en el encabezado ETag.
+// @Tags prestacion
+// @Param id path int true "id de la prestación"
+// @Produce json
+// @Success 200 {object} web.response
+// @Header 200 {string} ETag "versión de la prestación"
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Router /prestaciones/:id [get]
+func (h *prestacionHandler) GetPrestacionByID() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			web.ErrorResponse(ctx, http.StatusBadRequest)
+			return
+		}
+
+		prestacion, err := h.s.GetPrestacionByID(ctx, id)
+		if err != nil {
+			web.ErrorResponse(ctx, http.StatusNotFound)
+			return
+		}
+		web.ETag(ctx, prestacion.Version)
+		web.OkResponse(ctx, http.StatusOK, prestacion)
+	}
+}
+
+// PUT --> actualiza completa una prestación
+// Prestacion godoc
+// @Summary update prestacion
+// @Description Update prestacion by id
+// @Tags prestacion
+// @Accept json
+// @Produce json
+// @Param	If-Match	header	string	true	"ETag de la prestación leída"
+// @Param	Prestacion	body	prestacion.PrestacionRequest	true	"Update prestacion"
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 412 {object} web.errorResponse
+// @Failure 428 {object} web.errorResponse
+// @Failure 500 {object} web.errorResponse
+// @Router /prestaciones/:id [put]
+func (h *prestacionHandler) UpdatePrestacion() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+		version, ok := web.IfMatch(c)
+		if !ok {
+			return
+		}
+
+		var prestacion prestacion.PrestacionRequest
+		err = c.ShouldBindJSON(&prestacion)
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		valid, err := validatePrestacionEmptys(prestacion)
+		if !valid {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		p, err := h.s.UpdatePrestacion(c, prestacion, id, version)
+		if err != nil {
+			web.ErrorResponse(c, statusErrorPrestacion(err, http.StatusInternalServerError))
+			return
+		}
+		web.ETag(c, p.Version)
+		web.OkResponse(c, http.StatusOK, p)
+	}
+}
+
+// DELETE --> elimina una prestación del catálogo
+// Prestacion godoc
+// @Summary delete prestacion
+// @Description Delete prestacion by id
+// @Tags prestacion
+// @Param id path int true "id de la prestación"
+// @Param If-Match header string true "ETag de la prestación leída"
+// @Produce json
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 412 {object} web.errorResponse
+// @Failure 428 {object} web.errorResponse
+// @Router /prestaciones/:id [delete]
+func (h *prestacionHandler) DeletePrestacion() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		version, ok := web.IfMatch(c)
+		if !ok {
+			return
+		}
+
+		err = h.s.DeletePrestacion(c, id, version)
+		if err != nil {
+			web.ErrorResponse(c, statusErrorPrestacion(err, http.StatusNotFound))
+			return
+		}
+		respuesta := "Prestación de ID " + c.Param("id") + " eliminada"
+		web.OkResponse(c, http.StatusOK, respuesta)
+	}
+}
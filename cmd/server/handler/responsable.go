@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"finalgo/internal/paciente"
+	"finalgo/pkg/web"
+
+	"github.com/gin-gonic/gin"
+)
+
+// creo la estructura del controlador, inyectando el service de pacientes que es el dueño de los responsables
+type responsableHandler struct {
+	s paciente.Service
+}
+
+// funcion para instanciar el controlador
+func NewResponsableHandler(s paciente.Service) *responsableHandler {
+	return &responsableHandler{
+		s: s,
+	}
+}
+
+// GET --> responsables del paciente
+// Responsable godoc
+// @Summary get responsables del paciente
+// @Description Get los adultos a cargo del paciente (padres, tutores)
+// @Tags responsables
+// @Param id path int true "id del paciente"
+// @Produce json
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 500 {object} web.errorResponse
+// @Router /pacientes/:id/responsables [get]
+func (h *responsableHandler) GetResponsables() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		responsables, err := h.s.GetResponsables(c, id)
+		if err != nil {
+			web.ErrorResponse(c, http.StatusInternalServerError)
+			return
+		}
+		web.OkResponse(c, http.StatusOK, responsables)
+	}
+}
+
+// POST --> carga un responsable
+// Responsable godoc
+// @Summary crear responsable
+// @Description Vincula al paciente con otro paciente mayor de edad (id_responsable) o con un responsable externo
+// @Tags responsables
+// @Accept json
+// @Produce json
+// @Param id path int true "id del paciente"
+// @Param	Responsable	body	paciente.ResponsableRequest	true	"Add responsable"
+// @Success 201 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 500 {object} web.errorResponse
+// @Router /pacientes/:id/responsables [post]
+func (h *responsableHandler) CreateResponsable() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		var responsable paciente.ResponsableRequest
+		err = c.ShouldBindJSON(&responsable)
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		// valido la existencia de datos clave
+		valid, err := validateResponsableEmptys(responsable)
+		if !valid {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		r, err := h.s.CreateResponsable(c, responsable, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, paciente.ErrNotFound):
+				web.ErrorResponse(c, http.StatusNotFound)
+			case errors.Is(err, paciente.ErrResponsable):
+				web.ErrorResponse(c, http.StatusBadRequest)
+			default:
+				web.ErrorResponse(c, http.StatusInternalServerError)
+			}
+			return
+		}
+		web.OkResponse(c, 201, r)
+	}
+}
+
+// DELETE --> desvincula un responsable
+// Responsable godoc
+// @Summary delete responsable
+// @Description Delete responsable del paciente
+// @Tags responsables
+// @Param id path int true "id del paciente"
+// @Param idResponsable path int true "id del responsable"
+// @Param If-Match header string true "versión del responsable leído"
+// @Produce json
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 412 {object} web.errorResponse
+// @Failure 428 {object} web.errorResponse
+// @Router /pacientes/:id/responsables/:idResponsable [delete]
+func (h *responsableHandler) DeleteResponsable() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+		idResponsable, err := strconv.Atoi(c.Param("idResponsable"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		version, ok := web.IfMatch(c)
+		if !ok {
+			return
+		}
+
+		err = h.s.DeleteResponsable(c, id, idResponsable, version)
+		if err != nil {
+			if errors.Is(err, paciente.ErrVersionResponsable) {
+				web.ErrorResponse(c, http.StatusPreconditionFailed)
+				return
+			}
+			web.ErrorResponse(c, http.StatusNotFound)
+			return
+		}
+		respuesta := "Responsable de ID " + c.Param("idResponsable") + " eliminado"
+		web.OkResponse(c, http.StatusOK, respuesta)
+	}
+}
+
+// validateResponsableEmptys valida que venga la relación y, si no es otro paciente, el nombre y el DNI
+func validateResponsableEmptys(responsable paciente.ResponsableRequest) (bool, error) {
+	if responsable.Relacion == "" {
+		return false, errors.New("No se permite la relación vacía")
+	}
+	if responsable.IdResponsable == 0 && (responsable.Nombre == "" || responsable.DNI == "") {
+		return false, errors.New("No se permiten los campos nombre y DNI vacíos para un responsable externo")
+	}
+	return true, nil
+}
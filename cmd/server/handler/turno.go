@@ -26,13 +26,15 @@ func NewTurnoHandler(s turno.Service) *turnoHandler {
 // POST --> agregar turno
 // Turno godoc
 // @Summary Create Turno
-// @Description Create a new turno
+// @Description Create a new turno. Sin id_odontologo y con especialidad, asigna el odontólogo de esa especialidad con el primer turno libre desde fecha_hora
 // @Tags turno
 // @Accept json
 // @Produce json
 // @Param	Turno	body	turno.TurnoRequest	true	"Add turno"
 // @Success 201 {object} web.response
 // @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 409 {object} web.errorResponse
 // @Failure 500 {object} web.errorResponse
 // @Router /turnos [post]
 func (h *turnoHandler) CreateTurno() gin.HandlerFunc {
@@ -46,7 +48,7 @@ func (h *turnoHandler) CreateTurno() gin.HandlerFunc {
 		}
 
 		// valido la existencia de datos clave
-		valid, err := validateTurnoEmptys(turno)
+		valid, err := validateTurnoCreacion(turno)
 		if !valid {
 			web.ErrorResponse(c, http.StatusBadRequest)
 			return
@@ -54,7 +56,7 @@ func (h *turnoHandler) CreateTurno() gin.HandlerFunc {
 
 		p, err := h.s.CreateTurno(c, turno)
 		if err != nil {
-			web.ErrorResponse(c, http.StatusBadRequest)
+			web.ErrorResponse(c, statusErrorTurno(err, http.StatusBadRequest))
 			return
 		}
 		web.OkResponse(c, 201, p)
@@ -69,6 +71,18 @@ func validateTurnoEmptys(turno turno.TurnoRequest) (bool, error) {
 	return true, nil
 }
 
+// validateTurnoCreacion es como validateTurnoEmptys, pero si piden por especialidad el odontólogo y la fecha
+// los asigna el sistema (la fecha, si viene, es desde cuándo buscar)
+func validateTurnoCreacion(turno turno.TurnoRequest) (bool, error) {
+	if turno.Especialidad == "" || turno.IdOdontologo > 0 {
+		return validateTurnoEmptys(turno)
+	}
+	if turno.IdPaciente < 1 {
+		return false, errors.New("No se permite el campo paciente vacío")
+	}
+	return true, nil
+}
+
 // POST --> agregar turno con dni de paciente y matricula del odontologo
 // Turno godoc
 // @Summary Create Turno By DNI and Matricula
@@ -79,6 +93,7 @@ func validateTurnoEmptys(turno turno.TurnoRequest) (bool, error) {
 // @Param	Turno	body	turno.TurnoDniMatriculaRequest	true	"Add turno by dni and matricula"
 // @Success 201 {object} web.response
 // @Failure 400 {object} web.errorResponse
+// @Failure 409 {object} web.errorResponse
 // @Failure 500 {object} web.errorResponse
 // @Router /turnos/dni [post]
 func (h *turnoHandler) CreateTurnoByDniAndMatricula() gin.HandlerFunc {
@@ -100,7 +115,7 @@ func (h *turnoHandler) CreateTurnoByDniAndMatricula() gin.HandlerFunc {
 
 		t, err := h.s.CreateTurnoByDniAndMatricula(c, turno)
 		if err != nil {
-			web.ErrorResponse(c, http.StatusBadRequest)
+			web.ErrorResponse(c, statusErrorTurno(err, http.StatusBadRequest))
 			return
 		}
 		web.OkResponse(c, 201, t)
@@ -118,12 +133,13 @@ func validateTurnoEmptys2(turno turno.TurnoDniMatriculaRequest) (bool, error) {
 // GET --> traer turno por id
 // Turno godoc
 // @Summary turno example
-// @Description Get turno by id
+// @Description Get turno by id. La versión del turno vuelve en el encabezado ETag.
 // @Tags turno
 // @Param id path int true "id del turno"
 // @Accept json
 // @Produce json
 // @Success 200 {object} web.response
+// @Header 200 {string} ETag "versión del turno"
 // @Failure 400 {object} web.errorResponse
 // @Failure 500 {object} web.errorResponse
 // @Router /turnos/:id [get]
@@ -150,6 +166,7 @@ func (h *turnoHandler) GetTurnoByID() gin.HandlerFunc {
 				web.ErrorResponse(ctx, http.StatusNotFound)
 				return
 			}
+			web.ETag(ctx, turno.Version)
 			web.OkResponse(ctx, http.StatusOK, turno)
 			return
 		}
@@ -210,9 +227,13 @@ func (h *turnoHandler) GetTurnoByPaciente() gin.HandlerFunc {
 // @Tags turno
 // @Accept json
 // @Produce json
+// @Param	If-Match	header	string	true	"ETag del turno leído"
 // @Param	Turno	body	turno.TurnoRequest	true	"Update turno"
 // @Success 200 {object} web.response
 // @Failure 400 {object} web.errorResponse
+// @Failure 409 {object} web.errorResponse
+// @Failure 412 {object} web.errorResponse
+// @Failure 428 {object} web.errorResponse
 // @Failure 500 {object} web.errorResponse
 // @Router /turnos/:id [put]
 func (h *turnoHandler) UpdateTurno() gin.HandlerFunc {
@@ -225,6 +246,12 @@ func (h *turnoHandler) UpdateTurno() gin.HandlerFunc {
 			return
 		}
 
+		// la versión que leyó el cliente
+		version, ok := web.IfMatch(c)
+		if !ok {
+			return
+		}
+
 		// verifico el json a enviar
 		var turno turno.TurnoRequest
 		err = c.ShouldBindJSON(&turno)
@@ -241,12 +268,13 @@ func (h *turnoHandler) UpdateTurno() gin.HandlerFunc {
 		}
 
 		// llamo al servicio para actualizar al turno
-		p, err := h.s.UpdateTurno(c, turno, id)
+		p, err := h.s.UpdateTurno(c, turno, id, version)
 		if err != nil {
-			web.ErrorResponse(c, http.StatusInternalServerError)
+			web.ErrorResponse(c, statusErrorTurno(err, http.StatusInternalServerError))
 			return
 		}
 
+		web.ETag(c, p.Version)
 		web.OkResponse(c, http.StatusOK, p)
 	}
 }
@@ -258,9 +286,13 @@ func (h *turnoHandler) UpdateTurno() gin.HandlerFunc {
 // @Tags turno
 // @Accept json
 // @Produce json
+// @Param	If-Match	header	string	true	"ETag del turno leído"
 // @Param	Turno	body	turno.TurnoRequest	true	"Update turno for field"
 // @Success 200 {object} web.response
 // @Failure 400 {object} web.errorResponse
+// @Failure 409 {object} web.errorResponse
+// @Failure 412 {object} web.errorResponse
+// @Failure 428 {object} web.errorResponse
 // @Failure 500 {object} web.errorResponse
 // @Router /turnos/patch/:id [patch]
 func (h *turnoHandler) UpdateTurnoForField() gin.HandlerFunc {
@@ -272,12 +304,17 @@ func (h *turnoHandler) UpdateTurnoForField() gin.HandlerFunc {
 			web.ErrorResponse(c, http.StatusBadRequest)
 			return
 		}
+		version, ok := web.IfMatch(c)
+		if !ok {
+			return
+		}
 
 		// le paso los query params
 		odontologoQuery := c.Query("id_odontologo")
 		pacienteQuery := c.Query("id_paciente")
 		fechaHoraQuery := c.Query("fecha_hora")
 		descripcionQuery := c.Query("descripcion")
+		prestacionQuery := c.Query("codigo_prestacion")
 
 		// obtengo los datos del turno original
 		turnoOriginal, err := h.s.GetTurnoByID(c, id)
@@ -288,10 +325,11 @@ func (h *turnoHandler) UpdateTurnoForField() gin.HandlerFunc {
 
 		// creo el turno request con los datos del original
 		turnoRequest := turno.TurnoRequest{
-			IdOdontologo: turnoOriginal.IdOdontologo,
-			IdPaciente:   turnoOriginal.IdPaciente,
-			FechaHora:    turnoOriginal.FechaHora,
-			Descripcion:  turnoOriginal.Descripcion,
+			IdOdontologo:     turnoOriginal.IdOdontologo,
+			IdPaciente:       turnoOriginal.IdPaciente,
+			FechaHora:        turnoOriginal.FechaHora,
+			Descripcion:      turnoOriginal.Descripcion,
+			CodigoPrestacion: turnoOriginal.CodigoPrestacion,
 		}
 
 		// verifico si los campos tienen datos, los casteo y se los asigno al turno request
@@ -322,14 +360,18 @@ func (h *turnoHandler) UpdateTurnoForField() gin.HandlerFunc {
 		if descripcionQuery != "" {
 			turnoRequest.Descripcion = descripcionQuery
 		}
+		if prestacionQuery != "" {
+			turnoRequest.CodigoPrestacion = prestacionQuery
+		}
 
 		// llamo al metodo de actualizar turno, usando el turnoRequest
-		p, err := h.s.UpdateTurno(c, turnoRequest, id)
+		p, err := h.s.UpdateTurno(c, turnoRequest, id, version)
 		if err != nil {
-			web.ErrorResponse(c, http.StatusInternalServerError)
+			web.ErrorResponse(c, statusErrorTurno(err, http.StatusInternalServerError))
 			return
 		}
 
+		web.ETag(c, p.Version)
 		web.OkResponse(c, http.StatusOK, p)
 	}
 }
@@ -340,11 +382,15 @@ func (h *turnoHandler) UpdateTurnoForField() gin.HandlerFunc {
 // @Description Delete turno by id
 // @Tags turno
 // @Param id path int true "id del turno"
+// @Param If-Match header string true "ETag del turno leído"
 // @Accept json
 // @Produce json
 // @Success 200 {object} web.response
 // @Failure 400 {object} web.errorResponse
-// @Failure 500 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 409 {object} web.errorResponse
+// @Failure 412 {object} web.errorResponse
+// @Failure 428 {object} web.errorResponse
 // @Router /turnos/:id [delete]
 func (h *turnoHandler) DeleteTurno() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -356,13 +402,295 @@ func (h *turnoHandler) DeleteTurno() gin.HandlerFunc {
 			return
 		}
 
-		// si falla el delete, es porque el ID era invalido
-		err = h.s.DeleteTurno(c, id)
+		version, ok := web.IfMatch(c)
+		if !ok {
+			return
+		}
+		// si falla el delete, es porque el ID era invalido o el turno cambió
+		err = h.s.DeleteTurno(c, id, version)
 		if err != nil {
-			web.ErrorResponse(c, http.StatusNotFound)
+			web.ErrorResponse(c, statusErrorTurno(err, http.StatusNotFound))
 			return
 		}
 		respuesta := "Turno de ID " + c.Param("id") + " eliminado"
 		web.OkResponse(c, http.StatusOK, respuesta)
 	}
 }
+
+// POST --> restaura un turno dado de baja
+// Turno godoc
+// @Summary restaurar turno
+// @Description Vuelve a dar de alta un turno dado de baja. El paciente y el odontólogo del turno tienen que estar activos
+// @Tags turno
+// @Param id path int true "id del turno"
+// @Produce json
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 409 {object} web.errorResponse
+// @Router /turnos/:id/restaurar [post]
+func (h *turnoHandler) RestaurarTurno() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// valido id
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		t, err := h.s.RestaurarTurno(c, id)
+		if err != nil {
+			if errors.Is(err, turno.ErrNotFound) {
+				web.ErrorResponse(c, http.StatusNotFound)
+				return
+			}
+			web.ErrorResponse(c, statusErrorTurno(err, http.StatusInternalServerError))
+			return
+		}
+		web.OkResponse(c, http.StatusOK, t)
+	}
+}
+
+// GET --> turnos dados de baja
+// Turno godoc
+// @Summary get turnos eliminados
+// @Description Get los turnos dados de baja, con la fecha y el usuario de la baja
+// @Tags turno
+// @Produce json
+// @Success 200 {object} web.response
+// @Failure 500 {object} web.errorResponse
+// @Router /eliminados/turnos [get]
+func (h *turnoHandler) GetEliminados() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		turnos, err := h.s.GetEliminados(c)
+		if err != nil {
+			web.ErrorResponse(c, http.StatusInternalServerError)
+			return
+		}
+		web.OkResponse(c, http.StatusOK, turnos)
+	}
+}
+
+// POST --> marca un turno como atendido
+// Turno godoc
+// @Summary atender turno
+// @Description Marca el turno como atendido, lo que habilita su facturación
+// @Tags turno
+// @Param id path int true "id del turno"
+// @Param If-Match header string true "ETag del turno leído"
+// @Produce json
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 409 {object} web.errorResponse
+// @Failure 412 {object} web.errorResponse
+// @Failure 428 {object} web.errorResponse
+// @Failure 500 {object} web.errorResponse
+// @Router /turnos/:id/atender [post]
+func (h *turnoHandler) AtenderTurno() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// valido id
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		version, ok := web.IfMatch(c)
+		if !ok {
+			return
+		}
+		t, err := h.s.AtenderTurno(c, id, version)
+		if err != nil {
+			if errors.Is(err, turno.ErrConsentimiento) {
+				web.ErrorResponse(c, http.StatusConflict)
+				return
+			}
+			web.ErrorResponse(c, statusCambioEstado(err))
+			return
+		}
+		web.ETag(c, t.Version)
+		web.OkResponse(c, http.StatusOK, t)
+	}
+}
+
+// POST --> cancela un turno desde la clínica
+// Turno godoc
+// @Summary cancelar turno
+// @Description Cancela un turno pendiente o confirmado. Si no respeta el aviso mínimo queda registrado como cancelación tardía, con la penalidad de la política
+// @Tags turno
+// @Param id path int true "id del turno"
+// @Param If-Match header string true "ETag del turno leído"
+// @Produce json
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 409 {object} web.errorResponse
+// @Failure 412 {object} web.errorResponse
+// @Failure 428 {object} web.errorResponse
+// @Failure 500 {object} web.errorResponse
+// @Router /turnos/:id/cancelar [post]
+func (h *turnoHandler) CancelarTurno() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		version, ok := web.IfMatch(c)
+		if !ok {
+			return
+		}
+		t, err := h.s.CancelarTurno(c, id, version, turno.OrigenClinica)
+		if err != nil {
+			web.ErrorResponse(c, statusCambioEstado(err))
+			return
+		}
+		web.ETag(c, t.Version)
+		web.OkResponse(c, http.StatusOK, t)
+	}
+}
+
+// POST --> marca que el paciente no vino al turno
+// Turno godoc
+// @Summary marcar ausente
+// @Description Marca como ausente un turno pendiente o confirmado cuyo horario ya pasó y lo suma al historial de ausencias del paciente
+// @Tags turno
+// @Param id path int true "id del turno"
+// @Param If-Match header string true "ETag del turno leído"
+// @Produce json
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 409 {object} web.errorResponse
+// @Failure 412 {object} web.errorResponse
+// @Failure 428 {object} web.errorResponse
+// @Failure 500 {object} web.errorResponse
+// @Router /turnos/:id/ausente [post]
+func (h *turnoHandler) MarcarAusente() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		version, ok := web.IfMatch(c)
+		if !ok {
+			return
+		}
+		t, err := h.s.MarcarAusente(c, id, version)
+		if err != nil {
+			web.ErrorResponse(c, statusCambioEstado(err))
+			return
+		}
+		web.ETag(c, t.Version)
+		web.OkResponse(c, http.StatusOK, t)
+	}
+}
+
+// GET --> historial de ausencias y cancelaciones tardías del paciente
+// Turno godoc
+// @Summary asistencia del paciente
+// @Description Ausencias y cancelaciones tardías del paciente en el período de la política, y si quedó restringido para reservar por su cuenta
+// @Tags turno
+// @Param id path int true "id del paciente"
+// @Produce json
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 500 {object} web.errorResponse
+// @Router /pacientes/:id/asistencia [get]
+func (h *turnoHandler) GetAsistencia() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		asistencia, err := h.s.GetAsistencia(c, id)
+		if err != nil {
+			if errors.Is(err, turno.ErrNotFound) {
+				web.ErrorResponse(c, http.StatusNotFound)
+				return
+			}
+			web.ErrorResponse(c, http.StatusInternalServerError)
+			return
+		}
+		web.OkResponse(c, http.StatusOK, asistencia)
+	}
+}
+
+// GET --> agenda del día del odontólogo
+// Turno godoc
+// @Summary agenda del odontologo
+// @Description Turnos del odontólogo en el día, en orden, con las alertas médicas activas de cada paciente
+// @Tags turno
+// @Param id path int true "id del odontólogo"
+// @Param fecha query string false "día en formato AAAA-MM-DD, por defecto hoy"
+// @Produce json
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 500 {object} web.errorResponse
+// @Router /odontologos/:id/agenda [get]
+func (h *turnoHandler) GetAgenda() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		fecha := time.Now()
+		if f := c.Query("fecha"); f != "" {
+			fecha, err = time.ParseInLocation("2006-01-02", f, time.Local)
+			if err != nil {
+				web.ErrorResponse(c, http.StatusBadRequest)
+				return
+			}
+		}
+
+		agenda, err := h.s.GetAgenda(c, id, fecha)
+		if err != nil {
+			if errors.Is(err, turno.ErrNotFound) {
+				web.ErrorResponse(c, http.StatusNotFound)
+				return
+			}
+			web.ErrorResponse(c, http.StatusInternalServerError)
+			return
+		}
+		web.OkResponse(c, http.StatusOK, agenda)
+	}
+}
+
+// statusErrorTurno traduce los errores del turno a un código HTTP. Devuelve 409 cuando el turno choca con una regla
+// del paciente (menor sin responsable, máximo de ausencias), con su estado o con otro turno del odontólogo. También
+// es 409 si no hay turnos libres, si el paciente o el odontólogo están dados de baja o si el turno cambió mientras se
+// procesaba el pedido. Es 404 si no hay odontólogos de la especialidad y 412 si el turno cambió desde que el cliente
+// lo leyó. El resto usa el código por defecto de la operación.
+func statusErrorTurno(err error, porDefecto int) int {
+	switch {
+	case errors.Is(err, turno.ErrResponsable), errors.Is(err, turno.ErrSinDisponibilidad), errors.Is(err, turno.ErrEstado),
+		errors.Is(err, turno.ErrAusencia), errors.Is(err, turno.ErrRestringido), errors.Is(err, turno.ErrCancelacionTardia),
+		errors.Is(err, turno.ErrBajaRelacionada), errors.Is(err, turno.ErrBloqueOcupado), errors.Is(err, turno.ErrConcurrente):
+		return http.StatusConflict
+	case errors.Is(err, turno.ErrEspecialidad):
+		return http.StatusNotFound
+	case errors.Is(err, turno.ErrVersion):
+		return http.StatusPreconditionFailed
+	default:
+		return porDefecto
+	}
+}
+
+// statusCambioEstado es statusErrorTurno para atender, cancelar y marcar ausente: el turno inexistente es 404 y si
+// falla la base (el cambio de estado o su incidencia) es 500
+func statusCambioEstado(err error) int {
+	if errors.Is(err, turno.ErrExec) {
+		return http.StatusInternalServerError
+	}
+	return statusErrorTurno(err, http.StatusNotFound)
+}
@@ -0,0 +1,266 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"finalgo/internal/usuario"
+	"finalgo/pkg/auth"
+	"finalgo/pkg/web"
+
+	"github.com/gin-gonic/gin"
+)
+
+// creo la estructura del controlador de usuarios y sesiones del personal, inyectando su service
+type usuarioHandler struct {
+	s usuario.Service
+}
+
+// funcion para instanciar el controlador
+func NewUsuarioHandler(s usuario.Service) *usuarioHandler {
+	return &usuarioHandler{
+		s: s,
+	}
+}
+
+// POST --> inicia sesión
+// Auth godoc
+// @Summary login
+// @Description Valida email y contraseña y devuelve un token de acceso (Authorization: Bearer) y un refresh token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param	Login	body	usuario.LoginRequest	true	"Credenciales"
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 403 {object} web.errorResponse
+// @Router /auth/login [post]
+func (h *usuarioHandler) Login() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var login usuario.LoginRequest
+		err := c.ShouldBindJSON(&login)
+		if err != nil || login.Email == "" || login.Password == "" {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		sesion, err := h.s.Login(c, login)
+		if err != nil {
+			web.ErrorResponse(c, statusErrorSesion(err))
+			return
+		}
+		web.OkResponse(c, http.StatusOK, sesion)
+	}
+}
+
+// POST --> renueva la sesión
+// Auth godoc
+// @Summary refresh
+// @Description Cambia un refresh token por un token de acceso y un refresh token nuevos. Cada refresh token sirve una sola vez.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param	Refresh	body	usuario.RefreshRequest	true	"Refresh token"
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 403 {object} web.errorResponse
+// @Router /auth/refresh [post]
+func (h *usuarioHandler) Refresh() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var refresh usuario.RefreshRequest
+		err := c.ShouldBindJSON(&refresh)
+		if err != nil || refresh.RefreshToken == "" {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		sesion, err := h.s.Refresh(c, refresh.RefreshToken)
+		if err != nil {
+			web.ErrorResponse(c, statusErrorSesion(err))
+			return
+		}
+		web.OkResponse(c, http.StatusOK, sesion)
+	}
+}
+
+// POST --> cierra la sesión
+// Auth godoc
+// @Summary logout
+// @Description Revoca el refresh token. El token de acceso vale hasta su vencimiento.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param	Refresh	body	usuario.RefreshRequest	true	"Refresh token"
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 500 {object} web.errorResponse
+// @Router /auth/logout [post]
+func (h *usuarioHandler) Logout() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var refresh usuario.RefreshRequest
+		err := c.ShouldBindJSON(&refresh)
+		if err != nil || refresh.RefreshToken == "" {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		if err := h.s.Logout(c, refresh.RefreshToken); err != nil {
+			web.ErrorResponse(c, http.StatusInternalServerError)
+			return
+		}
+		web.OkResponse(c, http.StatusOK, "Sesión cerrada")
+	}
+}
+
+// GET --> usuario de la sesión
+// Auth godoc
+// @Summary usuario actual
+// @Description Get el usuario autenticado con el token de acceso
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} web.response
+// @Failure 401 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Router /auth/me [get]
+func (h *usuarioHandler) GetUsuarioActual() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identidad, ok := auth.UsuarioDesde(c)
+		if !ok {
+			web.ErrorResponse(c, http.StatusNotFound)
+			return
+		}
+
+		u, err := h.s.GetUsuarioByID(c, identidad.ID)
+		if err != nil {
+			web.ErrorResponse(c, http.StatusNotFound)
+			return
+		}
+		web.OkResponse(c, http.StatusOK, u)
+	}
+}
+
+// POST --> alta de usuario del personal
+// Usuario godoc
+// @Summary crear usuario
+// @Description Crea una cuenta del personal con email, contraseña (entre 8 y 72 caracteres) y rol (admin, recepcionista u odontologo; este último con id_odontologo)
+// @Tags usuario
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param	Usuario	body	usuario.UsuarioRequest	true	"Usuario"
+// @Success 201 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 409 {object} web.errorResponse
+// @Failure 500 {object} web.errorResponse
+// @Router /usuarios [post]
+func (h *usuarioHandler) CreateUsuario() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request usuario.UsuarioRequest
+		err := c.ShouldBindJSON(&request)
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		u, err := h.s.CreateUsuario(c, request)
+		if err != nil {
+			web.ErrorResponse(c, statusErrorUsuario(err))
+			return
+		}
+		web.OkResponse(c, http.StatusCreated, u)
+	}
+}
+
+// GET --> listado de usuarios del personal
+// Usuario godoc
+// @Summary listar usuarios
+// @Description Get todos los usuarios con su rol
+// @Tags usuario
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} web.response
+// @Failure 500 {object} web.errorResponse
+// @Router /usuarios [get]
+func (h *usuarioHandler) GetAll() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		usuarios, err := h.s.GetAll(c)
+		if err != nil {
+			web.ErrorResponse(c, http.StatusInternalServerError)
+			return
+		}
+		web.OkResponse(c, http.StatusOK, usuarios)
+	}
+}
+
+// PATCH --> cambia nombre, rol, odontólogo o estado de un usuario
+// Usuario godoc
+// @Summary update usuario
+// @Description Cambia los campos que vengan. Con activo en false el usuario no puede volver a iniciar sesión.
+// @Tags usuario
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "id del usuario"
+// @Param If-Match header string true "versión del usuario leída del listado"
+// @Param	Usuario	body	usuario.UsuarioUpdate	true	"Cambios"
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 409 {object} web.errorResponse
+// @Failure 412 {object} web.errorResponse
+// @Failure 428 {object} web.errorResponse
+// @Router /usuarios/:id [patch]
+func (h *usuarioHandler) UpdateUsuario() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+		// no hay GET por id: la versión sale del listado de usuarios
+		version, ok := web.IfMatch(c)
+		if !ok {
+			return
+		}
+		var cambios usuario.UsuarioUpdate
+		if err := c.ShouldBindJSON(&cambios); err != nil {
+			web.ErrorResponse(c, http.StatusBadRequest)
+			return
+		}
+
+		u, err := h.s.UpdateUsuario(c, id, version, cambios)
+		if err != nil {
+			web.ErrorResponse(c, statusErrorUsuario(err))
+			return
+		}
+		web.ETag(c, u.Version)
+		web.OkResponse(c, http.StatusOK, u)
+	}
+}
+
+// statusErrorUsuario traduce los errores de alta y modificación de usuarios
+func statusErrorUsuario(err error) int {
+	switch {
+	case errors.Is(err, usuario.ErrEmail), errors.Is(err, usuario.ErrPassword), errors.Is(err, usuario.ErrDatos),
+		errors.Is(err, usuario.ErrRol), errors.Is(err, usuario.ErrOdontologo):
+		return http.StatusBadRequest
+	case errors.Is(err, usuario.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, usuario.ErrVersion):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, usuario.ErrEmailExistente), errors.Is(err, usuario.ErrPropio):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// statusErrorSesion: credenciales o refresh token inválidos son 403, el resto es un error interno
+func statusErrorSesion(err error) int {
+	if errors.Is(err, usuario.ErrCredenciales) {
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
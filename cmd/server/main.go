@@ -2,27 +2,21 @@ package main
 
 import (
 	"database/sql"
-	"fmt"
 	"log"
 	"os"
 
-	_ "github.com/go-sql-driver/mysql"
-
 	"finalgo/cmd/server/routes"
+	"finalgo/pkg/basedatos"
+	"finalgo/pkg/config"
 	"finalgo/pkg/middleware"
 
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 
 	_ "finalgo/docs"
 )
 
-const (
-	puerto = ":8080"
-)
-
 // @title           Swagger Clinica Odontologica API
 // @version         1.0
 // @description     Documentacion de la clinica odontologica
@@ -40,6 +34,11 @@ const (
 
 // @securityDefinitions.basic  BasicAuth
 
+// @securityDefinitions.apikey  BearerAuth
+// @in                          header
+// @name                        Authorization
+// @description                 Token de acceso de /auth/login, con el formato "Bearer <token>"
+
 // @externalDocs.description  OpenAPI
 // @externalDocs.url          https://swagger.io/resources/open-api/
 func main() {
@@ -50,58 +49,63 @@ func main() {
 		}
 	}()
 
-	// Usa la librería de env.
-	errE := godotenv.Load()
-	if errE != nil {
-		log.Fatal(errE)
+	// Lee la configuración: valores por defecto, config.yaml, .env y variables de entorno.
+	cfg, err := config.Cargar()
+	if err != nil {
+		log.Fatalf("Error en la configuración: %v", err)
 	}
 
-	// Inicia el router
-	router := gin.Default()
+	// Inicia el router. En nivel error no se loguea cada pedido.
+	if cfg.Log.Nivel != config.LogDebug {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	router := gin.New()
+	// los handlers pasan el *gin.Context a los services; así su Done y su Deadline son los del pedido, y las
+	// consultas se cortan al vencer el timeout o si el cliente se desconecta
+	router.ContextWithFallback = true
+	// la IP del cliente sólo se toma de X-Forwarded-For si el pedido viene de un proxy configurado
+	if err := router.SetTrustedProxies(cfg.Servidor.ProxiesConfiables()); err != nil {
+		log.Fatalf("Error en la configuración de proxies: %v", err)
+	}
 	router.Use(gin.Recovery())
-	router.Use(middleware.Logger())
+	if cfg.Log.Nivel != config.LogError {
+		router.Use(gin.Logger())
+		router.Use(middleware.Logger())
+	}
 
 	// Agrego swagger
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// Conecta a la base de datos
-	db := connectDB()
+	db := connectDB(cfg.DB)
 
 	// Ejecuta la aplicación
-	runApp(db, router)
+	runApp(db, router, cfg)
 
 	// cierro BD
 	defer db.Close()
 }
 
-func runApp(db *sql.DB, engine *gin.Engine) {
+func runApp(db *sql.DB, engine *gin.Engine, cfg config.Config) {
 	// Ejecuta la aplicación.
-	router := routes.NewRouter(engine, db)
+	router := routes.NewRouter(engine, db, cfg)
 	// Mapea todas las rutas.
 	router.MapRoutes()
-	if err := engine.Run(puerto); err != nil {
+
+	var err error
+	if cfg.Servidor.TLS() {
+		err = engine.RunTLS(cfg.Servidor.Direccion(), cfg.Servidor.TLSCert, cfg.Servidor.TLSClave)
+	} else {
+		err = engine.Run(cfg.Servidor.Direccion())
+	}
+	if err != nil {
 		log.Fatalf("Error al ejecutar la aplicación: %v", err)
 	}
 }
 
-func connectDB() *sql.DB {
-	var (
-		dbUsername = "root"
-		dbPassword = "1234"
-		dbHost     = "localhost"
-		dbPort     = "3306"
-		dbName     = "my_db"
-	)
-
-	dataSource := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true", dbUsername, dbPassword, dbHost, dbPort, dbName)
-
-	db, err := sql.Open("mysql", dataSource)
+func connectDB(cfg config.DB) *sql.DB {
+	db, err := basedatos.Abrir(cfg)
 	if err != nil {
-		log.Fatalf("Error al abrir la conexión a la base de datos: %v", err)
-	}
-
-	err = db.Ping()
-	if err := db.Ping(); err != nil {
 		log.Fatalf("Error al conectar con la base de datos: %v", err)
 	}
 
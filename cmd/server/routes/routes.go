@@ -1,13 +1,32 @@
 package routes
 
 import (
+	"context"
 	"database/sql"
+	"log"
+	"time"
 	"github.com/gin-gonic/gin"
 	"finalgo/pkg/middleware"
+	"finalgo/pkg/documento"
+	"finalgo/internal/adjunto"
+	"finalgo/internal/consentimiento"
+	"finalgo/internal/comprobante"
 	"finalgo/internal/odontologo"
+	"finalgo/internal/obrasocial"
+	"finalgo/internal/facturacion"
+	"finalgo/internal/liquidacion"
+	"finalgo/internal/prestacion"
 	handler "finalgo/cmd/server/handler"
 	"finalgo/internal/paciente"
 	"finalgo/internal/turno"
+	"finalgo/internal/portal"
+	"finalgo/pkg/auth"
+	"finalgo/internal/usuario"
+	"finalgo/internal/auditoria"
+	"finalgo/internal/baja"
+	"finalgo/pkg/transaccion"
+	"finalgo/pkg/config"
+	"finalgo/internal/armado"
 )
 
 // Router es una interfaz que define los métodos que debe implementar cualquier enrutador.
@@ -20,81 +39,447 @@ type router struct {
 	engine      *gin.Engine
 	routerGroup *gin.RouterGroup
 	db          *sql.DB
+	cfg         config.Config
+	tokens      *auth.Tokens
+	privado     *gin.RouterGroup
+	relacion    middleware.RelacionPaciente
+	auditoria   auditoria.Service
+	// unidad de trabajo con la que los services aplican cada cambio junto con su registro de auditoría
+	uow         transaccion.UnitOfWork
+	// repositorios compartidos por todas las rutas: en memoria, cada dominio tiene que tener una sola instancia
+	repos       armado.Repositorios
 }
 
 // NewRouter crea un nuevo enrutador Gin.
-func NewRouter(engine *gin.Engine, db *sql.DB) Router {
+func NewRouter(engine *gin.Engine, db *sql.DB, cfg config.Config) Router {
 	return &router{
 		engine: engine,
 		db:     db,
+		cfg:    cfg,
 	}
 }
 
 // MapRoutes mapea todas las rutas.
 func (r *router) MapRoutes() {
 	r.setGroup()
+	r.setTokens()
+	r.setRepositorios()
+	r.setAuditoria()
+	r.setPrivateGroup()
+	r.buildAuthRoutes()
+	r.buildAuditoriaRoutes()
 	r.buildOdontologoRoutes()
 	r.buildPacienteRoutes()
 	r.buildTurnoRoutes()
+	r.buildPrestacionRoutes()
 	r.buildPingRoutes()
+	if r.enMemoria() {
+		log.Println("REPOSITORIOS=memoria: obras sociales, facturación, liquidaciones, comprobantes, adjuntos, consentimientos y portal quedan deshabilitados")
+		return
+	}
+	r.buildObraSocialRoutes()
+	r.buildFacturacionRoutes()
+	r.buildLiquidacionRoutes()
+	r.buildComprobanteRoutes()
+	r.buildAdjuntoRoutes()
+	r.buildConsentimientoRoutes()
+	r.buildPortalRoutes()
+}
+
+// enMemoria indica si pacientes, odontólogos y turnos están en memoria
+func (r *router) enMemoria() bool {
+	return r.cfg.Repositorios == config.RepositoriosMemoria
 }
 
-// setGroup establece el grupo de enrutador.
+// setGroup establece el grupo de enrutador. Todas las rutas llevan el tiempo máximo de su operación.
 func (r *router) setGroup() {
-	r.routerGroup = r.engine.Group("/api/v1")
+	const base = "/api/v1"
+	r.routerGroup = r.engine.Group(base, middleware.Timeout(r.configTimeouts(base)))
+}
+
+// configTimeouts arma los tiempos máximos de las lecturas, las escrituras y los procesos pesados (lotes de
+// liquidación, exportaciones y archivos adjuntos).
+func (r *router) configTimeouts(base string) middleware.Timeouts {
+	procesos := r.cfg.Timeouts.Procesos()
+	return middleware.Timeouts{
+		Lectura:   r.cfg.Timeouts.Lectura(),
+		Escritura: r.cfg.Timeouts.Escritura(),
+		PorRuta: map[string]time.Duration{
+			"POST " + base + "/liquidaciones":                    procesos,
+			"GET " + base + "/liquidaciones/:id/exportar":        procesos,
+			"GET " + base + "/liquidaciones/:id/conciliacion":    procesos,
+			"GET " + base + "/pacientes/:id/adjuntos/:idAdjunto": procesos,
+			"POST " + base + "/pacientes/:id/adjuntos":           procesos,
+		},
+	}
+}
+
+// setTokens arma el emisor de JWT con las claves de la configuración. Para rotar se agrega la clave nueva, se cambia
+// la actual y la vieja se saca cuando vencieron sus tokens.
+func (r *router) setTokens() {
+	claves, err := auth.ParseClaves(r.cfg.Tokens.Claves, r.cfg.Tokens.ClaveActual)
+	if err != nil {
+		log.Fatalf("Error en las claves de JWT: %v", err)
+	}
+	r.tokens = auth.NewTokens(claves, r.cfg.Tokens.Emisor, r.cfg.Tokens.Vigencia())
+}
+
+// setRepositorios arma los repositorios del motor de la base (DB_MOTOR). Pacientes, odontólogos y turnos pueden ir
+// en memoria según REPOSITORIOS, sólo sobre una base sin esos datos; ahí no hay transacciones, así que una baja en
+// cascada que falla a la mitad no se deshace.
+func (r *router) setRepositorios() {
+	if r.enMemoria() {
+		repos, err := armado.NuevosRepositoriosMemoria(context.Background(), r.db, r.cfg.DB.Motor)
+		if err != nil {
+			log.Fatalf("Error armando los repositorios: %v", err)
+		}
+		log.Println("pacientes, odontólogos y turnos se guardan en memoria: se pierden al reiniciar")
+		r.repos = repos
+		return
+	}
+	repos, err := armado.NuevosRepositorios(r.db, r.cfg.DB.Motor)
+	if err != nil {
+		log.Fatalf("Error armando los repositorios: %v", err)
+	}
+	r.repos = repos
+}
+
+// setAuditoria arma el registro de cambios que comparten los services de paciente, odontólogo y turno, y la unidad de
+// trabajo en la que cada cambio se registra.
+func (r *router) setAuditoria() {
+	auditoriaRepo := r.repos.Auditoria
+	r.auditoria = auditoria.NewService(auditoriaRepo)
+	r.uow = transaccion.NewUnitOfWork(r.db)
+}
+
+// setPrivateGroup arma el grupo de rutas del personal: todas exigen un token válido y cada una, además, el permiso de
+// su operación según el rol (middleware.Autorizar). Las de historia clínica usan AutorizarPaciente, que limita al
+// odontólogo a los pacientes que atiende, y atender un turno usa AutorizarTurno, que lo limita a sus turnos.
+func (r *router) setPrivateGroup() {
+	r.privado = r.routerGroup.Group("", middleware.Authenticate(r.tokens))
+
+	pacienteRepo := r.repos.Paciente
+	pacienteService := paciente.NewService(pacienteRepo, r.uow, r.auditoria)
+	odontologoRepo := r.repos.Odontologo
+	odontologoService := odontologo.NewService(odontologoRepo, r.uow, r.auditoria)
+	turnoRepo := r.repos.Turno
+	r.relacion = turno.NewService(turnoRepo, r.uow, pacienteService, odontologoService, nil, nil, r.auditoria, turno.Config{})
+}
+
+// buildAuthRoutes mapea las rutas de login y sesiones del personal, y la administración de usuarios. Si la tabla de usuarios
+// está vacía crea el usuario de ADMIN_EMAIL y ADMIN_PASSWORD para poder entrar la primera vez.
+func (r *router) buildAuthRoutes() {
+	odontologoRepo := r.repos.Odontologo
+	odontologoService := odontologo.NewService(odontologoRepo, r.uow, r.auditoria)
+	usuarioRepo := r.repos.Usuario
+	usuarioService := usuario.NewService(usuarioRepo, r.tokens, odontologoService, r.cfg.Tokens.VigenciaRefresh())
+	if err := usuarioService.CrearUsuarioInicial(context.Background(), r.cfg.Tokens.AdminEmail, r.cfg.Tokens.AdminPassword); err != nil {
+		log.Fatalf("Error al crear el usuario inicial: %v", err)
+	}
+	controladorUsuario := handler.NewUsuarioHandler(usuarioService)
+
+	r.routerGroup.POST("/auth/login", controladorUsuario.Login())
+	r.routerGroup.POST("/auth/refresh", controladorUsuario.Refresh())
+	r.routerGroup.POST("/auth/logout", controladorUsuario.Logout())
+	r.privado.GET("/auth/me", controladorUsuario.GetUsuarioActual())
+	r.privado.GET("/usuarios", middleware.Autorizar(auth.PermisoUsuariosGestionar), controladorUsuario.GetAll())
+	r.privado.POST("/usuarios", middleware.Autorizar(auth.PermisoUsuariosGestionar), controladorUsuario.CreateUsuario())
+	r.privado.PATCH("/usuarios/:id", middleware.Autorizar(auth.PermisoUsuariosGestionar), controladorUsuario.UpdateUsuario())
+}
+
+// buildAuditoriaRoutes mapea la consulta del registro de cambios, sólo para el admin.
+func (r *router) buildAuditoriaRoutes() {
+	controladorAuditoria := handler.NewAuditoriaHandler(r.auditoria)
+
+	r.privado.GET("/auditoria", middleware.Autorizar(auth.PermisoAuditoria), controladorAuditoria.Buscar())
 }
 
 // buildOdontologoRoutes mapea todas las rutas para el dominio Odontologo.
 func (r *router) buildOdontologoRoutes() {
-	odontologoRepo := odontologo.NewRepositoryMySql(r.db)
-	odontologoService := odontologo.NewService(odontologoRepo)
-	turnoRepo := turno.NewRepositoryMySql(r.db)
-	pacienteRepo := paciente.NewRepositoryMySql(r.db)
-	pacienteService := paciente.NewService(pacienteRepo)
-	turnoService := turno.NewService(turnoRepo, pacienteService, odontologoService)
-	controladorOdontologo := handler.NewodOntologoHandler(odontologoService, turnoService)
+	odontologoRepo := r.repos.Odontologo
+	odontologoService := odontologo.NewService(odontologoRepo, r.uow, r.auditoria)
+	turnoRepo := r.repos.Turno
+	pacienteRepo := r.repos.Paciente
+	pacienteService := paciente.NewService(pacienteRepo, r.uow, r.auditoria)
+	turnoService := turno.NewService(turnoRepo, r.uow, pacienteService, odontologoService, nil, nil, r.auditoria, turno.Config{})
+	bajaService := r.nuevoBajaService(pacienteService, odontologoService, turnoService)
+	controladorOdontologo := handler.NewodOntologoHandler(odontologoService, bajaService)
 
-	r.routerGroup.GET("/odontologos/:id", controladorOdontologo.GetOdontologoByID()) 
-	r.routerGroup.POST("/odontologos", middleware.Authenticate(), controladorOdontologo.CreateOdontologo())
-	r.routerGroup.PUT("/odontologos/:id", middleware.Authenticate(), controladorOdontologo.UpdateOdontologo())
-	r.routerGroup.PATCH("/odontologos/:id", middleware.Authenticate(), controladorOdontologo.UpdateOdontologoForField())
-	r.routerGroup.DELETE("/odontologos/:id", middleware.Authenticate(), controladorOdontologo.DeleteOdontologo())
+	r.privado.GET("/odontologos", middleware.Autorizar(auth.PermisoOdontologosLeer), controladorOdontologo.GetOdontologoByID())
+	r.privado.GET("/odontologos/:id", middleware.Autorizar(auth.PermisoOdontologosLeer), controladorOdontologo.GetOdontologoByID()) 
+	r.privado.GET("/especialidades", middleware.Autorizar(auth.PermisoOdontologosLeer), controladorOdontologo.GetEspecialidades())
+	r.privado.POST("/odontologos", middleware.Autorizar(auth.PermisoOdontologosEditar), controladorOdontologo.CreateOdontologo())
+	r.privado.PUT("/odontologos/:id", middleware.Autorizar(auth.PermisoOdontologosEditar), controladorOdontologo.UpdateOdontologo())
+	r.privado.PATCH("/odontologos/:id", middleware.Autorizar(auth.PermisoOdontologosEditar), controladorOdontologo.UpdateOdontologoForField())
+	r.privado.DELETE("/odontologos/:id", middleware.Autorizar(auth.PermisoOdontologosEditar), controladorOdontologo.DeleteOdontologo())
+	r.privado.POST("/odontologos/:id/restaurar", middleware.Autorizar(auth.PermisoOdontologosEditar), controladorOdontologo.RestaurarOdontologo())
+	r.privado.GET("/eliminados/odontologos", middleware.Autorizar(auth.PermisoEliminados), controladorOdontologo.GetEliminados())
+}
+
+// nuevoBajaService arma las bajas en cascada. BAJA_TURNOS_FUTUROS=bloquear impide dar de baja a quien tenga turnos
+// pendientes a futuro; sin configurar, se borran junto con el resto.
+func (r *router) nuevoBajaService(pacienteService paciente.Service, odontologoService odontologo.Service, turnoService turno.Service) baja.Service {
+	return baja.NewService(r.uow, pacienteService, odontologoService, turnoService, r.cfg.Turnos.BajaFuturos)
 }
 
 // buildPacienteRoutes mapea todas las rutas para el dominio Paciente.
 func (r *router) buildPacienteRoutes() {
-	pacienteRepo := paciente.NewRepositoryMySql(r.db)
-	pacienteService := paciente.NewService(pacienteRepo)
-	odontologoRepo := odontologo.NewRepositoryMySql(r.db)
-	odontologoService := odontologo.NewService(odontologoRepo)
-	turnoRepo := turno.NewRepositoryMySql(r.db)
-	turnoService := turno.NewService(turnoRepo, pacienteService, odontologoService)
-	controladorPaciente := handler.NewPacienteHandler(pacienteService, turnoService)
+	pacienteRepo := r.repos.Paciente
+	pacienteService := paciente.NewService(pacienteRepo, r.uow, r.auditoria)
+	odontologoRepo := r.repos.Odontologo
+	odontologoService := odontologo.NewService(odontologoRepo, r.uow, r.auditoria)
+	turnoRepo := r.repos.Turno
+	turnoService := turno.NewService(turnoRepo, r.uow, pacienteService, odontologoService, nil, nil, r.auditoria, turno.Config{})
+	bajaService := r.nuevoBajaService(pacienteService, odontologoService, turnoService)
+	controladorPaciente := handler.NewPacienteHandler(pacienteService, bajaService)
+
+	r.privado.GET("/pacientes/:id", middleware.Autorizar(auth.PermisoPacientesLeer), controladorPaciente.GetPacienteByID())
+	r.privado.POST("/pacientes", middleware.Autorizar(auth.PermisoPacientesEditar), controladorPaciente.CreatePaciente())
+	r.privado.PUT("/pacientes/:id", middleware.Autorizar(auth.PermisoPacientesEditar), controladorPaciente.UpdatePaciente())
+	r.privado.PATCH("/pacientes/:id", middleware.Autorizar(auth.PermisoPacientesEditar), controladorPaciente.UpdatePacienteForField())
+	r.privado.DELETE("/pacientes/:id", middleware.Autorizar(auth.PermisoPacientesEliminar), controladorPaciente.DeletePaciente())
+	r.privado.POST("/pacientes/:id/restaurar", middleware.Autorizar(auth.PermisoPacientesEliminar), controladorPaciente.RestaurarPaciente())
+	r.privado.GET("/eliminados/pacientes", middleware.Autorizar(auth.PermisoEliminados), controladorPaciente.GetEliminados())
 
-	r.routerGroup.GET("/pacientes/:id", controladorPaciente.GetPacienteByID())
-	r.routerGroup.POST("/pacientes", middleware.Authenticate(), controladorPaciente.CreatePaciente())
-	r.routerGroup.PUT("/pacientes/:id", middleware.Authenticate(), controladorPaciente.UpdatePaciente())
-	r.routerGroup.PATCH("/pacientes/:id", middleware.Authenticate(), controladorPaciente.UpdatePacienteForField())
-	r.routerGroup.DELETE("/pacientes/:id", middleware.Authenticate(), controladorPaciente.DeletePaciente())
+	controladorAlerta := handler.NewAlertaHandler(pacienteService)
+	r.privado.GET("/pacientes/:id/alertas", middleware.Autorizar(auth.PermisoPacientesLeer), controladorAlerta.GetAlertasByPaciente())
+	r.privado.POST("/pacientes/:id/alertas", middleware.AutorizarPaciente(auth.PermisoHistoriaEditar, r.relacion), controladorAlerta.CreateAlerta())
+	r.privado.PUT("/pacientes/:id/alertas/:idAlerta", middleware.AutorizarPaciente(auth.PermisoHistoriaEditar, r.relacion), controladorAlerta.UpdateAlerta())
+	r.privado.DELETE("/pacientes/:id/alertas/:idAlerta", middleware.AutorizarPaciente(auth.PermisoHistoriaEditar, r.relacion), controladorAlerta.DeleteAlerta())
+
+	controladorResponsable := handler.NewResponsableHandler(pacienteService)
+	r.privado.GET("/pacientes/:id/responsables", middleware.Autorizar(auth.PermisoPacientesLeer), controladorResponsable.GetResponsables())
+	r.privado.POST("/pacientes/:id/responsables", middleware.Autorizar(auth.PermisoPacientesEditar), controladorResponsable.CreateResponsable())
+	r.privado.DELETE("/pacientes/:id/responsables/:idResponsable", middleware.Autorizar(auth.PermisoPacientesEditar), controladorResponsable.DeleteResponsable())
 }
 
 // buildTurnoRoutes mapea todas las rutas para el dominio Turno.
 func (r *router) buildTurnoRoutes() {
-	turnoRepo := turno.NewRepositoryMySql(r.db)
-	pacienteRepo := paciente.NewRepositoryMySql(r.db)
-	pacienteService := paciente.NewService(pacienteRepo)
-	odontologoRepo := odontologo.NewRepositoryMySql(r.db)
-	odontologoService := odontologo.NewService(odontologoRepo)
-	turnoService := turno.NewService(turnoRepo, pacienteService, odontologoService)
+	turnoRepo := r.repos.Turno
+	pacienteRepo := r.repos.Paciente
+	pacienteService := paciente.NewService(pacienteRepo, r.uow, r.auditoria)
+	odontologoRepo := r.repos.Odontologo
+	odontologoService := odontologo.NewService(odontologoRepo, r.uow, r.auditoria)
+	// en memoria no hay consentimientos ni obras sociales: sus registros apuntan a pacientes de la base
+	var consentimientoService consentimiento.Service
+	var obraSocialService obrasocial.Service
+	if !r.enMemoria() {
+		prestacionRepo := r.repos.Prestacion
+		prestacionService := prestacion.NewService(prestacionRepo)
+		adjuntoService, _ := r.nuevoAdjuntoService(pacienteService)
+		consentimientoRepo := r.repos.Consentimiento
+		consentimientoService = consentimiento.NewService(consentimientoRepo, pacienteService, prestacionService, adjuntoService)
+		obraSocialRepo := r.repos.ObraSocial
+		obraSocialService = obrasocial.NewService(obraSocialRepo)
+	}
+	turnoService := turno.NewService(turnoRepo, r.uow, pacienteService, odontologoService, consentimientoService, obraSocialService, r.auditoria, r.configTurnos())
 	controladorTurno := handler.NewTurnoHandler(turnoService)
 
-	r.routerGroup.GET("/turnos/:id", controladorTurno.GetTurnoByID())
-	r.routerGroup.GET("/turnos/dni/:id", controladorTurno.GetTurnoByPaciente())
-	r.routerGroup.POST("/turnos", middleware.Authenticate(), controladorTurno.CreateTurno())
-	r.routerGroup.POST("/turnos/dni", middleware.Authenticate(), controladorTurno.CreateTurnoByDniAndMatricula())
-	r.routerGroup.PUT("/turnos/:id", middleware.Authenticate(), controladorTurno.UpdateTurno())
-	r.routerGroup.PATCH("/turnos/:id", middleware.Authenticate(), controladorTurno.UpdateTurnoForField())
-	r.routerGroup.DELETE("/turnos/:id", middleware.Authenticate(), controladorTurno.DeleteTurno())
+	r.privado.GET("/turnos/:id", middleware.Autorizar(auth.PermisoTurnosLeer), controladorTurno.GetTurnoByID())
+	r.privado.GET("/turnos/dni/:id", middleware.Autorizar(auth.PermisoTurnosLeer), controladorTurno.GetTurnoByPaciente())
+	r.privado.POST("/turnos", middleware.Autorizar(auth.PermisoTurnosGestionar), controladorTurno.CreateTurno())
+	r.privado.POST("/turnos/dni", middleware.Autorizar(auth.PermisoTurnosGestionar), controladorTurno.CreateTurnoByDniAndMatricula())
+	r.privado.PUT("/turnos/:id", middleware.Autorizar(auth.PermisoTurnosGestionar), controladorTurno.UpdateTurno())
+	r.privado.PATCH("/turnos/:id", middleware.Autorizar(auth.PermisoTurnosGestionar), controladorTurno.UpdateTurnoForField())
+	r.privado.DELETE("/turnos/:id", middleware.Autorizar(auth.PermisoTurnosGestionar), controladorTurno.DeleteTurno())
+	r.privado.POST("/turnos/:id/restaurar", middleware.Autorizar(auth.PermisoTurnosGestionar), controladorTurno.RestaurarTurno())
+	r.privado.GET("/eliminados/turnos", middleware.Autorizar(auth.PermisoEliminados), controladorTurno.GetEliminados())
+	r.privado.POST("/turnos/:id/atender", middleware.AutorizarTurno(auth.PermisoTurnosAtender, r.relacion), controladorTurno.AtenderTurno())
+	r.privado.POST("/turnos/:id/cancelar", middleware.Autorizar(auth.PermisoTurnosGestionar), controladorTurno.CancelarTurno())
+	r.privado.POST("/turnos/:id/ausente", middleware.Autorizar(auth.PermisoTurnosGestionar), controladorTurno.MarcarAusente())
+	r.privado.GET("/pacientes/:id/asistencia", middleware.Autorizar(auth.PermisoTurnosLeer), controladorTurno.GetAsistencia())
+	r.privado.GET("/odontologos/:id/agenda", middleware.Autorizar(auth.PermisoTurnosLeer), controladorTurno.GetAgenda())
+}
+
+// buildObraSocialRoutes mapea todas las rutas para obras sociales, reglas de cobertura y coberturas de pacientes.
+func (r *router) buildObraSocialRoutes() {
+	obraSocialRepo := r.repos.ObraSocial
+	obraSocialService := obrasocial.NewService(obraSocialRepo)
+	pacienteRepo := r.repos.Paciente
+	pacienteService := paciente.NewService(pacienteRepo, r.uow, r.auditoria)
+	controladorObraSocial := handler.NewObraSocialHandler(obraSocialService, pacienteService)
+
+	r.privado.GET("/obras-sociales", middleware.Autorizar(auth.PermisoCatalogoLeer), controladorObraSocial.GetAll())
+	r.privado.GET("/obras-sociales/:id", middleware.Autorizar(auth.PermisoCatalogoLeer), controladorObraSocial.GetObraSocialByID())
+	r.privado.POST("/obras-sociales", middleware.Autorizar(auth.PermisoCatalogoEditar), controladorObraSocial.CreateObraSocial())
+	r.privado.PUT("/obras-sociales/:id", middleware.Autorizar(auth.PermisoCatalogoEditar), controladorObraSocial.UpdateObraSocial())
+	r.privado.DELETE("/obras-sociales/:id", middleware.Autorizar(auth.PermisoCatalogoEditar), controladorObraSocial.DeleteObraSocial())
+	r.privado.GET("/obras-sociales/:id/reglas", middleware.Autorizar(auth.PermisoCatalogoLeer), controladorObraSocial.GetReglas())
+	r.privado.POST("/obras-sociales/:id/reglas", middleware.Autorizar(auth.PermisoCatalogoEditar), controladorObraSocial.CreateRegla())
+	r.privado.DELETE("/obras-sociales/:id/reglas/:idRegla", middleware.Autorizar(auth.PermisoCatalogoEditar), controladorObraSocial.DeleteRegla())
+
+	r.privado.GET("/pacientes/:id/coberturas", middleware.Autorizar(auth.PermisoPacientesLeer), controladorObraSocial.GetCoberturas())
+	r.privado.POST("/pacientes/:id/coberturas", middleware.Autorizar(auth.PermisoPacientesEditar), controladorObraSocial.CreateCobertura())
+	r.privado.DELETE("/pacientes/:id/coberturas/:idCobertura", middleware.Autorizar(auth.PermisoPacientesEditar), controladorObraSocial.DeleteCobertura())
+	r.privado.GET("/pacientes/:id/cobertura", middleware.Autorizar(auth.PermisoPacientesLeer), controladorObraSocial.GetCoberturaPrestacion())
+}
+
+// buildPrestacionRoutes mapea todas las rutas para el catálogo de prestaciones.
+func (r *router) buildPrestacionRoutes() {
+	prestacionRepo := r.repos.Prestacion
+	prestacionService := prestacion.NewService(prestacionRepo)
+	controladorPrestacion := handler.NewPrestacionHandler(prestacionService)
+
+	r.privado.GET("/prestaciones", middleware.Autorizar(auth.PermisoCatalogoLeer), controladorPrestacion.GetAll())
+	r.privado.GET("/prestaciones/:id", middleware.Autorizar(auth.PermisoCatalogoLeer), controladorPrestacion.GetPrestacionByID())
+	r.privado.POST("/prestaciones", middleware.Autorizar(auth.PermisoCatalogoEditar), controladorPrestacion.CreatePrestacion())
+	r.privado.PUT("/prestaciones/:id", middleware.Autorizar(auth.PermisoCatalogoEditar), controladorPrestacion.UpdatePrestacion())
+	r.privado.DELETE("/prestaciones/:id", middleware.Autorizar(auth.PermisoCatalogoEditar), controladorPrestacion.DeletePrestacion())
+}
+
+// buildFacturacionRoutes mapea todas las rutas para cargos, pagos y cuentas de pacientes.
+func (r *router) buildFacturacionRoutes() {
+	pacienteRepo := r.repos.Paciente
+	pacienteService := paciente.NewService(pacienteRepo, r.uow, r.auditoria)
+	odontologoRepo := r.repos.Odontologo
+	odontologoService := odontologo.NewService(odontologoRepo, r.uow, r.auditoria)
+	turnoRepo := r.repos.Turno
+	turnoService := turno.NewService(turnoRepo, r.uow, pacienteService, odontologoService, nil, nil, r.auditoria, turno.Config{})
+	prestacionRepo := r.repos.Prestacion
+	prestacionService := prestacion.NewService(prestacionRepo)
+	obraSocialRepo := r.repos.ObraSocial
+	obraSocialService := obrasocial.NewService(obraSocialRepo)
+	facturacionRepo := r.repos.Facturacion
+	facturacionService := facturacion.NewService(facturacionRepo, turnoService, prestacionService, obraSocialService)
+	controladorFacturacion := handler.NewFacturacionHandler(facturacionService, pacienteService)
+
+	r.privado.POST("/turnos/:id/cargo", middleware.Autorizar(auth.PermisoFacturacion), controladorFacturacion.GenerarCargo())
+	r.privado.GET("/pacientes/:id/cuenta", middleware.Autorizar(auth.PermisoFacturacion), controladorFacturacion.GetCuenta())
+	r.privado.POST("/pacientes/:id/cuenta/pagos", middleware.Autorizar(auth.PermisoFacturacion), controladorFacturacion.RegistrarPago())
+}
+
+// buildLiquidacionRoutes mapea todas las rutas para los lotes de liquidación a obras sociales.
+func (r *router) buildLiquidacionRoutes() {
+	obraSocialRepo := r.repos.ObraSocial
+	obraSocialService := obrasocial.NewService(obraSocialRepo)
+	liquidacionRepo := r.repos.Liquidacion
+	liquidacionService := liquidacion.NewService(liquidacionRepo, obraSocialService)
+	controladorLiquidacion := handler.NewLiquidacionHandler(liquidacionService)
+
+	r.privado.GET("/liquidaciones", middleware.Autorizar(auth.PermisoLiquidaciones), controladorLiquidacion.GetLotes())
+	r.privado.GET("/liquidaciones/:id", middleware.Autorizar(auth.PermisoLiquidaciones), controladorLiquidacion.GetLoteByID())
+	r.privado.POST("/liquidaciones", middleware.Autorizar(auth.PermisoLiquidaciones), controladorLiquidacion.GenerarLote())
+	r.privado.POST("/liquidaciones/:id/enviar", middleware.Autorizar(auth.PermisoLiquidaciones), controladorLiquidacion.MarcarEnviado())
+	r.privado.GET("/liquidaciones/:id/exportar", middleware.Autorizar(auth.PermisoLiquidaciones), controladorLiquidacion.Exportar())
+	r.privado.POST("/liquidaciones/:id/pagos", middleware.Autorizar(auth.PermisoLiquidaciones), controladorLiquidacion.RegistrarPago())
+	r.privado.GET("/liquidaciones/:id/conciliacion", middleware.Autorizar(auth.PermisoLiquidaciones), controladorLiquidacion.Conciliar())
+	r.privado.GET("/obras-sociales/:id/layout", middleware.Autorizar(auth.PermisoLiquidaciones), controladorLiquidacion.GetLayout())
+	r.privado.PUT("/obras-sociales/:id/layout", middleware.Autorizar(auth.PermisoLiquidaciones), controladorLiquidacion.SaveLayout())
+}
+
+// buildComprobanteRoutes mapea las rutas de los documentos imprimibles (comprobantes, presupuestos y recetas).
+// Los datos de la clínica salen de la configuración y las plantillas se pueden reemplazar desde DOCUMENTOS_PLANTILLAS.
+func (r *router) buildComprobanteRoutes() {
+	renderer, err := documento.NewRenderer(r.cfg.Clinica.Plantillas)
+	if err != nil {
+		log.Fatalf("Error al cargar las plantillas de documentos: %v", err)
+	}
+	clinica := documento.Clinica{
+		Nombre:    r.cfg.Clinica.Nombre,
+		Direccion: r.cfg.Clinica.Direccion,
+		Telefono:  r.cfg.Clinica.Telefono,
+	}
+
+	pacienteRepo := r.repos.Paciente
+	pacienteService := paciente.NewService(pacienteRepo, r.uow, r.auditoria)
+	odontologoRepo := r.repos.Odontologo
+	odontologoService := odontologo.NewService(odontologoRepo, r.uow, r.auditoria)
+	turnoRepo := r.repos.Turno
+	turnoService := turno.NewService(turnoRepo, r.uow, pacienteService, odontologoService, nil, nil, r.auditoria, turno.Config{})
+	prestacionRepo := r.repos.Prestacion
+	prestacionService := prestacion.NewService(prestacionRepo)
+	obraSocialRepo := r.repos.ObraSocial
+	obraSocialService := obrasocial.NewService(obraSocialRepo)
+	facturacionRepo := r.repos.Facturacion
+	facturacionService := facturacion.NewService(facturacionRepo, turnoService, prestacionService, obraSocialService)
+	comprobanteService := comprobante.NewService(renderer, clinica, turnoService, pacienteService, odontologoService, prestacionService, obraSocialService, facturacionService)
+	controladorComprobante := handler.NewComprobanteHandler(comprobanteService)
+
+	r.privado.GET("/turnos/:id/comprobante", middleware.Autorizar(auth.PermisoTurnosLeer), controladorComprobante.ConfirmacionTurno())
+	r.privado.POST("/pacientes/:id/presupuesto", middleware.Autorizar(auth.PermisoFacturacion), controladorComprobante.Presupuesto())
+	r.privado.POST("/pacientes/:id/receta", middleware.AutorizarPaciente(auth.PermisoHistoriaEditar, r.relacion), controladorComprobante.Receta())
+}
+
+// buildAdjuntoRoutes mapea las rutas de los archivos del paciente. El almacenamiento se elige con ADJUNTOS_STORAGE
+// (local por defecto, o s3 para cualquier servicio compatible) y el tamaño máximo con ADJUNTOS_MAX_MB.
+func (r *router) buildAdjuntoRoutes() {
+	pacienteRepo := r.repos.Paciente
+	pacienteService := paciente.NewService(pacienteRepo, r.uow, r.auditoria)
+	adjuntoService, tamanioMaximo := r.nuevoAdjuntoService(pacienteService)
+	controladorAdjunto := handler.NewAdjuntoHandler(adjuntoService, tamanioMaximo)
+
+	r.privado.GET("/pacientes/:id/adjuntos", middleware.Autorizar(auth.PermisoHistoriaLeer), controladorAdjunto.GetAdjuntosByPaciente())
+	r.privado.GET("/pacientes/:id/adjuntos/:idAdjunto", middleware.Autorizar(auth.PermisoHistoriaLeer), controladorAdjunto.DescargarAdjunto())
+	r.privado.POST("/pacientes/:id/adjuntos", middleware.AutorizarPaciente(auth.PermisoHistoriaEditar, r.relacion), controladorAdjunto.SubirAdjunto())
+	r.privado.DELETE("/pacientes/:id/adjuntos/:idAdjunto", middleware.AutorizarPaciente(auth.PermisoHistoriaEditar, r.relacion), controladorAdjunto.DeleteAdjunto())
+}
+
+// nuevoAdjuntoService arma el service de adjuntos con el almacenamiento y el tamaño máximo de la configuración.
+// Lo comparten las rutas de adjuntos y las de consentimientos, que guardan ahí la firma.
+func (r *router) nuevoAdjuntoService(pacienteService paciente.Service) (adjunto.Service, int64) {
+	store, err := armado.NuevoBlobStore(r.cfg.Adjuntos)
+	if err != nil {
+		log.Fatalf("Error al configurar el almacenamiento de adjuntos: %v", err)
+	}
+	tamanioMaximo := int64(r.cfg.Adjuntos.MaxMB) << 20
+
+	adjuntoRepo := r.repos.Adjunto
+	return adjunto.NewService(adjuntoRepo, store, pacienteService, tamanioMaximo), tamanioMaximo
+}
+
+// buildConsentimientoRoutes mapea las rutas de plantillas y firmas de consentimiento informado.
+func (r *router) buildConsentimientoRoutes() {
+	pacienteRepo := r.repos.Paciente
+	pacienteService := paciente.NewService(pacienteRepo, r.uow, r.auditoria)
+	prestacionRepo := r.repos.Prestacion
+	prestacionService := prestacion.NewService(prestacionRepo)
+	adjuntoService, tamanioMaximo := r.nuevoAdjuntoService(pacienteService)
+	consentimientoRepo := r.repos.Consentimiento
+	consentimientoService := consentimiento.NewService(consentimientoRepo, pacienteService, prestacionService, adjuntoService)
+	controladorConsentimiento := handler.NewConsentimientoHandler(consentimientoService, tamanioMaximo)
+
+	r.privado.GET("/consentimientos/plantillas", middleware.Autorizar(auth.PermisoCatalogoLeer), controladorConsentimiento.GetPlantillas())
+	r.privado.GET("/consentimientos/plantillas/:id", middleware.Autorizar(auth.PermisoCatalogoLeer), controladorConsentimiento.GetPlantillaByID())
+	r.privado.POST("/consentimientos/plantillas", middleware.Autorizar(auth.PermisoCatalogoEditar), controladorConsentimiento.CreatePlantilla())
+	r.privado.GET("/pacientes/:id/consentimientos", middleware.Autorizar(auth.PermisoHistoriaLeer), controladorConsentimiento.GetConsentimientosByPaciente())
+	r.privado.POST("/pacientes/:id/consentimientos", middleware.AutorizarPaciente(auth.PermisoHistoriaEditar, r.relacion), controladorConsentimiento.RegistrarConsentimiento())
+}
+
+// configTurnos arma la política de turnos de la configuración; si está mal el servidor no arranca
+func (r *router) configTurnos() turno.Config {
+	cfg, err := armado.ConfigTurnos(r.cfg.Turnos)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return cfg
+}
+
+// buildPortalRoutes mapea las rutas del portal de pacientes. Van en un grupo aparte porque no usan el token del
+// personal: el paciente entra con su DNI y un código de un solo uso, y sólo ve y toca sus propios turnos.
+func (r *router) buildPortalRoutes() {
+	pacienteRepo := r.repos.Paciente
+	pacienteService := paciente.NewService(pacienteRepo, r.uow, r.auditoria)
+	odontologoRepo := r.repos.Odontologo
+	odontologoService := odontologo.NewService(odontologoRepo, r.uow, r.auditoria)
+	turnoRepo := r.repos.Turno
+	turnoService := turno.NewService(turnoRepo, r.uow, pacienteService, odontologoService, nil, nil, r.auditoria, r.configTurnos())
+	portalRepo := r.repos.Portal
+	portalService, err := portal.NewService(portalRepo, pacienteService, turnoService, odontologoService, armado.NuevoNotificador(r.cfg.Notificador), []byte(r.cfg.Portal.Secreto), armado.PoliticaPortal(r.cfg.Portal))
+	if err != nil {
+		log.Fatalf("Error al configurar el portal de pacientes: %v", err)
+	}
+	controladorPortal := handler.NewPortalHandler(portalService)
+
+	grupo := r.routerGroup.Group("/portal")
+	grupo.POST("/codigo", controladorPortal.SolicitarCodigo())
+	grupo.POST("/login", controladorPortal.IniciarSesion())
+
+	privado := grupo.Group("", middleware.AutenticarPaciente(portalService))
+	privado.GET("/disponibilidad", controladorPortal.GetDisponibilidad())
+	privado.GET("/turnos", controladorPortal.GetTurnos())
+	privado.POST("/turnos", controladorPortal.Reservar())
+	privado.POST("/turnos/:id/confirmar", controladorPortal.Confirmar())
+	privado.POST("/turnos/:id/cancelar", controladorPortal.Cancelar())
 }
 
 // API de prueba
@@ -0,0 +1,37 @@
+package adjunto
+
+import (
+	"io"
+	"time"
+)
+
+// tipos de adjunto admitidos
+const (
+	TipoRadiografia    = "radiografia"
+	TipoConsentimiento = "consentimiento"
+	TipoFoto           = "foto"
+	TipoOtro           = "otro"
+)
+
+// creamos la estructura del adjunto: en la base sólo va la metadata, el archivo queda en el BlobStore bajo Clave.
+type Adjunto struct {
+	ID          int       `json:"id"`
+	IdPaciente  int       `json:"id_paciente"`
+	Tipo        string    `json:"tipo"`
+	Nombre      string    `json:"nombre"`
+	ContentType string    `json:"content_type"`
+	Tamanio     int64     `json:"tamanio"`
+	Descripcion string    `json:"descripcion"`
+	Clave       string    `json:"-"`
+	FechaCarga  time.Time `json:"fecha_carga"`
+	Version     int       `json:"version"`
+}
+
+// AdjuntoRequest es lo que llega en la subida multipart. Contenido se lee una sola vez.
+type AdjuntoRequest struct {
+	Tipo        string
+	Nombre      string
+	Descripcion string
+	Tamanio     int64
+	Contenido   io.Reader
+}
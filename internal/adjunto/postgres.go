@@ -0,0 +1,29 @@
+package adjunto
+
+import "database/sql"
+
+// Queries de postgres: parámetros $1, $2, ..., marcas BOOLEAN y las altas devuelven el ID con RETURNING id
+var (
+	QueryInsertPostgres        = `INSERT INTO adjunto(id_paciente, tipo, nombre, content_type, tamanio, descripcion, clave, fecha_carga) VALUES($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`
+	QueryGetByPacientePostgres = `SELECT id, id_paciente, tipo, nombre, content_type, tamanio, descripcion, clave, fecha_carga, version FROM adjunto WHERE id_paciente = $1 ORDER BY fecha_carga DESC`
+	QueryGetByIdPostgres       = `SELECT id, id_paciente, tipo, nombre, content_type, tamanio, descripcion, clave, fecha_carga, version FROM adjunto WHERE id = $1 AND id_paciente = $2`
+	QueryDeletePostgres        = `DELETE FROM adjunto WHERE id = $1 AND id_paciente = $2 AND version = $3`
+	QueryExistsPostgres        = `SELECT COUNT(*) FROM adjunto WHERE id = $1 AND id_paciente = $2`
+)
+
+var consultasPostgres = consultas{
+	insert:        QueryInsertPostgres,
+	getByPaciente: QueryGetByPacientePostgres,
+	getById:       QueryGetByIdPostgres,
+	delete:        QueryDeletePostgres,
+	exists:        QueryExistsPostgres,
+	returning:     true,
+}
+
+// NewRepositoryPostgres instancia repositorio sobre postgres
+func NewRepositoryPostgres(db *sql.DB) Repository {
+	return &repository{
+		db: db,
+		q:  consultasPostgres,
+	}
+}
@@ -0,0 +1,198 @@
+package adjunto
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"finalgo/pkg/basedatos"
+)
+
+// Errores
+var (
+	ErrEmptyList   = errors.New("la lista de adjuntos esta vacia")
+	ErrNotFound    = errors.New("adjunto no encontrado")
+	ErrStatement   = errors.New("sentencia incorrecta")
+	ErrExec        = errors.New("ejecución SQL incorrecta")
+	ErrLastId      = errors.New("error al obtener el último ID")
+	ErrTipo        = errors.New("tipo de adjunto inválido")
+	ErrTamanio     = errors.New("el archivo supera el tamaño permitido")
+	ErrContentType = errors.New("tipo de archivo no admitido")
+	ErrArchivo     = errors.New("error al guardar o leer el archivo")
+	ErrVersion     = errors.New("el adjunto cambió desde que se leyó, hay que volver a consultarlo")
+)
+
+// Queries a usar en cada función. La baja sólo se aplica si la versión es la que leyó quien la pide.
+var (
+	QueryInsert        = `INSERT INTO adjunto(id_paciente, tipo, nombre, content_type, tamanio, descripcion, clave, fecha_carga) VALUES(?,?,?,?,?,?,?,?)`
+	QueryGetByPaciente = `SELECT id, id_paciente, tipo, nombre, content_type, tamanio, descripcion, clave, fecha_carga, version FROM adjunto WHERE id_paciente = ? ORDER BY fecha_carga DESC`
+	QueryGetById       = `SELECT id, id_paciente, tipo, nombre, content_type, tamanio, descripcion, clave, fecha_carga, version FROM adjunto WHERE id = ? AND id_paciente = ?`
+	QueryDelete        = `DELETE FROM adjunto WHERE id = ? AND id_paciente = ? AND version = ?`
+	QueryExists        = `SELECT COUNT(*) FROM adjunto WHERE id = ? AND id_paciente = ?`
+)
+
+// consultas son las queries de cada función en el dialecto del motor: MySQL y SQLite usan las de arriba y
+// PostgreSQL las de postgres.go
+type consultas struct {
+	insert        string
+	getByPaciente string
+	getById       string
+	delete        string
+	exists        string
+	// las altas devuelven el ID con RETURNING id, porque el motor no tiene LastInsertId
+	returning bool
+}
+
+var consultasMySQL = consultas{
+	insert:        QueryInsert,
+	getByPaciente: QueryGetByPaciente,
+	getById:       QueryGetById,
+	delete:        QueryDelete,
+	exists:        QueryExists,
+}
+
+// defino la interfaz para que se apliquen siempre todos los métodos
+type Repository interface {
+	GetAdjuntosByPaciente(ctx context.Context, idPaciente int) ([]Adjunto, error)
+	GetAdjuntoByID(ctx context.Context, idPaciente int, id int) (Adjunto, error)
+	CreateAdjunto(ctx context.Context, a Adjunto) (Adjunto, error)
+	DeleteAdjunto(ctx context.Context, idPaciente int, id int, version int) error
+}
+
+// estructura repositorio con base de datos mysql
+type repository struct {
+	db *sql.DB
+	q  consultas
+}
+
+// NewRepositoryMySql instancia repositorio
+func NewRepositoryMySql(db *sql.DB) Repository {
+	return &repository{
+		db: db,
+		q:  consultasMySQL,
+	}
+}
+
+// NewRepositorySqlite instancia repositorio sobre sqlite, con las mismas consultas
+func NewRepositorySqlite(db *sql.DB) Repository {
+	return &repository{
+		db: db,
+		q:  consultasMySQL,
+	}
+}
+
+// obtener los adjuntos del paciente, del más nuevo al más viejo
+func (r *repository) GetAdjuntosByPaciente(ctx context.Context, idPaciente int) ([]Adjunto, error) {
+	rows, err := r.db.QueryContext(ctx, r.q.getByPaciente, idPaciente)
+	if err != nil {
+		return []Adjunto{}, ErrEmptyList
+	}
+	defer rows.Close()
+
+	adjuntos := []Adjunto{}
+	for rows.Next() {
+		adjunto, err := scanAdjunto(rows)
+		if err != nil {
+			return []Adjunto{}, ErrExec
+		}
+		adjuntos = append(adjuntos, adjunto)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []Adjunto{}, ErrExec
+	}
+
+	return adjuntos, nil
+}
+
+// obtener un adjunto del paciente por ID
+func (r *repository) GetAdjuntoByID(ctx context.Context, idPaciente int, id int) (Adjunto, error) {
+	adjunto, err := scanAdjunto(r.db.QueryRowContext(ctx, r.q.getById, id, idPaciente))
+	if err != nil {
+		return Adjunto{}, ErrNotFound
+	}
+	return adjunto, nil
+}
+
+// crear adjunto en BD
+func (r *repository) CreateAdjunto(ctx context.Context, adjunto Adjunto) (Adjunto, error) {
+	statement, err := r.db.PrepareContext(ctx, r.q.insert)
+	if err != nil {
+		return Adjunto{}, ErrStatement
+	}
+	defer statement.Close()
+
+	result, err := basedatos.InsertarPreparado(
+		ctx,
+		statement,
+		r.q.returning,
+		adjunto.IdPaciente,
+		adjunto.Tipo,
+		adjunto.Nombre,
+		adjunto.ContentType,
+		adjunto.Tamanio,
+		adjunto.Descripcion,
+		adjunto.Clave,
+		adjunto.FechaCarga,
+	)
+	if err != nil {
+		return Adjunto{}, ErrExec
+	}
+
+	// obtengo el ID del registro y lo devuelvo como dato
+	lastId, err := result.LastInsertId()
+	if err != nil {
+		return Adjunto{}, ErrLastId
+	}
+	adjunto.ID = int(lastId)
+	adjunto.Version = 1
+	return adjunto, nil
+}
+
+// eliminar registro
+func (r *repository) DeleteAdjunto(ctx context.Context, idPaciente int, id int, version int) error {
+	result, err := r.db.ExecContext(ctx, r.q.delete, id, idPaciente, version)
+	if err != nil {
+		return ErrStatement
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return ErrExec
+	}
+	if rowsAffected > 0 {
+		return nil
+	}
+
+	// sin filas afectadas, distingue un adjunto inexistente de uno que otro usuario modificó antes
+	var cantidad int
+	if err := r.db.QueryRowContext(ctx, r.q.exists, id, idPaciente).Scan(&cantidad); err != nil {
+		return ErrExec
+	}
+	if cantidad < 1 {
+		return ErrNotFound
+	}
+	return ErrVersion
+}
+
+// scanner lo cumplen tanto *sql.Row como *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAdjunto(s scanner) (Adjunto, error) {
+	var adjunto Adjunto
+	err := s.Scan(
+		&adjunto.ID,
+		&adjunto.IdPaciente,
+		&adjunto.Tipo,
+		&adjunto.Nombre,
+		&adjunto.ContentType,
+		&adjunto.Tamanio,
+		&adjunto.Descripcion,
+		&adjunto.Clave,
+		&adjunto.FechaCarga,
+		&adjunto.Version,
+	)
+	return adjunto, err
+}
@@ -0,0 +1,220 @@
+package adjunto
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"finalgo/internal/paciente"
+	"finalgo/pkg/blobstore"
+)
+
+// tipos de archivo admitidos y la extensión con la que se guardan
+var contentTypesPermitidos = map[string]string{
+	"image/jpeg":        ".jpg",
+	"image/png":         ".png",
+	"image/webp":        ".webp",
+	"application/pdf":   ".pdf",
+	"application/dicom": ".dcm",
+}
+
+// defino la interfaz para que se apliquen siempre todos los métodos
+type Service interface {
+	GetAdjuntosByPaciente(ctx context.Context, idPaciente int) ([]Adjunto, error)
+	GetAdjuntoByID(ctx context.Context, idPaciente int, id int) (Adjunto, error)
+	SubirAdjunto(ctx context.Context, a AdjuntoRequest, idPaciente int) (Adjunto, error)
+	DescargarAdjunto(ctx context.Context, idPaciente int, id int) (Adjunto, io.ReadCloser, error)
+	DeleteAdjunto(ctx context.Context, idPaciente int, id int, version int) error
+}
+
+// estrucutra service que contará con el repositorio de metadata y el almacenamiento de los archivos
+type service struct {
+	r             Repository
+	store         blobstore.BlobStore
+	ps            paciente.Service
+	tamanioMaximo int64
+}
+
+// función para instanciar service. tamanioMaximo está en bytes.
+func NewService(r Repository, store blobstore.BlobStore, ps paciente.Service, tamanioMaximo int64) Service {
+	return &service{
+		r,
+		store,
+		ps,
+		tamanioMaximo,
+	}
+}
+
+func (s *service) GetAdjuntosByPaciente(ctx context.Context, idPaciente int) ([]Adjunto, error) {
+	adjuntos, err := s.r.GetAdjuntosByPaciente(ctx, idPaciente)
+	if err != nil {
+		log.Println("log de error en adjuntos del paciente", err.Error())
+		return []Adjunto{}, ErrEmptyList
+	}
+	return adjuntos, nil
+}
+
+func (s *service) GetAdjuntoByID(ctx context.Context, idPaciente int, id int) (Adjunto, error) {
+	a, err := s.r.GetAdjuntoByID(ctx, idPaciente, id)
+	if err != nil {
+		log.Println("log de error por adjunto inexistente", err.Error())
+		return Adjunto{}, ErrNotFound
+	}
+	return a, nil
+}
+
+// SubirAdjunto valida tipo, tamaño y contenido, guarda el archivo en el BlobStore y después registra la metadata.
+// El content type se detecta mirando los primeros bytes, no se confía en lo que declara el cliente.
+func (s *service) SubirAdjunto(ctx context.Context, adjuntoRequest AdjuntoRequest, idPaciente int) (Adjunto, error) {
+	if !tipoValido(adjuntoRequest.Tipo) {
+		return Adjunto{}, ErrTipo
+	}
+	if adjuntoRequest.Tamanio > s.tamanioMaximo {
+		return Adjunto{}, ErrTamanio
+	}
+	if _, err := s.ps.GetPacienteByID(ctx, idPaciente); err != nil {
+		log.Println("log de error por paciente inexistente", err.Error())
+		return Adjunto{}, paciente.ErrNotFound
+	}
+
+	// leo el comienzo del archivo para detectar el tipo y después lo vuelvo a unir con el resto
+	cabecera := make([]byte, 512)
+	n, err := io.ReadFull(adjuntoRequest.Contenido, cabecera)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		log.Println("log de error al leer adjunto", err.Error())
+		return Adjunto{}, ErrArchivo
+	}
+	cabecera = cabecera[:n]
+	contentType := detectarContentType(cabecera)
+	extension, ok := contentTypesPermitidos[contentType]
+	if !ok {
+		return Adjunto{}, ErrContentType
+	}
+
+	clave, err := nuevaClave(idPaciente, extension)
+	if err != nil {
+		log.Println("log de error al generar clave de adjunto", err.Error())
+		return Adjunto{}, ErrArchivo
+	}
+	contenido := &lectorLimitado{
+		r:        io.MultiReader(bytes.NewReader(cabecera), adjuntoRequest.Contenido),
+		restante: s.tamanioMaximo,
+	}
+	if err := s.store.Put(ctx, clave, contenido, adjuntoRequest.Tamanio, contentType); err != nil {
+		log.Println("log de error al guardar adjunto", err.Error())
+		if errors.Is(err, ErrTamanio) {
+			return Adjunto{}, ErrTamanio
+		}
+		return Adjunto{}, ErrArchivo
+	}
+
+	adjunto := Adjunto{
+		IdPaciente:  idPaciente,
+		Tipo:        adjuntoRequest.Tipo,
+		Nombre:      filepath.Base(adjuntoRequest.Nombre),
+		ContentType: contentType,
+		Tamanio:     contenido.leidos,
+		Descripcion: adjuntoRequest.Descripcion,
+		Clave:       clave,
+		FechaCarga:  time.Now(),
+	}
+	response, err := s.r.CreateAdjunto(ctx, adjunto)
+	if err != nil {
+		// sin metadata el archivo queda huérfano, lo saco del almacenamiento
+		log.Println("error al crear adjunto")
+		if err := s.store.Delete(ctx, clave); err != nil {
+			log.Println("log de error al borrar archivo huérfano", clave, err.Error())
+		}
+		return Adjunto{}, ErrExec
+	}
+	return response, nil
+}
+
+// DescargarAdjunto devuelve la metadata y el contenido del archivo; quien lo llama tiene que cerrarlo.
+func (s *service) DescargarAdjunto(ctx context.Context, idPaciente int, id int) (Adjunto, io.ReadCloser, error) {
+	a, err := s.GetAdjuntoByID(ctx, idPaciente, id)
+	if err != nil {
+		return Adjunto{}, nil, err
+	}
+	contenido, err := s.store.Get(ctx, a.Clave)
+	if err != nil {
+		log.Println("log de error al leer adjunto", a.Clave, err.Error())
+		return Adjunto{}, nil, ErrArchivo
+	}
+	return a, contenido, nil
+}
+
+// DeleteAdjunto borra la metadata, si la versión es la leída, y después el archivo. Si falla el borrado del archivo queda en el log, el adjunto ya no es visible.
+func (s *service) DeleteAdjunto(ctx context.Context, idPaciente int, id int, version int) error {
+	a, err := s.GetAdjuntoByID(ctx, idPaciente, id)
+	if err != nil {
+		return err
+	}
+	if err := s.r.DeleteAdjunto(ctx, idPaciente, id, version); err != nil {
+		log.Println("log de error borrado de adjunto", err.Error())
+		if errors.Is(err, ErrVersion) {
+			return ErrVersion
+		}
+		return ErrNotFound
+	}
+	if err := s.store.Delete(ctx, a.Clave); err != nil {
+		log.Println("log de error al borrar archivo de adjunto", a.Clave, err.Error())
+	}
+	return nil
+}
+
+func tipoValido(tipo string) bool {
+	switch tipo {
+	case TipoRadiografia, TipoConsentimiento, TipoFoto, TipoOtro:
+		return true
+	}
+	return false
+}
+
+// detectarContentType usa la detección de net/http y agrega DICOM, que tiene la marca "DICM" en el byte 128
+func detectarContentType(cabecera []byte) string {
+	if len(cabecera) >= 132 && string(cabecera[128:132]) == "DICM" {
+		return "application/dicom"
+	}
+	contentType := http.DetectContentType(cabecera)
+	if i := strings.Index(contentType, ";"); i >= 0 {
+		contentType = contentType[:i]
+	}
+	return contentType
+}
+
+// nuevaClave arma una clave aleatoria agrupada por paciente y año: pacientes/15/2024/3f9a...e1.jpg
+func nuevaClave(idPaciente int, extension string) (string, error) {
+	aleatorio := make([]byte, 16)
+	if _, err := rand.Read(aleatorio); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("pacientes/%d/%d/%s%s", idPaciente, time.Now().Year(), hex.EncodeToString(aleatorio), extension), nil
+}
+
+// lectorLimitado corta la lectura con ErrTamanio si el archivo resulta más grande que lo permitido,
+// por si el tamaño declarado en la subida no era real
+type lectorLimitado struct {
+	r        io.Reader
+	restante int64
+	leidos   int64
+}
+
+func (l *lectorLimitado) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.leidos += int64(n)
+	l.restante -= int64(n)
+	if l.restante < 0 {
+		return n, ErrTamanio
+	}
+	return n, err
+}
@@ -0,0 +1,63 @@
+// Package armado arma, a partir de la configuración, las piezas que comparten el servidor y clinicctl: los
+// repositorios del motor de la base y las dependencias de los services (política de turnos, almacenamiento de
+// adjuntos, notificador y política del portal).
+package armado
+
+import (
+	"fmt"
+	"time"
+
+	"finalgo/internal/portal"
+	"finalgo/internal/turno"
+	"finalgo/pkg/blobstore"
+	"finalgo/pkg/config"
+	"finalgo/pkg/notificador"
+)
+
+// ConfigTurnos arma qué hacer sin consentimiento, el horario de atención y la política de cancelaciones y ausencias.
+// La usan el servidor y clinicctl, para que los reportes apliquen la misma política que la API.
+func ConfigTurnos(cfg config.Turnos) (turno.Config, error) {
+	horario, err := turno.ParseHorario(cfg.HoraInicio, cfg.HoraFin, cfg.DuracionMin, cfg.Dias)
+	if err != nil {
+		return turno.Config{}, fmt.Errorf("error en el horario de turnos: %w", err)
+	}
+	cancelacion, err := turno.ParsePoliticaCancelacion(cfg.CancelacionHoras, cfg.MaxAusencias, cfg.AusenciasMeses, cfg.RestriccionAusencias, cfg.Penalidad)
+	if err != nil {
+		return turno.Config{}, fmt.Errorf("error en la política de cancelación de turnos: %w", err)
+	}
+	return turno.Config{
+		ModoConsentimiento: cfg.Consentimiento,
+		Horario:            horario,
+		Cancelacion:        cancelacion,
+	}, nil
+}
+
+// NuevoBlobStore arma el almacenamiento de archivos: local o cualquier servicio compatible con S3
+func NuevoBlobStore(cfg config.Adjuntos) (blobstore.BlobStore, error) {
+	if cfg.Almacenamiento == config.AdjuntosS3 {
+		return blobstore.NewS3(blobstore.ConfigS3{
+			Endpoint:  cfg.S3.Endpoint,
+			Region:    cfg.S3.Region,
+			Bucket:    cfg.S3.Bucket,
+			AccessKey: cfg.S3.AccessKey,
+			SecretKey: cfg.S3.SecretKey,
+		}, nil)
+	}
+	return blobstore.NewLocal(cfg.Directorio)
+}
+
+// NuevoNotificador arma el envío de mensajes a pacientes: webhook a la pasarela o sólo log
+func NuevoNotificador(cfg config.Notificador) notificador.Notifier {
+	if cfg.Tipo == config.NotificadorWebhook {
+		return notificador.NewWebhook(cfg.URL, nil)
+	}
+	return notificador.NewLog()
+}
+
+// PoliticaPortal toma la política por defecto del portal y le aplica las horas y días de la configuración
+func PoliticaPortal(cfg config.Portal) portal.Politica {
+	politica := portal.PoliticaPorDefecto()
+	politica.AnticipacionMinima = time.Duration(cfg.AnticipacionHoras) * time.Hour
+	politica.VentanaMaxima = time.Duration(cfg.VentanaDias) * 24 * time.Hour
+	return politica
+}
@@ -0,0 +1,57 @@
+package armado
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"finalgo/internal/contrato/contratotest"
+	"finalgo/internal/paciente"
+	"finalgo/pkg/config"
+)
+
+func TestConfigTurnos(t *testing.T) {
+	casos := []struct {
+		nombre string
+		cfg    config.Turnos
+		valida bool
+	}{
+		{"sin configurar", config.Turnos{}, true},
+		{"horario propio", config.Turnos{HoraInicio: "08:00", HoraFin: "14:00", DuracionMin: "20"}, true},
+		{"hora inválida", config.Turnos{HoraInicio: "25:00"}, false},
+		{"duración inválida", config.Turnos{DuracionMin: "media hora"}, false},
+		{"horas de cancelación inválidas", config.Turnos{CancelacionHoras: "muchas"}, false},
+	}
+
+	for _, caso := range casos {
+		_, err := ConfigTurnos(caso.cfg)
+		if (err == nil) != caso.valida {
+			t.Fatalf("%s: ConfigTurnos = %v, se esperaba válida=%v", caso.nombre, err, caso.valida)
+		}
+	}
+}
+
+func TestNuevosRepositoriosMotorDesconocido(t *testing.T) {
+	if _, err := NuevosRepositorios(nil, "oracle"); err == nil {
+		t.Fatal("se aceptó un motor desconocido")
+	}
+}
+
+func TestNuevosRepositoriosMemoria(t *testing.T) {
+	ctx := context.Background()
+	db := contratotest.Base(t, config.MotorSQLite)
+	if _, err := NuevosRepositoriosMemoria(ctx, db, config.MotorSQLite); err != nil {
+		t.Fatalf("con la base vacía tiene que aceptar la memoria: %v", err)
+	}
+
+	repos, err := NuevosRepositorios(db, config.MotorSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repos.Paciente.CreatePaciente(ctx, paciente.Paciente{Nombre: "Ana", Apellido: "Gómez", DNI: "30111222", Alta: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NuevosRepositoriosMemoria(ctx, db, config.MotorSQLite); err == nil {
+		t.Fatal("con pacientes en la base no se puede usar la memoria: los IDs se pisarían")
+	}
+}
@@ -0,0 +1,136 @@
+package armado
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"finalgo/internal/adjunto"
+	"finalgo/internal/auditoria"
+	"finalgo/internal/consentimiento"
+	"finalgo/internal/facturacion"
+	"finalgo/internal/liquidacion"
+	"finalgo/internal/obrasocial"
+	"finalgo/internal/odontologo"
+	"finalgo/internal/paciente"
+	"finalgo/internal/portal"
+	"finalgo/internal/prestacion"
+	"finalgo/internal/turno"
+	"finalgo/internal/usuario"
+	"finalgo/pkg/config"
+)
+
+// Repositorios son los repositorios de todos los dominios sobre una misma base
+type Repositorios struct {
+	Adjunto        adjunto.Repository
+	Auditoria      auditoria.Repository
+	Consentimiento consentimiento.Repository
+	Facturacion    facturacion.Repository
+	Liquidacion    liquidacion.Repository
+	ObraSocial     obrasocial.Repository
+	Odontologo     odontologo.Repository
+	Paciente       paciente.Repository
+	Portal         portal.Repository
+	Prestacion     prestacion.Repository
+	Turno          turno.Repository
+	Usuario        usuario.Repository
+}
+
+// NuevosRepositorios instancia los repositorios del motor de la base (mysql, postgres o sqlite)
+func NuevosRepositorios(db *sql.DB, motor string) (Repositorios, error) {
+	switch motor {
+	case config.MotorMySQL:
+		return Repositorios{
+			Adjunto:        adjunto.NewRepositoryMySql(db),
+			Auditoria:      auditoria.NewRepositoryMySql(db),
+			Consentimiento: consentimiento.NewRepositoryMySql(db),
+			Facturacion:    facturacion.NewRepositoryMySql(db),
+			Liquidacion:    liquidacion.NewRepositoryMySql(db),
+			ObraSocial:     obrasocial.NewRepositoryMySql(db),
+			Odontologo:     odontologo.NewRepositoryMySql(db),
+			Paciente:       paciente.NewRepositoryMySql(db),
+			Portal:         portal.NewRepositoryMySql(db),
+			Prestacion:     prestacion.NewRepositoryMySql(db),
+			Turno:          turno.NewRepositoryMySql(db),
+			Usuario:        usuario.NewRepositoryMySql(db),
+		}, nil
+	case config.MotorPostgres:
+		return Repositorios{
+			Adjunto:        adjunto.NewRepositoryPostgres(db),
+			Auditoria:      auditoria.NewRepositoryPostgres(db),
+			Consentimiento: consentimiento.NewRepositoryPostgres(db),
+			Facturacion:    facturacion.NewRepositoryPostgres(db),
+			Liquidacion:    liquidacion.NewRepositoryPostgres(db),
+			ObraSocial:     obrasocial.NewRepositoryPostgres(db),
+			Odontologo:     odontologo.NewRepositoryPostgres(db),
+			Paciente:       paciente.NewRepositoryPostgres(db),
+			Portal:         portal.NewRepositoryPostgres(db),
+			Prestacion:     prestacion.NewRepositoryPostgres(db),
+			Turno:          turno.NewRepositoryPostgres(db),
+			Usuario:        usuario.NewRepositoryPostgres(db),
+		}, nil
+	case config.MotorSQLite:
+		return Repositorios{
+			Adjunto:        adjunto.NewRepositorySqlite(db),
+			Auditoria:      auditoria.NewRepositorySqlite(db),
+			Consentimiento: consentimiento.NewRepositorySqlite(db),
+			Facturacion:    facturacion.NewRepositorySqlite(db),
+			Liquidacion:    liquidacion.NewRepositorySqlite(db),
+			ObraSocial:     obrasocial.NewRepositorySqlite(db),
+			Odontologo:     odontologo.NewRepositorySqlite(db),
+			Paciente:       paciente.NewRepositorySqlite(db),
+			Portal:         portal.NewRepositorySqlite(db),
+			Prestacion:     prestacion.NewRepositorySqlite(db),
+			Turno:          turno.NewRepositorySqlite(db),
+			Usuario:        usuario.NewRepositorySqlite(db),
+		}, nil
+	}
+	return Repositorios{}, fmt.Errorf("motor de base desconocido: %q", motor)
+}
+
+// conDatosDeMemoria cuenta las filas que guardan pacientes, odontólogos o turnos, o que apuntan a ellos. Las foreign
+// keys de cargo e item_liquidacion quedan cubiertas: sin cargos no puede haber ítems ni pagos imputados. La auditoría
+// también entra porque guarda los IDs de esas entidades.
+var conDatosDeMemoria = []string{
+	`SELECT COUNT(*) FROM paciente`,
+	`SELECT COUNT(*) FROM odontologo`,
+	`SELECT COUNT(*) FROM turno`,
+	`SELECT COUNT(*) FROM alerta_medica`,
+	`SELECT COUNT(*) FROM telefono_paciente`,
+	`SELECT COUNT(*) FROM contacto_emergencia`,
+	`SELECT COUNT(*) FROM responsable_paciente`,
+	`SELECT COUNT(*) FROM odontologo_especialidad`,
+	`SELECT COUNT(*) FROM incidencia_turno`,
+	`SELECT COUNT(*) FROM cobertura_paciente`,
+	`SELECT COUNT(*) FROM cargo`,
+	`SELECT COUNT(*) FROM pago`,
+	`SELECT COUNT(*) FROM adjunto`,
+	`SELECT COUNT(*) FROM consentimiento`,
+	`SELECT COUNT(*) FROM codigo_portal`,
+	`SELECT COUNT(*) FROM usuario WHERE id_odontologo IS NOT NULL`,
+	`SELECT COUNT(*) FROM auditoria`,
+}
+
+// NuevosRepositoriosMemoria deja pacientes, odontólogos y turnos en memoria y el resto en la base. Sólo se acepta
+// con la base vacía de esos datos: un ID de memoria podría coincidir con una fila real y colgarle coberturas o
+// adjuntos a otro paciente. Las funciones que escriben en tablas con foreign keys hacia ellos no se habilitan. La
+// auditoría sigue en la base, fuera de la memoria: si no se puede grabar, el cambio en memoria no se deshace.
+func NuevosRepositoriosMemoria(ctx context.Context, db *sql.DB, motor string) (Repositorios, error) {
+	repos, err := NuevosRepositorios(db, motor)
+	if err != nil {
+		return Repositorios{}, err
+	}
+	for _, query := range conDatosDeMemoria {
+		var cantidad int
+		if err := db.QueryRowContext(ctx, query).Scan(&cantidad); err != nil {
+			return Repositorios{}, fmt.Errorf("no se pudo verificar la base para REPOSITORIOS=memoria: %w", err)
+		}
+		if cantidad > 0 {
+			return Repositorios{}, fmt.Errorf("REPOSITORIOS=memoria necesita una base sin pacientes, odontólogos, turnos ni datos que los referencien (%s)", query)
+		}
+	}
+	repos.Paciente = paciente.NewRepositoryMemoria()
+	repos.Odontologo = odontologo.NewRepositoryMemoria()
+	repos.Turno = turno.NewRepositoryMemoria()
+	return repos, nil
+}
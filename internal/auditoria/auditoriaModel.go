@@ -0,0 +1,45 @@
+package auditoria
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// acciones que se registran
+const (
+	AccionAlta         = "alta"
+	AccionModificacion = "modificacion"
+	AccionBaja         = "baja"
+	AccionRestauracion = "restauracion"
+)
+
+// quién hizo el cambio: un usuario del personal, un paciente desde el portal o el propio sistema (tareas sin usuario)
+const (
+	ActorUsuario  = "usuario"
+	ActorPaciente = "paciente"
+	ActorSistema  = "sistema"
+)
+
+// Registro es un cambio de datos: quién lo hizo, cuándo, sobre qué entidad y cómo estaba antes y cómo quedó. En un
+// alta Antes va vacío y en una baja Despues.
+type Registro struct {
+	ID        int             `json:"id"`
+	FechaHora time.Time       `json:"fecha_hora"`
+	TipoActor string          `json:"tipo_actor"`
+	IdActor   int             `json:"id_actor,omitempty"`
+	Actor     string          `json:"actor,omitempty"`
+	Accion    string          `json:"accion"`
+	Entidad   string          `json:"entidad"`
+	IdEntidad int             `json:"id_entidad"`
+	Antes     json.RawMessage `json:"antes,omitempty"`
+	Despues   json.RawMessage `json:"despues,omitempty"`
+}
+
+// Filtro de la consulta de auditoría. Los campos en cero no filtran; Hasta es exclusivo.
+type Filtro struct {
+	Entidad   string
+	IdEntidad int
+	IdUsuario int
+	Desde     time.Time
+	Hasta     time.Time
+}
@@ -0,0 +1,25 @@
+package auditoria
+
+import "database/sql"
+
+// Queries de postgres: parámetros $1, $2, ..., marcas BOOLEAN y las altas devuelven el ID con RETURNING id
+var (
+	QueryInsertPostgres = `INSERT INTO auditoria(fecha_hora, tipo_actor, id_actor, actor, accion, entidad, id_entidad, antes, despues) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`
+	QueryBuscarPostgres = `SELECT id, fecha_hora, tipo_actor, id_actor, actor, accion, entidad, id_entidad, antes, despues FROM auditoria
+		WHERE ($1 = '' OR entidad = $2) AND ($3 = 0 OR id_entidad = $4) AND ($5 = 0 OR (tipo_actor = 'usuario' AND id_actor = $6))
+		AND fecha_hora >= $7 AND fecha_hora < $8 ORDER BY fecha_hora DESC, id DESC LIMIT $9`
+)
+
+var consultasPostgres = consultas{
+	insert:    QueryInsertPostgres,
+	buscar:    QueryBuscarPostgres,
+	returning: true,
+}
+
+// NewRepositoryPostgres instancia repositorio sobre postgres
+func NewRepositoryPostgres(db *sql.DB) Repository {
+	return &repository{
+		db: db,
+		q:  consultasPostgres,
+	}
+}
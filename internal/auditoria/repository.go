@@ -0,0 +1,162 @@
+package auditoria
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"finalgo/pkg/basedatos"
+	"finalgo/pkg/transaccion"
+)
+
+// Errores
+var (
+	ErrStatement = errors.New("sentencia incorrecta")
+	ErrExec      = errors.New("ejecución SQL incorrecta")
+	ErrLastId    = errors.New("error al obtener el último ID")
+	ErrFiltro    = errors.New("filtro de auditoría inválido")
+)
+
+// Queries a usar en cada función. La tabla es de sólo inserción: no hay update ni delete, y en la base los impiden
+// los triggers.
+var (
+	QueryInsert = `INSERT INTO auditoria(fecha_hora, tipo_actor, id_actor, actor, accion, entidad, id_entidad, antes, despues) VALUES(?,?,?,?,?,?,?,?,?)`
+	QueryBuscar = `SELECT id, fecha_hora, tipo_actor, id_actor, actor, accion, entidad, id_entidad, antes, despues FROM auditoria
+		WHERE (? = '' OR entidad = ?) AND (? = 0 OR id_entidad = ?) AND (? = 0 OR (tipo_actor = 'usuario' AND id_actor = ?))
+		AND fecha_hora >= ? AND fecha_hora < ? ORDER BY fecha_hora DESC, id DESC LIMIT ?`
+)
+
+// consultas son las queries de cada función en el dialecto del motor: MySQL y SQLite usan las de arriba y
+// PostgreSQL las de postgres.go
+type consultas struct {
+	insert string
+	buscar string
+	// las altas devuelven el ID con RETURNING id, porque el motor no tiene LastInsertId
+	returning bool
+}
+
+var consultasMySQL = consultas{
+	insert: QueryInsert,
+	buscar: QueryBuscar,
+}
+
+// máximo de registros que devuelve una consulta; para ver más hay que acotar el período
+const maxRegistros = 1000
+
+// defino la interfaz para que se apliquen siempre todos los métodos
+type Repository interface {
+	CreateRegistro(ctx context.Context, r Registro) (Registro, error)
+	Buscar(ctx context.Context, f Filtro) ([]Registro, error)
+}
+
+// estructura repositorio con base de datos mysql
+type repository struct {
+	db *sql.DB
+	q  consultas
+}
+
+// NewRepositoryMySql instancia repositorio
+func NewRepositoryMySql(db *sql.DB) Repository {
+	return &repository{
+		db: db,
+		q:  consultasMySQL,
+	}
+}
+
+// NewRepositorySqlite instancia repositorio sobre sqlite, con las mismas consultas
+func NewRepositorySqlite(db *sql.DB) Repository {
+	return &repository{
+		db: db,
+		q:  consultasMySQL,
+	}
+}
+
+// conexion devuelve la transacción de la unidad de trabajo en curso, si la hay, o la base
+func (r *repository) conexion(ctx context.Context) transaccion.Ejecutor {
+	return transaccion.Conexion(ctx, r.db)
+}
+
+// guardar un registro de auditoría
+func (r *repository) CreateRegistro(ctx context.Context, registro Registro) (Registro, error) {
+	result, err := basedatos.Insertar(ctx, r.conexion(ctx), r.q.returning, r.q.insert,
+		registro.FechaHora,
+		registro.TipoActor,
+		sql.NullInt64{Int64: int64(registro.IdActor), Valid: registro.IdActor != 0},
+		registro.Actor,
+		registro.Accion,
+		registro.Entidad,
+		registro.IdEntidad,
+		nullJSON(registro.Antes),
+		nullJSON(registro.Despues),
+	)
+	if err != nil {
+		return Registro{}, ErrExec
+	}
+
+	lastId, err := result.LastInsertId()
+	if err != nil {
+		return Registro{}, ErrLastId
+	}
+	registro.ID = int(lastId)
+	return registro, nil
+}
+
+// buscar los registros que cumplen el filtro, del más nuevo al más viejo
+func (r *repository) Buscar(ctx context.Context, f Filtro) ([]Registro, error) {
+	hasta := f.Hasta
+	if hasta.IsZero() {
+		hasta = time.Now().Add(time.Minute)
+	}
+	rows, err := r.conexion(ctx).QueryContext(ctx, r.q.buscar,
+		f.Entidad, f.Entidad,
+		f.IdEntidad, f.IdEntidad,
+		f.IdUsuario, f.IdUsuario,
+		f.Desde, hasta,
+		maxRegistros,
+	)
+	if err != nil {
+		return []Registro{}, ErrStatement
+	}
+	defer rows.Close()
+
+	registros := []Registro{}
+	for rows.Next() {
+		var registro Registro
+		var idActor sql.NullInt64
+		var antes, despues []byte
+		err := rows.Scan(
+			&registro.ID,
+			&registro.FechaHora,
+			&registro.TipoActor,
+			&idActor,
+			&registro.Actor,
+			&registro.Accion,
+			&registro.Entidad,
+			&registro.IdEntidad,
+			&antes,
+			&despues,
+		)
+		if err != nil {
+			return []Registro{}, ErrExec
+		}
+		registro.IdActor = int(idActor.Int64)
+		registro.Antes = antes
+		registro.Despues = despues
+		registros = append(registros, registro)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []Registro{}, ErrExec
+	}
+
+	return registros, nil
+}
+
+// nullJSON guarda NULL cuando no hay snapshot
+func nullJSON(valor []byte) interface{} {
+	if len(valor) == 0 {
+		return nil
+	}
+	return string(valor)
+}
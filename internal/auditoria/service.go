@@ -0,0 +1,103 @@
+package auditoria
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"finalgo/pkg/auth"
+)
+
+// defino la interfaz para que se apliquen siempre todos los métodos. Alta, Modificacion, Baja y Restauracion los
+// llaman los services de paciente, odontólogo y turno dentro de la misma transacción que el cambio; el actor sale del
+// contexto del pedido.
+type Service interface {
+	Alta(ctx context.Context, entidad string, id int, despues interface{}) error
+	Modificacion(ctx context.Context, entidad string, id int, antes, despues interface{}) error
+	Baja(ctx context.Context, entidad string, id int, antes interface{}) error
+	Restauracion(ctx context.Context, entidad string, id int, despues interface{}) error
+	Buscar(ctx context.Context, f Filtro) ([]Registro, error)
+}
+
+// estrucutra service que contará con un repositorio
+type service struct {
+	r Repository
+}
+
+// función para instanciar service
+func NewService(r Repository) Service {
+	return &service{r}
+}
+
+func (s *service) Alta(ctx context.Context, entidad string, id int, despues interface{}) error {
+	return s.registrar(ctx, AccionAlta, entidad, id, nil, despues)
+}
+
+func (s *service) Modificacion(ctx context.Context, entidad string, id int, antes, despues interface{}) error {
+	return s.registrar(ctx, AccionModificacion, entidad, id, antes, despues)
+}
+
+func (s *service) Baja(ctx context.Context, entidad string, id int, antes interface{}) error {
+	return s.registrar(ctx, AccionBaja, entidad, id, antes, nil)
+}
+
+func (s *service) Restauracion(ctx context.Context, entidad string, id int, despues interface{}) error {
+	return s.registrar(ctx, AccionRestauracion, entidad, id, nil, despues)
+}
+
+func (s *service) Buscar(ctx context.Context, f Filtro) ([]Registro, error) {
+	if !f.Hasta.IsZero() && !f.Hasta.After(f.Desde) {
+		return []Registro{}, ErrFiltro
+	}
+	registros, err := s.r.Buscar(ctx, f)
+	if err != nil {
+		log.Println("log de error en consulta de auditoria", err.Error())
+		return []Registro{}, ErrExec
+	}
+	return registros, nil
+}
+
+// registrar guarda el cambio con el actor del contexto. Se llama dentro de la transacción del cambio: si no se puede
+// registrar devuelve el error para que el cambio se deshaga con él.
+func (s *service) registrar(ctx context.Context, accion, entidad string, id int, antes, despues interface{}) error {
+	registro := Registro{
+		FechaHora: time.Now(),
+		Accion:    accion,
+		Entidad:   entidad,
+		IdEntidad: id,
+		Antes:     snapshot(antes),
+		Despues:   snapshot(despues),
+	}
+	registro.TipoActor, registro.IdActor, registro.Actor = actor(ctx)
+
+	if _, err := s.r.CreateRegistro(ctx, registro); err != nil {
+		log.Println("error al registrar auditoria", err.Error())
+		return ErrExec
+	}
+	return nil
+}
+
+// actor devuelve quién hace el pedido: el usuario del token, el paciente del portal o el sistema
+func actor(ctx context.Context) (string, int, string) {
+	if u, ok := auth.UsuarioDesde(ctx); ok {
+		return ActorUsuario, u.ID, u.Email
+	}
+	if idPaciente, ok := auth.PacienteDesde(ctx); ok {
+		return ActorPaciente, idPaciente, ""
+	}
+	return ActorSistema, 0, ""
+}
+
+// snapshot serializa el estado de la entidad; nil queda vacío
+func snapshot(valor interface{}) json.RawMessage {
+	if valor == nil {
+		return nil
+	}
+	datos, err := json.Marshal(valor)
+	if err != nil {
+		log.Println("log de error al serializar snapshot de auditoria", err.Error())
+		return nil
+	}
+	return datos
+}
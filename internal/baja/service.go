@@ -0,0 +1,177 @@
+package baja
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"finalgo/internal/odontologo"
+	"finalgo/internal/paciente"
+	"finalgo/internal/turno"
+	"finalgo/pkg/transaccion"
+)
+
+// qué hacer con los turnos pendientes o confirmados a futuro al dar de baja un paciente o un odontólogo
+const (
+	TurnosBorrar   = "borrar"
+	TurnosBloquear = "bloquear"
+)
+
+// Errores
+var (
+	ErrTurnosFuturos = errors.New("tiene turnos pendientes a futuro; hay que cancelarlos o reasignarlos antes de darlo de baja")
+)
+
+// defino la interfaz para que se apliquen siempre todos los métodos. Las bajas son lógicas: cada baja da de baja
+// también los turnos en una sola transacción y con la misma fecha, que es lo que usa la restauración para saber qué
+// turnos cayeron con ella.
+type Service interface {
+	DeletePaciente(ctx context.Context, id int, version int) error
+	DeleteOdontologo(ctx context.Context, id int, version int) error
+	RestaurarPaciente(ctx context.Context, id int) (paciente.Paciente, error)
+	RestaurarOdontologo(ctx context.Context, id int) (odontologo.Odontologo, error)
+}
+
+// estrucutra service: no tiene repositorio propio, coordina los de paciente, odontólogo y turno con la unidad de trabajo
+type service struct {
+	uow        transaccion.UnitOfWork
+	ps         paciente.Service
+	os         odontologo.Service
+	ts         turno.Service
+	modoTurnos string
+}
+
+// función para instanciar service. modoTurnos es TurnosBorrar o TurnosBloquear; vacío borra.
+func NewService(uow transaccion.UnitOfWork, ps paciente.Service, os odontologo.Service, ts turno.Service, modoTurnos string) Service {
+	return &service{
+		uow,
+		ps,
+		os,
+		ts,
+		modoTurnos,
+	}
+}
+
+// DeletePaciente da de baja el paciente con sus turnos. El resto de sus datos (alertas, teléfonos, cobros, etc.)
+// queda como está para que la restauración lo devuelva completo. La versión es la del paciente; los turnos se dan de
+// baja con la que tienen al leerlos dentro de la transacción.
+func (s *service) DeletePaciente(ctx context.Context, id int, version int) error {
+	return s.uow.Ejecutar(ctx, func(ctx context.Context) error {
+		p, err := s.ps.GetPacienteByID(ctx, id)
+		if err != nil {
+			return paciente.ErrNotFound
+		}
+
+		turnos, err := s.ts.GetTurnoByPaciente(ctx, p.DNI)
+		if err != nil {
+			log.Println("log de error en turnos del paciente", err.Error())
+			return turno.ErrExec
+		}
+		if err := s.borrarTurnos(ctx, turnos); err != nil {
+			return err
+		}
+		return s.ps.DeletePaciente(ctx, id, version)
+	})
+}
+
+// DeleteOdontologo da de baja el odontólogo con sus turnos, si sigue en la versión indicada
+func (s *service) DeleteOdontologo(ctx context.Context, id int, version int) error {
+	return s.uow.Ejecutar(ctx, func(ctx context.Context) error {
+		turnos, err := s.ts.GetTurnoByOdontologo(ctx, id)
+		if err != nil {
+			if errors.Is(err, turno.ErrNotFound) {
+				return odontologo.ErrNotFound
+			}
+			return err
+		}
+		if err := s.borrarTurnos(ctx, turnos); err != nil {
+			return err
+		}
+		return s.os.DeleteOdontologo(ctx, id, version)
+	})
+}
+
+// RestaurarPaciente vuelve a dar de alta al paciente y a los turnos que se dieron de baja junto con él
+func (s *service) RestaurarPaciente(ctx context.Context, id int) (paciente.Paciente, error) {
+	var restaurado paciente.Paciente
+	err := s.uow.Ejecutar(ctx, func(ctx context.Context) error {
+		eliminado, err := s.ps.GetEliminadoByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		restaurado, err = s.ps.RestaurarPaciente(ctx, id)
+		if err != nil {
+			return err
+		}
+		turnos, err := s.ts.GetTurnosEliminadosEn(ctx, *eliminado.DeletedAt, id, 0)
+		if err != nil {
+			return err
+		}
+		return s.restaurarTurnos(ctx, turnos)
+	})
+	if err != nil {
+		return paciente.Paciente{}, err
+	}
+	return restaurado, nil
+}
+
+// RestaurarOdontologo vuelve a dar de alta al odontólogo y a los turnos que se dieron de baja junto con él
+func (s *service) RestaurarOdontologo(ctx context.Context, id int) (odontologo.Odontologo, error) {
+	var restaurado odontologo.Odontologo
+	err := s.uow.Ejecutar(ctx, func(ctx context.Context) error {
+		eliminado, err := s.os.GetEliminadoByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		restaurado, err = s.os.RestaurarOdontologo(ctx, id)
+		if err != nil {
+			return err
+		}
+		turnos, err := s.ts.GetTurnosEliminadosEn(ctx, *eliminado.DeletedAt, 0, id)
+		if err != nil {
+			return err
+		}
+		return s.restaurarTurnos(ctx, turnos)
+	})
+	if err != nil {
+		return odontologo.Odontologo{}, err
+	}
+	return restaurado, nil
+}
+
+// restaurarTurnos restaura los turnos que cayeron con la baja en cascada. Si el otro lado del turno (el odontólogo de
+// un paciente o al revés) sigue dado de baja, el turno se deja como está.
+func (s *service) restaurarTurnos(ctx context.Context, turnos []turno.Turno) error {
+	for _, t := range turnos {
+		if _, err := s.ts.RestaurarTurno(ctx, t.ID); err != nil {
+			if errors.Is(err, turno.ErrBajaRelacionada) {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// borrarTurnos da de baja los turnos del paciente u odontólogo. En modo bloquear, si alguno está pendiente o
+// confirmado a futuro no da de baja ninguno.
+func (s *service) borrarTurnos(ctx context.Context, turnos []turno.Turno) error {
+	if s.modoTurnos == TurnosBloquear {
+		ahora := time.Now()
+		for _, t := range turnos {
+			if t.FechaHora.After(ahora) && (t.Estado == turno.EstadoPendiente || t.Estado == turno.EstadoConfirmado) {
+				return ErrTurnosFuturos
+			}
+		}
+	}
+
+	for _, t := range turnos {
+		if err := s.ts.DeleteTurno(ctx, t.ID, t.Version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
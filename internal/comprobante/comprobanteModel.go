@@ -0,0 +1,31 @@
+package comprobante
+
+// ItemPresupuestoRequest es una prestación del catálogo a presupuestar. Cantidad en cero se toma como uno.
+type ItemPresupuestoRequest struct {
+	CodigoPrestacion string `json:"codigo_prestacion"`
+	Cantidad         int    `json:"cantidad"`
+}
+
+// PresupuestoRequest es lo que se pide por API para armar el presupuesto de tratamiento del paciente.
+type PresupuestoRequest struct {
+	IdOdontologo  int                      `json:"id_odontologo"`
+	Items         []ItemPresupuestoRequest `json:"items"`
+	ValidezDias   int                      `json:"validez_dias"`
+	Observaciones string                   `json:"observaciones"`
+}
+
+// MedicamentoRequest es una línea de la receta.
+type MedicamentoRequest struct {
+	Nombre       string `json:"nombre"`
+	Presentacion string `json:"presentacion"`
+	Posologia    string `json:"posologia"`
+	Cantidad     int    `json:"cantidad"`
+}
+
+// RecetaRequest es lo que se pide por API para emitir una receta firmada por el odontólogo. El odontólogo puede omitir
+// id_odontologo porque firma siempre él; el admin lo tiene que indicar.
+type RecetaRequest struct {
+	IdOdontologo int                  `json:"id_odontologo"`
+	Medicamentos []MedicamentoRequest `json:"medicamentos"`
+	Indicaciones string               `json:"indicaciones"`
+}
@@ -0,0 +1,263 @@
+package comprobante
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"finalgo/internal/facturacion"
+	"finalgo/internal/obrasocial"
+	"finalgo/internal/odontologo"
+	"finalgo/internal/paciente"
+	"finalgo/internal/prestacion"
+	"finalgo/internal/turno"
+	"finalgo/pkg/auth"
+	"finalgo/pkg/documento"
+)
+
+// validez del presupuesto cuando no se indica
+const validezPorDefecto = 30
+
+// Errores
+var (
+	ErrSinItems        = errors.New("el presupuesto no tiene prestaciones")
+	ErrSinMedicamentos = errors.New("la receta no tiene medicamentos")
+	ErrRender          = errors.New("error al generar el documento")
+	ErrFirmante        = errors.New("la receta sólo la puede firmar el odontólogo que la emite")
+)
+
+// defino la interfaz para que se apliquen siempre todos los métodos. Todos devuelven el PDF listo para descargar.
+type Service interface {
+	ConfirmacionTurno(ctx context.Context, idTurno int) ([]byte, error)
+	Presupuesto(ctx context.Context, idPaciente int, p PresupuestoRequest) ([]byte, error)
+	Receta(ctx context.Context, idPaciente int, r RecetaRequest) ([]byte, error)
+}
+
+// estrucutra service: no tiene repositorio propio, arma los documentos con los datos de los otros servicios
+type service struct {
+	renderer documento.Renderer
+	clinica  documento.Clinica
+	ts       turno.Service
+	ps       paciente.Service
+	os       odontologo.Service
+	prs      prestacion.Service
+	obs      obrasocial.Service
+	fs       facturacion.Service
+}
+
+// función para instanciar service
+func NewService(renderer documento.Renderer, clinica documento.Clinica, ts turno.Service, ps paciente.Service, os odontologo.Service, prs prestacion.Service, obs obrasocial.Service, fs facturacion.Service) Service {
+	return &service{
+		renderer,
+		clinica,
+		ts,
+		ps,
+		os,
+		prs,
+		obs,
+		fs,
+	}
+}
+
+// ConfirmacionTurno arma el comprobante del turno con los datos del paciente y del odontólogo.
+func (s *service) ConfirmacionTurno(ctx context.Context, idTurno int) ([]byte, error) {
+	t, err := s.ts.GetTurnoByID(ctx, idTurno)
+	if err != nil {
+		log.Println("log de error por turno inexistente", err.Error())
+		return nil, turno.ErrNotFound
+	}
+	p, o, err := s.participantes(ctx, t.IdPaciente, t.IdOdontologo)
+	if err != nil {
+		return nil, err
+	}
+
+	datos := documento.ConfirmacionTurno{
+		Clinica:     s.clinica,
+		Numero:      t.ID,
+		FechaHora:   t.FechaHora,
+		Paciente:    persona(p),
+		Profesional: profesional(o),
+		Descripcion: t.Descripcion,
+		Emitido:     time.Now(),
+	}
+	if t.CodigoPrestacion != "" {
+		datos.Prestacion = t.CodigoPrestacion
+		if pr, err := s.prs.GetPrestacionByCodigo(ctx, t.CodigoPrestacion); err == nil {
+			datos.Prestacion = pr.Descripcion
+		}
+	}
+
+	return s.render(documento.PlantillaConfirmacionTurno, datos)
+}
+
+// Presupuesto valoriza las prestaciones pedidas con el catálogo y, si el paciente tiene cobertura vigente,
+// separa lo que pagaría la obra social de lo que queda a cargo del paciente.
+func (s *service) Presupuesto(ctx context.Context, idPaciente int, presupuestoRequest PresupuestoRequest) ([]byte, error) {
+	if len(presupuestoRequest.Items) == 0 {
+		return nil, ErrSinItems
+	}
+	p, o, err := s.participantes(ctx, idPaciente, presupuestoRequest.IdOdontologo)
+	if err != nil {
+		return nil, err
+	}
+
+	hoy := time.Now()
+	validez := presupuestoRequest.ValidezDias
+	if validez <= 0 {
+		validez = validezPorDefecto
+	}
+	datos := documento.Presupuesto{
+		Clinica:       s.clinica,
+		Fecha:         hoy,
+		ValidoHasta:   hoy.AddDate(0, 0, validez),
+		Paciente:      persona(p),
+		Profesional:   profesional(o),
+		Observaciones: presupuestoRequest.Observaciones,
+	}
+
+	for _, itemRequest := range presupuestoRequest.Items {
+		// se cotiza igual que al facturar, así el presupuesto coincide con el cargo que después se genera
+		cotizacion, err := s.fs.Cotizar(ctx, idPaciente, itemRequest.CodigoPrestacion, hoy)
+		if err != nil {
+			return nil, err
+		}
+		pr := cotizacion.Prestacion
+		cantidad := itemRequest.Cantidad
+		if cantidad <= 0 {
+			cantidad = 1
+		}
+
+		item := documento.ItemPresupuesto{
+			Codigo:         pr.Codigo,
+			Descripcion:    pr.Descripcion,
+			Cantidad:       cantidad,
+			PrecioUnitario: pr.Precio,
+			Subtotal:       pr.Precio * float64(cantidad),
+		}
+		item.ObraSocial = cotizacion.ImporteObraSocial * float64(cantidad)
+		item.Paciente = cotizacion.ImportePaciente * float64(cantidad)
+
+		if cobertura := cotizacion.Cobertura; cobertura.IdObraSocial != 0 && datos.ObraSocial == "" {
+			datos.ObraSocial = s.nombreObraSocial(ctx, cobertura.IdObraSocial, cobertura.Plan)
+		}
+
+		datos.Items = append(datos.Items, item)
+		datos.Total += item.Subtotal
+		datos.TotalObraSocial += item.ObraSocial
+		datos.TotalPaciente += item.Paciente
+	}
+
+	return s.render(documento.PlantillaPresupuesto, datos)
+}
+
+// Receta emite la receta firmada con el nombre y la matrícula del odontólogo.
+func (s *service) Receta(ctx context.Context, idPaciente int, recetaRequest RecetaRequest) ([]byte, error) {
+	if len(recetaRequest.Medicamentos) == 0 {
+		return nil, ErrSinMedicamentos
+	}
+	for _, m := range recetaRequest.Medicamentos {
+		if m.Nombre == "" {
+			return nil, ErrSinMedicamentos
+		}
+	}
+	idOdontologo, err := firmante(ctx, recetaRequest.IdOdontologo)
+	if err != nil {
+		return nil, err
+	}
+	p, o, err := s.participantes(ctx, idPaciente, idOdontologo)
+	if err != nil {
+		return nil, err
+	}
+
+	hoy := time.Now()
+	datos := documento.Receta{
+		Clinica:      s.clinica,
+		Fecha:        hoy,
+		Paciente:     persona(p),
+		Profesional:  profesional(o),
+		Indicaciones: recetaRequest.Indicaciones,
+	}
+	for _, m := range recetaRequest.Medicamentos {
+		datos.Medicamentos = append(datos.Medicamentos, documento.Medicamento{
+			Nombre:       m.Nombre,
+			Presentacion: m.Presentacion,
+			Posologia:    m.Posologia,
+			Cantidad:     m.Cantidad,
+		})
+	}
+
+	// la receta lleva la obra social vigente del paciente, si tiene
+	if coberturas, err := s.obs.GetCoberturasByPaciente(ctx, idPaciente); err == nil {
+		for _, c := range coberturas {
+			if c.VigenteEn(hoy) {
+				datos.ObraSocial = s.nombreObraSocial(ctx, c.IdObraSocial, c.Plan)
+				datos.NumeroAfiliado = c.NumeroAfiliado
+				break
+			}
+		}
+	}
+
+	return s.render(documento.PlantillaReceta, datos)
+}
+
+// firmante decide quién firma la receta. El odontólogo firma siempre con su propia matrícula (si manda otro
+// odontólogo se rechaza) y sólo el admin elige el firmante.
+func firmante(ctx context.Context, pedido int) (int, error) {
+	u, ok := auth.UsuarioDesde(ctx)
+	switch {
+	case ok && u.Rol == auth.RolOdontologo && u.IdOdontologo > 0:
+		if pedido != 0 && pedido != u.IdOdontologo {
+			return 0, ErrFirmante
+		}
+		return u.IdOdontologo, nil
+	case ok && u.Rol == auth.RolAdmin:
+		return pedido, nil
+	default:
+		return 0, ErrFirmante
+	}
+}
+
+// participantes busca al paciente y al odontólogo que figuran en el documento
+func (s *service) participantes(ctx context.Context, idPaciente int, idOdontologo int) (paciente.Paciente, odontologo.Odontologo, error) {
+	p, err := s.ps.GetPacienteByID(ctx, idPaciente)
+	if err != nil {
+		log.Println("log de error por paciente inexistente", err.Error())
+		return paciente.Paciente{}, odontologo.Odontologo{}, paciente.ErrNotFound
+	}
+	o, err := s.os.GetOdontologoByID(ctx, idOdontologo)
+	if err != nil {
+		log.Println("log de error por odontólogo inexistente", err.Error())
+		return paciente.Paciente{}, odontologo.Odontologo{}, odontologo.ErrNotFound
+	}
+	return p, o, nil
+}
+
+// nombreObraSocial devuelve "Nombre - plan" o el plan solo si no se encuentra la obra social
+func (s *service) nombreObraSocial(ctx context.Context, idObraSocial int, plan string) string {
+	nombre := plan
+	if o, err := s.obs.GetObraSocialByID(ctx, idObraSocial); err == nil {
+		nombre = o.Nombre
+		if plan != "" {
+			nombre += " - " + plan
+		}
+	}
+	return nombre
+}
+
+func (s *service) render(plantilla string, datos interface{}) ([]byte, error) {
+	pdf, err := s.renderer.Render(plantilla, datos)
+	if err != nil {
+		log.Println("log de error al generar documento", err.Error())
+		return nil, ErrRender
+	}
+	return pdf, nil
+}
+
+func persona(p paciente.Paciente) documento.Persona {
+	return documento.Persona{Nombre: p.Nombre, Apellido: p.Apellido, DNI: p.DNI}
+}
+
+func profesional(o odontologo.Odontologo) documento.Profesional {
+	return documento.Profesional{Nombre: o.Nombre, Apellido: o.Apellido, Matricula: o.Matricula}
+}
@@ -0,0 +1,36 @@
+package comprobante
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"finalgo/pkg/auth"
+)
+
+// el odontólogo firma con su matrícula aunque no la mande, y sólo el admin elige el firmante
+func TestFirmante(t *testing.T) {
+	odontologo := auth.Identidad{ID: 2, Rol: auth.RolOdontologo, IdOdontologo: 7}
+	casos := []struct {
+		nombre   string
+		ctx      context.Context
+		pedido   int
+		esperado int
+		errorEsp error
+	}{
+		{"odontólogo sin firmante", auth.ConUsuario(context.Background(), odontologo), 0, 7, nil},
+		{"odontólogo con su firma", auth.ConUsuario(context.Background(), odontologo), 7, 7, nil},
+		{"odontólogo firmando por otro", auth.ConUsuario(context.Background(), odontologo), 8, 0, ErrFirmante},
+		{"admin elige", auth.ConUsuario(context.Background(), auth.Identidad{ID: 1, Rol: auth.RolAdmin}), 8, 8, nil},
+		{"recepción no elige", auth.ConUsuario(context.Background(), auth.Identidad{ID: 3, Rol: auth.RolRecepcionista}), 8, 0, ErrFirmante},
+		{"sin usuario", context.Background(), 8, 0, ErrFirmante},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			id, err := firmante(c.ctx, c.pedido)
+			if !errors.Is(err, c.errorEsp) || id != c.esperado {
+				t.Fatalf("firmante(%d) = %d, %v; se esperaba %d, %v", c.pedido, id, err, c.esperado, c.errorEsp)
+			}
+		})
+	}
+}
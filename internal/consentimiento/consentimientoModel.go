@@ -0,0 +1,63 @@
+package consentimiento
+
+import (
+	"time"
+
+	"finalgo/internal/adjunto"
+)
+
+// PlantillaConsentimiento es el texto legal que el paciente firma antes de una prestación.
+// Cada prestación tiene una sola versión activa; cargar una plantilla nueva la reemplaza y las firmas anteriores dejan de valer.
+// VigenciaDias en cero significa que la firma no vence.
+type PlantillaConsentimiento struct {
+	ID               int       `json:"id"`
+	CodigoPrestacion string    `json:"codigo_prestacion"`
+	Version          int       `json:"version"`
+	Titulo           string    `json:"titulo"`
+	Texto            string    `json:"texto"`
+	VigenciaDias     int       `json:"vigencia_dias"`
+	Activa           bool      `json:"activa"`
+	FechaCreacion    time.Time `json:"fecha_creacion"`
+}
+
+// creamos la misma estructura de plantilla para las solicitudes por API (la versión la asigna el sistema).
+type PlantillaConsentimientoRequest struct {
+	CodigoPrestacion string `json:"codigo_prestacion"`
+	Titulo           string `json:"titulo"`
+	Texto            string `json:"texto"`
+	VigenciaDias     int    `json:"vigencia_dias"`
+}
+
+// Consentimiento es el registro de que el paciente firmó una versión de la plantilla. IdAdjunto apunta a la imagen de la firma o al escaneo.
+// Si el paciente es menor firma su responsable, que queda en IdResponsable.
+type Consentimiento struct {
+	ID               int       `json:"id"`
+	IdPaciente       int       `json:"id_paciente"`
+	IdPlantilla      int       `json:"id_plantilla"`
+	CodigoPrestacion string    `json:"codigo_prestacion"`
+	Version          int       `json:"version"`
+	FirmadoPor       string    `json:"firmado_por"`
+	IdResponsable    int       `json:"id_responsable,omitempty"`
+	FechaFirma       time.Time `json:"fecha_firma"`
+	IdAdjunto        int       `json:"id_adjunto"`
+}
+
+// ConsentimientoRequest llega en la subida multipart: la plantilla firmada, quién firmó (o el responsable que firma
+// por el menor) y el archivo con la firma.
+type ConsentimientoRequest struct {
+	IdPlantilla   int
+	FirmadoPor    string
+	IdResponsable int
+	Archivo       adjunto.AdjuntoRequest
+}
+
+// VigenteEn indica si la firma sigue valiendo en la fecha según la vigencia de la plantilla.
+func (c Consentimiento) VigenteEn(fecha time.Time, p PlantillaConsentimiento) bool {
+	if c.IdPlantilla != p.ID {
+		return false
+	}
+	if p.VigenciaDias == 0 {
+		return true
+	}
+	return !fecha.After(c.FechaFirma.AddDate(0, 0, p.VigenciaDias))
+}
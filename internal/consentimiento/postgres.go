@@ -0,0 +1,40 @@
+package consentimiento
+
+import "database/sql"
+
+// Queries de postgres: parámetros $1, $2, ..., marcas BOOLEAN y las altas devuelven el ID con RETURNING id
+var (
+	QueryGetPlantillasPostgres        = `SELECT id, codigo_prestacion, version, titulo, texto, vigencia_dias, activa, fecha_creacion FROM plantilla_consentimiento WHERE ($1 = '' OR codigo_prestacion = $2) ORDER BY codigo_prestacion, version DESC`
+	QueryGetPlantillaByIdPostgres     = `SELECT id, codigo_prestacion, version, titulo, texto, vigencia_dias, activa, fecha_creacion FROM plantilla_consentimiento WHERE id = $1`
+	QueryGetPlantillaActivaPostgres   = `SELECT id, codigo_prestacion, version, titulo, texto, vigencia_dias, activa, fecha_creacion FROM plantilla_consentimiento WHERE codigo_prestacion = $1 AND activa = TRUE`
+	QueryDesactivarPlantillasPostgres = `UPDATE plantilla_consentimiento SET activa = FALSE WHERE codigo_prestacion = $1`
+	QueryInsertPlantillaPostgres      = `INSERT INTO plantilla_consentimiento(codigo_prestacion, version, titulo, texto, vigencia_dias, activa, fecha_creacion) VALUES($1, $2, $3, $4, $5, TRUE, $6) RETURNING id`
+	QueryGetByPacientePostgres        = `SELECT c.id, c.id_paciente, c.id_plantilla, p.codigo_prestacion, p.version, c.firmado_por, c.id_responsable, c.fecha_firma, c.id_adjunto FROM consentimiento c JOIN plantilla_consentimiento p ON p.id = c.id_plantilla WHERE c.id_paciente = $1 ORDER BY c.fecha_firma DESC`
+	QueryGetUltimoFirmadoPostgres     = `SELECT c.id, c.id_paciente, c.id_plantilla, p.codigo_prestacion, p.version, c.firmado_por, c.id_responsable, c.fecha_firma, c.id_adjunto FROM consentimiento c JOIN plantilla_consentimiento p ON p.id = c.id_plantilla WHERE c.id_paciente = $1 AND c.id_plantilla = $2 ORDER BY c.fecha_firma DESC LIMIT 1`
+	QueryInsertPostgres               = `INSERT INTO consentimiento(id_paciente, id_plantilla, firmado_por, id_responsable, fecha_firma, id_adjunto) VALUES($1, $2, $3, $4, $5, $6) RETURNING id`
+	// postgres no acepta FOR UPDATE con MAX: antes toma un lock por prestación que dura hasta el fin de la transacción
+	QueryGetUltimaVersionPostgres   = `SELECT COALESCE(MAX(version), 0) FROM plantilla_consentimiento WHERE codigo_prestacion = $1`
+	QueryBloquearPrestacionPostgres = `SELECT pg_advisory_xact_lock(hashtext($1))`
+)
+
+var consultasPostgres = consultas{
+	getPlantillas:        QueryGetPlantillasPostgres,
+	getPlantillaById:     QueryGetPlantillaByIdPostgres,
+	getPlantillaActiva:   QueryGetPlantillaActivaPostgres,
+	desactivarPlantillas: QueryDesactivarPlantillasPostgres,
+	insertPlantilla:      QueryInsertPlantillaPostgres,
+	getByPaciente:        QueryGetByPacientePostgres,
+	getUltimoFirmado:     QueryGetUltimoFirmadoPostgres,
+	insert:               QueryInsertPostgres,
+	ultimaVersion:        QueryGetUltimaVersionPostgres,
+	bloqueo:              QueryBloquearPrestacionPostgres,
+	returning:            true,
+}
+
+// NewRepositoryPostgres instancia repositorio sobre postgres
+func NewRepositoryPostgres(db *sql.DB) Repository {
+	return &repository{
+		db: db,
+		q:  consultasPostgres,
+	}
+}
@@ -0,0 +1,299 @@
+package consentimiento
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"finalgo/pkg/basedatos"
+)
+
+// Errores
+var (
+	ErrEmptyList         = errors.New("la lista de consentimientos esta vacia")
+	ErrNotFound          = errors.New("consentimiento no encontrado")
+	ErrPlantillaNotFound = errors.New("plantilla de consentimiento no encontrada")
+	ErrStatement         = errors.New("sentencia incorrecta")
+	ErrExec              = errors.New("ejecución SQL incorrecta")
+	ErrLastId            = errors.New("error al obtener el último ID")
+	ErrPlantillaInactiva = errors.New("la plantilla fue reemplazada por una versión nueva")
+	ErrSinConsentimiento = errors.New("la prestación requiere un consentimiento informado firmado y vigente")
+	ErrFirmaResponsable  = errors.New("el paciente es menor de edad y el consentimiento lo tiene que firmar uno de sus responsables")
+)
+
+// Queries a usar en cada función
+var (
+	QueryGetPlantillas      = `SELECT id, codigo_prestacion, version, titulo, texto, vigencia_dias, activa, fecha_creacion FROM plantilla_consentimiento WHERE (? = '' OR codigo_prestacion = ?) ORDER BY codigo_prestacion, version DESC`
+	QueryGetPlantillaById   = `SELECT id, codigo_prestacion, version, titulo, texto, vigencia_dias, activa, fecha_creacion FROM plantilla_consentimiento WHERE id = ?`
+	QueryGetPlantillaActiva = `SELECT id, codigo_prestacion, version, titulo, texto, vigencia_dias, activa, fecha_creacion FROM plantilla_consentimiento WHERE codigo_prestacion = ? AND activa = 1`
+	QueryGetUltimaVersion   = `SELECT COALESCE(MAX(version), 0) FROM plantilla_consentimiento WHERE codigo_prestacion = ? FOR UPDATE`
+	// sqlite no tiene FOR UPDATE: las transacciones toman el lock de escritura al empezar (_txlock=immediate)
+	QueryGetUltimaVersionSinLock = `SELECT COALESCE(MAX(version), 0) FROM plantilla_consentimiento WHERE codigo_prestacion = ?`
+	QueryDesactivarPlantillas    = `UPDATE plantilla_consentimiento SET activa = 0 WHERE codigo_prestacion = ?`
+	QueryInsertPlantilla         = `INSERT INTO plantilla_consentimiento(codigo_prestacion, version, titulo, texto, vigencia_dias, activa, fecha_creacion) VALUES(?,?,?,?,?,1,?)`
+	QueryGetByPaciente           = `SELECT c.id, c.id_paciente, c.id_plantilla, p.codigo_prestacion, p.version, c.firmado_por, c.id_responsable, c.fecha_firma, c.id_adjunto FROM consentimiento c JOIN plantilla_consentimiento p ON p.id = c.id_plantilla WHERE c.id_paciente = ? ORDER BY c.fecha_firma DESC`
+	QueryGetUltimoFirmado        = `SELECT c.id, c.id_paciente, c.id_plantilla, p.codigo_prestacion, p.version, c.firmado_por, c.id_responsable, c.fecha_firma, c.id_adjunto FROM consentimiento c JOIN plantilla_consentimiento p ON p.id = c.id_plantilla WHERE c.id_paciente = ? AND c.id_plantilla = ? ORDER BY c.fecha_firma DESC LIMIT 1`
+	QueryInsert                  = `INSERT INTO consentimiento(id_paciente, id_plantilla, firmado_por, id_responsable, fecha_firma, id_adjunto) VALUES(?,?,?,?,?,?)`
+)
+
+// consultas son las queries de cada función en el dialecto del motor: MySQL y SQLite usan las de arriba y
+// PostgreSQL las de postgres.go
+type consultas struct {
+	getPlantillas        string
+	getPlantillaById     string
+	getPlantillaActiva   string
+	desactivarPlantillas string
+	insertPlantilla      string
+	getByPaciente        string
+	getUltimoFirmado     string
+	insert               string
+	// última versión de la prestación y lock previo (vacío si no hace falta), dentro de la transacción del alta
+	ultimaVersion string
+	bloqueo       string
+	// las altas devuelven el ID con RETURNING id, porque el motor no tiene LastInsertId
+	returning bool
+}
+
+var consultasMySQL = consultas{
+	getPlantillas:        QueryGetPlantillas,
+	getPlantillaById:     QueryGetPlantillaById,
+	getPlantillaActiva:   QueryGetPlantillaActiva,
+	desactivarPlantillas: QueryDesactivarPlantillas,
+	insertPlantilla:      QueryInsertPlantilla,
+	getByPaciente:        QueryGetByPaciente,
+	getUltimoFirmado:     QueryGetUltimoFirmado,
+	insert:               QueryInsert,
+	ultimaVersion:        QueryGetUltimaVersion,
+}
+
+// defino la interfaz para que se apliquen siempre todos los métodos
+type Repository interface {
+	GetPlantillas(ctx context.Context, codigoPrestacion string) ([]PlantillaConsentimiento, error)
+	GetPlantillaByID(ctx context.Context, id int) (PlantillaConsentimiento, error)
+	GetPlantillaActiva(ctx context.Context, codigoPrestacion string) (PlantillaConsentimiento, error)
+	CreatePlantilla(ctx context.Context, p PlantillaConsentimiento) (PlantillaConsentimiento, error)
+
+	GetConsentimientosByPaciente(ctx context.Context, idPaciente int) ([]Consentimiento, error)
+	GetUltimoFirmado(ctx context.Context, idPaciente int, idPlantilla int) (Consentimiento, error)
+	CreateConsentimiento(ctx context.Context, c Consentimiento) (Consentimiento, error)
+}
+
+// estructura repositorio con base de datos mysql
+type repository struct {
+	db *sql.DB
+	q  consultas
+}
+
+// NewRepositoryMySql instancia repositorio
+func NewRepositoryMySql(db *sql.DB) Repository {
+	return &repository{
+		db: db,
+		q:  consultasMySQL,
+	}
+}
+
+// NewRepositorySqlite instancia repositorio sobre sqlite
+func NewRepositorySqlite(db *sql.DB) Repository {
+	q := consultasMySQL
+	q.ultimaVersion = QueryGetUltimaVersionSinLock
+	return &repository{
+		db: db,
+		q:  q,
+	}
+}
+
+// obtener plantillas, todas o las de una prestación, de la versión más nueva a la más vieja
+func (r *repository) GetPlantillas(ctx context.Context, codigoPrestacion string) ([]PlantillaConsentimiento, error) {
+	rows, err := r.db.QueryContext(ctx, r.q.getPlantillas, codigoPrestacion, codigoPrestacion)
+	if err != nil {
+		return []PlantillaConsentimiento{}, ErrEmptyList
+	}
+	defer rows.Close()
+
+	plantillas := []PlantillaConsentimiento{}
+	for rows.Next() {
+		plantilla, err := scanPlantilla(rows)
+		if err != nil {
+			return []PlantillaConsentimiento{}, ErrExec
+		}
+		plantillas = append(plantillas, plantilla)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []PlantillaConsentimiento{}, ErrExec
+	}
+
+	return plantillas, nil
+}
+
+func (r *repository) GetPlantillaByID(ctx context.Context, id int) (PlantillaConsentimiento, error) {
+	plantilla, err := scanPlantilla(r.db.QueryRowContext(ctx, r.q.getPlantillaById, id))
+	if err != nil {
+		return PlantillaConsentimiento{}, ErrPlantillaNotFound
+	}
+	return plantilla, nil
+}
+
+func (r *repository) GetPlantillaActiva(ctx context.Context, codigoPrestacion string) (PlantillaConsentimiento, error) {
+	plantilla, err := scanPlantilla(r.db.QueryRowContext(ctx, r.q.getPlantillaActiva, codigoPrestacion))
+	if errors.Is(err, sql.ErrNoRows) {
+		return PlantillaConsentimiento{}, ErrPlantillaNotFound
+	}
+	if err != nil {
+		return PlantillaConsentimiento{}, ErrExec
+	}
+	return plantilla, nil
+}
+
+// crear una versión nueva: en la misma transacción se calcula el número de versión y se desactivan las anteriores
+func (r *repository) CreatePlantilla(ctx context.Context, plantilla PlantillaConsentimiento) (PlantillaConsentimiento, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return PlantillaConsentimiento{}, ErrStatement
+	}
+	defer tx.Rollback()
+
+	if r.q.bloqueo != "" {
+		if _, err := tx.ExecContext(ctx, r.q.bloqueo, plantilla.CodigoPrestacion); err != nil {
+			return PlantillaConsentimiento{}, ErrExec
+		}
+	}
+	var ultimaVersion int
+	if err := tx.QueryRowContext(ctx, r.q.ultimaVersion, plantilla.CodigoPrestacion).Scan(&ultimaVersion); err != nil {
+		return PlantillaConsentimiento{}, ErrExec
+	}
+	if _, err := tx.ExecContext(ctx, r.q.desactivarPlantillas, plantilla.CodigoPrestacion); err != nil {
+		return PlantillaConsentimiento{}, ErrExec
+	}
+
+	plantilla.Version = ultimaVersion + 1
+	plantilla.Activa = true
+	result, err := basedatos.Insertar(
+		ctx,
+		tx,
+		r.q.returning,
+		r.q.insertPlantilla,
+		plantilla.CodigoPrestacion,
+		plantilla.Version,
+		plantilla.Titulo,
+		plantilla.Texto,
+		plantilla.VigenciaDias,
+		plantilla.FechaCreacion,
+	)
+	if err != nil {
+		return PlantillaConsentimiento{}, ErrExec
+	}
+	lastId, err := result.LastInsertId()
+	if err != nil {
+		return PlantillaConsentimiento{}, ErrLastId
+	}
+	plantilla.ID = int(lastId)
+
+	if err := tx.Commit(); err != nil {
+		return PlantillaConsentimiento{}, ErrExec
+	}
+	return plantilla, nil
+}
+
+// obtener los consentimientos firmados por el paciente, del más nuevo al más viejo
+func (r *repository) GetConsentimientosByPaciente(ctx context.Context, idPaciente int) ([]Consentimiento, error) {
+	rows, err := r.db.QueryContext(ctx, r.q.getByPaciente, idPaciente)
+	if err != nil {
+		return []Consentimiento{}, ErrEmptyList
+	}
+	defer rows.Close()
+
+	consentimientos := []Consentimiento{}
+	for rows.Next() {
+		consentimiento, err := scanConsentimiento(rows)
+		if err != nil {
+			return []Consentimiento{}, ErrExec
+		}
+		consentimientos = append(consentimientos, consentimiento)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []Consentimiento{}, ErrExec
+	}
+
+	return consentimientos, nil
+}
+
+// obtener la última firma del paciente para una plantilla
+func (r *repository) GetUltimoFirmado(ctx context.Context, idPaciente int, idPlantilla int) (Consentimiento, error) {
+	consentimiento, err := scanConsentimiento(r.db.QueryRowContext(ctx, r.q.getUltimoFirmado, idPaciente, idPlantilla))
+	if err != nil {
+		return Consentimiento{}, ErrNotFound
+	}
+	return consentimiento, nil
+}
+
+// crear consentimiento en BD
+func (r *repository) CreateConsentimiento(ctx context.Context, consentimiento Consentimiento) (Consentimiento, error) {
+	statement, err := r.db.PrepareContext(ctx, r.q.insert)
+	if err != nil {
+		return Consentimiento{}, ErrStatement
+	}
+	defer statement.Close()
+
+	result, err := basedatos.InsertarPreparado(
+		ctx,
+		statement,
+		r.q.returning,
+		consentimiento.IdPaciente,
+		consentimiento.IdPlantilla,
+		consentimiento.FirmadoPor,
+		sql.NullInt64{Int64: int64(consentimiento.IdResponsable), Valid: consentimiento.IdResponsable != 0},
+		consentimiento.FechaFirma,
+		consentimiento.IdAdjunto,
+	)
+	if err != nil {
+		return Consentimiento{}, ErrExec
+	}
+
+	// obtengo el ID del registro y lo devuelvo como dato
+	lastId, err := result.LastInsertId()
+	if err != nil {
+		return Consentimiento{}, ErrLastId
+	}
+	consentimiento.ID = int(lastId)
+	return consentimiento, nil
+}
+
+// scanner lo cumplen tanto *sql.Row como *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPlantilla(s scanner) (PlantillaConsentimiento, error) {
+	var plantilla PlantillaConsentimiento
+	err := s.Scan(
+		&plantilla.ID,
+		&plantilla.CodigoPrestacion,
+		&plantilla.Version,
+		&plantilla.Titulo,
+		&plantilla.Texto,
+		&plantilla.VigenciaDias,
+		&plantilla.Activa,
+		&plantilla.FechaCreacion,
+	)
+	return plantilla, err
+}
+
+func scanConsentimiento(s scanner) (Consentimiento, error) {
+	var consentimiento Consentimiento
+	var idResponsable sql.NullInt64
+	err := s.Scan(
+		&consentimiento.ID,
+		&consentimiento.IdPaciente,
+		&consentimiento.IdPlantilla,
+		&consentimiento.CodigoPrestacion,
+		&consentimiento.Version,
+		&consentimiento.FirmadoPor,
+		&idResponsable,
+		&consentimiento.FechaFirma,
+		&consentimiento.IdAdjunto,
+	)
+	consentimiento.IdResponsable = int(idResponsable.Int64)
+	return consentimiento, err
+}
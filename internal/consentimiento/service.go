@@ -0,0 +1,175 @@
+package consentimiento
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"finalgo/internal/adjunto"
+	"finalgo/internal/paciente"
+	"finalgo/internal/prestacion"
+)
+
+// defino la interfaz para que se apliquen siempre todos los métodos
+type Service interface {
+	GetPlantillas(ctx context.Context, codigoPrestacion string) ([]PlantillaConsentimiento, error)
+	GetPlantillaByID(ctx context.Context, id int) (PlantillaConsentimiento, error)
+	CreatePlantilla(ctx context.Context, p PlantillaConsentimientoRequest) (PlantillaConsentimiento, error)
+
+	GetConsentimientosByPaciente(ctx context.Context, idPaciente int) ([]Consentimiento, error)
+	RegistrarConsentimiento(ctx context.Context, c ConsentimientoRequest, idPaciente int) (Consentimiento, error)
+	VerificarConsentimiento(ctx context.Context, idPaciente int, codigoPrestacion string, fecha time.Time) error
+}
+
+// estrucutra service que contará con un repositorio y los servicios de pacientes, prestaciones y adjuntos (donde queda la firma)
+type service struct {
+	r   Repository
+	ps  paciente.Service
+	prs prestacion.Service
+	as  adjunto.Service
+}
+
+// función para instanciar service
+func NewService(r Repository, ps paciente.Service, prs prestacion.Service, as adjunto.Service) Service {
+	return &service{
+		r,
+		ps,
+		prs,
+		as,
+	}
+}
+
+func (s *service) GetPlantillas(ctx context.Context, codigoPrestacion string) ([]PlantillaConsentimiento, error) {
+	plantillas, err := s.r.GetPlantillas(ctx, codigoPrestacion)
+	if err != nil {
+		log.Println("log de error en plantillas de consentimiento", err.Error())
+		return []PlantillaConsentimiento{}, ErrEmptyList
+	}
+	return plantillas, nil
+}
+
+func (s *service) GetPlantillaByID(ctx context.Context, id int) (PlantillaConsentimiento, error) {
+	p, err := s.r.GetPlantillaByID(ctx, id)
+	if err != nil {
+		log.Println("log de error por plantilla inexistente", err.Error())
+		return PlantillaConsentimiento{}, ErrPlantillaNotFound
+	}
+	return p, nil
+}
+
+// CreatePlantilla carga una versión nueva del consentimiento de la prestación, que pasa a ser la única activa.
+func (s *service) CreatePlantilla(ctx context.Context, plantillaRequest PlantillaConsentimientoRequest) (PlantillaConsentimiento, error) {
+	if _, err := s.prs.GetPrestacionByCodigo(ctx, plantillaRequest.CodigoPrestacion); err != nil {
+		log.Println("log de error por prestación inexistente", err.Error())
+		return PlantillaConsentimiento{}, prestacion.ErrNotFound
+	}
+
+	plantilla := PlantillaConsentimiento{
+		CodigoPrestacion: plantillaRequest.CodigoPrestacion,
+		Titulo:           plantillaRequest.Titulo,
+		Texto:            plantillaRequest.Texto,
+		VigenciaDias:     plantillaRequest.VigenciaDias,
+		FechaCreacion:    time.Now(),
+	}
+	response, err := s.r.CreatePlantilla(ctx, plantilla)
+	if err != nil {
+		log.Println("error al crear plantilla de consentimiento")
+		return PlantillaConsentimiento{}, ErrExec
+	}
+	return response, nil
+}
+
+func (s *service) GetConsentimientosByPaciente(ctx context.Context, idPaciente int) ([]Consentimiento, error) {
+	consentimientos, err := s.r.GetConsentimientosByPaciente(ctx, idPaciente)
+	if err != nil {
+		log.Println("log de error en consentimientos del paciente", err.Error())
+		return []Consentimiento{}, ErrEmptyList
+	}
+	return consentimientos, nil
+}
+
+// RegistrarConsentimiento guarda el archivo de la firma como adjunto del paciente y registra qué versión firmó y cuándo.
+// Sólo se puede firmar la versión activa de la plantilla, y por un paciente menor tiene que firmar uno de sus responsables.
+func (s *service) RegistrarConsentimiento(ctx context.Context, consentimientoRequest ConsentimientoRequest, idPaciente int) (Consentimiento, error) {
+	plantilla, err := s.GetPlantillaByID(ctx, consentimientoRequest.IdPlantilla)
+	if err != nil {
+		return Consentimiento{}, err
+	}
+	if !plantilla.Activa {
+		return Consentimiento{}, ErrPlantillaInactiva
+	}
+	p, err := s.ps.GetPacienteByID(ctx, idPaciente)
+	if err != nil {
+		log.Println("log de error por paciente inexistente", err.Error())
+		return Consentimiento{}, paciente.ErrNotFound
+	}
+
+	// un menor no puede firmar: lo hace uno de sus responsables, que tiene que estar cargado
+	firmadoPor := consentimientoRequest.FirmadoPor
+	if consentimientoRequest.IdResponsable != 0 {
+		responsable, err := s.ps.GetResponsableByID(ctx, idPaciente, consentimientoRequest.IdResponsable)
+		if err != nil {
+			return Consentimiento{}, ErrFirmaResponsable
+		}
+		if firmadoPor == "" {
+			firmadoPor = responsable.Nombre
+		}
+	} else if p.EsMenorEn(time.Now()) {
+		return Consentimiento{}, ErrFirmaResponsable
+	}
+	if firmadoPor == "" {
+		firmadoPor = p.Apellido + ", " + p.Nombre
+	}
+
+	archivo := consentimientoRequest.Archivo
+	archivo.Tipo = adjunto.TipoConsentimiento
+	archivo.Descripcion = fmt.Sprintf("%s (versión %d) firmado por %s", plantilla.Titulo, plantilla.Version, firmadoPor)
+	a, err := s.as.SubirAdjunto(ctx, archivo, idPaciente)
+	if err != nil {
+		return Consentimiento{}, err
+	}
+
+	consentimiento := Consentimiento{
+		IdPaciente:       idPaciente,
+		IdPlantilla:      plantilla.ID,
+		CodigoPrestacion: plantilla.CodigoPrestacion,
+		Version:          plantilla.Version,
+		FirmadoPor:       firmadoPor,
+		IdResponsable:    consentimientoRequest.IdResponsable,
+		FechaFirma:       time.Now(),
+		IdAdjunto:        a.ID,
+	}
+	response, err := s.r.CreateConsentimiento(ctx, consentimiento)
+	if err != nil {
+		log.Println("error al registrar consentimiento")
+		if err := s.as.DeleteAdjunto(ctx, idPaciente, a.ID, a.Version); err != nil {
+			log.Println("log de error al borrar la firma del consentimiento no registrado", err.Error())
+		}
+		return Consentimiento{}, ErrExec
+	}
+	return response, nil
+}
+
+// VerificarConsentimiento devuelve ErrSinConsentimiento si la prestación tiene una plantilla activa y el paciente
+// no firmó esa versión o la firma ya venció a la fecha. Las prestaciones sin plantilla no requieren consentimiento.
+func (s *service) VerificarConsentimiento(ctx context.Context, idPaciente int, codigoPrestacion string, fecha time.Time) error {
+	if codigoPrestacion == "" {
+		return nil
+	}
+	plantilla, err := s.r.GetPlantillaActiva(ctx, codigoPrestacion)
+	if errors.Is(err, ErrPlantillaNotFound) {
+		return nil
+	}
+	if err != nil {
+		log.Println("log de error al buscar plantilla de consentimiento", err.Error())
+		return ErrExec
+	}
+
+	firmado, err := s.r.GetUltimoFirmado(ctx, idPaciente, plantilla.ID)
+	if err != nil || !firmado.VigenteEn(fecha, plantilla) {
+		return ErrSinConsentimiento
+	}
+	return nil
+}
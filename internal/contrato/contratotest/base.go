@@ -0,0 +1,143 @@
+package contratotest
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+
+	"finalgo/pkg/basedatos"
+	"finalgo/pkg/config"
+	"finalgo/pkg/migraciones"
+)
+
+// EntornoMySQL es la variable con el DSN de la base MySQL de pruebas. Sin definir, las pruebas contra MySQL se
+// saltean.
+const EntornoMySQL = "PRUEBAS_MYSQL_DSN"
+
+// EntornoPostgres es la variable con el DSN de la base PostgreSQL de pruebas. Sin definir, las pruebas contra
+// PostgreSQL se saltean.
+const EntornoPostgres = "PRUEBAS_POSTGRES_DSN"
+
+// tablas que cargan las migraciones y no se vacían entre pruebas
+var catalogos = map[string]bool{"schema_migrations": true, "especialidad": true}
+
+// Requiere saltea la prueba si el motor necesita una base de pruebas que no está configurada
+func Requiere(t *testing.T, motor string) {
+	t.Helper()
+	if motor == config.MotorMySQL && os.Getenv(EntornoMySQL) == "" {
+		t.Skipf("sin %s no se prueba contra MySQL", EntornoMySQL)
+	}
+	if motor == config.MotorPostgres && os.Getenv(EntornoPostgres) == "" {
+		t.Skipf("sin %s no se prueba contra PostgreSQL", EntornoPostgres)
+	}
+}
+
+// Base devuelve una base del motor con el esquema al día y sin más datos que los catálogos de las migraciones.
+// SQLite usa un archivo nuevo en cada llamada; MySQL y PostgreSQL usan la base de su variable de entorno y la vacían,
+// así que nunca tiene que ser la de la clínica.
+func Base(t *testing.T, motor string) *sql.DB {
+	t.Helper()
+	Requiere(t, motor)
+	var db *sql.DB
+	switch motor {
+	case config.MotorSQLite:
+		db = abrirSqlite(t)
+	case config.MotorMySQL:
+		db = abrirMySQL(t)
+	case config.MotorPostgres:
+		var err error
+		db, err = sql.Open("postgres", os.Getenv(EntornoPostgres))
+		sinError(t, "abrir PostgreSQL", err)
+	default:
+		t.Fatalf("motor de base desconocido: %q", motor)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrador, err := migraciones.NewMigrador(db, motor)
+	sinError(t, "NewMigrador", err)
+	_, err = migrador.Up(ctx)
+	sinError(t, "migraciones", err)
+	switch motor {
+	case config.MotorMySQL:
+		vaciarMySQL(t, db)
+	case config.MotorPostgres:
+		vaciarPostgres(t, db)
+	}
+	return db
+}
+
+func abrirSqlite(t *testing.T) *sql.DB {
+	db, err := basedatos.Abrir(config.DB{
+		Motor:        config.MotorSQLite,
+		Archivo:      filepath.Join(t.TempDir(), "contrato.db"),
+		MaxAbiertas:  10,
+		MaxInactivas: 5,
+		VidaMaxMin:   30,
+	})
+	sinError(t, "abrir SQLite", err)
+	return db
+}
+
+// abrirMySQL abre la base de PRUEBAS_MYSQL_DSN, con las fechas como time.Time igual que en la clínica
+func abrirMySQL(t *testing.T) *sql.DB {
+	cfg, err := mysql.ParseDSN(os.Getenv(EntornoMySQL))
+	sinError(t, EntornoMySQL, err)
+	cfg.ParseTime = true
+	db, err := sql.Open("mysql", cfg.FormatDSN())
+	sinError(t, "abrir MySQL", err)
+	return db
+}
+
+// vaciarMySQL deja vacías las tablas que no son catálogos. TRUNCATE vuelve a empezar los AUTO_INCREMENT y no dispara
+// los triggers que protegen la auditoría; las foreign keys se apagan en la conexión que lo hace.
+func vaciarMySQL(t *testing.T, db *sql.DB) {
+	conn, err := db.Conn(ctx)
+	sinError(t, "conexión", err)
+	defer conn.Close()
+
+	filas, err := conn.QueryContext(ctx, `SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE()`)
+	sinError(t, "listar tablas", err)
+	tablas := []string{}
+	for filas.Next() {
+		var tabla string
+		sinError(t, "listar tablas", filas.Scan(&tabla))
+		if !catalogos[tabla] {
+			tablas = append(tablas, tabla)
+		}
+	}
+	sinError(t, "listar tablas", filas.Err())
+	filas.Close()
+
+	_, err = conn.ExecContext(ctx, `SET FOREIGN_KEY_CHECKS = 0`)
+	sinError(t, "apagar foreign keys", err)
+	defer conn.ExecContext(ctx, `SET FOREIGN_KEY_CHECKS = 1`)
+	for _, tabla := range tablas {
+		_, err := conn.ExecContext(ctx, "TRUNCATE TABLE `"+tabla+"`")
+		sinError(t, "vaciar "+tabla, err)
+	}
+}
+
+// vaciarPostgres deja vacías las tablas que no son catálogos en una sola sentencia: con CASCADE no importa el orden
+// de las foreign keys, RESTART IDENTITY vuelve a empezar los ID y los triggers de la auditoría no cubren TRUNCATE.
+func vaciarPostgres(t *testing.T, db *sql.DB) {
+	filas, err := db.QueryContext(ctx, `SELECT tablename FROM pg_tables WHERE schemaname = current_schema()`)
+	sinError(t, "listar tablas", err)
+	tablas := []string{}
+	for filas.Next() {
+		var tabla string
+		sinError(t, "listar tablas", filas.Scan(&tabla))
+		if !catalogos[tabla] {
+			tablas = append(tablas, `"`+tabla+`"`)
+		}
+	}
+	sinError(t, "listar tablas", filas.Err())
+	filas.Close()
+
+	_, err = db.ExecContext(ctx, "TRUNCATE TABLE "+strings.Join(tablas, ", ")+" RESTART IDENTITY CASCADE")
+	sinError(t, "vaciar tablas", err)
+}
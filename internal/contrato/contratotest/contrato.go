@@ -0,0 +1,101 @@
+// Package contratotest reúne las pruebas que tiene que pasar cualquier implementación de los repositorios de
+// paciente, odontólogo y turno (MySQL, SQLite, memoria, etc.), para que todas se comporten igual: IDs correlativos,
+// los mismos errores, baja lógica, filtros y orden de los listados. Sólo lo importan los tests.
+//
+// Se usa desde el test de cada implementación, pasándole una función que devuelve repositorios vacíos:
+//
+//	func TestRepositoryMemoria(t *testing.T) {
+//		contratotest.Odontologos(t, func(t *testing.T) odontologo.Repository {
+//			return odontologo.NewRepositoryMemoria()
+//		})
+//	}
+//
+// Para los motores SQL, Base devuelve en cada llamada una base vacía con el esquema al día. MySQL y PostgreSQL se
+// prueban sólo si PRUEBAS_MYSQL_DSN o PRUEBAS_POSTGRES_DSN apuntan a una base de pruebas, nunca a la de la clínica,
+// porque se vacía antes de cada prueba.
+package contratotest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fecha arma un instante en UTC con precisión de segundos, que es lo que guardan las columnas DATETIME
+func fecha(t *testing.T, valor string) time.Time {
+	t.Helper()
+	f, err := time.Parse("2006-01-02 15:04:05", valor)
+	if err != nil {
+		t.Fatalf("fecha inválida %q: %v", valor, err)
+	}
+	return f
+}
+
+// mismoDia compara sólo el día, que es lo que guardan las columnas DATE
+func mismoDia(a, b time.Time) bool {
+	return a.UTC().Format("2006-01-02") == b.UTC().Format("2006-01-02")
+}
+
+// esperarError falla si err no es el error esperado
+func esperarError(t *testing.T, operacion string, err, esperado error) {
+	t.Helper()
+	if !errors.Is(err, esperado) {
+		t.Fatalf("%s: se esperaba %q y dio %v", operacion, esperado, err)
+	}
+}
+
+// sinError corta la prueba si hubo error
+func sinError(t *testing.T, operacion string, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("%s: %v", operacion, err)
+	}
+}
+
+// crecientes controla que los IDs sean positivos, distintos y en orden de creación
+func crecientes(t *testing.T, ids []int) {
+	t.Helper()
+	for i, id := range ids {
+		if id <= 0 {
+			t.Fatalf("ID inválido: %d", id)
+		}
+		if i > 0 && id <= ids[i-1] {
+			t.Fatalf("los IDs no son crecientes: %v", ids)
+		}
+	}
+}
+
+// enParalelo corre crear n veces a la vez y controla que cada alta tenga un ID distinto
+func enParalelo(t *testing.T, n int, crear func(i int) (int, error)) {
+	t.Helper()
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	ids := map[int]bool{}
+	errores := []error{}
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			id, err := crear(i)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errores = append(errores, err)
+				return
+			}
+			ids[id] = true
+		}(i)
+	}
+	wg.Wait()
+
+	if len(errores) > 0 {
+		t.Fatalf("altas en paralelo: %v", errores[0])
+	}
+	if len(ids) != n {
+		t.Fatalf("altas en paralelo: %d IDs distintos para %d altas", len(ids), n)
+	}
+}
+
+var ctx = context.Background()
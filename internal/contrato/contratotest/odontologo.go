@@ -0,0 +1,197 @@
+package contratotest
+
+import (
+	"fmt"
+	"testing"
+
+	"finalgo/internal/odontologo"
+)
+
+// Odontologos prueba una implementación de odontologo.Repository. nuevo tiene que devolver un repositorio sin
+// odontólogos y con el catálogo de especialidades inicial.
+func Odontologos(t *testing.T, nuevo func(t *testing.T) odontologo.Repository) {
+	t.Run("alta y consulta", func(t *testing.T) {
+		r := nuevo(t)
+		lista, err := r.GetAll(ctx)
+		sinError(t, "GetAll", err)
+		if len(lista) != 0 {
+			t.Fatalf("el repositorio nuevo tiene %d odontólogos", len(lista))
+		}
+
+		ids := []int{}
+		for i, matricula := range []string{"100", "200", "300"} {
+			o, err := r.CreateOdontologo(ctx, odontologo.Odontologo{Apellido: "Apellido", Nombre: fmt.Sprint("Nombre ", i), Matricula: matricula})
+			sinError(t, "CreateOdontologo", err)
+			ids = append(ids, o.ID)
+		}
+		crecientes(t, ids)
+
+		o, err := r.GetOdontologoByID(ctx, ids[1])
+		sinError(t, "GetOdontologoByID", err)
+		if o.Matricula != "200" || o.Nombre != "Nombre 1" || o.Especialidades == nil || len(o.Especialidades) != 0 {
+			t.Fatalf("odontólogo leído distinto del guardado: %+v", o)
+		}
+		lista, err = r.GetAll(ctx)
+		sinError(t, "GetAll", err)
+		if len(lista) != 3 || lista[0].ID != ids[0] || lista[2].ID != ids[2] {
+			t.Fatalf("GetAll tiene que devolver los tres por ID: %+v", lista)
+		}
+
+		_, err = r.GetOdontologoByID(ctx, ids[2]+100)
+		esperarError(t, "GetOdontologoByID inexistente", err, odontologo.ErrNotFound)
+
+		id, err := r.GetOdontologoIdByMatricula(ctx, "300")
+		sinError(t, "GetOdontologoIdByMatricula", err)
+		if id != ids[2] {
+			t.Fatalf("la matrícula 300 es del %d y devolvió %d", ids[2], id)
+		}
+		_, err = r.GetOdontologoIdByMatricula(ctx, "999")
+		esperarError(t, "GetOdontologoIdByMatricula inexistente", err, odontologo.ErrNotFound)
+	})
+
+	t.Run("especialidades", func(t *testing.T) {
+		r := nuevo(t)
+		catalogo, err := r.GetEspecialidades(ctx)
+		sinError(t, "GetEspecialidades", err)
+		if len(catalogo) == 0 {
+			t.Fatal("el catálogo de especialidades está vacío")
+		}
+		for i := 1; i < len(catalogo); i++ {
+			if catalogo[i-1].Nombre > catalogo[i].Nombre {
+				t.Fatalf("el catálogo tiene que venir por nombre: %+v", catalogo)
+			}
+		}
+
+		o, err := r.CreateOdontologo(ctx, odontologo.Odontologo{
+			Apellido:       "Ruiz",
+			Nombre:         "Ana",
+			Matricula:      "100",
+			Especialidades: []odontologo.Especialidad{{Codigo: "ortodoncia"}, {Codigo: "endodoncia"}},
+		})
+		sinError(t, "CreateOdontologo con especialidades", err)
+		leido, err := r.GetOdontologoByID(ctx, o.ID)
+		sinError(t, "GetOdontologoByID", err)
+		if len(leido.Especialidades) != 2 || leido.Especialidades[0].Codigo != "endodoncia" || leido.Especialidades[0].ID == 0 {
+			t.Fatalf("las especialidades tienen que venir completas y por nombre: %+v", leido.Especialidades)
+		}
+
+		_, err = r.CreateOdontologo(ctx, odontologo.Odontologo{
+			Apellido:       "Paz",
+			Nombre:         "Luis",
+			Matricula:      "200",
+			Especialidades: []odontologo.Especialidad{{Codigo: "general"}, {Codigo: "no-existe"}},
+		})
+		esperarError(t, "CreateOdontologo con especialidad inexistente", err, odontologo.ErrEspecialidad)
+		if _, err := r.GetOdontologoIdByMatricula(ctx, "200"); err == nil {
+			t.Fatal("un alta con especialidad inexistente no tiene que guardar nada")
+		}
+
+		porEspecialidad, err := r.GetOdontologosByEspecialidad(ctx, "ortodoncia")
+		sinError(t, "GetOdontologosByEspecialidad", err)
+		if len(porEspecialidad) != 1 || porEspecialidad[0].ID != o.ID {
+			t.Fatalf("ortodoncia tiene que devolver sólo al %d: %+v", o.ID, porEspecialidad)
+		}
+		porEspecialidad, err = r.GetOdontologosByEspecialidad(ctx, "protesis")
+		sinError(t, "GetOdontologosByEspecialidad", err)
+		if len(porEspecialidad) != 0 {
+			t.Fatalf("nadie hace prótesis: %+v", porEspecialidad)
+		}
+	})
+
+	t.Run("modificación", func(t *testing.T) {
+		r := nuevo(t)
+		o, err := r.CreateOdontologo(ctx, odontologo.Odontologo{
+			Apellido:       "Ruiz",
+			Nombre:         "Ana",
+			Matricula:      "100",
+			Especialidades: []odontologo.Especialidad{{Codigo: "general"}},
+		})
+		sinError(t, "CreateOdontologo", err)
+
+		// sin cambios no es un error y la versión sube igual
+		modificado, err := r.UpdateOdontologo(ctx, odontologo.Odontologo{ID: o.ID, Apellido: "Ruiz", Nombre: "Ana", Matricula: "100", Version: o.Version})
+		sinError(t, "UpdateOdontologo sin cambios", err)
+		if o.Version != 1 || modificado.Version != 2 {
+			t.Fatalf("el alta empieza en la versión 1 y cada modificación la sube: %d, %d", o.Version, modificado.Version)
+		}
+		_, err = r.UpdateOdontologo(ctx, odontologo.Odontologo{ID: o.ID, Apellido: "Ruiz", Nombre: "Ana", Matricula: "100", Version: o.Version})
+		esperarError(t, "UpdateOdontologo con versión vieja", err, odontologo.ErrVersion)
+
+		// con Especialidades en nil se conservan
+		_, err = r.UpdateOdontologo(ctx, odontologo.Odontologo{ID: o.ID, Apellido: "Ruiz", Nombre: "Ana María", Matricula: "101", Version: 2})
+		sinError(t, "UpdateOdontologo", err)
+		leido, err := r.GetOdontologoByID(ctx, o.ID)
+		sinError(t, "GetOdontologoByID", err)
+		if leido.Nombre != "Ana María" || leido.Matricula != "101" || len(leido.Especialidades) != 1 || leido.Version != 3 {
+			t.Fatalf("modificación mal guardada: %+v", leido)
+		}
+
+		_, err = r.UpdateOdontologo(ctx, odontologo.Odontologo{ID: o.ID, Apellido: "Ruiz", Nombre: "Ana María", Matricula: "101", Especialidades: []odontologo.Especialidad{}, Version: 3})
+		sinError(t, "UpdateOdontologo sin especialidades", err)
+		leido, _ = r.GetOdontologoByID(ctx, o.ID)
+		if len(leido.Especialidades) != 0 {
+			t.Fatalf("con una lista vacía se tienen que borrar las especialidades: %+v", leido.Especialidades)
+		}
+
+		_, err = r.UpdateOdontologo(ctx, odontologo.Odontologo{ID: o.ID + 100, Apellido: "X", Nombre: "Y", Matricula: "Z"})
+		esperarError(t, "UpdateOdontologo inexistente", err, odontologo.ErrNotFound)
+	})
+
+	t.Run("baja y restauración", func(t *testing.T) {
+		r := nuevo(t)
+		o, err := r.CreateOdontologo(ctx, odontologo.Odontologo{Apellido: "Ruiz", Nombre: "Ana", Matricula: "100"})
+		sinError(t, "CreateOdontologo", err)
+		otro, err := r.CreateOdontologo(ctx, odontologo.Odontologo{Apellido: "Paz", Nombre: "Luis", Matricula: "200"})
+		sinError(t, "CreateOdontologo", err)
+
+		baja := fecha(t, "2024-05-10 12:30:00")
+		esperarError(t, "DeleteOdontologo con versión vieja", r.DeleteOdontologo(ctx, o.ID, o.Version+1, baja, 0), odontologo.ErrVersion)
+		sinError(t, "DeleteOdontologo", r.DeleteOdontologo(ctx, o.ID, o.Version, baja, 0))
+		esperarError(t, "DeleteOdontologo repetido", r.DeleteOdontologo(ctx, o.ID, o.Version+1, baja, 0), odontologo.ErrNotFound)
+		esperarError(t, "DeleteOdontologo inexistente", r.DeleteOdontologo(ctx, otro.ID+100, 1, baja, 0), odontologo.ErrNotFound)
+
+		_, err = r.GetOdontologoByID(ctx, o.ID)
+		esperarError(t, "GetOdontologoByID dado de baja", err, odontologo.ErrNotFound)
+		_, err = r.GetOdontologoIdByMatricula(ctx, "100")
+		esperarError(t, "GetOdontologoIdByMatricula dado de baja", err, odontologo.ErrNotFound)
+		_, err = r.UpdateOdontologo(ctx, odontologo.Odontologo{ID: o.ID, Apellido: "X", Nombre: "Y", Matricula: "Z", Version: o.Version + 1})
+		esperarError(t, "UpdateOdontologo dado de baja", err, odontologo.ErrNotFound)
+		lista, _ := r.GetAll(ctx)
+		if len(lista) != 1 || lista[0].ID != otro.ID {
+			t.Fatalf("GetAll no tiene que traer a los dados de baja: %+v", lista)
+		}
+
+		eliminados, err := r.GetEliminados(ctx)
+		sinError(t, "GetEliminados", err)
+		if len(eliminados) != 1 || eliminados[0].ID != o.ID || eliminados[0].DeletedAt == nil || !eliminados[0].DeletedAt.Equal(baja) {
+			t.Fatalf("GetEliminados tiene que traer la baja con su fecha: %+v", eliminados)
+		}
+		eliminado, err := r.GetEliminadoByID(ctx, o.ID)
+		sinError(t, "GetEliminadoByID", err)
+		if eliminado.ID != o.ID || eliminado.DeletedAt == nil || !eliminado.DeletedAt.Equal(baja) {
+			t.Fatalf("GetEliminadoByID tiene que traer la baja con su fecha: %+v", eliminado)
+		}
+		_, err = r.GetEliminadoByID(ctx, otro.ID)
+		esperarError(t, "GetEliminadoByID activo", err, odontologo.ErrNotFound)
+		_, err = r.GetEliminadoByID(ctx, otro.ID+100)
+		esperarError(t, "GetEliminadoByID inexistente", err, odontologo.ErrNotFound)
+
+		esperarError(t, "RestaurarOdontologo activo", r.RestaurarOdontologo(ctx, otro.ID), odontologo.ErrNotFound)
+		sinError(t, "RestaurarOdontologo", r.RestaurarOdontologo(ctx, o.ID))
+		if _, err := r.GetOdontologoByID(ctx, o.ID); err != nil {
+			t.Fatalf("el restaurado tiene que volver a verse: %v", err)
+		}
+		eliminados, _ = r.GetEliminados(ctx)
+		if len(eliminados) != 0 {
+			t.Fatalf("el restaurado no tiene que seguir entre los eliminados: %+v", eliminados)
+		}
+	})
+
+	t.Run("altas en paralelo", func(t *testing.T) {
+		r := nuevo(t)
+		enParalelo(t, 20, func(i int) (int, error) {
+			o, err := r.CreateOdontologo(ctx, odontologo.Odontologo{Apellido: "Apellido", Nombre: "Nombre", Matricula: fmt.Sprint(1000 + i)})
+			return o.ID, err
+		})
+	})
+}
@@ -0,0 +1,339 @@
+package contratotest
+
+import (
+	"fmt"
+	"testing"
+
+	"finalgo/internal/paciente"
+)
+
+// Pacientes prueba una implementación de paciente.Repository. nuevo tiene que devolver un repositorio sin pacientes.
+func Pacientes(t *testing.T, nuevo func(t *testing.T) paciente.Repository) {
+	t.Run("alta y consulta", func(t *testing.T) {
+		r := nuevo(t)
+		lista, err := r.GetAll(ctx)
+		sinError(t, "GetAll", err)
+		if len(lista) != 0 {
+			t.Fatalf("el repositorio nuevo tiene %d pacientes", len(lista))
+		}
+
+		ids := []int{}
+		for _, dni := range []string{"30111222", "30222333", "30333444"} {
+			p, err := r.CreatePaciente(ctx, nuevoPaciente(t, dni))
+			sinError(t, "CreatePaciente", err)
+			ids = append(ids, p.ID)
+		}
+		crecientes(t, ids)
+
+		p, err := r.GetPacienteByID(ctx, ids[1])
+		sinError(t, "GetPacienteByID", err)
+		if p.DNI != "30222333" || p.Apellido != "Gómez" || p.Email != "paciente@example.com" || !p.AceptaRecordatorios {
+			t.Fatalf("paciente leído distinto del guardado: %+v", p)
+		}
+		if !mismoDia(p.Alta, fecha(t, "2024-03-01 00:00:00")) || !mismoDia(p.FechaNacimiento, fecha(t, "1990-07-15 00:00:00")) {
+			t.Fatalf("fechas mal guardadas: alta %v, nacimiento %v", p.Alta, p.FechaNacimiento)
+		}
+		if len(p.Telefonos) != 2 || !p.Telefonos[0].Principal || p.Telefonos[0].Numero != "+5491144445555" {
+			t.Fatalf("los teléfonos tienen que venir con el principal primero: %+v", p.Telefonos)
+		}
+		if len(p.ContactosEmergencia) != 1 || p.ContactosEmergencia[0].Relacion != "madre" {
+			t.Fatalf("contactos de emergencia mal guardados: %+v", p.ContactosEmergencia)
+		}
+
+		lista, err = r.GetAll(ctx)
+		sinError(t, "GetAll", err)
+		if len(lista) != 3 || lista[0].ID != ids[0] || lista[2].ID != ids[2] || len(lista[0].Telefonos) != 2 {
+			t.Fatalf("GetAll tiene que devolver los tres por ID y completos: %+v", lista)
+		}
+
+		_, err = r.GetPacienteByID(ctx, ids[2]+100)
+		esperarError(t, "GetPacienteByID inexistente", err, paciente.ErrNotFound)
+		id, err := r.GetPacienteIDByDNI(ctx, "30333444")
+		sinError(t, "GetPacienteIDByDNI", err)
+		if id != ids[2] {
+			t.Fatalf("el DNI 30333444 es del %d y devolvió %d", ids[2], id)
+		}
+		_, err = r.GetPacienteIDByDNI(ctx, "99999999")
+		esperarError(t, "GetPacienteIDByDNI inexistente", err, paciente.ErrNotFound)
+	})
+
+	t.Run("sin datos opcionales", func(t *testing.T) {
+		r := nuevo(t)
+		p, err := r.CreatePaciente(ctx, paciente.Paciente{Nombre: "Ana", Apellido: "Ruiz", DNI: "40111222", Alta: fecha(t, "2024-03-01 00:00:00")})
+		sinError(t, "CreatePaciente", err)
+		leido, err := r.GetPacienteByID(ctx, p.ID)
+		sinError(t, "GetPacienteByID", err)
+		if !leido.FechaNacimiento.IsZero() {
+			t.Fatalf("sin fecha de nacimiento tiene que volver en cero: %v", leido.FechaNacimiento)
+		}
+		if leido.Telefonos == nil || len(leido.Telefonos) != 0 || leido.ContactosEmergencia == nil || len(leido.ContactosEmergencia) != 0 {
+			t.Fatalf("sin teléfonos ni contactos tienen que volver listas vacías: %+v", leido)
+		}
+	})
+
+	t.Run("teléfono repetido", func(t *testing.T) {
+		r := nuevo(t)
+		p := nuevoPaciente(t, "30111222")
+		p.Telefonos = append(p.Telefonos, paciente.Telefono{Numero: p.Telefonos[0].Numero, Tipo: paciente.TelefonoFijo})
+		_, err := r.CreatePaciente(ctx, p)
+		esperarError(t, "CreatePaciente con teléfono repetido", err, paciente.ErrExec)
+		if _, err := r.GetPacienteIDByDNI(ctx, "30111222"); err == nil {
+			t.Fatal("un alta con teléfono repetido no tiene que guardar nada")
+		}
+	})
+
+	t.Run("modificación", func(t *testing.T) {
+		r := nuevo(t)
+		p, err := r.CreatePaciente(ctx, nuevoPaciente(t, "30111222"))
+		sinError(t, "CreatePaciente", err)
+
+		if p.Version != 1 {
+			t.Fatalf("el alta tiene que empezar en la versión 1: %d", p.Version)
+		}
+
+		// sin cambios no es un error y la versión sube igual
+		viejo := p
+		p, err = r.UpdatePaciente(ctx, p)
+		sinError(t, "UpdatePaciente sin cambios", err)
+		if p.Version != 2 {
+			t.Fatalf("la modificación tiene que subir la versión: %d", p.Version)
+		}
+		_, err = r.UpdatePaciente(ctx, viejo)
+		esperarError(t, "UpdatePaciente con versión vieja", err, paciente.ErrVersion)
+
+		p.Domicilio = "Calle Nueva 456"
+		p.Telefonos = []paciente.Telefono{{Numero: "+5491166667777", Tipo: paciente.TelefonoMovil, Principal: true}}
+		p.ContactosEmergencia = nil
+		_, err = r.UpdatePaciente(ctx, p)
+		sinError(t, "UpdatePaciente", err)
+		leido, err := r.GetPacienteByID(ctx, p.ID)
+		sinError(t, "GetPacienteByID", err)
+		if leido.Domicilio != "Calle Nueva 456" || len(leido.Telefonos) != 1 || leido.Telefonos[0].Numero != "+5491166667777" || len(leido.ContactosEmergencia) != 0 {
+			t.Fatalf("la modificación tiene que reemplazar teléfonos y contactos: %+v", leido)
+		}
+		if leido.Version != 3 {
+			t.Fatalf("la versión guardada tiene que ser la 3: %d", leido.Version)
+		}
+
+		p.ID += 100
+		_, err = r.UpdatePaciente(ctx, p)
+		esperarError(t, "UpdatePaciente inexistente", err, paciente.ErrNotFound)
+	})
+
+	t.Run("baja y restauración", func(t *testing.T) {
+		r := nuevo(t)
+		p, err := r.CreatePaciente(ctx, nuevoPaciente(t, "30111222"))
+		sinError(t, "CreatePaciente", err)
+		otro, err := r.CreatePaciente(ctx, nuevoPaciente(t, "30222333"))
+		sinError(t, "CreatePaciente", err)
+
+		baja := fecha(t, "2024-05-10 12:30:00")
+		esperarError(t, "DeletePaciente con versión vieja", r.DeletePaciente(ctx, p.ID, p.Version+1, baja, 0), paciente.ErrVersion)
+		sinError(t, "DeletePaciente", r.DeletePaciente(ctx, p.ID, p.Version, baja, 0))
+		esperarError(t, "DeletePaciente repetido", r.DeletePaciente(ctx, p.ID, p.Version+1, baja, 0), paciente.ErrNotFound)
+		esperarError(t, "DeletePaciente inexistente", r.DeletePaciente(ctx, otro.ID+100, 1, baja, 0), paciente.ErrNotFound)
+
+		_, err = r.GetPacienteByID(ctx, p.ID)
+		esperarError(t, "GetPacienteByID dado de baja", err, paciente.ErrNotFound)
+		_, err = r.GetPacienteIDByDNI(ctx, "30111222")
+		esperarError(t, "GetPacienteIDByDNI dado de baja", err, paciente.ErrNotFound)
+		_, err = r.UpdatePaciente(ctx, p)
+		esperarError(t, "UpdatePaciente dado de baja", err, paciente.ErrNotFound)
+		lista, _ := r.GetAll(ctx)
+		if len(lista) != 1 || lista[0].ID != otro.ID {
+			t.Fatalf("GetAll no tiene que traer a los dados de baja: %+v", lista)
+		}
+
+		eliminados, err := r.GetEliminados(ctx)
+		sinError(t, "GetEliminados", err)
+		if len(eliminados) != 1 || eliminados[0].ID != p.ID || eliminados[0].DeletedAt == nil || !eliminados[0].DeletedAt.Equal(baja) {
+			t.Fatalf("GetEliminados tiene que traer la baja con su fecha: %+v", eliminados)
+		}
+		eliminado, err := r.GetEliminadoByID(ctx, p.ID)
+		sinError(t, "GetEliminadoByID", err)
+		if eliminado.ID != p.ID || eliminado.DeletedAt == nil || !eliminado.DeletedAt.Equal(baja) || len(eliminado.Telefonos) != 2 {
+			t.Fatalf("GetEliminadoByID tiene que traer la baja con su fecha y sus teléfonos: %+v", eliminado)
+		}
+		_, err = r.GetEliminadoByID(ctx, otro.ID)
+		esperarError(t, "GetEliminadoByID activo", err, paciente.ErrNotFound)
+		_, err = r.GetEliminadoByID(ctx, otro.ID+100)
+		esperarError(t, "GetEliminadoByID inexistente", err, paciente.ErrNotFound)
+
+		esperarError(t, "RestaurarPaciente activo", r.RestaurarPaciente(ctx, otro.ID), paciente.ErrNotFound)
+		sinError(t, "RestaurarPaciente", r.RestaurarPaciente(ctx, p.ID))
+		leido, err := r.GetPacienteByID(ctx, p.ID)
+		if err != nil || len(leido.Telefonos) != 2 || leido.Version != 3 {
+			t.Fatalf("el restaurado tiene que volver a verse con sus teléfonos y la versión de la baja y la restauración: %+v, %v", leido, err)
+		}
+		eliminados, _ = r.GetEliminados(ctx)
+		if len(eliminados) != 0 {
+			t.Fatalf("el restaurado no tiene que seguir entre los eliminados: %+v", eliminados)
+		}
+	})
+
+	t.Run("alertas médicas", func(t *testing.T) {
+		r := nuevo(t)
+		p, err := r.CreatePaciente(ctx, nuevoPaciente(t, "30111222"))
+		sinError(t, "CreatePaciente", err)
+		otro, err := r.CreatePaciente(ctx, nuevoPaciente(t, "30222333"))
+		sinError(t, "CreatePaciente", err)
+
+		_, err = r.CreateAlerta(ctx, paciente.AlertaMedica{IdPaciente: otro.ID + 100, Tipo: paciente.AlertaAlergia, Severidad: paciente.SeveridadAlta, Activa: true})
+		if err == nil {
+			t.Fatal("CreateAlerta de un paciente inexistente tiene que fallar")
+		}
+
+		alertas := []paciente.AlertaMedica{
+			{Tipo: paciente.AlertaMedicacion, Descripcion: "aspirina", Severidad: paciente.SeveridadBaja, FechaDesde: fecha(t, "2024-01-01 00:00:00")},
+			{Tipo: paciente.AlertaAlergia, Descripcion: "penicilina", Severidad: paciente.SeveridadAlta, FechaDesde: fecha(t, "2023-01-01 00:00:00")},
+			{Tipo: paciente.AlertaCondicionCronica, Descripcion: "diabetes", Severidad: paciente.SeveridadMedia, FechaDesde: fecha(t, "2022-01-01 00:00:00")},
+			{Tipo: paciente.AlertaAlergia, Descripcion: "látex", Severidad: paciente.SeveridadAlta, FechaDesde: fecha(t, "2024-02-01 00:00:00"), FechaHasta: fecha(t, "2025-02-01 10:00:00")},
+		}
+		ids := []int{}
+		for _, a := range alertas {
+			a.IdPaciente = p.ID
+			a.Activa = true
+			creada, err := r.CreateAlerta(ctx, a)
+			sinError(t, "CreateAlerta", err)
+			ids = append(ids, creada.ID)
+		}
+		crecientes(t, ids)
+
+		lista, err := r.GetAlertasByPaciente(ctx, p.ID)
+		sinError(t, "GetAlertasByPaciente", err)
+		orden := []string{"látex", "penicilina", "diabetes", "aspirina"}
+		if len(lista) != len(orden) {
+			t.Fatalf("se esperaban %d alertas: %+v", len(orden), lista)
+		}
+		for i, descripcion := range orden {
+			if lista[i].Descripcion != descripcion {
+				t.Fatalf("las alertas tienen que venir por severidad y de la más nueva a la más vieja: %+v", lista)
+			}
+		}
+		if !lista[0].FechaHasta.Equal(fecha(t, "2025-02-01 10:00:00")) || !lista[1].FechaHasta.IsZero() {
+			t.Fatalf("fechas de vigencia mal guardadas: %+v", lista[:2])
+		}
+		lista, err = r.GetAlertasByPaciente(ctx, otro.ID)
+		sinError(t, "GetAlertasByPaciente", err)
+		if lista == nil || len(lista) != 0 {
+			t.Fatalf("sin alertas tiene que volver una lista vacía: %+v", lista)
+		}
+
+		a, err := r.GetAlertaByID(ctx, p.ID, ids[1])
+		sinError(t, "GetAlertaByID", err)
+		viejo := a
+		a.Activa = false
+		a.Descripcion = "penicilina y derivados"
+		_, err = r.UpdateAlerta(ctx, a)
+		sinError(t, "UpdateAlerta", err)
+		a, _ = r.GetAlertaByID(ctx, p.ID, ids[1])
+		if a.Activa || a.Descripcion != "penicilina y derivados" || a.Version != 2 {
+			t.Fatalf("modificación de la alerta mal guardada: %+v", a)
+		}
+		_, err = r.UpdateAlerta(ctx, viejo)
+		esperarError(t, "UpdateAlerta con versión vieja", err, paciente.ErrVersionAlerta)
+		viejo.ID = ids[3] + 100
+		_, err = r.UpdateAlerta(ctx, viejo)
+		esperarError(t, "UpdateAlerta inexistente", err, paciente.ErrAlertaNotFound)
+
+		// las activas de varios pacientes juntas: por paciente y, en cada uno, en el orden de GetAlertasByPaciente
+		_, err = r.CreateAlerta(ctx, paciente.AlertaMedica{IdPaciente: otro.ID, Tipo: paciente.AlertaAlergia, Descripcion: "iodo", Severidad: paciente.SeveridadMedia, Activa: true, FechaDesde: fecha(t, "2024-03-01 00:00:00")})
+		sinError(t, "CreateAlerta", err)
+		activas, err := r.GetAlertasActivasByPacientes(ctx, []int{otro.ID, p.ID, otro.ID + 100})
+		sinError(t, "GetAlertasActivasByPacientes", err)
+		orden = []string{"látex", "diabetes", "aspirina", "iodo"}
+		if len(activas) != len(orden) {
+			t.Fatalf("se esperaban %d alertas activas: %+v", len(orden), activas)
+		}
+		for i, descripcion := range orden {
+			if activas[i].Descripcion != descripcion {
+				t.Fatalf("las alertas activas tienen que venir por paciente, severidad y de la más nueva a la más vieja: %+v", activas)
+			}
+		}
+		activas, err = r.GetAlertasActivasByPacientes(ctx, []int{})
+		sinError(t, "GetAlertasActivasByPacientes sin pacientes", err)
+		if activas == nil || len(activas) != 0 {
+			t.Fatalf("sin pacientes tiene que volver una lista vacía: %+v", activas)
+		}
+
+		_, err = r.GetAlertaByID(ctx, otro.ID, ids[1])
+		esperarError(t, "GetAlertaByID de otro paciente", err, paciente.ErrAlertaNotFound)
+		esperarError(t, "DeleteAlerta de otro paciente", r.DeleteAlerta(ctx, otro.ID, ids[1], a.Version), paciente.ErrAlertaNotFound)
+		esperarError(t, "DeleteAlerta con versión vieja", r.DeleteAlerta(ctx, p.ID, ids[1], 1), paciente.ErrVersionAlerta)
+		sinError(t, "DeleteAlerta", r.DeleteAlerta(ctx, p.ID, ids[1], a.Version))
+		esperarError(t, "DeleteAlerta repetido", r.DeleteAlerta(ctx, p.ID, ids[1], a.Version), paciente.ErrAlertaNotFound)
+		_, err = r.GetAlertaByID(ctx, p.ID, ids[1])
+		esperarError(t, "GetAlertaByID borrada", err, paciente.ErrAlertaNotFound)
+	})
+
+	t.Run("responsables", func(t *testing.T) {
+		r := nuevo(t)
+		menor, err := r.CreatePaciente(ctx, nuevoPaciente(t, "50111222"))
+		sinError(t, "CreatePaciente", err)
+		madre, err := r.CreatePaciente(ctx, nuevoPaciente(t, "30222333"))
+		sinError(t, "CreatePaciente", err)
+
+		_, err = r.CreateResponsable(ctx, paciente.Responsable{IdPaciente: madre.ID + 100, Nombre: "Nadie", DNI: "1", Relacion: "padre"})
+		if err == nil {
+			t.Fatal("CreateResponsable de un paciente inexistente tiene que fallar")
+		}
+
+		primero, err := r.CreateResponsable(ctx, paciente.Responsable{IdPaciente: menor.ID, IdResponsable: madre.ID, Nombre: "Laura Gómez", DNI: madre.DNI, Relacion: "madre"})
+		sinError(t, "CreateResponsable", err)
+		segundo, err := r.CreateResponsable(ctx, paciente.Responsable{IdPaciente: menor.ID, Nombre: "Jorge Pérez", DNI: "28111222", Relacion: "padre", Telefono: "+5491188889999"})
+		sinError(t, "CreateResponsable", err)
+		crecientes(t, []int{primero.ID, segundo.ID})
+
+		lista, err := r.GetResponsables(ctx, menor.ID)
+		sinError(t, "GetResponsables", err)
+		if len(lista) != 2 || lista[0].ID != primero.ID || lista[0].IdResponsable != madre.ID || lista[1].IdResponsable != 0 || lista[1].Telefono != "+5491188889999" || lista[0].Version != 1 {
+			t.Fatalf("responsables mal guardados: %+v", lista)
+		}
+		lista, err = r.GetResponsables(ctx, madre.ID)
+		sinError(t, "GetResponsables", err)
+		if lista == nil || len(lista) != 0 {
+			t.Fatalf("sin responsables tiene que volver una lista vacía: %+v", lista)
+		}
+
+		_, err = r.GetResponsableByID(ctx, madre.ID, primero.ID)
+		esperarError(t, "GetResponsableByID de otro paciente", err, paciente.ErrResponsableNotFound)
+		esperarError(t, "DeleteResponsable de otro paciente", r.DeleteResponsable(ctx, madre.ID, primero.ID, primero.Version), paciente.ErrResponsableNotFound)
+		esperarError(t, "DeleteResponsable con versión vieja", r.DeleteResponsable(ctx, menor.ID, primero.ID, primero.Version+1), paciente.ErrVersionResponsable)
+		sinError(t, "DeleteResponsable", r.DeleteResponsable(ctx, menor.ID, primero.ID, primero.Version))
+		esperarError(t, "DeleteResponsable repetido", r.DeleteResponsable(ctx, menor.ID, primero.ID, primero.Version), paciente.ErrResponsableNotFound)
+		lista, _ = r.GetResponsables(ctx, menor.ID)
+		if len(lista) != 1 || lista[0].ID != segundo.ID {
+			t.Fatalf("después de la baja tiene que quedar sólo el segundo: %+v", lista)
+		}
+	})
+
+	t.Run("altas en paralelo", func(t *testing.T) {
+		r := nuevo(t)
+		enParalelo(t, 20, func(i int) (int, error) {
+			p := nuevoPaciente(t, fmt.Sprint(20000000+i))
+			p.Telefonos = []paciente.Telefono{{Numero: fmt.Sprint("+54911", 40000000+i), Tipo: paciente.TelefonoMovil, Principal: true}}
+			creado, err := r.CreatePaciente(ctx, p)
+			return creado.ID, err
+		})
+	})
+}
+
+// nuevoPaciente arma un paciente completo, con el teléfono principal cargado después del fijo
+func nuevoPaciente(t *testing.T, dni string) paciente.Paciente {
+	return paciente.Paciente{
+		Nombre:          "Laura",
+		Apellido:        "Gómez",
+		Domicilio:       "Calle Falsa 123",
+		DNI:             dni,
+		Alta:            fecha(t, "2024-03-01 00:00:00"),
+		FechaNacimiento: fecha(t, "1990-07-15 00:00:00"),
+		Telefonos: []paciente.Telefono{
+			{Numero: "+541143334444", Tipo: paciente.TelefonoFijo},
+			{Numero: "+5491144445555", Tipo: paciente.TelefonoMovil, Principal: true},
+		},
+		Email:               "paciente@example.com",
+		CanalPreferido:      paciente.CanalWhatsApp,
+		AceptaRecordatorios: true,
+		ContactosEmergencia: []paciente.ContactoEmergencia{{Nombre: "Marta Gómez", Relacion: "madre", Telefono: "+5491155556666"}},
+	}
+}
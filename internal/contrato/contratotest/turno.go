@@ -0,0 +1,360 @@
+package contratotest
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"finalgo/internal/odontologo"
+	"finalgo/internal/paciente"
+	"finalgo/internal/turno"
+)
+
+// Turnos prueba una implementación de turno.Repository. nuevo tiene que devolver repositorios vacíos de turnos,
+// pacientes y odontólogos sobre los mismos datos: los pacientes y odontólogos de los turnos se dan de alta con esos
+// repositorios, así valen también las foreign keys.
+func Turnos(t *testing.T, nuevo func(t *testing.T) (turno.Repository, paciente.Repository, odontologo.Repository)) {
+	t.Run("alta y consulta", func(t *testing.T) {
+		r, pacientes, odontologos := nuevo(t)
+		idPaciente, idOdontologo := altaPaciente(t, pacientes, "30111222"), altaOdontologo(t, odontologos, "100")
+		lista, err := r.GetAll(ctx)
+		sinError(t, "GetAll", err)
+		if len(lista) != 0 {
+			t.Fatalf("el repositorio nuevo tiene %d turnos", len(lista))
+		}
+
+		ids := []int{}
+		for _, hora := range []string{"2024-06-03 10:00:00", "2024-06-03 09:00:00", "2024-06-04 11:30:00"} {
+			creado, err := r.CreateTurno(ctx, nuevoTurno(t, idPaciente, idOdontologo, hora))
+			sinError(t, "CreateTurno", err)
+			ids = append(ids, creado.ID)
+		}
+		crecientes(t, ids)
+
+		leido, err := r.GetTurnoByID(ctx, ids[1])
+		sinError(t, "GetTurnoByID", err)
+		if leido.IdPaciente != idPaciente || leido.IdOdontologo != idOdontologo || !leido.FechaHora.Equal(fecha(t, "2024-06-03 09:00:00")) ||
+			leido.Descripcion != "control" || leido.CodigoPrestacion != "CONSULTA" || leido.Estado != turno.EstadoPendiente {
+			t.Fatalf("turno leído distinto del guardado: %+v", leido)
+		}
+		lista, err = r.GetAll(ctx)
+		sinError(t, "GetAll", err)
+		if len(lista) != 3 || lista[0].ID != ids[0] || lista[2].ID != ids[2] {
+			t.Fatalf("GetAll tiene que devolver los tres por ID: %+v", lista)
+		}
+
+		_, err = r.GetTurnoByID(ctx, ids[2]+100)
+		esperarError(t, "GetTurnoByID inexistente", err, turno.ErrNotFound)
+	})
+
+	t.Run("filtros y agenda", func(t *testing.T) {
+		r, pacientes, odontologos := nuevo(t)
+		ana, luis := altaPaciente(t, pacientes, "30111222"), altaPaciente(t, pacientes, "30222333")
+		ruiz, paz := altaOdontologo(t, odontologos, "100"), altaOdontologo(t, odontologos, "200")
+
+		crear := func(idPaciente, idOdontologo int, hora string) int {
+			creado, err := r.CreateTurno(ctx, nuevoTurno(t, idPaciente, idOdontologo, hora))
+			sinError(t, "CreateTurno", err)
+			return creado.ID
+		}
+		tarde := crear(ana, ruiz, "2024-06-03 16:00:00")
+		temprano := crear(luis, ruiz, "2024-06-03 09:00:00")
+		otroDia := crear(ana, ruiz, "2024-06-04 09:00:00")
+		otroOdontologo := crear(ana, paz, "2024-06-03 10:00:00")
+
+		porPaciente, err := r.GetTurnoByPaciente(ctx, ana)
+		sinError(t, "GetTurnoByPaciente", err)
+		if len(porPaciente) != 3 || porPaciente[0].ID != tarde || porPaciente[2].ID != otroOdontologo {
+			t.Fatalf("GetTurnoByPaciente tiene que traer los tres de Ana por ID: %+v", porPaciente)
+		}
+		porOdontologo, err := r.GetTurnoByOdontologo(ctx, paz)
+		sinError(t, "GetTurnoByOdontologo", err)
+		if len(porOdontologo) != 1 || porOdontologo[0].ID != otroOdontologo {
+			t.Fatalf("GetTurnoByOdontologo tiene que traer sólo el de Paz: %+v", porOdontologo)
+		}
+		porPaciente, err = r.GetTurnoByPaciente(ctx, luis+100)
+		sinError(t, "GetTurnoByPaciente sin turnos", err)
+		if len(porPaciente) != 0 {
+			t.Fatalf("un paciente sin turnos no tiene que traer nada: %+v", porPaciente)
+		}
+
+		// la agenda incluye el inicio del período y excluye el final, y viene por horario
+		agenda, err := r.GetAgenda(ctx, ruiz, fecha(t, "2024-06-03 09:00:00"), fecha(t, "2024-06-04 09:00:00"))
+		sinError(t, "GetAgenda", err)
+		if len(agenda) != 2 || agenda[0].ID != temprano || agenda[1].ID != tarde {
+			t.Fatalf("la agenda del 3 de Ruiz tiene que traer %d y %d por horario: %+v", temprano, tarde, agenda)
+		}
+		agenda, err = r.GetAgenda(ctx, paz, fecha(t, "2024-06-04 00:00:00"), fecha(t, "2024-06-05 00:00:00"))
+		sinError(t, "GetAgenda vacía", err)
+		if agenda == nil || len(agenda) != 0 {
+			t.Fatalf("una agenda sin turnos tiene que ser una lista vacía: %+v", agenda)
+		}
+
+		sinError(t, "DeleteTurno", r.DeleteTurno(ctx, otroDia, 1, fecha(t, "2024-05-01 08:00:00"), 0))
+		sinError(t, "UpdateEstado", r.UpdateEstado(ctx, tarde, 1, turno.EstadoCancelado))
+		cantidad, err := r.CountTurnosOdontologoPaciente(ctx, ruiz, ana)
+		sinError(t, "CountTurnosOdontologoPaciente", err)
+		if cantidad != 0 {
+			t.Fatalf("no cuentan los cancelados ni los dados de baja, dio %d", cantidad)
+		}
+		cantidad, err = r.CountTurnosOdontologoPaciente(ctx, ruiz, luis)
+		sinError(t, "CountTurnosOdontologoPaciente", err)
+		if cantidad != 1 {
+			t.Fatalf("Luis tiene un turno con Ruiz, dio %d", cantidad)
+		}
+	})
+
+	t.Run("modificación y estado", func(t *testing.T) {
+		r, pacientes, odontologos := nuevo(t)
+		idPaciente := altaPaciente(t, pacientes, "30111222")
+		ruiz, paz := altaOdontologo(t, odontologos, "100"), altaOdontologo(t, odontologos, "200")
+		creado, err := r.CreateTurno(ctx, nuevoTurno(t, idPaciente, ruiz, "2024-06-03 10:00:00"))
+		sinError(t, "CreateTurno", err)
+
+		// el cambio de estado también sube la versión
+		sinError(t, "UpdateEstado", r.UpdateEstado(ctx, creado.ID, creado.Version, turno.EstadoConfirmado))
+		esperarError(t, "UpdateEstado con versión vieja", r.UpdateEstado(ctx, creado.ID, creado.Version, turno.EstadoCancelado), turno.ErrVersion)
+		modificado := nuevoTurno(t, idPaciente, paz, "2024-06-05 15:30:00")
+		modificado.ID = creado.ID
+		modificado.Descripcion = "limpieza"
+		modificado.CodigoPrestacion = "LIMPIEZA"
+		modificado.Version = creado.Version
+		_, err = r.UpdateTurno(ctx, modificado)
+		esperarError(t, "UpdateTurno con versión vieja", err, turno.ErrVersion)
+		modificado.Version = creado.Version + 1
+		_, err = r.UpdateTurno(ctx, modificado)
+		sinError(t, "UpdateTurno", err)
+
+		leido, err := r.GetTurnoByID(ctx, creado.ID)
+		sinError(t, "GetTurnoByID", err)
+		if leido.IdOdontologo != paz || !leido.FechaHora.Equal(fecha(t, "2024-06-05 15:30:00")) || leido.Descripcion != "limpieza" || leido.CodigoPrestacion != "LIMPIEZA" {
+			t.Fatalf("modificación mal guardada: %+v", leido)
+		}
+		if leido.Estado != turno.EstadoConfirmado {
+			t.Fatalf("UpdateTurno no tiene que tocar el estado, quedó %q", leido.Estado)
+		}
+		if creado.Version != 1 || leido.Version != 3 {
+			t.Fatalf("el alta empieza en la versión 1 y el estado y la modificación la suben: %d, %d", creado.Version, leido.Version)
+		}
+
+		modificado.ID = creado.ID + 100
+		_, err = r.UpdateTurno(ctx, modificado)
+		esperarError(t, "UpdateTurno inexistente", err, turno.ErrNotFound)
+		esperarError(t, "UpdateEstado inexistente", r.UpdateEstado(ctx, creado.ID+100, 1, turno.EstadoAtendido), turno.ErrNotFound)
+	})
+
+	t.Run("baja y restauración", func(t *testing.T) {
+		r, pacientes, odontologos := nuevo(t)
+		idPaciente, idOdontologo := altaPaciente(t, pacientes, "30111222"), altaOdontologo(t, odontologos, "100")
+		primero, err := r.CreateTurno(ctx, nuevoTurno(t, idPaciente, idOdontologo, "2024-06-03 10:00:00"))
+		sinError(t, "CreateTurno", err)
+		segundo, err := r.CreateTurno(ctx, nuevoTurno(t, idPaciente, idOdontologo, "2024-06-03 11:00:00"))
+		sinError(t, "CreateTurno", err)
+		tercero, err := r.CreateTurno(ctx, nuevoTurno(t, idPaciente, idOdontologo, "2024-06-03 12:00:00"))
+		sinError(t, "CreateTurno", err)
+
+		vieja, nueva := fecha(t, "2024-05-10 12:30:00"), fecha(t, "2024-05-11 08:00:00")
+		esperarError(t, "DeleteTurno con versión vieja", r.DeleteTurno(ctx, primero.ID, primero.Version+1, vieja, 0), turno.ErrVersion)
+		sinError(t, "DeleteTurno", r.DeleteTurno(ctx, primero.ID, primero.Version, vieja, 0))
+		sinError(t, "DeleteTurno", r.DeleteTurno(ctx, tercero.ID, tercero.Version, nueva, 0))
+		esperarError(t, "DeleteTurno repetido", r.DeleteTurno(ctx, primero.ID, primero.Version+1, vieja, 0), turno.ErrNotFound)
+		esperarError(t, "DeleteTurno inexistente", r.DeleteTurno(ctx, tercero.ID+100, 1, vieja, 0), turno.ErrNotFound)
+
+		_, err = r.GetTurnoByID(ctx, primero.ID)
+		esperarError(t, "GetTurnoByID dado de baja", err, turno.ErrNotFound)
+		esperarError(t, "UpdateEstado dado de baja", r.UpdateEstado(ctx, primero.ID, primero.Version+1, turno.EstadoCancelado), turno.ErrNotFound)
+		lista, _ := r.GetAll(ctx)
+		if len(lista) != 1 || lista[0].ID != segundo.ID {
+			t.Fatalf("GetAll no tiene que traer a los dados de baja: %+v", lista)
+		}
+		lista, _ = r.GetTurnoByPaciente(ctx, idPaciente)
+		if len(lista) != 1 {
+			t.Fatalf("GetTurnoByPaciente no tiene que traer a los dados de baja: %+v", lista)
+		}
+		agenda, _ := r.GetAgenda(ctx, idOdontologo, fecha(t, "2024-06-03 00:00:00"), fecha(t, "2024-06-04 00:00:00"))
+		if len(agenda) != 1 {
+			t.Fatalf("la agenda no tiene que traer a los dados de baja: %+v", agenda)
+		}
+
+		eliminados, err := r.GetEliminados(ctx)
+		sinError(t, "GetEliminados", err)
+		if len(eliminados) != 2 || eliminados[0].ID != tercero.ID || eliminados[1].ID != primero.ID ||
+			eliminados[0].DeletedAt == nil || !eliminados[1].DeletedAt.Equal(vieja) {
+			t.Fatalf("GetEliminados tiene que traer las bajas de la más nueva a la más vieja: %+v", eliminados)
+		}
+		eliminado, err := r.GetEliminadoByID(ctx, primero.ID)
+		sinError(t, "GetEliminadoByID", err)
+		if eliminado.ID != primero.ID || eliminado.DeletedAt == nil || !eliminado.DeletedAt.Equal(vieja) {
+			t.Fatalf("GetEliminadoByID tiene que traer la baja con su fecha: %+v", eliminado)
+		}
+		_, err = r.GetEliminadoByID(ctx, segundo.ID)
+		esperarError(t, "GetEliminadoByID activo", err, turno.ErrNotFound)
+		_, err = r.GetEliminadoByID(ctx, tercero.ID+100)
+		esperarError(t, "GetEliminadoByID inexistente", err, turno.ErrNotFound)
+
+		// la fecha leída de la baja encuentra los turnos que cayeron con ella, por paciente o por odontólogo
+		enFecha, err := r.GetTurnosEliminadosEn(ctx, *eliminado.DeletedAt, idPaciente, 0)
+		sinError(t, "GetTurnosEliminadosEn", err)
+		if len(enFecha) != 1 || enFecha[0].ID != primero.ID || enFecha[0].DeletedAt == nil {
+			t.Fatalf("GetTurnosEliminadosEn por paciente tiene que traer sólo la baja de esa fecha: %+v", enFecha)
+		}
+		enFecha, err = r.GetTurnosEliminadosEn(ctx, nueva, 0, idOdontologo)
+		sinError(t, "GetTurnosEliminadosEn", err)
+		if len(enFecha) != 1 || enFecha[0].ID != tercero.ID {
+			t.Fatalf("GetTurnosEliminadosEn por odontólogo tiene que traer sólo la baja de esa fecha: %+v", enFecha)
+		}
+		enFecha, err = r.GetTurnosEliminadosEn(ctx, vieja, idPaciente+100, idOdontologo+100)
+		sinError(t, "GetTurnosEliminadosEn", err)
+		if len(enFecha) != 0 {
+			t.Fatalf("GetTurnosEliminadosEn no tiene que traer turnos de otros: %+v", enFecha)
+		}
+
+		esperarError(t, "RestaurarTurno activo", r.RestaurarTurno(ctx, segundo.ID), turno.ErrNotFound)
+		sinError(t, "RestaurarTurno", r.RestaurarTurno(ctx, primero.ID))
+		if _, err := r.GetTurnoByID(ctx, primero.ID); err != nil {
+			t.Fatalf("el restaurado tiene que volver a verse: %v", err)
+		}
+		eliminados, _ = r.GetEliminados(ctx)
+		if len(eliminados) != 1 || eliminados[0].ID != tercero.ID {
+			t.Fatalf("el restaurado no tiene que seguir entre los eliminados: %+v", eliminados)
+		}
+	})
+
+	t.Run("incidencias", func(t *testing.T) {
+		r, pacientes, odontologos := nuevo(t)
+		idPaciente, idOdontologo := altaPaciente(t, pacientes, "30111222"), altaOdontologo(t, odontologos, "100")
+		otroPaciente := altaPaciente(t, pacientes, "30222333")
+		primero, err := r.CreateTurno(ctx, nuevoTurno(t, idPaciente, idOdontologo, "2024-06-03 10:00:00"))
+		sinError(t, "CreateTurno", err)
+		segundo, err := r.CreateTurno(ctx, nuevoTurno(t, idPaciente, idOdontologo, "2024-06-10 10:00:00"))
+		sinError(t, "CreateTurno", err)
+
+		_, err = r.GetIncidenciaByTurno(ctx, primero.ID)
+		esperarError(t, "GetIncidenciaByTurno sin incidencia", err, turno.ErrIncidencia)
+
+		ausencia, err := r.CreateIncidencia(ctx, turno.Incidencia{IdTurno: primero.ID, IdPaciente: idPaciente, Tipo: turno.IncidenciaAusencia, Fecha: fecha(t, "2024-06-03 10:30:00"), Penalidad: 1500.5})
+		sinError(t, "CreateIncidencia", err)
+		tardia, err := r.CreateIncidencia(ctx, turno.Incidencia{IdTurno: segundo.ID, IdPaciente: idPaciente, Tipo: turno.IncidenciaCancelacionTardia, Fecha: fecha(t, "2024-06-10 08:00:00")})
+		sinError(t, "CreateIncidencia", err)
+		crecientes(t, []int{ausencia.ID, tardia.ID})
+
+		_, err = r.CreateIncidencia(ctx, turno.Incidencia{IdTurno: primero.ID, IdPaciente: idPaciente, Tipo: turno.IncidenciaAusencia, Fecha: fecha(t, "2024-06-03 11:00:00")})
+		if err == nil {
+			t.Fatal("un turno no puede tener dos incidencias")
+		}
+		_, err = r.CreateIncidencia(ctx, turno.Incidencia{IdTurno: segundo.ID + 100, IdPaciente: idPaciente, Tipo: turno.IncidenciaAusencia, Fecha: fecha(t, "2024-06-03 11:00:00")})
+		if err == nil {
+			t.Fatal("CreateIncidencia de un turno inexistente tiene que fallar")
+		}
+
+		leida, err := r.GetIncidenciaByTurno(ctx, primero.ID)
+		sinError(t, "GetIncidenciaByTurno", err)
+		if leida.ID != ausencia.ID || leida.Tipo != turno.IncidenciaAusencia || leida.Penalidad != 1500.5 || !leida.Fecha.Equal(fecha(t, "2024-06-03 10:30:00")) {
+			t.Fatalf("incidencia leída distinta de la guardada: %+v", leida)
+		}
+
+		lista, err := r.GetIncidenciasByPaciente(ctx, idPaciente)
+		sinError(t, "GetIncidenciasByPaciente", err)
+		if len(lista) != 2 || lista[0].ID != tardia.ID || lista[1].ID != ausencia.ID {
+			t.Fatalf("las incidencias tienen que venir de la más nueva a la más vieja: %+v", lista)
+		}
+		lista, err = r.GetIncidenciasByPaciente(ctx, otroPaciente)
+		sinError(t, "GetIncidenciasByPaciente", err)
+		if lista == nil || len(lista) != 0 {
+			t.Fatalf("sin incidencias tiene que volver una lista vacía: %+v", lista)
+		}
+	})
+
+	t.Run("horario ocupado", func(t *testing.T) {
+		r, pacientes, odontologos := nuevo(t)
+		ana, luis := altaPaciente(t, pacientes, "30111222"), altaPaciente(t, pacientes, "30222333")
+		ruiz, paz := altaOdontologo(t, odontologos, "100"), altaOdontologo(t, odontologos, "200")
+		primero, err := r.CreateTurno(ctx, nuevoTurno(t, ana, ruiz, "2024-06-03 10:00:00"))
+		sinError(t, "CreateTurno", err)
+
+		// el mismo horario con el mismo odontólogo choca aunque sea otro paciente; con otro odontólogo no
+		_, err = r.CreateTurno(ctx, nuevoTurno(t, luis, ruiz, "2024-06-03 10:00:00"))
+		esperarError(t, "CreateTurno en horario ocupado", err, turno.ErrBloqueOcupado)
+		_, err = r.CreateTurno(ctx, nuevoTurno(t, luis, paz, "2024-06-03 10:00:00"))
+		sinError(t, "CreateTurno con otro odontólogo", err)
+
+		segundo, err := r.CreateTurno(ctx, nuevoTurno(t, luis, ruiz, "2024-06-03 11:00:00"))
+		sinError(t, "CreateTurno", err)
+		movido := segundo
+		movido.FechaHora = primero.FechaHora
+		_, err = r.UpdateTurno(ctx, movido)
+		esperarError(t, "UpdateTurno a un horario ocupado", err, turno.ErrBloqueOcupado)
+
+		// cancelado o dado de baja, el horario se libera; al restaurarlo no puede pisar al que lo tomó
+		sinError(t, "UpdateEstado", r.UpdateEstado(ctx, primero.ID, primero.Version, turno.EstadoCancelado))
+		reemplazo, err := r.CreateTurno(ctx, nuevoTurno(t, luis, ruiz, "2024-06-03 10:00:00"))
+		sinError(t, "CreateTurno en horario cancelado", err)
+		sinError(t, "DeleteTurno", r.DeleteTurno(ctx, reemplazo.ID, reemplazo.Version, fecha(t, "2024-05-01 08:00:00"), 0))
+		_, err = r.CreateTurno(ctx, nuevoTurno(t, ana, ruiz, "2024-06-03 10:00:00"))
+		sinError(t, "CreateTurno en horario dado de baja", err)
+		esperarError(t, "RestaurarTurno en horario ocupado", r.RestaurarTurno(ctx, reemplazo.ID), turno.ErrBloqueOcupado)
+
+		// de muchos pedidos simultáneos por el mismo horario, sólo uno lo consigue
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		creados, ocupados := 0, 0
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := r.CreateTurno(ctx, nuevoTurno(t, ana, ruiz, "2024-06-04 09:00:00"))
+				mu.Lock()
+				defer mu.Unlock()
+				switch {
+				case err == nil:
+					creados++
+				case errors.Is(err, turno.ErrBloqueOcupado):
+					ocupados++
+				}
+			}()
+		}
+		wg.Wait()
+		if creados != 1 || ocupados != 9 {
+			t.Fatalf("de 10 altas simultáneas en el mismo horario tiene que quedar una: %d creadas, %d rechazadas", creados, ocupados)
+		}
+	})
+
+	t.Run("altas en paralelo", func(t *testing.T) {
+		r, pacientes, odontologos := nuevo(t)
+		idPaciente, idOdontologo := altaPaciente(t, pacientes, "30111222"), altaOdontologo(t, odontologos, "100")
+		inicio := fecha(t, "2024-06-03 08:00:00")
+		enParalelo(t, 20, func(i int) (int, error) {
+			nuevo := nuevoTurno(t, idPaciente, idOdontologo, "2024-06-03 08:00:00")
+			nuevo.FechaHora = inicio.Add(time.Duration(i) * 30 * time.Minute)
+			creado, err := r.CreateTurno(ctx, nuevo)
+			return creado.ID, err
+		})
+	})
+}
+
+// nuevoTurno arma un turno pendiente para el paciente y el odontólogo
+func nuevoTurno(t *testing.T, idPaciente, idOdontologo int, fechaHora string) turno.Turno {
+	return turno.Turno{
+		IdPaciente:       idPaciente,
+		IdOdontologo:     idOdontologo,
+		FechaHora:        fecha(t, fechaHora),
+		Descripcion:      "control",
+		CodigoPrestacion: "CONSULTA",
+		Estado:           turno.EstadoPendiente,
+	}
+}
+
+func altaPaciente(t *testing.T, r paciente.Repository, dni string) int {
+	t.Helper()
+	p, err := r.CreatePaciente(ctx, nuevoPaciente(t, dni))
+	sinError(t, "CreatePaciente", err)
+	return p.ID
+}
+
+func altaOdontologo(t *testing.T, r odontologo.Repository, matricula string) int {
+	t.Helper()
+	o, err := r.CreateOdontologo(ctx, odontologo.Odontologo{Apellido: "Ruiz", Nombre: "Ana", Matricula: matricula})
+	sinError(t, "CreateOdontologo", err)
+	return o.ID
+}
@@ -0,0 +1,81 @@
+package facturacion
+
+import (
+	"time"
+
+	"finalgo/internal/obrasocial"
+	"finalgo/internal/prestacion"
+)
+
+// medios de pago admitidos
+const (
+	MedioEfectivo      = "efectivo"
+	MedioTarjeta       = "tarjeta"
+	MedioTransferencia = "transferencia"
+)
+
+// estados de un cargo según lo que el paciente ya pagó de su parte
+const (
+	EstadoPendiente = "pendiente"
+	EstadoParcial   = "parcial"
+	EstadoPagado    = "pagado"
+)
+
+// código con el que se factura la penalidad por una ausencia o cancelación tardía, en lugar de una prestación
+const CodigoPenalidad = "penalidad"
+
+// Cargo es lo que se factura por un turno atendido, o la penalidad de uno al que el paciente faltó o canceló tarde. El importe se reparte entre el paciente y su obra social.
+// Pagado y Estado no se guardan, se calculan a partir de los pagos al armar la cuenta.
+type Cargo struct {
+	ID                int       `json:"id"`
+	IdTurno           int       `json:"id_turno"`
+	IdPaciente        int       `json:"id_paciente"`
+	IdObraSocial      int       `json:"id_obra_social,omitempty"`
+	CodigoPrestacion  string    `json:"codigo_prestacion"`
+	Fecha             time.Time `json:"fecha"`
+	Importe           float64   `json:"importe"`
+	ImporteObraSocial float64   `json:"importe_obra_social"`
+	ImportePaciente   float64   `json:"importe_paciente"`
+	Pagado            float64   `json:"pagado"`
+	Estado            string    `json:"estado"`
+	// sube cada vez que un lote de liquidación reclama el cargo
+	Version int `json:"version"`
+}
+
+// Cotizacion es lo que sale una prestación para un paciente en una fecha, ya repartido entre la obra social y el paciente.
+// Cobertura queda vacía si el paciente no tiene una vigente.
+type Cotizacion struct {
+	Prestacion        prestacion.Prestacion          `json:"prestacion"`
+	Cobertura         obrasocial.CoberturaPrestacion `json:"cobertura"`
+	ImporteObraSocial float64                        `json:"importe_obra_social"`
+	ImportePaciente   float64                        `json:"importe_paciente"`
+}
+
+// Pago es un ingreso de dinero del paciente. Si IdCargo es cero el pago queda a cuenta y se imputa a los cargos más viejos.
+type Pago struct {
+	ID         int       `json:"id"`
+	IdPaciente int       `json:"id_paciente"`
+	IdCargo    int       `json:"id_cargo,omitempty"`
+	Medio      string    `json:"medio"`
+	Importe    float64   `json:"importe"`
+	Fecha      time.Time `json:"fecha"`
+	Referencia string    `json:"referencia"`
+}
+
+// creamos la estructura de pago para las solicitudes por API (el paciente viene por la ruta).
+type PagoRequest struct {
+	IdCargo    int     `json:"id_cargo"`
+	Medio      string  `json:"medio"`
+	Importe    float64 `json:"importe"`
+	Referencia string  `json:"referencia"`
+}
+
+// Cuenta es el estado de cuenta del paciente: lo que se le cargó, lo que pagó y lo que debe.
+type Cuenta struct {
+	IdPaciente  int     `json:"id_paciente"`
+	TotalCargos float64 `json:"total_cargos"`
+	TotalPagos  float64 `json:"total_pagos"`
+	Saldo       float64 `json:"saldo"`
+	Cargos      []Cargo `json:"cargos"`
+	Pagos       []Pago  `json:"pagos"`
+}
@@ -0,0 +1,31 @@
+package facturacion
+
+import "database/sql"
+
+// Queries de postgres: parámetros $1, $2, ..., marcas BOOLEAN y las altas devuelven el ID con RETURNING id
+var (
+	QueryInsertCargoPostgres         = `INSERT INTO cargo(id_turno, id_paciente, id_obra_social, codigo_prestacion, fecha, importe, importe_obra_social, importe_paciente) VALUES($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`
+	QueryGetCargoByIdPostgres        = `SELECT id, id_turno, id_paciente, id_obra_social, codigo_prestacion, fecha, importe, importe_obra_social, importe_paciente, version FROM cargo WHERE id = $1`
+	QueryGetCargoByTurnoPostgres     = `SELECT id, id_turno, id_paciente, id_obra_social, codigo_prestacion, fecha, importe, importe_obra_social, importe_paciente, version FROM cargo WHERE id_turno = $1`
+	QueryGetCargosByPacientePostgres = `SELECT id, id_turno, id_paciente, id_obra_social, codigo_prestacion, fecha, importe, importe_obra_social, importe_paciente, version FROM cargo WHERE id_paciente = $1 ORDER BY fecha, id`
+	QueryInsertPagoPostgres          = `INSERT INTO pago(id_paciente, id_cargo, medio, importe, fecha, referencia) VALUES($1, $2, $3, $4, $5, $6) RETURNING id`
+	QueryGetPagosByPacientePostgres  = `SELECT id, id_paciente, id_cargo, medio, importe, fecha, referencia FROM pago WHERE id_paciente = $1 ORDER BY fecha, id`
+)
+
+var consultasPostgres = consultas{
+	insertCargo:         QueryInsertCargoPostgres,
+	getCargoById:        QueryGetCargoByIdPostgres,
+	getCargoByTurno:     QueryGetCargoByTurnoPostgres,
+	getCargosByPaciente: QueryGetCargosByPacientePostgres,
+	insertPago:          QueryInsertPagoPostgres,
+	getPagosByPaciente:  QueryGetPagosByPacientePostgres,
+	returning:           true,
+}
+
+// NewRepositoryPostgres instancia repositorio sobre postgres
+func NewRepositoryPostgres(db *sql.DB) Repository {
+	return &repository{
+		db: db,
+		q:  consultasPostgres,
+	}
+}
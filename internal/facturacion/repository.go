@@ -0,0 +1,261 @@
+package facturacion
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"finalgo/pkg/basedatos"
+)
+
+// Errores
+var (
+	ErrEmptyList       = errors.New("la lista de cargos esta vacia")
+	ErrNotFound        = errors.New("cargo no encontrado")
+	ErrCargoExistente  = errors.New("el turno ya tiene un cargo generado")
+	ErrTurnoNoAtendido = errors.New("solo se facturan turnos atendidos o con penalidad por ausencia o cancelación tardía")
+	ErrSinPrestacion   = errors.New("el turno no tiene una prestación del catálogo")
+	ErrMedioInvalido   = errors.New("medio de pago inválido")
+	ErrImporteInvalido = errors.New("el importe del pago es inválido")
+	ErrStatement       = errors.New("sentencia incorrecta")
+	ErrExec            = errors.New("ejecución SQL incorrecta")
+	ErrLastId          = errors.New("error al obtener el último ID")
+)
+
+// Queries a usar en cada función
+var (
+	QueryInsertCargo         = `INSERT INTO cargo(id_turno, id_paciente, id_obra_social, codigo_prestacion, fecha, importe, importe_obra_social, importe_paciente) VALUES(?,?,?,?,?,?,?,?)`
+	QueryGetCargoById        = `SELECT id, id_turno, id_paciente, id_obra_social, codigo_prestacion, fecha, importe, importe_obra_social, importe_paciente, version FROM cargo WHERE id = ?`
+	QueryGetCargoByTurno     = `SELECT id, id_turno, id_paciente, id_obra_social, codigo_prestacion, fecha, importe, importe_obra_social, importe_paciente, version FROM cargo WHERE id_turno = ?`
+	QueryGetCargosByPaciente = `SELECT id, id_turno, id_paciente, id_obra_social, codigo_prestacion, fecha, importe, importe_obra_social, importe_paciente, version FROM cargo WHERE id_paciente = ? ORDER BY fecha, id`
+	QueryInsertPago          = `INSERT INTO pago(id_paciente, id_cargo, medio, importe, fecha, referencia) VALUES(?,?,?,?,?,?)`
+	QueryGetPagosByPaciente  = `SELECT id, id_paciente, id_cargo, medio, importe, fecha, referencia FROM pago WHERE id_paciente = ? ORDER BY fecha, id`
+)
+
+// consultas son las queries de cada función en el dialecto del motor: MySQL y SQLite usan las de arriba y
+// PostgreSQL las de postgres.go
+type consultas struct {
+	insertCargo         string
+	getCargoById        string
+	getCargoByTurno     string
+	getCargosByPaciente string
+	insertPago          string
+	getPagosByPaciente  string
+	// las altas devuelven el ID con RETURNING id, porque el motor no tiene LastInsertId
+	returning bool
+}
+
+var consultasMySQL = consultas{
+	insertCargo:         QueryInsertCargo,
+	getCargoById:        QueryGetCargoById,
+	getCargoByTurno:     QueryGetCargoByTurno,
+	getCargosByPaciente: QueryGetCargosByPaciente,
+	insertPago:          QueryInsertPago,
+	getPagosByPaciente:  QueryGetPagosByPaciente,
+}
+
+// defino la interfaz para que se apliquen siempre todos los métodos
+type Repository interface {
+	CreateCargo(ctx context.Context, c Cargo) (Cargo, error)
+	GetCargoByID(ctx context.Context, id int) (Cargo, error)
+	GetCargoByTurno(ctx context.Context, idTurno int) (Cargo, error)
+	GetCargosByPaciente(ctx context.Context, idPaciente int) ([]Cargo, error)
+	CreatePago(ctx context.Context, p Pago) (Pago, error)
+	GetPagosByPaciente(ctx context.Context, idPaciente int) ([]Pago, error)
+}
+
+// estructura repositorio con base de datos mysql
+type repository struct {
+	db *sql.DB
+	q  consultas
+}
+
+// NewRepositoryMySql instancia repositorio
+func NewRepositoryMySql(db *sql.DB) Repository {
+	return &repository{
+		db: db,
+		q:  consultasMySQL,
+	}
+}
+
+// NewRepositorySqlite instancia repositorio sobre sqlite, con las mismas consultas
+func NewRepositorySqlite(db *sql.DB) Repository {
+	return &repository{
+		db: db,
+		q:  consultasMySQL,
+	}
+}
+
+// crear cargo en BD
+func (r *repository) CreateCargo(ctx context.Context, cargo Cargo) (Cargo, error) {
+	statement, err := r.db.PrepareContext(ctx, r.q.insertCargo)
+	if err != nil {
+		return Cargo{}, ErrStatement
+	}
+	defer statement.Close()
+
+	// la obra social queda en NULL si el paciente no tiene cobertura
+	result, err := basedatos.InsertarPreparado(
+		ctx,
+		statement,
+		r.q.returning,
+		cargo.IdTurno,
+		cargo.IdPaciente,
+		nullInt(cargo.IdObraSocial),
+		cargo.CodigoPrestacion,
+		cargo.Fecha,
+		cargo.Importe,
+		cargo.ImporteObraSocial,
+		cargo.ImportePaciente,
+	)
+	if err != nil {
+		return Cargo{}, ErrExec
+	}
+
+	// obtengo el ID del registro y lo devuelvo como dato
+	lastId, err := result.LastInsertId()
+	if err != nil {
+		return Cargo{}, ErrLastId
+	}
+	cargo.ID = int(lastId)
+	cargo.Version = 1
+	return cargo, nil
+}
+
+// obtener cargo por ID
+func (r *repository) GetCargoByID(ctx context.Context, id int) (Cargo, error) {
+	return scanCargo(r.db.QueryRowContext(ctx, r.q.getCargoById, id))
+}
+
+// obtener el cargo generado para un turno
+func (r *repository) GetCargoByTurno(ctx context.Context, idTurno int) (Cargo, error) {
+	return scanCargo(r.db.QueryRowContext(ctx, r.q.getCargoByTurno, idTurno))
+}
+
+// obtener los cargos de un paciente, del más viejo al más nuevo
+func (r *repository) GetCargosByPaciente(ctx context.Context, idPaciente int) ([]Cargo, error) {
+	rows, err := r.db.QueryContext(ctx, r.q.getCargosByPaciente, idPaciente)
+	if err != nil {
+		return []Cargo{}, ErrEmptyList
+	}
+	defer rows.Close()
+
+	var cargos []Cargo
+	for rows.Next() {
+		cargo, err := scanCargo(rows)
+		if err != nil {
+			return []Cargo{}, ErrExec
+		}
+		cargos = append(cargos, cargo)
+	}
+
+	// verifico haber cargado bien todos los registros
+	if err := rows.Err(); err != nil {
+		return []Cargo{}, ErrExec
+	}
+
+	return cargos, nil
+}
+
+// crear pago en BD
+func (r *repository) CreatePago(ctx context.Context, pago Pago) (Pago, error) {
+	statement, err := r.db.PrepareContext(ctx, r.q.insertPago)
+	if err != nil {
+		return Pago{}, ErrStatement
+	}
+	defer statement.Close()
+
+	result, err := basedatos.InsertarPreparado(
+		ctx,
+		statement,
+		r.q.returning,
+		pago.IdPaciente,
+		nullInt(pago.IdCargo),
+		pago.Medio,
+		pago.Importe,
+		pago.Fecha,
+		pago.Referencia,
+	)
+	if err != nil {
+		return Pago{}, ErrExec
+	}
+
+	lastId, err := result.LastInsertId()
+	if err != nil {
+		return Pago{}, ErrLastId
+	}
+	pago.ID = int(lastId)
+	return pago, nil
+}
+
+// obtener los pagos de un paciente, del más viejo al más nuevo
+func (r *repository) GetPagosByPaciente(ctx context.Context, idPaciente int) ([]Pago, error) {
+	rows, err := r.db.QueryContext(ctx, r.q.getPagosByPaciente, idPaciente)
+	if err != nil {
+		return []Pago{}, ErrEmptyList
+	}
+	defer rows.Close()
+
+	var pagos []Pago
+	for rows.Next() {
+		var pago Pago
+		var idCargo sql.NullInt64
+		err := rows.Scan(
+			&pago.ID,
+			&pago.IdPaciente,
+			&idCargo,
+			&pago.Medio,
+			&pago.Importe,
+			&pago.Fecha,
+			&pago.Referencia,
+		)
+		if err != nil {
+			return []Pago{}, ErrExec
+		}
+		pago.IdCargo = int(idCargo.Int64)
+		pagos = append(pagos, pago)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []Pago{}, ErrExec
+	}
+
+	return pagos, nil
+}
+
+// scanner lo cumplen tanto *sql.Row como *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanCargo lee un cargo de una fila. Distingue la fila que no está de un error de la base, que no puede tomarse
+// como "sin cargo".
+func scanCargo(row scanner) (Cargo, error) {
+	var cargo Cargo
+	var idObraSocial sql.NullInt64
+	err := row.Scan(
+		&cargo.ID,
+		&cargo.IdTurno,
+		&cargo.IdPaciente,
+		&idObraSocial,
+		&cargo.CodigoPrestacion,
+		&cargo.Fecha,
+		&cargo.Importe,
+		&cargo.ImporteObraSocial,
+		&cargo.ImportePaciente,
+		&cargo.Version,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Cargo{}, ErrNotFound
+	}
+	if err != nil {
+		return Cargo{}, ErrExec
+	}
+	cargo.IdObraSocial = int(idObraSocial.Int64)
+	return cargo, nil
+}
+
+// nullInt guarda NULL cuando la referencia no fue informada
+func nullInt(id int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(id), Valid: id > 0}
+}
@@ -0,0 +1,302 @@
+package facturacion
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"time"
+
+	"finalgo/internal/obrasocial"
+	"finalgo/internal/prestacion"
+	"finalgo/internal/turno"
+)
+
+// defino la interfaz para que se apliquen siempre todos los métodos
+type Service interface {
+	Cotizar(ctx context.Context, idPaciente int, codigoPrestacion string, fecha time.Time) (Cotizacion, error)
+	GenerarCargo(ctx context.Context, idTurno int) (Cargo, error)
+	GetCargoByID(ctx context.Context, id int) (Cargo, error)
+	GetCuenta(ctx context.Context, idPaciente int) (Cuenta, error)
+	RegistrarPago(ctx context.Context, p PagoRequest, idPaciente int) (Pago, error)
+}
+
+// estrucutra service que contará con un repositorio y los servicios de los que toma turnos, precios y coberturas
+type service struct {
+	r   Repository
+	ts  turno.Service
+	ps  prestacion.Service
+	obs obrasocial.Service
+}
+
+// función para instanciar service
+func NewService(r Repository, ts turno.Service, ps prestacion.Service, obs obrasocial.Service) Service {
+	return &service{
+		r,
+		ts,
+		ps,
+		obs,
+	}
+}
+
+// Cotizar calcula lo que sale una prestación para el paciente en la fecha indicada, con la misma regla de reparto que
+// se usa al facturar. Si no tiene una cobertura vigente el paciente paga el total.
+func (s *service) Cotizar(ctx context.Context, idPaciente int, codigoPrestacion string, fecha time.Time) (Cotizacion, error) {
+	p, err := s.ps.GetPrestacionByCodigo(ctx, codigoPrestacion)
+	if err != nil {
+		log.Println("log de error por prestación inexistente", err.Error())
+		return Cotizacion{}, prestacion.ErrNotFound
+	}
+	cotizacion := Cotizacion{
+		Prestacion:      p,
+		ImportePaciente: p.Precio,
+	}
+
+	cobertura, err := s.obs.GetCoberturaPrestacion(ctx, idPaciente, codigoPrestacion, fecha)
+	if errors.Is(err, obrasocial.ErrSinCobertura) {
+		log.Println("prestación cotizada sin cobertura", err.Error())
+		return cotizacion, nil
+	}
+	if err != nil {
+		log.Println("error al buscar la cobertura de la prestación", err.Error())
+		return Cotizacion{}, ErrExec
+	}
+	cotizacion.Cobertura = cobertura
+	if cobertura.Cubierto {
+		cotizacion.ImporteObraSocial, cotizacion.ImportePaciente = repartir(p.Precio, cobertura.PorcentajeCubierto, cobertura.Copago)
+	}
+	return cotizacion, nil
+}
+
+// GenerarCargo factura un turno atendido al precio del catálogo, repartiendo el importe entre la obra social y el paciente.
+func (s *service) GenerarCargo(ctx context.Context, idTurno int) (Cargo, error) {
+	t, err := s.ts.GetTurnoByID(ctx, idTurno)
+	if err != nil {
+		log.Println("log de error por turno inexistente", err.Error())
+		return Cargo{}, turno.ErrNotFound
+	}
+	if t.Estado == turno.EstadoCancelado || t.Estado == turno.EstadoAusente {
+		return s.generarPenalidad(ctx, t)
+	}
+	if t.Estado != turno.EstadoAtendido {
+		return Cargo{}, ErrTurnoNoAtendido
+	}
+	if t.CodigoPrestacion == "" {
+		return Cargo{}, ErrSinPrestacion
+	}
+
+	// un turno se factura una sola vez
+	if err := s.sinCargo(ctx, idTurno); err != nil {
+		return Cargo{}, err
+	}
+
+	cotizacion, err := s.Cotizar(ctx, t.IdPaciente, t.CodigoPrestacion, t.FechaHora)
+	if err != nil {
+		return Cargo{}, err
+	}
+
+	cargo := Cargo{
+		IdTurno:           t.ID,
+		IdPaciente:        t.IdPaciente,
+		CodigoPrestacion:  t.CodigoPrestacion,
+		Fecha:             t.FechaHora,
+		Importe:           cotizacion.Prestacion.Precio,
+		ImporteObraSocial: cotizacion.ImporteObraSocial,
+		ImportePaciente:   cotizacion.ImportePaciente,
+	}
+	if cotizacion.Cobertura.Cubierto {
+		cargo.IdObraSocial = cotizacion.Cobertura.IdObraSocial
+	}
+
+	response, err := s.r.CreateCargo(ctx, cargo)
+	if err != nil {
+		log.Println("error al crear cargo")
+		return Cargo{}, ErrExec
+	}
+	response.Estado = EstadoPendiente
+	if response.ImportePaciente == 0 {
+		response.Estado = EstadoPagado
+	}
+	return response, nil
+}
+
+// generarPenalidad cobra al paciente la penalidad registrada por la ausencia o cancelación tardía del turno. No la
+// cubre la obra social, así que tampoco entra en las liquidaciones.
+func (s *service) generarPenalidad(ctx context.Context, t turno.Turno) (Cargo, error) {
+	incidencia, err := s.ts.GetIncidenciaByTurno(ctx, t.ID)
+	if err != nil || incidencia.Penalidad <= 0 {
+		return Cargo{}, ErrTurnoNoAtendido
+	}
+	if err := s.sinCargo(ctx, t.ID); err != nil {
+		return Cargo{}, err
+	}
+
+	response, err := s.r.CreateCargo(ctx, Cargo{
+		IdTurno:          t.ID,
+		IdPaciente:       t.IdPaciente,
+		CodigoPrestacion: CodigoPenalidad,
+		Fecha:            incidencia.Fecha,
+		Importe:          incidencia.Penalidad,
+		ImportePaciente:  incidencia.Penalidad,
+	})
+	if err != nil {
+		log.Println("error al crear cargo de penalidad", err.Error())
+		return Cargo{}, ErrExec
+	}
+	response.Estado = EstadoPendiente
+	return response, nil
+}
+
+// sinCargo confirma que el turno todavía no se facturó. Si la base falla no se puede saber, así que tampoco se factura.
+func (s *service) sinCargo(ctx context.Context, idTurno int) error {
+	_, err := s.r.GetCargoByTurno(ctx, idTurno)
+	if err == nil {
+		return ErrCargoExistente
+	}
+	if !errors.Is(err, ErrNotFound) {
+		log.Println("log de error al buscar el cargo del turno", err.Error())
+		return ErrExec
+	}
+	return nil
+}
+
+func (s *service) GetCargoByID(ctx context.Context, id int) (Cargo, error) {
+	c, err := s.r.GetCargoByID(ctx, id)
+	if err != nil {
+		log.Println("log de error por cargo inexistente", err.Error())
+		return Cargo{}, ErrNotFound
+	}
+	return c, nil
+}
+
+// GetCuenta arma el estado de cuenta del paciente imputando los pagos a los cargos.
+func (s *service) GetCuenta(ctx context.Context, idPaciente int) (Cuenta, error) {
+	cargos, err := s.r.GetCargosByPaciente(ctx, idPaciente)
+	if err != nil {
+		log.Println("log de error en cargos del paciente", err.Error())
+		return Cuenta{}, ErrExec
+	}
+	pagos, err := s.r.GetPagosByPaciente(ctx, idPaciente)
+	if err != nil {
+		log.Println("log de error en pagos del paciente", err.Error())
+		return Cuenta{}, ErrExec
+	}
+
+	cuenta := Cuenta{
+		IdPaciente: idPaciente,
+		Cargos:     imputarPagos(cargos, pagos),
+		Pagos:      pagos,
+	}
+	if cuenta.Pagos == nil {
+		cuenta.Pagos = []Pago{}
+	}
+	for _, c := range cuenta.Cargos {
+		cuenta.TotalCargos += c.ImportePaciente
+	}
+	for _, p := range cuenta.Pagos {
+		cuenta.TotalPagos += p.Importe
+	}
+	cuenta.TotalCargos = redondear(cuenta.TotalCargos)
+	cuenta.TotalPagos = redondear(cuenta.TotalPagos)
+	cuenta.Saldo = redondear(cuenta.TotalCargos - cuenta.TotalPagos)
+	return cuenta, nil
+}
+
+// RegistrarPago guarda un pago total o parcial del paciente. Si se indica el cargo, no puede superar lo que resta pagar de él.
+func (s *service) RegistrarPago(ctx context.Context, pagoRequest PagoRequest, idPaciente int) (Pago, error) {
+	if pagoRequest.Medio != MedioEfectivo && pagoRequest.Medio != MedioTarjeta && pagoRequest.Medio != MedioTransferencia {
+		return Pago{}, ErrMedioInvalido
+	}
+	if pagoRequest.Importe <= 0 {
+		return Pago{}, ErrImporteInvalido
+	}
+
+	if pagoRequest.IdCargo > 0 {
+		cuenta, err := s.GetCuenta(ctx, idPaciente)
+		if err != nil {
+			return Pago{}, err
+		}
+		var cargo *Cargo
+		for i := range cuenta.Cargos {
+			if cuenta.Cargos[i].ID == pagoRequest.IdCargo {
+				cargo = &cuenta.Cargos[i]
+				break
+			}
+		}
+		if cargo == nil {
+			return Pago{}, ErrNotFound
+		}
+		if redondear(pagoRequest.Importe) > redondear(cargo.ImportePaciente-cargo.Pagado) {
+			return Pago{}, ErrImporteInvalido
+		}
+	}
+
+	pago := Pago{
+		IdPaciente: idPaciente,
+		IdCargo:    pagoRequest.IdCargo,
+		Medio:      pagoRequest.Medio,
+		Importe:    redondear(pagoRequest.Importe),
+		Fecha:      time.Now(),
+		Referencia: pagoRequest.Referencia,
+	}
+	response, err := s.r.CreatePago(ctx, pago)
+	if err != nil {
+		log.Println("error al registrar pago")
+		return Pago{}, ErrExec
+	}
+	return response, nil
+}
+
+// repartir calcula la parte de la obra social y la del paciente. El copago siempre lo paga el paciente
+// y el porcentaje de cobertura se aplica sobre el resto.
+func repartir(precio float64, porcentaje float64, copago float64) (float64, float64) {
+	copago = math.Min(copago, precio)
+	obraSocial := redondear((precio - copago) * porcentaje / 100)
+	return obraSocial, redondear(precio - obraSocial)
+}
+
+// imputarPagos aplica primero los pagos dirigidos a un cargo y después los pagos a cuenta, del cargo más viejo al más nuevo.
+func imputarPagos(cargos []Cargo, pagos []Pago) []Cargo {
+	if cargos == nil {
+		return []Cargo{}
+	}
+
+	indice := make(map[int]int, len(cargos))
+	for i := range cargos {
+		indice[cargos[i].ID] = i
+	}
+
+	aCuenta := 0.0
+	for _, p := range pagos {
+		if i, ok := indice[p.IdCargo]; ok {
+			cargos[i].Pagado += p.Importe
+			continue
+		}
+		aCuenta += p.Importe
+	}
+
+	for i := range cargos {
+		pendiente := cargos[i].ImportePaciente - cargos[i].Pagado
+		if pendiente > 0 && aCuenta > 0 {
+			aplicado := math.Min(pendiente, aCuenta)
+			cargos[i].Pagado += aplicado
+			aCuenta -= aplicado
+		}
+		cargos[i].Pagado = redondear(cargos[i].Pagado)
+
+		switch {
+		case cargos[i].Pagado >= cargos[i].ImportePaciente:
+			cargos[i].Estado = EstadoPagado
+		case cargos[i].Pagado > 0:
+			cargos[i].Estado = EstadoParcial
+		default:
+			cargos[i].Estado = EstadoPendiente
+		}
+	}
+	return cargos
+}
+
+// redondear deja los importes en centavos
+func redondear(importe float64) float64 {
+	return math.Round(importe*100) / 100
+}
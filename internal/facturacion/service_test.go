@@ -0,0 +1,86 @@
+package facturacion
+
+import "testing"
+
+func TestRepartir(t *testing.T) {
+	casos := []struct {
+		nombre     string
+		precio     float64
+		porcentaje float64
+		copago     float64
+		obraSocial float64
+		paciente   float64
+	}{
+		{"sin cobertura", 1000, 0, 0, 0, 1000},
+		{"sin cobertura con copago", 1000, 0, 200, 0, 1000},
+		{"cobertura total", 1000, 100, 0, 1000, 0},
+		{"cobertura total con copago", 1000, 100, 200, 800, 200},
+		{"cobertura parcial con copago", 1000, 80, 100, 720, 280},
+		{"copago mayor al precio", 1000, 80, 1500, 0, 1000},
+		{"copago igual al precio", 1000, 100, 1000, 0, 1000},
+		{"redondea la obra social a centavos", 100, 33.333, 0, 33.33, 66.67},
+		{"el paciente paga el resto exacto", 99.99, 50, 0.01, 49.99, 50},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			obraSocial, paciente := repartir(c.precio, c.porcentaje, c.copago)
+			if obraSocial != c.obraSocial || paciente != c.paciente {
+				t.Fatalf("repartir(%v, %v, %v) = %v, %v; se esperaba %v, %v", c.precio, c.porcentaje, c.copago, obraSocial, paciente, c.obraSocial, c.paciente)
+			}
+			if redondear(obraSocial+paciente) != c.precio {
+				t.Fatalf("las partes no suman el precio: %v + %v != %v", obraSocial, paciente, c.precio)
+			}
+		})
+	}
+}
+
+func TestImputarPagos(t *testing.T) {
+	// cargos del más viejo al más nuevo, con lo que le toca pagar al paciente en cada uno
+	cargos := func(importes ...float64) []Cargo {
+		lista := make([]Cargo, len(importes))
+		for i, importe := range importes {
+			lista[i] = Cargo{ID: i + 1, ImportePaciente: importe}
+		}
+		return lista
+	}
+	type esperado struct {
+		pagado float64
+		estado string
+	}
+	casos := []struct {
+		nombre string
+		cargos []Cargo
+		pagos  []Pago
+		espera []esperado
+	}{
+		{"sin pagos", cargos(300), nil, []esperado{{0, EstadoPendiente}}},
+		{"pago parcial del cargo", cargos(300), []Pago{{IdCargo: 1, Importe: 100}}, []esperado{{100, EstadoParcial}}},
+		{"pagos parciales que completan el cargo", cargos(300), []Pago{{IdCargo: 1, Importe: 100}, {IdCargo: 1, Importe: 200}}, []esperado{{300, EstadoPagado}}},
+		{"el pago dirigido no pasa a otro cargo", cargos(200, 300), []Pago{{IdCargo: 2, Importe: 100}}, []esperado{{0, EstadoPendiente}, {100, EstadoParcial}}},
+		{"a cuenta cubre primero el más viejo", cargos(200, 300), []Pago{{Importe: 250}}, []esperado{{200, EstadoPagado}, {50, EstadoParcial}}},
+		{"a cuenta después de los dirigidos", cargos(200, 300), []Pago{{Importe: 100}, {IdCargo: 2, Importe: 300}}, []esperado{{100, EstadoParcial}, {300, EstadoPagado}}},
+		{"a cuenta salta los cargos ya pagados", cargos(200, 300), []Pago{{IdCargo: 1, Importe: 200}, {Importe: 50}}, []esperado{{200, EstadoPagado}, {50, EstadoParcial}}},
+		{"un cargo que no es del paciente cuenta como a cuenta", cargos(200), []Pago{{IdCargo: 9, Importe: 80}}, []esperado{{80, EstadoParcial}}},
+		{"el sobrante a cuenta no se imputa", cargos(200), []Pago{{Importe: 500}}, []esperado{{200, EstadoPagado}}},
+		{"cargo cubierto entero por la obra social", cargos(0, 100), []Pago{{Importe: 40}}, []esperado{{0, EstadoPagado}, {40, EstadoParcial}}},
+		{"redondea a centavos al repartir", cargos(33.33, 33.33, 33.34), []Pago{{Importe: 0.1}, {Importe: 0.2}, {Importe: 99.7}}, []esperado{{33.33, EstadoPagado}, {33.33, EstadoPagado}, {33.34, EstadoPagado}}},
+		{"redondea el pago parcial", cargos(10), []Pago{{Importe: 0.1}, {Importe: 0.2}}, []esperado{{0.3, EstadoParcial}}},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			resultado := imputarPagos(c.cargos, c.pagos)
+			if len(resultado) != len(c.espera) {
+				t.Fatalf("imputarPagos devolvió %d cargos, se esperaban %d", len(resultado), len(c.espera))
+			}
+			for i, e := range c.espera {
+				if resultado[i].Pagado != e.pagado || resultado[i].Estado != e.estado {
+					t.Fatalf("cargo %d: pagado %v (%s), se esperaba %v (%s)", resultado[i].ID, resultado[i].Pagado, resultado[i].Estado, e.pagado, e.estado)
+				}
+			}
+		})
+	}
+
+	if resultado := imputarPagos(nil, []Pago{{Importe: 10}}); resultado == nil || len(resultado) != 0 {
+		t.Fatalf("sin cargos tiene que devolver una lista vacía, vino %#v", resultado)
+	}
+}
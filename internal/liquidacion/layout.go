@@ -0,0 +1,211 @@
+package liquidacion
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// formatos de exportación
+const (
+	FormatoCSV  = "csv"
+	FormatoFijo = "fijo"
+)
+
+// alineaciones de campos de ancho fijo
+const (
+	AlineacionIzquierda = "izquierda"
+	AlineacionDerecha   = "derecha"
+)
+
+// Errores de exportación. ErrLayoutInvalido es una definición que no se puede aplicar; ErrDesborde, un valor que no
+// entra en el ancho de su campo. Truncarlo daría importes o IDs equivocados, así que se rechaza el archivo entero.
+var (
+	ErrLayoutInvalido = errors.New("layout de exportación inválido")
+	ErrDesborde       = errors.New("un valor no entra en el ancho de su campo del layout")
+)
+
+// Layout describe cómo espera recibir la liquidación cada obra social: CSV o registro de ancho fijo.
+type Layout struct {
+	Formato    string        `json:"formato"`
+	Separador  string        `json:"separador"`
+	Encabezado bool          `json:"encabezado"`
+	Campos     []CampoLayout `json:"campos"`
+	// Version no es parte de la definición: viaja en ETag/If-Match y vale 0 mientras se use el layout por defecto
+	Version int `json:"-"`
+}
+
+// CampoLayout es una columna del archivo. Para ancho fijo, Ancho es obligatorio y el valor se completa con Relleno.
+// Los importes se pueden informar sin separador decimal (en centavos), como piden muchos sistemas de obras sociales.
+type CampoLayout struct {
+	Nombre              string `json:"nombre"`
+	Titulo              string `json:"titulo"`
+	Ancho               int    `json:"ancho"`
+	Alineacion          string `json:"alineacion"`
+	Relleno             string `json:"relleno"`
+	FormatoFecha        string `json:"formato_fecha"`
+	SinSeparadorDecimal bool   `json:"sin_separador_decimal"`
+}
+
+// campos que se pueden exportar de cada ítem
+var camposItem = map[string]bool{
+	"item":              true,
+	"id_cargo":          true,
+	"id_paciente":       true,
+	"numero_afiliado":   true,
+	"plan":              true,
+	"codigo_prestacion": true,
+	"fecha":             true,
+	"importe":           true,
+	"periodo":           true,
+}
+
+// LayoutPorDefecto se usa cuando la obra social no tiene un layout propio.
+func LayoutPorDefecto() Layout {
+	return Layout{
+		Formato:    FormatoCSV,
+		Separador:  ";",
+		Encabezado: true,
+		Campos: []CampoLayout{
+			{Nombre: "periodo"},
+			{Nombre: "numero_afiliado"},
+			{Nombre: "plan"},
+			{Nombre: "codigo_prestacion"},
+			{Nombre: "fecha", FormatoFecha: "02/01/2006"},
+			{Nombre: "importe"},
+		},
+	}
+}
+
+// Validar verifica que el layout se pueda aplicar.
+func (l Layout) Validar() error {
+	if l.Formato != FormatoCSV && l.Formato != FormatoFijo {
+		return ErrLayoutInvalido
+	}
+	if l.Formato == FormatoCSV && utf8.RuneCountInString(l.Separador) > 1 {
+		return ErrLayoutInvalido
+	}
+	if len(l.Campos) == 0 {
+		return ErrLayoutInvalido
+	}
+	for _, c := range l.Campos {
+		if !camposItem[c.Nombre] {
+			return ErrLayoutInvalido
+		}
+		if l.Formato == FormatoFijo && c.Ancho < 1 {
+			return ErrLayoutInvalido
+		}
+		if c.Alineacion != "" && c.Alineacion != AlineacionIzquierda && c.Alineacion != AlineacionDerecha {
+			return ErrLayoutInvalido
+		}
+		if utf8.RuneCountInString(c.Relleno) > 1 {
+			return ErrLayoutInvalido
+		}
+	}
+	return nil
+}
+
+// Exportar genera el archivo del lote según el layout.
+func (l Layout) Exportar(lote Lote) ([]byte, error) {
+	if err := l.Validar(); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if l.Formato == FormatoCSV {
+		w := csv.NewWriter(&buf)
+		if l.Separador != "" {
+			w.Comma, _ = utf8.DecodeRuneInString(l.Separador)
+		}
+		if l.Encabezado {
+			titulos := make([]string, len(l.Campos))
+			for i, c := range l.Campos {
+				titulos[i] = c.Titulo
+				if titulos[i] == "" {
+					titulos[i] = c.Nombre
+				}
+			}
+			if err := w.Write(titulos); err != nil {
+				return nil, err
+			}
+		}
+		for i, item := range lote.Items {
+			registro := make([]string, len(l.Campos))
+			for j, c := range l.Campos {
+				registro[j] = valorCampo(c, lote, i, item)
+			}
+			if err := w.Write(registro); err != nil {
+				return nil, err
+			}
+		}
+		w.Flush()
+		return buf.Bytes(), w.Error()
+	}
+
+	// ancho fijo: una línea por ítem, cada campo completado a su ancho
+	for i, item := range lote.Items {
+		for _, c := range l.Campos {
+			valor, err := ajustar(valorCampo(c, lote, i, item), c)
+			if err != nil {
+				return nil, fmt.Errorf("%w: ítem %d, %v", err, i+1, c.Nombre)
+			}
+			buf.WriteString(valor)
+		}
+		buf.WriteString("\r\n")
+	}
+	return buf.Bytes(), nil
+}
+
+// valorCampo devuelve el valor de un campo del ítem como texto
+func valorCampo(c CampoLayout, lote Lote, indice int, item Item) string {
+	switch c.Nombre {
+	case "item":
+		return strconv.Itoa(indice + 1)
+	case "id_cargo":
+		return strconv.Itoa(item.IdCargo)
+	case "id_paciente":
+		return strconv.Itoa(item.IdPaciente)
+	case "numero_afiliado":
+		return item.NumeroAfiliado
+	case "plan":
+		return item.Plan
+	case "codigo_prestacion":
+		return item.CodigoPrestacion
+	case "periodo":
+		return lote.Periodo
+	case "fecha":
+		formato := c.FormatoFecha
+		if formato == "" {
+			formato = "2006-01-02"
+		}
+		return item.Fecha.Format(formato)
+	case "importe":
+		if c.SinSeparadorDecimal {
+			return fmt.Sprintf("%d", int64(item.Importe*100+0.5))
+		}
+		return strconv.FormatFloat(item.Importe, 'f', 2, 64)
+	}
+	return ""
+}
+
+// ajustar completa el valor al ancho del campo. Si no entra devuelve ErrDesborde en lugar de cortarlo.
+func ajustar(valor string, c CampoLayout) (string, error) {
+	runas := []rune(valor)
+	if len(runas) > c.Ancho {
+		return "", ErrDesborde
+	}
+
+	relleno := c.Relleno
+	if relleno == "" {
+		relleno = " "
+	}
+	faltante := strings.Repeat(relleno, c.Ancho-len(runas))
+	if c.Alineacion == AlineacionDerecha {
+		return faltante + valor, nil
+	}
+	return valor + faltante, nil
+}
@@ -0,0 +1,53 @@
+package liquidacion
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAjustar(t *testing.T) {
+	casos := []struct {
+		nombre string
+		valor  string
+		campo  CampoLayout
+		espera string
+		err    error
+	}{
+		{"completa a la izquierda", "AB", CampoLayout{Ancho: 5}, "AB   ", nil},
+		{"completa a la derecha con ceros", "123", CampoLayout{Ancho: 6, Alineacion: AlineacionDerecha, Relleno: "0"}, "000123", nil},
+		{"justo el ancho", "12345", CampoLayout{Ancho: 5}, "12345", nil},
+		{"cuenta runas y no bytes", "Ñandú", CampoLayout{Ancho: 5}, "Ñandú", nil},
+		{"no trunca", "123456", CampoLayout{Ancho: 5}, "", ErrDesborde},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			valor, err := ajustar(c.valor, c.campo)
+			if !errors.Is(err, c.err) || valor != c.espera {
+				t.Fatalf("ajustar(%q) = %q, %v; se esperaba %q, %v", c.valor, valor, err, c.espera, c.err)
+			}
+		})
+	}
+}
+
+func TestExportarFijoRechazaDesborde(t *testing.T) {
+	layout := Layout{Formato: FormatoFijo, Campos: []CampoLayout{
+		{Nombre: "id_cargo", Ancho: 4, Alineacion: AlineacionDerecha, Relleno: "0"},
+		{Nombre: "importe", Ancho: 6, Alineacion: AlineacionDerecha, Relleno: "0", SinSeparadorDecimal: true},
+	}}
+	lote := Lote{Items: []Item{
+		{IdCargo: 7, Importe: 1500, Fecha: time.Now()},
+		{IdCargo: 8, Importe: 12345.67, Fecha: time.Now()},
+	}}
+
+	_, err := layout.Exportar(lote)
+	if !errors.Is(err, ErrDesborde) {
+		t.Fatalf("un importe de 1234567 centavos no entra en 6 posiciones y dio %v", err)
+	}
+
+	lote.Items = lote.Items[:1]
+	archivo, err := layout.Exportar(lote)
+	if err != nil || string(archivo) != "0007150000\r\n" {
+		t.Fatalf("exportación mal armada: %q, %v", archivo, err)
+	}
+}
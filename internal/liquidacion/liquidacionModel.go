@@ -0,0 +1,98 @@
+package liquidacion
+
+import "time"
+
+// estados del lote de liquidación
+const (
+	EstadoAbierto       = "abierto"
+	EstadoEnviado       = "enviado"
+	EstadoPagadoParcial = "pagado_parcial"
+	EstadoPagado        = "pagado"
+	EstadoRechazado     = "rechazado"
+)
+
+// estados de cada ítem del lote
+const (
+	ItemPendiente = "pendiente"
+	ItemPagado    = "pagado"
+	ItemRechazado = "rechazado"
+)
+
+// Lote agrupa lo que una obra social nos debe por las prestaciones de un período (YYYY-MM).
+type Lote struct {
+	ID             int       `json:"id"`
+	IdObraSocial   int       `json:"id_obra_social"`
+	Periodo        string    `json:"periodo"`
+	Estado         string    `json:"estado"`
+	FechaCreacion  time.Time `json:"fecha_creacion"`
+	FechaEnvio     time.Time `json:"fecha_envio"`
+	Total          float64   `json:"total"`
+	TotalPagado    float64   `json:"total_pagado"`
+	TotalRechazado float64   `json:"total_rechazado"`
+	Items          []Item    `json:"items,omitempty"`
+	// sube con cada cambio de estado; el que se hace sobre una versión vieja no se aplica
+	Version int `json:"version"`
+}
+
+// creamos la estructura de lote para las solicitudes por API.
+type LoteRequest struct {
+	IdObraSocial int    `json:"id_obra_social"`
+	Periodo      string `json:"periodo"`
+}
+
+// Item es una prestación reclamada a la obra social, tomada de la parte a su cargo de un cargo facturado.
+type Item struct {
+	ID               int       `json:"id"`
+	IdLote           int       `json:"id_lote"`
+	IdCargo          int       `json:"id_cargo"`
+	IdPaciente       int       `json:"id_paciente"`
+	NumeroAfiliado   string    `json:"numero_afiliado"`
+	Plan             string    `json:"plan"`
+	CodigoPrestacion string    `json:"codigo_prestacion"`
+	Fecha            time.Time `json:"fecha"`
+	Importe          float64   `json:"importe"`
+	ImportePagado    float64   `json:"importe_pagado"`
+	Estado           string    `json:"estado"`
+	MotivoRechazo    string    `json:"motivo_rechazo"`
+	// versión del cargo al armar el lote: el alta del ítem lo reclama sólo si ningún otro lote lo hizo antes
+	VersionCargo int `json:"-"`
+}
+
+// PagoLote es una transferencia recibida de la obra social a cuenta de un lote.
+type PagoLote struct {
+	ID         int       `json:"id"`
+	IdLote     int       `json:"id_lote"`
+	Fecha      time.Time `json:"fecha"`
+	Importe    float64   `json:"importe"`
+	Referencia string    `json:"referencia"`
+}
+
+// PagoLoteRequest registra el pago recibido junto con el detalle de ítems pagados y rechazados que informa la obra social.
+type PagoLoteRequest struct {
+	Importe    float64         `json:"importe"`
+	Referencia string          `json:"referencia"`
+	Items      []ItemLiquidado `json:"items"`
+}
+
+// ItemLiquidado es la respuesta de la obra social para un ítem del lote.
+type ItemLiquidado struct {
+	IdItem        int     `json:"id_item"`
+	ImportePagado float64 `json:"importe_pagado"`
+	Rechazado     bool    `json:"rechazado"`
+	MotivoRechazo string  `json:"motivo_rechazo"`
+}
+
+// Conciliacion compara lo facturado en el lote con lo liquidado por ítem y con el dinero efectivamente recibido.
+type Conciliacion struct {
+	IdLote         int        `json:"id_lote"`
+	Estado         string     `json:"estado"`
+	Total          float64    `json:"total"`
+	TotalPagado    float64    `json:"total_pagado"`
+	TotalRechazado float64    `json:"total_rechazado"`
+	TotalPendiente float64    `json:"total_pendiente"`
+	TotalRecibido  float64    `json:"total_recibido"`
+	Diferencia     float64    `json:"diferencia"`
+	Pagos          []PagoLote `json:"pagos"`
+	// versión del lote, la que hay que mandar en el próximo pago
+	Version int `json:"version"`
+}
@@ -0,0 +1,49 @@
+package liquidacion
+
+import "database/sql"
+
+// Queries de postgres: parámetros $1, $2, ..., marcas BOOLEAN y las altas devuelven el ID con RETURNING id
+var (
+	QueryInsertLotePostgres           = `INSERT INTO lote_liquidacion(id_obra_social, periodo, estado, fecha_creacion, total) VALUES($1, $2, $3, $4, $5) RETURNING id`
+	QueryGetLoteByIdPostgres          = `SELECT id, id_obra_social, periodo, estado, fecha_creacion, fecha_envio, total, version FROM lote_liquidacion WHERE id = $1`
+	QueryGetLotesPostgres             = `SELECT id, id_obra_social, periodo, estado, fecha_creacion, fecha_envio, total, version FROM lote_liquidacion WHERE ($1 = 0 OR id_obra_social = $2) AND ($3 = '' OR periodo = $4) ORDER BY periodo DESC, id DESC`
+	QueryUpdateEstadoLotePostgres     = `UPDATE lote_liquidacion SET estado = $1, fecha_envio = $2, version = version + 1 WHERE id = $3 AND version = $4`
+	QuerySetEstadoLotePostgres        = `UPDATE lote_liquidacion SET estado = $1, version = version + 1 WHERE id = $2 AND version = $3`
+	QueryReclamarCargoPostgres        = `UPDATE cargo SET version = version + 1 WHERE id = $1 AND version = $2`
+	QueryInsertItemPostgres           = `INSERT INTO item_liquidacion(id_lote, id_cargo, id_paciente, numero_afiliado, plan, codigo_prestacion, fecha, importe, importe_pagado, estado, motivo_rechazo) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id`
+	QueryGetItemsByLotePostgres       = `SELECT id, id_lote, id_cargo, id_paciente, numero_afiliado, plan, codigo_prestacion, fecha, importe, importe_pagado, estado, motivo_rechazo FROM item_liquidacion WHERE id_lote = $1 ORDER BY fecha, id`
+	QueryUpdateItemPostgres           = `UPDATE item_liquidacion SET importe_pagado = $1, estado = $2, motivo_rechazo = $3, version = version + 1 WHERE id = $4 AND id_lote = $5 AND estado = 'pendiente'`
+	QueryInsertPagoLotePostgres       = `INSERT INTO pago_liquidacion(id_lote, fecha, importe, referencia) VALUES($1, $2, $3, $4) RETURNING id`
+	QueryGetPagosByLotePostgres       = `SELECT id, id_lote, fecha, importe, referencia FROM pago_liquidacion WHERE id_lote = $1 ORDER BY fecha, id`
+	QueryGetCargosLiquidablesPostgres = `SELECT c.id, c.id_turno, c.id_paciente, c.id_obra_social, c.codigo_prestacion, c.fecha, c.importe, c.importe_obra_social, c.importe_paciente, c.version FROM cargo c WHERE c.id_obra_social = $1 AND c.fecha >= $2 AND c.fecha < $3 AND c.importe_obra_social > 0 AND NOT EXISTS (SELECT 1 FROM item_liquidacion i WHERE i.id_cargo = c.id AND i.estado <> 'rechazado') ORDER BY c.fecha, c.id`
+	QueryGetLayoutPostgres            = `SELECT definicion, version FROM layout_liquidacion WHERE id_obra_social = $1`
+	QueryInsertLayoutPostgres         = `INSERT INTO layout_liquidacion(id_obra_social, definicion) VALUES($1, $2)`
+	QueryUpdateLayoutPostgres         = `UPDATE layout_liquidacion SET definicion = $1, version = version + 1 WHERE id_obra_social = $2 AND version = $3`
+)
+
+var consultasPostgres = consultas{
+	insertLote:           QueryInsertLotePostgres,
+	getLoteById:          QueryGetLoteByIdPostgres,
+	getLotes:             QueryGetLotesPostgres,
+	updateEstadoLote:     QueryUpdateEstadoLotePostgres,
+	setEstadoLote:        QuerySetEstadoLotePostgres,
+	reclamarCargo:        QueryReclamarCargoPostgres,
+	insertItem:           QueryInsertItemPostgres,
+	getItemsByLote:       QueryGetItemsByLotePostgres,
+	updateItem:           QueryUpdateItemPostgres,
+	insertPagoLote:       QueryInsertPagoLotePostgres,
+	getPagosByLote:       QueryGetPagosByLotePostgres,
+	getCargosLiquidables: QueryGetCargosLiquidablesPostgres,
+	getLayout:            QueryGetLayoutPostgres,
+	insertLayout:         QueryInsertLayoutPostgres,
+	updateLayout:         QueryUpdateLayoutPostgres,
+	returning:            true,
+}
+
+// NewRepositoryPostgres instancia repositorio sobre postgres
+func NewRepositoryPostgres(db *sql.DB) Repository {
+	return &repository{
+		db: db,
+		q:  consultasPostgres,
+	}
+}
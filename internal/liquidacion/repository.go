@@ -0,0 +1,484 @@
+package liquidacion
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"finalgo/internal/facturacion"
+	"finalgo/pkg/basedatos"
+)
+
+// Errores
+var (
+	ErrEmptyList       = errors.New("la lista de lotes esta vacia")
+	ErrNotFound        = errors.New("lote no encontrado")
+	ErrItemNotFound    = errors.New("ítem del lote no encontrado")
+	ErrSinPrestaciones = errors.New("no hay prestaciones para liquidar en el período")
+	ErrPeriodo         = errors.New("el período debe tener formato YYYY-MM")
+	ErrEstado          = errors.New("el estado del lote no permite la operación")
+	ErrImporte         = errors.New("el importe liquidado es inválido")
+	ErrStatement       = errors.New("sentencia incorrecta")
+	ErrExec            = errors.New("ejecución SQL incorrecta")
+	ErrLastId          = errors.New("error al obtener el último ID")
+	ErrVersionLayout   = errors.New("el layout cambió desde que se leyó, hay que volver a consultarlo")
+	ErrVersion         = errors.New("el lote cambió desde que se leyó, hay que volver a consultarlo")
+	ErrCargoReclamado  = errors.New("otro lote reclamó uno de los cargos, hay que volver a generarlo")
+)
+
+// Queries a usar en cada función. Toda modificación sube la versión y sólo se aplica sobre la versión leída. Los ítems
+// se liquidan una sola vez: el update exige que sigan pendientes.
+var (
+	QueryInsertLote       = `INSERT INTO lote_liquidacion(id_obra_social, periodo, estado, fecha_creacion, total) VALUES(?,?,?,?,?)`
+	QueryGetLoteById      = `SELECT id, id_obra_social, periodo, estado, fecha_creacion, fecha_envio, total, version FROM lote_liquidacion WHERE id = ?`
+	QueryGetLotes         = `SELECT id, id_obra_social, periodo, estado, fecha_creacion, fecha_envio, total, version FROM lote_liquidacion WHERE (? = 0 OR id_obra_social = ?) AND (? = '' OR periodo = ?) ORDER BY periodo DESC, id DESC`
+	QueryUpdateEstadoLote = `UPDATE lote_liquidacion SET estado = ?, fecha_envio = ?, version = version + 1 WHERE id = ? AND version = ?`
+	QuerySetEstadoLote    = `UPDATE lote_liquidacion SET estado = ?, version = version + 1 WHERE id = ? AND version = ?`
+	// un cargo lo reclama un solo lote: dos altas simultáneas leen la misma versión y la segunda no afecta filas
+	QueryReclamarCargo = `UPDATE cargo SET version = version + 1 WHERE id = ? AND version = ?`
+
+	QueryInsertItem     = `INSERT INTO item_liquidacion(id_lote, id_cargo, id_paciente, numero_afiliado, plan, codigo_prestacion, fecha, importe, importe_pagado, estado, motivo_rechazo) VALUES(?,?,?,?,?,?,?,?,?,?,?)`
+	QueryGetItemsByLote = `SELECT id, id_lote, id_cargo, id_paciente, numero_afiliado, plan, codigo_prestacion, fecha, importe, importe_pagado, estado, motivo_rechazo FROM item_liquidacion WHERE id_lote = ? ORDER BY fecha, id`
+	QueryUpdateItem     = `UPDATE item_liquidacion SET importe_pagado = ?, estado = ?, motivo_rechazo = ?, version = version + 1 WHERE id = ? AND id_lote = ? AND estado = 'pendiente'`
+	QueryInsertPagoLote = `INSERT INTO pago_liquidacion(id_lote, fecha, importe, referencia) VALUES(?,?,?,?)`
+	QueryGetPagosByLote = `SELECT id, id_lote, fecha, importe, referencia FROM pago_liquidacion WHERE id_lote = ? ORDER BY fecha, id`
+
+	// cargos con parte a cargo de la obra social que todavía no se reclamaron (o que fueron rechazados y se pueden volver a presentar)
+	QueryGetCargosLiquidables = `SELECT c.id, c.id_turno, c.id_paciente, c.id_obra_social, c.codigo_prestacion, c.fecha, c.importe, c.importe_obra_social, c.importe_paciente, c.version FROM cargo c WHERE c.id_obra_social = ? AND c.fecha >= ? AND c.fecha < ? AND c.importe_obra_social > 0 AND NOT EXISTS (SELECT 1 FROM item_liquidacion i WHERE i.id_cargo = c.id AND i.estado <> 'rechazado') ORDER BY c.fecha, c.id`
+
+	// la primera vez se inserta; después se reemplaza la definición de la versión leída
+	QueryGetLayout    = `SELECT definicion, version FROM layout_liquidacion WHERE id_obra_social = ?`
+	QueryInsertLayout = `INSERT INTO layout_liquidacion(id_obra_social, definicion) VALUES(?,?)`
+	QueryUpdateLayout = `UPDATE layout_liquidacion SET definicion = ?, version = version + 1 WHERE id_obra_social = ? AND version = ?`
+)
+
+// consultas son las queries de cada función en el dialecto del motor: MySQL y SQLite usan las de arriba y
+// PostgreSQL las de postgres.go
+type consultas struct {
+	insertLote           string
+	getLoteById          string
+	getLotes             string
+	updateEstadoLote     string
+	setEstadoLote        string
+	reclamarCargo        string
+	insertItem           string
+	getItemsByLote       string
+	updateItem           string
+	insertPagoLote       string
+	getPagosByLote       string
+	getCargosLiquidables string
+	getLayout            string
+	insertLayout         string
+	updateLayout         string
+	// las altas devuelven el ID con RETURNING id, porque el motor no tiene LastInsertId
+	returning bool
+}
+
+var consultasMySQL = consultas{
+	insertLote:           QueryInsertLote,
+	getLoteById:          QueryGetLoteById,
+	getLotes:             QueryGetLotes,
+	updateEstadoLote:     QueryUpdateEstadoLote,
+	setEstadoLote:        QuerySetEstadoLote,
+	reclamarCargo:        QueryReclamarCargo,
+	insertItem:           QueryInsertItem,
+	getItemsByLote:       QueryGetItemsByLote,
+	updateItem:           QueryUpdateItem,
+	insertPagoLote:       QueryInsertPagoLote,
+	getPagosByLote:       QueryGetPagosByLote,
+	getCargosLiquidables: QueryGetCargosLiquidables,
+	getLayout:            QueryGetLayout,
+	insertLayout:         QueryInsertLayout,
+	updateLayout:         QueryUpdateLayout,
+}
+
+// defino la interfaz para que se apliquen siempre todos los métodos
+type Repository interface {
+	CreateLote(ctx context.Context, l Lote) (Lote, error)
+	GetLoteByID(ctx context.Context, id int) (Lote, error)
+	GetLotes(ctx context.Context, idObraSocial int, periodo string) ([]Lote, error)
+	UpdateEstadoLote(ctx context.Context, id int, version int, estado string, fechaEnvio time.Time) error
+	GetItemsByLote(ctx context.Context, idLote int) ([]Item, error)
+	RegistrarPago(ctx context.Context, p PagoLote, items []Item, estado string, version int) (PagoLote, error)
+	GetPagosByLote(ctx context.Context, idLote int) ([]PagoLote, error)
+	GetCargosLiquidables(ctx context.Context, idObraSocial int, desde time.Time, hasta time.Time) ([]facturacion.Cargo, error)
+	GetLayout(ctx context.Context, idObraSocial int) (Layout, error)
+	SaveLayout(ctx context.Context, idObraSocial int, l Layout) (Layout, error)
+}
+
+// estructura repositorio con base de datos mysql
+type repository struct {
+	db *sql.DB
+	q  consultas
+}
+
+// NewRepositoryMySql instancia repositorio
+func NewRepositoryMySql(db *sql.DB) Repository {
+	return &repository{
+		db: db,
+		q:  consultasMySQL,
+	}
+}
+
+// NewRepositorySqlite instancia repositorio sobre sqlite
+func NewRepositorySqlite(db *sql.DB) Repository {
+	return &repository{
+		db: db,
+		q:  consultasMySQL,
+	}
+}
+
+// crear el lote con todos sus ítems; si falla algún ítem, o su cargo ya lo reclamó otro lote, no queda nada grabado
+func (r *repository) CreateLote(ctx context.Context, lote Lote) (Lote, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Lote{}, ErrStatement
+	}
+	defer tx.Rollback()
+
+	result, err := basedatos.Insertar(ctx, tx, r.q.returning, r.q.insertLote, lote.IdObraSocial, lote.Periodo, lote.Estado, lote.FechaCreacion, lote.Total)
+	if err != nil {
+		return Lote{}, ErrExec
+	}
+	lastId, err := result.LastInsertId()
+	if err != nil {
+		return Lote{}, ErrLastId
+	}
+	lote.ID = int(lastId)
+
+	statement, err := tx.PrepareContext(ctx, r.q.insertItem)
+	if err != nil {
+		return Lote{}, ErrStatement
+	}
+	defer statement.Close()
+
+	for i := range lote.Items {
+		lote.Items[i].IdLote = lote.ID
+		item := lote.Items[i]
+		reclamo, err := tx.ExecContext(ctx, r.q.reclamarCargo, item.IdCargo, item.VersionCargo)
+		if err != nil {
+			return Lote{}, ErrExec
+		}
+		if rowsAffected, err := reclamo.RowsAffected(); err != nil || rowsAffected < 1 {
+			return Lote{}, ErrCargoReclamado
+		}
+		result, err := basedatos.InsertarPreparado(
+			ctx,
+			statement,
+			r.q.returning,
+			item.IdLote,
+			item.IdCargo,
+			item.IdPaciente,
+			item.NumeroAfiliado,
+			item.Plan,
+			item.CodigoPrestacion,
+			item.Fecha,
+			item.Importe,
+			item.ImportePagado,
+			item.Estado,
+			item.MotivoRechazo,
+		)
+		if err != nil {
+			return Lote{}, ErrExec
+		}
+		lastId, err := result.LastInsertId()
+		if err != nil {
+			return Lote{}, ErrLastId
+		}
+		lote.Items[i].ID = int(lastId)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Lote{}, ErrExec
+	}
+	lote.Version = 1
+	return lote, nil
+}
+
+// obtener lote por ID (sin ítems)
+func (r *repository) GetLoteByID(ctx context.Context, id int) (Lote, error) {
+	return scanLote(r.db.QueryRowContext(ctx, r.q.getLoteById, id))
+}
+
+// obtener lotes filtrando por obra social y período (cero y vacío no filtran)
+func (r *repository) GetLotes(ctx context.Context, idObraSocial int, periodo string) ([]Lote, error) {
+	rows, err := r.db.QueryContext(ctx, r.q.getLotes, idObraSocial, idObraSocial, periodo, periodo)
+	if err != nil {
+		return []Lote{}, ErrEmptyList
+	}
+	defer rows.Close()
+
+	var lotes []Lote
+	for rows.Next() {
+		lote, err := scanLote(rows)
+		if err != nil {
+			return []Lote{}, ErrExec
+		}
+		lotes = append(lotes, lote)
+	}
+
+	// verifico haber cargado bien todos los registros
+	if err := rows.Err(); err != nil {
+		return []Lote{}, ErrExec
+	}
+
+	return lotes, nil
+}
+
+// actualizar el estado del lote, sólo si la versión es la leída
+func (r *repository) UpdateEstadoLote(ctx context.Context, id int, version int, estado string, fechaEnvio time.Time) error {
+	result, err := r.db.ExecContext(ctx, r.q.updateEstadoLote, estado, nullTime(fechaEnvio), id, version)
+	if err != nil {
+		return ErrStatement
+	}
+
+	// el service ya leyó el lote: sin filas afectadas, otro pedido lo cambió en el medio
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return ErrExec
+	}
+	if rowsAffected < 1 {
+		return ErrVersion
+	}
+
+	return nil
+}
+
+// obtener los ítems de un lote
+func (r *repository) GetItemsByLote(ctx context.Context, idLote int) ([]Item, error) {
+	rows, err := r.db.QueryContext(ctx, r.q.getItemsByLote, idLote)
+	if err != nil {
+		return []Item{}, ErrEmptyList
+	}
+	defer rows.Close()
+
+	var items []Item
+	for rows.Next() {
+		var item Item
+		err := rows.Scan(
+			&item.ID,
+			&item.IdLote,
+			&item.IdCargo,
+			&item.IdPaciente,
+			&item.NumeroAfiliado,
+			&item.Plan,
+			&item.CodigoPrestacion,
+			&item.Fecha,
+			&item.Importe,
+			&item.ImportePagado,
+			&item.Estado,
+			&item.MotivoRechazo,
+		)
+		if err != nil {
+			return []Item{}, ErrExec
+		}
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []Item{}, ErrExec
+	}
+
+	return items, nil
+}
+
+// registrar el pago recibido, actualizar los ítems liquidados y el estado del lote en una sola transacción. Si un ítem
+// ya no está pendiente o el lote cambió desde que se leyó, no queda nada grabado: así un pedido repetido no duplica el
+// pago ni pisa lo que liquidó el primero.
+func (r *repository) RegistrarPago(ctx context.Context, pago PagoLote, items []Item, estado string, version int) (PagoLote, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return PagoLote{}, ErrStatement
+	}
+	defer tx.Rollback()
+
+	result, err := basedatos.Insertar(ctx, tx, r.q.returning, r.q.insertPagoLote, pago.IdLote, pago.Fecha, pago.Importe, pago.Referencia)
+	if err != nil {
+		return PagoLote{}, ErrExec
+	}
+	lastId, err := result.LastInsertId()
+	if err != nil {
+		return PagoLote{}, ErrLastId
+	}
+	pago.ID = int(lastId)
+
+	for _, item := range items {
+		result, err := tx.ExecContext(ctx, r.q.updateItem, item.ImportePagado, item.Estado, item.MotivoRechazo, item.ID, pago.IdLote)
+		if err != nil {
+			return PagoLote{}, ErrExec
+		}
+		if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected < 1 {
+			return PagoLote{}, ErrItemNotFound
+		}
+	}
+
+	result, err = tx.ExecContext(ctx, r.q.setEstadoLote, estado, pago.IdLote, version)
+	if err != nil {
+		return PagoLote{}, ErrExec
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected < 1 {
+		return PagoLote{}, ErrVersion
+	}
+
+	if err := tx.Commit(); err != nil {
+		return PagoLote{}, ErrExec
+	}
+	return pago, nil
+}
+
+// obtener los pagos recibidos para un lote
+func (r *repository) GetPagosByLote(ctx context.Context, idLote int) ([]PagoLote, error) {
+	rows, err := r.db.QueryContext(ctx, r.q.getPagosByLote, idLote)
+	if err != nil {
+		return []PagoLote{}, ErrEmptyList
+	}
+	defer rows.Close()
+
+	var pagos []PagoLote
+	for rows.Next() {
+		var pago PagoLote
+		err := rows.Scan(
+			&pago.ID,
+			&pago.IdLote,
+			&pago.Fecha,
+			&pago.Importe,
+			&pago.Referencia,
+		)
+		if err != nil {
+			return []PagoLote{}, ErrExec
+		}
+		pagos = append(pagos, pago)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []PagoLote{}, ErrExec
+	}
+
+	return pagos, nil
+}
+
+// obtener los cargos de la obra social en el rango [desde, hasta) que todavía no fueron reclamados
+func (r *repository) GetCargosLiquidables(ctx context.Context, idObraSocial int, desde time.Time, hasta time.Time) ([]facturacion.Cargo, error) {
+	rows, err := r.db.QueryContext(ctx, r.q.getCargosLiquidables, idObraSocial, desde, hasta)
+	if err != nil {
+		return []facturacion.Cargo{}, ErrEmptyList
+	}
+	defer rows.Close()
+
+	var cargos []facturacion.Cargo
+	for rows.Next() {
+		var cargo facturacion.Cargo
+		err := rows.Scan(
+			&cargo.ID,
+			&cargo.IdTurno,
+			&cargo.IdPaciente,
+			&cargo.IdObraSocial,
+			&cargo.CodigoPrestacion,
+			&cargo.Fecha,
+			&cargo.Importe,
+			&cargo.ImporteObraSocial,
+			&cargo.ImportePaciente,
+			&cargo.Version,
+		)
+		if err != nil {
+			return []facturacion.Cargo{}, ErrExec
+		}
+		cargos = append(cargos, cargo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []facturacion.Cargo{}, ErrExec
+	}
+
+	return cargos, nil
+}
+
+// obtener el layout de exportación de una obra social
+func (r *repository) GetLayout(ctx context.Context, idObraSocial int) (Layout, error) {
+	var definicion string
+	var version int
+	err := r.db.QueryRowContext(ctx, r.q.getLayout, idObraSocial).Scan(&definicion, &version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Layout{}, ErrNotFound
+	}
+	if err != nil {
+		return Layout{}, ErrExec
+	}
+
+	var layout Layout
+	if err := json.Unmarshal([]byte(definicion), &layout); err != nil {
+		return Layout{}, ErrLayoutInvalido
+	}
+	layout.Version = version
+	return layout, nil
+}
+
+// guardar el layout de exportación de una obra social. Con Version 0 es el primero (si otro ya lo cargó, el alta
+// choca con la clave y es ErrVersionLayout); si no, reemplaza la versión leída.
+func (r *repository) SaveLayout(ctx context.Context, idObraSocial int, layout Layout) (Layout, error) {
+	definicion, err := json.Marshal(layout)
+	if err != nil {
+		return Layout{}, ErrLayoutInvalido
+	}
+
+	if layout.Version == 0 {
+		if _, err := r.db.ExecContext(ctx, r.q.insertLayout, idObraSocial, string(definicion)); err != nil {
+			if basedatos.EsDuplicado(err) {
+				return Layout{}, ErrVersionLayout
+			}
+			return Layout{}, ErrExec
+		}
+		layout.Version = 1
+		return layout, nil
+	}
+
+	result, err := r.db.ExecContext(ctx, r.q.updateLayout, string(definicion), idObraSocial, layout.Version)
+	if err != nil {
+		return Layout{}, ErrExec
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return Layout{}, ErrExec
+	}
+	// la obra social ya se verificó: si no hay fila con esa versión, el layout cambió o nunca existió esa versión
+	if rowsAffected < 1 {
+		return Layout{}, ErrVersionLayout
+	}
+	layout.Version++
+	return layout, nil
+}
+
+// scanner lo cumplen tanto *sql.Row como *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanLote lee un lote de una fila
+func scanLote(row scanner) (Lote, error) {
+	var lote Lote
+	var fechaEnvio sql.NullTime
+	err := row.Scan(
+		&lote.ID,
+		&lote.IdObraSocial,
+		&lote.Periodo,
+		&lote.Estado,
+		&lote.FechaCreacion,
+		&fechaEnvio,
+		&lote.Total,
+		&lote.Version,
+	)
+	if err != nil {
+		return Lote{}, ErrNotFound
+	}
+	if fechaEnvio.Valid {
+		lote.FechaEnvio = fechaEnvio.Time
+	}
+	return lote, nil
+}
+
+// nullTime guarda NULL cuando la fecha no fue informada
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
@@ -0,0 +1,160 @@
+package liquidacion_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"finalgo/internal/contrato/contratotest"
+	"finalgo/internal/facturacion"
+	"finalgo/internal/liquidacion"
+	"finalgo/internal/obrasocial"
+	"finalgo/internal/odontologo"
+	"finalgo/internal/paciente"
+	"finalgo/internal/turno"
+	"finalgo/pkg/config"
+)
+
+// el layout arranca en la versión 0 (el por defecto) y cada guardado tiene que traer la versión leída
+func TestSaveLayoutVersion(t *testing.T) {
+	ctx := context.Background()
+	db := contratotest.Base(t, config.MotorSQLite)
+	o, err := obrasocial.NewRepositorySqlite(db).CreateObraSocial(ctx, obrasocial.ObraSocial{Nombre: "OSDE", Sigla: "OSDE", CUIT: "30546741253", Tipo: "prepaga"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := liquidacion.NewRepositorySqlite(db)
+
+	layout := liquidacion.LayoutPorDefecto()
+	primero, err := r.SaveLayout(ctx, o.ID, layout)
+	if err != nil || primero.Version != 1 {
+		t.Fatalf("el primer guardado tiene que dejar la versión 1: %+v, %v", primero, err)
+	}
+	if _, err := r.SaveLayout(ctx, o.ID, layout); !errors.Is(err, liquidacion.ErrVersionLayout) {
+		t.Fatalf("otro alta desde el layout por defecto tiene que fallar por versión, vino %v", err)
+	}
+
+	primero.Separador = "|"
+	segundo, err := r.SaveLayout(ctx, o.ID, primero)
+	if err != nil || segundo.Version != 2 {
+		t.Fatalf("el reemplazo tiene que subir la versión: %+v, %v", segundo, err)
+	}
+	if _, err := r.SaveLayout(ctx, o.ID, primero); !errors.Is(err, liquidacion.ErrVersionLayout) {
+		t.Fatalf("reemplazar con la versión vieja tiene que fallar, vino %v", err)
+	}
+
+	guardado, err := r.GetLayout(ctx, o.ID)
+	if err != nil || guardado.Version != 2 || guardado.Separador != "|" {
+		t.Fatalf("layout mal guardado: %+v, %v", guardado, err)
+	}
+}
+
+// dos lotes armados a la vez leen el mismo cargo: el segundo no lo puede reclamar y no deja nada grabado
+func TestCreateLoteCargoReclamado(t *testing.T) {
+	ctx := context.Background()
+	db := contratotest.Base(t, config.MotorSQLite)
+	r := liquidacion.NewRepositorySqlite(db)
+	idObraSocial, cargo := cargoLiquidable(t, db)
+
+	primero, err := r.CreateLote(ctx, loteCon(idObraSocial, cargo))
+	if err != nil || primero.Version != 1 {
+		t.Fatalf("el primer lote tiene que grabarse con la versión 1: %+v, %v", primero, err)
+	}
+	if _, err := r.CreateLote(ctx, loteCon(idObraSocial, cargo)); !errors.Is(err, liquidacion.ErrCargoReclamado) {
+		t.Fatalf("el segundo lote tiene que fallar por cargo reclamado, vino %v", err)
+	}
+
+	lotes, err := r.GetLotes(ctx, idObraSocial, "")
+	if err != nil || len(lotes) != 1 {
+		t.Fatalf("tiene que quedar un solo lote: %+v, %v", lotes, err)
+	}
+}
+
+// un pago repetido sobre los mismos ítems no se graba dos veces, y un cambio de estado con versión vieja no se aplica
+func TestRegistrarPagoUnaVez(t *testing.T) {
+	ctx := context.Background()
+	db := contratotest.Base(t, config.MotorSQLite)
+	r := liquidacion.NewRepositorySqlite(db)
+	idObraSocial, cargo := cargoLiquidable(t, db)
+
+	lote, err := r.CreateLote(ctx, loteCon(idObraSocial, cargo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.UpdateEstadoLote(ctx, lote.ID, lote.Version, liquidacion.EstadoEnviado, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.UpdateEstadoLote(ctx, lote.ID, lote.Version, liquidacion.EstadoEnviado, time.Now()); !errors.Is(err, liquidacion.ErrVersion) {
+		t.Fatalf("el envío con la versión vieja tiene que fallar, vino %v", err)
+	}
+
+	items, err := r.GetItemsByLote(ctx, lote.ID)
+	if err != nil || len(items) != 1 {
+		t.Fatalf("ítems mal grabados: %+v, %v", items, err)
+	}
+	items[0].Estado = liquidacion.ItemPagado
+	items[0].ImportePagado = items[0].Importe
+	pago := liquidacion.PagoLote{IdLote: lote.ID, Fecha: time.Now(), Importe: items[0].Importe}
+
+	if _, err := r.RegistrarPago(ctx, pago, items, liquidacion.EstadoPagado, lote.Version+1); err != nil {
+		t.Fatal(err)
+	}
+	// el mismo pago con la versión nueva del lote tampoco pasa: el ítem ya no está pendiente
+	if _, err := r.RegistrarPago(ctx, pago, items, liquidacion.EstadoPagado, lote.Version+2); !errors.Is(err, liquidacion.ErrItemNotFound) {
+		t.Fatalf("el pago repetido tiene que fallar por ítem ya liquidado, vino %v", err)
+	}
+
+	pagos, err := r.GetPagosByLote(ctx, lote.ID)
+	if err != nil || len(pagos) != 1 {
+		t.Fatalf("tiene que quedar un solo pago: %+v, %v", pagos, err)
+	}
+}
+
+// cargoLiquidable da de alta la obra social, el paciente, el odontólogo y el turno que necesita un cargo a liquidar
+func cargoLiquidable(t *testing.T, db *sql.DB) (int, facturacion.Cargo) {
+	t.Helper()
+	ctx := context.Background()
+	o, err := obrasocial.NewRepositorySqlite(db).CreateObraSocial(ctx, obrasocial.ObraSocial{Nombre: "OSDE", Sigla: "OSDE", CUIT: "30546741253", Tipo: "prepaga"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := paciente.NewRepositorySqlite(db).CreatePaciente(ctx, paciente.Paciente{Nombre: "Laura", Apellido: "Gómez", DNI: "30111222", Alta: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	od, err := odontologo.NewRepositorySqlite(db).CreateOdontologo(ctx, odontologo.Odontologo{Apellido: "Ruiz", Nombre: "Ana", Matricula: "MP-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fecha := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	tu, err := turno.NewRepositorySqlite(db).CreateTurno(ctx, turno.Turno{IdPaciente: p.ID, IdOdontologo: od.ID, FechaHora: fecha, Descripcion: "control", CodigoPrestacion: "CONSULTA", Estado: turno.EstadoAtendido})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cargo, err := facturacion.NewRepositorySqlite(db).CreateCargo(ctx, facturacion.Cargo{IdTurno: tu.ID, IdPaciente: p.ID, IdObraSocial: o.ID, CodigoPrestacion: "CONSULTA", Fecha: fecha, Importe: 1000, ImporteObraSocial: 800, ImportePaciente: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return o.ID, cargo
+}
+
+func loteCon(idObraSocial int, cargo facturacion.Cargo) liquidacion.Lote {
+	return liquidacion.Lote{
+		IdObraSocial:  idObraSocial,
+		Periodo:       "2024-03",
+		Estado:        liquidacion.EstadoAbierto,
+		FechaCreacion: time.Now(),
+		Total:         cargo.ImporteObraSocial,
+		Items: []liquidacion.Item{{
+			IdCargo:          cargo.ID,
+			IdPaciente:       cargo.IdPaciente,
+			CodigoPrestacion: cargo.CodigoPrestacion,
+			Fecha:            cargo.Fecha,
+			Importe:          cargo.ImporteObraSocial,
+			Estado:           liquidacion.ItemPendiente,
+			VersionCargo:     cargo.Version,
+		}},
+	}
+}
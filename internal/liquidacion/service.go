@@ -0,0 +1,348 @@
+package liquidacion
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"time"
+
+	"finalgo/internal/obrasocial"
+)
+
+// defino la interfaz para que se apliquen siempre todos los métodos
+type Service interface {
+	GenerarLote(ctx context.Context, l LoteRequest) (Lote, error)
+	GetLoteByID(ctx context.Context, id int) (Lote, error)
+	GetLotes(ctx context.Context, idObraSocial int, periodo string) ([]Lote, error)
+	MarcarEnviado(ctx context.Context, id int, version int) (Lote, error)
+	RegistrarPago(ctx context.Context, p PagoLoteRequest, id int, version int) (Conciliacion, error)
+	Conciliar(ctx context.Context, id int) (Conciliacion, error)
+	Exportar(ctx context.Context, id int) ([]byte, Layout, error)
+	GetLayout(ctx context.Context, idObraSocial int) (Layout, error)
+	SaveLayout(ctx context.Context, l Layout, idObraSocial int, version int) (Layout, error)
+}
+
+// estrucutra service que contará con un repositorio y el servicio de obras sociales para los datos de afiliación
+type service struct {
+	r   Repository
+	obs obrasocial.Service
+}
+
+// función para instanciar service
+func NewService(r Repository, obs obrasocial.Service) Service {
+	return &service{
+		r,
+		obs,
+	}
+}
+
+// GenerarLote agrupa en un lote todo lo que la obra social cubrió en el período y todavía no se le reclamó.
+func (s *service) GenerarLote(ctx context.Context, loteRequest LoteRequest) (Lote, error) {
+	desde, err := time.Parse("2006-01", loteRequest.Periodo)
+	if err != nil {
+		return Lote{}, ErrPeriodo
+	}
+	if _, err := s.obs.GetObraSocialByID(ctx, loteRequest.IdObraSocial); err != nil {
+		log.Println("log de error por obra social inexistente", err.Error())
+		return Lote{}, obrasocial.ErrNotFound
+	}
+
+	cargos, err := s.r.GetCargosLiquidables(ctx, loteRequest.IdObraSocial, desde, desde.AddDate(0, 1, 0))
+	if err != nil {
+		log.Println("log de error en cargos a liquidar", err.Error())
+		return Lote{}, ErrExec
+	}
+	if len(cargos) == 0 {
+		return Lote{}, ErrSinPrestaciones
+	}
+
+	lote := Lote{
+		IdObraSocial:  loteRequest.IdObraSocial,
+		Periodo:       loteRequest.Periodo,
+		Estado:        EstadoAbierto,
+		FechaCreacion: time.Now(),
+	}
+	for _, cargo := range cargos {
+		item := Item{
+			IdCargo:          cargo.ID,
+			IdPaciente:       cargo.IdPaciente,
+			CodigoPrestacion: cargo.CodigoPrestacion,
+			Fecha:            cargo.Fecha,
+			Importe:          cargo.ImporteObraSocial,
+			Estado:           ItemPendiente,
+			VersionCargo:     cargo.Version,
+		}
+		// el número de afiliado y el plan son los vigentes a la fecha de la prestación
+		cobertura, err := s.obs.GetCoberturaPrestacion(ctx, cargo.IdPaciente, cargo.CodigoPrestacion, cargo.Fecha)
+		if err == nil {
+			item.NumeroAfiliado = cobertura.NumeroAfiliado
+			item.Plan = cobertura.Plan
+		} else if !errors.Is(err, obrasocial.ErrSinCobertura) {
+			log.Println("log de error en la cobertura del cargo", err.Error())
+			return Lote{}, ErrExec
+		}
+		lote.Items = append(lote.Items, item)
+		lote.Total += item.Importe
+	}
+	lote.Total = redondear(lote.Total)
+
+	response, err := s.r.CreateLote(ctx, lote)
+	if err != nil {
+		log.Println("error al crear lote de liquidación", err.Error())
+		if errors.Is(err, ErrCargoReclamado) {
+			return Lote{}, ErrCargoReclamado
+		}
+		return Lote{}, ErrExec
+	}
+	return response, nil
+}
+
+// GetLoteByID devuelve el lote con sus ítems y los totales liquidados hasta el momento.
+func (s *service) GetLoteByID(ctx context.Context, id int) (Lote, error) {
+	lote, err := s.r.GetLoteByID(ctx, id)
+	if err != nil {
+		log.Println("log de error por lote inexistente", err.Error())
+		return Lote{}, ErrNotFound
+	}
+
+	items, err := s.r.GetItemsByLote(ctx, id)
+	if err != nil {
+		log.Println("log de error en ítems del lote", err.Error())
+		return Lote{}, ErrExec
+	}
+	lote.Items = items
+	for _, item := range items {
+		lote.TotalPagado += item.ImportePagado
+		if item.Estado == ItemRechazado {
+			lote.TotalRechazado += item.Importe
+		}
+	}
+	lote.TotalPagado = redondear(lote.TotalPagado)
+	lote.TotalRechazado = redondear(lote.TotalRechazado)
+	return lote, nil
+}
+
+func (s *service) GetLotes(ctx context.Context, idObraSocial int, periodo string) ([]Lote, error) {
+	lotes, err := s.r.GetLotes(ctx, idObraSocial, periodo)
+	if err != nil {
+		log.Println("log de error en service de liquidaciones", err.Error())
+		return []Lote{}, ErrEmptyList
+	}
+	return lotes, nil
+}
+
+// MarcarEnviado registra que el lote se presentó a la obra social; a partir de ahí ya no se modifica su contenido.
+// Sólo se aplica sobre la versión del lote que leyó el cliente.
+func (s *service) MarcarEnviado(ctx context.Context, id int, version int) (Lote, error) {
+	lote, err := s.GetLoteByID(ctx, id)
+	if err != nil {
+		return Lote{}, err
+	}
+	if lote.Version != version {
+		return Lote{}, ErrVersion
+	}
+	if lote.Estado != EstadoAbierto {
+		return Lote{}, ErrEstado
+	}
+
+	lote.Estado = EstadoEnviado
+	lote.FechaEnvio = time.Now()
+	if err := s.r.UpdateEstadoLote(ctx, id, lote.Version, lote.Estado, lote.FechaEnvio); err != nil {
+		log.Println("error al marcar lote enviado", err.Error())
+		if errors.Is(err, ErrVersion) {
+			return Lote{}, ErrVersion
+		}
+		return Lote{}, ErrExec
+	}
+	lote.Version++
+	return lote, nil
+}
+
+// RegistrarPago aplica la liquidación informada por la obra social (ítems pagados y rechazados) y guarda el dinero recibido.
+// Sólo se aplica sobre la versión del lote que leyó el cliente, así un pago reenviado no se registra dos veces.
+func (s *service) RegistrarPago(ctx context.Context, pagoRequest PagoLoteRequest, id int, version int) (Conciliacion, error) {
+	lote, err := s.GetLoteByID(ctx, id)
+	if err != nil {
+		return Conciliacion{}, err
+	}
+	if lote.Version != version {
+		return Conciliacion{}, ErrVersion
+	}
+	if lote.Estado != EstadoEnviado && lote.Estado != EstadoPagadoParcial {
+		return Conciliacion{}, ErrEstado
+	}
+
+	indice := make(map[int]int, len(lote.Items))
+	for i := range lote.Items {
+		indice[lote.Items[i].ID] = i
+	}
+
+	var modificados []Item
+	for _, liquidado := range pagoRequest.Items {
+		i, ok := indice[liquidado.IdItem]
+		if !ok || lote.Items[i].Estado != ItemPendiente {
+			return Conciliacion{}, ErrItemNotFound
+		}
+		item := &lote.Items[i]
+		if liquidado.Rechazado {
+			item.Estado = ItemRechazado
+			item.ImportePagado = 0
+			item.MotivoRechazo = liquidado.MotivoRechazo
+		} else {
+			if liquidado.ImportePagado <= 0 || redondear(liquidado.ImportePagado) > item.Importe {
+				return Conciliacion{}, ErrImporte
+			}
+			item.Estado = ItemPagado
+			item.ImportePagado = redondear(liquidado.ImportePagado)
+		}
+		modificados = append(modificados, *item)
+	}
+
+	pago := PagoLote{
+		IdLote:     id,
+		Fecha:      time.Now(),
+		Importe:    redondear(pagoRequest.Importe),
+		Referencia: pagoRequest.Referencia,
+	}
+	if _, err := s.r.RegistrarPago(ctx, pago, modificados, estadoSegunItems(lote.Items), lote.Version); err != nil {
+		log.Println("error al registrar pago de lote", err.Error())
+		if errors.Is(err, ErrVersion) || errors.Is(err, ErrItemNotFound) {
+			return Conciliacion{}, ErrVersion
+		}
+		return Conciliacion{}, ErrExec
+	}
+	return s.Conciliar(ctx, id)
+}
+
+// Conciliar compara lo facturado, lo liquidado por ítem y lo efectivamente cobrado.
+func (s *service) Conciliar(ctx context.Context, id int) (Conciliacion, error) {
+	lote, err := s.GetLoteByID(ctx, id)
+	if err != nil {
+		return Conciliacion{}, err
+	}
+	pagos, err := s.r.GetPagosByLote(ctx, id)
+	if err != nil {
+		log.Println("log de error en pagos del lote", err.Error())
+		return Conciliacion{}, ErrExec
+	}
+
+	conciliacion := Conciliacion{
+		IdLote:         lote.ID,
+		Estado:         lote.Estado,
+		Total:          lote.Total,
+		TotalPagado:    lote.TotalPagado,
+		TotalRechazado: lote.TotalRechazado,
+		Pagos:          pagos,
+		Version:        lote.Version,
+	}
+	if conciliacion.Pagos == nil {
+		conciliacion.Pagos = []PagoLote{}
+	}
+	for _, item := range lote.Items {
+		if item.Estado == ItemPendiente {
+			conciliacion.TotalPendiente += item.Importe
+		}
+	}
+	for _, pago := range pagos {
+		conciliacion.TotalRecibido += pago.Importe
+	}
+	conciliacion.TotalPendiente = redondear(conciliacion.TotalPendiente)
+	conciliacion.TotalRecibido = redondear(conciliacion.TotalRecibido)
+	// positiva si recibimos más de lo liquidado, negativa si la obra social transfirió menos de lo que informó
+	conciliacion.Diferencia = redondear(conciliacion.TotalRecibido - conciliacion.TotalPagado)
+	return conciliacion, nil
+}
+
+// Exportar genera el archivo del lote con el layout de la obra social (o el layout por defecto si no tiene uno).
+func (s *service) Exportar(ctx context.Context, id int) ([]byte, Layout, error) {
+	lote, err := s.GetLoteByID(ctx, id)
+	if err != nil {
+		return nil, Layout{}, err
+	}
+
+	layout, err := s.GetLayout(ctx, lote.IdObraSocial)
+	if err != nil {
+		return nil, Layout{}, err
+	}
+
+	archivo, err := layout.Exportar(lote)
+	if errors.Is(err, ErrDesborde) {
+		log.Println("error al exportar lote", err.Error())
+		return nil, Layout{}, ErrDesborde
+	}
+	if err != nil {
+		log.Println("error al exportar lote", err.Error())
+		return nil, Layout{}, ErrLayoutInvalido
+	}
+	return archivo, layout, nil
+}
+
+// GetLayout devuelve el layout de la obra social o, si no configuró uno, el layout por defecto. Un error de la base no
+// es "sin layout": exportar con el formato equivocado daría un archivo que la obra social rechaza.
+func (s *service) GetLayout(ctx context.Context, idObraSocial int) (Layout, error) {
+	layout, err := s.r.GetLayout(ctx, idObraSocial)
+	if errors.Is(err, ErrNotFound) {
+		return LayoutPorDefecto(), nil
+	}
+	if errors.Is(err, ErrLayoutInvalido) {
+		log.Println("log de error por layout guardado inválido", err.Error())
+		return Layout{}, ErrLayoutInvalido
+	}
+	if err != nil {
+		log.Println("log de error al leer layout", err.Error())
+		return Layout{}, ErrExec
+	}
+	return layout, nil
+}
+
+// SaveLayout guarda el layout si version es la leída: 0 si la obra social todavía usaba el layout por defecto.
+func (s *service) SaveLayout(ctx context.Context, layout Layout, idObraSocial int, version int) (Layout, error) {
+	if err := layout.Validar(); err != nil {
+		return Layout{}, err
+	}
+	if _, err := s.obs.GetObraSocialByID(ctx, idObraSocial); err != nil {
+		log.Println("log de error por obra social inexistente", err.Error())
+		return Layout{}, obrasocial.ErrNotFound
+	}
+
+	layout.Version = version
+	response, err := s.r.SaveLayout(ctx, idObraSocial, layout)
+	if err != nil {
+		log.Println("error al guardar layout", err.Error())
+		if errors.Is(err, ErrVersionLayout) {
+			return Layout{}, ErrVersionLayout
+		}
+		return Layout{}, ErrExec
+	}
+	return response, nil
+}
+
+// estadoSegunItems calcula el estado del lote a partir de sus ítems
+func estadoSegunItems(items []Item) string {
+	pendientes, rechazados := 0, 0
+	for _, item := range items {
+		switch item.Estado {
+		case ItemPendiente:
+			pendientes++
+		case ItemRechazado:
+			rechazados++
+		}
+	}
+
+	switch {
+	case pendientes == len(items):
+		return EstadoEnviado
+	case pendientes > 0:
+		return EstadoPagadoParcial
+	case rechazados == len(items):
+		return EstadoRechazado
+	default:
+		return EstadoPagado
+	}
+}
+
+// redondear deja los importes en centavos
+func redondear(importe float64) float64 {
+	return math.Round(importe*100) / 100
+}
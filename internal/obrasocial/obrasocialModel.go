@@ -0,0 +1,107 @@
+package obrasocial
+
+import "time"
+
+// tipos de financiador admitidos
+const (
+	TipoObraSocial = "obra_social"
+	TipoPrepaga    = "prepaga"
+)
+
+// creamos la estructura de la obra social o prepaga que financia las prestaciones.
+type ObraSocial struct {
+	ID     int    `json:"id"`
+	Nombre string `json:"nombre"`
+	Sigla  string `json:"sigla"`
+	CUIT   string `json:"cuit"`
+	Tipo   string `json:"tipo"`
+	// sube con cada modificación; viaja en el ETag y hay que mandarla en If-Match para modificar o eliminar
+	Version int `json:"version"`
+}
+
+// creamos la misma estructura de obra social para las solicitudes por API.
+type ObraSocialRequest struct {
+	Nombre string `json:"nombre"`
+	Sigla  string `json:"sigla"`
+	CUIT   string `json:"cuit"`
+	Tipo   string `json:"tipo"`
+}
+
+// Cobertura es la afiliación de un paciente a una obra social. VigenciaHasta en cero significa que no vence.
+type Cobertura struct {
+	ID             int       `json:"id"`
+	IdPaciente     int       `json:"id_paciente"`
+	IdObraSocial   int       `json:"id_obra_social"`
+	Plan           string    `json:"plan"`
+	NumeroAfiliado string    `json:"numero_afiliado"`
+	VigenciaDesde  time.Time `json:"vigencia_desde"`
+	VigenciaHasta  time.Time `json:"vigencia_hasta"`
+	Version        int       `json:"version"`
+}
+
+// creamos la misma estructura de cobertura para las solicitudes por API (el paciente viene por la ruta).
+type CoberturaRequest struct {
+	IdObraSocial   int       `json:"id_obra_social"`
+	Plan           string    `json:"plan"`
+	NumeroAfiliado string    `json:"numero_afiliado"`
+	VigenciaDesde  time.Time `json:"vigencia_desde"`
+	VigenciaHasta  time.Time `json:"vigencia_hasta"`
+}
+
+// VigenteEn indica si la cobertura está vigente en la fecha indicada.
+func (c Cobertura) VigenteEn(fecha time.Time) bool {
+	if !c.VigenciaDesde.IsZero() && fecha.Before(c.VigenciaDesde) {
+		return false
+	}
+	if !c.VigenciaHasta.IsZero() && fecha.After(c.VigenciaHasta) {
+		return false
+	}
+	return true
+}
+
+// SeSuperponeCon indica si las vigencias de las dos coberturas comparten algún día. Una vigencia sin fin se superpone
+// con todo lo que empiece después de su inicio.
+func (c Cobertura) SeSuperponeCon(otra Cobertura) bool {
+	if !c.VigenciaHasta.IsZero() && c.VigenciaHasta.Before(otra.VigenciaDesde) {
+		return false
+	}
+	if !otra.VigenciaHasta.IsZero() && otra.VigenciaHasta.Before(c.VigenciaDesde) {
+		return false
+	}
+	return true
+}
+
+// ReglaCobertura define cuánto cubre una obra social por prestación. Plan vacío aplica a todos los planes.
+type ReglaCobertura struct {
+	ID                   int     `json:"id"`
+	IdObraSocial         int     `json:"id_obra_social"`
+	Plan                 string  `json:"plan"`
+	CodigoPrestacion     string  `json:"codigo_prestacion"`
+	PorcentajeCubierto   float64 `json:"porcentaje_cubierto"`
+	Copago               float64 `json:"copago"`
+	RequiereAutorizacion bool    `json:"requiere_autorizacion"`
+	Version              int     `json:"version"`
+}
+
+// creamos la misma estructura de regla para las solicitudes por API (la obra social viene por la ruta).
+type ReglaCoberturaRequest struct {
+	Plan                 string  `json:"plan"`
+	CodigoPrestacion     string  `json:"codigo_prestacion"`
+	PorcentajeCubierto   float64 `json:"porcentaje_cubierto"`
+	Copago               float64 `json:"copago"`
+	RequiereAutorizacion bool    `json:"requiere_autorizacion"`
+}
+
+// CoberturaPrestacion es la respuesta a la consulta "¿qué le cubre la obra social a este paciente para esta prestación?".
+// La usan turnos (avisa al dar el turno si hace falta autorización previa) y facturación (reparto entre paciente y
+// obra social).
+type CoberturaPrestacion struct {
+	Cubierto             bool    `json:"cubierto"`
+	IdObraSocial         int     `json:"id_obra_social"`
+	Plan                 string  `json:"plan"`
+	NumeroAfiliado       string  `json:"numero_afiliado"`
+	CodigoPrestacion     string  `json:"codigo_prestacion"`
+	PorcentajeCubierto   float64 `json:"porcentaje_cubierto"`
+	Copago               float64 `json:"copago"`
+	RequiereAutorizacion bool    `json:"requiere_autorizacion"`
+}
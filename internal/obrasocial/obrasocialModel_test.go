@@ -0,0 +1,41 @@
+package obrasocial
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSeSuperponeCon(t *testing.T) {
+	dia := func(mes, dia int) time.Time {
+		return time.Date(2026, time.Month(mes), dia, 0, 0, 0, 0, time.UTC)
+	}
+	var sinFin time.Time
+	casos := []struct {
+		nombre    string
+		desde     time.Time
+		hasta     time.Time
+		otraDesde time.Time
+		otraHasta time.Time
+		superpone bool
+	}{
+		{"una después de la otra", dia(1, 1), dia(3, 31), dia(4, 1), dia(6, 30), false},
+		{"una antes de la otra", dia(4, 1), dia(6, 30), dia(1, 1), dia(3, 31), false},
+		{"comparten el último día", dia(1, 1), dia(3, 31), dia(3, 31), dia(6, 30), true},
+		{"una dentro de la otra", dia(1, 1), dia(12, 31), dia(4, 1), dia(6, 30), true},
+		{"sin fin y otra que empieza después", dia(1, 1), sinFin, dia(6, 1), dia(6, 30), true},
+		{"sin fin y otra que termina antes", dia(6, 1), sinFin, dia(1, 1), dia(3, 31), false},
+		{"las dos sin fin", dia(1, 1), sinFin, dia(6, 1), sinFin, true},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			cobertura := Cobertura{VigenciaDesde: c.desde, VigenciaHasta: c.hasta}
+			otra := Cobertura{VigenciaDesde: c.otraDesde, VigenciaHasta: c.otraHasta}
+			if got := cobertura.SeSuperponeCon(otra); got != c.superpone {
+				t.Fatalf("SeSuperponeCon = %v; se esperaba %v", got, c.superpone)
+			}
+			if got := otra.SeSuperponeCon(cobertura); got != c.superpone {
+				t.Fatalf("SeSuperponeCon al revés = %v; se esperaba %v", got, c.superpone)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package obrasocial
+
+import "database/sql"
+
+// Queries de postgres: parámetros $1, $2, ..., marcas BOOLEAN y las altas devuelven el ID con RETURNING id
+var (
+	QueryInsertPostgres                  = `INSERT INTO obra_social(nombre, sigla, cuit, tipo) VALUES($1, $2, $3, $4) RETURNING id`
+	QueryDeletePostgres                  = `DELETE FROM obra_social WHERE id = $1 AND version = $2`
+	QueryGetByIdPostgres                 = `SELECT id, nombre, sigla, cuit, tipo, version FROM obra_social WHERE id = $1`
+	QueryUpdatePostgres                  = `UPDATE obra_social SET nombre = $1, sigla = $2, cuit = $3, tipo = $4, version = version + 1 WHERE id = $5 AND version = $6`
+	QueryExistsPostgres                  = `SELECT COUNT(*) FROM obra_social WHERE id = $1`
+	QueryInsertCoberturaPostgres         = `INSERT INTO cobertura_paciente(id_paciente, id_obra_social, plan, numero_afiliado, vigencia_desde, vigencia_hasta) VALUES($1, $2, $3, $4, $5, $6) RETURNING id`
+	QueryGetCoberturasByPacientePostgres = `SELECT id, id_paciente, id_obra_social, plan, numero_afiliado, vigencia_desde, vigencia_hasta, version FROM cobertura_paciente WHERE id_paciente = $1 ORDER BY id`
+	QueryDeleteCoberturaPostgres         = `DELETE FROM cobertura_paciente WHERE id = $1 AND id_paciente = $2 AND version = $3`
+	QueryExistsCoberturaPostgres         = `SELECT COUNT(*) FROM cobertura_paciente WHERE id = $1 AND id_paciente = $2`
+	QueryInsertReglaPostgres             = `INSERT INTO regla_cobertura(id_obra_social, plan, codigo_prestacion, porcentaje_cubierto, copago, requiere_autorizacion) VALUES($1, $2, $3, $4, $5, $6) RETURNING id`
+	QueryGetReglasByObraSocialPostgres   = `SELECT id, id_obra_social, plan, codigo_prestacion, porcentaje_cubierto, copago, requiere_autorizacion, version FROM regla_cobertura WHERE id_obra_social = $1 ORDER BY id`
+	QueryGetReglaPostgres                = `SELECT id, id_obra_social, plan, codigo_prestacion, porcentaje_cubierto, copago, requiere_autorizacion, version FROM regla_cobertura WHERE id_obra_social = $1 AND plan = $2 AND codigo_prestacion = $3`
+	QueryDeleteReglaPostgres             = `DELETE FROM regla_cobertura WHERE id = $1 AND id_obra_social = $2 AND version = $3`
+	QueryExistsReglaPostgres             = `SELECT COUNT(*) FROM regla_cobertura WHERE id = $1 AND id_obra_social = $2`
+)
+
+var consultasPostgres = consultas{
+	insert:                  QueryInsertPostgres,
+	getAll:                  QueryGetAll,
+	delete:                  QueryDeletePostgres,
+	getById:                 QueryGetByIdPostgres,
+	update:                  QueryUpdatePostgres,
+	exists:                  QueryExistsPostgres,
+	insertCobertura:         QueryInsertCoberturaPostgres,
+	getCoberturasByPaciente: QueryGetCoberturasByPacientePostgres,
+	deleteCobertura:         QueryDeleteCoberturaPostgres,
+	existsCobertura:         QueryExistsCoberturaPostgres,
+	insertRegla:             QueryInsertReglaPostgres,
+	getReglasByObraSocial:   QueryGetReglasByObraSocialPostgres,
+	getRegla:                QueryGetReglaPostgres,
+	deleteRegla:             QueryDeleteReglaPostgres,
+	existsRegla:             QueryExistsReglaPostgres,
+	returning:               true,
+}
+
+// NewRepositoryPostgres instancia repositorio sobre postgres
+func NewRepositoryPostgres(db *sql.DB) Repository {
+	return &repository{
+		db: db,
+		q:  consultasPostgres,
+	}
+}
@@ -0,0 +1,470 @@
+package obrasocial
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"finalgo/pkg/basedatos"
+)
+
+// Errores
+var (
+	ErrEmptyList         = errors.New("la lista de obras sociales esta vacia")
+	ErrNotFound          = errors.New("obra social no encontrada")
+	ErrCoberturaNotFound = errors.New("cobertura no encontrada")
+	ErrReglaNotFound     = errors.New("regla de cobertura no encontrada")
+	ErrSinCobertura      = errors.New("el paciente no tiene cobertura vigente")
+	ErrSuperpuesta       = errors.New("el paciente ya tiene una cobertura vigente en ese período")
+	ErrStatement         = errors.New("sentencia incorrecta")
+	ErrExec              = errors.New("ejecución SQL incorrecta")
+	ErrLastId            = errors.New("error al obtener el último ID")
+	ErrVersion           = errors.New("la obra social cambió desde que se leyó, hay que volver a consultarla")
+	ErrVersionCobertura  = errors.New("la cobertura cambió desde que se leyó, hay que volver a consultarla")
+	ErrVersionRegla      = errors.New("la regla de cobertura cambió desde que se leyó, hay que volver a consultarla")
+)
+
+// Queries a usar en cada función. Las modificaciones y las bajas sólo se aplican si la versión es la que leyó quien
+// las pide.
+var (
+	QueryInsert  = `INSERT INTO obra_social(nombre, sigla, cuit, tipo) VALUES(?,?,?,?)`
+	QueryGetAll  = `SELECT id, nombre, sigla, cuit, tipo, version FROM obra_social ORDER BY id`
+	QueryDelete  = `DELETE FROM obra_social WHERE id = ? AND version = ?`
+	QueryGetById = `SELECT id, nombre, sigla, cuit, tipo, version FROM obra_social WHERE id = ?`
+	QueryUpdate  = `UPDATE obra_social SET nombre = ?, sigla = ?, cuit = ?, tipo = ?, version = version + 1 WHERE id = ? AND version = ?`
+	QueryExists  = `SELECT COUNT(*) FROM obra_social WHERE id = ?`
+
+	QueryInsertCobertura         = `INSERT INTO cobertura_paciente(id_paciente, id_obra_social, plan, numero_afiliado, vigencia_desde, vigencia_hasta) VALUES(?,?,?,?,?,?)`
+	QueryGetCoberturasByPaciente = `SELECT id, id_paciente, id_obra_social, plan, numero_afiliado, vigencia_desde, vigencia_hasta, version FROM cobertura_paciente WHERE id_paciente = ? ORDER BY id`
+	QueryDeleteCobertura         = `DELETE FROM cobertura_paciente WHERE id = ? AND id_paciente = ? AND version = ?`
+	QueryExistsCobertura         = `SELECT COUNT(*) FROM cobertura_paciente WHERE id = ? AND id_paciente = ?`
+
+	QueryInsertRegla           = `INSERT INTO regla_cobertura(id_obra_social, plan, codigo_prestacion, porcentaje_cubierto, copago, requiere_autorizacion) VALUES(?,?,?,?,?,?)`
+	QueryGetReglasByObraSocial = `SELECT id, id_obra_social, plan, codigo_prestacion, porcentaje_cubierto, copago, requiere_autorizacion, version FROM regla_cobertura WHERE id_obra_social = ? ORDER BY id`
+	QueryGetRegla              = `SELECT id, id_obra_social, plan, codigo_prestacion, porcentaje_cubierto, copago, requiere_autorizacion, version FROM regla_cobertura WHERE id_obra_social = ? AND plan = ? AND codigo_prestacion = ?`
+	QueryDeleteRegla           = `DELETE FROM regla_cobertura WHERE id = ? AND id_obra_social = ? AND version = ?`
+	QueryExistsRegla           = `SELECT COUNT(*) FROM regla_cobertura WHERE id = ? AND id_obra_social = ?`
+)
+
+// consultas son las queries de cada función en el dialecto del motor: MySQL y SQLite usan las de arriba y
+// PostgreSQL las de postgres.go
+type consultas struct {
+	insert                  string
+	getAll                  string
+	delete                  string
+	getById                 string
+	update                  string
+	exists                  string
+	insertCobertura         string
+	getCoberturasByPaciente string
+	deleteCobertura         string
+	existsCobertura         string
+	insertRegla             string
+	getReglasByObraSocial   string
+	getRegla                string
+	deleteRegla             string
+	existsRegla             string
+	// las altas devuelven el ID con RETURNING id, porque el motor no tiene LastInsertId
+	returning bool
+}
+
+var consultasMySQL = consultas{
+	insert:                  QueryInsert,
+	getAll:                  QueryGetAll,
+	delete:                  QueryDelete,
+	getById:                 QueryGetById,
+	update:                  QueryUpdate,
+	exists:                  QueryExists,
+	insertCobertura:         QueryInsertCobertura,
+	getCoberturasByPaciente: QueryGetCoberturasByPaciente,
+	deleteCobertura:         QueryDeleteCobertura,
+	existsCobertura:         QueryExistsCobertura,
+	insertRegla:             QueryInsertRegla,
+	getReglasByObraSocial:   QueryGetReglasByObraSocial,
+	getRegla:                QueryGetRegla,
+	deleteRegla:             QueryDeleteRegla,
+	existsRegla:             QueryExistsRegla,
+}
+
+// defino la interfaz para que se apliquen siempre todos los métodos
+type Repository interface {
+	GetObraSocialByID(ctx context.Context, id int) (ObraSocial, error)
+	GetAll(ctx context.Context) ([]ObraSocial, error)
+	CreateObraSocial(ctx context.Context, o ObraSocial) (ObraSocial, error)
+	UpdateObraSocial(ctx context.Context, o ObraSocial) (ObraSocial, error)
+	DeleteObraSocial(ctx context.Context, id int, version int) error
+
+	GetCoberturasByPaciente(ctx context.Context, idPaciente int) ([]Cobertura, error)
+	CreateCobertura(ctx context.Context, c Cobertura) (Cobertura, error)
+	DeleteCobertura(ctx context.Context, idPaciente int, id int, version int) error
+
+	GetReglasByObraSocial(ctx context.Context, idObraSocial int) ([]ReglaCobertura, error)
+	GetRegla(ctx context.Context, idObraSocial int, plan string, codigoPrestacion string) (ReglaCobertura, error)
+	CreateRegla(ctx context.Context, r ReglaCobertura) (ReglaCobertura, error)
+	DeleteRegla(ctx context.Context, idObraSocial int, id int, version int) error
+}
+
+// estructura repositorio con base de datos mysql
+type repository struct {
+	db *sql.DB
+	q  consultas
+}
+
+// NewRepositoryMySql instancia repositorio
+func NewRepositoryMySql(db *sql.DB) Repository {
+	return &repository{
+		db: db,
+		q:  consultasMySQL,
+	}
+}
+
+// NewRepositorySqlite instancia repositorio sobre sqlite, con las mismas consultas
+func NewRepositorySqlite(db *sql.DB) Repository {
+	return &repository{
+		db: db,
+		q:  consultasMySQL,
+	}
+}
+
+// obtener todas las obras sociales:
+func (r *repository) GetAll(ctx context.Context) ([]ObraSocial, error) {
+	// ejecuto la query que trae todos los datos
+	rows, err := r.db.QueryContext(ctx, r.q.getAll)
+
+	// si hay error de query, lo devuelvo
+	if err != nil {
+		return []ObraSocial{}, ErrEmptyList
+	}
+	defer rows.Close()
+
+	// voy poblando el listado de obras sociales
+	var obrasSociales []ObraSocial
+	for rows.Next() {
+		var obraSocial ObraSocial
+		err := rows.Scan(
+			&obraSocial.ID,
+			&obraSocial.Nombre,
+			&obraSocial.Sigla,
+			&obraSocial.CUIT,
+			&obraSocial.Tipo,
+			&obraSocial.Version,
+		)
+		if err != nil {
+			return []ObraSocial{}, ErrExec
+		}
+		obrasSociales = append(obrasSociales, obraSocial)
+	}
+
+	// verifico haber cargado bien todos los registros
+	if err := rows.Err(); err != nil {
+		return []ObraSocial{}, ErrExec
+	}
+
+	return obrasSociales, nil
+}
+
+// obtener obra social por ID
+func (r *repository) GetObraSocialByID(ctx context.Context, id int) (ObraSocial, error) {
+	// ejecuto la query de búsqueda por ID
+	row := r.db.QueryRowContext(ctx, r.q.getById, id)
+
+	var obraSocial ObraSocial
+	err := row.Scan(
+		&obraSocial.ID,
+		&obraSocial.Nombre,
+		&obraSocial.Sigla,
+		&obraSocial.CUIT,
+		&obraSocial.Tipo,
+		&obraSocial.Version,
+	)
+
+	// devuelvo el error o la obra social
+	if err != nil {
+		return ObraSocial{}, ErrNotFound
+	}
+	return obraSocial, nil
+}
+
+// crear obra social en BD
+func (r *repository) CreateObraSocial(ctx context.Context, obraSocial ObraSocial) (ObraSocial, error) {
+	// ejecuto la query
+	statement, err := r.db.PrepareContext(ctx, r.q.insert)
+	if err != nil {
+		return ObraSocial{}, ErrStatement
+	}
+	defer statement.Close()
+
+	// paso los parámetros para que se ejecute la query
+	result, err := basedatos.InsertarPreparado(
+		ctx,
+		statement,
+		r.q.returning,
+		obraSocial.Nombre,
+		obraSocial.Sigla,
+		obraSocial.CUIT,
+		obraSocial.Tipo,
+	)
+	if err != nil {
+		return ObraSocial{}, ErrExec
+	}
+
+	// obtengo el ID del registro y lo devuelvo como dato
+	lastId, err := result.LastInsertId()
+	if err != nil {
+		return ObraSocial{}, ErrLastId
+	}
+	obraSocial.ID = int(lastId)
+	obraSocial.Version = 1
+	return obraSocial, nil
+}
+
+// actualizar un registro
+func (r *repository) UpdateObraSocial(ctx context.Context, obraSocial ObraSocial) (ObraSocial, error) {
+	// preparo query para actualizar campos
+	statement, err := r.db.PrepareContext(ctx, r.q.update)
+	if err != nil {
+		return ObraSocial{}, ErrStatement
+	}
+	defer statement.Close()
+
+	result, err := statement.ExecContext(
+		ctx,
+		obraSocial.Nombre,
+		obraSocial.Sigla,
+		obraSocial.CUIT,
+		obraSocial.Tipo,
+		obraSocial.ID,
+		obraSocial.Version,
+	)
+	if err != nil {
+		return ObraSocial{}, ErrStatement
+	}
+
+	// verifico filas afectadas
+	if err := r.verificarVersion(ctx, result, ErrNotFound, ErrVersion, r.q.exists, obraSocial.ID); err != nil {
+		return ObraSocial{}, err
+	}
+	obraSocial.Version++
+
+	return obraSocial, nil
+}
+
+// eliminar registro
+func (r *repository) DeleteObraSocial(ctx context.Context, id int, version int) error {
+	result, err := r.db.ExecContext(ctx, r.q.delete, id, version)
+	if err != nil {
+		return ErrStatement
+	}
+
+	// verifico filas afectadas
+	return r.verificarVersion(ctx, result, ErrNotFound, ErrVersion, r.q.exists, id)
+}
+
+// verificarVersion confirma que la modificación o la baja se aplicó. Si no afectó filas, la consulta de existencia
+// distingue un registro inexistente (noExiste) de uno que otro usuario modificó antes (cambio).
+func (r *repository) verificarVersion(ctx context.Context, result sql.Result, noExiste, cambio error, queryExists string, args ...interface{}) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return ErrExec
+	}
+	if rowsAffected > 0 {
+		return nil
+	}
+	var cantidad int
+	if err := r.db.QueryRowContext(ctx, queryExists, args...).Scan(&cantidad); err != nil {
+		return ErrExec
+	}
+	if cantidad < 1 {
+		return noExiste
+	}
+	return cambio
+}
+
+// obtener las coberturas de un paciente
+func (r *repository) GetCoberturasByPaciente(ctx context.Context, idPaciente int) ([]Cobertura, error) {
+	rows, err := r.db.QueryContext(ctx, r.q.getCoberturasByPaciente, idPaciente)
+	if err != nil {
+		return []Cobertura{}, ErrEmptyList
+	}
+	defer rows.Close()
+
+	var coberturas []Cobertura
+	for rows.Next() {
+		var cobertura Cobertura
+		// vigencia_hasta admite NULL (cobertura sin vencimiento)
+		var hasta sql.NullTime
+		err := rows.Scan(
+			&cobertura.ID,
+			&cobertura.IdPaciente,
+			&cobertura.IdObraSocial,
+			&cobertura.Plan,
+			&cobertura.NumeroAfiliado,
+			&cobertura.VigenciaDesde,
+			&hasta,
+			&cobertura.Version,
+		)
+		if err != nil {
+			return []Cobertura{}, ErrExec
+		}
+		if hasta.Valid {
+			cobertura.VigenciaHasta = hasta.Time
+		}
+		coberturas = append(coberturas, cobertura)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []Cobertura{}, ErrExec
+	}
+
+	return coberturas, nil
+}
+
+// crear cobertura de un paciente
+func (r *repository) CreateCobertura(ctx context.Context, cobertura Cobertura) (Cobertura, error) {
+	statement, err := r.db.PrepareContext(ctx, r.q.insertCobertura)
+	if err != nil {
+		return Cobertura{}, ErrStatement
+	}
+	defer statement.Close()
+
+	result, err := basedatos.InsertarPreparado(
+		ctx,
+		statement,
+		r.q.returning,
+		cobertura.IdPaciente,
+		cobertura.IdObraSocial,
+		cobertura.Plan,
+		cobertura.NumeroAfiliado,
+		cobertura.VigenciaDesde,
+		nullTime(cobertura.VigenciaHasta),
+	)
+	if err != nil {
+		return Cobertura{}, ErrExec
+	}
+
+	lastId, err := result.LastInsertId()
+	if err != nil {
+		return Cobertura{}, ErrLastId
+	}
+	cobertura.ID = int(lastId)
+	cobertura.Version = 1
+	return cobertura, nil
+}
+
+// eliminar cobertura de un paciente
+func (r *repository) DeleteCobertura(ctx context.Context, idPaciente int, id int, version int) error {
+	result, err := r.db.ExecContext(ctx, r.q.deleteCobertura, id, idPaciente, version)
+	if err != nil {
+		return ErrStatement
+	}
+
+	// verifico filas afectadas
+	return r.verificarVersion(ctx, result, ErrCoberturaNotFound, ErrVersionCobertura, r.q.existsCobertura, id, idPaciente)
+}
+
+// obtener las reglas de cobertura de una obra social
+func (r *repository) GetReglasByObraSocial(ctx context.Context, idObraSocial int) ([]ReglaCobertura, error) {
+	rows, err := r.db.QueryContext(ctx, r.q.getReglasByObraSocial, idObraSocial)
+	if err != nil {
+		return []ReglaCobertura{}, ErrEmptyList
+	}
+	defer rows.Close()
+
+	var reglas []ReglaCobertura
+	for rows.Next() {
+		var regla ReglaCobertura
+		err := rows.Scan(
+			&regla.ID,
+			&regla.IdObraSocial,
+			&regla.Plan,
+			&regla.CodigoPrestacion,
+			&regla.PorcentajeCubierto,
+			&regla.Copago,
+			&regla.RequiereAutorizacion,
+			&regla.Version,
+		)
+		if err != nil {
+			return []ReglaCobertura{}, ErrExec
+		}
+		reglas = append(reglas, regla)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []ReglaCobertura{}, ErrExec
+	}
+
+	return reglas, nil
+}
+
+// obtener la regla exacta para obra social, plan y prestación
+func (r *repository) GetRegla(ctx context.Context, idObraSocial int, plan string, codigoPrestacion string) (ReglaCobertura, error) {
+	row := r.db.QueryRowContext(ctx, r.q.getRegla, idObraSocial, plan, codigoPrestacion)
+
+	var regla ReglaCobertura
+	err := row.Scan(
+		&regla.ID,
+		&regla.IdObraSocial,
+		&regla.Plan,
+		&regla.CodigoPrestacion,
+		&regla.PorcentajeCubierto,
+		&regla.Copago,
+		&regla.RequiereAutorizacion,
+		&regla.Version,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ReglaCobertura{}, ErrReglaNotFound
+	}
+	if err != nil {
+		return ReglaCobertura{}, ErrExec
+	}
+	return regla, nil
+}
+
+// crear regla de cobertura
+func (r *repository) CreateRegla(ctx context.Context, regla ReglaCobertura) (ReglaCobertura, error) {
+	statement, err := r.db.PrepareContext(ctx, r.q.insertRegla)
+	if err != nil {
+		return ReglaCobertura{}, ErrStatement
+	}
+	defer statement.Close()
+
+	result, err := basedatos.InsertarPreparado(
+		ctx,
+		statement,
+		r.q.returning,
+		regla.IdObraSocial,
+		regla.Plan,
+		regla.CodigoPrestacion,
+		regla.PorcentajeCubierto,
+		regla.Copago,
+		regla.RequiereAutorizacion,
+	)
+	if err != nil {
+		return ReglaCobertura{}, ErrExec
+	}
+
+	lastId, err := result.LastInsertId()
+	if err != nil {
+		return ReglaCobertura{}, ErrLastId
+	}
+	regla.ID = int(lastId)
+	regla.Version = 1
+	return regla, nil
+}
+
+// eliminar regla de cobertura
+func (r *repository) DeleteRegla(ctx context.Context, idObraSocial int, id int, version int) error {
+	result, err := r.db.ExecContext(ctx, r.q.deleteRegla, id, idObraSocial, version)
+	if err != nil {
+		return ErrStatement
+	}
+
+	// verifico filas afectadas
+	return r.verificarVersion(ctx, result, ErrReglaNotFound, ErrVersionRegla, r.q.existsRegla, id, idObraSocial)
+}
+
+// nullTime guarda NULL cuando la fecha no fue informada
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
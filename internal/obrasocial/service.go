@@ -0,0 +1,252 @@
+package obrasocial
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// defino la interfaz para que se apliquen siempre todos los métodos
+type Service interface {
+	GetObraSocialByID(ctx context.Context, id int) (ObraSocial, error)
+	GetAll(ctx context.Context) ([]ObraSocial, error)
+	CreateObraSocial(ctx context.Context, o ObraSocialRequest) (ObraSocial, error)
+	UpdateObraSocial(ctx context.Context, o ObraSocialRequest, id int, version int) (ObraSocial, error)
+	DeleteObraSocial(ctx context.Context, id int, version int) error
+
+	GetCoberturasByPaciente(ctx context.Context, idPaciente int) ([]Cobertura, error)
+	CreateCobertura(ctx context.Context, c CoberturaRequest, idPaciente int) (Cobertura, error)
+	DeleteCobertura(ctx context.Context, idPaciente int, id int, version int) error
+
+	GetReglasByObraSocial(ctx context.Context, idObraSocial int) ([]ReglaCobertura, error)
+	CreateRegla(ctx context.Context, r ReglaCoberturaRequest, idObraSocial int) (ReglaCobertura, error)
+	DeleteRegla(ctx context.Context, idObraSocial int, id int, version int) error
+
+	GetCoberturaPrestacion(ctx context.Context, idPaciente int, codigoPrestacion string, fecha time.Time) (CoberturaPrestacion, error)
+}
+
+// estrucutra service que contará con un repositorio
+type service struct {
+	r Repository
+}
+
+// función para instanciar service
+func NewService(r Repository) Service {
+	return &service{r}
+}
+
+func (s *service) GetAll(ctx context.Context) ([]ObraSocial, error) {
+	obrasSociales, err := s.r.GetAll(ctx)
+	if err != nil {
+		log.Println("log de error en service de obras sociales", err.Error())
+		return []ObraSocial{}, ErrEmptyList
+	}
+	return obrasSociales, nil
+}
+
+func (s *service) GetObraSocialByID(ctx context.Context, id int) (ObraSocial, error) {
+	o, err := s.r.GetObraSocialByID(ctx, id)
+	if err != nil {
+		log.Println("log de error por obra social inexistente", err.Error())
+		return ObraSocial{}, ErrNotFound
+	}
+	return o, nil
+}
+
+func (s *service) CreateObraSocial(ctx context.Context, obraSocialRequest ObraSocialRequest) (ObraSocial, error) {
+	obraSocial := requestToObraSocial(obraSocialRequest)
+	response, err := s.r.CreateObraSocial(ctx, obraSocial)
+	if err != nil {
+		log.Println("error al crear obra social")
+		return ObraSocial{}, ErrExec
+	}
+	return response, nil
+}
+
+func (s *service) UpdateObraSocial(ctx context.Context, obraSocialRequest ObraSocialRequest, id int, version int) (ObraSocial, error) {
+	obraSocial := requestToObraSocial(obraSocialRequest)
+	obraSocial.ID = id
+	obraSocial.Version = version
+	response, err := s.r.UpdateObraSocial(ctx, obraSocial)
+	if err != nil {
+		log.Println("error al actualizar obra social")
+		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrVersion) {
+			return ObraSocial{}, err
+		}
+		return ObraSocial{}, ErrExec
+	}
+	return response, nil
+}
+
+func (s *service) DeleteObraSocial(ctx context.Context, id int, version int) error {
+	err := s.r.DeleteObraSocial(ctx, id, version)
+	if err != nil {
+		log.Println("log de error borrado de obra social", err.Error())
+		if errors.Is(err, ErrVersion) {
+			return ErrVersion
+		}
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *service) GetCoberturasByPaciente(ctx context.Context, idPaciente int) ([]Cobertura, error) {
+	coberturas, err := s.r.GetCoberturasByPaciente(ctx, idPaciente)
+	if err != nil {
+		log.Println("log de error en coberturas del paciente", err.Error())
+		return []Cobertura{}, ErrEmptyList
+	}
+	return coberturas, nil
+}
+
+func (s *service) CreateCobertura(ctx context.Context, coberturaRequest CoberturaRequest, idPaciente int) (Cobertura, error) {
+	// la obra social tiene que existir antes de afiliar al paciente
+	if _, err := s.r.GetObraSocialByID(ctx, coberturaRequest.IdObraSocial); err != nil {
+		log.Println("log de error por obra social inexistente", err.Error())
+		return Cobertura{}, ErrNotFound
+	}
+
+	cobertura := Cobertura{
+		IdPaciente:     idPaciente,
+		IdObraSocial:   coberturaRequest.IdObraSocial,
+		Plan:           coberturaRequest.Plan,
+		NumeroAfiliado: coberturaRequest.NumeroAfiliado,
+		VigenciaDesde:  coberturaRequest.VigenciaDesde,
+		VigenciaHasta:  coberturaRequest.VigenciaHasta,
+	}
+
+	// el paciente tiene una sola cobertura por fecha: así nunca hay que elegir cuál aplica
+	coberturas, err := s.r.GetCoberturasByPaciente(ctx, idPaciente)
+	if err != nil {
+		log.Println("log de error en coberturas del paciente", err.Error())
+		return Cobertura{}, ErrExec
+	}
+	for _, otra := range coberturas {
+		if cobertura.SeSuperponeCon(otra) {
+			return Cobertura{}, ErrSuperpuesta
+		}
+	}
+
+	response, err := s.r.CreateCobertura(ctx, cobertura)
+	if err != nil {
+		log.Println("error al crear cobertura")
+		return Cobertura{}, ErrExec
+	}
+	return response, nil
+}
+
+func (s *service) DeleteCobertura(ctx context.Context, idPaciente int, id int, version int) error {
+	err := s.r.DeleteCobertura(ctx, idPaciente, id, version)
+	if err != nil {
+		log.Println("log de error borrado de cobertura", err.Error())
+		if errors.Is(err, ErrVersionCobertura) {
+			return ErrVersionCobertura
+		}
+		return ErrCoberturaNotFound
+	}
+	return nil
+}
+
+func (s *service) GetReglasByObraSocial(ctx context.Context, idObraSocial int) ([]ReglaCobertura, error) {
+	reglas, err := s.r.GetReglasByObraSocial(ctx, idObraSocial)
+	if err != nil {
+		log.Println("log de error en reglas de cobertura", err.Error())
+		return []ReglaCobertura{}, ErrEmptyList
+	}
+	return reglas, nil
+}
+
+func (s *service) CreateRegla(ctx context.Context, reglaRequest ReglaCoberturaRequest, idObraSocial int) (ReglaCobertura, error) {
+	if _, err := s.r.GetObraSocialByID(ctx, idObraSocial); err != nil {
+		log.Println("log de error por obra social inexistente", err.Error())
+		return ReglaCobertura{}, ErrNotFound
+	}
+
+	regla := ReglaCobertura{
+		IdObraSocial:         idObraSocial,
+		Plan:                 reglaRequest.Plan,
+		CodigoPrestacion:     reglaRequest.CodigoPrestacion,
+		PorcentajeCubierto:   reglaRequest.PorcentajeCubierto,
+		Copago:               reglaRequest.Copago,
+		RequiereAutorizacion: reglaRequest.RequiereAutorizacion,
+	}
+	response, err := s.r.CreateRegla(ctx, regla)
+	if err != nil {
+		log.Println("error al crear regla de cobertura")
+		return ReglaCobertura{}, ErrExec
+	}
+	return response, nil
+}
+
+func (s *service) DeleteRegla(ctx context.Context, idObraSocial int, id int, version int) error {
+	err := s.r.DeleteRegla(ctx, idObraSocial, id, version)
+	if err != nil {
+		log.Println("log de error borrado de regla de cobertura", err.Error())
+		if errors.Is(err, ErrVersionRegla) {
+			return ErrVersionRegla
+		}
+		return ErrReglaNotFound
+	}
+	return nil
+}
+
+// GetCoberturaPrestacion busca la cobertura vigente del paciente a la fecha y la regla que aplica a la prestación.
+// CreateCobertura no deja superponer vigencias; si igual hubiera dos (datos cargados antes), gana la más nueva.
+// Primero se busca una regla específica del plan y, si no existe, la regla general de la obra social.
+// Si el paciente tiene cobertura pero la prestación no está contemplada, se devuelve Cubierto en false sin error. Un
+// error de la base no es "sin cobertura": vuelve ErrExec para que nadie facture como si no la tuviera.
+func (s *service) GetCoberturaPrestacion(ctx context.Context, idPaciente int, codigoPrestacion string, fecha time.Time) (CoberturaPrestacion, error) {
+	coberturas, err := s.r.GetCoberturasByPaciente(ctx, idPaciente)
+	if err != nil {
+		log.Println("log de error en coberturas del paciente", err.Error())
+		return CoberturaPrestacion{}, ErrExec
+	}
+
+	// vienen ordenadas por ID, así que recorro desde la última cargada
+	var vigente *Cobertura
+	for i := len(coberturas) - 1; i >= 0; i-- {
+		if coberturas[i].VigenteEn(fecha) {
+			vigente = &coberturas[i]
+			break
+		}
+	}
+	if vigente == nil {
+		return CoberturaPrestacion{}, ErrSinCobertura
+	}
+
+	resultado := CoberturaPrestacion{
+		IdObraSocial:     vigente.IdObraSocial,
+		Plan:             vigente.Plan,
+		NumeroAfiliado:   vigente.NumeroAfiliado,
+		CodigoPrestacion: codigoPrestacion,
+	}
+
+	regla, err := s.r.GetRegla(ctx, vigente.IdObraSocial, vigente.Plan, codigoPrestacion)
+	if errors.Is(err, ErrReglaNotFound) && vigente.Plan != "" {
+		regla, err = s.r.GetRegla(ctx, vigente.IdObraSocial, "", codigoPrestacion)
+	}
+	if errors.Is(err, ErrReglaNotFound) {
+		return resultado, nil
+	}
+	if err != nil {
+		log.Println("log de error en regla de cobertura", err.Error())
+		return CoberturaPrestacion{}, ErrExec
+	}
+
+	resultado.Cubierto = true
+	resultado.PorcentajeCubierto = regla.PorcentajeCubierto
+	resultado.Copago = regla.Copago
+	resultado.RequiereAutorizacion = regla.RequiereAutorizacion
+	return resultado, nil
+}
+
+// función para transformar request en la estructura definida en GO
+func requestToObraSocial(obraSocialRequest ObraSocialRequest) ObraSocial {
+	var obraSocial ObraSocial
+	obraSocial.Nombre = obraSocialRequest.Nombre
+	obraSocial.Sigla = obraSocialRequest.Sigla
+	obraSocial.CUIT = obraSocialRequest.CUIT
+	obraSocial.Tipo = obraSocialRequest.Tipo
+	return obraSocial
+}
@@ -0,0 +1,269 @@
+package odontologo
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// catálogo inicial de especialidades, el mismo que carga la primera migración
+var especialidadesIniciales = []Especialidad{
+	{ID: 1, Codigo: "general", Nombre: "Odontología general"},
+	{ID: 2, Codigo: "ortodoncia", Nombre: "Ortodoncia"},
+	{ID: 3, Codigo: "endodoncia", Nombre: "Endodoncia"},
+	{ID: 4, Codigo: "periodoncia", Nombre: "Periodoncia"},
+	{ID: 5, Codigo: "odontopediatria", Nombre: "Odontopediatría"},
+	{ID: 6, Codigo: "cirugia", Nombre: "Cirugía bucomaxilofacial"},
+	{ID: 7, Codigo: "implantes", Nombre: "Implantología"},
+	{ID: 8, Codigo: "protesis", Nombre: "Prótesis"},
+}
+
+// estructura repositorio en memoria, para pruebas y demos. Se comporta como el de MySQL (IDs correlativos, los mismos
+// errores, baja lógica y orden de los listados) pero los datos se pierden al cerrar la aplicación.
+type repositoryMemoria struct {
+	mu             sync.RWMutex
+	ultimoID       int
+	odontologos    map[int]Odontologo
+	especialidades map[int][]int
+}
+
+// NewRepositoryMemoria instancia repositorio en memoria, vacío y con el catálogo de especialidades inicial
+func NewRepositoryMemoria() Repository {
+	return &repositoryMemoria{
+		odontologos:    map[int]Odontologo{},
+		especialidades: map[int][]int{},
+	}
+}
+
+func (r *repositoryMemoria) GetAll(ctx context.Context) ([]Odontologo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// como el de MySQL, sin odontólogos devuelve nil
+	var odontologos []Odontologo
+	for _, o := range r.ordenados() {
+		if o.DeletedAt == nil {
+			odontologos = append(odontologos, r.completo(o))
+		}
+	}
+	return odontologos, nil
+}
+
+func (r *repositoryMemoria) GetOdontologoByID(ctx context.Context, id int) (Odontologo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	o, ok := r.odontologos[id]
+	if !ok || o.DeletedAt != nil {
+		return Odontologo{}, ErrNotFound
+	}
+	return r.completo(o), nil
+}
+
+func (r *repositoryMemoria) GetOdontologoIdByMatricula(ctx context.Context, matricula string) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, o := range r.ordenados() {
+		if o.Matricula == matricula && o.DeletedAt == nil {
+			return o.ID, nil
+		}
+	}
+	return 0, ErrNotFound
+}
+
+func (r *repositoryMemoria) CreateOdontologo(ctx context.Context, o Odontologo) (Odontologo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// valido las especialidades antes de guardar nada, como el rollback de la transacción
+	ids, err := idsEspecialidades(o.Especialidades)
+	if err != nil {
+		return Odontologo{}, err
+	}
+
+	r.ultimoID++
+	o.ID = r.ultimoID
+	o.Version = 1
+	r.odontologos[o.ID] = sinRelaciones(o)
+	r.especialidades[o.ID] = ids
+	return o, nil
+}
+
+func (r *repositoryMemoria) UpdateOdontologo(ctx context.Context, o Odontologo) (Odontologo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	actual, ok := r.odontologos[o.ID]
+	if !ok || actual.DeletedAt != nil {
+		return Odontologo{}, ErrNotFound
+	}
+	if actual.Version != o.Version {
+		return Odontologo{}, ErrVersion
+	}
+	var ids []int
+	if o.Especialidades != nil {
+		var err error
+		if ids, err = idsEspecialidades(o.Especialidades); err != nil {
+			return Odontologo{}, err
+		}
+	}
+
+	actual.Apellido = o.Apellido
+	actual.Nombre = o.Nombre
+	actual.Matricula = o.Matricula
+	actual.Version++
+	o.Version = actual.Version
+	r.odontologos[o.ID] = actual
+	if o.Especialidades != nil {
+		r.especialidades[o.ID] = ids
+	}
+	return o, nil
+}
+
+func (r *repositoryMemoria) DeleteOdontologo(ctx context.Context, id int, version int, fecha time.Time, idUsuario int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	o, ok := r.odontologos[id]
+	if !ok || o.DeletedAt != nil {
+		return ErrNotFound
+	}
+	if o.Version != version {
+		return ErrVersion
+	}
+	// DATETIME guarda segundos
+	deletedAt := fecha.UTC().Round(time.Second)
+	o.DeletedAt = &deletedAt
+	o.DeletedBy = idUsuario
+	o.Version++
+	r.odontologos[id] = o
+	return nil
+}
+
+func (r *repositoryMemoria) GetEliminados(ctx context.Context) ([]Odontologo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	odontologos := []Odontologo{}
+	for _, o := range r.ordenados() {
+		if o.DeletedAt != nil {
+			odontologos = append(odontologos, r.completo(o))
+		}
+	}
+	// del más reciente al más viejo; a igual fecha quedan por ID
+	sort.SliceStable(odontologos, func(i, j int) bool {
+		return odontologos[i].DeletedAt.After(*odontologos[j].DeletedAt)
+	})
+	return odontologos, nil
+}
+
+func (r *repositoryMemoria) GetEliminadoByID(ctx context.Context, id int) (Odontologo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	o, ok := r.odontologos[id]
+	if !ok || o.DeletedAt == nil {
+		return Odontologo{}, ErrNotFound
+	}
+	return r.completo(o), nil
+}
+
+func (r *repositoryMemoria) RestaurarOdontologo(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	o, ok := r.odontologos[id]
+	if !ok || o.DeletedAt == nil {
+		return ErrNotFound
+	}
+	o.DeletedAt = nil
+	o.DeletedBy = 0
+	o.Version++
+	r.odontologos[id] = o
+	return nil
+}
+
+func (r *repositoryMemoria) GetEspecialidades(ctx context.Context) ([]Especialidad, error) {
+	especialidades := append([]Especialidad{}, especialidadesIniciales...)
+	ordenarEspecialidades(especialidades)
+	return especialidades, nil
+}
+
+func (r *repositoryMemoria) GetOdontologosByEspecialidad(ctx context.Context, codigo string) ([]Odontologo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	odontologos := []Odontologo{}
+	for _, o := range r.ordenados() {
+		if o.DeletedAt != nil {
+			continue
+		}
+		for _, e := range r.completo(o).Especialidades {
+			if e.Codigo == codigo {
+				odontologos = append(odontologos, r.completo(o))
+				break
+			}
+		}
+	}
+	return odontologos, nil
+}
+
+// ordenados devuelve los odontólogos guardados por ID, que es el orden en que los devuelve MySQL
+func (r *repositoryMemoria) ordenados() []Odontologo {
+	odontologos := make([]Odontologo, 0, len(r.odontologos))
+	for _, o := range r.odontologos {
+		odontologos = append(odontologos, o)
+	}
+	sort.Slice(odontologos, func(i, j int) bool { return odontologos[i].ID < odontologos[j].ID })
+	return odontologos
+}
+
+// completo le agrega al odontólogo guardado sus especialidades del catálogo, ordenadas por nombre
+func (r *repositoryMemoria) completo(o Odontologo) Odontologo {
+	o.Especialidades = []Especialidad{}
+	for _, id := range r.especialidades[o.ID] {
+		o.Especialidades = append(o.Especialidades, especialidadesIniciales[id-1])
+	}
+	ordenarEspecialidades(o.Especialidades)
+	if o.DeletedAt != nil {
+		deletedAt := *o.DeletedAt
+		o.DeletedAt = &deletedAt
+	}
+	return o
+}
+
+// sinRelaciones es lo que se guarda del odontólogo: las especialidades van aparte, como en su tabla
+func sinRelaciones(o Odontologo) Odontologo {
+	o.Especialidades = nil
+	return o
+}
+
+// idsEspecialidades busca cada código en el catálogo. Un código inexistente da ErrEspecialidad y uno repetido ErrExec,
+// como la clave primaria de odontologo_especialidad.
+func idsEspecialidades(especialidades []Especialidad) ([]int, error) {
+	ids := []int{}
+	vistos := map[int]bool{}
+	for _, e := range especialidades {
+		id := 0
+		for _, c := range especialidadesIniciales {
+			if c.Codigo == e.Codigo {
+				id = c.ID
+			}
+		}
+		if id == 0 {
+			return nil, ErrEspecialidad
+		}
+		if vistos[id] {
+			return nil, ErrExec
+		}
+		vistos[id] = true
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func ordenarEspecialidades(especialidades []Especialidad) {
+	sort.Slice(especialidades, func(i, j int) bool { return especialidades[i].Nombre < especialidades[j].Nombre })
+}
@@ -0,0 +1,14 @@
+package odontologo_test
+
+import (
+	"testing"
+
+	"finalgo/internal/contrato/contratotest"
+	"finalgo/internal/odontologo"
+)
+
+func TestRepositoryMemoria(t *testing.T) {
+	contratotest.Odontologos(t, func(t *testing.T) odontologo.Repository {
+		return odontologo.NewRepositoryMemoria()
+	})
+}
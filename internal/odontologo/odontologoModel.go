@@ -1,11 +1,19 @@
 package odontologo
 
+import "time"
+
 // creamos la estructura de la entidad Odontologo. El ".json" especifica que deben serializarse y deserializarse al formato JSON
 type Odontologo struct {
-	ID        int    `json:"id"`
-	Apellido  string `json:"apellido"`
-	Nombre    string `json:"nombre"`
-	Matricula string `json:"matricula"`
+	ID             int            `json:"id"`
+	Apellido       string         `json:"apellido"`
+	Nombre         string         `json:"nombre"`
+	Matricula      string         `json:"matricula"`
+	Especialidades []Especialidad `json:"especialidades"`
+	// baja lógica: sólo vienen en el listado de dados de baja
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	DeletedBy int        `json:"deleted_by,omitempty"`
+	// sube con cada modificación; viaja en el ETag y hay que mandarla en If-Match para modificar o dar de baja
+	Version int `json:"version"`
 }
 
 // creamos la misma estructura de Odontologo para las solicitudes por API o recibir datos de entrada.
@@ -14,4 +22,13 @@ type OdontologoRequest struct {
 	Apellido  string `json:"apellido"`
 	Nombre    string `json:"nombre"`
 	Matricula string `json:"matricula"`
+	// códigos de especialidad; si no viene (null) se conservan las que ya tenía
+	Especialidades []string `json:"especialidades"`
+}
+
+// Especialidad del catálogo (ortodoncia, endodoncia, etc.). Un odontólogo puede tener varias.
+type Especialidad struct {
+	ID     int    `json:"id"`
+	Codigo string `json:"codigo"`
+	Nombre string `json:"nombre"`
 }
@@ -0,0 +1,47 @@
+package odontologo
+
+import "database/sql"
+
+// Queries de postgres: parámetros $1, $2, ..., marcas BOOLEAN y las altas devuelven el ID con RETURNING id
+var (
+	QueryInsertPostgres                      = `INSERT INTO odontologo(apellido, nombre, matricula) VALUES($1, $2, $3) RETURNING id`
+	QueryDeletePostgres                      = `UPDATE odontologo SET deleted_at = $1, deleted_by = $2, version = version + 1 WHERE id = $3 AND version = $4 AND deleted_at IS NULL`
+	QueryGetByIdPostgres                     = `SELECT id, apellido,nombre,matricula,version FROM odontologo WHERE id = $1 AND deleted_at IS NULL`
+	QueryUpdatePostgres                      = `UPDATE odontologo SET apellido = $1,nombre = $2,matricula = $3,version = version + 1 WHERE id = $4 AND version = $5 AND deleted_at IS NULL`
+	QueryGetIdByMatriculaPostgres            = `SELECT id FROM odontologo WHERE matricula = $1 AND deleted_at IS NULL`
+	QueryExistsPostgres                      = `SELECT COUNT(*) FROM odontologo WHERE id = $1 AND deleted_at IS NULL`
+	QueryGetEliminadoPostgres                = `SELECT id, apellido, nombre, matricula, version, deleted_at, deleted_by FROM odontologo WHERE id = $1 AND deleted_at IS NOT NULL`
+	QueryRestaurarPostgres                   = `UPDATE odontologo SET deleted_at = NULL, deleted_by = NULL, version = version + 1 WHERE id = $1 AND deleted_at IS NOT NULL`
+	QueryGetEspecialidadesOdontologoPostgres = `SELECT oe.id_odontologo, e.id, e.codigo, e.nombre FROM odontologo_especialidad oe JOIN especialidad e ON e.id = oe.id_especialidad WHERE oe.id_odontologo = $1 ORDER BY e.nombre`
+	QueryGetByEspecialidadPostgres           = `SELECT o.id, o.apellido, o.nombre, o.matricula, o.version FROM odontologo o JOIN odontologo_especialidad oe ON oe.id_odontologo = o.id JOIN especialidad e ON e.id = oe.id_especialidad WHERE e.codigo = $1 AND o.deleted_at IS NULL ORDER BY o.id`
+	QueryDeleteEspecialidadesPostgres        = `DELETE FROM odontologo_especialidad WHERE id_odontologo = $1`
+	QueryInsertEspecialidadPostgres          = `INSERT INTO odontologo_especialidad(id_odontologo, id_especialidad) SELECT CAST($1 AS INTEGER), id FROM especialidad WHERE codigo = $2`
+)
+
+var consultasPostgres = consultas{
+	insert:                      QueryInsertPostgres,
+	getAll:                      QueryGetAll,
+	delete:                      QueryDeletePostgres,
+	getById:                     QueryGetByIdPostgres,
+	update:                      QueryUpdatePostgres,
+	getIdByMatricula:            QueryGetIdByMatriculaPostgres,
+	exists:                      QueryExistsPostgres,
+	getEliminados:               QueryGetEliminados,
+	getEliminado:                QueryGetEliminadoPostgres,
+	restaurar:                   QueryRestaurarPostgres,
+	getEspecialidades:           QueryGetEspecialidades,
+	getEspecialidadesOdontologo: QueryGetEspecialidadesOdontologoPostgres,
+	getAllEspecialidades:        QueryGetAllEspecialidades,
+	getByEspecialidad:           QueryGetByEspecialidadPostgres,
+	deleteEspecialidades:        QueryDeleteEspecialidadesPostgres,
+	insertEspecialidad:          QueryInsertEspecialidadPostgres,
+	returning:                   true,
+}
+
+// NewRepositoryPostgres instancia repositorio sobre postgres
+func NewRepositoryPostgres(db *sql.DB) Repository {
+	return &repository{
+		db: db,
+		q:  consultasPostgres,
+	}
+}
@@ -4,6 +4,10 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
+
+	"finalgo/pkg/basedatos"
+	"finalgo/pkg/transaccion"
 )
 
 // Errores
@@ -13,44 +17,124 @@ var (
 	ErrStatement = errors.New("sentencia incorrecta")
 	ErrExec      = errors.New("ejecución SQL incorrecta")
 	ErrLastId    = errors.New("error al obtener el último ID")
+
+	ErrEspecialidad = errors.New("especialidad inexistente")
+	ErrVersion      = errors.New("el odontólogo cambió desde que se leyó, hay que volver a consultarlo")
 )
 
-// Queries a usar en cada función
+// Queries a usar en cada función. La baja es lógica: se marca deleted_at y las consultas ignoran a los dados de baja.
+// Toda modificación sube la versión; las que pide un usuario sólo se aplican si la versión es la que él leyó.
 var (
-	QueryInsert           = `INSERT INTO my_db.odontologo(apellido,nombre,matricula) VALUES(?,?,?)`
-	QueryGetAll           = `SELECT id,apellido,nombre,matricula, alta FROM my_db.odontologo`
-	QueryDelete           = `DELETE FROM my_db.odontologo WHERE id = ?`
-	QueryGetById          = `SELECT id, apellido,nombre,matricula FROM my_db.odontologo WHERE id = ?`
-	QueryUpdate           = `UPDATE my_db.odontologo SET apellido = ?,nombre = ?,matricula = ? WHERE id = ?`
-	QueryGetIdByMatricula = `SELECT id FROM my_db.odontologo WHERE matricula = ?`
+	QueryInsert           = `INSERT INTO odontologo(apellido,nombre,matricula) VALUES(?,?,?)`
+	QueryGetAll           = `SELECT id,apellido,nombre,matricula,version FROM odontologo WHERE deleted_at IS NULL ORDER BY id`
+	QueryDelete           = `UPDATE odontologo SET deleted_at = ?, deleted_by = ?, version = version + 1 WHERE id = ? AND version = ? AND deleted_at IS NULL`
+	QueryGetById          = `SELECT id, apellido,nombre,matricula,version FROM odontologo WHERE id = ? AND deleted_at IS NULL`
+	QueryUpdate           = `UPDATE odontologo SET apellido = ?,nombre = ?,matricula = ?,version = version + 1 WHERE id = ? AND version = ? AND deleted_at IS NULL`
+	QueryGetIdByMatricula = `SELECT id FROM odontologo WHERE matricula = ? AND deleted_at IS NULL`
+	QueryExists           = `SELECT COUNT(*) FROM odontologo WHERE id = ? AND deleted_at IS NULL`
+
+	QueryGetEliminados = `SELECT id, apellido, nombre, matricula, version, deleted_at, deleted_by FROM odontologo WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC`
+	QueryGetEliminado  = `SELECT id, apellido, nombre, matricula, version, deleted_at, deleted_by FROM odontologo WHERE id = ? AND deleted_at IS NOT NULL`
+	QueryRestaurar     = `UPDATE odontologo SET deleted_at = NULL, deleted_by = NULL, version = version + 1 WHERE id = ? AND deleted_at IS NOT NULL`
+
+	// especialidades: catálogo y relación muchos a muchos con odontólogos
+	QueryGetEspecialidades           = `SELECT id, codigo, nombre FROM especialidad ORDER BY nombre`
+	QueryGetEspecialidadesOdontologo = `SELECT oe.id_odontologo, e.id, e.codigo, e.nombre FROM odontologo_especialidad oe JOIN especialidad e ON e.id = oe.id_especialidad WHERE oe.id_odontologo = ? ORDER BY e.nombre`
+	QueryGetAllEspecialidades        = `SELECT oe.id_odontologo, e.id, e.codigo, e.nombre FROM odontologo_especialidad oe JOIN especialidad e ON e.id = oe.id_especialidad ORDER BY oe.id_odontologo, e.nombre`
+	QueryGetByEspecialidad           = `SELECT o.id, o.apellido, o.nombre, o.matricula, o.version FROM odontologo o JOIN odontologo_especialidad oe ON oe.id_odontologo = o.id JOIN especialidad e ON e.id = oe.id_especialidad WHERE e.codigo = ? AND o.deleted_at IS NULL ORDER BY o.id`
+	QueryDeleteEspecialidades        = `DELETE FROM odontologo_especialidad WHERE id_odontologo = ?`
+	QueryInsertEspecialidad          = `INSERT INTO odontologo_especialidad(id_odontologo, id_especialidad) SELECT ?, id FROM especialidad WHERE codigo = ?`
 )
 
+// consultas son las queries de cada función en el dialecto del motor: MySQL y SQLite usan las de arriba y
+// PostgreSQL las de postgres.go
+type consultas struct {
+	insert                      string
+	getAll                      string
+	delete                      string
+	getById                     string
+	update                      string
+	getIdByMatricula            string
+	exists                      string
+	getEliminados               string
+	getEliminado                string
+	restaurar                   string
+	getEspecialidades           string
+	getEspecialidadesOdontologo string
+	getAllEspecialidades        string
+	getByEspecialidad           string
+	deleteEspecialidades        string
+	insertEspecialidad          string
+	// las altas devuelven el ID con RETURNING id, porque el motor no tiene LastInsertId
+	returning bool
+}
+
+var consultasMySQL = consultas{
+	insert:                      QueryInsert,
+	getAll:                      QueryGetAll,
+	delete:                      QueryDelete,
+	getById:                     QueryGetById,
+	update:                      QueryUpdate,
+	getIdByMatricula:            QueryGetIdByMatricula,
+	exists:                      QueryExists,
+	getEliminados:               QueryGetEliminados,
+	getEliminado:                QueryGetEliminado,
+	restaurar:                   QueryRestaurar,
+	getEspecialidades:           QueryGetEspecialidades,
+	getEspecialidadesOdontologo: QueryGetEspecialidadesOdontologo,
+	getAllEspecialidades:        QueryGetAllEspecialidades,
+	getByEspecialidad:           QueryGetByEspecialidad,
+	deleteEspecialidades:        QueryDeleteEspecialidades,
+	insertEspecialidad:          QueryInsertEspecialidad,
+}
+
 // defino la interfaz para que se apliquen siempre todos los métodos
 type Repository interface {
 	GetOdontologoByID(ctx context.Context, id int) (Odontologo, error)
 	CreateOdontologo(ctx context.Context, o Odontologo) (Odontologo, error)
 	UpdateOdontologo(ctx context.Context, o Odontologo) (Odontologo, error)
 	GetAll(ctx context.Context) ([]Odontologo, error)
-	DeleteOdontologo(ctx context.Context, id int) error
+	DeleteOdontologo(ctx context.Context, id int, version int, fecha time.Time, idUsuario int) error
 	GetOdontologoIdByMatricula(ctx context.Context, matricula string) (int, error)
+	GetEliminados(ctx context.Context) ([]Odontologo, error)
+	GetEliminadoByID(ctx context.Context, id int) (Odontologo, error)
+	RestaurarOdontologo(ctx context.Context, id int) error
+
+	GetEspecialidades(ctx context.Context) ([]Especialidad, error)
+	GetOdontologosByEspecialidad(ctx context.Context, codigo string) ([]Odontologo, error)
 }
 
 // estructura repositorio con base de datos mysql
 type repository struct {
 	db *sql.DB
+	q  consultas
 }
 
 // NewRepositoryMySql instancia repositorio
 func NewRepositoryMySql(db *sql.DB) Repository {
 	return &repository{
 		db: db,
+		q:  consultasMySQL,
 	}
 }
 
+// NewRepositorySqlite instancia repositorio sobre sqlite, con las mismas consultas
+func NewRepositorySqlite(db *sql.DB) Repository {
+	return &repository{
+		db: db,
+		q:  consultasMySQL,
+	}
+}
+
+// conexion devuelve la transacción de la unidad de trabajo en curso, si la hay, o la base
+func (r *repository) conexion(ctx context.Context) transaccion.Ejecutor {
+	return transaccion.Conexion(ctx, r.db)
+}
+
 // obtener todos los odontologos:
 func (r *repository) GetAll(ctx context.Context) ([]Odontologo, error) {
 	// ejecuto la query que trae todos los datos
-	rows, err := r.db.Query(QueryGetAll)
+	rows, err := r.conexion(ctx).QueryContext(ctx, r.q.getAll)
 
 	// si hay error de query, lo devuelvo
 	if err != nil {
@@ -68,6 +152,7 @@ func (r *repository) GetAll(ctx context.Context) ([]Odontologo, error) {
 			&odontologo.Apellido,
 			&odontologo.Nombre,
 			&odontologo.Matricula,
+			&odontologo.Version,
 		)
 		if err != nil {
 			return []Odontologo{}, ErrExec
@@ -80,6 +165,11 @@ func (r *repository) GetAll(ctx context.Context) ([]Odontologo, error) {
 		return []Odontologo{}, ErrExec
 	}
 
+	// traigo las especialidades de todos en una sola consulta
+	if err := r.cargarEspecialidades(ctx, odontologos); err != nil {
+		return []Odontologo{}, ErrExec
+	}
+
 	// devuelvo el resultado
 	return odontologos, nil
 }
@@ -87,7 +177,7 @@ func (r *repository) GetAll(ctx context.Context) ([]Odontologo, error) {
 // obtener Odontologo por ID
 func (r *repository) GetOdontologoByID(ctx context.Context, id int) (Odontologo, error) {
 	// ejecuto la query de búsqueda por ID
-	row := r.db.QueryRow(QueryGetById, id)
+	row := r.conexion(ctx).QueryRowContext(ctx, r.q.getById, id)
 
 	// creo la variable que guarde (muestre) el resultado
 	var odontologo Odontologo
@@ -98,19 +188,26 @@ func (r *repository) GetOdontologoByID(ctx context.Context, id int) (Odontologo,
 		&odontologo.Apellido,
 		&odontologo.Nombre,
 		&odontologo.Matricula,
+		&odontologo.Version,
 	)
 
 	// devuelvo el error o el odontologo
 	if err != nil {
 		return Odontologo{}, ErrNotFound
 	}
+
+	especialidades, err := r.getEspecialidades(ctx, r.q.getEspecialidadesOdontologo, id)
+	if err != nil {
+		return Odontologo{}, ErrExec
+	}
+	odontologo.Especialidades = conDefault(especialidades[id])
 	return odontologo, nil
 }
 
 // obtener Odontologo por ID
 func (r *repository) GetOdontologoIdByMatricula(ctx context.Context, dni string) (int, error) {
 	// ejecuto la query de búsqueda por ID
-	row := r.db.QueryRow(QueryGetIdByMatricula, dni)
+	row := r.conexion(ctx).QueryRowContext(ctx, r.q.getIdByMatricula, dni)
 
 	// creo la variable que guarde (muestre) el resultado
 	var odontologo Odontologo
@@ -127,21 +224,20 @@ func (r *repository) GetOdontologoIdByMatricula(ctx context.Context, dni string)
 	return odontologo.ID, nil
 }
 
-// crear Odontologo en BD
+// crear Odontologo en BD, junto con sus especialidades en la misma transacción
 func (r *repository) CreateOdontologo(ctx context.Context, o Odontologo) (Odontologo, error) {
-	// ejecuto la query
-	statement, err := r.db.Prepare(QueryInsert)
-
-	// verifico error de ejecución de query
+	tx, err := transaccion.Begin(ctx, r.db)
 	if err != nil {
 		return Odontologo{}, ErrStatement
 	}
-
-	defer statement.Close()
+	defer tx.Rollback()
 
 	// paso los parámetros para que se ejecute la query
-	result, err := statement.Exec(
-
+	result, err := basedatos.Insertar(
+		ctx,
+		tx,
+		r.q.returning,
+		r.q.insert,
 		o.Apellido,
 		o.Nombre,
 		o.Matricula,
@@ -158,26 +254,34 @@ func (r *repository) CreateOdontologo(ctx context.Context, o Odontologo) (Odonto
 		return Odontologo{}, ErrLastId
 	}
 	o.ID = int(lastId)
+	o.Version = 1
+
+	if err := r.guardarEspecialidades(ctx, tx, o); err != nil {
+		return Odontologo{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return Odontologo{}, ErrExec
+	}
 	return o, nil
 }
 
-// actualizar un registro
+// actualizar un registro. Las especialidades se reemplazan sólo si vienen informadas (Especialidades distinto de nil).
 func (r *repository) UpdateOdontologo(ctx context.Context, o Odontologo) (Odontologo, error) {
-	// preparo query para actualizar campos
-	statement, err := r.db.Prepare(QueryUpdate)
-
-	// por problemas de query, devuelve error
+	tx, err := transaccion.Begin(ctx, r.db)
 	if err != nil {
 		return Odontologo{}, ErrStatement
 	}
-	defer statement.Close()
+	defer tx.Rollback()
 
 	// paso los parámetros para que se ejecute la query
-	result, err := statement.Exec(
+	result, err := tx.ExecContext(
+		ctx,
+		r.q.update,
 		o.Apellido,
 		o.Nombre,
 		o.Matricula,
 		o.ID,
+		o.Version,
 	)
 
 	// verifico error de parámetros
@@ -185,22 +289,30 @@ func (r *repository) UpdateOdontologo(ctx context.Context, o Odontologo) (Odonto
 		return Odontologo{}, ErrStatement
 	}
 
-	// ejecuto query
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return Odontologo{}, ErrExec
-	}
-	if rowsAffected < 1 {
-		return Odontologo{}, ErrNotFound
+	// la versión cambia siempre, así que 0 filas afectadas es que no existe o que otro lo modificó antes
+	if err := r.verificarVersion(ctx, tx, result, o.ID); err != nil {
+		return Odontologo{}, err
 	}
+	o.Version++
 
+	if o.Especialidades != nil {
+		if _, err := tx.ExecContext(ctx, r.q.deleteEspecialidades, o.ID); err != nil {
+			return Odontologo{}, ErrExec
+		}
+		if err := r.guardarEspecialidades(ctx, tx, o); err != nil {
+			return Odontologo{}, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return Odontologo{}, ErrExec
+	}
 	return o, nil
 }
 
-// eliminar registro
-func (r *repository) DeleteOdontologo(ctx context.Context, id int) error {
+// dar de baja el registro: queda en la base marcado con la fecha y el usuario de la baja
+func (r *repository) DeleteOdontologo(ctx context.Context, id int, version int, fecha time.Time, idUsuario int) error {
 	// ejecuto query
-	result, err := r.db.Exec(QueryDelete, id)
+	result, err := r.conexion(ctx).ExecContext(ctx, r.q.delete, fecha, nullID(idUsuario), id, version)
 
 	// verifico error
 	if err != nil {
@@ -208,6 +320,85 @@ func (r *repository) DeleteOdontologo(ctx context.Context, id int) error {
 	}
 
 	// verifico filas afectadas
+	return r.verificarVersion(ctx, r.conexion(ctx), result, id)
+}
+
+// obtener los odontólogos dados de baja, del más reciente al más viejo
+func (r *repository) GetEliminados(ctx context.Context) ([]Odontologo, error) {
+	rows, err := r.conexion(ctx).QueryContext(ctx, r.q.getEliminados)
+	if err != nil {
+		return []Odontologo{}, ErrStatement
+	}
+	defer rows.Close()
+
+	odontologos := []Odontologo{}
+	for rows.Next() {
+		odontologo, err := scanEliminado(rows)
+		if err != nil {
+			return []Odontologo{}, ErrExec
+		}
+		odontologos = append(odontologos, odontologo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []Odontologo{}, ErrExec
+	}
+	if err := r.cargarEspecialidades(ctx, odontologos); err != nil {
+		return []Odontologo{}, ErrExec
+	}
+	return odontologos, nil
+}
+
+// obtener un odontólogo dado de baja por ID, sin recorrer todas las bajas
+func (r *repository) GetEliminadoByID(ctx context.Context, id int) (Odontologo, error) {
+	odontologo, err := scanEliminado(r.conexion(ctx).QueryRowContext(ctx, r.q.getEliminado, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Odontologo{}, ErrNotFound
+	}
+	if err != nil {
+		return Odontologo{}, ErrExec
+	}
+	odontologos := []Odontologo{odontologo}
+	if err := r.cargarEspecialidades(ctx, odontologos); err != nil {
+		return Odontologo{}, ErrExec
+	}
+	return odontologos[0], nil
+}
+
+// scanner lo cumplen tanto *sql.Row como *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanEliminado lee un odontólogo dado de baja, con la fecha y el usuario de la baja
+func scanEliminado(row scanner) (Odontologo, error) {
+	var odontologo Odontologo
+	var deletedAt sql.NullTime
+	var deletedBy sql.NullInt64
+	err := row.Scan(
+		&odontologo.ID,
+		&odontologo.Apellido,
+		&odontologo.Nombre,
+		&odontologo.Matricula,
+		&odontologo.Version,
+		&deletedAt,
+		&deletedBy,
+	)
+	if err != nil {
+		return Odontologo{}, err
+	}
+	odontologo.DeletedAt = &deletedAt.Time
+	odontologo.DeletedBy = int(deletedBy.Int64)
+	return odontologo, nil
+}
+
+// quitar la marca de baja del odontólogo
+func (r *repository) RestaurarOdontologo(ctx context.Context, id int) error {
+	result, err := r.conexion(ctx).ExecContext(ctx, r.q.restaurar, id)
+	if err != nil {
+		return ErrStatement
+	}
+
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return ErrExec
@@ -215,6 +406,140 @@ func (r *repository) DeleteOdontologo(ctx context.Context, id int) error {
 	if rowsAffected < 1 {
 		return ErrNotFound
 	}
+	return nil
+}
+
+// obtener el catálogo de especialidades
+func (r *repository) GetEspecialidades(ctx context.Context) ([]Especialidad, error) {
+	rows, err := r.conexion(ctx).QueryContext(ctx, r.q.getEspecialidades)
+	if err != nil {
+		return []Especialidad{}, ErrEmptyList
+	}
+	defer rows.Close()
+
+	especialidades := []Especialidad{}
+	for rows.Next() {
+		var e Especialidad
+		if err := rows.Scan(&e.ID, &e.Codigo, &e.Nombre); err != nil {
+			return []Especialidad{}, ErrExec
+		}
+		especialidades = append(especialidades, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []Especialidad{}, ErrExec
+	}
+	return especialidades, nil
+}
+
+// obtener los odontólogos que tienen la especialidad, ordenados por ID
+func (r *repository) GetOdontologosByEspecialidad(ctx context.Context, codigo string) ([]Odontologo, error) {
+	rows, err := r.conexion(ctx).QueryContext(ctx, r.q.getByEspecialidad, codigo)
+	if err != nil {
+		return []Odontologo{}, ErrEmptyList
+	}
+	defer rows.Close()
 
+	odontologos := []Odontologo{}
+	for rows.Next() {
+		var odontologo Odontologo
+		err := rows.Scan(
+			&odontologo.ID,
+			&odontologo.Apellido,
+			&odontologo.Nombre,
+			&odontologo.Matricula,
+			&odontologo.Version,
+		)
+		if err != nil {
+			return []Odontologo{}, ErrExec
+		}
+		odontologos = append(odontologos, odontologo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []Odontologo{}, ErrExec
+	}
+	if err := r.cargarEspecialidades(ctx, odontologos); err != nil {
+		return []Odontologo{}, ErrExec
+	}
+	return odontologos, nil
+}
+
+// verificarVersion confirma que una modificación con control de versión se aplicó. Si no afectó filas, distingue un
+// odontólogo inexistente (o dado de baja) de uno que otro usuario modificó antes.
+func (r *repository) verificarVersion(ctx context.Context, e transaccion.Ejecutor, result sql.Result, id int) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return ErrExec
+	}
+	if rowsAffected > 0 {
+		return nil
+	}
+	var cantidad int
+	if err := e.QueryRowContext(ctx, r.q.exists, id).Scan(&cantidad); err != nil {
+		return ErrExec
+	}
+	if cantidad < 1 {
+		return ErrNotFound
+	}
+	return ErrVersion
+}
+
+// guardarEspecialidades vincula al odontólogo con cada código del catálogo; un código inexistente no inserta filas
+func (r *repository) guardarEspecialidades(ctx context.Context, tx transaccion.Ejecutor, o Odontologo) error {
+	for _, e := range o.Especialidades {
+		result, err := tx.ExecContext(ctx, r.q.insertEspecialidad, o.ID, e.Codigo)
+		if err != nil {
+			return ErrExec
+		}
+		if n, err := result.RowsAffected(); err != nil || n < 1 {
+			return ErrEspecialidad
+		}
+	}
 	return nil
 }
+
+// cargarEspecialidades completa las especialidades de cada odontólogo del listado
+func (r *repository) cargarEspecialidades(ctx context.Context, odontologos []Odontologo) error {
+	especialidades, err := r.getEspecialidades(ctx, r.q.getAllEspecialidades)
+	if err != nil {
+		return err
+	}
+	for i := range odontologos {
+		odontologos[i].Especialidades = conDefault(especialidades[odontologos[i].ID])
+	}
+	return nil
+}
+
+// getEspecialidades ejecuta la consulta y agrupa las especialidades por odontólogo
+func (r *repository) getEspecialidades(ctx context.Context, query string, args ...interface{}) (map[int][]Especialidad, error) {
+	rows, err := r.conexion(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	especialidades := map[int][]Especialidad{}
+	for rows.Next() {
+		var idOdontologo int
+		var e Especialidad
+		if err := rows.Scan(&idOdontologo, &e.ID, &e.Codigo, &e.Nombre); err != nil {
+			return nil, err
+		}
+		especialidades[idOdontologo] = append(especialidades[idOdontologo], e)
+	}
+	return especialidades, rows.Err()
+}
+
+// conDefault devuelve una lista vacía en lugar de nil, así el JSON muestra [] y no null
+func conDefault(lista []Especialidad) []Especialidad {
+	if lista == nil {
+		return []Especialidad{}
+	}
+	return lista
+}
+
+// nullID guarda NULL cuando no hay usuario (por ejemplo, una baja hecha por el sistema)
+func nullID(id int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(id), Valid: id != 0}
+}
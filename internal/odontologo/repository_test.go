@@ -0,0 +1,31 @@
+package odontologo_test
+
+import (
+	"testing"
+
+	"finalgo/internal/contrato/contratotest"
+	"finalgo/internal/odontologo"
+	"finalgo/pkg/config"
+)
+
+func TestRepositorySqlite(t *testing.T) {
+	contratotest.Odontologos(t, func(t *testing.T) odontologo.Repository {
+		return odontologo.NewRepositorySqlite(contratotest.Base(t, config.MotorSQLite))
+	})
+}
+
+// TestRepositoryMySql corre contra la base de PRUEBAS_MYSQL_DSN; sin ella se saltea
+func TestRepositoryMySql(t *testing.T) {
+	contratotest.Requiere(t, config.MotorMySQL)
+	contratotest.Odontologos(t, func(t *testing.T) odontologo.Repository {
+		return odontologo.NewRepositoryMySql(contratotest.Base(t, config.MotorMySQL))
+	})
+}
+
+// TestRepositoryPostgres corre contra la base de PRUEBAS_POSTGRES_DSN; sin ella se saltea
+func TestRepositoryPostgres(t *testing.T) {
+	contratotest.Requiere(t, config.MotorPostgres)
+	contratotest.Odontologos(t, func(t *testing.T) odontologo.Repository {
+		return odontologo.NewRepositoryPostgres(contratotest.Base(t, config.MotorPostgres))
+	})
+}
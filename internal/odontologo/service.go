@@ -2,7 +2,11 @@ package odontologo
 
 import (
 	"context"
+	"errors"
 	"log"
+
+	"finalgo/pkg/auth"
+	"finalgo/pkg/transaccion"
 )
 
 // defino la interfaz para que se apliquen siempre todos los métodos
@@ -11,18 +15,53 @@ type Service interface {
 	GetAll(ctx context.Context) ([]Odontologo, error)
 	GetOdontologoIdByMatricula(ctx context.Context, matricula string) (int, error)
 	CreateOdontologo(ctx context.Context, o OdontologoRequest) (Odontologo, error)
-	UpdateOdontologo(ctx context.Context, o OdontologoRequest, id int) (Odontologo, error)
-	DeleteOdontologo(ctx context.Context, id int) error
+	UpdateOdontologo(ctx context.Context, o OdontologoRequest, id int, version int) (Odontologo, error)
+	DeleteOdontologo(ctx context.Context, id int, version int) error
+	GetEliminados(ctx context.Context) ([]Odontologo, error)
+	GetEliminadoByID(ctx context.Context, id int) (Odontologo, error)
+	RestaurarOdontologo(ctx context.Context, id int) (Odontologo, error)
+
+	GetEspecialidades(ctx context.Context) ([]Especialidad, error)
+	GetOdontologosByEspecialidad(ctx context.Context, codigo string) ([]Odontologo, error)
+}
+
+// entidad con la que se registran los cambios en la auditoría
+const entidadOdontologo = "odontologo"
+
+// Auditoria registra quién cambió qué y cómo estaba antes. Lo implementa auditoria.Service; se define acá para que
+// odontologo no dependa de ese paquete.
+type Auditoria interface {
+	Alta(ctx context.Context, entidad string, id int, despues interface{}) error
+	Modificacion(ctx context.Context, entidad string, id int, antes, despues interface{}) error
+	Baja(ctx context.Context, entidad string, id int, antes interface{}) error
+	Restauracion(ctx context.Context, entidad string, id int, despues interface{}) error
 }
 
-// estrucutra service que contará con un repositorio
+// estrucutra service que contará con un repositorio. Cada cambio y su registro de auditoría van en una misma unidad
+// de trabajo.
 type service struct {
-	r Repository
+	r   Repository
+	uow transaccion.UnitOfWork
+	a   Auditoria
 }
 
 // función para instanciar service
-func NewService(r Repository) Service {
-	return &service{r}
+func NewService(r Repository, uow transaccion.UnitOfWork, a Auditoria) Service {
+	return &service{r, uow, a}
+}
+
+// auditado aplica el cambio y lo registra en la auditoría en una sola transacción: si el registro falla, el cambio se
+// deshace y el error es ErrExec. Los errores del cambio vuelven tal cual.
+func (s *service) auditado(ctx context.Context, cambio func(ctx context.Context) error, registro func(ctx context.Context) error) error {
+	return s.uow.Ejecutar(ctx, func(ctx context.Context) error {
+		if err := cambio(ctx); err != nil {
+			return err
+		}
+		if err := registro(ctx); err != nil {
+			return ErrExec
+		}
+		return nil
+	})
 }
 
 // aplico todos los métodos de la interfaz, llamando a su correspondiente método del repositorio (todos conservan el mismo nombre):
@@ -57,42 +96,168 @@ func (s *service) GetOdontologoIdByMatricula(ctx context.Context, matricula stri
 func (s *service) CreateOdontologo(ctx context.Context, odontologoRequest OdontologoRequest) (Odontologo, error) {
 	// uso la estructura de request para mejor manejo de campos (no tiene el ID), llamando a una función que lo transforma en el dato que requiere la DB
 	odontologo := requestToOdontologo(odontologoRequest)
-	response, err := s.r.CreateOdontologo(ctx, odontologo)
+	var response Odontologo
+	err := s.auditado(ctx, func(ctx context.Context) (err error) {
+		response, err = s.r.CreateOdontologo(ctx, odontologo)
+		return err
+	}, func(ctx context.Context) error {
+		response = s.conEspecialidades(ctx, response)
+		return s.a.Alta(ctx, entidadOdontologo, response.ID, response)
+	})
 	if err != nil {
 		log.Println("error al crear Odontologo")
+		if errors.Is(err, ErrEspecialidad) {
+			return Odontologo{}, ErrEspecialidad
+		}
 		return Odontologo{}, ErrExec
 	}
 	return response, nil
 }
 
-func (s *service) DeleteOdontologo(ctx context.Context, id int) error {
-	err := s.r.DeleteOdontologo(ctx, id)
+func (s *service) DeleteOdontologo(ctx context.Context, id int, version int) error {
+	antes, err := s.r.GetOdontologoByID(ctx, id)
+	if err != nil {
+		log.Println("log de error por odontologo inexistente", err.Error())
+		return ErrNotFound
+	}
+	// la baja es lógica; en una baja en cascada todos los registros comparten la misma fecha
+	err = s.auditado(ctx, func(ctx context.Context) error {
+		return s.r.DeleteOdontologo(ctx, id, version, transaccion.Momento(ctx), auth.IdUsuarioDesde(ctx))
+	}, func(ctx context.Context) error {
+		return s.a.Baja(ctx, entidadOdontologo, id, antes)
+	})
 	if err != nil {
 		log.Println("log de error borrado de Odontologo", err.Error())
+		if errors.Is(err, ErrVersion) || errors.Is(err, ErrExec) {
+			return err
+		}
 		return ErrNotFound
 	}
 	return nil
 }
 
+// GetEliminados devuelve los odontólogos dados de baja, con la fecha y el usuario de la baja
+func (s *service) GetEliminados(ctx context.Context) ([]Odontologo, error) {
+	odontologos, err := s.r.GetEliminados(ctx)
+	if err != nil {
+		log.Println("log de error en odontologos dados de baja", err.Error())
+		return []Odontologo{}, ErrExec
+	}
+	return odontologos, nil
+}
+
+// GetEliminadoByID devuelve un odontólogo dado de baja, con la fecha y el usuario de la baja
+func (s *service) GetEliminadoByID(ctx context.Context, id int) (Odontologo, error) {
+	o, err := s.r.GetEliminadoByID(ctx, id)
+	if err != nil {
+		log.Println("log de error por odontologo dado de baja", err.Error())
+		if errors.Is(err, ErrNotFound) {
+			return Odontologo{}, ErrNotFound
+		}
+		return Odontologo{}, ErrExec
+	}
+	return o, nil
+}
+
+// RestaurarOdontologo vuelve a dar de alta a un odontólogo dado de baja
+func (s *service) RestaurarOdontologo(ctx context.Context, id int) (Odontologo, error) {
+	var o Odontologo
+	err := s.auditado(ctx, func(ctx context.Context) (err error) {
+		if err := s.r.RestaurarOdontologo(ctx, id); err != nil {
+			return err
+		}
+		o, err = s.GetOdontologoByID(ctx, id)
+		return err
+	}, func(ctx context.Context) error {
+		return s.a.Restauracion(ctx, entidadOdontologo, id, o)
+	})
+	if err != nil {
+		log.Println("log de error al restaurar odontologo", err.Error())
+		if errors.Is(err, ErrNotFound) {
+			return Odontologo{}, ErrNotFound
+		}
+		return Odontologo{}, ErrExec
+	}
+	return o, nil
+}
+
 // este método está preparado para ser usado como PATCH o como PUT, se le deberá pasar desde el handler el Odontologo completo
-func (s *service) UpdateOdontologo(ctx context.Context, odontologoRequest OdontologoRequest, id int) (Odontologo, error) {
+// con la versión que se leyó
+func (s *service) UpdateOdontologo(ctx context.Context, odontologoRequest OdontologoRequest, id int, version int) (Odontologo, error) {
 	// uso la estructura de request para mejor manejo de campos (no tiene el ID), llamando a una función que lo transforma en el dato que requiere la DB
 	odontologo := requestToOdontologo(odontologoRequest)
 	odontologo.ID = id
-	response, err := s.r.UpdateOdontologo(ctx, odontologo)
+	odontologo.Version = version
+	antes, err := s.r.GetOdontologoByID(ctx, id)
+	if err != nil {
+		log.Println("log de error por odontologo inexistente", err.Error())
+		return Odontologo{}, ErrNotFound
+	}
+	var response Odontologo
+	err = s.auditado(ctx, func(ctx context.Context) (err error) {
+		response, err = s.r.UpdateOdontologo(ctx, odontologo)
+		return err
+	}, func(ctx context.Context) error {
+		response = s.conEspecialidades(ctx, response)
+		return s.a.Modificacion(ctx, entidadOdontologo, id, antes, response)
+	})
 	if err != nil {
 		log.Println("error al actualizar odontologo")
+		if errors.Is(err, ErrEspecialidad) || errors.Is(err, ErrNotFound) || errors.Is(err, ErrVersion) {
+			return Odontologo{}, err
+		}
 		return Odontologo{}, ErrExec
 	}
 	return response, nil
 }
 
-// función para transformar request en la estructura definida en GO
+func (s *service) GetEspecialidades(ctx context.Context) ([]Especialidad, error) {
+	especialidades, err := s.r.GetEspecialidades(ctx)
+	if err != nil {
+		log.Println("log de error en especialidades", err.Error())
+		return []Especialidad{}, ErrEmptyList
+	}
+	return especialidades, nil
+}
+
+func (s *service) GetOdontologosByEspecialidad(ctx context.Context, codigo string) ([]Odontologo, error) {
+	odontologos, err := s.r.GetOdontologosByEspecialidad(ctx, codigo)
+	if err != nil {
+		log.Println("log de error en odontologos por especialidad", err.Error())
+		return []Odontologo{}, ErrEmptyList
+	}
+	return odontologos, nil
+}
+
+// conEspecialidades vuelve a leer el odontólogo guardado para devolver las especialidades completas (con ID y nombre);
+// si la lectura falla devuelve lo que se guardó
+func (s *service) conEspecialidades(ctx context.Context, o Odontologo) Odontologo {
+	guardado, err := s.r.GetOdontologoByID(ctx, o.ID)
+	if err != nil {
+		log.Println("log de error al releer odontologo", err.Error())
+		return o
+	}
+	return guardado
+}
+
+// función para transformar request en la estructura definida en GO. Las especialidades quedan en nil si no vinieron,
+// para que el update no las toque.
 func requestToOdontologo(odontologoRequest OdontologoRequest) Odontologo {
 	var odontologo Odontologo
 	odontologo.Apellido = odontologoRequest.Apellido
 	odontologo.Nombre = odontologoRequest.Nombre
 	odontologo.Matricula = odontologoRequest.Matricula
+	if odontologoRequest.Especialidades != nil {
+		odontologo.Especialidades = []Especialidad{}
+		vistos := map[string]bool{}
+		for _, codigo := range odontologoRequest.Especialidades {
+			if vistos[codigo] {
+				continue
+			}
+			vistos[codigo] = true
+			odontologo.Especialidades = append(odontologo.Especialidades, Especialidad{Codigo: codigo})
+		}
+	}
 
 	return odontologo
 }
@@ -0,0 +1,174 @@
+package paciente
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+// código de país que se asume cuando el número se carga sin prefijo internacional
+const codigoPaisLocal = "54"
+
+// Errores de validación de los datos de contacto
+var (
+	ErrContacto = errors.New("datos de contacto inválidos")
+	ErrTelefono = errors.New("número de teléfono inválido")
+	ErrEmail    = errors.New("email inválido")
+)
+
+// NormalizarTelefono lleva el número a E.164. Acepta números con prefijo internacional (+ o 00) y números
+// argentinos en formato local: con o sin el 0 de la característica y con el 15 de los celulares
+// ("011 15-4444-5555" queda +5491144445555). Un número local de 10 dígitos sin 15 se toma como celular sólo si movil es true.
+// Los que ya traen el +54 pasan por la misma normalización, porque es común cargarlos con el 0 o el 15 ("+54 011 15 ...").
+func NormalizarTelefono(numero string, movil bool) (string, error) {
+	var limpio strings.Builder
+	for i, r := range strings.TrimSpace(numero) {
+		switch {
+		case r >= '0' && r <= '9':
+			limpio.WriteRune(r)
+		case r == '+' && i == 0:
+			limpio.WriteRune(r)
+		case strings.ContainsRune(" -().", r):
+		default:
+			return "", ErrTelefono
+		}
+	}
+
+	digitos := limpio.String()
+	internacional := true
+	switch {
+	case strings.HasPrefix(digitos, "+"):
+		digitos = digitos[1:]
+	case strings.HasPrefix(digitos, "00"):
+		digitos = digitos[2:]
+	default:
+		internacional = false
+	}
+	if !internacional || strings.HasPrefix(digitos, codigoPaisLocal) {
+		nacional, err := normalizarNacional(strings.TrimPrefix(digitos, codigoPaisLocal), internacional, movil)
+		if err != nil {
+			return "", err
+		}
+		digitos = nacional
+	}
+
+	// E.164: hasta 15 dígitos y el código de país nunca empieza con 0
+	if len(digitos) < 8 || len(digitos) > 15 || digitos[0] == '0' {
+		return "", ErrTelefono
+	}
+	return "+" + digitos, nil
+}
+
+// normalizarNacional arma el número internacional a partir del formato local argentino, donde característica y
+// número suman siempre 10 dígitos y los celulares llevan un 9 después del código de país en lugar del 15.
+// Si venía con el +54, el número puede estar ya completo con el 9 del celular.
+func normalizarNacional(digitos string, conCodigoPais bool, movil bool) (string, error) {
+	if conCodigoPais && len(digitos) == 11 && digitos[0] == '9' {
+		return codigoPaisLocal + digitos, nil
+	}
+	digitos = strings.TrimPrefix(digitos, "0")
+	switch len(digitos) {
+	case 10:
+		if movil {
+			return codigoPaisLocal + "9" + digitos, nil
+		}
+		return codigoPaisLocal + digitos, nil
+	case 12:
+		// las características tienen entre 2 y 4 dígitos y el 15 va justo después
+		for largo := 2; largo <= 4; largo++ {
+			if digitos[largo:largo+2] == "15" {
+				return codigoPaisLocal + "9" + digitos[:largo] + digitos[largo+2:], nil
+			}
+		}
+	}
+	return "", ErrTelefono
+}
+
+// NormalizarEmail valida que sea una dirección simple (sin nombre) y la pasa a minúsculas.
+func NormalizarEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	direccion, err := mail.ParseAddress(email)
+	if err != nil || direccion.Address != email || !strings.Contains(email[strings.LastIndex(email, "@"):], ".") {
+		return "", ErrEmail
+	}
+	return strings.ToLower(email), nil
+}
+
+// normalizarContacto valida y normaliza teléfonos, email, canal preferido y contactos de emergencia del paciente.
+// Los errores vuelven envueltos en ErrContacto con el detalle del campo.
+func normalizarContacto(p *Paciente) error {
+	vistos := map[string]bool{}
+	telefonos := []Telefono{}
+	hayPrincipal := false
+	for _, t := range p.Telefonos {
+		if t.Tipo == "" {
+			t.Tipo = TelefonoMovil
+		}
+		if t.Tipo != TelefonoMovil && t.Tipo != TelefonoFijo && t.Tipo != TelefonoTrabajo {
+			return fmt.Errorf("%w: tipo de teléfono %q", ErrContacto, t.Tipo)
+		}
+		numero, err := NormalizarTelefono(t.Numero, t.Tipo == TelefonoMovil)
+		if err != nil {
+			return fmt.Errorf("%w: teléfono %q", ErrContacto, t.Numero)
+		}
+		if vistos[numero] {
+			continue
+		}
+		vistos[numero] = true
+		t.Numero = numero
+
+		// un solo teléfono principal
+		if t.Principal && hayPrincipal {
+			t.Principal = false
+		}
+		hayPrincipal = hayPrincipal || t.Principal
+		telefonos = append(telefonos, t)
+	}
+	if len(telefonos) > 0 && !hayPrincipal {
+		telefonos[0].Principal = true
+	}
+	p.Telefonos = telefonos
+
+	if p.Email != "" {
+		email, err := NormalizarEmail(p.Email)
+		if err != nil {
+			return fmt.Errorf("%w: email %q", ErrContacto, p.Email)
+		}
+		p.Email = email
+	}
+
+	switch p.CanalPreferido {
+	case "":
+	case CanalEmail:
+		if p.Email == "" {
+			return fmt.Errorf("%w: el canal preferido es email pero no hay email", ErrContacto)
+		}
+	case CanalTelefono, CanalSMS, CanalWhatsApp:
+		if len(p.Telefonos) == 0 {
+			return fmt.Errorf("%w: el canal preferido es %s pero no hay teléfonos", ErrContacto, p.CanalPreferido)
+		}
+	default:
+		return fmt.Errorf("%w: canal preferido %q", ErrContacto, p.CanalPreferido)
+	}
+
+	// recordatorios sin ningún medio de contacto no tienen sentido
+	if p.AceptaRecordatorios && len(p.Telefonos) == 0 && p.Email == "" {
+		return fmt.Errorf("%w: acepta recordatorios pero no tiene teléfono ni email", ErrContacto)
+	}
+
+	contactos := []ContactoEmergencia{}
+	for _, c := range p.ContactosEmergencia {
+		if strings.TrimSpace(c.Nombre) == "" {
+			return fmt.Errorf("%w: contacto de emergencia sin nombre", ErrContacto)
+		}
+		numero, err := NormalizarTelefono(c.Telefono, false)
+		if err != nil {
+			return fmt.Errorf("%w: teléfono del contacto de emergencia %q", ErrContacto, c.Telefono)
+		}
+		c.Telefono = numero
+		contactos = append(contactos, c)
+	}
+	p.ContactosEmergencia = contactos
+	return nil
+}
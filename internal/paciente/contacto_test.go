@@ -0,0 +1,67 @@
+package paciente
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizarTelefono(t *testing.T) {
+	casos := []struct {
+		nombre string
+		numero string
+		movil  bool
+		espera string
+		err    error
+	}{
+		{"local con 0 y 15", "011 15-4444-5555", true, "+5491144445555", nil},
+		{"local sin 0 con 15", "11 15 4444 5555", false, "+5491144445555", nil},
+		{"local celular sin 15", "11 4444 5555", true, "+5491144445555", nil},
+		{"local fijo con 0", "(011) 4444-5555", false, "+541144445555", nil},
+		{"característica de 3 dígitos con 15", "0351 15 123-4567", true, "+5493511234567", nil},
+		{"característica de 4 dígitos con 15", "02944 15 12-3456", true, "+5492944123456", nil},
+		{"ya en E.164 celular", "+5491144445555", true, "+5491144445555", nil},
+		{"+54 con espacios", "+54 9 11 4444-5555", false, "+5491144445555", nil},
+		{"+54 fijo", "+54 11 4444 5555", false, "+541144445555", nil},
+		{"+54 con el 0 de la característica", "+54 011 4444 5555", false, "+541144445555", nil},
+		{"+54 con el 15", "+54 11 15 4444 5555", true, "+5491144445555", nil},
+		{"+54 con 0 y 15", "+54 (011) 15-4444-5555", true, "+5491144445555", nil},
+		{"prefijo 00", "0054 9 11 4444 5555", false, "+5491144445555", nil},
+		{"otro país", "+1 (202) 555-0123", false, "+12025550123", nil},
+		{"otro país con 00", "0034 612 345 678", false, "+34612345678", nil},
+		{"letras", "11-4444-ABCD", false, "", ErrTelefono},
+		{"+ en el medio", "11+4444-5555", false, "", ErrTelefono},
+		{"local corto", "4444-5555", false, "", ErrTelefono},
+		{"local largo sin 15", "011 1234 4444 5555", true, "", ErrTelefono},
+		{"+54 incompleto", "+54 11 4444", false, "", ErrTelefono},
+		{"internacional demasiado largo", "+1234567890123456", false, "", ErrTelefono},
+		{"código de país con 0", "+0 11 4444 5555", false, "", ErrTelefono},
+		{"vacío", "", false, "", ErrTelefono},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			numero, err := NormalizarTelefono(c.numero, c.movil)
+			if !errors.Is(err, c.err) || numero != c.espera {
+				t.Fatalf("NormalizarTelefono(%q, %v) = %q, %v; se esperaba %q, %v", c.numero, c.movil, numero, err, c.espera, c.err)
+			}
+		})
+	}
+}
+
+func TestNormalizarEmail(t *testing.T) {
+	casos := []struct {
+		email  string
+		espera string
+		err    error
+	}{
+		{" Ana.Perez@Mail.com ", "ana.perez@mail.com", nil},
+		{"Ana <ana@mail.com>", "", ErrEmail},
+		{"ana@localhost", "", ErrEmail},
+		{"sin-arroba", "", ErrEmail},
+	}
+	for _, c := range casos {
+		email, err := NormalizarEmail(c.email)
+		if !errors.Is(err, c.err) || email != c.espera {
+			t.Fatalf("NormalizarEmail(%q) = %q, %v; se esperaba %q, %v", c.email, email, err, c.espera, c.err)
+		}
+	}
+}
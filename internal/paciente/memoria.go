@@ -0,0 +1,431 @@
+package paciente
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// estructura repositorio en memoria, para pruebas y demos. Se comporta como el de MySQL (IDs correlativos, los mismos
+// errores, baja lógica, orden de los listados y fechas con la precisión de sus columnas) pero los datos se pierden al
+// cerrar la aplicación.
+type repositoryMemoria struct {
+	mu sync.RWMutex
+
+	ultimoID     int
+	pacientes    map[int]Paciente
+	telefonos    map[int][]Telefono
+	contactos    map[int][]ContactoEmergencia
+	ultimaAlerta int
+	alertas      map[int]AlertaMedica
+
+	ultimoResponsable int
+	responsables      map[int]Responsable
+}
+
+// NewRepositoryMemoria instancia repositorio en memoria, vacío
+func NewRepositoryMemoria() Repository {
+	return &repositoryMemoria{
+		pacientes:    map[int]Paciente{},
+		telefonos:    map[int][]Telefono{},
+		contactos:    map[int][]ContactoEmergencia{},
+		alertas:      map[int]AlertaMedica{},
+		responsables: map[int]Responsable{},
+	}
+}
+
+func (r *repositoryMemoria) GetAll(ctx context.Context) ([]Paciente, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// como el de MySQL, sin pacientes devuelve nil
+	var pacientes []Paciente
+	for _, p := range r.ordenados() {
+		if p.DeletedAt == nil {
+			pacientes = append(pacientes, r.completo(p))
+		}
+	}
+	return pacientes, nil
+}
+
+func (r *repositoryMemoria) GetPacienteByID(ctx context.Context, id int) (Paciente, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.pacientes[id]
+	if !ok || p.DeletedAt != nil {
+		return Paciente{}, ErrNotFound
+	}
+	return r.completo(p), nil
+}
+
+func (r *repositoryMemoria) GetPacienteIDByDNI(ctx context.Context, dni string) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, p := range r.ordenados() {
+		if p.DNI == dni && p.DeletedAt == nil {
+			return p.ID, nil
+		}
+	}
+	return 0, ErrNotFound
+}
+
+func (r *repositoryMemoria) CreatePaciente(ctx context.Context, paciente Paciente) (Paciente, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// valido los teléfonos antes de guardar nada, como el rollback de la transacción
+	if !telefonosUnicos(paciente.Telefonos) {
+		return Paciente{}, ErrExec
+	}
+
+	r.ultimoID++
+	paciente.ID = r.ultimoID
+	paciente.Version = 1
+	r.guardar(paciente)
+	return paciente, nil
+}
+
+func (r *repositoryMemoria) UpdatePaciente(ctx context.Context, paciente Paciente) (Paciente, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	actual, ok := r.pacientes[paciente.ID]
+	if !ok || actual.DeletedAt != nil {
+		return Paciente{}, ErrNotFound
+	}
+	if actual.Version != paciente.Version {
+		return Paciente{}, ErrVersion
+	}
+	if !telefonosUnicos(paciente.Telefonos) {
+		return Paciente{}, ErrExec
+	}
+	paciente.Version++
+	r.guardar(paciente)
+	return paciente, nil
+}
+
+func (r *repositoryMemoria) DeletePaciente(ctx context.Context, id int, version int, fecha time.Time, idUsuario int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.pacientes[id]
+	if !ok || p.DeletedAt != nil {
+		return ErrNotFound
+	}
+	if p.Version != version {
+		return ErrVersion
+	}
+	deletedAt := aSegundos(fecha)
+	p.DeletedAt = &deletedAt
+	p.DeletedBy = idUsuario
+	p.Version++
+	r.pacientes[id] = p
+	return nil
+}
+
+func (r *repositoryMemoria) GetEliminados(ctx context.Context) ([]Paciente, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	pacientes := []Paciente{}
+	for _, p := range r.ordenados() {
+		if p.DeletedAt != nil {
+			pacientes = append(pacientes, r.completo(p))
+		}
+	}
+	// del más reciente al más viejo; a igual fecha quedan por ID
+	sort.SliceStable(pacientes, func(i, j int) bool {
+		return pacientes[i].DeletedAt.After(*pacientes[j].DeletedAt)
+	})
+	return pacientes, nil
+}
+
+func (r *repositoryMemoria) GetEliminadoByID(ctx context.Context, id int) (Paciente, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.pacientes[id]
+	if !ok || p.DeletedAt == nil {
+		return Paciente{}, ErrNotFound
+	}
+	return r.completo(p), nil
+}
+
+func (r *repositoryMemoria) RestaurarPaciente(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.pacientes[id]
+	if !ok || p.DeletedAt == nil {
+		return ErrNotFound
+	}
+	p.DeletedAt = nil
+	p.DeletedBy = 0
+	p.Version++
+	r.pacientes[id] = p
+	return nil
+}
+
+func (r *repositoryMemoria) GetAlertasByPaciente(ctx context.Context, idPaciente int) ([]AlertaMedica, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	alertas := []AlertaMedica{}
+	for _, a := range r.alertas {
+		if a.IdPaciente == idPaciente {
+			alertas = append(alertas, a)
+		}
+	}
+	// las de alta severidad primero y, dentro de cada severidad, las más nuevas
+	sort.Slice(alertas, func(i, j int) bool {
+		si, sj := ordenSeveridad(alertas[i].Severidad), ordenSeveridad(alertas[j].Severidad)
+		if si != sj {
+			return si < sj
+		}
+		if !alertas[i].FechaDesde.Equal(alertas[j].FechaDesde) {
+			return alertas[i].FechaDesde.After(alertas[j].FechaDesde)
+		}
+		return alertas[i].ID < alertas[j].ID
+	})
+	return alertas, nil
+}
+
+func (r *repositoryMemoria) GetAlertasActivasByPacientes(ctx context.Context, idsPaciente []int) ([]AlertaMedica, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	buscados := map[int]bool{}
+	for _, id := range idsPaciente {
+		buscados[id] = true
+	}
+	alertas := []AlertaMedica{}
+	for _, a := range r.alertas {
+		if a.Activa && buscados[a.IdPaciente] {
+			alertas = append(alertas, a)
+		}
+	}
+	// por paciente y, dentro de cada uno, como GetAlertasByPaciente
+	sort.Slice(alertas, func(i, j int) bool {
+		if alertas[i].IdPaciente != alertas[j].IdPaciente {
+			return alertas[i].IdPaciente < alertas[j].IdPaciente
+		}
+		si, sj := ordenSeveridad(alertas[i].Severidad), ordenSeveridad(alertas[j].Severidad)
+		if si != sj {
+			return si < sj
+		}
+		if !alertas[i].FechaDesde.Equal(alertas[j].FechaDesde) {
+			return alertas[i].FechaDesde.After(alertas[j].FechaDesde)
+		}
+		return alertas[i].ID < alertas[j].ID
+	})
+	return alertas, nil
+}
+
+func (r *repositoryMemoria) GetAlertaByID(ctx context.Context, idPaciente int, id int) (AlertaMedica, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	a, ok := r.alertas[id]
+	if !ok || a.IdPaciente != idPaciente {
+		return AlertaMedica{}, ErrAlertaNotFound
+	}
+	return a, nil
+}
+
+func (r *repositoryMemoria) CreateAlerta(ctx context.Context, alerta AlertaMedica) (AlertaMedica, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// la foreign key exige que el paciente exista, aunque esté dado de baja
+	if _, ok := r.pacientes[alerta.IdPaciente]; !ok {
+		return AlertaMedica{}, ErrExec
+	}
+	r.ultimaAlerta++
+	alerta.ID = r.ultimaAlerta
+	alerta.Version = 1
+	r.alertas[alerta.ID] = alertaGuardada(alerta)
+	return alerta, nil
+}
+
+func (r *repositoryMemoria) UpdateAlerta(ctx context.Context, alerta AlertaMedica) (AlertaMedica, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	actual, ok := r.alertas[alerta.ID]
+	if !ok || actual.IdPaciente != alerta.IdPaciente {
+		return AlertaMedica{}, ErrAlertaNotFound
+	}
+	if actual.Version != alerta.Version {
+		return AlertaMedica{}, ErrVersionAlerta
+	}
+	alerta.Version++
+	r.alertas[alerta.ID] = alertaGuardada(alerta)
+	return alerta, nil
+}
+
+func (r *repositoryMemoria) DeleteAlerta(ctx context.Context, idPaciente int, id int, version int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	a, ok := r.alertas[id]
+	if !ok || a.IdPaciente != idPaciente {
+		return ErrAlertaNotFound
+	}
+	if a.Version != version {
+		return ErrVersionAlerta
+	}
+	delete(r.alertas, id)
+	return nil
+}
+
+func (r *repositoryMemoria) GetResponsables(ctx context.Context, idPaciente int) ([]Responsable, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	responsables := []Responsable{}
+	for _, responsable := range r.responsables {
+		if responsable.IdPaciente == idPaciente {
+			responsables = append(responsables, responsable)
+		}
+	}
+	sort.Slice(responsables, func(i, j int) bool { return responsables[i].ID < responsables[j].ID })
+	return responsables, nil
+}
+
+func (r *repositoryMemoria) GetResponsableByID(ctx context.Context, idPaciente int, id int) (Responsable, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	responsable, ok := r.responsables[id]
+	if !ok || responsable.IdPaciente != idPaciente {
+		return Responsable{}, ErrResponsableNotFound
+	}
+	return responsable, nil
+}
+
+func (r *repositoryMemoria) CreateResponsable(ctx context.Context, responsable Responsable) (Responsable, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// las foreign keys exigen que existan el paciente y, si es otro paciente, el responsable
+	if _, ok := r.pacientes[responsable.IdPaciente]; !ok {
+		return Responsable{}, ErrExec
+	}
+	if _, ok := r.pacientes[responsable.IdResponsable]; responsable.IdResponsable != 0 && !ok {
+		return Responsable{}, ErrExec
+	}
+	r.ultimoResponsable++
+	responsable.ID = r.ultimoResponsable
+	responsable.Version = 1
+	r.responsables[responsable.ID] = responsable
+	return responsable, nil
+}
+
+func (r *repositoryMemoria) DeleteResponsable(ctx context.Context, idPaciente int, id int, version int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	responsable, ok := r.responsables[id]
+	if !ok || responsable.IdPaciente != idPaciente {
+		return ErrResponsableNotFound
+	}
+	if responsable.Version != version {
+		return ErrVersionResponsable
+	}
+	delete(r.responsables, id)
+	return nil
+}
+
+// guardar reemplaza el paciente con sus teléfonos y contactos, con las fechas como las guardan las columnas DATE.
+// La baja no se toca: la manejan Delete y Restaurar.
+func (r *repositoryMemoria) guardar(paciente Paciente) {
+	guardado := paciente
+	if actual, ok := r.pacientes[paciente.ID]; ok {
+		guardado.DeletedAt, guardado.DeletedBy = actual.DeletedAt, actual.DeletedBy
+	}
+	guardado.Alta = soloFecha(paciente.Alta)
+	guardado.FechaNacimiento = soloFecha(paciente.FechaNacimiento)
+	guardado.Edad = nil
+	guardado.Alertas = nil
+	guardado.Telefonos = nil
+	guardado.ContactosEmergencia = nil
+	r.pacientes[paciente.ID] = guardado
+
+	// los teléfonos se devuelven con el principal primero
+	telefonos := append([]Telefono(nil), paciente.Telefonos...)
+	sort.SliceStable(telefonos, func(i, j int) bool { return telefonos[i].Principal && !telefonos[j].Principal })
+	r.telefonos[paciente.ID] = telefonos
+	r.contactos[paciente.ID] = append([]ContactoEmergencia(nil), paciente.ContactosEmergencia...)
+}
+
+// ordenados devuelve los pacientes guardados por ID, que es el orden en que los devuelve MySQL
+func (r *repositoryMemoria) ordenados() []Paciente {
+	pacientes := make([]Paciente, 0, len(r.pacientes))
+	for _, p := range r.pacientes {
+		pacientes = append(pacientes, p)
+	}
+	sort.Slice(pacientes, func(i, j int) bool { return pacientes[i].ID < pacientes[j].ID })
+	return pacientes
+}
+
+// completo devuelve una copia del paciente con sus teléfonos y contactos
+func (r *repositoryMemoria) completo(p Paciente) Paciente {
+	p.Telefonos = conDefault(append([]Telefono(nil), r.telefonos[p.ID]...))
+	p.ContactosEmergencia = conDefault(append([]ContactoEmergencia(nil), r.contactos[p.ID]...))
+	if p.DeletedAt != nil {
+		deletedAt := *p.DeletedAt
+		p.DeletedAt = &deletedAt
+	}
+	return p
+}
+
+// telefonosUnicos controla lo mismo que el índice único de telefono_paciente: un número por paciente
+func telefonosUnicos(telefonos []Telefono) bool {
+	vistos := map[string]bool{}
+	for _, t := range telefonos {
+		if vistos[t.Numero] {
+			return false
+		}
+		vistos[t.Numero] = true
+	}
+	return true
+}
+
+// alertaGuardada deja las fechas con la precisión de las columnas DATETIME
+func alertaGuardada(alerta AlertaMedica) AlertaMedica {
+	alerta.FechaDesde = aSegundos(alerta.FechaDesde)
+	alerta.FechaHasta = aSegundos(alerta.FechaHasta)
+	return alerta
+}
+
+// ordenSeveridad es el orden de las alertas: alta, media y el resto
+func ordenSeveridad(severidad string) int {
+	switch severidad {
+	case SeveridadAlta:
+		return 0
+	case SeveridadMedia:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// soloFecha es lo que devuelve una columna DATE: el día, en UTC como lee el driver. La fecha cero queda en cero (NULL).
+func soloFecha(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// aSegundos es lo que devuelve una columna DATETIME: redondeada al segundo y en UTC. La fecha cero queda en cero.
+func aSegundos(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return t.UTC().Round(time.Second)
+}
@@ -0,0 +1,14 @@
+package paciente_test
+
+import (
+	"testing"
+
+	"finalgo/internal/contrato/contratotest"
+	"finalgo/internal/paciente"
+)
+
+func TestRepositoryMemoria(t *testing.T) {
+	contratotest.Pacientes(t, func(t *testing.T) paciente.Repository {
+		return paciente.NewRepositoryMemoria()
+	})
+}
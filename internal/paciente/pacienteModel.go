@@ -10,6 +10,22 @@ type Paciente struct {
 	Domicilio string `json:"domicilio"`
 	DNI string `json:"dni"`
 	Alta time.Time `json:"fecha_alta"`
+	// en cero si no se conoce; la edad se calcula al consultar
+	FechaNacimiento time.Time `json:"fecha_nacimiento"`
+	Edad *int `json:"edad,omitempty"`
+	Telefonos []Telefono `json:"telefonos"`
+	Email string `json:"email"`
+	CanalPreferido string `json:"canal_preferido"`
+	AceptaRecordatorios bool `json:"acepta_recordatorios"`
+	AceptaMarketing bool `json:"acepta_marketing"`
+	ContactosEmergencia []ContactoEmergencia `json:"contactos_emergencia"`
+	// alertas médicas activas, se cargan al consultar el paciente y se mantienen por sus propias rutas
+	Alertas []AlertaMedica `json:"alertas,omitempty"`
+	// baja lógica: sólo vienen en el listado de dados de baja
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	DeletedBy int `json:"deleted_by,omitempty"`
+	// sube con cada modificación; viaja en el ETag y hay que mandarla en If-Match para modificar o dar de baja
+	Version int `json:"version"`
 }
 
 // creamos la misma estructura de paciente para las solicitudes por API.
@@ -19,4 +35,142 @@ type PacienteRequest struct {
 	Domicilio string `json:"domicilio"`
 	DNI string `json:"dni"`
 	Alta time.Time `json:"fecha_alta"`
-}
\ No newline at end of file
+	FechaNacimiento time.Time `json:"fecha_nacimiento"`
+	Telefonos []Telefono `json:"telefonos"`
+	Email string `json:"email"`
+	CanalPreferido string `json:"canal_preferido"`
+	AceptaRecordatorios bool `json:"acepta_recordatorios"`
+	AceptaMarketing bool `json:"acepta_marketing"`
+	ContactosEmergencia []ContactoEmergencia `json:"contactos_emergencia"`
+}
+
+// canales por los que el paciente prefiere que lo contacten
+const (
+	CanalTelefono = "telefono"
+	CanalSMS      = "sms"
+	CanalWhatsApp = "whatsapp"
+	CanalEmail    = "email"
+)
+
+// tipos de teléfono
+const (
+	TelefonoMovil   = "movil"
+	TelefonoFijo    = "fijo"
+	TelefonoTrabajo = "trabajo"
+)
+
+// Telefono del paciente. El número se guarda siempre en formato E.164 (+5491144445555).
+type Telefono struct {
+	Numero    string `json:"numero"`
+	Tipo      string `json:"tipo"`
+	Principal bool   `json:"principal"`
+}
+
+// ContactoEmergencia es a quién llamar si al paciente le pasa algo durante la atención.
+type ContactoEmergencia struct {
+	Nombre   string `json:"nombre"`
+	Relacion string `json:"relacion"`
+	Telefono string `json:"telefono"`
+}
+
+// edad a partir de la cual el paciente ya no necesita un responsable
+const MayoriaEdad = 18
+
+// EdadEn devuelve los años cumplidos a la fecha indicada; ok es false si no se cargó la fecha de nacimiento.
+func (p Paciente) EdadEn(fecha time.Time) (edad int, ok bool) {
+	if p.FechaNacimiento.IsZero() {
+		return 0, false
+	}
+	nacimiento := p.FechaNacimiento
+	edad = fecha.Year() - nacimiento.Year()
+	if fecha.Month() < nacimiento.Month() || (fecha.Month() == nacimiento.Month() && fecha.Day() < nacimiento.Day()) {
+		edad--
+	}
+	return edad, true
+}
+
+// EsMenorEn indica si el paciente es menor de edad a la fecha. Sin fecha de nacimiento se lo toma como mayor.
+func (p Paciente) EsMenorEn(fecha time.Time) bool {
+	edad, ok := p.EdadEn(fecha)
+	return ok && edad < MayoriaEdad
+}
+
+// Responsable es el adulto a cargo de un paciente menor: firma los consentimientos y responde por la facturación.
+// Puede ser otro paciente de la clínica (IdResponsable) o un contacto externo; en ambos casos se guardan nombre y DNI
+// tal como estaban al cargarlo.
+type Responsable struct {
+	ID            int    `json:"id"`
+	IdPaciente    int    `json:"id_paciente"`
+	IdResponsable int    `json:"id_responsable,omitempty"`
+	Nombre        string `json:"nombre"`
+	DNI           string `json:"dni"`
+	Relacion      string `json:"relacion"`
+	Telefono      string `json:"telefono"`
+	Email         string `json:"email"`
+	Version       int    `json:"version"`
+}
+
+// creamos la misma estructura de responsable para las solicitudes por API. Si viene IdResponsable, los datos
+// personales se toman de ese paciente.
+type ResponsableRequest struct {
+	IdResponsable int    `json:"id_responsable"`
+	Nombre        string `json:"nombre"`
+	DNI           string `json:"dni"`
+	Relacion      string `json:"relacion"`
+	Telefono      string `json:"telefono"`
+	Email         string `json:"email"`
+}
+
+// tipos de alerta médica
+const (
+	AlertaAlergia          = "alergia"
+	AlertaCondicionCronica = "condicion_cronica"
+	AlertaMedicacion       = "medicacion"
+	AlertaEmbarazo         = "embarazo"
+)
+
+// severidad de la alerta, la alta se muestra primero
+const (
+	SeveridadBaja  = "baja"
+	SeveridadMedia = "media"
+	SeveridadAlta  = "alta"
+)
+
+// AlertaMedica es un dato clínico que el odontólogo tiene que ver antes de atender (alergia a la penicilina, anticoagulantes, etc.).
+// FechaHasta en cero significa que no tiene fin previsto.
+type AlertaMedica struct {
+	ID          int       `json:"id"`
+	IdPaciente  int       `json:"id_paciente"`
+	Tipo        string    `json:"tipo"`
+	Descripcion string    `json:"descripcion"`
+	Severidad   string    `json:"severidad"`
+	Activa      bool      `json:"activa"`
+	FechaDesde  time.Time `json:"fecha_desde"`
+	FechaHasta  time.Time `json:"fecha_hasta"`
+	Version     int       `json:"version"`
+}
+
+// creamos la misma estructura de alerta para las solicitudes por API. Activa sin informar se toma como true al crear
+// y conserva el valor anterior al actualizar.
+type AlertaMedicaRequest struct {
+	Tipo        string    `json:"tipo"`
+	Descripcion string    `json:"descripcion"`
+	Severidad   string    `json:"severidad"`
+	Activa      *bool     `json:"activa"`
+	FechaDesde  time.Time `json:"fecha_desde"`
+	FechaHasta  time.Time `json:"fecha_hasta"`
+}
+
+// VigenteEn indica si la alerta está activa y dentro de su período en la fecha indicada.
+func (a AlertaMedica) VigenteEn(fecha time.Time) bool {
+	if !a.Activa {
+		return false
+	}
+	if !a.FechaDesde.IsZero() && fecha.Before(a.FechaDesde) {
+		return false
+	}
+	if !a.FechaHasta.IsZero() && fecha.After(a.FechaHasta) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,76 @@
+package paciente
+
+import "database/sql"
+
+// Queries de postgres: parámetros $1, $2, ..., marcas BOOLEAN y las altas devuelven el ID con RETURNING id
+var (
+	QueryInsertPostgres               = `INSERT INTO paciente(nombre, apellido, domicilio, dni, alta, fecha_nacimiento, email, canal_preferido, acepta_recordatorios, acepta_marketing) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id`
+	QueryDeletePostgres               = `UPDATE paciente SET deleted_at = $1, deleted_by = $2, version = version + 1 WHERE id = $3 AND version = $4 AND deleted_at IS NULL`
+	QueryGetByIdPostgres              = `SELECT id, nombre, apellido, domicilio, dni, alta, fecha_nacimiento, email, canal_preferido, acepta_recordatorios, acepta_marketing, version FROM paciente WHERE id = $1 AND deleted_at IS NULL`
+	QueryUpdatePostgres               = `UPDATE paciente SET nombre = $1, apellido = $2, domicilio = $3, dni = $4, alta = $5, fecha_nacimiento = $6, email = $7, canal_preferido = $8, acepta_recordatorios = $9, acepta_marketing = $10, version = version + 1 WHERE id = $11 AND version = $12 AND deleted_at IS NULL`
+	QueryExistsPostgres               = `SELECT COUNT(*) FROM paciente WHERE id = $1 AND deleted_at IS NULL`
+	QueryGetIdByDniPostgres           = `SELECT id FROM paciente WHERE dni = $1 AND deleted_at IS NULL`
+	QueryGetEliminadoPostgres         = `SELECT id, nombre, apellido, domicilio, dni, alta, fecha_nacimiento, email, canal_preferido, acepta_recordatorios, acepta_marketing, version, deleted_at, deleted_by FROM paciente WHERE id = $1 AND deleted_at IS NOT NULL`
+	QueryRestaurarPostgres            = `UPDATE paciente SET deleted_at = NULL, deleted_by = NULL, version = version + 1 WHERE id = $1 AND deleted_at IS NOT NULL`
+	QueryGetTelefonosPostgres         = `SELECT id_paciente, numero, tipo, principal FROM telefono_paciente WHERE id_paciente = $1 ORDER BY principal DESC, id`
+	QueryDeleteTelefonosPostgres      = `DELETE FROM telefono_paciente WHERE id_paciente = $1`
+	QueryInsertTelefonoPostgres       = `INSERT INTO telefono_paciente(id_paciente, numero, tipo, principal) VALUES($1, $2, $3, $4)`
+	QueryGetContactosPostgres         = `SELECT id_paciente, nombre, relacion, telefono FROM contacto_emergencia WHERE id_paciente = $1 ORDER BY id`
+	QueryDeleteContactosPostgres      = `DELETE FROM contacto_emergencia WHERE id_paciente = $1`
+	QueryInsertContactoPostgres       = `INSERT INTO contacto_emergencia(id_paciente, nombre, relacion, telefono) VALUES($1, $2, $3, $4)`
+	QueryGetResponsablesPostgres      = `SELECT id, id_paciente, id_responsable, nombre, dni, relacion, telefono, email, version FROM responsable_paciente WHERE id_paciente = $1 ORDER BY id`
+	QueryGetResponsableByIdPostgres   = `SELECT id, id_paciente, id_responsable, nombre, dni, relacion, telefono, email, version FROM responsable_paciente WHERE id = $1 AND id_paciente = $2`
+	QueryInsertResponsablePostgres    = `INSERT INTO responsable_paciente(id_paciente, id_responsable, nombre, dni, relacion, telefono, email) VALUES($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+	QueryDeleteResponsablePostgres    = `DELETE FROM responsable_paciente WHERE id = $1 AND id_paciente = $2 AND version = $3`
+	QueryExistsResponsablePostgres    = `SELECT COUNT(*) FROM responsable_paciente WHERE id = $1 AND id_paciente = $2`
+	QueryGetAlertasByPacientePostgres = `SELECT id, id_paciente, tipo, descripcion, severidad, activa, fecha_desde, fecha_hasta, version FROM alerta_medica WHERE id_paciente = $1 ORDER BY CASE severidad WHEN 'alta' THEN 0 WHEN 'media' THEN 1 ELSE 2 END, fecha_desde DESC`
+	QueryGetAlertaByIdPostgres        = `SELECT id, id_paciente, tipo, descripcion, severidad, activa, fecha_desde, fecha_hasta, version FROM alerta_medica WHERE id = $1 AND id_paciente = $2`
+	QueryGetAlertasActivasPostgres    = `SELECT id, id_paciente, tipo, descripcion, severidad, activa, fecha_desde, fecha_hasta, version FROM alerta_medica WHERE activa = $1 AND id_paciente IN (%s) ORDER BY id_paciente, CASE severidad WHEN 'alta' THEN 0 WHEN 'media' THEN 1 ELSE 2 END, fecha_desde DESC`
+	QueryInsertAlertaPostgres         = `INSERT INTO alerta_medica(id_paciente, tipo, descripcion, severidad, activa, fecha_desde, fecha_hasta) VALUES($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+	QueryUpdateAlertaPostgres         = `UPDATE alerta_medica SET tipo = $1, descripcion = $2, severidad = $3, activa = $4, fecha_desde = $5, fecha_hasta = $6, version = version + 1 WHERE id = $7 AND id_paciente = $8 AND version = $9`
+	QueryDeleteAlertaPostgres         = `DELETE FROM alerta_medica WHERE id = $1 AND id_paciente = $2 AND version = $3`
+	QueryExistsAlertaPostgres         = `SELECT COUNT(*) FROM alerta_medica WHERE id = $1 AND id_paciente = $2`
+)
+
+var consultasPostgres = consultas{
+	insert:               QueryInsertPostgres,
+	getAll:               QueryGetAll,
+	delete:               QueryDeletePostgres,
+	getById:              QueryGetByIdPostgres,
+	update:               QueryUpdatePostgres,
+	exists:               QueryExistsPostgres,
+	getIdByDni:           QueryGetIdByDniPostgres,
+	getEliminados:        QueryGetEliminados,
+	getEliminado:         QueryGetEliminadoPostgres,
+	restaurar:            QueryRestaurarPostgres,
+	getTelefonos:         QueryGetTelefonosPostgres,
+	getAllTelefonos:      QueryGetAllTelefonos,
+	deleteTelefonos:      QueryDeleteTelefonosPostgres,
+	insertTelefono:       QueryInsertTelefonoPostgres,
+	getContactos:         QueryGetContactosPostgres,
+	getAllContactos:      QueryGetAllContactos,
+	deleteContactos:      QueryDeleteContactosPostgres,
+	insertContacto:       QueryInsertContactoPostgres,
+	getResponsables:      QueryGetResponsablesPostgres,
+	getResponsableById:   QueryGetResponsableByIdPostgres,
+	insertResponsable:    QueryInsertResponsablePostgres,
+	deleteResponsable:    QueryDeleteResponsablePostgres,
+	existsResponsable:    QueryExistsResponsablePostgres,
+	getAlertasByPaciente: QueryGetAlertasByPacientePostgres,
+	getAlertaById:        QueryGetAlertaByIdPostgres,
+	getAlertasActivas:    QueryGetAlertasActivasPostgres,
+	insertAlerta:         QueryInsertAlertaPostgres,
+	updateAlerta:         QueryUpdateAlertaPostgres,
+	deleteAlerta:         QueryDeleteAlertaPostgres,
+	existsAlerta:         QueryExistsAlertaPostgres,
+	returning:            true,
+	numerados:            true,
+}
+
+// NewRepositoryPostgres instancia repositorio sobre postgres
+func NewRepositoryPostgres(db *sql.DB) Repository {
+	return &repository{
+		db: db,
+		q:  consultasPostgres,
+	}
+}
@@ -4,53 +4,199 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"time"
+
+	"finalgo/pkg/basedatos"
+	"finalgo/pkg/transaccion"
 )
 
 // Errores
 var (
-	ErrEmptyList = errors.New("la lista de pacientes esta vacia")
-	ErrNotFound  = errors.New("paciente no encontrado")
-	ErrStatement = errors.New("sentencia incorrecta")
-	ErrExec      = errors.New("ejecución SQL incorrecta")
-	ErrLastId    = errors.New("error al obtener el último ID")
+	ErrEmptyList           = errors.New("la lista de pacientes esta vacia")
+	ErrNotFound            = errors.New("paciente no encontrado")
+	ErrStatement           = errors.New("sentencia incorrecta")
+	ErrExec                = errors.New("ejecución SQL incorrecta")
+	ErrLastId              = errors.New("error al obtener el último ID")
+	ErrAlertaNotFound      = errors.New("alerta médica no encontrada")
+	ErrResponsableNotFound = errors.New("responsable no encontrado")
+	ErrVersion             = errors.New("el paciente cambió desde que se leyó, hay que volver a consultarlo")
+	ErrVersionAlerta       = errors.New("la alerta médica cambió desde que se leyó, hay que volver a consultarla")
+	ErrVersionResponsable  = errors.New("el responsable cambió desde que se leyó, hay que volver a consultarlo")
 )
 
-// Queries a usar en cada función
+// Queries a usar en cada función. La baja es lógica: se marca deleted_at y las consultas ignoran a los dados de baja.
+// Toda modificación sube la versión; las que pide un usuario sólo se aplican si la versión es la que él leyó.
 var (
-	QueryInsert     = `INSERT INTO my_db.paciente(nombre, apellido, domicilio, dni, alta) VALUES(?,?,?,?,?)`
-	QueryGetAll     = `SELECT id, nombre, apellido, domicilio, dni, alta FROM my_db.paciente`
-	QueryDelete     = `DELETE FROM my_db.paciente WHERE id = ?`
-	QueryGetById    = `SELECT id, nombre, apellido, domicilio, dni, alta FROM my_db.paciente WHERE id = ?`
-	QueryUpdate     = `UPDATE my_db.paciente SET nombre = ?, apellido = ?, domicilio = ?, dni = ?, alta = ? WHERE id = ?`
-	QueryGetIdByDni = `SELECT id FROM my_db.paciente WHERE dni = ?`
+	QueryInsert     = `INSERT INTO paciente(nombre, apellido, domicilio, dni, alta, fecha_nacimiento, email, canal_preferido, acepta_recordatorios, acepta_marketing) VALUES(?,?,?,?,?,?,?,?,?,?)`
+	QueryGetAll     = `SELECT id, nombre, apellido, domicilio, dni, alta, fecha_nacimiento, email, canal_preferido, acepta_recordatorios, acepta_marketing, version FROM paciente WHERE deleted_at IS NULL ORDER BY id`
+	QueryDelete     = `UPDATE paciente SET deleted_at = ?, deleted_by = ?, version = version + 1 WHERE id = ? AND version = ? AND deleted_at IS NULL`
+	QueryGetById    = `SELECT id, nombre, apellido, domicilio, dni, alta, fecha_nacimiento, email, canal_preferido, acepta_recordatorios, acepta_marketing, version FROM paciente WHERE id = ? AND deleted_at IS NULL`
+	QueryUpdate     = `UPDATE paciente SET nombre = ?, apellido = ?, domicilio = ?, dni = ?, alta = ?, fecha_nacimiento = ?, email = ?, canal_preferido = ?, acepta_recordatorios = ?, acepta_marketing = ?, version = version + 1 WHERE id = ? AND version = ? AND deleted_at IS NULL`
+	QueryExists     = `SELECT COUNT(*) FROM paciente WHERE id = ? AND deleted_at IS NULL`
+	QueryGetIdByDni = `SELECT id FROM paciente WHERE dni = ? AND deleted_at IS NULL`
+
+	QueryGetEliminados = `SELECT id, nombre, apellido, domicilio, dni, alta, fecha_nacimiento, email, canal_preferido, acepta_recordatorios, acepta_marketing, version, deleted_at, deleted_by FROM paciente WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC`
+	QueryGetEliminado  = `SELECT id, nombre, apellido, domicilio, dni, alta, fecha_nacimiento, email, canal_preferido, acepta_recordatorios, acepta_marketing, version, deleted_at, deleted_by FROM paciente WHERE id = ? AND deleted_at IS NOT NULL`
+	QueryRestaurar     = `UPDATE paciente SET deleted_at = NULL, deleted_by = NULL, version = version + 1 WHERE id = ? AND deleted_at IS NOT NULL`
+
+	// teléfonos y contactos de emergencia se reemplazan completos en cada alta o modificación del paciente
+	QueryGetTelefonos    = `SELECT id_paciente, numero, tipo, principal FROM telefono_paciente WHERE id_paciente = ? ORDER BY principal DESC, id`
+	QueryGetAllTelefonos = `SELECT id_paciente, numero, tipo, principal FROM telefono_paciente ORDER BY id_paciente, principal DESC, id`
+	QueryDeleteTelefonos = `DELETE FROM telefono_paciente WHERE id_paciente = ?`
+	QueryInsertTelefono  = `INSERT INTO telefono_paciente(id_paciente, numero, tipo, principal) VALUES(?,?,?,?)`
+	QueryGetContactos    = `SELECT id_paciente, nombre, relacion, telefono FROM contacto_emergencia WHERE id_paciente = ? ORDER BY id`
+	QueryGetAllContactos = `SELECT id_paciente, nombre, relacion, telefono FROM contacto_emergencia ORDER BY id_paciente, id`
+	QueryDeleteContactos = `DELETE FROM contacto_emergencia WHERE id_paciente = ?`
+	QueryInsertContacto  = `INSERT INTO contacto_emergencia(id_paciente, nombre, relacion, telefono) VALUES(?,?,?,?)`
+
+	QueryGetResponsables    = `SELECT id, id_paciente, id_responsable, nombre, dni, relacion, telefono, email, version FROM responsable_paciente WHERE id_paciente = ? ORDER BY id`
+	QueryGetResponsableById = `SELECT id, id_paciente, id_responsable, nombre, dni, relacion, telefono, email, version FROM responsable_paciente WHERE id = ? AND id_paciente = ?`
+	QueryInsertResponsable  = `INSERT INTO responsable_paciente(id_paciente, id_responsable, nombre, dni, relacion, telefono, email) VALUES(?,?,?,?,?,?,?)`
+	QueryDeleteResponsable  = `DELETE FROM responsable_paciente WHERE id = ? AND id_paciente = ? AND version = ?`
+	QueryExistsResponsable  = `SELECT COUNT(*) FROM responsable_paciente WHERE id = ? AND id_paciente = ?`
+
+	// las alertas de alta severidad van primero
+	QueryGetAlertasByPaciente = `SELECT id, id_paciente, tipo, descripcion, severidad, activa, fecha_desde, fecha_hasta, version FROM alerta_medica WHERE id_paciente = ? ORDER BY CASE severidad WHEN 'alta' THEN 0 WHEN 'media' THEN 1 ELSE 2 END, fecha_desde DESC`
+	QueryGetAlertaById        = `SELECT id, id_paciente, tipo, descripcion, severidad, activa, fecha_desde, fecha_hasta, version FROM alerta_medica WHERE id = ? AND id_paciente = ?`
+	QueryGetAlertasActivas    = `SELECT id, id_paciente, tipo, descripcion, severidad, activa, fecha_desde, fecha_hasta, version FROM alerta_medica WHERE activa = ? AND id_paciente IN (%s) ORDER BY id_paciente, CASE severidad WHEN 'alta' THEN 0 WHEN 'media' THEN 1 ELSE 2 END, fecha_desde DESC`
+	QueryInsertAlerta         = `INSERT INTO alerta_medica(id_paciente, tipo, descripcion, severidad, activa, fecha_desde, fecha_hasta) VALUES(?,?,?,?,?,?,?)`
+	QueryUpdateAlerta         = `UPDATE alerta_medica SET tipo = ?, descripcion = ?, severidad = ?, activa = ?, fecha_desde = ?, fecha_hasta = ?, version = version + 1 WHERE id = ? AND id_paciente = ? AND version = ?`
+	QueryDeleteAlerta         = `DELETE FROM alerta_medica WHERE id = ? AND id_paciente = ? AND version = ?`
+	QueryExistsAlerta         = `SELECT COUNT(*) FROM alerta_medica WHERE id = ? AND id_paciente = ?`
 )
 
+// loteAlertas es la cantidad de pacientes por consulta en GetAlertasActivasByPacientes
+const loteAlertas = 500
+
+// consultas son las queries de cada función en el dialecto del motor: MySQL y SQLite usan las de arriba y
+// PostgreSQL las de postgres.go
+type consultas struct {
+	insert               string
+	getAll               string
+	delete               string
+	getById              string
+	update               string
+	exists               string
+	getIdByDni           string
+	getEliminados        string
+	getEliminado         string
+	restaurar            string
+	getTelefonos         string
+	getAllTelefonos      string
+	deleteTelefonos      string
+	insertTelefono       string
+	getContactos         string
+	getAllContactos      string
+	deleteContactos      string
+	insertContacto       string
+	getResponsables      string
+	getResponsableById   string
+	insertResponsable    string
+	deleteResponsable    string
+	existsResponsable    string
+	getAlertasByPaciente string
+	getAlertaById        string
+	getAlertasActivas    string
+	insertAlerta         string
+	updateAlerta         string
+	deleteAlerta         string
+	existsAlerta         string
+	// las altas devuelven el ID con RETURNING id, porque el motor no tiene LastInsertId
+	returning bool
+	// los parámetros van numerados ($1, $2, ...) en lugar de ?
+	numerados bool
+}
+
+var consultasMySQL = consultas{
+	insert:               QueryInsert,
+	getAll:               QueryGetAll,
+	delete:               QueryDelete,
+	getById:              QueryGetById,
+	update:               QueryUpdate,
+	exists:               QueryExists,
+	getIdByDni:           QueryGetIdByDni,
+	getEliminados:        QueryGetEliminados,
+	getEliminado:         QueryGetEliminado,
+	restaurar:            QueryRestaurar,
+	getTelefonos:         QueryGetTelefonos,
+	getAllTelefonos:      QueryGetAllTelefonos,
+	deleteTelefonos:      QueryDeleteTelefonos,
+	insertTelefono:       QueryInsertTelefono,
+	getContactos:         QueryGetContactos,
+	getAllContactos:      QueryGetAllContactos,
+	deleteContactos:      QueryDeleteContactos,
+	insertContacto:       QueryInsertContacto,
+	getResponsables:      QueryGetResponsables,
+	getResponsableById:   QueryGetResponsableById,
+	insertResponsable:    QueryInsertResponsable,
+	deleteResponsable:    QueryDeleteResponsable,
+	existsResponsable:    QueryExistsResponsable,
+	getAlertasByPaciente: QueryGetAlertasByPaciente,
+	getAlertaById:        QueryGetAlertaById,
+	getAlertasActivas:    QueryGetAlertasActivas,
+	insertAlerta:         QueryInsertAlerta,
+	updateAlerta:         QueryUpdateAlerta,
+	deleteAlerta:         QueryDeleteAlerta,
+	existsAlerta:         QueryExistsAlerta,
+}
+
 // defino la interfaz para que se apliquen siempre todos los métodos
 type Repository interface {
 	GetPacienteByID(ctx context.Context, id int) (Paciente, error)
 	GetAll(ctx context.Context) ([]Paciente, error)
 	CreatePaciente(ctx context.Context, p Paciente) (Paciente, error)
 	UpdatePaciente(ctx context.Context, p Paciente) (Paciente, error)
-	DeletePaciente(ctx context.Context, id int) error
+	DeletePaciente(ctx context.Context, id int, version int, fecha time.Time, idUsuario int) error
 	GetPacienteIDByDNI(ctx context.Context, dni string) (int, error)
+	GetEliminados(ctx context.Context) ([]Paciente, error)
+	GetEliminadoByID(ctx context.Context, id int) (Paciente, error)
+	RestaurarPaciente(ctx context.Context, id int) error
+
+	GetAlertasByPaciente(ctx context.Context, idPaciente int) ([]AlertaMedica, error)
+	GetAlertaByID(ctx context.Context, idPaciente int, id int) (AlertaMedica, error)
+	GetAlertasActivasByPacientes(ctx context.Context, idsPaciente []int) ([]AlertaMedica, error)
+	CreateAlerta(ctx context.Context, a AlertaMedica) (AlertaMedica, error)
+	UpdateAlerta(ctx context.Context, a AlertaMedica) (AlertaMedica, error)
+	DeleteAlerta(ctx context.Context, idPaciente int, id int, version int) error
+
+	GetResponsables(ctx context.Context, idPaciente int) ([]Responsable, error)
+	GetResponsableByID(ctx context.Context, idPaciente int, id int) (Responsable, error)
+	CreateResponsable(ctx context.Context, r Responsable) (Responsable, error)
+	DeleteResponsable(ctx context.Context, idPaciente int, id int, version int) error
 }
 
 // estructura repositorio con base de datos mysql
 type repository struct {
 	db *sql.DB
+	q  consultas
 }
 
 // NewRepositoryMySql instancia repositorio
 func NewRepositoryMySql(db *sql.DB) Repository {
 	return &repository{
 		db: db,
+		q:  consultasMySQL,
 	}
 }
 
+// NewRepositorySqlite instancia repositorio sobre sqlite, con las mismas consultas
+func NewRepositorySqlite(db *sql.DB) Repository {
+	return &repository{
+		db: db,
+		q:  consultasMySQL,
+	}
+}
+
+// conexion devuelve la transacción de la unidad de trabajo en curso, si la hay, o la base
+func (r *repository) conexion(ctx context.Context) transaccion.Ejecutor {
+	return transaccion.Conexion(ctx, r.db)
+}
+
 // obtener todos los pacientes:
 func (r *repository) GetAll(ctx context.Context) ([]Paciente, error) {
 	// ejecuto la query que trae todos los datos
-	rows, err := r.db.Query(QueryGetAll)
+	rows, err := r.conexion(ctx).QueryContext(ctx, r.q.getAll)
 
 	// si hay error de query, lo devuelvo
 	if err != nil {
@@ -62,15 +208,7 @@ func (r *repository) GetAll(ctx context.Context) ([]Paciente, error) {
 	var pacientes []Paciente
 	// voy poblando el listado de pacientes
 	for rows.Next() {
-		var paciente Paciente
-		err := rows.Scan(
-			&paciente.ID,
-			&paciente.Nombre,
-			&paciente.Apellido,
-			&paciente.Domicilio,
-			&paciente.DNI,
-			&paciente.Alta,
-		)
+		paciente, err := scanPaciente(rows)
 		if err != nil {
 			return []Paciente{}, ErrExec
 		}
@@ -82,6 +220,20 @@ func (r *repository) GetAll(ctx context.Context) ([]Paciente, error) {
 		return []Paciente{}, ErrExec
 	}
 
+	// traigo teléfonos y contactos de todos en una sola consulta cada uno y los reparto por paciente
+	telefonos, err := r.getTelefonos(ctx, r.q.getAllTelefonos)
+	if err != nil {
+		return []Paciente{}, ErrExec
+	}
+	contactos, err := r.getContactos(ctx, r.q.getAllContactos)
+	if err != nil {
+		return []Paciente{}, ErrExec
+	}
+	for i := range pacientes {
+		pacientes[i].Telefonos = conDefault(telefonos[pacientes[i].ID])
+		pacientes[i].ContactosEmergencia = conDefault(contactos[pacientes[i].ID])
+	}
+
 	// devuelvo el resultado
 	return pacientes, nil
 }
@@ -89,32 +241,33 @@ func (r *repository) GetAll(ctx context.Context) ([]Paciente, error) {
 // obtener pacientes por ID
 func (r *repository) GetPacienteByID(ctx context.Context, id int) (Paciente, error) {
 	// ejecuto la query de búsqueda por ID
-	row := r.db.QueryRow(QueryGetById, id)
-
-	// creo la variable que guarde (muestre) el resultado
-	var paciente Paciente
+	row := r.conexion(ctx).QueryRowContext(ctx, r.q.getById, id)
 
 	// verifico si obtengo algún error en los datos
-	err := row.Scan(
-		&paciente.ID,
-		&paciente.Nombre,
-		&paciente.Apellido,
-		&paciente.Domicilio,
-		&paciente.DNI,
-		&paciente.Alta,
-	)
+	paciente, err := scanPaciente(row)
 
 	// devuelvo el error o el paciente
 	if err != nil {
 		return Paciente{}, ErrNotFound
 	}
+
+	telefonos, err := r.getTelefonos(ctx, r.q.getTelefonos, id)
+	if err != nil {
+		return Paciente{}, ErrExec
+	}
+	contactos, err := r.getContactos(ctx, r.q.getContactos, id)
+	if err != nil {
+		return Paciente{}, ErrExec
+	}
+	paciente.Telefonos = conDefault(telefonos[id])
+	paciente.ContactosEmergencia = conDefault(contactos[id])
 	return paciente, nil
 }
 
 // obtener ID del paciente por DNI
 func (r *repository) GetPacienteIDByDNI(ctx context.Context, dni string) (int, error) {
 	// ejecuto la query de búsqueda por ID
-	row := r.db.QueryRow(QueryGetIdByDni, dni)
+	row := r.conexion(ctx).QueryRowContext(ctx, r.q.getIdByDni, dni)
 
 	// creo la variable que guarde (muestre) el resultado
 	var paciente Paciente
@@ -131,25 +284,30 @@ func (r *repository) GetPacienteIDByDNI(ctx context.Context, dni string) (int, e
 	return paciente.ID, nil
 }
 
-// crear paciente en BD
+// crear paciente en BD, junto con sus teléfonos y contactos de emergencia en la misma transacción
 func (r *repository) CreatePaciente(ctx context.Context, paciente Paciente) (Paciente, error) {
-	// ejecuto la query
-	statement, err := r.db.Prepare(QueryInsert)
-
-	// verifico error de ejecución de query
+	tx, err := transaccion.Begin(ctx, r.db)
 	if err != nil {
 		return Paciente{}, ErrStatement
 	}
-
-	defer statement.Close()
+	defer tx.Rollback()
 
 	// paso los parámetros para que se ejecute la query
-	result, err := statement.Exec(
+	result, err := basedatos.Insertar(
+		ctx,
+		tx,
+		r.q.returning,
+		r.q.insert,
 		paciente.Nombre,
 		paciente.Apellido,
 		paciente.Domicilio,
 		paciente.DNI,
 		paciente.Alta,
+		nullTime(paciente.FechaNacimiento),
+		paciente.Email,
+		paciente.CanalPreferido,
+		paciente.AceptaRecordatorios,
+		paciente.AceptaMarketing,
 	)
 
 	// verifico error de ejecución de query
@@ -163,28 +321,41 @@ func (r *repository) CreatePaciente(ctx context.Context, paciente Paciente) (Pac
 		return Paciente{}, ErrLastId
 	}
 	paciente.ID = int(lastId)
+	paciente.Version = 1
+
+	if err := r.guardarContacto(ctx, tx, paciente); err != nil {
+		return Paciente{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return Paciente{}, ErrExec
+	}
 	return paciente, nil
 }
 
-// actualizar un registro
+// actualizar un registro, reemplazando teléfonos y contactos de emergencia en la misma transacción
 func (r *repository) UpdatePaciente(ctx context.Context, paciente Paciente) (Paciente, error) {
-	// preparo query para actualizar campos
-	statement, err := r.db.Prepare(QueryUpdate)
-
-	// por problemas de query, devuelve error
+	tx, err := transaccion.Begin(ctx, r.db)
 	if err != nil {
 		return Paciente{}, ErrStatement
 	}
-	defer statement.Close()
+	defer tx.Rollback()
 
 	// paso los parámetros para que se ejecute la query
-	result, err := statement.Exec(
+	result, err := tx.ExecContext(
+		ctx,
+		r.q.update,
 		paciente.Nombre,
 		paciente.Apellido,
 		paciente.Domicilio,
 		paciente.DNI,
 		paciente.Alta,
+		nullTime(paciente.FechaNacimiento),
+		paciente.Email,
+		paciente.CanalPreferido,
+		paciente.AceptaRecordatorios,
+		paciente.AceptaMarketing,
 		paciente.ID,
+		paciente.Version,
 	)
 
 	// verifico error de parámetros
@@ -192,22 +363,31 @@ func (r *repository) UpdatePaciente(ctx context.Context, paciente Paciente) (Pac
 		return Paciente{}, ErrStatement
 	}
 
-	// ejecuto query
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
+	// la versión cambia siempre, así que 0 filas afectadas es que no existe o que otro lo modificó antes
+	if err := verificarVersion(ctx, tx, result, ErrNotFound, ErrVersion, r.q.exists, paciente.ID); err != nil {
+		return Paciente{}, err
+	}
+	paciente.Version++
+
+	if _, err := tx.ExecContext(ctx, r.q.deleteTelefonos, paciente.ID); err != nil {
 		return Paciente{}, ErrExec
 	}
-	if rowsAffected < 1 {
-		return Paciente{}, ErrNotFound
+	if _, err := tx.ExecContext(ctx, r.q.deleteContactos, paciente.ID); err != nil {
+		return Paciente{}, ErrExec
+	}
+	if err := r.guardarContacto(ctx, tx, paciente); err != nil {
+		return Paciente{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return Paciente{}, ErrExec
 	}
-
 	return paciente, nil
 }
 
-// eliminar registro
-func (r *repository) DeletePaciente(ctx context.Context, id int) error {
+// dar de baja el registro: queda en la base marcado con la fecha y el usuario de la baja
+func (r *repository) DeletePaciente(ctx context.Context, id int, version int, fecha time.Time, idUsuario int) error {
 	// ejecuto query
-	result, err := r.db.Exec(QueryDelete, id)
+	result, err := r.conexion(ctx).ExecContext(ctx, r.q.delete, fecha, nullID(idUsuario), id, version)
 
 	// verifico error
 	if err != nil {
@@ -215,6 +395,85 @@ func (r *repository) DeletePaciente(ctx context.Context, id int) error {
 	}
 
 	// verifico filas afectadas
+	return verificarVersion(ctx, r.conexion(ctx), result, ErrNotFound, ErrVersion, r.q.exists, id)
+}
+
+// obtener los pacientes dados de baja, del más reciente al más viejo
+func (r *repository) GetEliminados(ctx context.Context) ([]Paciente, error) {
+	rows, err := r.conexion(ctx).QueryContext(ctx, r.q.getEliminados)
+	if err != nil {
+		return []Paciente{}, ErrStatement
+	}
+	defer rows.Close()
+
+	pacientes := []Paciente{}
+	for rows.Next() {
+		var deletedAt sql.NullTime
+		var deletedBy sql.NullInt64
+		paciente, err := scanPaciente(rows, &deletedAt, &deletedBy)
+		if err != nil {
+			return []Paciente{}, ErrExec
+		}
+		paciente.DeletedAt = &deletedAt.Time
+		paciente.DeletedBy = int(deletedBy.Int64)
+		pacientes = append(pacientes, paciente)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []Paciente{}, ErrExec
+	}
+
+	telefonos, err := r.getTelefonos(ctx, r.q.getAllTelefonos)
+	if err != nil {
+		return []Paciente{}, ErrExec
+	}
+	contactos, err := r.getContactos(ctx, r.q.getAllContactos)
+	if err != nil {
+		return []Paciente{}, ErrExec
+	}
+	for i := range pacientes {
+		pacientes[i].Telefonos = conDefault(telefonos[pacientes[i].ID])
+		pacientes[i].ContactosEmergencia = conDefault(contactos[pacientes[i].ID])
+	}
+	return pacientes, nil
+}
+
+// obtener un paciente dado de baja por ID, con la fecha y el usuario de la baja
+func (r *repository) GetEliminadoByID(ctx context.Context, id int) (Paciente, error) {
+	row := r.conexion(ctx).QueryRowContext(ctx, r.q.getEliminado, id)
+
+	var deletedAt sql.NullTime
+	var deletedBy sql.NullInt64
+	paciente, err := scanPaciente(row, &deletedAt, &deletedBy)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Paciente{}, ErrNotFound
+	}
+	if err != nil {
+		return Paciente{}, ErrExec
+	}
+	paciente.DeletedAt = &deletedAt.Time
+	paciente.DeletedBy = int(deletedBy.Int64)
+
+	telefonos, err := r.getTelefonos(ctx, r.q.getTelefonos, id)
+	if err != nil {
+		return Paciente{}, ErrExec
+	}
+	contactos, err := r.getContactos(ctx, r.q.getContactos, id)
+	if err != nil {
+		return Paciente{}, ErrExec
+	}
+	paciente.Telefonos = conDefault(telefonos[id])
+	paciente.ContactosEmergencia = conDefault(contactos[id])
+	return paciente, nil
+}
+
+// quitar la marca de baja del paciente
+func (r *repository) RestaurarPaciente(ctx context.Context, id int) error {
+	result, err := r.conexion(ctx).ExecContext(ctx, r.q.restaurar, id)
+	if err != nil {
+		return ErrStatement
+	}
+
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return ErrExec
@@ -222,6 +481,377 @@ func (r *repository) DeletePaciente(ctx context.Context, id int) error {
 	if rowsAffected < 1 {
 		return ErrNotFound
 	}
+	return nil
+}
+
+// obtener las alertas médicas del paciente
+func (r *repository) GetAlertasByPaciente(ctx context.Context, idPaciente int) ([]AlertaMedica, error) {
+	rows, err := r.conexion(ctx).QueryContext(ctx, r.q.getAlertasByPaciente, idPaciente)
+	if err != nil {
+		return []AlertaMedica{}, ErrEmptyList
+	}
+	defer rows.Close()
+
+	alertas := []AlertaMedica{}
+	for rows.Next() {
+		alerta, err := scanAlerta(rows)
+		if err != nil {
+			return []AlertaMedica{}, ErrExec
+		}
+		alertas = append(alertas, alerta)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []AlertaMedica{}, ErrExec
+	}
 
+	return alertas, nil
+}
+
+// obtener las alertas marcadas como activas de varios pacientes, ordenadas por paciente y después como las de
+// GetAlertasByPaciente. Van de a loteAlertas pacientes por consulta para no pasar el máximo de parámetros del motor.
+func (r *repository) GetAlertasActivasByPacientes(ctx context.Context, idsPaciente []int) ([]AlertaMedica, error) {
+	alertas := []AlertaMedica{}
+	for inicio := 0; inicio < len(idsPaciente); inicio += loteAlertas {
+		lote := idsPaciente[inicio:]
+		if len(lote) > loteAlertas {
+			lote = lote[:loteAlertas]
+		}
+		args := []interface{}{true}
+		for _, id := range lote {
+			args = append(args, id)
+		}
+		query := fmt.Sprintf(r.q.getAlertasActivas, basedatos.Marcadores(len(lote), r.q.numerados, 2))
+		rows, err := r.conexion(ctx).QueryContext(ctx, query, args...)
+		if err != nil {
+			return []AlertaMedica{}, ErrStatement
+		}
+		for rows.Next() {
+			alerta, err := scanAlerta(rows)
+			if err != nil {
+				rows.Close()
+				return []AlertaMedica{}, ErrExec
+			}
+			alertas = append(alertas, alerta)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return []AlertaMedica{}, ErrExec
+		}
+	}
+	return alertas, nil
+}
+
+// obtener una alerta del paciente por ID
+func (r *repository) GetAlertaByID(ctx context.Context, idPaciente int, id int) (AlertaMedica, error) {
+	alerta, err := scanAlerta(r.conexion(ctx).QueryRowContext(ctx, r.q.getAlertaById, id, idPaciente))
+	if err != nil {
+		return AlertaMedica{}, ErrAlertaNotFound
+	}
+	return alerta, nil
+}
+
+// crear alerta en BD
+func (r *repository) CreateAlerta(ctx context.Context, alerta AlertaMedica) (AlertaMedica, error) {
+	statement, err := r.conexion(ctx).PrepareContext(ctx, r.q.insertAlerta)
+	if err != nil {
+		return AlertaMedica{}, ErrStatement
+	}
+	defer statement.Close()
+
+	result, err := basedatos.InsertarPreparado(
+		ctx,
+		statement,
+		r.q.returning,
+		alerta.IdPaciente,
+		alerta.Tipo,
+		alerta.Descripcion,
+		alerta.Severidad,
+		alerta.Activa,
+		alerta.FechaDesde,
+		nullTime(alerta.FechaHasta),
+	)
+	if err != nil {
+		return AlertaMedica{}, ErrExec
+	}
+
+	// obtengo el ID del registro y lo devuelvo como dato
+	lastId, err := result.LastInsertId()
+	if err != nil {
+		return AlertaMedica{}, ErrLastId
+	}
+	alerta.ID = int(lastId)
+	alerta.Version = 1
+	return alerta, nil
+}
+
+// actualizar una alerta
+func (r *repository) UpdateAlerta(ctx context.Context, alerta AlertaMedica) (AlertaMedica, error) {
+	statement, err := r.conexion(ctx).PrepareContext(ctx, r.q.updateAlerta)
+	if err != nil {
+		return AlertaMedica{}, ErrStatement
+	}
+	defer statement.Close()
+
+	result, err := statement.ExecContext(
+		ctx,
+		alerta.Tipo,
+		alerta.Descripcion,
+		alerta.Severidad,
+		alerta.Activa,
+		alerta.FechaDesde,
+		nullTime(alerta.FechaHasta),
+		alerta.ID,
+		alerta.IdPaciente,
+		alerta.Version,
+	)
+	if err != nil {
+		return AlertaMedica{}, ErrExec
+	}
+	if err := verificarVersion(ctx, r.conexion(ctx), result, ErrAlertaNotFound, ErrVersionAlerta, r.q.existsAlerta, alerta.ID, alerta.IdPaciente); err != nil {
+		return AlertaMedica{}, err
+	}
+	alerta.Version++
+	return alerta, nil
+}
+
+// eliminar alerta
+func (r *repository) DeleteAlerta(ctx context.Context, idPaciente int, id int, version int) error {
+	result, err := r.conexion(ctx).ExecContext(ctx, r.q.deleteAlerta, id, idPaciente, version)
+	if err != nil {
+		return ErrStatement
+	}
+	return verificarVersion(ctx, r.conexion(ctx), result, ErrAlertaNotFound, ErrVersionAlerta, r.q.existsAlerta, id, idPaciente)
+}
+
+// obtener los responsables del paciente
+func (r *repository) GetResponsables(ctx context.Context, idPaciente int) ([]Responsable, error) {
+	rows, err := r.conexion(ctx).QueryContext(ctx, r.q.getResponsables, idPaciente)
+	if err != nil {
+		return []Responsable{}, ErrEmptyList
+	}
+	defer rows.Close()
+
+	responsables := []Responsable{}
+	for rows.Next() {
+		responsable, err := scanResponsable(rows)
+		if err != nil {
+			return []Responsable{}, ErrExec
+		}
+		responsables = append(responsables, responsable)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []Responsable{}, ErrExec
+	}
+	return responsables, nil
+}
+
+// obtener un responsable del paciente por ID
+func (r *repository) GetResponsableByID(ctx context.Context, idPaciente int, id int) (Responsable, error) {
+	responsable, err := scanResponsable(r.conexion(ctx).QueryRowContext(ctx, r.q.getResponsableById, id, idPaciente))
+	if err != nil {
+		return Responsable{}, ErrResponsableNotFound
+	}
+	return responsable, nil
+}
+
+// crear responsable en BD
+func (r *repository) CreateResponsable(ctx context.Context, responsable Responsable) (Responsable, error) {
+	statement, err := r.conexion(ctx).PrepareContext(ctx, r.q.insertResponsable)
+	if err != nil {
+		return Responsable{}, ErrStatement
+	}
+	defer statement.Close()
+
+	// el responsable externo se guarda con id_responsable en NULL
+	result, err := basedatos.InsertarPreparado(
+		ctx,
+		statement,
+		r.q.returning,
+		responsable.IdPaciente,
+		sql.NullInt64{Int64: int64(responsable.IdResponsable), Valid: responsable.IdResponsable != 0},
+		responsable.Nombre,
+		responsable.DNI,
+		responsable.Relacion,
+		responsable.Telefono,
+		responsable.Email,
+	)
+	if err != nil {
+		return Responsable{}, ErrExec
+	}
+
+	lastId, err := result.LastInsertId()
+	if err != nil {
+		return Responsable{}, ErrLastId
+	}
+	responsable.ID = int(lastId)
+	responsable.Version = 1
+	return responsable, nil
+}
+
+// eliminar responsable, sólo si la versión es la leída
+func (r *repository) DeleteResponsable(ctx context.Context, idPaciente int, id int, version int) error {
+	result, err := r.conexion(ctx).ExecContext(ctx, r.q.deleteResponsable, id, idPaciente, version)
+	if err != nil {
+		return ErrExec
+	}
+	return verificarVersion(ctx, r.conexion(ctx), result, ErrResponsableNotFound, ErrVersionResponsable, r.q.existsResponsable, id, idPaciente)
+}
+
+// verificarVersion confirma que una modificación con control de versión se aplicó. Si no afectó filas, la consulta
+// de existencia distingue un registro inexistente (o dado de baja) de uno que otro usuario modificó antes.
+func verificarVersion(ctx context.Context, e transaccion.Ejecutor, result sql.Result, noExiste, cambio error, queryExists string, args ...interface{}) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return ErrExec
+	}
+	if rowsAffected > 0 {
+		return nil
+	}
+	var cantidad int
+	if err := e.QueryRowContext(ctx, queryExists, args...).Scan(&cantidad); err != nil {
+		return ErrExec
+	}
+	if cantidad < 1 {
+		return noExiste
+	}
+	return cambio
+}
+
+// guardarContacto inserta los teléfonos y contactos de emergencia del paciente dentro de la transacción
+func (r *repository) guardarContacto(ctx context.Context, tx transaccion.Ejecutor, paciente Paciente) error {
+	for _, t := range paciente.Telefonos {
+		if _, err := tx.ExecContext(ctx, r.q.insertTelefono, paciente.ID, t.Numero, t.Tipo, t.Principal); err != nil {
+			return ErrExec
+		}
+	}
+	for _, c := range paciente.ContactosEmergencia {
+		if _, err := tx.ExecContext(ctx, r.q.insertContacto, paciente.ID, c.Nombre, c.Relacion, c.Telefono); err != nil {
+			return ErrExec
+		}
+	}
 	return nil
 }
+
+// getTelefonos ejecuta la consulta de teléfonos y los agrupa por paciente
+func (r *repository) getTelefonos(ctx context.Context, query string, args ...interface{}) (map[int][]Telefono, error) {
+	rows, err := r.conexion(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	telefonos := map[int][]Telefono{}
+	for rows.Next() {
+		var idPaciente int
+		var t Telefono
+		if err := rows.Scan(&idPaciente, &t.Numero, &t.Tipo, &t.Principal); err != nil {
+			return nil, err
+		}
+		telefonos[idPaciente] = append(telefonos[idPaciente], t)
+	}
+	return telefonos, rows.Err()
+}
+
+// getContactos ejecuta la consulta de contactos de emergencia y los agrupa por paciente
+func (r *repository) getContactos(ctx context.Context, query string, args ...interface{}) (map[int][]ContactoEmergencia, error) {
+	rows, err := r.conexion(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	contactos := map[int][]ContactoEmergencia{}
+	for rows.Next() {
+		var idPaciente int
+		var c ContactoEmergencia
+		if err := rows.Scan(&idPaciente, &c.Nombre, &c.Relacion, &c.Telefono); err != nil {
+			return nil, err
+		}
+		contactos[idPaciente] = append(contactos[idPaciente], c)
+	}
+	return contactos, rows.Err()
+}
+
+// conDefault devuelve una lista vacía en lugar de nil, así el JSON muestra [] y no null
+func conDefault[T any](lista []T) []T {
+	if lista == nil {
+		return []T{}
+	}
+	return lista
+}
+
+// scanner lo cumplen tanto *sql.Row como *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanPaciente lee las columnas de siempre y, a continuación, las que se pidan en extra
+func scanPaciente(s scanner, extra ...interface{}) (Paciente, error) {
+	var paciente Paciente
+	var fechaNacimiento sql.NullTime
+	err := s.Scan(append([]interface{}{
+		&paciente.ID,
+		&paciente.Nombre,
+		&paciente.Apellido,
+		&paciente.Domicilio,
+		&paciente.DNI,
+		&paciente.Alta,
+		&fechaNacimiento,
+		&paciente.Email,
+		&paciente.CanalPreferido,
+		&paciente.AceptaRecordatorios,
+		&paciente.AceptaMarketing,
+		&paciente.Version,
+	}, extra...)...)
+	paciente.FechaNacimiento = fechaNacimiento.Time
+	return paciente, err
+}
+
+func scanResponsable(s scanner) (Responsable, error) {
+	var responsable Responsable
+	var idResponsable sql.NullInt64
+	err := s.Scan(
+		&responsable.ID,
+		&responsable.IdPaciente,
+		&idResponsable,
+		&responsable.Nombre,
+		&responsable.DNI,
+		&responsable.Relacion,
+		&responsable.Telefono,
+		&responsable.Email,
+		&responsable.Version,
+	)
+	responsable.IdResponsable = int(idResponsable.Int64)
+	return responsable, err
+}
+
+func scanAlerta(s scanner) (AlertaMedica, error) {
+	var alerta AlertaMedica
+	var fechaHasta sql.NullTime
+	err := s.Scan(
+		&alerta.ID,
+		&alerta.IdPaciente,
+		&alerta.Tipo,
+		&alerta.Descripcion,
+		&alerta.Severidad,
+		&alerta.Activa,
+		&alerta.FechaDesde,
+		&fechaHasta,
+		&alerta.Version,
+	)
+	alerta.FechaHasta = fechaHasta.Time
+	return alerta, err
+}
+
+// nullTime guarda NULL cuando la fecha no está informada
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// nullID guarda NULL cuando no hay usuario (por ejemplo, una baja hecha por el sistema)
+func nullID(id int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(id), Valid: id != 0}
+}
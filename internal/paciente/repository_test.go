@@ -0,0 +1,31 @@
+package paciente_test
+
+import (
+	"testing"
+
+	"finalgo/internal/contrato/contratotest"
+	"finalgo/internal/paciente"
+	"finalgo/pkg/config"
+)
+
+func TestRepositorySqlite(t *testing.T) {
+	contratotest.Pacientes(t, func(t *testing.T) paciente.Repository {
+		return paciente.NewRepositorySqlite(contratotest.Base(t, config.MotorSQLite))
+	})
+}
+
+// TestRepositoryMySql corre contra la base de PRUEBAS_MYSQL_DSN; sin ella se saltea
+func TestRepositoryMySql(t *testing.T) {
+	contratotest.Requiere(t, config.MotorMySQL)
+	contratotest.Pacientes(t, func(t *testing.T) paciente.Repository {
+		return paciente.NewRepositoryMySql(contratotest.Base(t, config.MotorMySQL))
+	})
+}
+
+// TestRepositoryPostgres corre contra la base de PRUEBAS_POSTGRES_DSN; sin ella se saltea
+func TestRepositoryPostgres(t *testing.T) {
+	contratotest.Requiere(t, config.MotorPostgres)
+	contratotest.Pacientes(t, func(t *testing.T) paciente.Repository {
+		return paciente.NewRepositoryPostgres(contratotest.Base(t, config.MotorPostgres))
+	})
+}
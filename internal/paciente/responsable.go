@@ -0,0 +1,66 @@
+package paciente
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Errores de las reglas de menores y responsables
+var (
+	ErrResponsable         = errors.New("responsable inválido")
+	ErrMenorSinResponsable = errors.New("el paciente es menor de edad y no tiene un responsable cargado")
+	ErrFechaNacimiento     = errors.New("fecha de nacimiento inválida")
+)
+
+// validarFechaNacimiento rechaza fechas futuras o de hace más de 130 años
+func validarFechaNacimiento(p Paciente, ahora time.Time) error {
+	if p.FechaNacimiento.IsZero() {
+		return nil
+	}
+	if p.FechaNacimiento.After(ahora) || p.FechaNacimiento.Before(ahora.AddDate(-130, 0, 0)) {
+		return ErrFechaNacimiento
+	}
+	return nil
+}
+
+// normalizarResponsable valida el responsable externo: nombre, DNI y un teléfono en E.164 son obligatorios
+// porque es a quien se llama para autorizar una prestación.
+func normalizarResponsable(r *Responsable) error {
+	r.Nombre = strings.TrimSpace(r.Nombre)
+	r.DNI = strings.TrimSpace(r.DNI)
+	r.Relacion = strings.TrimSpace(r.Relacion)
+	if r.Nombre == "" || r.DNI == "" || r.Relacion == "" {
+		return fmt.Errorf("%w: nombre, DNI y relación son obligatorios", ErrResponsable)
+	}
+
+	telefono, err := NormalizarTelefono(r.Telefono, true)
+	if err != nil {
+		return fmt.Errorf("%w: teléfono %q", ErrResponsable, r.Telefono)
+	}
+	r.Telefono = telefono
+
+	if r.Email != "" {
+		email, err := NormalizarEmail(r.Email)
+		if err != nil {
+			return fmt.Errorf("%w: email %q", ErrResponsable, r.Email)
+		}
+		r.Email = email
+	}
+	return nil
+}
+
+// responsableDesdePaciente copia los datos del paciente que queda a cargo, usando su teléfono principal
+func responsableDesdePaciente(r *Responsable, adulto Paciente) {
+	r.Nombre = adulto.Apellido + ", " + adulto.Nombre
+	r.DNI = adulto.DNI
+	r.Email = adulto.Email
+	r.Telefono = ""
+	for _, t := range adulto.Telefonos {
+		if t.Principal {
+			r.Telefono = t.Numero
+			break
+		}
+	}
+}
@@ -2,7 +2,13 @@ package paciente
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"time"
+
+	"finalgo/pkg/auth"
+	"finalgo/pkg/transaccion"
 )
 
 // defino la interfaz para que se apliquen siempre todos los métodos
@@ -10,19 +16,68 @@ type Service interface {
 	GetPacienteByID(ctx context.Context, id int) (Paciente, error)
 	GetAll(ctx context.Context) ([]Paciente, error)
 	CreatePaciente(ctx context.Context, p PacienteRequest) (Paciente, error)
-	UpdatePaciente(ctx context.Context, p PacienteRequest, id int) (Paciente, error)
-	DeletePaciente(ctx context.Context, id int) error
+	UpdatePaciente(ctx context.Context, p PacienteRequest, id int, version int) (Paciente, error)
+	DeletePaciente(ctx context.Context, id int, version int) error
 	GetPacienteIDByDNI(ctx context.Context, dni string) (int, error)
+	GetEliminados(ctx context.Context) ([]Paciente, error)
+	GetEliminadoByID(ctx context.Context, id int) (Paciente, error)
+	RestaurarPaciente(ctx context.Context, id int) (Paciente, error)
+
+	GetAlertasByPaciente(ctx context.Context, idPaciente int) ([]AlertaMedica, error)
+	GetAlertasActivas(ctx context.Context, idPaciente int) ([]AlertaMedica, error)
+	GetAlertasActivasByPacientes(ctx context.Context, idsPaciente []int) (map[int][]AlertaMedica, error)
+	CreateAlerta(ctx context.Context, a AlertaMedicaRequest, idPaciente int) (AlertaMedica, error)
+	UpdateAlerta(ctx context.Context, a AlertaMedicaRequest, idPaciente int, id int, version int) (AlertaMedica, error)
+	DeleteAlerta(ctx context.Context, idPaciente int, id int, version int) error
+
+	GetResponsables(ctx context.Context, idPaciente int) ([]Responsable, error)
+	GetResponsableByID(ctx context.Context, idPaciente int, id int) (Responsable, error)
+	CreateResponsable(ctx context.Context, r ResponsableRequest, idPaciente int) (Responsable, error)
+	DeleteResponsable(ctx context.Context, idPaciente int, id int, version int) error
+	VerificarResponsable(ctx context.Context, idPaciente int, fecha time.Time) error
+}
+
+// entidades que se registran en la auditoría
+const (
+	entidadPaciente    = "paciente"
+	entidadAlerta      = "alerta_medica"
+	entidadResponsable = "responsable"
+)
+
+// Auditoria registra quién cambió qué y cómo estaba antes. Lo implementa auditoria.Service; se define acá para que
+// paciente no dependa de ese paquete.
+type Auditoria interface {
+	Alta(ctx context.Context, entidad string, id int, despues interface{}) error
+	Modificacion(ctx context.Context, entidad string, id int, antes, despues interface{}) error
+	Baja(ctx context.Context, entidad string, id int, antes interface{}) error
+	Restauracion(ctx context.Context, entidad string, id int, despues interface{}) error
 }
 
-// estrucutra service que contará con un repositorio
+// estrucutra service que contará con un repositorio. Cada cambio y su registro de auditoría van en una misma unidad
+// de trabajo.
 type service struct {
-	r Repository
+	r   Repository
+	uow transaccion.UnitOfWork
+	a   Auditoria
 }
 
 // función para instanciar service
-func NewService(r Repository) Service {
-	return &service{r}
+func NewService(r Repository, uow transaccion.UnitOfWork, a Auditoria) Service {
+	return &service{r, uow, a}
+}
+
+// auditado aplica el cambio y lo registra en la auditoría en una sola transacción: si el registro falla, el cambio se
+// deshace y el error es ErrExec. Los errores del cambio vuelven tal cual.
+func (s *service) auditado(ctx context.Context, cambio func(ctx context.Context) error, registro func(ctx context.Context) error) error {
+	return s.uow.Ejecutar(ctx, func(ctx context.Context) error {
+		if err := cambio(ctx); err != nil {
+			return err
+		}
+		if err := registro(ctx); err != nil {
+			return ErrExec
+		}
+		return nil
+	})
 }
 
 // aplico todos los métodos de la interfaz, llamando a su correspondiente método del repositorio (todos conservan el mismo nombre):
@@ -33,6 +88,10 @@ func (s *service) GetAll(ctx context.Context) ([]Paciente, error) {
 		log.Println("log de error en service de pacientes", err.Error())
 		return []Paciente{}, ErrEmptyList
 	}
+	ahora := time.Now()
+	for i := range pacientes {
+		conEdad(&pacientes[i], ahora)
+	}
 	return pacientes, nil
 }
 
@@ -42,6 +101,13 @@ func (s *service) GetPacienteByID(ctx context.Context, id int) (Paciente, error)
 		log.Println("log de error por paciente inexistente", err.Error())
 		return Paciente{}, ErrNotFound
 	}
+	conEdad(&p, time.Now())
+
+	// las alertas activas viajan siempre con el paciente
+	p.Alertas, err = s.GetAlertasActivas(ctx, id)
+	if err != nil {
+		return Paciente{}, err
+	}
 	return p, nil
 }
 
@@ -57,36 +123,385 @@ func (s *service) GetPacienteIDByDNI(ctx context.Context, dni string) (int, erro
 func (s *service) CreatePaciente(ctx context.Context, pacienteRequest PacienteRequest) (Paciente, error) {
 	// uso la estructura de request para mejor manejo de campos (no tiene el ID), llamando a una función que lo transforma en el dato que requiere la DB
 	paciente := requestToPaciente(pacienteRequest)
-	response, err := s.r.CreatePaciente(ctx, paciente)
+	// normalizo teléfonos y correo antes de guardar, así los recordatorios no fallan por formato
+	if err := normalizarContacto(&paciente); err != nil {
+		return Paciente{}, err
+	}
+	if err := validarFechaNacimiento(paciente, time.Now()); err != nil {
+		return Paciente{}, err
+	}
+	var response Paciente
+	err := s.auditado(ctx, func(ctx context.Context) (err error) {
+		response, err = s.r.CreatePaciente(ctx, paciente)
+		return err
+	}, func(ctx context.Context) error {
+		return s.a.Alta(ctx, entidadPaciente, response.ID, response)
+	})
 	if err != nil {
 		log.Println("error al crear paciente")
 		return Paciente{}, ErrExec
 	}
+	conEdad(&response, time.Now())
 	return response, nil
 }
 
-func (s *service) DeletePaciente(ctx context.Context, id int) error {
-	err := s.r.DeletePaciente(ctx, id)
+// DeletePaciente da de baja al paciente sólo si sigue en la versión que leyó quien lo pide
+func (s *service) DeletePaciente(ctx context.Context, id int, version int) error {
+	antes, err := s.r.GetPacienteByID(ctx, id)
+	if err != nil {
+		log.Println("log de error por paciente inexistente", err.Error())
+		return ErrNotFound
+	}
+	err = s.auditado(ctx, func(ctx context.Context) error {
+		return s.r.DeletePaciente(ctx, id, version, transaccion.Momento(ctx), auth.IdUsuarioDesde(ctx))
+	}, func(ctx context.Context) error {
+		return s.a.Baja(ctx, entidadPaciente, id, antes)
+	})
 	if err != nil {
 		log.Println("log de error borrado de paciente", err.Error())
+		if errors.Is(err, ErrVersion) || errors.Is(err, ErrExec) {
+			return err
+		}
 		return ErrNotFound
 	}
 	return nil
 }
 
+// GetEliminados devuelve los pacientes dados de baja, con la fecha y el usuario de la baja
+func (s *service) GetEliminados(ctx context.Context) ([]Paciente, error) {
+	pacientes, err := s.r.GetEliminados(ctx)
+	if err != nil {
+		log.Println("log de error en pacientes dados de baja", err.Error())
+		return []Paciente{}, ErrExec
+	}
+	return pacientes, nil
+}
+
+// GetEliminadoByID devuelve un paciente dado de baja, con la fecha y el usuario de la baja
+func (s *service) GetEliminadoByID(ctx context.Context, id int) (Paciente, error) {
+	p, err := s.r.GetEliminadoByID(ctx, id)
+	if err != nil {
+		log.Println("log de error por paciente dado de baja", err.Error())
+		if errors.Is(err, ErrNotFound) {
+			return Paciente{}, ErrNotFound
+		}
+		return Paciente{}, ErrExec
+	}
+	return p, nil
+}
+
+// RestaurarPaciente vuelve a dar de alta a un paciente dado de baja
+func (s *service) RestaurarPaciente(ctx context.Context, id int) (Paciente, error) {
+	var p Paciente
+	err := s.auditado(ctx, func(ctx context.Context) (err error) {
+		if err := s.r.RestaurarPaciente(ctx, id); err != nil {
+			return err
+		}
+		p, err = s.GetPacienteByID(ctx, id)
+		return err
+	}, func(ctx context.Context) error {
+		return s.a.Restauracion(ctx, entidadPaciente, id, p)
+	})
+	if err != nil {
+		log.Println("log de error al restaurar paciente", err.Error())
+		if errors.Is(err, ErrNotFound) {
+			return Paciente{}, ErrNotFound
+		}
+		return Paciente{}, ErrExec
+	}
+	return p, nil
+}
+
 // este método está preparado para ser usado como PATCH o como PUT, se le deberá pasar desde el handler el paciente completo
-func (s *service) UpdatePaciente(ctx context.Context, p PacienteRequest, id int) (Paciente, error) {
+// y la versión que se leyó: si otro lo modificó mientras tanto, no se pisa su cambio
+func (s *service) UpdatePaciente(ctx context.Context, p PacienteRequest, id int, version int) (Paciente, error) {
 	// uso la estructura de request para mejor manejo de campos (no tiene el ID), llamando a una función que lo transforma en el dato que requiere la DB
 	paciente := requestToPaciente(p)
 	paciente.ID = id
-	response, err := s.r.UpdatePaciente(ctx, paciente)
+	paciente.Version = version
+	if err := normalizarContacto(&paciente); err != nil {
+		return Paciente{}, err
+	}
+	if err := validarFechaNacimiento(paciente, time.Now()); err != nil {
+		return Paciente{}, err
+	}
+	antes, err := s.r.GetPacienteByID(ctx, id)
+	if err != nil {
+		log.Println("log de error por paciente inexistente", err.Error())
+		return Paciente{}, ErrNotFound
+	}
+	var response Paciente
+	err = s.auditado(ctx, func(ctx context.Context) (err error) {
+		response, err = s.r.UpdatePaciente(ctx, paciente)
+		return err
+	}, func(ctx context.Context) error {
+		return s.a.Modificacion(ctx, entidadPaciente, id, antes, response)
+	})
 	if err != nil {
 		log.Println("error al actualizar paciente")
+		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrVersion) {
+			return Paciente{}, err
+		}
 		return Paciente{}, ErrExec
 	}
+	conEdad(&response, time.Now())
+	return response, nil
+}
+
+func (s *service) GetAlertasByPaciente(ctx context.Context, idPaciente int) ([]AlertaMedica, error) {
+	alertas, err := s.r.GetAlertasByPaciente(ctx, idPaciente)
+	if err != nil {
+		log.Println("log de error en alertas del paciente", err.Error())
+		return []AlertaMedica{}, ErrEmptyList
+	}
+	return alertas, nil
+}
+
+// GetAlertasActivas devuelve sólo las alertas vigentes hoy, que son las que se muestran en el sillón.
+func (s *service) GetAlertasActivas(ctx context.Context, idPaciente int) ([]AlertaMedica, error) {
+	alertas, err := s.GetAlertasByPaciente(ctx, idPaciente)
+	if err != nil {
+		return []AlertaMedica{}, err
+	}
+
+	ahora := time.Now()
+	activas := []AlertaMedica{}
+	for _, a := range alertas {
+		if a.VigenteEn(ahora) {
+			activas = append(activas, a)
+		}
+	}
+	return activas, nil
+}
+
+// GetAlertasActivasByPacientes es GetAlertasActivas para varios pacientes con una sola consulta, para los listados.
+// Los pacientes sin alertas vigentes no aparecen en el mapa.
+func (s *service) GetAlertasActivasByPacientes(ctx context.Context, idsPaciente []int) (map[int][]AlertaMedica, error) {
+	alertas, err := s.r.GetAlertasActivasByPacientes(ctx, idsPaciente)
+	if err != nil {
+		log.Println("log de error en alertas de los pacientes", err.Error())
+		return map[int][]AlertaMedica{}, ErrExec
+	}
+
+	ahora := time.Now()
+	porPaciente := map[int][]AlertaMedica{}
+	for _, a := range alertas {
+		if a.VigenteEn(ahora) {
+			porPaciente[a.IdPaciente] = append(porPaciente[a.IdPaciente], a)
+		}
+	}
+	return porPaciente, nil
+}
+
+func (s *service) CreateAlerta(ctx context.Context, alertaRequest AlertaMedicaRequest, idPaciente int) (AlertaMedica, error) {
+	if _, err := s.r.GetPacienteByID(ctx, idPaciente); err != nil {
+		log.Println("log de error por paciente inexistente", err.Error())
+		return AlertaMedica{}, ErrNotFound
+	}
+
+	alerta := requestToAlerta(alertaRequest, AlertaMedica{Activa: true})
+	alerta.IdPaciente = idPaciente
+	if alerta.FechaDesde.IsZero() {
+		alerta.FechaDesde = time.Now()
+	}
+	var response AlertaMedica
+	err := s.auditado(ctx, func(ctx context.Context) (err error) {
+		response, err = s.r.CreateAlerta(ctx, alerta)
+		return err
+	}, func(ctx context.Context) error {
+		return s.a.Alta(ctx, entidadAlerta, response.ID, response)
+	})
+	if err != nil {
+		log.Println("error al crear alerta médica")
+		return AlertaMedica{}, ErrExec
+	}
+	return response, nil
+}
+
+func (s *service) UpdateAlerta(ctx context.Context, alertaRequest AlertaMedicaRequest, idPaciente int, id int, version int) (AlertaMedica, error) {
+	original, err := s.r.GetAlertaByID(ctx, idPaciente, id)
+	if err != nil {
+		log.Println("log de error por alerta inexistente", err.Error())
+		return AlertaMedica{}, ErrAlertaNotFound
+	}
+
+	alerta := requestToAlerta(alertaRequest, original)
+	if alerta.FechaDesde.IsZero() {
+		alerta.FechaDesde = original.FechaDesde
+	}
+	alerta.Version = version
+	var response AlertaMedica
+	err = s.auditado(ctx, func(ctx context.Context) (err error) {
+		response, err = s.r.UpdateAlerta(ctx, alerta)
+		return err
+	}, func(ctx context.Context) error {
+		return s.a.Modificacion(ctx, entidadAlerta, id, original, response)
+	})
+	if err != nil {
+		log.Println("error al actualizar alerta médica")
+		if errors.Is(err, ErrAlertaNotFound) || errors.Is(err, ErrVersionAlerta) {
+			return AlertaMedica{}, err
+		}
+		return AlertaMedica{}, ErrExec
+	}
+	return response, nil
+}
+
+func (s *service) DeleteAlerta(ctx context.Context, idPaciente int, id int, version int) error {
+	antes, err := s.r.GetAlertaByID(ctx, idPaciente, id)
+	if err != nil {
+		log.Println("log de error por alerta inexistente", err.Error())
+		return ErrAlertaNotFound
+	}
+	err = s.auditado(ctx, func(ctx context.Context) error {
+		return s.r.DeleteAlerta(ctx, idPaciente, id, version)
+	}, func(ctx context.Context) error {
+		return s.a.Baja(ctx, entidadAlerta, id, antes)
+	})
+	if err != nil {
+		log.Println("log de error borrado de alerta médica", err.Error())
+		if errors.Is(err, ErrVersionAlerta) || errors.Is(err, ErrExec) {
+			return err
+		}
+		return ErrAlertaNotFound
+	}
+	return nil
+}
+
+func (s *service) GetResponsables(ctx context.Context, idPaciente int) ([]Responsable, error) {
+	responsables, err := s.r.GetResponsables(ctx, idPaciente)
+	if err != nil {
+		log.Println("log de error en responsables del paciente", err.Error())
+		return []Responsable{}, ErrEmptyList
+	}
+	return responsables, nil
+}
+
+func (s *service) GetResponsableByID(ctx context.Context, idPaciente int, id int) (Responsable, error) {
+	responsable, err := s.r.GetResponsableByID(ctx, idPaciente, id)
+	if err != nil {
+		log.Println("log de error por responsable inexistente", err.Error())
+		return Responsable{}, ErrResponsableNotFound
+	}
+	return responsable, nil
+}
+
+// CreateResponsable vincula al paciente con el adulto a cargo. Si el responsable es otro paciente tiene que ser
+// mayor de edad y se copian sus datos; si es externo se validan los datos que vienen en el request.
+func (s *service) CreateResponsable(ctx context.Context, responsableRequest ResponsableRequest, idPaciente int) (Responsable, error) {
+	if _, err := s.r.GetPacienteByID(ctx, idPaciente); err != nil {
+		log.Println("log de error por paciente inexistente", err.Error())
+		return Responsable{}, ErrNotFound
+	}
+
+	responsable := Responsable{
+		IdPaciente:    idPaciente,
+		IdResponsable: responsableRequest.IdResponsable,
+		Nombre:        responsableRequest.Nombre,
+		DNI:           responsableRequest.DNI,
+		Relacion:      responsableRequest.Relacion,
+		Telefono:      responsableRequest.Telefono,
+		Email:         responsableRequest.Email,
+	}
+
+	if responsable.IdResponsable != 0 {
+		if responsable.IdResponsable == idPaciente {
+			return Responsable{}, fmt.Errorf("%w: el paciente no puede ser su propio responsable", ErrResponsable)
+		}
+		adulto, err := s.r.GetPacienteByID(ctx, responsable.IdResponsable)
+		if err != nil {
+			return Responsable{}, fmt.Errorf("%w: el paciente %d no existe", ErrResponsable, responsable.IdResponsable)
+		}
+		if adulto.EsMenorEn(time.Now()) {
+			return Responsable{}, fmt.Errorf("%w: el responsable tiene que ser mayor de edad", ErrResponsable)
+		}
+		responsableDesdePaciente(&responsable, adulto)
+		if responsable.Relacion == "" {
+			return Responsable{}, fmt.Errorf("%w: la relación es obligatoria", ErrResponsable)
+		}
+	} else if err := normalizarResponsable(&responsable); err != nil {
+		return Responsable{}, err
+	}
+
+	var response Responsable
+	err := s.auditado(ctx, func(ctx context.Context) (err error) {
+		response, err = s.r.CreateResponsable(ctx, responsable)
+		return err
+	}, func(ctx context.Context) error {
+		return s.a.Alta(ctx, entidadResponsable, response.ID, response)
+	})
+	if err != nil {
+		log.Println("error al crear responsable")
+		return Responsable{}, ErrExec
+	}
 	return response, nil
 }
 
+func (s *service) DeleteResponsable(ctx context.Context, idPaciente int, id int, version int) error {
+	antes, err := s.r.GetResponsableByID(ctx, idPaciente, id)
+	if err != nil {
+		log.Println("log de error por responsable inexistente", err.Error())
+		return ErrResponsableNotFound
+	}
+	err = s.auditado(ctx, func(ctx context.Context) error {
+		return s.r.DeleteResponsable(ctx, idPaciente, id, version)
+	}, func(ctx context.Context) error {
+		return s.a.Baja(ctx, entidadResponsable, id, antes)
+	})
+	if err != nil {
+		log.Println("log de error borrado de responsable", err.Error())
+		if errors.Is(err, ErrVersionResponsable) || errors.Is(err, ErrExec) {
+			return err
+		}
+		return ErrResponsableNotFound
+	}
+	return nil
+}
+
+// VerificarResponsable devuelve ErrMenorSinResponsable si el paciente es menor a la fecha indicada (la del turno o
+// la de la firma) y no tiene ningún responsable cargado.
+func (s *service) VerificarResponsable(ctx context.Context, idPaciente int, fecha time.Time) error {
+	p, err := s.r.GetPacienteByID(ctx, idPaciente)
+	if err != nil {
+		log.Println("log de error por paciente inexistente", err.Error())
+		return ErrNotFound
+	}
+	if !p.EsMenorEn(fecha) {
+		return nil
+	}
+
+	responsables, err := s.r.GetResponsables(ctx, idPaciente)
+	if err != nil {
+		log.Println("log de error en responsables del paciente", err.Error())
+		return ErrExec
+	}
+	if len(responsables) == 0 {
+		return ErrMenorSinResponsable
+	}
+	return nil
+}
+
+// conEdad completa la edad calculada cuando se conoce la fecha de nacimiento
+func conEdad(p *Paciente, ahora time.Time) {
+	if edad, ok := p.EdadEn(ahora); ok {
+		p.Edad = &edad
+	}
+}
+
+// requestToAlerta pisa los datos de base con los del request; Activa sólo cambia si vino informada
+func requestToAlerta(alertaRequest AlertaMedicaRequest, base AlertaMedica) AlertaMedica {
+	alerta := base
+	alerta.Tipo = alertaRequest.Tipo
+	alerta.Descripcion = alertaRequest.Descripcion
+	alerta.Severidad = alertaRequest.Severidad
+	alerta.FechaDesde = alertaRequest.FechaDesde
+	alerta.FechaHasta = alertaRequest.FechaHasta
+	if alertaRequest.Activa != nil {
+		alerta.Activa = *alertaRequest.Activa
+	}
+	return alerta
+}
+
 // función para transformar request en la estructura definida en GO
 func requestToPaciente(pacienteRequest PacienteRequest) Paciente {
 	var paciente Paciente
@@ -95,5 +510,12 @@ func requestToPaciente(pacienteRequest PacienteRequest) Paciente {
 	paciente.Domicilio = pacienteRequest.Domicilio
 	paciente.DNI = pacienteRequest.DNI
 	paciente.Alta = pacienteRequest.Alta
+	paciente.FechaNacimiento = pacienteRequest.FechaNacimiento
+	paciente.Telefonos = pacienteRequest.Telefonos
+	paciente.Email = pacienteRequest.Email
+	paciente.CanalPreferido = pacienteRequest.CanalPreferido
+	paciente.AceptaRecordatorios = pacienteRequest.AceptaRecordatorios
+	paciente.AceptaMarketing = pacienteRequest.AceptaMarketing
+	paciente.ContactosEmergencia = pacienteRequest.ContactosEmergencia
 	return paciente
 }
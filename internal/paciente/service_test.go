@@ -0,0 +1,40 @@
+package paciente_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"finalgo/internal/auditoria"
+	"finalgo/internal/contrato/contratotest"
+	"finalgo/internal/paciente"
+	"finalgo/pkg/config"
+	"finalgo/pkg/transaccion"
+)
+
+// el alta y su registro de auditoría van juntos: si la auditoría no se puede grabar, el paciente no queda dado de alta
+func TestCreatePacienteConAuditoria(t *testing.T) {
+	ctx := context.Background()
+	db := contratotest.Base(t, config.MotorSQLite)
+	registro := auditoria.NewService(auditoria.NewRepositorySqlite(db))
+	s := paciente.NewService(paciente.NewRepositorySqlite(db), transaccion.NewUnitOfWork(db), registro)
+
+	creado, err := s.CreatePaciente(ctx, paciente.PacienteRequest{Nombre: "Ana", Apellido: "Pérez", DNI: "30111222"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	registros, err := registro.Buscar(ctx, auditoria.Filtro{Entidad: "paciente", IdEntidad: creado.ID})
+	if err != nil || len(registros) != 1 {
+		t.Fatalf("el alta tiene que quedar en la auditoría: %+v, %v", registros, err)
+	}
+
+	if _, err := db.ExecContext(ctx, "DROP TABLE auditoria"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreatePaciente(ctx, paciente.PacienteRequest{Nombre: "Luis", Apellido: "Gómez", DNI: "30111333"}); !errors.Is(err, paciente.ErrExec) {
+		t.Fatalf("sin auditoría el alta tiene que fallar, vino %v", err)
+	}
+	if _, err := s.GetPacienteIDByDNI(ctx, "30111333"); err == nil {
+		t.Fatal("el paciente quedó grabado aunque la auditoría falló")
+	}
+}
@@ -0,0 +1,94 @@
+package portal
+
+import "time"
+
+// SolicitudCodigo es el primer paso del ingreso: el paciente informa su DNI y se le envía un código de un solo uso.
+type SolicitudCodigo struct {
+	DNI string `json:"dni"`
+}
+
+// LoginRequest es el segundo paso: DNI y el código recibido.
+type LoginRequest struct {
+	DNI    string `json:"dni"`
+	Codigo string `json:"codigo"`
+}
+
+// Sesion es el token con el que el paciente usa el resto del portal.
+type Sesion struct {
+	Token string    `json:"token"`
+	Vence time.Time `json:"vence"`
+}
+
+// CodigoAcceso es el código de un solo uso enviado al paciente. Sólo se guarda su hash.
+type CodigoAcceso struct {
+	ID         int
+	IdPaciente int
+	Hash       string
+	Vence      time.Time
+}
+
+// acciones del portal que se cuentan para limitar los intentos
+const (
+	AccionCodigo  = "codigo"
+	AccionIngreso = "ingreso"
+)
+
+// Intento es un pedido de código o un intento de ingreso, con el DNI informado (exista o no) y la IP de la que vino.
+type Intento struct {
+	Accion string
+	DNI    string
+	IP     string
+	Fecha  time.Time
+}
+
+// TurnoPortal es la vista del turno que ve el paciente: sin alertas médicas ni advertencias internas.
+type TurnoPortal struct {
+	ID           int       `json:"id"`
+	IdOdontologo int       `json:"id_odontologo"`
+	Odontologo   string    `json:"odontologo"`
+	FechaHora    time.Time `json:"fecha_hora"`
+	Descripcion  string    `json:"descripcion"`
+	Estado       string    `json:"estado"`
+}
+
+// ReservaRequest pide un turno con un odontólogo y horario de la disponibilidad, o el primero libre de la especialidad.
+type ReservaRequest struct {
+	IdOdontologo int       `json:"id_odontologo"`
+	Especialidad string    `json:"especialidad"`
+	FechaHora    time.Time `json:"fecha_hora"`
+	Descripcion  string    `json:"descripcion"`
+}
+
+// Politica son las reglas del portal que fija la clínica.
+type Politica struct {
+	// cuánto dura el código enviado
+	VigenciaCodigo time.Duration
+	// dentro de VentanaIntentos, cuántos códigos se pueden pedir y cuántas veces se puede intentar ingresar por DNI
+	// y por IP. Cuentan todos los intentos, no los de cada código, así pedir otro código no da más intentos.
+	VentanaIntentos time.Duration
+	CodigosPorDNI   int
+	CodigosPorIP    int
+	IngresosPorDNI  int
+	IngresosPorIP   int
+	// cuánto dura la sesión del portal
+	VigenciaSesion time.Duration
+	// los turnos se reservan con al menos AnticipacionMinima y hasta VentanaMaxima hacia adelante
+	AnticipacionMinima time.Duration
+	VentanaMaxima      time.Duration
+}
+
+// PoliticaPorDefecto: código de 10 minutos; por hora, 5 códigos y 5 intentos de ingreso por DNI y 20 de cada uno por
+// IP; sesión de 1 hora y reservas desde 2 horas hasta 60 días antes.
+func PoliticaPorDefecto() Politica {
+	return Politica{
+		VigenciaCodigo:     10 * time.Minute,
+		VentanaIntentos:    time.Hour,
+		CodigosPorDNI:      5,
+		CodigosPorIP:       20,
+		IngresosPorDNI:     5,
+		IngresosPorIP:      20,
+		VigenciaSesion:     time.Hour,
+		AnticipacionMinima: 2 * time.Hour,
+		VentanaMaxima:      60 * 24 * time.Hour,
+	}
+}
@@ -0,0 +1,35 @@
+package portal
+
+import "database/sql"
+
+// Queries de postgres: parámetros $1, $2, ..., marcas BOOLEAN y las altas devuelven el ID con RETURNING id
+var (
+	QueryInvalidarCodigosPostgres      = `UPDATE codigo_portal SET usado = TRUE, version = version + 1 WHERE id_paciente = $1 AND usado = FALSE`
+	QueryInsertCodigoPostgres          = `INSERT INTO codigo_portal(id_paciente, hash, vence, usado) VALUES($1, $2, $3, FALSE) RETURNING id`
+	QueryGetCodigoVigentePostgres      = `SELECT id, id_paciente, hash, vence FROM codigo_portal WHERE id_paciente = $1 AND usado = FALSE AND vence > $2 ORDER BY id DESC LIMIT 1`
+	QueryUsarCodigoPostgres            = `UPDATE codigo_portal SET usado = TRUE, version = version + 1 WHERE id = $1 AND usado = FALSE`
+	QueryInsertIntentoPostgres         = `INSERT INTO intento_portal(accion, dni, ip, fecha) VALUES($1, $2, $3, $4)`
+	QueryCountIntentosByDNIPostgres    = `SELECT COUNT(*) FROM intento_portal WHERE accion = $1 AND dni = $2 AND fecha > $3`
+	QueryCountIntentosByIPPostgres     = `SELECT COUNT(*) FROM intento_portal WHERE accion = $1 AND ip = $2 AND fecha > $3`
+	QueryDeleteIntentosPreviosPostgres = `DELETE FROM intento_portal WHERE fecha < $1`
+)
+
+var consultasPostgres = consultas{
+	invalidarCodigos:      QueryInvalidarCodigosPostgres,
+	insertCodigo:          QueryInsertCodigoPostgres,
+	getCodigoVigente:      QueryGetCodigoVigentePostgres,
+	usarCodigo:            QueryUsarCodigoPostgres,
+	insertIntento:         QueryInsertIntentoPostgres,
+	countIntentosByDNI:    QueryCountIntentosByDNIPostgres,
+	countIntentosByIP:     QueryCountIntentosByIPPostgres,
+	deleteIntentosPrevios: QueryDeleteIntentosPreviosPostgres,
+	returning:             true,
+}
+
+// NewRepositoryPostgres instancia repositorio sobre postgres
+func NewRepositoryPostgres(db *sql.DB) Repository {
+	return &repository{
+		db: db,
+		q:  consultasPostgres,
+	}
+}
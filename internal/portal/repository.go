@@ -0,0 +1,186 @@
+package portal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"finalgo/pkg/basedatos"
+)
+
+// Errores
+var (
+	ErrCodigoNotFound = errors.New("no hay un código vigente para el paciente")
+	ErrStatement      = errors.New("sentencia incorrecta")
+	ErrExec           = errors.New("ejecución SQL incorrecta")
+	ErrLastId         = errors.New("error al obtener el último ID")
+)
+
+// Queries a usar en cada función
+var (
+	QueryInvalidarCodigos = `UPDATE codigo_portal SET usado = 1, version = version + 1 WHERE id_paciente = ? AND usado = 0`
+	QueryInsertCodigo     = `INSERT INTO codigo_portal(id_paciente, hash, vence, usado) VALUES(?,?,?,0)`
+	QueryGetCodigoVigente = `SELECT id, id_paciente, hash, vence FROM codigo_portal WHERE id_paciente = ? AND usado = 0 AND vence > ? ORDER BY id DESC LIMIT 1`
+	QueryUsarCodigo       = `UPDATE codigo_portal SET usado = 1, version = version + 1 WHERE id = ? AND usado = 0`
+
+	QueryInsertIntento         = `INSERT INTO intento_portal(accion, dni, ip, fecha) VALUES(?,?,?,?)`
+	QueryCountIntentosByDNI    = `SELECT COUNT(*) FROM intento_portal WHERE accion = ? AND dni = ? AND fecha > ?`
+	QueryCountIntentosByIP     = `SELECT COUNT(*) FROM intento_portal WHERE accion = ? AND ip = ? AND fecha > ?`
+	QueryDeleteIntentosPrevios = `DELETE FROM intento_portal WHERE fecha < ?`
+)
+
+// consultas son las queries de cada función en el dialecto del motor: MySQL y SQLite usan las de arriba y
+// PostgreSQL las de postgres.go
+type consultas struct {
+	invalidarCodigos      string
+	insertCodigo          string
+	getCodigoVigente      string
+	usarCodigo            string
+	insertIntento         string
+	countIntentosByDNI    string
+	countIntentosByIP     string
+	deleteIntentosPrevios string
+	// las altas devuelven el ID con RETURNING id, porque el motor no tiene LastInsertId
+	returning bool
+}
+
+var consultasMySQL = consultas{
+	invalidarCodigos:      QueryInvalidarCodigos,
+	insertCodigo:          QueryInsertCodigo,
+	getCodigoVigente:      QueryGetCodigoVigente,
+	usarCodigo:            QueryUsarCodigo,
+	insertIntento:         QueryInsertIntento,
+	countIntentosByDNI:    QueryCountIntentosByDNI,
+	countIntentosByIP:     QueryCountIntentosByIP,
+	deleteIntentosPrevios: QueryDeleteIntentosPrevios,
+}
+
+// defino la interfaz para que se apliquen siempre todos los métodos
+type Repository interface {
+	CreateCodigo(ctx context.Context, c CodigoAcceso) (CodigoAcceso, error)
+	GetCodigoVigente(ctx context.Context, idPaciente int, ahora time.Time) (CodigoAcceso, error)
+	UsarCodigo(ctx context.Context, id int) error
+
+	CreateIntento(ctx context.Context, i Intento) error
+	CountIntentosByDNI(ctx context.Context, accion string, dni string, desde time.Time) (int, error)
+	CountIntentosByIP(ctx context.Context, accion string, ip string, desde time.Time) (int, error)
+	DeleteIntentosPrevios(ctx context.Context, antes time.Time) error
+}
+
+// estructura repositorio con base de datos mysql
+type repository struct {
+	db *sql.DB
+	q  consultas
+}
+
+// NewRepositoryMySql instancia repositorio
+func NewRepositoryMySql(db *sql.DB) Repository {
+	return &repository{
+		db: db,
+		q:  consultasMySQL,
+	}
+}
+
+// NewRepositorySqlite instancia repositorio sobre sqlite, con las mismas consultas
+func NewRepositorySqlite(db *sql.DB) Repository {
+	return &repository{
+		db: db,
+		q:  consultasMySQL,
+	}
+}
+
+// crear código: los anteriores del paciente dejan de valer
+func (r *repository) CreateCodigo(ctx context.Context, c CodigoAcceso) (CodigoAcceso, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return CodigoAcceso{}, ErrStatement
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, r.q.invalidarCodigos, c.IdPaciente); err != nil {
+		return CodigoAcceso{}, ErrExec
+	}
+	result, err := basedatos.Insertar(ctx, tx, r.q.returning, r.q.insertCodigo, c.IdPaciente, c.Hash, c.Vence)
+	if err != nil {
+		return CodigoAcceso{}, ErrExec
+	}
+
+	lastId, err := result.LastInsertId()
+	if err != nil {
+		return CodigoAcceso{}, ErrLastId
+	}
+	if err := tx.Commit(); err != nil {
+		return CodigoAcceso{}, ErrExec
+	}
+	c.ID = int(lastId)
+	return c, nil
+}
+
+// obtener el último código sin usar y sin vencer del paciente
+func (r *repository) GetCodigoVigente(ctx context.Context, idPaciente int, ahora time.Time) (CodigoAcceso, error) {
+	var c CodigoAcceso
+	err := r.db.QueryRowContext(ctx, r.q.getCodigoVigente, idPaciente, ahora).Scan(
+		&c.ID,
+		&c.IdPaciente,
+		&c.Hash,
+		&c.Vence,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return CodigoAcceso{}, ErrCodigoNotFound
+	}
+	if err != nil {
+		return CodigoAcceso{}, ErrExec
+	}
+	return c, nil
+}
+
+// marcar el código como usado; si otro pedido lo usó antes no afecta filas y el código ya no vale
+func (r *repository) UsarCodigo(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, r.q.usarCodigo, id)
+	if err != nil {
+		return ErrExec
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return ErrExec
+	}
+	if rowsAffected < 1 {
+		return ErrCodigoNotFound
+	}
+	return nil
+}
+
+// registrar un pedido de código o un intento de ingreso
+func (r *repository) CreateIntento(ctx context.Context, i Intento) error {
+	if _, err := r.db.ExecContext(ctx, r.q.insertIntento, i.Accion, i.DNI, i.IP, i.Fecha); err != nil {
+		return ErrExec
+	}
+	return nil
+}
+
+// contar los intentos de la acción con ese DNI desde la fecha dada
+func (r *repository) CountIntentosByDNI(ctx context.Context, accion string, dni string, desde time.Time) (int, error) {
+	return r.contarIntentos(ctx, r.q.countIntentosByDNI, accion, dni, desde)
+}
+
+// contar los intentos de la acción desde esa IP desde la fecha dada
+func (r *repository) CountIntentosByIP(ctx context.Context, accion string, ip string, desde time.Time) (int, error) {
+	return r.contarIntentos(ctx, r.q.countIntentosByIP, accion, ip, desde)
+}
+
+func (r *repository) contarIntentos(ctx context.Context, query string, accion string, valor string, desde time.Time) (int, error) {
+	var cantidad int
+	if err := r.db.QueryRowContext(ctx, query, accion, valor, desde).Scan(&cantidad); err != nil {
+		return 0, ErrExec
+	}
+	return cantidad, nil
+}
+
+// borrar los intentos anteriores a la fecha, que ya no cuentan para ningún límite
+func (r *repository) DeleteIntentosPrevios(ctx context.Context, antes time.Time) error {
+	if _, err := r.db.ExecContext(ctx, r.q.deleteIntentosPrevios, antes); err != nil {
+		return ErrExec
+	}
+	return nil
+}
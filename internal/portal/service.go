@@ -0,0 +1,417 @@
+package portal
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"finalgo/internal/odontologo"
+	"finalgo/internal/paciente"
+	"finalgo/internal/turno"
+	"finalgo/pkg/notificador"
+)
+
+// Errores del portal
+var (
+	ErrCredenciales     = errors.New("DNI o código incorrectos")
+	ErrToken            = errors.New("sesión inválida o vencida")
+	ErrFueraDeVentana   = errors.New("el horario está fuera del período en que se pueden reservar turnos")
+	ErrTurnoNotFound    = errors.New("turno no encontrado")
+	ErrSinDisponible    = errors.New("no hay turnos libres en el período permitido")
+	ErrSecretoCorto     = errors.New("el secreto del portal tiene que tener al menos 32 bytes")
+	ErrDatosIncompletos = errors.New("falta el odontólogo o la especialidad")
+	ErrDemasiados       = errors.New("demasiados intentos, hay que esperar antes de volver a probar")
+)
+
+// cantidad de bloques que se devuelven como máximo al consultar disponibilidad
+const maxBloques = 20
+
+// largo máximo del DNI que se registra en los intentos; uno más largo no puede ser de un paciente
+const maxDNI = 20
+
+// defino la interfaz para que se apliquen siempre todos los métodos
+type Service interface {
+	SolicitarCodigo(ctx context.Context, dni string, ip string) error
+	IniciarSesion(ctx context.Context, l LoginRequest, ip string) (Sesion, error)
+	Autenticar(token string) (int, error)
+
+	GetTurnos(ctx context.Context, idPaciente int) ([]TurnoPortal, error)
+	GetDisponibilidad(ctx context.Context, idOdontologo int, especialidad string, desde time.Time) ([]turno.Bloque, error)
+	Reservar(ctx context.Context, idPaciente int, r ReservaRequest) (TurnoPortal, error)
+	Confirmar(ctx context.Context, idPaciente int, idTurno int) (TurnoPortal, error)
+	Cancelar(ctx context.Context, idPaciente int, idTurno int) (TurnoPortal, error)
+}
+
+// estrucutra service con los servicios de pacientes, turnos y odontólogos, el notificador y la firma de sesiones
+type service struct {
+	r        Repository
+	ps       paciente.Service
+	ts       turno.Service
+	os       odontologo.Service
+	n        notificador.Notifier
+	secreto  []byte
+	politica Politica
+}
+
+// función para instanciar service. El secreto firma los tokens de sesión y tiene que ser el mismo en todas las instancias.
+func NewService(r Repository, ps paciente.Service, ts turno.Service, os odontologo.Service, n notificador.Notifier, secreto []byte, politica Politica) (Service, error) {
+	if len(secreto) < 32 {
+		return nil, ErrSecretoCorto
+	}
+	return &service{
+		r,
+		ps,
+		ts,
+		os,
+		n,
+		secreto,
+		politica,
+	}, nil
+}
+
+// SolicitarCodigo genera un código de 6 dígitos y lo envía por el canal preferido del paciente. Si el DNI no existe o
+// el paciente no tiene medio de contacto no devuelve error, para no revelar quién es paciente de la clínica. Los
+// pedidos se cuentan por DNI (exista o no) y por IP, y pasado el límite de la política devuelve ErrDemasiados.
+func (s *service) SolicitarCodigo(ctx context.Context, dni string, ip string) error {
+	if len(dni) > maxDNI {
+		log.Println("log de portal: código pedido para DNI inválido")
+		return nil
+	}
+	if err := s.registrarIntento(ctx, AccionCodigo, dni, ip, s.politica.CodigosPorDNI, s.politica.CodigosPorIP); err != nil {
+		return err
+	}
+
+	idPaciente, err := s.ps.GetPacienteIDByDNI(ctx, dni)
+	if err != nil {
+		log.Println("log de portal: código pedido para DNI inexistente")
+		return nil
+	}
+	p, err := s.ps.GetPacienteByID(ctx, idPaciente)
+	if err != nil {
+		log.Println("log de error por paciente inexistente", err.Error())
+		return nil
+	}
+	mensaje, ok := destino(p)
+	if !ok {
+		log.Println("log de portal: el paciente no tiene medio de contacto", idPaciente)
+		return nil
+	}
+
+	codigo, err := generarCodigo()
+	if err != nil {
+		return err
+	}
+	_, err = s.r.CreateCodigo(ctx, CodigoAcceso{
+		IdPaciente: idPaciente,
+		Hash:       s.hashCodigo(idPaciente, codigo),
+		Vence:      time.Now().Add(s.politica.VigenciaCodigo),
+	})
+	if err != nil {
+		log.Println("error al guardar código del portal", err.Error())
+		return ErrExec
+	}
+
+	mensaje.Asunto = "Código de acceso"
+	mensaje.Texto = fmt.Sprintf("Su código para ingresar al portal de turnos es %s. Vence en %d minutos.", codigo, int(s.politica.VigenciaCodigo.Minutes()))
+	if err := s.n.Enviar(ctx, mensaje); err != nil {
+		log.Println("log de error al enviar código del portal", err.Error())
+		return err
+	}
+	return nil
+}
+
+// IniciarSesion valida el código y devuelve el token de sesión. Cada intento se registra antes de comparar el código,
+// así los pedidos simultáneos también cuentan; pasado el límite por DNI o por IP devuelve ErrDemasiados aunque el
+// código sea correcto, y pedir un código nuevo no da más intentos.
+func (s *service) IniciarSesion(ctx context.Context, l LoginRequest, ip string) (Sesion, error) {
+	if len(l.DNI) > maxDNI {
+		return Sesion{}, ErrCredenciales
+	}
+	if err := s.registrarIntento(ctx, AccionIngreso, l.DNI, ip, s.politica.IngresosPorDNI, s.politica.IngresosPorIP); err != nil {
+		return Sesion{}, err
+	}
+
+	idPaciente, err := s.ps.GetPacienteIDByDNI(ctx, l.DNI)
+	if err != nil {
+		return Sesion{}, ErrCredenciales
+	}
+	codigo, err := s.r.GetCodigoVigente(ctx, idPaciente, time.Now())
+	if err != nil {
+		return Sesion{}, ErrCredenciales
+	}
+
+	esperado := []byte(codigo.Hash)
+	recibido := []byte(s.hashCodigo(idPaciente, strings.TrimSpace(l.Codigo)))
+	if subtle.ConstantTimeCompare(esperado, recibido) != 1 {
+		return Sesion{}, ErrCredenciales
+	}
+	if err := s.r.UsarCodigo(ctx, codigo.ID); err != nil {
+		return Sesion{}, ErrCredenciales
+	}
+
+	vence := time.Now().Add(s.politica.VigenciaSesion)
+	return Sesion{Token: s.firmar(idPaciente, vence), Vence: vence}, nil
+}
+
+// registrarIntento guarda el intento y después cuenta los de la ventana, incluido éste: si dos pedidos llegan a la vez
+// cada uno ve al otro, y ninguno pasa el límite por haber contado antes de que el otro se registrara. De paso borra
+// los intentos que ya quedaron fuera de la ventana.
+func (s *service) registrarIntento(ctx context.Context, accion string, dni string, ip string, porDNI int, porIP int) error {
+	ahora := time.Now()
+	if err := s.r.CreateIntento(ctx, Intento{Accion: accion, DNI: dni, IP: ip, Fecha: ahora}); err != nil {
+		log.Println("log de error al registrar intento del portal", err.Error())
+		return ErrExec
+	}
+	desde := ahora.Add(-s.politica.VentanaIntentos)
+	if accion == AccionCodigo {
+		if err := s.r.DeleteIntentosPrevios(ctx, desde); err != nil {
+			log.Println("log de error al borrar intentos viejos del portal", err.Error())
+		}
+	}
+
+	cantidad, err := s.r.CountIntentosByDNI(ctx, accion, dni, desde)
+	if err != nil {
+		log.Println("log de error al contar intentos del portal", err.Error())
+		return ErrExec
+	}
+	if cantidad > porDNI {
+		log.Println("log de portal: demasiados intentos para el DNI", accion)
+		return ErrDemasiados
+	}
+	cantidad, err = s.r.CountIntentosByIP(ctx, accion, ip, desde)
+	if err != nil {
+		log.Println("log de error al contar intentos del portal", err.Error())
+		return ErrExec
+	}
+	if cantidad > porIP {
+		log.Println("log de portal: demasiados intentos desde la IP", accion, ip)
+		return ErrDemasiados
+	}
+	return nil
+}
+
+// Autenticar valida el token de sesión y devuelve el paciente al que pertenece.
+func (s *service) Autenticar(token string) (int, error) {
+	datos, firma, ok := strings.Cut(token, ".")
+	if !ok {
+		return 0, ErrToken
+	}
+	crudo, err := base64.RawURLEncoding.DecodeString(datos)
+	if err != nil {
+		return 0, ErrToken
+	}
+	if !hmac.Equal([]byte(firma), []byte(s.firma(crudo))) {
+		return 0, ErrToken
+	}
+
+	id, venceUnix, ok := strings.Cut(string(crudo), ":")
+	if !ok {
+		return 0, ErrToken
+	}
+	idPaciente, err := strconv.Atoi(id)
+	if err != nil {
+		return 0, ErrToken
+	}
+	vence, err := strconv.ParseInt(venceUnix, 10, 64)
+	if err != nil || time.Now().Unix() >= vence {
+		return 0, ErrToken
+	}
+	return idPaciente, nil
+}
+
+func (s *service) GetTurnos(ctx context.Context, idPaciente int) ([]TurnoPortal, error) {
+	p, err := s.ps.GetPacienteByID(ctx, idPaciente)
+	if err != nil {
+		return []TurnoPortal{}, paciente.ErrNotFound
+	}
+	turnos, err := s.ts.GetTurnoByPaciente(ctx, p.DNI)
+	if err != nil {
+		// sin turnos no es un error para el paciente
+		return []TurnoPortal{}, nil
+	}
+
+	nombres := map[int]string{}
+	resultado := make([]TurnoPortal, 0, len(turnos))
+	for _, t := range turnos {
+		resultado = append(resultado, s.aPortal(ctx, t, nombres))
+	}
+	return resultado, nil
+}
+
+// GetDisponibilidad lista los bloques libres dentro de la ventana de reserva del portal.
+func (s *service) GetDisponibilidad(ctx context.Context, idOdontologo int, especialidad string, desde time.Time) ([]turno.Bloque, error) {
+	if idOdontologo == 0 && especialidad == "" {
+		return []turno.Bloque{}, ErrDatosIncompletos
+	}
+	ahora := time.Now()
+	if minimo := ahora.Add(s.politica.AnticipacionMinima); desde.Before(minimo) {
+		desde = minimo
+	}
+	hasta := ahora.Add(s.politica.VentanaMaxima)
+	if !desde.Before(hasta) {
+		return []turno.Bloque{}, ErrFueraDeVentana
+	}
+	return s.ts.GetDisponibilidad(ctx, idOdontologo, especialidad, desde, hasta, maxBloques)
+}
+
+// Reservar da el turno al paciente de la sesión. Con especialidad y sin odontólogo toma el primer bloque libre de la
+// especialidad desde la fecha pedida, y si otro pedido lo ocupa en el medio prueba con los siguientes; con odontólogo
+// el horario tiene que estar libre.
+func (s *service) Reservar(ctx context.Context, idPaciente int, reserva ReservaRequest) (TurnoPortal, error) {
+	if reserva.IdOdontologo != 0 {
+		return s.reservar(ctx, idPaciente, reserva)
+	}
+
+	bloques, err := s.GetDisponibilidad(ctx, 0, reserva.Especialidad, reserva.FechaHora)
+	if err != nil {
+		return TurnoPortal{}, err
+	}
+	if len(bloques) == 0 {
+		return TurnoPortal{}, ErrSinDisponible
+	}
+	for _, bloque := range bloques {
+		reserva.IdOdontologo = bloque.IdOdontologo
+		reserva.FechaHora = bloque.FechaHora
+		t, err := s.reservar(ctx, idPaciente, reserva)
+		if !errors.Is(err, turno.ErrBloqueOcupado) {
+			return t, err
+		}
+	}
+	return TurnoPortal{}, ErrSinDisponible
+}
+
+// reservar da el turno en el horario y con el odontólogo de la reserva, si está dentro de la ventana del portal
+func (s *service) reservar(ctx context.Context, idPaciente int, reserva ReservaRequest) (TurnoPortal, error) {
+	ahora := time.Now()
+	if reserva.FechaHora.Before(ahora.Add(s.politica.AnticipacionMinima)) || reserva.FechaHora.After(ahora.Add(s.politica.VentanaMaxima)) {
+		return TurnoPortal{}, ErrFueraDeVentana
+	}
+
+	t, err := s.ts.ReservarTurno(ctx, turno.TurnoRequest{
+		IdOdontologo: reserva.IdOdontologo,
+		IdPaciente:   idPaciente,
+		FechaHora:    reserva.FechaHora,
+		Descripcion:  reserva.Descripcion,
+	})
+	if err != nil {
+		return TurnoPortal{}, err
+	}
+	return s.aPortal(ctx, t, map[int]string{}), nil
+}
+
+func (s *service) Confirmar(ctx context.Context, idPaciente int, idTurno int) (TurnoPortal, error) {
+	if _, err := s.turnoDelPaciente(ctx, idPaciente, idTurno); err != nil {
+		return TurnoPortal{}, err
+	}
+	t, err := s.ts.ConfirmarTurno(ctx, idTurno)
+	if err != nil {
+		return TurnoPortal{}, err
+	}
+	return s.aPortal(ctx, t, map[int]string{}), nil
+}
+
+// Cancelar cancela el turno si falta al menos el plazo de cancelación de la clínica.
+func (s *service) Cancelar(ctx context.Context, idPaciente int, idTurno int) (TurnoPortal, error) {
+	t, err := s.turnoDelPaciente(ctx, idPaciente, idTurno)
+	if err != nil {
+		return TurnoPortal{}, err
+	}
+	// el portal no maneja versiones: cancela sobre la que acaba de leer
+	t, err = s.ts.CancelarTurno(ctx, idTurno, t.Version, turno.OrigenPaciente)
+	if err != nil {
+		return TurnoPortal{}, err
+	}
+	return s.aPortal(ctx, t, map[int]string{}), nil
+}
+
+// turnoDelPaciente trae el turno sólo si es del paciente de la sesión; si es de otro responde como inexistente
+func (s *service) turnoDelPaciente(ctx context.Context, idPaciente int, idTurno int) (turno.Turno, error) {
+	t, err := s.ts.GetTurnoByID(ctx, idTurno)
+	if err != nil || t.IdPaciente != idPaciente {
+		return turno.Turno{}, ErrTurnoNotFound
+	}
+	return t, nil
+}
+
+// aPortal arma la vista del turno para el paciente, buscando el nombre del odontólogo una sola vez por odontólogo
+func (s *service) aPortal(ctx context.Context, t turno.Turno, nombres map[int]string) TurnoPortal {
+	nombre, ok := nombres[t.IdOdontologo]
+	if !ok {
+		if o, err := s.os.GetOdontologoByID(ctx, t.IdOdontologo); err == nil {
+			nombre = o.Apellido + ", " + o.Nombre
+		}
+		nombres[t.IdOdontologo] = nombre
+	}
+	return TurnoPortal{
+		ID:           t.ID,
+		IdOdontologo: t.IdOdontologo,
+		Odontologo:   nombre,
+		FechaHora:    t.FechaHora,
+		Descripcion:  t.Descripcion,
+		Estado:       t.Estado,
+	}
+}
+
+// destino elige a dónde mandar el código según el canal preferido: email si lo eligió, si no el teléfono principal
+// (por SMS, salvo que prefiera WhatsApp) y como último recurso el email.
+func destino(p paciente.Paciente) (notificador.Mensaje, bool) {
+	var telefono string
+	for _, t := range p.Telefonos {
+		if t.Principal {
+			telefono = t.Numero
+			break
+		}
+	}
+
+	switch {
+	case p.CanalPreferido == paciente.CanalEmail && p.Email != "":
+		return notificador.Mensaje{Canal: paciente.CanalEmail, Destino: p.Email}, true
+	case telefono != "" && p.CanalPreferido == paciente.CanalWhatsApp:
+		return notificador.Mensaje{Canal: paciente.CanalWhatsApp, Destino: telefono}, true
+	case telefono != "":
+		return notificador.Mensaje{Canal: paciente.CanalSMS, Destino: telefono}, true
+	case p.Email != "":
+		return notificador.Mensaje{Canal: paciente.CanalEmail, Destino: p.Email}, true
+	}
+	return notificador.Mensaje{}, false
+}
+
+// generarCodigo devuelve 6 dígitos al azar
+func generarCodigo() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// hashCodigo firma el código con el secreto, así con sólo la tabla no se pueden probar los códigos de 6 dígitos.
+// Incluye el paciente para que el mismo código no tenga el mismo hash en dos pacientes.
+func (s *service) hashCodigo(idPaciente int, codigo string) string {
+	mac := hmac.New(sha256.New, s.secreto)
+	mac.Write([]byte(strconv.Itoa(idPaciente) + ":" + codigo))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// firmar arma el token "<paciente:vencimiento en base64>.<HMAC-SHA256 en base64>"
+func (s *service) firmar(idPaciente int, vence time.Time) string {
+	crudo := []byte(strconv.Itoa(idPaciente) + ":" + strconv.FormatInt(vence.Unix(), 10))
+	return base64.RawURLEncoding.EncodeToString(crudo) + "." + s.firma(crudo)
+}
+
+func (s *service) firma(datos []byte) string {
+	mac := hmac.New(sha256.New, s.secreto)
+	mac.Write(datos)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
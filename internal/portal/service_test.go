@@ -0,0 +1,239 @@
+package portal
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"finalgo/internal/paciente"
+	"finalgo/pkg/notificador"
+)
+
+const secretoPrueba = "secreto-de-prueba-del-portal-de-32-bytes"
+
+func TestSolicitarCodigoLimitaPorDNI(t *testing.T) {
+	s, _ := nuevoPortalDePrueba(t)
+	ctx := context.Background()
+
+	for i := 0; i < PoliticaPorDefecto().CodigosPorDNI; i++ {
+		if err := s.SolicitarCodigo(ctx, "30111222", "10.0.0."+strconv.Itoa(i)); err != nil {
+			t.Fatalf("pedido %d = %v; se esperaba que pase", i+1, err)
+		}
+	}
+	// cambiar de IP no alcanza para pedir más códigos para el mismo DNI
+	if err := s.SolicitarCodigo(ctx, "30111222", "10.0.1.1"); !errors.Is(err, ErrDemasiados) {
+		t.Fatalf("pedido de más = %v; se esperaba ErrDemasiados", err)
+	}
+	// y un DNI que no es de ningún paciente también cuenta, para no revelar cuáles existen
+	for i := 0; i < PoliticaPorDefecto().CodigosPorDNI; i++ {
+		s.SolicitarCodigo(ctx, "99999999", "10.0.2."+strconv.Itoa(i))
+	}
+	if err := s.SolicitarCodigo(ctx, "99999999", "10.0.3.1"); !errors.Is(err, ErrDemasiados) {
+		t.Fatalf("pedido de más para DNI inexistente = %v; se esperaba ErrDemasiados", err)
+	}
+}
+
+func TestSolicitarCodigoLimitaPorIP(t *testing.T) {
+	s, _ := nuevoPortalDePrueba(t)
+	ctx := context.Background()
+
+	for i := 0; i < PoliticaPorDefecto().CodigosPorIP; i++ {
+		if err := s.SolicitarCodigo(ctx, strconv.Itoa(40000000+i), "10.0.0.1"); err != nil {
+			t.Fatalf("pedido %d = %v; se esperaba que pase", i+1, err)
+		}
+	}
+	if err := s.SolicitarCodigo(ctx, "50000000", "10.0.0.1"); !errors.Is(err, ErrDemasiados) {
+		t.Fatalf("pedido de más = %v; se esperaba ErrDemasiados", err)
+	}
+	if err := s.SolicitarCodigo(ctx, "50000000", "10.0.0.2"); err != nil {
+		t.Fatalf("pedido desde otra IP = %v; se esperaba que pase", err)
+	}
+}
+
+func TestIniciarSesionCuentaIntentosPorPaciente(t *testing.T) {
+	s, n := nuevoPortalDePrueba(t)
+	ctx := context.Background()
+
+	if err := s.SolicitarCodigo(ctx, "30111222", "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < PoliticaPorDefecto().IngresosPorDNI; i++ {
+		_, err := s.IniciarSesion(ctx, LoginRequest{DNI: "30111222", Codigo: "000000"}, "10.0.0."+strconv.Itoa(i))
+		if !errors.Is(err, ErrCredenciales) {
+			t.Fatalf("intento %d = %v; se esperaba ErrCredenciales", i+1, err)
+		}
+	}
+
+	// un código nuevo no da más intentos: ni el correcto entra hasta que pase la ventana
+	if err := s.SolicitarCodigo(ctx, "30111222", "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.IniciarSesion(ctx, LoginRequest{DNI: "30111222", Codigo: n.codigo(t)}, "10.0.1.1"); !errors.Is(err, ErrDemasiados) {
+		t.Fatalf("ingreso con el código nuevo = %v; se esperaba ErrDemasiados", err)
+	}
+}
+
+func TestIniciarSesionConCodigoCorrecto(t *testing.T) {
+	s, n := nuevoPortalDePrueba(t)
+	ctx := context.Background()
+
+	if err := s.SolicitarCodigo(ctx, "30111222", "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.IniciarSesion(ctx, LoginRequest{DNI: "30111222", Codigo: "000000"}, "10.0.0.1"); !errors.Is(err, ErrCredenciales) {
+		t.Fatalf("ingreso con código equivocado = %v; se esperaba ErrCredenciales", err)
+	}
+	sesion, err := s.IniciarSesion(ctx, LoginRequest{DNI: "30111222", Codigo: n.codigo(t)}, "10.0.0.1")
+	if err != nil {
+		t.Fatalf("ingreso con el código enviado = %v", err)
+	}
+	if _, err := s.Autenticar(sesion.Token); err != nil {
+		t.Fatalf("Autenticar = %v", err)
+	}
+}
+
+// nuevoPortalDePrueba arma el portal con un paciente de DNI 30111222, los intentos en memoria y un notificador que
+// se queda con el último mensaje
+func nuevoPortalDePrueba(t *testing.T) (Service, *notificadorFalso) {
+	ps := paciente.NewService(paciente.NewRepositoryMemoria(), sinTransaccion{}, auditoriaNula{})
+	_, err := ps.CreatePaciente(context.Background(), paciente.PacienteRequest{
+		Nombre:   "Ana",
+		Apellido: "Pérez",
+		DNI:      "30111222",
+		Email:    "ana@example.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := &notificadorFalso{}
+	s, err := NewService(&repositoryFalso{}, ps, nil, nil, n, []byte(secretoPrueba), PoliticaPorDefecto())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, n
+}
+
+type auditoriaNula struct{}
+
+func (auditoriaNula) Alta(context.Context, string, int, interface{}) error { return nil }
+func (auditoriaNula) Modificacion(context.Context, string, int, interface{}, interface{}) error {
+	return nil
+}
+func (auditoriaNula) Baja(context.Context, string, int, interface{}) error         { return nil }
+func (auditoriaNula) Restauracion(context.Context, string, int, interface{}) error { return nil }
+
+// sinTransaccion corre el cambio sin base: el paciente está en memoria
+type sinTransaccion struct{}
+
+func (sinTransaccion) Ejecutar(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type notificadorFalso struct {
+	ultimo notificador.Mensaje
+}
+
+func (n *notificadorFalso) Enviar(_ context.Context, m notificador.Mensaje) error {
+	n.ultimo = m
+	return nil
+}
+
+// codigo saca el código de 6 dígitos del último mensaje enviado
+func (n *notificadorFalso) codigo(t *testing.T) string {
+	codigo := regexp.MustCompile(`\d{6}`).FindString(n.ultimo.Texto)
+	if codigo == "" {
+		t.Fatalf("el mensaje no tiene código: %q", n.ultimo.Texto)
+	}
+	return codigo
+}
+
+// repositoryFalso guarda códigos e intentos en memoria
+type repositoryFalso struct {
+	mu       sync.Mutex
+	codigos  []CodigoAcceso
+	usados   map[int]bool
+	intentos []Intento
+}
+
+func (r *repositoryFalso) CreateCodigo(_ context.Context, c CodigoAcceso) (CodigoAcceso, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.usados == nil {
+		r.usados = map[int]bool{}
+	}
+	for _, anterior := range r.codigos {
+		if anterior.IdPaciente == c.IdPaciente {
+			r.usados[anterior.ID] = true
+		}
+	}
+	c.ID = len(r.codigos) + 1
+	r.codigos = append(r.codigos, c)
+	return c, nil
+}
+
+func (r *repositoryFalso) GetCodigoVigente(_ context.Context, idPaciente int, ahora time.Time) (CodigoAcceso, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := len(r.codigos) - 1; i >= 0; i-- {
+		c := r.codigos[i]
+		if c.IdPaciente == idPaciente && !r.usados[c.ID] && c.Vence.After(ahora) {
+			return c, nil
+		}
+	}
+	return CodigoAcceso{}, ErrCodigoNotFound
+}
+
+func (r *repositoryFalso) UsarCodigo(_ context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.usados[id] {
+		return ErrCodigoNotFound
+	}
+	r.usados[id] = true
+	return nil
+}
+
+func (r *repositoryFalso) CreateIntento(_ context.Context, i Intento) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.intentos = append(r.intentos, i)
+	return nil
+}
+
+func (r *repositoryFalso) CountIntentosByDNI(_ context.Context, accion string, dni string, desde time.Time) (int, error) {
+	return r.contar(func(i Intento) bool { return i.Accion == accion && i.DNI == dni && i.Fecha.After(desde) }), nil
+}
+
+func (r *repositoryFalso) CountIntentosByIP(_ context.Context, accion string, ip string, desde time.Time) (int, error) {
+	return r.contar(func(i Intento) bool { return i.Accion == accion && i.IP == ip && i.Fecha.After(desde) }), nil
+}
+
+func (r *repositoryFalso) DeleteIntentosPrevios(_ context.Context, antes time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	vigentes := r.intentos[:0]
+	for _, i := range r.intentos {
+		if !i.Fecha.Before(antes) {
+			vigentes = append(vigentes, i)
+		}
+	}
+	r.intentos = vigentes
+	return nil
+}
+
+func (r *repositoryFalso) contar(cumple func(Intento) bool) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cantidad := 0
+	for _, i := range r.intentos {
+		if cumple(i) {
+			cantidad++
+		}
+	}
+	return cantidad
+}
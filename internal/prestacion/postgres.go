@@ -0,0 +1,32 @@
+package prestacion
+
+import "database/sql"
+
+// Queries de postgres: parámetros $1, $2, ..., marcas BOOLEAN y las altas devuelven el ID con RETURNING id
+var (
+	QueryInsertPostgres      = `INSERT INTO prestacion(codigo, descripcion, precio) VALUES($1, $2, $3) RETURNING id`
+	QueryDeletePostgres      = `DELETE FROM prestacion WHERE id = $1 AND version = $2`
+	QueryGetByIdPostgres     = `SELECT id, codigo, descripcion, precio, version FROM prestacion WHERE id = $1`
+	QueryGetByCodigoPostgres = `SELECT id, codigo, descripcion, precio, version FROM prestacion WHERE codigo = $1`
+	QueryUpdatePostgres      = `UPDATE prestacion SET codigo = $1, descripcion = $2, precio = $3, version = version + 1 WHERE id = $4 AND version = $5`
+	QueryExistsPostgres      = `SELECT COUNT(*) FROM prestacion WHERE id = $1`
+)
+
+var consultasPostgres = consultas{
+	insert:      QueryInsertPostgres,
+	getAll:      QueryGetAll,
+	delete:      QueryDeletePostgres,
+	getById:     QueryGetByIdPostgres,
+	getByCodigo: QueryGetByCodigoPostgres,
+	update:      QueryUpdatePostgres,
+	exists:      QueryExistsPostgres,
+	returning:   true,
+}
+
+// NewRepositoryPostgres instancia repositorio sobre postgres
+func NewRepositoryPostgres(db *sql.DB) Repository {
+	return &repository{
+		db: db,
+		q:  consultasPostgres,
+	}
+}
@@ -0,0 +1,18 @@
+package prestacion
+
+// creamos la estructura de la prestación del catálogo (nomenclador). El código es el que usan turnos, coberturas y facturación.
+type Prestacion struct {
+	ID          int     `json:"id"`
+	Codigo      string  `json:"codigo"`
+	Descripcion string  `json:"descripcion"`
+	Precio      float64 `json:"precio"`
+	// sube con cada modificación; viaja en el ETag y hay que mandarla en If-Match para modificar o eliminar
+	Version int `json:"version"`
+}
+
+// creamos la misma estructura de prestación para las solicitudes por API.
+type PrestacionRequest struct {
+	Codigo      string  `json:"codigo"`
+	Descripcion string  `json:"descripcion"`
+	Precio      float64 `json:"precio"`
+}
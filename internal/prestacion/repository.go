@@ -0,0 +1,236 @@
+package prestacion
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"finalgo/pkg/basedatos"
+)
+
+// Errores
+var (
+	ErrEmptyList = errors.New("el catálogo de prestaciones esta vacio")
+	ErrNotFound  = errors.New("prestación no encontrada")
+	ErrStatement = errors.New("sentencia incorrecta")
+	ErrExec      = errors.New("ejecución SQL incorrecta")
+	ErrLastId    = errors.New("error al obtener el último ID")
+	ErrVersion   = errors.New("la prestación cambió desde que se leyó, hay que volver a consultarla")
+)
+
+// Queries a usar en cada función. La modificación y la baja sólo se aplican si la versión es la que leyó quien las pide.
+var (
+	QueryInsert      = `INSERT INTO prestacion(codigo, descripcion, precio) VALUES(?,?,?)`
+	QueryGetAll      = `SELECT id, codigo, descripcion, precio, version FROM prestacion ORDER BY id`
+	QueryDelete      = `DELETE FROM prestacion WHERE id = ? AND version = ?`
+	QueryGetById     = `SELECT id, codigo, descripcion, precio, version FROM prestacion WHERE id = ?`
+	QueryGetByCodigo = `SELECT id, codigo, descripcion, precio, version FROM prestacion WHERE codigo = ?`
+	QueryUpdate      = `UPDATE prestacion SET codigo = ?, descripcion = ?, precio = ?, version = version + 1 WHERE id = ? AND version = ?`
+	QueryExists      = `SELECT COUNT(*) FROM prestacion WHERE id = ?`
+)
+
+// consultas son las queries de cada función en el dialecto del motor: MySQL y SQLite usan las de arriba y
+// PostgreSQL las de postgres.go
+type consultas struct {
+	insert      string
+	getAll      string
+	delete      string
+	getById     string
+	getByCodigo string
+	update      string
+	exists      string
+	// las altas devuelven el ID con RETURNING id, porque el motor no tiene LastInsertId
+	returning bool
+}
+
+var consultasMySQL = consultas{
+	insert:      QueryInsert,
+	getAll:      QueryGetAll,
+	delete:      QueryDelete,
+	getById:     QueryGetById,
+	getByCodigo: QueryGetByCodigo,
+	update:      QueryUpdate,
+	exists:      QueryExists,
+}
+
+// defino la interfaz para que se apliquen siempre todos los métodos
+type Repository interface {
+	GetPrestacionByID(ctx context.Context, id int) (Prestacion, error)
+	GetPrestacionByCodigo(ctx context.Context, codigo string) (Prestacion, error)
+	GetAll(ctx context.Context) ([]Prestacion, error)
+	CreatePrestacion(ctx context.Context, p Prestacion) (Prestacion, error)
+	UpdatePrestacion(ctx context.Context, p Prestacion) (Prestacion, error)
+	DeletePrestacion(ctx context.Context, id int, version int) error
+}
+
+// estructura repositorio con base de datos mysql
+type repository struct {
+	db *sql.DB
+	q  consultas
+}
+
+// NewRepositoryMySql instancia repositorio
+func NewRepositoryMySql(db *sql.DB) Repository {
+	return &repository{
+		db: db,
+		q:  consultasMySQL,
+	}
+}
+
+// NewRepositorySqlite instancia repositorio sobre sqlite, con las mismas consultas
+func NewRepositorySqlite(db *sql.DB) Repository {
+	return &repository{
+		db: db,
+		q:  consultasMySQL,
+	}
+}
+
+// obtener todo el catálogo:
+func (r *repository) GetAll(ctx context.Context) ([]Prestacion, error) {
+	// ejecuto la query que trae todos los datos
+	rows, err := r.db.QueryContext(ctx, r.q.getAll)
+	if err != nil {
+		return []Prestacion{}, ErrEmptyList
+	}
+	defer rows.Close()
+
+	// voy poblando el catálogo
+	var prestaciones []Prestacion
+	for rows.Next() {
+		var prestacion Prestacion
+		err := rows.Scan(
+			&prestacion.ID,
+			&prestacion.Codigo,
+			&prestacion.Descripcion,
+			&prestacion.Precio,
+			&prestacion.Version,
+		)
+		if err != nil {
+			return []Prestacion{}, ErrExec
+		}
+		prestaciones = append(prestaciones, prestacion)
+	}
+
+	// verifico haber cargado bien todos los registros
+	if err := rows.Err(); err != nil {
+		return []Prestacion{}, ErrExec
+	}
+
+	return prestaciones, nil
+}
+
+// obtener prestación por ID
+func (r *repository) GetPrestacionByID(ctx context.Context, id int) (Prestacion, error) {
+	return r.getOne(ctx, r.q.getById, id)
+}
+
+// obtener prestación por código
+func (r *repository) GetPrestacionByCodigo(ctx context.Context, codigo string) (Prestacion, error) {
+	return r.getOne(ctx, r.q.getByCodigo, codigo)
+}
+
+// getOne ejecuta una búsqueda que devuelve una única prestación
+func (r *repository) getOne(ctx context.Context, query string, arg interface{}) (Prestacion, error) {
+	row := r.db.QueryRowContext(ctx, query, arg)
+
+	var prestacion Prestacion
+	err := row.Scan(
+		&prestacion.ID,
+		&prestacion.Codigo,
+		&prestacion.Descripcion,
+		&prestacion.Precio,
+		&prestacion.Version,
+	)
+	if err != nil {
+		return Prestacion{}, ErrNotFound
+	}
+	return prestacion, nil
+}
+
+// crear prestación en BD
+func (r *repository) CreatePrestacion(ctx context.Context, prestacion Prestacion) (Prestacion, error) {
+	statement, err := r.db.PrepareContext(ctx, r.q.insert)
+	if err != nil {
+		return Prestacion{}, ErrStatement
+	}
+	defer statement.Close()
+
+	result, err := basedatos.InsertarPreparado(
+		ctx,
+		statement,
+		r.q.returning,
+		prestacion.Codigo,
+		prestacion.Descripcion,
+		prestacion.Precio,
+	)
+	if err != nil {
+		return Prestacion{}, ErrExec
+	}
+
+	// obtengo el ID del registro y lo devuelvo como dato
+	lastId, err := result.LastInsertId()
+	if err != nil {
+		return Prestacion{}, ErrLastId
+	}
+	prestacion.ID = int(lastId)
+	prestacion.Version = 1
+	return prestacion, nil
+}
+
+// actualizar un registro
+func (r *repository) UpdatePrestacion(ctx context.Context, prestacion Prestacion) (Prestacion, error) {
+	statement, err := r.db.PrepareContext(ctx, r.q.update)
+	if err != nil {
+		return Prestacion{}, ErrStatement
+	}
+	defer statement.Close()
+
+	result, err := statement.ExecContext(
+		ctx,
+		prestacion.Codigo,
+		prestacion.Descripcion,
+		prestacion.Precio,
+		prestacion.ID,
+		prestacion.Version,
+	)
+	if err != nil {
+		return Prestacion{}, ErrStatement
+	}
+
+	// verifico filas afectadas
+	if err := r.verificarVersion(ctx, result, prestacion.ID); err != nil {
+		return Prestacion{}, err
+	}
+	prestacion.Version++
+
+	return prestacion, nil
+}
+
+// eliminar registro
+func (r *repository) DeletePrestacion(ctx context.Context, id int, version int) error {
+	result, err := r.db.ExecContext(ctx, r.q.delete, id, version)
+	if err != nil {
+		return ErrStatement
+	}
+	return r.verificarVersion(ctx, result, id)
+}
+
+// verificarVersion confirma que la modificación o la baja se aplicó. Si no afectó filas, distingue una prestación
+// inexistente de una que otro usuario modificó antes.
+func (r *repository) verificarVersion(ctx context.Context, result sql.Result, id int) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return ErrExec
+	}
+	if rowsAffected > 0 {
+		return nil
+	}
+	var cantidad int
+	if err := r.db.QueryRowContext(ctx, r.q.exists, id).Scan(&cantidad); err != nil {
+		return ErrExec
+	}
+	if cantidad < 1 {
+		return ErrNotFound
+	}
+	return ErrVersion
+}
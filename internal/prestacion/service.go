@@ -0,0 +1,100 @@
+package prestacion
+
+import (
+	"context"
+	"errors"
+	"log"
+)
+
+// defino la interfaz para que se apliquen siempre todos los métodos
+type Service interface {
+	GetPrestacionByID(ctx context.Context, id int) (Prestacion, error)
+	GetPrestacionByCodigo(ctx context.Context, codigo string) (Prestacion, error)
+	GetAll(ctx context.Context) ([]Prestacion, error)
+	CreatePrestacion(ctx context.Context, p PrestacionRequest) (Prestacion, error)
+	UpdatePrestacion(ctx context.Context, p PrestacionRequest, id int, version int) (Prestacion, error)
+	DeletePrestacion(ctx context.Context, id int, version int) error
+}
+
+// estrucutra service que contará con un repositorio
+type service struct {
+	r Repository
+}
+
+// función para instanciar service
+func NewService(r Repository) Service {
+	return &service{r}
+}
+
+func (s *service) GetAll(ctx context.Context) ([]Prestacion, error) {
+	prestaciones, err := s.r.GetAll(ctx)
+	if err != nil {
+		log.Println("log de error en service de prestaciones", err.Error())
+		return []Prestacion{}, ErrEmptyList
+	}
+	return prestaciones, nil
+}
+
+func (s *service) GetPrestacionByID(ctx context.Context, id int) (Prestacion, error) {
+	p, err := s.r.GetPrestacionByID(ctx, id)
+	if err != nil {
+		log.Println("log de error por prestación inexistente", err.Error())
+		return Prestacion{}, ErrNotFound
+	}
+	return p, nil
+}
+
+func (s *service) GetPrestacionByCodigo(ctx context.Context, codigo string) (Prestacion, error) {
+	p, err := s.r.GetPrestacionByCodigo(ctx, codigo)
+	if err != nil {
+		log.Println("log de error por prestación inexistente", err.Error())
+		return Prestacion{}, ErrNotFound
+	}
+	return p, nil
+}
+
+func (s *service) CreatePrestacion(ctx context.Context, prestacionRequest PrestacionRequest) (Prestacion, error) {
+	prestacion := requestToPrestacion(prestacionRequest)
+	response, err := s.r.CreatePrestacion(ctx, prestacion)
+	if err != nil {
+		log.Println("error al crear prestación")
+		return Prestacion{}, ErrExec
+	}
+	return response, nil
+}
+
+func (s *service) UpdatePrestacion(ctx context.Context, prestacionRequest PrestacionRequest, id int, version int) (Prestacion, error) {
+	prestacion := requestToPrestacion(prestacionRequest)
+	prestacion.ID = id
+	prestacion.Version = version
+	response, err := s.r.UpdatePrestacion(ctx, prestacion)
+	if err != nil {
+		log.Println("error al actualizar prestación")
+		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrVersion) {
+			return Prestacion{}, err
+		}
+		return Prestacion{}, ErrExec
+	}
+	return response, nil
+}
+
+func (s *service) DeletePrestacion(ctx context.Context, id int, version int) error {
+	err := s.r.DeletePrestacion(ctx, id, version)
+	if err != nil {
+		log.Println("log de error borrado de prestación", err.Error())
+		if errors.Is(err, ErrVersion) {
+			return ErrVersion
+		}
+		return ErrNotFound
+	}
+	return nil
+}
+
+// función para transformar request en la estructura definida en GO
+func requestToPrestacion(prestacionRequest PrestacionRequest) Prestacion {
+	var prestacion Prestacion
+	prestacion.Codigo = prestacionRequest.Codigo
+	prestacion.Descripcion = prestacionRequest.Descripcion
+	prestacion.Precio = prestacionRequest.Precio
+	return prestacion
+}
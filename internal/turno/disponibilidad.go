@@ -0,0 +1,138 @@
+package turno
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// días hacia adelante en los que se busca un turno libre al pedir por especialidad
+const horizonteBusqueda = 60
+
+// veces que se vuelve a buscar odontólogo y horario cuando otro pedido tomó el bloque elegido antes de guardarlo
+const intentosAsignacion = 3
+
+// Horario es la franja en la que la clínica da turnos: de Inicio a Fin (medidos desde la medianoche), en bloques de
+// Duracion, sólo los días habilitados. Cada turno ocupa un bloque.
+type Horario struct {
+	Inicio   time.Duration
+	Fin      time.Duration
+	Duracion time.Duration
+	Dias     map[time.Weekday]bool
+}
+
+// HorarioPorDefecto es de lunes a viernes de 8 a 20 con turnos de 30 minutos.
+func HorarioPorDefecto() Horario {
+	return Horario{
+		Inicio:   8 * time.Hour,
+		Fin:      20 * time.Hour,
+		Duracion: 30 * time.Minute,
+		Dias: map[time.Weekday]bool{
+			time.Monday:    true,
+			time.Tuesday:   true,
+			time.Wednesday: true,
+			time.Thursday:  true,
+			time.Friday:    true,
+		},
+	}
+}
+
+// ParseHorario arma el horario a partir de la configuración: horas "HH:MM", duración en minutos y días como números
+// separados por coma (0 es domingo). Lo que venga vacío toma el valor de HorarioPorDefecto.
+func ParseHorario(inicio, fin, duracionMinutos, dias string) (Horario, error) {
+	h := HorarioPorDefecto()
+	var err error
+	if inicio != "" {
+		if h.Inicio, err = parseHora(inicio); err != nil {
+			return Horario{}, err
+		}
+	}
+	if fin != "" {
+		if h.Fin, err = parseHora(fin); err != nil {
+			return Horario{}, err
+		}
+	}
+	if duracionMinutos != "" {
+		minutos, err := strconv.Atoi(duracionMinutos)
+		if err != nil || minutos <= 0 {
+			return Horario{}, fmt.Errorf("duración de turno inválida: %q", duracionMinutos)
+		}
+		h.Duracion = time.Duration(minutos) * time.Minute
+	}
+	if dias != "" {
+		h.Dias = map[time.Weekday]bool{}
+		for _, d := range strings.Split(dias, ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(d))
+			if err != nil || n < 0 || n > 6 {
+				return Horario{}, fmt.Errorf("día de atención inválido: %q", d)
+			}
+			h.Dias[time.Weekday(n)] = true
+		}
+	}
+	if h.Fin-h.Inicio < h.Duracion {
+		return Horario{}, errors.New("el horario de atención no alcanza para un turno")
+	}
+	return h, nil
+}
+
+func parseHora(valor string) (time.Duration, error) {
+	t, err := time.Parse("15:04", valor)
+	if err != nil {
+		return 0, fmt.Errorf("hora inválida: %q", valor)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// siguienteBloque devuelve el primer comienzo de bloque igual o posterior a desde, dentro del horario de atención
+func (h Horario) siguienteBloque(desde time.Time) time.Time {
+	dia := time.Date(desde.Year(), desde.Month(), desde.Day(), 0, 0, 0, 0, desde.Location())
+	for i := 0; i <= 7; i++ {
+		if h.Dias[dia.Weekday()] {
+			for bloque := dia.Add(h.Inicio); !bloque.Add(h.Duracion).After(dia.Add(h.Fin)); bloque = bloque.Add(h.Duracion) {
+				if !bloque.Before(desde) {
+					return bloque
+				}
+			}
+		}
+		dia = dia.AddDate(0, 0, 1)
+	}
+	// sin días habilitados no hay bloques
+	return time.Time{}
+}
+
+// libres devuelve hasta max bloques entre desde y hasta que no se superpongan con ningún turno ocupado
+func (h Horario) libres(desde, hasta time.Time, ocupados []time.Time, max int) []time.Time {
+	bloques := []time.Time{}
+	for bloque := h.siguienteBloque(desde); !bloque.IsZero() && bloque.Before(hasta) && len(bloques) < max; bloque = h.siguienteBloque(bloque.Add(h.Duracion)) {
+		if h.libre(bloque, ocupados) {
+			bloques = append(bloques, bloque)
+		}
+	}
+	return bloques
+}
+
+// primerLibre busca el primer bloque entre desde y hasta que no se superponga con ningún turno ocupado
+func (h Horario) primerLibre(desde, hasta time.Time, ocupados []time.Time) (time.Time, bool) {
+	bloques := h.libres(desde, hasta, ocupados, 1)
+	if len(bloques) == 0 {
+		return time.Time{}, false
+	}
+	return bloques[0], true
+}
+
+// libre indica si el bloque no se superpone con ningún turno ocupado (cada turno dura un bloque)
+func (h Horario) libre(bloque time.Time, ocupados []time.Time) bool {
+	for _, t := range ocupados {
+		if t.Before(bloque.Add(h.Duracion)) && t.Add(h.Duracion).After(bloque) {
+			return false
+		}
+	}
+	return true
+}
+
+// esBloque indica si la fecha es el comienzo de un bloque del horario de atención
+func (h Horario) esBloque(fecha time.Time) bool {
+	return h.siguienteBloque(fecha).Equal(fecha)
+}
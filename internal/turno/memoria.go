@@ -0,0 +1,321 @@
+package turno
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// estructura repositorio en memoria, para pruebas y demos. Se comporta como el de MySQL (IDs correlativos, los mismos
+// errores, baja lógica, filtros y orden de los listados) pero los datos se pierden al cerrar la aplicación. No conoce
+// a los pacientes ni a los odontólogos, así que no controla que existan: eso lo hace el service.
+type repositoryMemoria struct {
+	mu sync.RWMutex
+
+	ultimoID int
+	turnos   map[int]Turno
+
+	ultimaIncidencia int
+	incidencias      map[int]Incidencia
+}
+
+// NewRepositoryMemoria instancia repositorio en memoria, vacío
+func NewRepositoryMemoria() Repository {
+	return &repositoryMemoria{
+		turnos:      map[int]Turno{},
+		incidencias: map[int]Incidencia{},
+	}
+}
+
+func (r *repositoryMemoria) GetAll(ctx context.Context) ([]Turno, error) {
+	return r.filtrar(func(t Turno) bool { return true }), nil
+}
+
+func (r *repositoryMemoria) GetTurnoByID(ctx context.Context, id int) (Turno, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.turnos[id]
+	if !ok || t.DeletedAt != nil {
+		return Turno{}, ErrNotFound
+	}
+	return copiar(t), nil
+}
+
+func (r *repositoryMemoria) GetTurnoByPaciente(ctx context.Context, id int) ([]Turno, error) {
+	return r.filtrar(func(t Turno) bool { return t.IdPaciente == id }), nil
+}
+
+func (r *repositoryMemoria) GetTurnoByOdontologo(ctx context.Context, id int) ([]Turno, error) {
+	return r.filtrar(func(t Turno) bool { return t.IdOdontologo == id }), nil
+}
+
+func (r *repositoryMemoria) CreateTurno(ctx context.Context, turno Turno) (Turno, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.bloqueTomado(turno.IdOdontologo, turno.FechaHora, 0) {
+		return Turno{}, ErrBloqueOcupado
+	}
+	r.ultimoID++
+	turno.ID = r.ultimoID
+	turno.Version = 1
+	guardado := turno
+	guardado.FechaHora = aSegundos(turno.FechaHora)
+	guardado.Advertencias = nil
+	guardado.Alertas = nil
+	guardado.DeletedAt = nil
+	guardado.DeletedBy = 0
+	r.turnos[turno.ID] = guardado
+	return turno, nil
+}
+
+func (r *repositoryMemoria) UpdateTurno(ctx context.Context, turno Turno) (Turno, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	actual, ok := r.turnos[turno.ID]
+	if !ok || actual.DeletedAt != nil {
+		return Turno{}, ErrNotFound
+	}
+	if actual.Version != turno.Version {
+		return Turno{}, ErrVersion
+	}
+	if actual.Estado != EstadoCancelado && r.bloqueTomado(turno.IdOdontologo, turno.FechaHora, turno.ID) {
+		return Turno{}, ErrBloqueOcupado
+	}
+	// el estado no se toca: se cambia con UpdateEstado
+	actual.IdOdontologo = turno.IdOdontologo
+	actual.IdPaciente = turno.IdPaciente
+	actual.FechaHora = aSegundos(turno.FechaHora)
+	actual.Descripcion = turno.Descripcion
+	actual.CodigoPrestacion = turno.CodigoPrestacion
+	actual.Version++
+	turno.Version = actual.Version
+	r.turnos[turno.ID] = actual
+	return turno, nil
+}
+
+func (r *repositoryMemoria) DeleteTurno(ctx context.Context, id int, version int, fecha time.Time, idUsuario int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.turnos[id]
+	if !ok || t.DeletedAt != nil {
+		return ErrNotFound
+	}
+	if t.Version != version {
+		return ErrVersion
+	}
+	deletedAt := aSegundos(fecha)
+	t.DeletedAt = &deletedAt
+	t.DeletedBy = idUsuario
+	t.Version++
+	r.turnos[id] = t
+	return nil
+}
+
+func (r *repositoryMemoria) GetEliminados(ctx context.Context) ([]Turno, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	turnos := []Turno{}
+	for _, t := range r.ordenados() {
+		if t.DeletedAt != nil {
+			turnos = append(turnos, copiar(t))
+		}
+	}
+	// del más reciente al más viejo; a igual fecha quedan por ID
+	sort.SliceStable(turnos, func(i, j int) bool {
+		return turnos[i].DeletedAt.After(*turnos[j].DeletedAt)
+	})
+	return turnos, nil
+}
+
+func (r *repositoryMemoria) GetEliminadoByID(ctx context.Context, id int) (Turno, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.turnos[id]
+	if !ok || t.DeletedAt == nil {
+		return Turno{}, ErrNotFound
+	}
+	return copiar(t), nil
+}
+
+func (r *repositoryMemoria) GetTurnosEliminadosEn(ctx context.Context, deletedAt time.Time, idPaciente int, idOdontologo int) ([]Turno, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	turnos := []Turno{}
+	for _, t := range r.ordenados() {
+		if t.DeletedAt != nil && t.DeletedAt.Equal(aSegundos(deletedAt)) && (t.IdPaciente == idPaciente || t.IdOdontologo == idOdontologo) {
+			turnos = append(turnos, copiar(t))
+		}
+	}
+	return turnos, nil
+}
+
+func (r *repositoryMemoria) RestaurarTurno(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.turnos[id]
+	if !ok || t.DeletedAt == nil {
+		return ErrNotFound
+	}
+	if t.Estado != EstadoCancelado && r.bloqueTomado(t.IdOdontologo, t.FechaHora, id) {
+		return ErrBloqueOcupado
+	}
+	t.DeletedAt = nil
+	t.DeletedBy = 0
+	t.Version++
+	r.turnos[id] = t
+	return nil
+}
+
+func (r *repositoryMemoria) UpdateEstado(ctx context.Context, id int, version int, estado string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.turnos[id]
+	if !ok || t.DeletedAt != nil {
+		return ErrNotFound
+	}
+	if t.Version != version {
+		return ErrVersion
+	}
+	t.Estado = estado
+	t.Version++
+	r.turnos[id] = t
+	return nil
+}
+
+func (r *repositoryMemoria) GetAgenda(ctx context.Context, idOdontologo int, desde time.Time, hasta time.Time) ([]Turno, error) {
+	turnos := r.filtrar(func(t Turno) bool {
+		return t.IdOdontologo == idOdontologo && !t.FechaHora.Before(desde) && t.FechaHora.Before(hasta)
+	})
+	if turnos == nil {
+		turnos = []Turno{}
+	}
+	sort.SliceStable(turnos, func(i, j int) bool { return turnos[i].FechaHora.Before(turnos[j].FechaHora) })
+	return turnos, nil
+}
+
+func (r *repositoryMemoria) CreateIncidencia(ctx context.Context, incidencia Incidencia) (Incidencia, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// como las foreign keys y el índice único de incidencia_turno: el turno tiene que existir y tener una sola
+	if _, ok := r.turnos[incidencia.IdTurno]; !ok {
+		return Incidencia{}, ErrExec
+	}
+	for _, i := range r.incidencias {
+		if i.IdTurno == incidencia.IdTurno {
+			return Incidencia{}, ErrExec
+		}
+	}
+
+	r.ultimaIncidencia++
+	incidencia.ID = r.ultimaIncidencia
+	guardada := incidencia
+	guardada.Fecha = aSegundos(incidencia.Fecha)
+	// DECIMAL(10,2)
+	guardada.Penalidad = math.Round(incidencia.Penalidad*100) / 100
+	r.incidencias[incidencia.ID] = guardada
+	return incidencia, nil
+}
+
+func (r *repositoryMemoria) GetIncidenciasByPaciente(ctx context.Context, idPaciente int) ([]Incidencia, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	incidencias := []Incidencia{}
+	for _, i := range r.incidencias {
+		if i.IdPaciente == idPaciente {
+			incidencias = append(incidencias, i)
+		}
+	}
+	// de la más nueva a la más vieja
+	sort.Slice(incidencias, func(i, j int) bool {
+		if !incidencias[i].Fecha.Equal(incidencias[j].Fecha) {
+			return incidencias[i].Fecha.After(incidencias[j].Fecha)
+		}
+		return incidencias[i].ID < incidencias[j].ID
+	})
+	return incidencias, nil
+}
+
+func (r *repositoryMemoria) GetIncidenciaByTurno(ctx context.Context, idTurno int) (Incidencia, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, i := range r.incidencias {
+		if i.IdTurno == idTurno {
+			return i, nil
+		}
+	}
+	return Incidencia{}, ErrIncidencia
+}
+
+func (r *repositoryMemoria) CountTurnosOdontologoPaciente(ctx context.Context, idOdontologo int, idPaciente int) (int, error) {
+	turnos := r.filtrar(func(t Turno) bool {
+		return t.IdOdontologo == idOdontologo && t.IdPaciente == idPaciente && t.Estado != EstadoCancelado
+	})
+	return len(turnos), nil
+}
+
+// filtrar devuelve, por ID, los turnos activos que cumplen la condición. Como el de MySQL, si no hay ninguno devuelve nil.
+func (r *repositoryMemoria) filtrar(condicion func(t Turno) bool) []Turno {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var turnos []Turno
+	for _, t := range r.ordenados() {
+		if t.DeletedAt == nil && condicion(t) {
+			turnos = append(turnos, copiar(t))
+		}
+	}
+	return turnos
+}
+
+// bloqueTomado reemplaza al índice único de la base: un odontólogo no puede tener dos turnos vigentes (ni cancelados
+// ni dados de baja) en el mismo horario. excepto es el turno que se está modificando.
+func (r *repositoryMemoria) bloqueTomado(idOdontologo int, fechaHora time.Time, excepto int) bool {
+	if idOdontologo == 0 {
+		return false
+	}
+	fechaHora = aSegundos(fechaHora)
+	for _, t := range r.turnos {
+		if t.ID != excepto && t.DeletedAt == nil && t.Estado != EstadoCancelado && t.IdOdontologo == idOdontologo && t.FechaHora.Equal(fechaHora) {
+			return true
+		}
+	}
+	return false
+}
+
+// ordenados devuelve los turnos guardados por ID, que es el orden en que los devuelve MySQL
+func (r *repositoryMemoria) ordenados() []Turno {
+	turnos := make([]Turno, 0, len(r.turnos))
+	for _, t := range r.turnos {
+		turnos = append(turnos, t)
+	}
+	sort.Slice(turnos, func(i, j int) bool { return turnos[i].ID < turnos[j].ID })
+	return turnos
+}
+
+// copiar devuelve el turno sin compartir la fecha de baja con el guardado
+func copiar(t Turno) Turno {
+	if t.DeletedAt != nil {
+		deletedAt := *t.DeletedAt
+		t.DeletedAt = &deletedAt
+	}
+	return t
+}
+
+// aSegundos es lo que devuelve una columna DATETIME: redondeada al segundo y en UTC
+func aSegundos(t time.Time) time.Time {
+	return t.UTC().Round(time.Second)
+}
@@ -0,0 +1,16 @@
+package turno_test
+
+import (
+	"testing"
+
+	"finalgo/internal/contrato/contratotest"
+	"finalgo/internal/odontologo"
+	"finalgo/internal/paciente"
+	"finalgo/internal/turno"
+)
+
+func TestRepositoryMemoria(t *testing.T) {
+	contratotest.Turnos(t, func(t *testing.T) (turno.Repository, paciente.Repository, odontologo.Repository) {
+		return turno.NewRepositoryMemoria(), paciente.NewRepositoryMemoria(), odontologo.NewRepositoryMemoria()
+	})
+}
@@ -0,0 +1,98 @@
+package turno
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// qué pasa cuando un paciente llega al máximo de ausencias: se lo marca para que lo vea la recepción o no puede
+// reservar más por su cuenta
+const (
+	RestriccionMarcar   = "marcar"
+	RestriccionBloquear = "bloquear"
+)
+
+// quién cancela el turno. El paciente no puede cancelar fuera de plazo; la clínica sí, y queda registrado.
+const (
+	OrigenClinica  = "clinica"
+	OrigenPaciente = "paciente"
+)
+
+// PoliticaCancelacion son las reglas de la clínica para cancelaciones y ausencias. Con AvisoMinimo en cero no hay
+// cancelaciones tardías y con MaxAusencias en cero nadie queda restringido.
+type PoliticaCancelacion struct {
+	// cancelar con menos anticipación que esta es una cancelación tardía
+	AvisoMinimo time.Duration
+	// ausencias a partir de las cuales se aplica la restricción, contadas en el último Periodo (cero cuenta todas)
+	MaxAusencias int
+	Periodo      time.Duration
+	Restriccion  string
+	// importe que se cobra por cada cancelación tardía o ausencia, cero si no se cobra
+	Penalidad float64
+}
+
+// ParsePoliticaCancelacion arma la política a partir de la configuración: horas de aviso, máximo de ausencias, meses
+// que se cuentan, restricción (marcar o bloquear) e importe de la penalidad. Lo que venga vacío queda sin aplicar.
+func ParsePoliticaCancelacion(horasAviso, maxAusencias, meses, restriccion, penalidad string) (PoliticaCancelacion, error) {
+	p := PoliticaCancelacion{Restriccion: RestriccionMarcar}
+	if horasAviso != "" {
+		horas, err := strconv.Atoi(horasAviso)
+		if err != nil || horas < 0 {
+			return PoliticaCancelacion{}, fmt.Errorf("horas de aviso inválidas: %q", horasAviso)
+		}
+		p.AvisoMinimo = time.Duration(horas) * time.Hour
+	}
+	if maxAusencias != "" {
+		n, err := strconv.Atoi(maxAusencias)
+		if err != nil || n < 0 {
+			return PoliticaCancelacion{}, fmt.Errorf("máximo de ausencias inválido: %q", maxAusencias)
+		}
+		p.MaxAusencias = n
+	}
+	if meses != "" {
+		n, err := strconv.Atoi(meses)
+		if err != nil || n < 0 {
+			return PoliticaCancelacion{}, fmt.Errorf("período de ausencias inválido: %q", meses)
+		}
+		p.Periodo = time.Duration(n) * 30 * 24 * time.Hour
+	}
+	switch restriccion {
+	case "":
+	case RestriccionMarcar, RestriccionBloquear:
+		p.Restriccion = restriccion
+	default:
+		return PoliticaCancelacion{}, fmt.Errorf("restricción por ausencias inválida: %q", restriccion)
+	}
+	if penalidad != "" {
+		importe, err := strconv.ParseFloat(penalidad, 64)
+		if err != nil || importe < 0 {
+			return PoliticaCancelacion{}, fmt.Errorf("penalidad inválida: %q", penalidad)
+		}
+		p.Penalidad = importe
+	}
+	return p, nil
+}
+
+// tardia indica si cancelar el turno ahora es una cancelación tardía
+func (p PoliticaCancelacion) tardia(turno Turno, ahora time.Time) bool {
+	return turno.FechaHora.Sub(ahora) < p.AvisoMinimo
+}
+
+// asistencia cuenta las incidencias del paciente dentro del período y decide si queda restringido
+func (p PoliticaCancelacion) asistencia(idPaciente int, incidencias []Incidencia, ahora time.Time) Asistencia {
+	a := Asistencia{IdPaciente: idPaciente, Incidencias: incidencias}
+	for _, i := range incidencias {
+		if p.Periodo > 0 && i.Fecha.Before(ahora.Add(-p.Periodo)) {
+			continue
+		}
+		switch i.Tipo {
+		case IncidenciaAusencia:
+			a.Ausencias++
+		case IncidenciaCancelacionTardia:
+			a.CancelacionesTardias++
+		}
+	}
+	a.Restringido = p.MaxAusencias > 0 && a.Ausencias >= p.MaxAusencias
+	return a
+}
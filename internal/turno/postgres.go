@@ -0,0 +1,53 @@
+package turno
+
+import "database/sql"
+
+// Queries de postgres: parámetros $1, $2, ..., marcas BOOLEAN y las altas devuelven el ID con RETURNING id
+var (
+	QueryInsertPostgres                   = `INSERT INTO turno(id_odontologo, id_paciente, fecha_hora, descripcion, codigo_prestacion, estado) VALUES($1, $2, $3, $4, $5, $6) RETURNING id`
+	QueryDeletePostgres                   = `UPDATE turno SET deleted_at = $1, deleted_by = $2, version = version + 1 WHERE id = $3 AND version = $4 AND deleted_at IS NULL`
+	QueryGetByIdPostgres                  = `SELECT id, id_odontologo, id_paciente, fecha_hora, descripcion, codigo_prestacion, estado, version FROM turno WHERE id = $1 AND deleted_at IS NULL`
+	QueryUpdatePostgres                   = `UPDATE turno SET id_odontologo = $1, id_paciente = $2, fecha_hora = $3, descripcion = $4, codigo_prestacion = $5, version = version + 1 WHERE id = $6 AND version = $7 AND deleted_at IS NULL`
+	QueryUpdateEstadoPostgres             = `UPDATE turno SET estado = $1, version = version + 1 WHERE id = $2 AND version = $3 AND deleted_at IS NULL`
+	QueryExistsPostgres                   = `SELECT COUNT(*) FROM turno WHERE id = $1 AND deleted_at IS NULL`
+	QueryGetByPacientePostgres            = `SELECT id, id_odontologo, id_paciente, fecha_hora, descripcion, codigo_prestacion, estado, version FROM turno WHERE id_paciente = $1 AND deleted_at IS NULL ORDER BY id`
+	QueryGetByOdontologoPostgres          = `SELECT id, id_odontologo, id_paciente, fecha_hora, descripcion, codigo_prestacion, estado, version FROM turno WHERE id_odontologo = $1 AND deleted_at IS NULL ORDER BY id`
+	QueryGetAgendaPostgres                = `SELECT id, id_odontologo, id_paciente, fecha_hora, descripcion, codigo_prestacion, estado, version FROM turno WHERE id_odontologo = $1 AND fecha_hora >= $2 AND fecha_hora < $3 AND deleted_at IS NULL ORDER BY fecha_hora`
+	QueryGetEliminadoPostgres             = `SELECT id, id_odontologo, id_paciente, fecha_hora, descripcion, codigo_prestacion, estado, version, deleted_at, deleted_by FROM turno WHERE id = $1 AND deleted_at IS NOT NULL`
+	QueryGetEliminadosEnPostgres          = `SELECT id, id_odontologo, id_paciente, fecha_hora, descripcion, codigo_prestacion, estado, version, deleted_at, deleted_by FROM turno WHERE deleted_at = $1 AND (id_paciente = $2 OR id_odontologo = $3) ORDER BY id`
+	QueryRestaurarPostgres                = `UPDATE turno SET deleted_at = NULL, deleted_by = NULL, version = version + 1 WHERE id = $1 AND deleted_at IS NOT NULL`
+	QueryInsertIncidenciaPostgres         = `INSERT INTO incidencia_turno(id_turno, id_paciente, tipo, fecha, penalidad) VALUES($1, $2, $3, $4, $5) RETURNING id`
+	QueryGetIncidenciasByPacientePostgres = `SELECT id, id_turno, id_paciente, tipo, fecha, penalidad FROM incidencia_turno WHERE id_paciente = $1 ORDER BY fecha DESC`
+	QueryGetIncidenciaByTurnoPostgres     = `SELECT id, id_turno, id_paciente, tipo, fecha, penalidad FROM incidencia_turno WHERE id_turno = $1`
+	QueryCountOdontologoPacientePostgres  = `SELECT COUNT(*) FROM turno WHERE id_odontologo = $1 AND id_paciente = $2 AND estado <> 'cancelado' AND deleted_at IS NULL`
+)
+
+var consultasPostgres = consultas{
+	insert:                   QueryInsertPostgres,
+	getAll:                   QueryGetAll,
+	delete:                   QueryDeletePostgres,
+	getById:                  QueryGetByIdPostgres,
+	update:                   QueryUpdatePostgres,
+	updateEstado:             QueryUpdateEstadoPostgres,
+	exists:                   QueryExistsPostgres,
+	getByPaciente:            QueryGetByPacientePostgres,
+	getByOdontologo:          QueryGetByOdontologoPostgres,
+	getAgenda:                QueryGetAgendaPostgres,
+	getEliminados:            QueryGetEliminados,
+	getEliminado:             QueryGetEliminadoPostgres,
+	getEliminadosEn:          QueryGetEliminadosEnPostgres,
+	restaurar:                QueryRestaurarPostgres,
+	insertIncidencia:         QueryInsertIncidenciaPostgres,
+	getIncidenciasByPaciente: QueryGetIncidenciasByPacientePostgres,
+	getIncidenciaByTurno:     QueryGetIncidenciaByTurnoPostgres,
+	countOdontologoPaciente:  QueryCountOdontologoPacientePostgres,
+	returning:                true,
+}
+
+// NewRepositoryPostgres instancia repositorio sobre postgres
+func NewRepositoryPostgres(db *sql.DB) Repository {
+	return &repository{
+		db: db,
+		q:  consultasPostgres,
+	}
+}
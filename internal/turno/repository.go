@@ -4,55 +4,157 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
+
+	"finalgo/pkg/basedatos"
+	"finalgo/pkg/transaccion"
 )
 
 // Errores
 var (
-	ErrEmptyList = errors.New("la lista de turnos esta vacia")
-	ErrNotFound  = errors.New("turno no encontrado")
-	ErrStatement = errors.New("sentencia incorrecta")
-	ErrExec      = errors.New("ejecución SQL incorrecta")
-	ErrLastId    = errors.New("error al obtener el último ID")
+	ErrEmptyList         = errors.New("la lista de turnos esta vacia")
+	ErrNotFound          = errors.New("turno no encontrado")
+	ErrStatement         = errors.New("sentencia incorrecta")
+	ErrExec              = errors.New("ejecución SQL incorrecta")
+	ErrLastId            = errors.New("error al obtener el último ID")
+	ErrEstado            = errors.New("el estado del turno no permite la operación")
+	ErrConsentimiento    = errors.New("la prestación requiere un consentimiento informado firmado y vigente")
+	ErrResponsable       = errors.New("el paciente es menor de edad y necesita un responsable cargado para dar turno")
+	ErrEspecialidad      = errors.New("no hay odontólogos con la especialidad pedida")
+	ErrSinDisponibilidad = errors.New("no hay turnos libres para la especialidad en el período de búsqueda")
+	ErrBloqueOcupado     = errors.New("el horario pedido no está disponible")
+	ErrCancelacionTardia = errors.New("el turno ya no se puede cancelar sin penalidad, comuníquese con la clínica")
+	ErrAusencia          = errors.New("sólo se puede marcar ausente un turno pendiente o confirmado cuyo horario ya pasó")
+	ErrRestringido       = errors.New("el paciente superó el máximo de ausencias y no puede reservar turnos por su cuenta")
+	ErrIncidencia        = errors.New("incidencia no encontrada")
+	ErrBajaRelacionada   = errors.New("el paciente o el odontólogo del turno está dado de baja, hay que restaurarlo primero")
+	ErrVersion           = errors.New("el turno cambió desde que se leyó, hay que volver a consultarlo")
+	ErrConcurrente       = errors.New("el turno cambió mientras se procesaba el pedido, hay que volver a intentarlo")
 )
 
-// Queries a usar en cada función
+// Queries a usar en cada función. La baja es lógica: se marca deleted_at y las consultas ignoran a los dados de baja.
+// Toda modificación sube la versión y sólo se aplica si la versión es la que se leyó: la que pide el usuario o, en los
+// cambios de estado, la que leyó el servicio al validar el estado.
 var (
-	QueryInsert        = `INSERT INTO my_db.turno(id_odontologo, id_paciente, fecha_hora, descripcion) VALUES(?,?,?,?)`
-	QueryGetAll        = `SELECT id, id_odontologo, id_paciente, fecha_hora, descripcion FROM my_db.turno`
-	QueryDelete        = `DELETE FROM my_db.turno WHERE id = ?`
-	QueryGetById       = `SELECT id, id_odontologo, id_paciente, fecha_hora, descripcion FROM my_db.turno WHERE id = ?`
-	QueryUpdate        = `UPDATE my_db.turno SET nombre = ?, apellido = ?, domicilio = ?, dni = ?, alta = ? WHERE id = ?`
-	QueryGetByPaciente = `SELECT id, id_odontologo, id_paciente, fecha_hora, descripcion FROM my_db.turno WHERE id_paciente = ?`
-	QueryGetByOdontologo = `SELECT id, id_odontologo, id_paciente, fecha_hora, descripcion FROM my_db.turno WHERE id_odontologo = ?`
+	QueryInsert          = `INSERT INTO turno(id_odontologo, id_paciente, fecha_hora, descripcion, codigo_prestacion, estado) VALUES(?,?,?,?,?,?)`
+	QueryGetAll          = `SELECT id, id_odontologo, id_paciente, fecha_hora, descripcion, codigo_prestacion, estado, version FROM turno WHERE deleted_at IS NULL ORDER BY id`
+	QueryDelete          = `UPDATE turno SET deleted_at = ?, deleted_by = ?, version = version + 1 WHERE id = ? AND version = ? AND deleted_at IS NULL`
+	QueryGetById         = `SELECT id, id_odontologo, id_paciente, fecha_hora, descripcion, codigo_prestacion, estado, version FROM turno WHERE id = ? AND deleted_at IS NULL`
+	QueryUpdate          = `UPDATE turno SET id_odontologo = ?, id_paciente = ?, fecha_hora = ?, descripcion = ?, codigo_prestacion = ?, version = version + 1 WHERE id = ? AND version = ? AND deleted_at IS NULL`
+	QueryUpdateEstado    = `UPDATE turno SET estado = ?, version = version + 1 WHERE id = ? AND version = ? AND deleted_at IS NULL`
+	QueryExists          = `SELECT COUNT(*) FROM turno WHERE id = ? AND deleted_at IS NULL`
+	QueryGetByPaciente   = `SELECT id, id_odontologo, id_paciente, fecha_hora, descripcion, codigo_prestacion, estado, version FROM turno WHERE id_paciente = ? AND deleted_at IS NULL ORDER BY id`
+	QueryGetByOdontologo = `SELECT id, id_odontologo, id_paciente, fecha_hora, descripcion, codigo_prestacion, estado, version FROM turno WHERE id_odontologo = ? AND deleted_at IS NULL ORDER BY id`
+	QueryGetAgenda       = `SELECT id, id_odontologo, id_paciente, fecha_hora, descripcion, codigo_prestacion, estado, version FROM turno WHERE id_odontologo = ? AND fecha_hora >= ? AND fecha_hora < ? AND deleted_at IS NULL ORDER BY fecha_hora`
+	QueryGetEliminados   = `SELECT id, id_odontologo, id_paciente, fecha_hora, descripcion, codigo_prestacion, estado, version, deleted_at, deleted_by FROM turno WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC`
+	QueryGetEliminado    = `SELECT id, id_odontologo, id_paciente, fecha_hora, descripcion, codigo_prestacion, estado, version, deleted_at, deleted_by FROM turno WHERE id = ? AND deleted_at IS NOT NULL`
+	QueryGetEliminadosEn = `SELECT id, id_odontologo, id_paciente, fecha_hora, descripcion, codigo_prestacion, estado, version, deleted_at, deleted_by FROM turno WHERE deleted_at = ? AND (id_paciente = ? OR id_odontologo = ?) ORDER BY id`
+	QueryRestaurar       = `UPDATE turno SET deleted_at = NULL, deleted_by = NULL, version = version + 1 WHERE id = ? AND deleted_at IS NOT NULL`
+
+	QueryInsertIncidencia         = `INSERT INTO incidencia_turno(id_turno, id_paciente, tipo, fecha, penalidad) VALUES(?,?,?,?,?)`
+	QueryGetIncidenciasByPaciente = `SELECT id, id_turno, id_paciente, tipo, fecha, penalidad FROM incidencia_turno WHERE id_paciente = ? ORDER BY fecha DESC`
+	QueryGetIncidenciaByTurno     = `SELECT id, id_turno, id_paciente, tipo, fecha, penalidad FROM incidencia_turno WHERE id_turno = ?`
+	QueryCountOdontologoPaciente  = `SELECT COUNT(*) FROM turno WHERE id_odontologo = ? AND id_paciente = ? AND estado <> 'cancelado' AND deleted_at IS NULL`
 )
 
+// consultas son las queries de cada función en el dialecto del motor: MySQL y SQLite usan las de arriba y
+// PostgreSQL las de postgres.go
+type consultas struct {
+	insert                   string
+	getAll                   string
+	delete                   string
+	getById                  string
+	update                   string
+	updateEstado             string
+	exists                   string
+	getByPaciente            string
+	getByOdontologo          string
+	getAgenda                string
+	getEliminados            string
+	getEliminado             string
+	getEliminadosEn          string
+	restaurar                string
+	insertIncidencia         string
+	getIncidenciasByPaciente string
+	getIncidenciaByTurno     string
+	countOdontologoPaciente  string
+	// las altas devuelven el ID con RETURNING id, porque el motor no tiene LastInsertId
+	returning bool
+}
+
+var consultasMySQL = consultas{
+	insert:                   QueryInsert,
+	getAll:                   QueryGetAll,
+	delete:                   QueryDelete,
+	getById:                  QueryGetById,
+	update:                   QueryUpdate,
+	updateEstado:             QueryUpdateEstado,
+	exists:                   QueryExists,
+	getByPaciente:            QueryGetByPaciente,
+	getByOdontologo:          QueryGetByOdontologo,
+	getAgenda:                QueryGetAgenda,
+	getEliminados:            QueryGetEliminados,
+	getEliminado:             QueryGetEliminado,
+	getEliminadosEn:          QueryGetEliminadosEn,
+	restaurar:                QueryRestaurar,
+	insertIncidencia:         QueryInsertIncidencia,
+	getIncidenciasByPaciente: QueryGetIncidenciasByPaciente,
+	getIncidenciaByTurno:     QueryGetIncidenciaByTurno,
+	countOdontologoPaciente:  QueryCountOdontologoPaciente,
+}
+
 // defino la interfaz para que se apliquen siempre todos los métodos
 type Repository interface {
 	GetTurnoByID(ctx context.Context, id int) (Turno, error)
 	GetAll(ctx context.Context) ([]Turno, error)
 	CreateTurno(ctx context.Context, p Turno) (Turno, error)
 	UpdateTurno(ctx context.Context, p Turno) (Turno, error)
-	DeleteTurno(ctx context.Context, id int) error
+	DeleteTurno(ctx context.Context, id int, version int, fecha time.Time, idUsuario int) error
+	GetEliminados(ctx context.Context) ([]Turno, error)
+	GetEliminadoByID(ctx context.Context, id int) (Turno, error)
+	GetTurnosEliminadosEn(ctx context.Context, deletedAt time.Time, idPaciente int, idOdontologo int) ([]Turno, error)
+	RestaurarTurno(ctx context.Context, id int) error
 	GetTurnoByPaciente(ctx context.Context, id int) ([]Turno, error)
 	GetTurnoByOdontologo(ctx context.Context, idOdontolog int) ([]Turno, error)
+	UpdateEstado(ctx context.Context, id int, version int, estado string) error
+	GetAgenda(ctx context.Context, idOdontologo int, desde time.Time, hasta time.Time) ([]Turno, error)
+	CreateIncidencia(ctx context.Context, i Incidencia) (Incidencia, error)
+	GetIncidenciasByPaciente(ctx context.Context, idPaciente int) ([]Incidencia, error)
+	GetIncidenciaByTurno(ctx context.Context, idTurno int) (Incidencia, error)
+	CountTurnosOdontologoPaciente(ctx context.Context, idOdontologo int, idPaciente int) (int, error)
 }
 
 // estructura repositorio con base de datos mysql
 type repository struct {
 	db *sql.DB
+	q  consultas
 }
 
 // NewRepositoryMySql instancia repositorio
 func NewRepositoryMySql(db *sql.DB) Repository {
 	return &repository{
 		db: db,
+		q:  consultasMySQL,
+	}
+}
+
+// NewRepositorySqlite instancia repositorio sobre sqlite, con las mismas consultas
+func NewRepositorySqlite(db *sql.DB) Repository {
+	return &repository{
+		db: db,
+		q:  consultasMySQL,
 	}
 }
 
+// conexion devuelve la transacción de la unidad de trabajo en curso, si la hay, o la base
+func (r *repository) conexion(ctx context.Context) transaccion.Ejecutor {
+	return transaccion.Conexion(ctx, r.db)
+}
+
 // obtener todos los turnos:
 func (r *repository) GetAll(ctx context.Context) ([]Turno, error) {
 	// ejecuto la query que trae todos los datos
-	rows, err := r.db.Query(QueryGetAll)
+	rows, err := r.conexion(ctx).QueryContext(ctx, r.q.getAll)
 
 	// si hay error de query, lo devuelvo
 	if err != nil {
@@ -71,6 +173,9 @@ func (r *repository) GetAll(ctx context.Context) ([]Turno, error) {
 			&turno.IdPaciente,
 			&turno.FechaHora,
 			&turno.Descripcion,
+			&turno.CodigoPrestacion,
+			&turno.Estado,
+			&turno.Version,
 		)
 		if err != nil {
 			return []Turno{}, ErrExec
@@ -90,7 +195,7 @@ func (r *repository) GetAll(ctx context.Context) ([]Turno, error) {
 // obtener turnos por ID
 func (r *repository) GetTurnoByID(ctx context.Context, id int) (Turno, error) {
 	// ejecuto la query de búsqueda por ID
-	row := r.db.QueryRow(QueryGetById, id)
+	row := r.conexion(ctx).QueryRowContext(ctx, r.q.getById, id)
 
 	// creo la variable que guarde (muestre) el resultado
 	var turno Turno
@@ -102,6 +207,9 @@ func (r *repository) GetTurnoByID(ctx context.Context, id int) (Turno, error) {
 		&turno.IdPaciente,
 		&turno.FechaHora,
 		&turno.Descripcion,
+		&turno.CodigoPrestacion,
+		&turno.Estado,
+		&turno.Version,
 	)
 
 	// devuelvo el error o el turno
@@ -114,7 +222,7 @@ func (r *repository) GetTurnoByID(ctx context.Context, id int) (Turno, error) {
 // obtener turnos por ID del paciente
 func (r *repository) GetTurnoByPaciente(ctx context.Context, id int) ([]Turno, error) {
 	// ejecuto la query de búsqueda por ID
-	row,err := r.db.Query(QueryGetByPaciente, id)
+	row,err := r.conexion(ctx).QueryContext(ctx, r.q.getByPaciente, id)
 
 	// si hay error de query, lo devuelvo
 	if err != nil {
@@ -134,6 +242,9 @@ func (r *repository) GetTurnoByPaciente(ctx context.Context, id int) ([]Turno, e
 			&turno.IdPaciente,
 			&turno.FechaHora,
 			&turno.Descripcion,
+			&turno.CodigoPrestacion,
+			&turno.Estado,
+			&turno.Version,
 		)
 		if err != nil {
 			return []Turno{}, ErrExec
@@ -153,7 +264,7 @@ func (r *repository) GetTurnoByPaciente(ctx context.Context, id int) ([]Turno, e
 // obtener turnos por ID del odontolog
 func (r *repository) GetTurnoByOdontologo(ctx context.Context, id int) ([]Turno, error) {
 	// ejecuto la query de búsqueda por ID
-	row,err := r.db.Query(QueryGetByOdontologo, id)
+	row,err := r.conexion(ctx).QueryContext(ctx, r.q.getByOdontologo, id)
 
 	// si hay error de query, lo devuelvo
 	if err != nil {
@@ -173,6 +284,9 @@ func (r *repository) GetTurnoByOdontologo(ctx context.Context, id int) ([]Turno,
 			&turno.IdPaciente,
 			&turno.FechaHora,
 			&turno.Descripcion,
+			&turno.CodigoPrestacion,
+			&turno.Estado,
+			&turno.Version,
 		)
 		if err != nil {
 			return []Turno{}, ErrExec
@@ -192,7 +306,7 @@ func (r *repository) GetTurnoByOdontologo(ctx context.Context, id int) ([]Turno,
 // crear turno en BD
 func (r *repository) CreateTurno(ctx context.Context, turno Turno) (Turno, error) {
 	// ejecuto la query
-	statement, err := r.db.Prepare(QueryInsert)
+	statement, err := r.conexion(ctx).PrepareContext(ctx, r.q.insert)
 
 	// verifico error de ejecución de query
 	if err != nil {
@@ -202,15 +316,24 @@ func (r *repository) CreateTurno(ctx context.Context, turno Turno) (Turno, error
 	defer statement.Close()
 
 	// paso los parámetros para que se ejecute la query
-	result, err := statement.Exec(
+	result, err := basedatos.InsertarPreparado(
+		ctx,
+		statement,
+		r.q.returning,
 		turno.IdOdontologo,
 		turno.IdPaciente,
 		turno.FechaHora,
 		turno.Descripcion,
+		turno.CodigoPrestacion,
+		turno.Estado,
 	)
 
-	// verifico error de ejecución de query
+	// verifico error de ejecución de query. El índice único de los turnos vigentes rechaza un segundo turno del
+	// odontólogo en el mismo horario, aunque dos pedidos lo hayan visto libre al mismo tiempo
 	if err != nil {
+		if basedatos.EsDuplicado(err) {
+			return Turno{}, ErrBloqueOcupado
+		}
 		return Turno{}, ErrExec
 	}
 
@@ -220,13 +343,14 @@ func (r *repository) CreateTurno(ctx context.Context, turno Turno) (Turno, error
 		return Turno{}, ErrLastId
 	}
 	turno.ID = int(lastId)
+	turno.Version = 1
 	return turno, nil
 }
 
 // actualizar un registro
 func (r *repository) UpdateTurno(ctx context.Context, turno Turno) (Turno, error) {
 	// preparo query para actualizar campos
-	statement, err := r.db.Prepare(QueryUpdate)
+	statement, err := r.conexion(ctx).PrepareContext(ctx, r.q.update)
 
 	// por problemas de query, devuelve error
 	if err != nil {
@@ -235,42 +359,161 @@ func (r *repository) UpdateTurno(ctx context.Context, turno Turno) (Turno, error
 	defer statement.Close()
 
 	// paso los parámetros para que se ejecute la query
-	result, err := statement.Exec(
+	result, err := statement.ExecContext(
+		ctx,
 		turno.IdOdontologo,
 		turno.IdPaciente,
 		turno.FechaHora,
 		turno.Descripcion,
+		turno.CodigoPrestacion,
 		turno.ID,
+		turno.Version,
 	)
 
-	// verifico error de parámetros
+	// verifico error de parámetros o que el nuevo horario ya esté tomado
 	if err != nil {
+		if basedatos.EsDuplicado(err) {
+			return Turno{}, ErrBloqueOcupado
+		}
 		return Turno{}, ErrStatement
 	}
 
+	// si no actualizó nada, o no existe o alguien lo modificó antes
+	if err := r.verificarVersion(ctx, result, turno.ID); err != nil {
+		return Turno{}, err
+	}
+	turno.Version++
+
+	return turno, nil
+}
+
+// dar de baja el registro: queda en la base marcado con la fecha y el usuario de la baja
+func (r *repository) DeleteTurno(ctx context.Context, id int, version int, fecha time.Time, idUsuario int) error {
 	// ejecuto query
+	result, err := r.conexion(ctx).ExecContext(ctx, r.q.delete, fecha, sql.NullInt64{Int64: int64(idUsuario), Valid: idUsuario != 0}, id, version)
+
+	// verifico error
+	if err != nil {
+		return ErrStatement
+	}
+
+	// verifico filas afectadas
+	return r.verificarVersion(ctx, result, id)
+}
+
+// verificarVersion confirma que una modificación con control de versión se aplicó. Si no afectó filas, distingue un
+// turno inexistente (o dado de baja) de uno que otro usuario modificó antes.
+func (r *repository) verificarVersion(ctx context.Context, result sql.Result, id int) error {
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return Turno{}, ErrExec
+		return ErrExec
 	}
-	if rowsAffected < 1 {
-		return Turno{}, ErrNotFound
+	if rowsAffected > 0 {
+		return nil
+	}
+	var cantidad int
+	if err := r.conexion(ctx).QueryRowContext(ctx, r.q.exists, id).Scan(&cantidad); err != nil {
+		return ErrExec
 	}
+	if cantidad < 1 {
+		return ErrNotFound
+	}
+	return ErrVersion
+}
 
+// obtener los turnos dados de baja, del más reciente al más viejo
+func (r *repository) GetEliminados(ctx context.Context) ([]Turno, error) {
+	rows, err := r.conexion(ctx).QueryContext(ctx, r.q.getEliminados)
+	if err != nil {
+		return []Turno{}, ErrStatement
+	}
+	defer rows.Close()
+
+	return leerEliminados(rows)
+}
+
+// obtener un turno dado de baja por ID, sin recorrer todas las bajas
+func (r *repository) GetEliminadoByID(ctx context.Context, id int) (Turno, error) {
+	turno, err := scanEliminado(r.conexion(ctx).QueryRowContext(ctx, r.q.getEliminado, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Turno{}, ErrNotFound
+	}
+	if err != nil {
+		return Turno{}, ErrExec
+	}
 	return turno, nil
 }
 
-// eliminar registro
-func (r *repository) DeleteTurno(ctx context.Context, id int) error {
-	// ejecuto query
-	result, err := r.db.Exec(QueryDelete, id)
+// obtener los turnos del paciente o del odontólogo dados de baja en esa fecha, que es lo que comparten los de una
+// baja en cascada. Al que no se usa se le pasa 0, que no es el ID de nadie.
+func (r *repository) GetTurnosEliminadosEn(ctx context.Context, deletedAt time.Time, idPaciente int, idOdontologo int) ([]Turno, error) {
+	rows, err := r.conexion(ctx).QueryContext(ctx, r.q.getEliminadosEn, deletedAt, idPaciente, idOdontologo)
+	if err != nil {
+		return []Turno{}, ErrStatement
+	}
+	defer rows.Close()
 
-	// verifico error
+	return leerEliminados(rows)
+}
+
+// leerEliminados arma la lista de turnos dados de baja de una consulta
+func leerEliminados(rows *sql.Rows) ([]Turno, error) {
+	turnos := []Turno{}
+	for rows.Next() {
+		turno, err := scanEliminado(rows)
+		if err != nil {
+			return []Turno{}, ErrExec
+		}
+		turnos = append(turnos, turno)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []Turno{}, ErrExec
+	}
+	return turnos, nil
+}
+
+// scanner lo cumplen tanto *sql.Row como *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanEliminado lee un turno dado de baja, con la fecha y el usuario de la baja
+func scanEliminado(row scanner) (Turno, error) {
+	var turno Turno
+	var deletedAt sql.NullTime
+	var deletedBy sql.NullInt64
+	err := row.Scan(
+		&turno.ID,
+		&turno.IdOdontologo,
+		&turno.IdPaciente,
+		&turno.FechaHora,
+		&turno.Descripcion,
+		&turno.CodigoPrestacion,
+		&turno.Estado,
+		&turno.Version,
+		&deletedAt,
+		&deletedBy,
+	)
 	if err != nil {
+		return Turno{}, err
+	}
+	turno.DeletedAt = &deletedAt.Time
+	turno.DeletedBy = int(deletedBy.Int64)
+	return turno, nil
+}
+
+// quitar la marca de baja del turno
+func (r *repository) RestaurarTurno(ctx context.Context, id int) error {
+	result, err := r.conexion(ctx).ExecContext(ctx, r.q.restaurar, id)
+	if err != nil {
+		// mientras estuvo de baja otro turno pudo tomar su horario
+		if basedatos.EsDuplicado(err) {
+			return ErrBloqueOcupado
+		}
 		return ErrStatement
 	}
 
-	// verifico filas afectadas
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return ErrExec
@@ -278,6 +521,132 @@ func (r *repository) DeleteTurno(ctx context.Context, id int) error {
 	if rowsAffected < 1 {
 		return ErrNotFound
 	}
-
 	return nil
 }
+
+// actualizar solo el estado del turno
+func (r *repository) UpdateEstado(ctx context.Context, id int, version int, estado string) error {
+	// ejecuto query
+	result, err := r.conexion(ctx).ExecContext(ctx, r.q.updateEstado, estado, id, version)
+
+	// verifico error
+	if err != nil {
+		return ErrStatement
+	}
+
+	// verifico filas afectadas
+	return r.verificarVersion(ctx, result, id)
+}
+
+// obtener los turnos del odontólogo entre dos fechas, ordenados por horario
+func (r *repository) GetAgenda(ctx context.Context, idOdontologo int, desde time.Time, hasta time.Time) ([]Turno, error) {
+	rows, err := r.conexion(ctx).QueryContext(ctx, r.q.getAgenda, idOdontologo, desde, hasta)
+	if err != nil {
+		return []Turno{}, ErrEmptyList
+	}
+	defer rows.Close()
+
+	turnos := []Turno{}
+	for rows.Next() {
+		var turno Turno
+		err := rows.Scan(
+			&turno.ID,
+			&turno.IdOdontologo,
+			&turno.IdPaciente,
+			&turno.FechaHora,
+			&turno.Descripcion,
+			&turno.CodigoPrestacion,
+			&turno.Estado,
+			&turno.Version,
+		)
+		if err != nil {
+			return []Turno{}, ErrExec
+		}
+		turnos = append(turnos, turno)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []Turno{}, ErrExec
+	}
+
+	return turnos, nil
+}
+
+// registrar una ausencia o cancelación tardía
+func (r *repository) CreateIncidencia(ctx context.Context, incidencia Incidencia) (Incidencia, error) {
+	result, err := basedatos.Insertar(ctx, r.conexion(ctx), r.q.returning, r.q.insertIncidencia,
+		incidencia.IdTurno,
+		incidencia.IdPaciente,
+		incidencia.Tipo,
+		incidencia.Fecha,
+		incidencia.Penalidad,
+	)
+	if err != nil {
+		return Incidencia{}, ErrExec
+	}
+
+	lastId, err := result.LastInsertId()
+	if err != nil {
+		return Incidencia{}, ErrLastId
+	}
+	incidencia.ID = int(lastId)
+	return incidencia, nil
+}
+
+// obtener el historial de incidencias del paciente, de la más nueva a la más vieja
+func (r *repository) GetIncidenciasByPaciente(ctx context.Context, idPaciente int) ([]Incidencia, error) {
+	rows, err := r.conexion(ctx).QueryContext(ctx, r.q.getIncidenciasByPaciente, idPaciente)
+	if err != nil {
+		return []Incidencia{}, ErrStatement
+	}
+	defer rows.Close()
+
+	incidencias := []Incidencia{}
+	for rows.Next() {
+		var incidencia Incidencia
+		err := rows.Scan(
+			&incidencia.ID,
+			&incidencia.IdTurno,
+			&incidencia.IdPaciente,
+			&incidencia.Tipo,
+			&incidencia.Fecha,
+			&incidencia.Penalidad,
+		)
+		if err != nil {
+			return []Incidencia{}, ErrExec
+		}
+		incidencias = append(incidencias, incidencia)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []Incidencia{}, ErrExec
+	}
+
+	return incidencias, nil
+}
+
+// obtener la incidencia de un turno; cada turno tiene a lo sumo una
+func (r *repository) GetIncidenciaByTurno(ctx context.Context, idTurno int) (Incidencia, error) {
+	var incidencia Incidencia
+	err := r.conexion(ctx).QueryRowContext(ctx, r.q.getIncidenciaByTurno, idTurno).Scan(
+		&incidencia.ID,
+		&incidencia.IdTurno,
+		&incidencia.IdPaciente,
+		&incidencia.Tipo,
+		&incidencia.Fecha,
+		&incidencia.Penalidad,
+	)
+	if err != nil {
+		return Incidencia{}, ErrIncidencia
+	}
+	return incidencia, nil
+}
+
+// contar los turnos no cancelados del paciente con el odontólogo
+func (r *repository) CountTurnosOdontologoPaciente(ctx context.Context, idOdontologo int, idPaciente int) (int, error) {
+	var total int
+	if err := r.conexion(ctx).QueryRowContext(ctx, r.q.countOdontologoPaciente, idOdontologo, idPaciente).Scan(&total); err != nil {
+		return 0, ErrExec
+	}
+	return total, nil
+}
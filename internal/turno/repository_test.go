@@ -0,0 +1,36 @@
+package turno_test
+
+import (
+	"testing"
+
+	"finalgo/internal/contrato/contratotest"
+	"finalgo/internal/odontologo"
+	"finalgo/internal/paciente"
+	"finalgo/internal/turno"
+	"finalgo/pkg/config"
+)
+
+func TestRepositorySqlite(t *testing.T) {
+	contratotest.Turnos(t, func(t *testing.T) (turno.Repository, paciente.Repository, odontologo.Repository) {
+		db := contratotest.Base(t, config.MotorSQLite)
+		return turno.NewRepositorySqlite(db), paciente.NewRepositorySqlite(db), odontologo.NewRepositorySqlite(db)
+	})
+}
+
+// TestRepositoryMySql corre contra la base de PRUEBAS_MYSQL_DSN; sin ella se saltea
+func TestRepositoryMySql(t *testing.T) {
+	contratotest.Requiere(t, config.MotorMySQL)
+	contratotest.Turnos(t, func(t *testing.T) (turno.Repository, paciente.Repository, odontologo.Repository) {
+		db := contratotest.Base(t, config.MotorMySQL)
+		return turno.NewRepositoryMySql(db), paciente.NewRepositoryMySql(db), odontologo.NewRepositoryMySql(db)
+	})
+}
+
+// TestRepositoryPostgres corre contra la base de PRUEBAS_POSTGRES_DSN; sin ella se saltea
+func TestRepositoryPostgres(t *testing.T) {
+	contratotest.Requiere(t, config.MotorPostgres)
+	contratotest.Turnos(t, func(t *testing.T) (turno.Repository, paciente.Repository, odontologo.Repository) {
+		db := contratotest.Base(t, config.MotorPostgres)
+		return turno.NewRepositoryPostgres(db), paciente.NewRepositoryPostgres(db), odontologo.NewRepositoryPostgres(db)
+	})
+}
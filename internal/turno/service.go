@@ -2,9 +2,43 @@ package turno
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"finalgo/internal/obrasocial"
 	"finalgo/internal/odontologo"
 	"finalgo/internal/paciente"
+	"finalgo/pkg/auth"
+	"finalgo/pkg/transaccion"
 	"log"
+	"sort"
+	"time"
+)
+
+// qué hacer al atender una prestación sin consentimiento informado vigente
+const (
+	ConsentimientoAdvertir = "advertir"
+	ConsentimientoBloquear = "bloquear"
+)
+
+// Consentimientos verifica que el paciente haya firmado el consentimiento que exige la prestación.
+// Lo implementa consentimiento.Service; se define acá para que turno no dependa de ese paquete.
+type Consentimientos interface {
+	VerificarConsentimiento(ctx context.Context, idPaciente int, codigoPrestacion string, fecha time.Time) error
+}
+
+// Auditoria registra quién cambió qué y cómo estaba antes. Lo implementa auditoria.Service; se define acá para que
+// turno no dependa de ese paquete.
+type Auditoria interface {
+	Alta(ctx context.Context, entidad string, id int, despues interface{}) error
+	Modificacion(ctx context.Context, entidad string, id int, antes, despues interface{}) error
+	Baja(ctx context.Context, entidad string, id int, antes interface{}) error
+	Restauracion(ctx context.Context, entidad string, id int, despues interface{}) error
+}
+
+// entidades que se registran en la auditoría
+const (
+	entidadTurno      = "turno"
+	entidadIncidencia = "incidencia_turno"
 )
 
 // defino la interfaz para que se apliquen siempre todos los métodos
@@ -12,26 +46,63 @@ type Service interface {
 	GetTurnoByID(ctx context.Context, id int) (Turno, error)
 	GetAll(ctx context.Context) ([]Turno, error)
 	CreateTurno(ctx context.Context, t TurnoRequest) (Turno, error)
-	UpdateTurno(ctx context.Context, t TurnoRequest, id int) (Turno, error)
-	DeleteTurno(ctx context.Context, id int) error
+	UpdateTurno(ctx context.Context, t TurnoRequest, id int, version int) (Turno, error)
+	DeleteTurno(ctx context.Context, id int, version int) error
+	GetEliminados(ctx context.Context) ([]Turno, error)
+	GetTurnosEliminadosEn(ctx context.Context, deletedAt time.Time, idPaciente int, idOdontologo int) ([]Turno, error)
+	RestaurarTurno(ctx context.Context, id int) (Turno, error)
 	GetTurnoByPaciente(ctx context.Context, dniPaciente string) ([]Turno, error)
 	GetTurnoByOdontologo(ctx context.Context, idOdontolog int) ([]Turno, error)
 	CreateTurnoByDniAndMatricula(ctx context.Context, t TurnoDniMatriculaRequest) (Turno, error)
+	AtenderTurno(ctx context.Context, id int, version int) (Turno, error)
+	GetAgenda(ctx context.Context, idOdontologo int, fecha time.Time) ([]Turno, error)
+	GetDisponibilidad(ctx context.Context, idOdontologo int, especialidad string, desde, hasta time.Time, max int) ([]Bloque, error)
+	ReservarTurno(ctx context.Context, t TurnoRequest) (Turno, error)
+	ConfirmarTurno(ctx context.Context, id int) (Turno, error)
+	CancelarTurno(ctx context.Context, id int, version int, origen string) (Turno, error)
+	MarcarAusente(ctx context.Context, id int, version int) (Turno, error)
+	GetAsistencia(ctx context.Context, idPaciente int) (Asistencia, error)
+	GetIncidenciaByTurno(ctx context.Context, idTurno int) (Incidencia, error)
+	AtiendeAPaciente(ctx context.Context, idOdontologo int, idPaciente int) (bool, error)
+	AtiendeTurno(ctx context.Context, idOdontologo int, idTurno int) (bool, error)
+}
+
+// Config reúne las opciones del servicio que vienen de la configuración. El horario en cero toma HorarioPorDefecto
+// y la política de cancelación en cero no penaliza ni restringe a nadie.
+type Config struct {
+	ModoConsentimiento string
+	Horario            Horario
+	Cancelacion        PoliticaCancelacion
 }
 
-// estrucutra service que contará con un repositorio
+// estrucutra service que contará con un repositorio. Cada cambio y su registro de auditoría van en una misma unidad
+// de trabajo.
 type service struct {
-	r  Repository
-	ps paciente.Service
-	os odontologo.Service
+	r   Repository
+	uow transaccion.UnitOfWork
+	ps  paciente.Service
+	os  odontologo.Service
+	cs  Consentimientos
+	obs obrasocial.Service
+	a   Auditoria
+	cfg Config
 }
 
-// función para instanciar service
-func NewService(r Repository, ps paciente.Service, os odontologo.Service) Service {
+// función para instanciar service. cs puede ser nil cuando el servicio no se usa para atender turnos, y obs cuando
+// no se dan turnos desde la recepción (sin obs no se avisa de las prestaciones que piden autorización previa).
+func NewService(r Repository, uow transaccion.UnitOfWork, ps paciente.Service, os odontologo.Service, cs Consentimientos, obs obrasocial.Service, a Auditoria, cfg Config) Service {
+	if cfg.Horario.Duracion == 0 {
+		cfg.Horario = HorarioPorDefecto()
+	}
 	return &service{
 		r,
+		uow,
 		ps,
 		os,
+		cs,
+		obs,
+		a,
+		cfg,
 	}
 }
 
@@ -43,7 +114,7 @@ func (s *service) GetAll(ctx context.Context) ([]Turno, error) {
 		log.Println("log de error en service de turnos", err.Error())
 		return []Turno{}, ErrEmptyList
 	}
-	return turnos, nil
+	return s.conAlertas(ctx, turnos), nil
 }
 
 func (s *service) GetTurnoByID(ctx context.Context, id int) (Turno, error) {
@@ -52,7 +123,7 @@ func (s *service) GetTurnoByID(ctx context.Context, id int) (Turno, error) {
 		log.Println("log de error por turno inexistente", err.Error())
 		return Turno{}, ErrNotFound
 	}
-	return p, nil
+	return s.conAlertas(ctx, []Turno{p})[0], nil
 }
 
 func (s *service) GetTurnoByPaciente(ctx context.Context, dniPaciente string) ([]Turno, error) {
@@ -67,7 +138,7 @@ func (s *service) GetTurnoByPaciente(ctx context.Context, dniPaciente string) ([
 		log.Println("log de error por turno inexistente", err.Error())
 		return []Turno{}, ErrNotFound
 	}
-	return t, nil
+	return s.conAlertas(ctx, t), nil
 }
 
 func (s *service) GetTurnoByOdontologo(ctx context.Context, idOdontologo int) ([]Turno, error) {
@@ -82,7 +153,7 @@ func (s *service) GetTurnoByOdontologo(ctx context.Context, idOdontologo int) ([
 		log.Println("log de error por turno inexistente", err.Error())
 		return []Turno{}, ErrNotFound
 	}
-	return t, nil
+	return s.conAlertas(ctx, t), nil
 }
 
 
@@ -90,12 +161,406 @@ func (s *service) GetTurnoByOdontologo(ctx context.Context, idOdontologo int) ([
 func (s *service) CreateTurno(ctx context.Context, turnoRequest TurnoRequest) (Turno, error) {
 	// uso la estructura de request para mejor manejo de campos (no tiene el ID), llamando a una función que lo transforma en el dato que requiere la DB
 	turno := requestToTurno(turnoRequest)
-	response, err := s.r.CreateTurno(ctx, turno)
+
+	// sin odontólogo pero con especialidad, asigno el de esa especialidad con el primer turno libre. Si otro pedido
+	// toma ese bloque entre la búsqueda y el alta, la base lo rechaza y se vuelve a buscar
+	asignar := turno.IdOdontologo == 0 && turnoRequest.Especialidad != ""
+	for intento := 1; ; intento++ {
+		if asignar {
+			turno.FechaHora = turnoRequest.FechaHora
+			if err := s.asignarOdontologo(ctx, &turno, turnoRequest.Especialidad); err != nil {
+				return Turno{}, err
+			}
+		}
+
+		if err := s.verificarResponsable(ctx, turno); err != nil {
+			return Turno{}, err
+		}
+		var response Turno
+		err := s.auditado(ctx, func(ctx context.Context) (err error) {
+			response, err = s.r.CreateTurno(ctx, turno)
+			return err
+		}, func(ctx context.Context) error {
+			return s.a.Alta(ctx, entidadTurno, response.ID, response)
+		})
+		if errors.Is(err, ErrBloqueOcupado) {
+			if asignar && intento < intentosAsignacion {
+				continue
+			}
+			return Turno{}, ErrBloqueOcupado
+		}
+		if err != nil {
+			log.Println("error al crear turno")
+			return Turno{}, ErrExec
+		}
+		response.Advertencias = append(response.Advertencias, s.avisoAutorizacion(ctx, response)...)
+		return response, nil
+	}
+}
+
+// avisoAutorizacion deja una advertencia en el turno cuando la obra social del paciente pide autorización previa para
+// la prestación, así la recepción la tramita antes de la fecha. No bloquea el alta: la autorización se consigue con el
+// turno ya dado. Si no se puede consultar la cobertura, también se avisa para que nadie asuma que no hace falta.
+func (s *service) avisoAutorizacion(ctx context.Context, turno Turno) []string {
+	if s.obs == nil || turno.CodigoPrestacion == "" {
+		return nil
+	}
+	cobertura, err := s.obs.GetCoberturaPrestacion(ctx, turno.IdPaciente, turno.CodigoPrestacion, turno.FechaHora)
+	if errors.Is(err, obrasocial.ErrSinCobertura) {
+		return nil
+	}
 	if err != nil {
-		log.Println("error al crear turno")
-		return Turno{}, ErrExec
+		log.Println("log de error en cobertura del paciente", err.Error())
+		return []string{"no se pudo verificar si la obra social pide autorización previa para la prestación"}
+	}
+	if !cobertura.RequiereAutorizacion {
+		return nil
+	}
+	return []string{fmt.Sprintf("la obra social del paciente pide autorización previa para la prestación %s", turno.CodigoPrestacion)}
+}
+
+// auditado aplica el cambio y lo registra en la auditoría en una sola transacción: si el registro falla, el cambio se
+// deshace y el error es ErrExec. Los errores del cambio vuelven tal cual.
+func (s *service) auditado(ctx context.Context, cambio func(ctx context.Context) error, registro func(ctx context.Context) error) error {
+	return s.uow.Ejecutar(ctx, func(ctx context.Context) error {
+		if err := cambio(ctx); err != nil {
+			return err
+		}
+		if err := registro(ctx); err != nil {
+			return ErrExec
+		}
+		return nil
+	})
+}
+
+// asignarOdontologo elige, entre los odontólogos de la especialidad, el que tenga el primer bloque libre a partir
+// de la fecha pedida (o de ahora, si no se pidió una fecha futura). El turno queda con ese odontólogo y ese horario.
+// A igual horario gana el de menor ID, así la asignación es predecible.
+func (s *service) asignarOdontologo(ctx context.Context, turno *Turno, especialidad string) error {
+	odontologos, err := s.os.GetOdontologosByEspecialidad(ctx, especialidad)
+	if err != nil {
+		log.Println("log de error en odontologos por especialidad", err.Error())
+		return ErrExec
+	}
+	if len(odontologos) == 0 {
+		return ErrEspecialidad
+	}
+
+	desde := time.Now()
+	if turno.FechaHora.After(desde) {
+		desde = turno.FechaHora
+	}
+	hasta := desde.AddDate(0, 0, horizonteBusqueda)
+
+	var elegido int
+	var primerBloque time.Time
+	for _, o := range odontologos {
+		ocupados, err := s.ocupados(ctx, o.ID, desde, hasta)
+		if err != nil {
+			return err
+		}
+
+		bloque, ok := s.cfg.Horario.primerLibre(desde, hasta, ocupados)
+		if ok && (elegido == 0 || bloque.Before(primerBloque)) {
+			elegido = o.ID
+			primerBloque = bloque
+		}
+	}
+	if elegido == 0 {
+		return ErrSinDisponibilidad
+	}
+
+	turno.IdOdontologo = elegido
+	turno.FechaHora = primerBloque
+	return nil
+}
+
+// ocupados devuelve los horarios de los turnos no cancelados del odontólogo que pueden superponerse con el período
+func (s *service) ocupados(ctx context.Context, idOdontologo int, desde, hasta time.Time) ([]time.Time, error) {
+	agenda, err := s.r.GetAgenda(ctx, idOdontologo, desde.Add(-s.cfg.Horario.Duracion), hasta)
+	if err != nil {
+		log.Println("log de error en agenda del odontologo", err.Error())
+		return nil, ErrExec
+	}
+	ocupados := make([]time.Time, 0, len(agenda))
+	for _, t := range agenda {
+		if t.Estado != EstadoCancelado {
+			ocupados = append(ocupados, t.FechaHora)
+		}
+	}
+	return ocupados, nil
+}
+
+// GetDisponibilidad lista los bloques libres entre desde y hasta, del odontólogo indicado o de todos los de la
+// especialidad, ordenados por horario y limitados a max.
+func (s *service) GetDisponibilidad(ctx context.Context, idOdontologo int, especialidad string, desde, hasta time.Time, max int) ([]Bloque, error) {
+	var ids []int
+	if idOdontologo != 0 {
+		if _, err := s.os.GetOdontologoByID(ctx, idOdontologo); err != nil {
+			log.Println("log de error por odontologo inexistente", err.Error())
+			return []Bloque{}, ErrNotFound
+		}
+		ids = []int{idOdontologo}
+	} else {
+		odontologos, err := s.os.GetOdontologosByEspecialidad(ctx, especialidad)
+		if err != nil {
+			log.Println("log de error en odontologos por especialidad", err.Error())
+			return []Bloque{}, ErrExec
+		}
+		if len(odontologos) == 0 {
+			return []Bloque{}, ErrEspecialidad
+		}
+		for _, o := range odontologos {
+			ids = append(ids, o.ID)
+		}
+	}
+
+	bloques := []Bloque{}
+	for _, id := range ids {
+		ocupados, err := s.ocupados(ctx, id, desde, hasta)
+		if err != nil {
+			return []Bloque{}, err
+		}
+		for _, libre := range s.cfg.Horario.libres(desde, hasta, ocupados, max) {
+			bloques = append(bloques, Bloque{IdOdontologo: id, FechaHora: libre})
+		}
+	}
+
+	// mezclo los de todos los odontólogos y me quedo con los primeros
+	sort.SliceStable(bloques, func(i, j int) bool {
+		return bloques[i].FechaHora.Before(bloques[j].FechaHora)
+	})
+	if len(bloques) > max {
+		bloques = bloques[:max]
+	}
+	return bloques, nil
+}
+
+// ReservarTurno crea el turno sólo si el horario pedido es un bloque del horario de atención y está libre; es la
+// alta que usa el portal de pacientes, donde no hay una recepcionista que controle la agenda.
+func (s *service) ReservarTurno(ctx context.Context, turnoRequest TurnoRequest) (Turno, error) {
+	// al paciente que llegó al máximo de ausencias se lo bloquea o se deja el aviso en el turno, según la política
+	asistencia, err := s.GetAsistencia(ctx, turnoRequest.IdPaciente)
+	if err != nil {
+		return Turno{}, err
+	}
+	var advertencias []string
+	if asistencia.Restringido {
+		if s.cfg.Cancelacion.Restriccion == RestriccionBloquear {
+			return Turno{}, ErrRestringido
+		}
+		advertencias = append(advertencias, fmt.Sprintf("el paciente tiene %d ausencias sin aviso", asistencia.Ausencias))
+	}
+
+	turno, err := s.reservar(ctx, turnoRequest)
+	if err != nil {
+		return Turno{}, err
+	}
+	turno.Advertencias = append(turno.Advertencias, advertencias...)
+	return turno, nil
+}
+
+// reservar crea el turno controlando el horario
+func (s *service) reservar(ctx context.Context, turnoRequest TurnoRequest) (Turno, error) {
+	if turnoRequest.IdOdontologo == 0 {
+		return s.CreateTurno(ctx, turnoRequest)
+	}
+	if !s.cfg.Horario.esBloque(turnoRequest.FechaHora) {
+		return Turno{}, ErrBloqueOcupado
+	}
+	ocupados, err := s.ocupados(ctx, turnoRequest.IdOdontologo, turnoRequest.FechaHora, turnoRequest.FechaHora.Add(s.cfg.Horario.Duracion))
+	if err != nil {
+		return Turno{}, err
+	}
+	if !s.cfg.Horario.libre(turnoRequest.FechaHora, ocupados) {
+		return Turno{}, ErrBloqueOcupado
+	}
+	return s.CreateTurno(ctx, turnoRequest)
+}
+
+// ConfirmarTurno marca como confirmado un turno pendiente
+func (s *service) ConfirmarTurno(ctx context.Context, id int) (Turno, error) {
+	turno, err := s.r.GetTurnoByID(ctx, id)
+	if err != nil {
+		log.Println("log de error por turno inexistente", err.Error())
+		return Turno{}, ErrNotFound
+	}
+	return s.cambiarEstado(ctx, turno, EstadoConfirmado, EstadoPendiente)
+}
+
+// CancelarTurno cancela un turno que todavía no se atendió y libera el horario. Si no se respeta el aviso mínimo de la
+// política, el paciente no puede cancelarlo por su cuenta; la clínica sí, y queda como cancelación tardía.
+func (s *service) CancelarTurno(ctx context.Context, id int, version int, origen string) (Turno, error) {
+	turno, err := s.r.GetTurnoByID(ctx, id)
+	if err != nil {
+		log.Println("log de error por turno inexistente", err.Error())
+		return Turno{}, ErrNotFound
+	}
+	if turno.Version != version {
+		return Turno{}, ErrVersion
+	}
+	if turno.Estado != EstadoPendiente && turno.Estado != EstadoConfirmado {
+		return Turno{}, ErrEstado
+	}
+	tardia := s.cfg.Cancelacion.tardia(turno, time.Now())
+	if tardia && origen == OrigenPaciente {
+		return Turno{}, ErrCancelacionTardia
+	}
+
+	// la cancelación y su incidencia van juntas: si no se puede registrar la incidencia, el turno sigue sin cancelar
+	err = s.uow.Ejecutar(ctx, func(ctx context.Context) (err error) {
+		turno, err = s.cambiarEstado(ctx, turno, EstadoCancelado, EstadoPendiente, EstadoConfirmado)
+		if err != nil || !tardia {
+			return err
+		}
+		return s.registrarIncidencia(ctx, turno, IncidenciaCancelacionTardia)
+	})
+	if err != nil {
+		return Turno{}, err
+	}
+	return turno, nil
+}
+
+// MarcarAusente registra que el paciente no vino a un turno que ya pasó, con la penalidad de la política
+func (s *service) MarcarAusente(ctx context.Context, id int, version int) (Turno, error) {
+	turno, err := s.r.GetTurnoByID(ctx, id)
+	if err != nil {
+		log.Println("log de error por turno inexistente", err.Error())
+		return Turno{}, ErrNotFound
+	}
+	if turno.Version != version {
+		return Turno{}, ErrVersion
+	}
+	if turno.FechaHora.After(time.Now()) {
+		return Turno{}, ErrAusencia
+	}
+
+	// el cambio de estado y la ausencia van juntos, así el historial del paciente no queda sin la incidencia
+	err = s.uow.Ejecutar(ctx, func(ctx context.Context) (err error) {
+		turno, err = s.cambiarEstado(ctx, turno, EstadoAusente, EstadoPendiente, EstadoConfirmado)
+		if err != nil {
+			return err
+		}
+		return s.registrarIncidencia(ctx, turno, IncidenciaAusencia)
+	})
+	if err != nil {
+		if errors.Is(err, ErrEstado) {
+			return Turno{}, ErrAusencia
+		}
+		return Turno{}, err
+	}
+	return turno, nil
+}
+
+// registrarIncidencia guarda la ausencia o cancelación tardía. Se llama dentro de la unidad de trabajo del cambio de
+// estado: si falla, devuelve ErrExec y el turno vuelve al estado que tenía.
+func (s *service) registrarIncidencia(ctx context.Context, turno Turno, tipo string) error {
+	var incidencia Incidencia
+	err := s.auditado(ctx, func(ctx context.Context) (err error) {
+		incidencia, err = s.r.CreateIncidencia(ctx, Incidencia{
+			IdTurno:    turno.ID,
+			IdPaciente: turno.IdPaciente,
+			Tipo:       tipo,
+			Fecha:      turno.FechaHora,
+			Penalidad:  s.cfg.Cancelacion.Penalidad,
+		})
+		return err
+	}, func(ctx context.Context) error {
+		return s.a.Alta(ctx, entidadIncidencia, incidencia.ID, incidencia)
+	})
+	if err != nil {
+		log.Println("error al registrar incidencia del turno", err.Error())
+		return ErrExec
+	}
+	return nil
+}
+
+// GetAsistencia devuelve las ausencias y cancelaciones tardías del paciente y si está restringido por la política
+func (s *service) GetAsistencia(ctx context.Context, idPaciente int) (Asistencia, error) {
+	if _, err := s.ps.GetPacienteByID(ctx, idPaciente); err != nil {
+		log.Println("log de error por paciente inexistente", err.Error())
+		return Asistencia{}, ErrNotFound
+	}
+
+	incidencias, err := s.r.GetIncidenciasByPaciente(ctx, idPaciente)
+	if err != nil {
+		log.Println("error al obtener incidencias del paciente", err.Error())
+		return Asistencia{}, ErrExec
+	}
+	return s.cfg.Cancelacion.asistencia(idPaciente, incidencias, time.Now()), nil
+}
+
+// AtiendeAPaciente indica si el paciente tiene o tuvo turnos (no cancelados) con el odontólogo, que es lo que hace
+// que sea su paciente
+func (s *service) AtiendeAPaciente(ctx context.Context, idOdontologo int, idPaciente int) (bool, error) {
+	if idOdontologo == 0 {
+		return false, nil
+	}
+	total, err := s.r.CountTurnosOdontologoPaciente(ctx, idOdontologo, idPaciente)
+	if err != nil {
+		log.Println("error al contar turnos del odontólogo con el paciente", err.Error())
+		return false, ErrExec
+	}
+	return total > 0, nil
+}
+
+// AtiendeTurno indica si el turno es del odontólogo. Un turno inexistente no es de nadie, así que no es un error.
+func (s *service) AtiendeTurno(ctx context.Context, idOdontologo int, idTurno int) (bool, error) {
+	if idOdontologo == 0 {
+		return false, nil
+	}
+	turno, err := s.r.GetTurnoByID(ctx, idTurno)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		log.Println("error al buscar el odontólogo del turno", err.Error())
+		return false, ErrExec
+	}
+	return turno.IdOdontologo == idOdontologo, nil
+}
+
+// GetIncidenciaByTurno devuelve la ausencia o cancelación tardía del turno, si la hubo
+func (s *service) GetIncidenciaByTurno(ctx context.Context, idTurno int) (Incidencia, error) {
+	return s.r.GetIncidenciaByTurno(ctx, idTurno)
+}
+
+// cambiarEstado pasa el turno leído al estado nuevo si está en alguno de los estados permitidos. El cambio se aplica
+// sobre la versión leída: si otro pedido tocó el turno entretanto, lo validado ya no vale y no se cambia nada.
+func (s *service) cambiarEstado(ctx context.Context, turno Turno, nuevo string, permitidos ...string) (Turno, error) {
+	permitido := false
+	for _, estado := range permitidos {
+		permitido = permitido || turno.Estado == estado
+	}
+	if !permitido {
+		return Turno{}, ErrEstado
+	}
+
+	antes := turno
+	turno.Estado = nuevo
+	turno.Version++
+	err := s.auditado(ctx, func(ctx context.Context) error {
+		return s.r.UpdateEstado(ctx, antes.ID, antes.Version, nuevo)
+	}, func(ctx context.Context) error {
+		return s.a.Modificacion(ctx, entidadTurno, turno.ID, antes, turno)
+	})
+	if err != nil {
+		log.Println("error al cambiar el estado del turno", err.Error())
+		return Turno{}, errorEstado(err)
+	}
+	return turno, nil
+}
+
+// errorEstado traduce el error de UpdateEstado: el turno se dio de baja o cambió después de leerlo
+func errorEstado(err error) error {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return ErrNotFound
+	case errors.Is(err, ErrVersion):
+		return ErrConcurrente
+	default:
+		return ErrExec
 	}
-	return response, nil
 }
 
 func (s *service) CreateTurnoByDniAndMatricula(ctx context.Context, t TurnoDniMatriculaRequest) (Turno, error) {
@@ -113,48 +578,246 @@ func (s *service) CreateTurnoByDniAndMatricula(ctx context.Context, t TurnoDniMa
 	}
 
 	turnoRequest := TurnoRequest{
-		IdOdontologo: IdOdontologo,
-		IdPaciente:   idPaciente,
-		FechaHora:    t.FechaHora,
-		Descripcion:  t.Descripcion,
-	}
-	turno := requestToTurno(turnoRequest)
-	response, err := s.r.CreateTurno(ctx, turno)
-	if err != nil {
-		log.Println("error al crear turno")
-		return Turno{}, ErrExec
+		IdOdontologo:     IdOdontologo,
+		IdPaciente:       idPaciente,
+		FechaHora:        t.FechaHora,
+		Descripcion:      t.Descripcion,
+		CodigoPrestacion: t.CodigoPrestacion,
 	}
-	return response, nil
+	return s.CreateTurno(ctx, turnoRequest)
 }
 
-func (s *service) DeleteTurno(ctx context.Context, id int) error {
-	err := s.r.DeleteTurno(ctx, id)
+// DeleteTurno da de baja el turno si sigue en la versión indicada; la baja en cascada pasa la que acaba de leer
+func (s *service) DeleteTurno(ctx context.Context, id int, version int) error {
+	antes, err := s.r.GetTurnoByID(ctx, id)
+	if err != nil {
+		log.Println("log de error por turno inexistente", err.Error())
+		return ErrNotFound
+	}
+	// la baja es lógica; en una baja en cascada todos los registros comparten la misma fecha
+	err = s.auditado(ctx, func(ctx context.Context) error {
+		return s.r.DeleteTurno(ctx, id, version, transaccion.Momento(ctx), auth.IdUsuarioDesde(ctx))
+	}, func(ctx context.Context) error {
+		return s.a.Baja(ctx, entidadTurno, id, antes)
+	})
 	if err != nil {
 		log.Println("log de error borrado de turno", err.Error())
+		if errors.Is(err, ErrVersion) || errors.Is(err, ErrExec) {
+			return err
+		}
 		return ErrNotFound
 	}
 	return nil
 }
 
+// GetEliminados devuelve los turnos dados de baja, con la fecha y el usuario de la baja
+func (s *service) GetEliminados(ctx context.Context) ([]Turno, error) {
+	turnos, err := s.r.GetEliminados(ctx)
+	if err != nil {
+		log.Println("log de error en turnos dados de baja", err.Error())
+		return []Turno{}, ErrExec
+	}
+	return turnos, nil
+}
+
+// GetTurnosEliminadosEn devuelve los turnos del paciente o del odontólogo dados de baja en esa fecha: los de una
+// baja en cascada. Al que no se busca se le pasa 0.
+func (s *service) GetTurnosEliminadosEn(ctx context.Context, deletedAt time.Time, idPaciente int, idOdontologo int) ([]Turno, error) {
+	turnos, err := s.r.GetTurnosEliminadosEn(ctx, deletedAt, idPaciente, idOdontologo)
+	if err != nil {
+		log.Println("log de error en turnos dados de baja", err.Error())
+		return []Turno{}, ErrExec
+	}
+	return turnos, nil
+}
+
+// RestaurarTurno vuelve a dar de alta un turno dado de baja. El paciente y el odontólogo tienen que estar activos,
+// si no el turno quedaría apuntando a registros que las consultas ya no muestran.
+func (s *service) RestaurarTurno(ctx context.Context, id int) (Turno, error) {
+	turno, err := s.r.GetEliminadoByID(ctx, id)
+	if err != nil {
+		log.Println("log de error por turno dado de baja", err.Error())
+		if errors.Is(err, ErrNotFound) {
+			return Turno{}, ErrNotFound
+		}
+		return Turno{}, ErrExec
+	}
+	if _, err := s.ps.GetPacienteByID(ctx, turno.IdPaciente); err != nil {
+		return Turno{}, ErrBajaRelacionada
+	}
+	if _, err := s.os.GetOdontologoByID(ctx, turno.IdOdontologo); err != nil {
+		return Turno{}, ErrBajaRelacionada
+	}
+
+	var t Turno
+	err = s.auditado(ctx, func(ctx context.Context) (err error) {
+		if err := s.r.RestaurarTurno(ctx, id); err != nil {
+			return err
+		}
+		t, err = s.GetTurnoByID(ctx, id)
+		return err
+	}, func(ctx context.Context) error {
+		return s.a.Restauracion(ctx, entidadTurno, id, t)
+	})
+	if err != nil {
+		log.Println("log de error al restaurar turno", err.Error())
+		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrBloqueOcupado) {
+			return Turno{}, err
+		}
+		return Turno{}, ErrExec
+	}
+	return t, nil
+}
+
 // este método está preparado para ser usado como PATCH o como PUT, se le deberá pasar desde el handler el turno completo
-func (s *service) UpdateTurno(ctx context.Context, p TurnoRequest, id int) (Turno, error) {
+// y la versión que se leyó: si otro lo modificó mientras tanto, no se pisa su cambio
+func (s *service) UpdateTurno(ctx context.Context, p TurnoRequest, id int, version int) (Turno, error) {
 	// uso la estructura de request para mejor manejo de campos (no tiene el ID), llamando a una función que lo transforma en el dato que requiere la DB
 	turno := requestToTurno(p)
 	turno.ID = id
-	response, err := s.r.UpdateTurno(ctx, turno)
+	turno.Version = version
+
+	// el estado no se modifica por esta vía, conservo el que ya tenía
+	original, err := s.r.GetTurnoByID(ctx, id)
+	if err != nil {
+		log.Println("log de error por turno inexistente", err.Error())
+		return Turno{}, ErrNotFound
+	}
+	turno.Estado = original.Estado
+	if err := s.verificarResponsable(ctx, turno); err != nil {
+		return Turno{}, err
+	}
+
+	var response Turno
+	err = s.auditado(ctx, func(ctx context.Context) (err error) {
+		response, err = s.r.UpdateTurno(ctx, turno)
+		return err
+	}, func(ctx context.Context) error {
+		return s.a.Modificacion(ctx, entidadTurno, id, original, response)
+	})
 	if err != nil {
 		log.Println("error al actualizar turno")
+		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrVersion) || errors.Is(err, ErrBloqueOcupado) {
+			return Turno{}, err
+		}
 		return Turno{}, ErrExec
 	}
 	return response, nil
 }
 
-// función para transformar request en la estructura definida en GO
+// marca el turno como atendido; solo los turnos pendientes o confirmados se pueden atender y es lo que habilita su facturación.
+// Como los otros cambios de estado de la clínica, sólo se aplica sobre la versión que leyó el cliente.
+func (s *service) AtenderTurno(ctx context.Context, id int, version int) (Turno, error) {
+	turno, err := s.r.GetTurnoByID(ctx, id)
+	if err != nil {
+		log.Println("log de error por turno inexistente", err.Error())
+		return Turno{}, ErrNotFound
+	}
+	if turno.Version != version {
+		return Turno{}, ErrVersion
+	}
+	if turno.Estado != EstadoPendiente && turno.Estado != EstadoConfirmado {
+		return Turno{}, ErrEstado
+	}
+
+	// sin consentimiento vigente se bloquea o se atiende igual dejando la advertencia, según la configuración
+	var advertencias []string
+	if s.cs != nil {
+		if err := s.cs.VerificarConsentimiento(ctx, turno.IdPaciente, turno.CodigoPrestacion, turno.FechaHora); err != nil {
+			log.Println("log de error por consentimiento informado", err.Error())
+			if s.cfg.ModoConsentimiento == ConsentimientoBloquear {
+				return Turno{}, ErrConsentimiento
+			}
+			advertencias = append(advertencias, err.Error())
+		}
+	}
+
+	antes := turno
+	turno.Estado = EstadoAtendido
+	turno.Version++
+	err = s.auditado(ctx, func(ctx context.Context) error {
+		return s.r.UpdateEstado(ctx, id, antes.Version, EstadoAtendido)
+	}, func(ctx context.Context) error {
+		return s.a.Modificacion(ctx, entidadTurno, id, antes, turno)
+	})
+	if err != nil {
+		log.Println("error al atender turno", err.Error())
+		return Turno{}, errorEstado(err)
+	}
+	turno.Advertencias = advertencias
+	return s.conAlertas(ctx, []Turno{turno})[0], nil
+}
+
+// GetAgenda devuelve los turnos del odontólogo en el día indicado, en orden y con las alertas de cada paciente.
+func (s *service) GetAgenda(ctx context.Context, idOdontologo int, fecha time.Time) ([]Turno, error) {
+	if _, err := s.os.GetOdontologoByID(ctx, idOdontologo); err != nil {
+		log.Println("log de error por odontologo inexistente", err.Error())
+		return []Turno{}, ErrNotFound
+	}
+
+	desde := time.Date(fecha.Year(), fecha.Month(), fecha.Day(), 0, 0, 0, 0, fecha.Location())
+	turnos, err := s.r.GetAgenda(ctx, idOdontologo, desde, desde.AddDate(0, 0, 1))
+	if err != nil {
+		log.Println("log de error en agenda del odontologo", err.Error())
+		return []Turno{}, ErrEmptyList
+	}
+	return s.conAlertas(ctx, turnos), nil
+}
+
+// verificarResponsable exige que los pacientes menores a la fecha del turno tengan un adulto responsable cargado
+func (s *service) verificarResponsable(ctx context.Context, turno Turno) error {
+	err := s.ps.VerificarResponsable(ctx, turno.IdPaciente, turno.FechaHora)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, paciente.ErrMenorSinResponsable):
+		return ErrResponsable
+	case errors.Is(err, paciente.ErrNotFound):
+		return ErrNotFound
+	default:
+		log.Println("log de error al verificar responsable del paciente", err.Error())
+		return ErrExec
+	}
+}
+
+// conAlertas agrega a cada turno las alertas médicas activas de su paciente, con una sola consulta para todos los
+// pacientes del listado. Si no se pueden obtener, los turnos salen igual pero con una advertencia para que nadie
+// asuma que no hay alertas.
+func (s *service) conAlertas(ctx context.Context, turnos []Turno) []Turno {
+	if len(turnos) == 0 {
+		return turnos
+	}
+	ids := []int{}
+	vistos := map[int]bool{}
+	for _, t := range turnos {
+		if !vistos[t.IdPaciente] {
+			vistos[t.IdPaciente] = true
+			ids = append(ids, t.IdPaciente)
+		}
+	}
+
+	porPaciente, err := s.ps.GetAlertasActivasByPacientes(ctx, ids)
+	if err != nil {
+		log.Println("log de error en alertas de los pacientes", err.Error())
+		for i := range turnos {
+			turnos[i].Advertencias = append(turnos[i].Advertencias, "no se pudieron obtener las alertas médicas del paciente")
+		}
+		return turnos
+	}
+	for i := range turnos {
+		turnos[i].Alertas = porPaciente[turnos[i].IdPaciente]
+	}
+	return turnos
+}
+
+// función para transformar request en la estructura definida en GO. Todo turno nuevo nace pendiente.
 func requestToTurno(turnoRequest TurnoRequest) Turno {
 	var turno Turno
 	turno.IdOdontologo = turnoRequest.IdOdontologo
 	turno.IdPaciente = turnoRequest.IdPaciente
 	turno.FechaHora = turnoRequest.FechaHora
 	turno.Descripcion = turnoRequest.Descripcion
+	turno.CodigoPrestacion = turnoRequest.CodigoPrestacion
+	turno.Estado = EstadoPendiente
 	return turno
 }
@@ -0,0 +1,95 @@
+package turno_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"finalgo/internal/auditoria"
+	"finalgo/internal/contrato/contratotest"
+	"finalgo/internal/obrasocial"
+	"finalgo/internal/odontologo"
+	"finalgo/internal/paciente"
+	"finalgo/internal/turno"
+	"finalgo/pkg/config"
+	"finalgo/pkg/transaccion"
+)
+
+// la ausencia y su incidencia van juntas: si la incidencia no se puede grabar, el turno sigue pendiente
+func TestMarcarAusenteConIncidencia(t *testing.T) {
+	ctx := context.Background()
+	db := contratotest.Base(t, config.MotorSQLite)
+	uow := transaccion.NewUnitOfWork(db)
+	a := auditoria.NewService(auditoria.NewRepositorySqlite(db))
+	ps := paciente.NewService(paciente.NewRepositorySqlite(db), uow, a)
+	os := odontologo.NewService(odontologo.NewRepositorySqlite(db), uow, a)
+	r := turno.NewRepositorySqlite(db)
+	s := turno.NewService(r, uow, ps, os, nil, nil, a, turno.Config{})
+
+	p, err := ps.CreatePaciente(ctx, paciente.PacienteRequest{Nombre: "Ana", Apellido: "Pérez", DNI: "30111222"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	o, err := os.CreateOdontologo(ctx, odontologo.OdontologoRequest{Apellido: "Ruiz", Nombre: "Ana", Matricula: "MP-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pasado := time.Now().Add(-48 * time.Hour).Truncate(time.Hour)
+	tu, err := r.CreateTurno(ctx, turno.Turno{IdPaciente: p.ID, IdOdontologo: o.ID, FechaHora: pasado, Descripcion: "control", CodigoPrestacion: "CONSULTA", Estado: turno.EstadoPendiente})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// una incidencia previa del mismo turno hace fallar el alta de la nueva (hay una sola por turno)
+	if _, err := r.CreateIncidencia(ctx, turno.Incidencia{IdTurno: tu.ID, IdPaciente: p.ID, Tipo: turno.IncidenciaAusencia, Fecha: pasado}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.MarcarAusente(ctx, tu.ID, tu.Version); !errors.Is(err, turno.ErrExec) {
+		t.Fatalf("sin incidencia la ausencia tiene que fallar, vino %v", err)
+	}
+	guardado, err := r.GetTurnoByID(ctx, tu.ID)
+	if err != nil || guardado.Estado != turno.EstadoPendiente || guardado.Version != tu.Version {
+		t.Fatalf("el turno cambió aunque la incidencia falló: %+v, %v", guardado, err)
+	}
+}
+
+// el turno de una prestación que la obra social autoriza antes sale con el aviso para la recepción
+func TestCreateTurnoAvisaAutorizacion(t *testing.T) {
+	ctx := context.Background()
+	db := contratotest.Base(t, config.MotorSQLite)
+	uow := transaccion.NewUnitOfWork(db)
+	a := auditoria.NewService(auditoria.NewRepositorySqlite(db))
+	ps := paciente.NewService(paciente.NewRepositorySqlite(db), uow, a)
+	os := odontologo.NewService(odontologo.NewRepositorySqlite(db), uow, a)
+	obs := obrasocial.NewService(obrasocial.NewRepositorySqlite(db))
+	s := turno.NewService(turno.NewRepositorySqlite(db), uow, ps, os, nil, obs, a, turno.Config{})
+
+	p, err := ps.CreatePaciente(ctx, paciente.PacienteRequest{Nombre: "Ana", Apellido: "Pérez", DNI: "30111222"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	o, err := os.CreateOdontologo(ctx, odontologo.OdontologoRequest{Apellido: "Ruiz", Nombre: "Ana", Matricula: "MP-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	obra, err := obs.CreateObraSocial(ctx, obrasocial.ObraSocialRequest{Nombre: "Obra Social de Prueba", Sigla: "OSP", CUIT: "30-11111111-1", Tipo: obrasocial.TipoObraSocial})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := obs.CreateCobertura(ctx, obrasocial.CoberturaRequest{IdObraSocial: obra.ID, NumeroAfiliado: "123", VigenciaDesde: time.Now().AddDate(-1, 0, 0)}, p.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := obs.CreateRegla(ctx, obrasocial.ReglaCoberturaRequest{CodigoPrestacion: "CONSULTA", PorcentajeCubierto: 100, RequiereAutorizacion: true}, obra.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	pedido := turno.TurnoRequest{IdPaciente: p.ID, IdOdontologo: o.ID, FechaHora: time.Now().Add(48 * time.Hour).Truncate(time.Hour), Descripcion: "control", CodigoPrestacion: "CONSULTA"}
+	tu, err := s.CreateTurno(ctx, pedido)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tu.Advertencias) != 1 {
+		t.Fatalf("se esperaba el aviso de autorización previa, vino %v", tu.Advertencias)
+	}
+}
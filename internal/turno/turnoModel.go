@@ -1,22 +1,57 @@
 package turno
 
-import "time"
+import (
+	"time"
 
-// creamos la estructura del turno.
+	"finalgo/internal/paciente"
+)
+
+// estados por los que pasa un turno. El paciente puede confirmarlo o cancelarlo desde el portal; un turno cancelado
+// libera el horario. Ausente es el turno al que el paciente no vino sin avisar.
+const (
+	EstadoPendiente  = "pendiente"
+	EstadoConfirmado = "confirmado"
+	EstadoAtendido   = "atendido"
+	EstadoCancelado  = "cancelado"
+	EstadoAusente    = "ausente"
+)
+
+// tipos de incidencia que cuentan en el historial de asistencia del paciente
+const (
+	IncidenciaAusencia          = "ausencia"
+	IncidenciaCancelacionTardia = "cancelacion_tardia"
+)
+
+// creamos la estructura del turno. CodigoPrestacion referencia al catálogo de prestaciones y es lo que se factura.
 type Turno struct {
-	ID           int       `json:"id"`
-	IdOdontologo int       `json:"id_odontologo"`
-	IdPaciente   int       `json:"id_paciente"`
-	FechaHora    time.Time `json:"fecha_hora"`
-	Descripcion  string    `json:"descripcion"`
+	ID               int       `json:"id"`
+	IdOdontologo     int       `json:"id_odontologo"`
+	IdPaciente       int       `json:"id_paciente"`
+	FechaHora        time.Time `json:"fecha_hora"`
+	Descripcion      string    `json:"descripcion"`
+	CodigoPrestacion string    `json:"codigo_prestacion"`
+	Estado           string    `json:"estado"`
+	// avisos para quien atiende (por ejemplo, falta de consentimiento); no se guardan en la base
+	Advertencias []string `json:"advertencias,omitempty"`
+	// alertas médicas activas del paciente, para que se vean en el sillón; tampoco se guardan con el turno
+	Alertas []paciente.AlertaMedica `json:"alertas,omitempty"`
+	// baja lógica: sólo vienen en el listado de dados de baja
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	DeletedBy int        `json:"deleted_by,omitempty"`
+	// sube con cada modificación, también al cambiar de estado; viaja en el ETag y hay que mandarla en If-Match para
+	// modificar o dar de baja
+	Version int `json:"version"`
 }
 
-// creamos la misma estructura de turno para las solicitudes por API.
+// creamos la misma estructura de turno para las solicitudes por API. Si no viene el odontólogo pero sí la especialidad,
+// el sistema asigna el de esa especialidad con el primer turno libre desde FechaHora.
 type TurnoRequest struct {
-	IdOdontologo int       `json:"id_odontologo"`
-	IdPaciente   int       `json:"id_paciente"`
-	FechaHora    time.Time `json:"fecha_hora"`
-	Descripcion  string    `json:"descripcion"`
+	IdOdontologo     int       `json:"id_odontologo"`
+	IdPaciente       int       `json:"id_paciente"`
+	FechaHora        time.Time `json:"fecha_hora"`
+	Descripcion      string    `json:"descripcion"`
+	CodigoPrestacion string    `json:"codigo_prestacion"`
+	Especialidad     string    `json:"especialidad,omitempty"`
 }
 
 type TurnoDniMatriculaRequest struct {
@@ -24,4 +59,31 @@ type TurnoDniMatriculaRequest struct {
 	DniPaciente         string    `json:"dni_paciente"`
 	FechaHora           time.Time `json:"fecha_hora"`
 	Descripcion         string    `json:"descripcion"`
+	CodigoPrestacion    string    `json:"codigo_prestacion"`
+}
+
+// Bloque es un horario libre de un odontólogo en el que se puede reservar un turno.
+type Bloque struct {
+	IdOdontologo int       `json:"id_odontologo"`
+	FechaHora    time.Time `json:"fecha_hora"`
+}
+
+// Incidencia es una ausencia o cancelación tardía de un turno, con la penalidad que correspondía en ese momento.
+type Incidencia struct {
+	ID         int       `json:"id"`
+	IdTurno    int       `json:"id_turno"`
+	IdPaciente int       `json:"id_paciente"`
+	Tipo       string    `json:"tipo"`
+	Fecha      time.Time `json:"fecha"`
+	Penalidad  float64   `json:"penalidad"`
+}
+
+// Asistencia resume el historial del paciente según la política de cancelación vigente. Restringido indica que llegó
+// al máximo de ausencias.
+type Asistencia struct {
+	IdPaciente           int          `json:"id_paciente"`
+	Ausencias            int          `json:"ausencias"`
+	CancelacionesTardias int          `json:"cancelaciones_tardias"`
+	Restringido          bool         `json:"restringido"`
+	Incidencias          []Incidencia `json:"incidencias"`
 }
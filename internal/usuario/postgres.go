@@ -0,0 +1,39 @@
+package usuario
+
+import "database/sql"
+
+// Queries de postgres: parámetros $1, $2, ..., marcas BOOLEAN y las altas devuelven el ID con RETURNING id
+var (
+	QueryInsertPostgres      = `INSERT INTO usuario(email, nombre, password_hash, rol, id_odontologo, activo) VALUES($1, $2, $3, $4, $5, TRUE) RETURNING id`
+	QueryGetByIdPostgres     = `SELECT id, email, nombre, password_hash, rol, id_odontologo, activo, version FROM usuario WHERE id = $1`
+	QueryGetByEmailPostgres  = `SELECT id, email, nombre, password_hash, rol, id_odontologo, activo, version FROM usuario WHERE email = $1`
+	QueryUpdatePostgres      = `UPDATE usuario SET nombre = $1, rol = $2, id_odontologo = $3, activo = $4, version = version + 1 WHERE id = $5 AND version = $6`
+	QueryExistsPostgres      = `SELECT COUNT(*) FROM usuario WHERE id = $1`
+	QueryInsertTokenPostgres = `INSERT INTO refresh_token(id_usuario, hash, vence, revocado) VALUES($1, $2, $3, FALSE) RETURNING id`
+	QueryGetTokenPostgres    = `SELECT id, id_usuario, hash, vence, revocado FROM refresh_token WHERE hash = $1`
+	QueryRevocarPostgres     = `UPDATE refresh_token SET revocado = TRUE, version = version + 1 WHERE id = $1 AND revocado = FALSE`
+	QueryRevocarTodoPostgres = `UPDATE refresh_token SET revocado = TRUE, version = version + 1 WHERE id_usuario = $1 AND revocado = FALSE`
+)
+
+var consultasPostgres = consultas{
+	insert:      QueryInsertPostgres,
+	getAll:      QueryGetAll,
+	getById:     QueryGetByIdPostgres,
+	getByEmail:  QueryGetByEmailPostgres,
+	update:      QueryUpdatePostgres,
+	count:       QueryCount,
+	exists:      QueryExistsPostgres,
+	insertToken: QueryInsertTokenPostgres,
+	getToken:    QueryGetTokenPostgres,
+	revocar:     QueryRevocarPostgres,
+	revocarTodo: QueryRevocarTodoPostgres,
+	returning:   true,
+}
+
+// NewRepositoryPostgres instancia repositorio sobre postgres
+func NewRepositoryPostgres(db *sql.DB) Repository {
+	return &repository{
+		db: db,
+		q:  consultasPostgres,
+	}
+}
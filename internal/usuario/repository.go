@@ -0,0 +1,270 @@
+package usuario
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"finalgo/pkg/basedatos"
+)
+
+// Errores
+var (
+	ErrNotFound        = errors.New("usuario no encontrado")
+	ErrRefreshNotFound = errors.New("refresh token no encontrado")
+	ErrStatement       = errors.New("sentencia incorrecta")
+	ErrExec            = errors.New("ejecución SQL incorrecta")
+	ErrLastId          = errors.New("error al obtener el último ID")
+	ErrVersion         = errors.New("el usuario cambió desde que se leyó, hay que volver a consultarlo")
+)
+
+// Queries a usar en cada función. La modificación del usuario sólo se aplica si la versión es la que leyó quien la pide.
+var (
+	QueryInsert      = `INSERT INTO usuario(email, nombre, password_hash, rol, id_odontologo, activo) VALUES(?,?,?,?,?,1)`
+	QueryGetAll      = `SELECT id, email, nombre, password_hash, rol, id_odontologo, activo, version FROM usuario ORDER BY nombre`
+	QueryGetById     = `SELECT id, email, nombre, password_hash, rol, id_odontologo, activo, version FROM usuario WHERE id = ?`
+	QueryGetByEmail  = `SELECT id, email, nombre, password_hash, rol, id_odontologo, activo, version FROM usuario WHERE email = ?`
+	QueryUpdate      = `UPDATE usuario SET nombre = ?, rol = ?, id_odontologo = ?, activo = ?, version = version + 1 WHERE id = ? AND version = ?`
+	QueryCount       = `SELECT COUNT(*) FROM usuario`
+	QueryExists      = `SELECT COUNT(*) FROM usuario WHERE id = ?`
+	QueryInsertToken = `INSERT INTO refresh_token(id_usuario, hash, vence, revocado) VALUES(?,?,?,0)`
+	QueryGetToken    = `SELECT id, id_usuario, hash, vence, revocado FROM refresh_token WHERE hash = ?`
+	QueryRevocar     = `UPDATE refresh_token SET revocado = 1, version = version + 1 WHERE id = ? AND revocado = 0`
+	QueryRevocarTodo = `UPDATE refresh_token SET revocado = 1, version = version + 1 WHERE id_usuario = ? AND revocado = 0`
+)
+
+// consultas son las queries de cada función en el dialecto del motor: MySQL y SQLite usan las de arriba y
+// PostgreSQL las de postgres.go
+type consultas struct {
+	insert      string
+	getAll      string
+	getById     string
+	getByEmail  string
+	update      string
+	count       string
+	exists      string
+	insertToken string
+	getToken    string
+	revocar     string
+	revocarTodo string
+	// las altas devuelven el ID con RETURNING id, porque el motor no tiene LastInsertId
+	returning bool
+}
+
+var consultasMySQL = consultas{
+	insert:      QueryInsert,
+	getAll:      QueryGetAll,
+	getById:     QueryGetById,
+	getByEmail:  QueryGetByEmail,
+	update:      QueryUpdate,
+	count:       QueryCount,
+	exists:      QueryExists,
+	insertToken: QueryInsertToken,
+	getToken:    QueryGetToken,
+	revocar:     QueryRevocar,
+	revocarTodo: QueryRevocarTodo,
+}
+
+// defino la interfaz para que se apliquen siempre todos los métodos
+type Repository interface {
+	GetAll(ctx context.Context) ([]Usuario, error)
+	GetUsuarioByID(ctx context.Context, id int) (Usuario, error)
+	GetUsuarioByEmail(ctx context.Context, email string) (Usuario, error)
+	CountUsuarios(ctx context.Context) (int, error)
+	CreateUsuario(ctx context.Context, u Usuario) (Usuario, error)
+	UpdateUsuario(ctx context.Context, u Usuario) (Usuario, error)
+	CreateRefreshToken(ctx context.Context, t RefreshToken) (RefreshToken, error)
+	GetRefreshToken(ctx context.Context, hash string) (RefreshToken, error)
+	RevocarRefreshToken(ctx context.Context, id int) error
+	RevocarRefreshTokens(ctx context.Context, idUsuario int) error
+}
+
+// estructura repositorio con base de datos mysql
+type repository struct {
+	db *sql.DB
+	q  consultas
+}
+
+// NewRepositoryMySql instancia repositorio
+func NewRepositoryMySql(db *sql.DB) Repository {
+	return &repository{
+		db: db,
+		q:  consultasMySQL,
+	}
+}
+
+// NewRepositorySqlite instancia repositorio sobre sqlite, con las mismas consultas
+func NewRepositorySqlite(db *sql.DB) Repository {
+	return &repository{
+		db: db,
+		q:  consultasMySQL,
+	}
+}
+
+// obtener todos los usuarios
+func (r *repository) GetAll(ctx context.Context) ([]Usuario, error) {
+	rows, err := r.db.QueryContext(ctx, r.q.getAll)
+	if err != nil {
+		return []Usuario{}, ErrStatement
+	}
+	defer rows.Close()
+
+	usuarios := []Usuario{}
+	for rows.Next() {
+		u, err := scanUsuario(rows)
+		if err != nil {
+			return []Usuario{}, ErrExec
+		}
+		usuarios = append(usuarios, u)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []Usuario{}, ErrExec
+	}
+
+	return usuarios, nil
+}
+
+// obtener un usuario por ID
+func (r *repository) GetUsuarioByID(ctx context.Context, id int) (Usuario, error) {
+	return scanUsuario(r.db.QueryRowContext(ctx, r.q.getById, id))
+}
+
+// obtener un usuario por email, que es con lo que se inicia sesión
+func (r *repository) GetUsuarioByEmail(ctx context.Context, email string) (Usuario, error) {
+	return scanUsuario(r.db.QueryRowContext(ctx, r.q.getByEmail, email))
+}
+
+// scanUsuario lee un usuario de un *sql.Row o *sql.Rows; id_odontologo es NULL para los que no son odontólogos
+func scanUsuario(row interface{ Scan(dest ...any) error }) (Usuario, error) {
+	var u Usuario
+	var idOdontologo sql.NullInt64
+	err := row.Scan(
+		&u.ID,
+		&u.Email,
+		&u.Nombre,
+		&u.PasswordHash,
+		&u.Rol,
+		&idOdontologo,
+		&u.Activo,
+		&u.Version,
+	)
+	if err != nil {
+		return Usuario{}, ErrNotFound
+	}
+	u.IdOdontologo = int(idOdontologo.Int64)
+	return u, nil
+}
+
+// el odontólogo va NULL cuando no hay
+func nullOdontologo(id int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(id), Valid: id > 0}
+}
+
+// contar los usuarios, para saber si hay que crear el inicial
+func (r *repository) CountUsuarios(ctx context.Context) (int, error) {
+	var total int
+	if err := r.db.QueryRowContext(ctx, r.q.count).Scan(&total); err != nil {
+		return 0, ErrExec
+	}
+	return total, nil
+}
+
+// crear usuario en BD
+func (r *repository) CreateUsuario(ctx context.Context, u Usuario) (Usuario, error) {
+	result, err := basedatos.Insertar(ctx, r.db, r.q.returning, r.q.insert, u.Email, u.Nombre, u.PasswordHash, u.Rol, nullOdontologo(u.IdOdontologo))
+	if err != nil {
+		return Usuario{}, ErrExec
+	}
+
+	lastId, err := result.LastInsertId()
+	if err != nil {
+		return Usuario{}, ErrLastId
+	}
+	u.ID = int(lastId)
+	u.Activo = true
+	u.Version = 1
+	return u, nil
+}
+
+// actualizar nombre, rol, odontólogo y estado, si el usuario sigue en la versión u.Version
+func (r *repository) UpdateUsuario(ctx context.Context, u Usuario) (Usuario, error) {
+	result, err := r.db.ExecContext(ctx, r.q.update, u.Nombre, u.Rol, nullOdontologo(u.IdOdontologo), u.Activo, u.ID, u.Version)
+	if err != nil {
+		return Usuario{}, ErrExec
+	}
+
+	// si no actualizó nada, o no existe o alguien lo modificó antes
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return Usuario{}, ErrExec
+	}
+	if rowsAffected < 1 {
+		var cantidad int
+		if err := r.db.QueryRowContext(ctx, r.q.exists, u.ID).Scan(&cantidad); err != nil {
+			return Usuario{}, ErrExec
+		}
+		if cantidad < 1 {
+			return Usuario{}, ErrNotFound
+		}
+		return Usuario{}, ErrVersion
+	}
+	u.Version++
+	return u, nil
+}
+
+// guardar un refresh token emitido
+func (r *repository) CreateRefreshToken(ctx context.Context, t RefreshToken) (RefreshToken, error) {
+	result, err := basedatos.Insertar(ctx, r.db, r.q.returning, r.q.insertToken, t.IdUsuario, t.Hash, t.Vence)
+	if err != nil {
+		return RefreshToken{}, ErrExec
+	}
+
+	lastId, err := result.LastInsertId()
+	if err != nil {
+		return RefreshToken{}, ErrLastId
+	}
+	t.ID = int(lastId)
+	return t, nil
+}
+
+// obtener un refresh token por su hash, esté o no revocado
+func (r *repository) GetRefreshToken(ctx context.Context, hash string) (RefreshToken, error) {
+	var t RefreshToken
+	err := r.db.QueryRowContext(ctx, r.q.getToken, hash).Scan(
+		&t.ID,
+		&t.IdUsuario,
+		&t.Hash,
+		&t.Vence,
+		&t.Revocado,
+	)
+	if err != nil {
+		return RefreshToken{}, ErrRefreshNotFound
+	}
+	return t, nil
+}
+
+// revocar un refresh token. Si ya estaba revocado devuelve ErrRefreshNotFound: otro pedido lo usó antes.
+func (r *repository) RevocarRefreshToken(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, r.q.revocar, id)
+	if err != nil {
+		return ErrExec
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return ErrExec
+	}
+	if rowsAffected < 1 {
+		return ErrRefreshNotFound
+	}
+	return nil
+}
+
+// revocar todos los refresh tokens del usuario
+func (r *repository) RevocarRefreshTokens(ctx context.Context, idUsuario int) error {
+	if _, err := r.db.ExecContext(ctx, r.q.revocarTodo, idUsuario); err != nil {
+		return ErrExec
+	}
+	return nil
+}
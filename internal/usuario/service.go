@@ -0,0 +1,335 @@
+package usuario
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/mail"
+	"strings"
+	"time"
+
+	"finalgo/internal/odontologo"
+	"finalgo/pkg/auth"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Errores del servicio
+var (
+	ErrCredenciales   = errors.New("email o contraseña incorrectos")
+	ErrEmail          = errors.New("email inválido")
+	ErrEmailExistente = errors.New("ya existe un usuario con ese email")
+	ErrPassword       = errors.New("la contraseña tiene que tener entre 8 y 72 caracteres")
+	ErrDatos          = errors.New("faltan datos del usuario")
+	ErrRol            = errors.New("rol inválido")
+	ErrOdontologo     = errors.New("un usuario odontólogo tiene que estar asociado a un odontólogo existente")
+	ErrPropio         = errors.New("no puede cambiar su propio rol ni darse de baja")
+)
+
+// hash que se compara cuando el email no existe, para que el login tarde lo mismo y no delate qué cuentas hay
+var hashFalso, _ = bcrypt.GenerateFromPassword([]byte("contraseña-que-no-es-de-nadie"), bcrypt.DefaultCost)
+
+// Emisor firma los tokens de acceso. Lo implementa auth.Tokens.
+type Emisor interface {
+	Emitir(u auth.Identidad) (string, time.Time, error)
+}
+
+// Odontologos valida el odontólogo asociado a un usuario. Lo implementa odontologo.Service.
+type Odontologos interface {
+	GetOdontologoByID(ctx context.Context, id int) (odontologo.Odontologo, error)
+}
+
+// defino la interfaz para que se apliquen siempre todos los métodos
+type Service interface {
+	GetAll(ctx context.Context) ([]Usuario, error)
+	GetUsuarioByID(ctx context.Context, id int) (Usuario, error)
+	CreateUsuario(ctx context.Context, u UsuarioRequest) (Usuario, error)
+	UpdateUsuario(ctx context.Context, id int, version int, u UsuarioUpdate) (Usuario, error)
+	CrearUsuarioInicial(ctx context.Context, email, password string) error
+	Login(ctx context.Context, l LoginRequest) (Sesion, error)
+	Refresh(ctx context.Context, refreshToken string) (Sesion, error)
+	Logout(ctx context.Context, refreshToken string) error
+}
+
+// estrucutra service que contará con un repositorio, el emisor de tokens y los odontólogos
+type service struct {
+	r               Repository
+	emisor          Emisor
+	os              Odontologos
+	vigenciaRefresh time.Duration
+}
+
+// función para instanciar service. vigenciaRefresh es cuánto dura un refresh token sin usarse.
+func NewService(r Repository, emisor Emisor, os Odontologos, vigenciaRefresh time.Duration) Service {
+	return &service{
+		r,
+		emisor,
+		os,
+		vigenciaRefresh,
+	}
+}
+
+func (s *service) GetAll(ctx context.Context) ([]Usuario, error) {
+	usuarios, err := s.r.GetAll(ctx)
+	if err != nil {
+		log.Println("log de error en service de usuarios", err.Error())
+		return []Usuario{}, ErrExec
+	}
+	return usuarios, nil
+}
+
+func (s *service) GetUsuarioByID(ctx context.Context, id int) (Usuario, error) {
+	u, err := s.r.GetUsuarioByID(ctx, id)
+	if err != nil {
+		log.Println("log de error por usuario inexistente", err.Error())
+		return Usuario{}, ErrNotFound
+	}
+	return u, nil
+}
+
+func (s *service) CreateUsuario(ctx context.Context, usuarioRequest UsuarioRequest) (Usuario, error) {
+	email, err := normalizarEmail(usuarioRequest.Email)
+	if err != nil {
+		return Usuario{}, err
+	}
+	nombre := strings.TrimSpace(usuarioRequest.Nombre)
+	if nombre == "" {
+		return Usuario{}, ErrDatos
+	}
+	if len(usuarioRequest.Password) < 8 || len(usuarioRequest.Password) > 72 {
+		return Usuario{}, ErrPassword
+	}
+	if err := s.validarRol(ctx, usuarioRequest.Rol, usuarioRequest.IdOdontologo); err != nil {
+		return Usuario{}, err
+	}
+	if _, err := s.r.GetUsuarioByEmail(ctx, email); err == nil {
+		return Usuario{}, ErrEmailExistente
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(usuarioRequest.Password), bcrypt.DefaultCost)
+	if err != nil {
+		log.Println("error al generar hash de contraseña", err.Error())
+		return Usuario{}, ErrExec
+	}
+	response, err := s.r.CreateUsuario(ctx, Usuario{
+		Email:        email,
+		Nombre:       nombre,
+		PasswordHash: string(hash),
+		Rol:          usuarioRequest.Rol,
+		IdOdontologo: idOdontologoDeRol(usuarioRequest.Rol, usuarioRequest.IdOdontologo),
+	})
+	if err != nil {
+		log.Println("error al crear usuario")
+		return Usuario{}, ErrExec
+	}
+	return response, nil
+}
+
+// CrearUsuarioInicial da de alta el primer usuario cuando la tabla está vacía, para poder entrar la primera vez.
+// Si ya hay usuarios o no se configuró el email no hace nada.
+func (s *service) CrearUsuarioInicial(ctx context.Context, email, password string) error {
+	if email == "" {
+		return nil
+	}
+	total, err := s.r.CountUsuarios(ctx)
+	if err != nil {
+		return err
+	}
+	if total > 0 {
+		return nil
+	}
+	_, err = s.CreateUsuario(ctx, UsuarioRequest{Email: email, Nombre: "Administrador", Password: password, Rol: auth.RolAdmin})
+	return err
+}
+
+// UpdateUsuario aplica los cambios que vengan sobre la versión indicada. Al dar de baja un usuario se cierran sus
+// sesiones; el token de acceso que ya tenga vale hasta que vence.
+func (s *service) UpdateUsuario(ctx context.Context, id int, version int, cambios UsuarioUpdate) (Usuario, error) {
+	u, err := s.r.GetUsuarioByID(ctx, id)
+	if err != nil {
+		log.Println("log de error por usuario inexistente", err.Error())
+		return Usuario{}, ErrNotFound
+	}
+	u.Version = version
+	if actual, ok := auth.UsuarioDesde(ctx); ok && actual.ID == id && (cambios.Rol != nil || cambios.Activo != nil) {
+		return Usuario{}, ErrPropio
+	}
+
+	if cambios.Nombre != nil {
+		u.Nombre = strings.TrimSpace(*cambios.Nombre)
+		if u.Nombre == "" {
+			return Usuario{}, ErrDatos
+		}
+	}
+	if cambios.Rol != nil {
+		u.Rol = *cambios.Rol
+	}
+	if cambios.IdOdontologo != nil {
+		u.IdOdontologo = *cambios.IdOdontologo
+	}
+	if err := s.validarRol(ctx, u.Rol, u.IdOdontologo); err != nil {
+		return Usuario{}, err
+	}
+	u.IdOdontologo = idOdontologoDeRol(u.Rol, u.IdOdontologo)
+	if cambios.Activo != nil {
+		u.Activo = *cambios.Activo
+	}
+
+	response, err := s.r.UpdateUsuario(ctx, u)
+	if err != nil {
+		log.Println("error al actualizar usuario", err.Error())
+		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrVersion) {
+			return Usuario{}, err
+		}
+		return Usuario{}, ErrExec
+	}
+	if !response.Activo {
+		s.revocarTodo(ctx, response.ID)
+	}
+	return response, nil
+}
+
+// validarRol controla que el rol exista y que un odontólogo tenga su odontólogo asociado
+func (s *service) validarRol(ctx context.Context, rol string, idOdontologo int) error {
+	if !auth.RolValido(rol) {
+		return ErrRol
+	}
+	if rol != auth.RolOdontologo {
+		return nil
+	}
+	if idOdontologo <= 0 {
+		return ErrOdontologo
+	}
+	if _, err := s.os.GetOdontologoByID(ctx, idOdontologo); err != nil {
+		log.Println("log de error por odontologo inexistente", err.Error())
+		return ErrOdontologo
+	}
+	return nil
+}
+
+// idOdontologoDeRol: sólo los usuarios odontólogos quedan asociados a un odontólogo
+func idOdontologoDeRol(rol string, idOdontologo int) int {
+	if rol != auth.RolOdontologo {
+		return 0
+	}
+	return idOdontologo
+}
+
+// Login valida email y contraseña y abre una sesión
+func (s *service) Login(ctx context.Context, l LoginRequest) (Sesion, error) {
+	email, err := normalizarEmail(l.Email)
+	if err != nil {
+		return Sesion{}, ErrCredenciales
+	}
+	u, err := s.r.GetUsuarioByEmail(ctx, email)
+	if err != nil {
+		bcrypt.CompareHashAndPassword(hashFalso, []byte(l.Password))
+		return Sesion{}, ErrCredenciales
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(l.Password)); err != nil || !u.Activo {
+		return Sesion{}, ErrCredenciales
+	}
+	return s.nuevaSesion(ctx, u)
+}
+
+// Refresh cambia un refresh token válido por una sesión nueva y lo revoca. Si llega uno ya revocado es que alguien
+// lo reutilizó (pudo ser robado), así que se revocan todas las sesiones del usuario.
+func (s *service) Refresh(ctx context.Context, refreshToken string) (Sesion, error) {
+	t, err := s.r.GetRefreshToken(ctx, hashToken(refreshToken))
+	if err != nil {
+		return Sesion{}, ErrCredenciales
+	}
+	if t.Revocado {
+		s.revocarTodo(ctx, t.IdUsuario)
+		return Sesion{}, ErrCredenciales
+	}
+	if time.Now().After(t.Vence) {
+		return Sesion{}, ErrCredenciales
+	}
+	if err := s.r.RevocarRefreshToken(ctx, t.ID); err != nil {
+		if errors.Is(err, ErrRefreshNotFound) {
+			s.revocarTodo(ctx, t.IdUsuario)
+			return Sesion{}, ErrCredenciales
+		}
+		log.Println("error al revocar refresh token", err.Error())
+		return Sesion{}, ErrExec
+	}
+
+	u, err := s.r.GetUsuarioByID(ctx, t.IdUsuario)
+	if err != nil || !u.Activo {
+		return Sesion{}, ErrCredenciales
+	}
+	return s.nuevaSesion(ctx, u)
+}
+
+// Logout revoca el refresh token. El token de acceso sigue valiendo hasta que vence, por eso dura poco.
+func (s *service) Logout(ctx context.Context, refreshToken string) error {
+	t, err := s.r.GetRefreshToken(ctx, hashToken(refreshToken))
+	if err != nil || t.Revocado {
+		return nil
+	}
+	if err := s.r.RevocarRefreshToken(ctx, t.ID); err != nil && !errors.Is(err, ErrRefreshNotFound) {
+		log.Println("error al revocar refresh token", err.Error())
+		return ErrExec
+	}
+	return nil
+}
+
+// nuevaSesion firma el token de acceso y guarda un refresh token nuevo
+func (s *service) nuevaSesion(ctx context.Context, u Usuario) (Sesion, error) {
+	access, vence, err := s.emisor.Emitir(auth.Identidad{ID: u.ID, Email: u.Email, Rol: u.Rol, IdOdontologo: u.IdOdontologo})
+	if err != nil {
+		log.Println("error al firmar token de acceso", err.Error())
+		return Sesion{}, ErrExec
+	}
+
+	crudo := make([]byte, 32)
+	if _, err := rand.Read(crudo); err != nil {
+		log.Println("error al generar refresh token", err.Error())
+		return Sesion{}, ErrExec
+	}
+	refresh := base64.RawURLEncoding.EncodeToString(crudo)
+	_, err = s.r.CreateRefreshToken(ctx, RefreshToken{
+		IdUsuario: u.ID,
+		Hash:      hashToken(refresh),
+		Vence:     time.Now().Add(s.vigenciaRefresh),
+	})
+	if err != nil {
+		log.Println("error al guardar refresh token", err.Error())
+		return Sesion{}, ErrExec
+	}
+
+	return Sesion{
+		AccessToken:  access,
+		TokenType:    "Bearer",
+		Vence:        vence,
+		RefreshToken: refresh,
+		Usuario:      u,
+	}, nil
+}
+
+func (s *service) revocarTodo(ctx context.Context, idUsuario int) {
+	log.Println("se revocan las sesiones del usuario", idUsuario)
+	if err := s.r.RevocarRefreshTokens(ctx, idUsuario); err != nil {
+		log.Println("error al revocar refresh tokens", err.Error())
+	}
+}
+
+// hashToken: los refresh tokens son aleatorios de 256 bits, así que alcanza con SHA-256 para no guardarlos en claro
+func hashToken(token string) string {
+	suma := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(suma[:])
+}
+
+func normalizarEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	direccion, err := mail.ParseAddress(email)
+	if err != nil || direccion.Address != email {
+		return "", ErrEmail
+	}
+	return email, nil
+}
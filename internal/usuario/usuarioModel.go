@@ -0,0 +1,64 @@
+package usuario
+
+import "time"
+
+// Usuario es una cuenta del personal de la clínica. La contraseña se guarda sólo como hash bcrypt y nunca sale en
+// las respuestas. Rol es uno de auth.Rol*; si es odontologo, IdOdontologo dice qué odontólogo es.
+type Usuario struct {
+	ID           int    `json:"id"`
+	Email        string `json:"email"`
+	Nombre       string `json:"nombre"`
+	PasswordHash string `json:"-"`
+	Rol          string `json:"rol"`
+	IdOdontologo int    `json:"id_odontologo,omitempty"`
+	Activo       bool   `json:"activo"`
+	// sube con cada modificación; hay que mandarla en If-Match para modificar el usuario
+	Version int `json:"version"`
+}
+
+// UsuarioRequest es el alta de un usuario por API
+type UsuarioRequest struct {
+	Email        string `json:"email"`
+	Nombre       string `json:"nombre"`
+	Password     string `json:"password"`
+	Rol          string `json:"rol"`
+	IdOdontologo int    `json:"id_odontologo"`
+}
+
+// UsuarioUpdate cambia nombre, rol u odontólogo de un usuario, o lo da de baja. Lo que no viene no se toca.
+type UsuarioUpdate struct {
+	Nombre       *string `json:"nombre"`
+	Rol          *string `json:"rol"`
+	IdOdontologo *int    `json:"id_odontologo"`
+	Activo       *bool   `json:"activo"`
+}
+
+// LoginRequest son las credenciales para iniciar sesión
+type LoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// RefreshRequest trae el refresh token para renovar la sesión o cerrarla
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Sesion es lo que devuelve el login: un token de acceso corto (JWT) y un refresh token para pedir otro sin volver a
+// poner la contraseña. Cada refresh token se usa una sola vez.
+type Sesion struct {
+	AccessToken  string    `json:"access_token"`
+	TokenType    string    `json:"token_type"`
+	Vence        time.Time `json:"vence"`
+	RefreshToken string    `json:"refresh_token"`
+	Usuario      Usuario   `json:"usuario"`
+}
+
+// RefreshToken es el registro de un refresh token emitido; se guarda el hash, no el token
+type RefreshToken struct {
+	ID        int
+	IdUsuario int
+	Hash      string
+	Vence     time.Time
+	Revocado  bool
+}
@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Errores
+var (
+	ErrToken      = errors.New("token inválido o vencido")
+	ErrClaves     = errors.New("configuración de claves JWT inválida")
+	ErrClaveCorta = errors.New("cada clave JWT tiene que tener al menos 32 bytes")
+)
+
+// ClaveUsuario es la clave del contexto de gin donde el middleware deja la Identidad del usuario autenticado.
+const ClaveUsuario = "usuario"
+
+// ClavePaciente es la clave del contexto de gin donde el middleware del portal deja el ID del paciente autenticado.
+const ClavePaciente = "id_paciente"
+
+// Identidad es el usuario del personal que hace el pedido, tal como viene en el token. IdOdontologo es el odontólogo
+// que corresponde al usuario cuando su rol es odontologo.
+type Identidad struct {
+	ID           int    `json:"id"`
+	Email        string `json:"email"`
+	Rol          string `json:"rol"`
+	IdOdontologo int    `json:"id_odontologo,omitempty"`
+}
+
+// claims del token de acceso: el sujeto es el ID del usuario
+type claims struct {
+	Email        string `json:"email"`
+	Rol          string `json:"rol"`
+	IdOdontologo int    `json:"id_odontologo,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Claves son las claves HMAC con las que se firman los tokens, identificadas por kid. Se firma siempre con la actual
+// y se aceptan todas, así que para rotar se agrega una clave nueva, se la hace actual y la anterior se saca cuando ya
+// vencieron los tokens que firmó.
+type Claves struct {
+	Actual string
+	PorKid map[string][]byte
+}
+
+// ParseClaves lee las claves con el formato "kid:secreto,kid2:secreto2" y valida que exista la actual.
+func ParseClaves(valor, actual string) (Claves, error) {
+	c := Claves{Actual: actual, PorKid: map[string][]byte{}}
+	for _, par := range strings.Split(valor, ",") {
+		kid, secreto, ok := strings.Cut(strings.TrimSpace(par), ":")
+		if !ok || kid == "" {
+			return Claves{}, fmt.Errorf("%w: %q no tiene el formato kid:secreto", ErrClaves, par)
+		}
+		if len(secreto) < 32 {
+			return Claves{}, fmt.Errorf("%w (kid %q)", ErrClaveCorta, kid)
+		}
+		c.PorKid[kid] = []byte(secreto)
+	}
+	if _, ok := c.PorKid[actual]; !ok {
+		return Claves{}, fmt.Errorf("%w: no existe la clave actual %q", ErrClaves, actual)
+	}
+	return c, nil
+}
+
+// Tokens emite y verifica los tokens de acceso del personal.
+type Tokens struct {
+	claves   Claves
+	emisor   string
+	vigencia time.Duration
+}
+
+// NewTokens instancia el emisor de tokens. vigencia es cuánto dura cada token de acceso.
+func NewTokens(claves Claves, emisor string, vigencia time.Duration) *Tokens {
+	return &Tokens{
+		claves:   claves,
+		emisor:   emisor,
+		vigencia: vigencia,
+	}
+}
+
+// Emitir firma un token de acceso para el usuario y devuelve cuándo vence.
+func (t *Tokens) Emitir(u Identidad) (string, time.Time, error) {
+	ahora := time.Now()
+	vence := ahora.Add(t.vigencia)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims{
+		Email:        u.Email,
+		Rol:          u.Rol,
+		IdOdontologo: u.IdOdontologo,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    t.emisor,
+			Subject:   strconv.Itoa(u.ID),
+			IssuedAt:  jwt.NewNumericDate(ahora),
+			ExpiresAt: jwt.NewNumericDate(vence),
+		},
+	})
+	token.Header["kid"] = t.claves.Actual
+
+	firmado, err := token.SignedString(t.claves.PorKid[t.claves.Actual])
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return firmado, vence, nil
+}
+
+// Verificar valida firma, emisor y vencimiento del token y devuelve el usuario.
+func (t *Tokens) Verificar(token string) (Identidad, error) {
+	var c claims
+	_, err := jwt.ParseWithClaims(token, &c, func(tk *jwt.Token) (interface{}, error) {
+		kid, _ := tk.Header["kid"].(string)
+		clave, ok := t.claves.PorKid[kid]
+		if !ok {
+			return nil, ErrToken
+		}
+		return clave, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(t.emisor),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return Identidad{}, ErrToken
+	}
+
+	id, err := strconv.Atoi(c.Subject)
+	if err != nil || id <= 0 {
+		return Identidad{}, ErrToken
+	}
+	return Identidad{ID: id, Email: c.Email, Rol: c.Rol, IdOdontologo: c.IdOdontologo}, nil
+}
+
+// clave propia para guardar la identidad en un context.Context
+type claveContexto struct{}
+
+// ConUsuario devuelve un contexto que lleva la identidad del usuario.
+func ConUsuario(ctx context.Context, u Identidad) context.Context {
+	return context.WithValue(ctx, claveContexto{}, u)
+}
+
+// UsuarioDesde devuelve el usuario autenticado del contexto. Sirve tanto con el contexto del request como con el
+// *gin.Context que reciben los services.
+func UsuarioDesde(ctx context.Context) (Identidad, bool) {
+	if u, ok := ctx.Value(claveContexto{}).(Identidad); ok {
+		return u, true
+	}
+	u, ok := ctx.Value(ClaveUsuario).(Identidad)
+	return u, ok
+}
+
+// PacienteDesde devuelve el paciente autenticado en el portal, si el pedido viene de ahí.
+func PacienteDesde(ctx context.Context) (int, bool) {
+	id, ok := ctx.Value(ClavePaciente).(int)
+	return id, ok && id > 0
+}
+
+// IdUsuarioDesde devuelve el ID del usuario autenticado, o 0 si el pedido no viene de un usuario del personal.
+func IdUsuarioDesde(ctx context.Context) int {
+	u, _ := UsuarioDesde(ctx)
+	return u.ID
+}
@@ -0,0 +1,73 @@
+package auth
+
+// roles del personal
+const (
+	RolAdmin         = "admin"
+	RolRecepcionista = "recepcionista"
+	RolOdontologo    = "odontologo"
+)
+
+// Permiso es una operación que se habilita por rol. Cada ruta exige uno.
+type Permiso string
+
+// permisos de la API
+const (
+	PermisoPacientesLeer     Permiso = "pacientes:leer"
+	PermisoPacientesEditar   Permiso = "pacientes:editar"
+	PermisoPacientesEliminar Permiso = "pacientes:eliminar"
+	PermisoTurnosLeer        Permiso = "turnos:leer"
+	PermisoTurnosGestionar   Permiso = "turnos:gestionar"
+	PermisoTurnosAtender     Permiso = "turnos:atender"
+	PermisoHistoriaLeer      Permiso = "historia:leer"
+	PermisoHistoriaEditar    Permiso = "historia:editar"
+	PermisoOdontologosLeer   Permiso = "odontologos:leer"
+	PermisoOdontologosEditar Permiso = "odontologos:editar"
+	PermisoCatalogoLeer      Permiso = "catalogo:leer"
+	PermisoCatalogoEditar    Permiso = "catalogo:editar"
+	PermisoFacturacion       Permiso = "facturacion"
+	PermisoLiquidaciones     Permiso = "liquidaciones"
+	PermisoUsuariosGestionar Permiso = "usuarios:gestionar"
+	PermisoAuditoria         Permiso = "auditoria"
+	PermisoEliminados        Permiso = "eliminados"
+)
+
+// permisos de cada rol. La recepción maneja pacientes y turnos, el odontólogo la historia clínica (sólo de sus
+// pacientes, ver AlcancePaciente) y el admin todo, incluidos odontólogos, usuarios, la auditoría y los registros dados de baja.
+var permisosPorRol = map[string][]Permiso{
+	RolRecepcionista: {
+		PermisoPacientesLeer, PermisoPacientesEditar,
+		PermisoTurnosLeer, PermisoTurnosGestionar,
+		PermisoOdontologosLeer, PermisoCatalogoLeer,
+		PermisoFacturacion,
+	},
+	RolOdontologo: {
+		PermisoPacientesLeer,
+		PermisoTurnosLeer, PermisoTurnosAtender,
+		PermisoHistoriaLeer, PermisoHistoriaEditar,
+		PermisoOdontologosLeer, PermisoCatalogoLeer,
+	},
+}
+
+// RolValido indica si el rol existe
+func RolValido(rol string) bool {
+	return rol == RolAdmin || permisosPorRol[rol] != nil
+}
+
+// Puede indica si el usuario tiene el permiso por su rol
+func (i Identidad) Puede(p Permiso) bool {
+	if i.Rol == RolAdmin {
+		return true
+	}
+	for _, permiso := range permisosPorRol[i.Rol] {
+		if permiso == p {
+			return true
+		}
+	}
+	return false
+}
+
+// AlcancePaciente indica si además del permiso hay que verificar que el paciente sea del usuario: un odontólogo sólo
+// edita la historia clínica de los pacientes que atiende.
+func (i Identidad) AlcancePaciente() bool {
+	return i.Rol == RolOdontologo
+}
@@ -0,0 +1,112 @@
+// Package basedatos abre la conexión a la base del motor configurado, con el pool de conexiones armado. Los
+// repositorios de cada dominio eligen sus consultas según el mismo motor: MySQL y SQLite comparten las suyas y
+// PostgreSQL tiene las propias.
+package basedatos
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+
+	"finalgo/pkg/config"
+)
+
+// Abrir abre la base y controla que responda
+func Abrir(cfg config.DB) (*sql.DB, error) {
+	var db *sql.DB
+	switch cfg.Motor {
+	case config.MotorMySQL:
+		var err error
+		if db, err = sql.Open("mysql", cfg.DSN()); err != nil {
+			return nil, err
+		}
+	case config.MotorPostgres:
+		var err error
+		if db, err = sql.Open("postgres", cfg.DSNPostgres()); err != nil {
+			return nil, err
+		}
+	case config.MotorSQLite:
+		db = sql.OpenDB(conectorSqlite{cfg.DSNSqlite()})
+	default:
+		return nil, fmt.Errorf("motor de base desconocido: %q", cfg.Motor)
+	}
+	db.SetMaxOpenConns(cfg.MaxAbiertas)
+	db.SetMaxIdleConns(cfg.MaxInactivas)
+	db.SetConnMaxLifetime(cfg.VidaMaxima())
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// conexionCompleta son las variantes con contexto que tiene la conexión del driver de SQLite
+type conexionCompleta interface {
+	driver.Conn
+	driver.ConnBeginTx
+	driver.ConnPrepareContext
+	driver.ExecerContext
+	driver.QueryerContext
+	driver.Pinger
+}
+
+// Ejecutor es lo que comparten *sql.DB, *sql.Tx y *sql.Conn para correr un INSERT
+type Ejecutor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// Insertar corre un INSERT y devuelve su resultado. PostgreSQL no tiene LastInsertId: sus altas terminan en
+// RETURNING id (returning) y el resultado devuelve el ID de esa fila.
+func Insertar(ctx context.Context, e Ejecutor, returning bool, query string, args ...interface{}) (sql.Result, error) {
+	if !returning {
+		return e.ExecContext(ctx, query, args...)
+	}
+	return leerID(e.QueryRowContext(ctx, query, args...))
+}
+
+// InsertarPreparado es Insertar con la sentencia ya preparada
+func InsertarPreparado(ctx context.Context, stmt *sql.Stmt, returning bool, args ...interface{}) (sql.Result, error) {
+	if !returning {
+		return stmt.ExecContext(ctx, args...)
+	}
+	return leerID(stmt.QueryRowContext(ctx, args...))
+}
+
+// Marcadores arma los parámetros de un IN (...) de n valores: "?, ?, ?" o, si el motor los numera (PostgreSQL),
+// "$desde, $desde+1, ..." siguiendo a los parámetros que ya tiene la consulta.
+func Marcadores(n int, numerados bool, desde int) string {
+	marcadores := make([]string, n)
+	for i := range marcadores {
+		marcadores[i] = "?"
+		if numerados {
+			marcadores[i] = fmt.Sprintf("$%d", desde+i)
+		}
+	}
+	return strings.Join(marcadores, ", ")
+}
+
+func leerID(fila *sql.Row) (sql.Result, error) {
+	var id int64
+	if err := fila.Scan(&id); err != nil {
+		return nil, err
+	}
+	return insertado(id), nil
+}
+
+// insertado es el resultado de un alta con RETURNING id: una fila, con ese ID
+type insertado int64
+
+func (i insertado) LastInsertId() (int64, error) {
+	return int64(i), nil
+}
+
+func (i insertado) RowsAffected() (int64, error) {
+	return 1, nil
+}
@@ -0,0 +1,76 @@
+package basedatos
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"finalgo/pkg/config"
+)
+
+// SQLite acepta RETURNING, así que sirve para probar las dos formas de obtener el ID de un alta
+func TestInsertar(t *testing.T) {
+	ctx := context.Background()
+	db, err := Abrir(config.DB{Motor: config.MotorSQLite, Archivo: filepath.Join(t.TempDir(), "prueba.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.ExecContext(ctx, `CREATE TABLE prueba (id INTEGER PRIMARY KEY AUTOINCREMENT, nombre TEXT NOT NULL)`); err != nil {
+		t.Fatal(err)
+	}
+
+	stmt, err := db.PrepareContext(ctx, `INSERT INTO prueba(nombre) VALUES(?) RETURNING id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stmt.Close()
+
+	altas := []struct {
+		nombre   string
+		insertar func() (sql.Result, error)
+	}{
+		{"LastInsertId", func() (sql.Result, error) {
+			return Insertar(ctx, db, false, `INSERT INTO prueba(nombre) VALUES(?)`, "uno")
+		}},
+		{"RETURNING id", func() (sql.Result, error) {
+			return Insertar(ctx, db, true, `INSERT INTO prueba(nombre) VALUES(?) RETURNING id`, "dos")
+		}},
+		{"preparada con RETURNING id", func() (sql.Result, error) {
+			return InsertarPreparado(ctx, stmt, true, "tres")
+		}},
+	}
+	for i, alta := range altas {
+		result, err := alta.insertar()
+		if err != nil {
+			t.Fatalf("%s: %v", alta.nombre, err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil || id != int64(i+1) {
+			t.Fatalf("%s: LastInsertId = %d, %v; se esperaba %d", alta.nombre, id, err, i+1)
+		}
+	}
+}
+
+func TestMarcadores(t *testing.T) {
+	casos := []struct {
+		nombre    string
+		n         int
+		numerados bool
+		desde     int
+		espera    string
+	}{
+		{"uno", 1, false, 1, "?"},
+		{"varios", 3, false, 1, "?, ?, ?"},
+		{"numerados", 3, true, 1, "$1, $2, $3"},
+		{"numerados después de otro parámetro", 2, true, 2, "$2, $3"},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			if got := Marcadores(c.n, c.numerados, c.desde); got != c.espera {
+				t.Fatalf("Marcadores(%d, %v, %d) = %q; se esperaba %q", c.n, c.numerados, c.desde, got, c.espera)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package basedatos
+
+import (
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// códigos de error de clave duplicada de cada motor
+const (
+	duplicadoMySQL    = 1062
+	duplicadoPostgres = "23505"
+)
+
+// EsDuplicado indica si el error es una violación de un índice único o de la clave primaria, en cualquiera de los
+// motores. Los repositorios lo usan para devolver su propio error de conflicto en lugar de ErrExec.
+func EsDuplicado(err error) bool {
+	var errMySQL *mysql.MySQLError
+	if errors.As(err, &errMySQL) {
+		return errMySQL.Number == duplicadoMySQL
+	}
+	var errPostgres *pq.Error
+	if errors.As(err, &errPostgres) {
+		return errPostgres.Code == duplicadoPostgres
+	}
+	var errSqlite *sqlite.Error
+	if errors.As(err, &errSqlite) {
+		return errSqlite.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || errSqlite.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+	}
+	return false
+}
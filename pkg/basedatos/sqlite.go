@@ -0,0 +1,65 @@
+package basedatos
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"time"
+
+	"modernc.org/sqlite"
+)
+
+// formatoFecha es como se guardan las fechas en SQLite, que no tiene un tipo propio: texto en UTC y con milisegundos,
+// siempre del mismo largo, así las comparaciones y los ORDER BY de las consultas funcionan igual que en MySQL.
+const formatoFecha = "2006-01-02 15:04:05.000"
+
+// conectorSqlite abre las conexiones del pool con el driver de SQLite, envueltas para que las fechas se guarden
+// como en MySQL. Al leer, el driver convierte a time.Time las columnas declaradas DATE o DATETIME.
+type conectorSqlite struct {
+	dsn string
+}
+
+func (c conectorSqlite) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Driver().Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+	completa, ok := conn.(conexionCompleta)
+	if !ok {
+		conn.Close()
+		return nil, fmt.Errorf("el driver de SQLite no soporta contextos")
+	}
+	return conexionSqlite{completa}, nil
+}
+
+func (c conectorSqlite) Driver() driver.Driver {
+	return &sqlite.Driver{}
+}
+
+// conexionSqlite es una conexión del driver que pasa las fechas a formatoFecha antes de mandarlas
+type conexionSqlite struct {
+	conexionCompleta
+}
+
+// CheckNamedValue convierte las fechas, incluidas las sql.NullTime; el resto de los valores sigue la conversión
+// normal de database/sql
+func (c conexionSqlite) CheckNamedValue(nv *driver.NamedValue) error {
+	switch valor := nv.Value.(type) {
+	case time.Time:
+		nv.Value = aTexto(valor)
+	case sql.NullTime:
+		if !valor.Valid {
+			nv.Value = nil
+			return nil
+		}
+		nv.Value = aTexto(valor.Time)
+	default:
+		return driver.ErrSkip
+	}
+	return nil
+}
+
+func aTexto(t time.Time) string {
+	return t.UTC().Round(time.Millisecond).Format(formatoFecha)
+}
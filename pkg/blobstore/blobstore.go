@@ -0,0 +1,22 @@
+// Package blobstore guarda archivos binarios (radiografías, consentimientos escaneados, fotos) fuera de la base de datos.
+// La base sólo guarda la clave con la que se recupera el archivo desde el BlobStore configurado.
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// Errores
+var (
+	ErrNotFound = errors.New("archivo no encontrado")
+	ErrClave    = errors.New("clave de archivo inválida")
+)
+
+// BlobStore es el almacenamiento de archivos. Las claves usan "/" como separador, sin importar el backend.
+type BlobStore interface {
+	Put(ctx context.Context, clave string, contenido io.Reader, tamanio int64, contentType string) error
+	Get(ctx context.Context, clave string) (io.ReadCloser, error)
+	Delete(ctx context.Context, clave string) error
+}
@@ -0,0 +1,84 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// estructura del almacenamiento en disco, todo queda debajo de dir
+type local struct {
+	dir string
+}
+
+// NewLocal guarda los archivos en un directorio del servidor, creándolo si no existe.
+func NewLocal(dir string) (BlobStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	return &local{dir: dir}, nil
+}
+
+func (l *local) Put(ctx context.Context, clave string, contenido io.Reader, tamanio int64, contentType string) error {
+	destino, err := l.ruta(clave)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(destino), 0o750); err != nil {
+		return err
+	}
+
+	// escribo en un temporal y renombro al final, así nunca queda un archivo a medio escribir con la clave definitiva
+	tmp, err := os.CreateTemp(filepath.Dir(destino), ".subida-*")
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(tmp, contenido); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), destino)
+}
+
+func (l *local) Get(ctx context.Context, clave string) (io.ReadCloser, error) {
+	origen, err := l.ruta(clave)
+	if err != nil {
+		return nil, err
+	}
+	archivo, err := os.Open(origen)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return archivo, err
+}
+
+func (l *local) Delete(ctx context.Context, clave string) error {
+	destino, err := l.ruta(clave)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(destino)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// ruta traduce la clave a un path del disco sin dejar que se escape del directorio base
+func (l *local) ruta(clave string) (string, error) {
+	limpia := path.Clean("/" + clave)
+	if clave == "" || limpia == "/" || strings.Contains(clave, "..") {
+		return "", ErrClave
+	}
+	return filepath.Join(l.dir, filepath.FromSlash(strings.TrimPrefix(limpia, "/"))), nil
+}
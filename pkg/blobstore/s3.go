@@ -0,0 +1,189 @@
+package blobstore
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// payload sin firmar: S3 y los compatibles (MinIO, etc.) lo aceptan y permite subir el archivo en streaming
+const payloadSinFirmar = "UNSIGNED-PAYLOAD"
+
+// ConfigS3 son los datos de conexión a un almacenamiento compatible con S3.
+// Endpoint es la URL base (https://s3.amazonaws.com, http://localhost:9000 para un MinIO local, etc.).
+type ConfigS3 struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+}
+
+// estructura del cliente S3. Usa direccionamiento por path (endpoint/bucket/clave), que soportan todos los compatibles.
+type s3 struct {
+	config   ConfigS3
+	endpoint *url.URL
+	client   *http.Client
+}
+
+// NewS3 arma el cliente S3 firmando los pedidos con AWS Signature V4. Si client es nil se usa http.DefaultClient.
+func NewS3(config ConfigS3, client *http.Client) (BlobStore, error) {
+	endpoint, err := url.Parse(strings.TrimSuffix(config.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("endpoint S3 inválido: %q", config.Endpoint)
+	}
+	if config.Bucket == "" {
+		return nil, fmt.Errorf("falta el bucket S3")
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &s3{config: config, endpoint: endpoint, client: client}, nil
+}
+
+func (s *s3) Put(ctx context.Context, clave string, contenido io.Reader, tamanio int64, contentType string) error {
+	req, err := s.nuevoPedido(ctx, http.MethodPut, clave, contenido)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = tamanio
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := s.ejecutar(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *s3) Get(ctx context.Context, clave string) (io.ReadCloser, error) {
+	req, err := s.nuevoPedido(ctx, http.MethodGet, clave, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.ejecutar(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (s *s3) Delete(ctx context.Context, clave string) error {
+	req, err := s.nuevoPedido(ctx, http.MethodDelete, clave, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.ejecutar(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *s3) nuevoPedido(ctx context.Context, metodo string, clave string, contenido io.Reader) (*http.Request, error) {
+	if clave == "" || strings.Contains(clave, "..") {
+		return nil, ErrClave
+	}
+	u := *s.endpoint
+	u.Path = s.endpoint.Path + "/" + s.config.Bucket + "/" + strings.TrimPrefix(clave, "/")
+	return http.NewRequestWithContext(ctx, metodo, u.String(), contenido)
+}
+
+// ejecutar firma y envía el pedido. Un 404 se traduce a ErrNotFound y cualquier otro error HTTP se devuelve con el cuerpo de S3.
+func (s *s3) ejecutar(req *http.Request) (*http.Response, error) {
+	s.firmar(req, time.Now().UTC())
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode >= 300 {
+		detalle, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("S3 respondió %d: %s", resp.StatusCode, strings.TrimSpace(string(detalle)))
+	}
+	return resp, nil
+}
+
+// firmar agrega los encabezados de AWS Signature V4 (https://docs.aws.amazon.com/general/latest/gr/sigv4_signing.html)
+func (s *s3) firmar(req *http.Request, ahora time.Time) {
+	fecha := ahora.Format("20060102")
+	fechaHora := ahora.Format("20060102T150405Z")
+	req.Header.Set("Host", req.URL.Host)
+	req.Header.Set("X-Amz-Date", fechaHora)
+	req.Header.Set("X-Amz-Content-Sha256", payloadSinFirmar)
+
+	// encabezados firmados, en minúscula y ordenados
+	var nombres []string
+	valores := map[string]string{}
+	for nombre := range req.Header {
+		minuscula := strings.ToLower(nombre)
+		if minuscula == "host" || minuscula == "content-type" || strings.HasPrefix(minuscula, "x-amz-") {
+			nombres = append(nombres, minuscula)
+			valores[minuscula] = strings.TrimSpace(req.Header.Get(nombre))
+		}
+	}
+	sort.Strings(nombres)
+	var canonicos strings.Builder
+	for _, nombre := range nombres {
+		canonicos.WriteString(nombre + ":" + valores[nombre] + "\n")
+	}
+	firmados := strings.Join(nombres, ";")
+	// el encabezado Host lo arma net/http a partir de req.Host, no hace falta mandarlo dos veces
+	req.Header.Del("Host")
+
+	pedidoCanonico := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicos.String(),
+		firmados,
+		payloadSinFirmar,
+	}, "\n")
+
+	alcance := fecha + "/" + s.config.Region + "/s3/aws4_request"
+	aFirmar := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		fechaHora,
+		alcance,
+		hexSHA256([]byte(pedidoCanonico)),
+	}, "\n")
+
+	clave := hmacSHA256([]byte("AWS4"+s.config.SecretKey), fecha)
+	clave = hmacSHA256(clave, s.config.Region)
+	clave = hmacSHA256(clave, "s3")
+	clave = hmacSHA256(clave, "aws4_request")
+	firma := hex.EncodeToString(hmacSHA256(clave, aFirmar))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.config.AccessKey, alcance, firmados, firma))
+}
+
+func hmacSHA256(clave []byte, dato string) []byte {
+	h := hmac.New(sha256.New, clave)
+	h.Write([]byte(dato))
+	return h.Sum(nil)
+}
+
+func hexSHA256(dato []byte) string {
+	suma := sha256.Sum256(dato)
+	return hex.EncodeToString(suma[:])
+}
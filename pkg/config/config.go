@@ -0,0 +1,479 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// niveles de log: debug muestra el detalle de gin y de cada pedido, info cada pedido y error sólo los errores
+const (
+	LogDebug = "debug"
+	LogInfo  = "info"
+	LogError = "error"
+)
+
+// motores de base soportados. SQLite guarda todo en un solo archivo, para consultorios chicos y desarrollo.
+const (
+	MotorMySQL    = "mysql"
+	MotorPostgres = "postgres"
+	MotorSQLite   = "sqlite"
+)
+
+// dónde se guardan pacientes, odontólogos y turnos: en la base, o en memoria para pruebas y demos (se pierden al
+// reiniciar). El resto de los datos sigue en la base, así que memoria sólo arranca si la base no tiene pacientes,
+// odontólogos, turnos ni filas que los referencien o los auditen, y deja sin rutas a obras sociales, facturación,
+// liquidaciones, comprobantes, adjuntos, consentimientos y portal. Los usuarios no se pueden vincular a un
+// odontólogo en memoria.
+const (
+	RepositoriosDB      = "db"
+	RepositoriosMemoria = "memoria"
+)
+
+// archivo YAML que se lee si existe y no se indicó otro con CONFIG_ARCHIVO
+const archivoPorDefecto = "config.yaml"
+
+// los valores de ejemplo de las claves empiezan así; Validar no los acepta para que nadie arranque con ellos
+const marcaEjemplo = "cambiar-"
+
+// dónde se guardan los archivos adjuntos y cómo se envían los mensajes a los pacientes
+const (
+	AdjuntosLocal       = "local"
+	AdjuntosS3          = "s3"
+	NotificadorLog      = "log"
+	NotificadorWebhook  = "webhook"
+	largoMinimoSecretos = 32
+)
+
+// Config reúne la configuración de la aplicación. Se arma con los valores por defecto, después el archivo YAML (si hay)
+// y por último las variables de entorno, que pisan a todo lo anterior. El .env define las variables que no estén
+// definidas, así que también pisa al YAML: por eso el repositorio trae .env.example y no un .env.
+type Config struct {
+	Servidor     Servidor    `yaml:"servidor"`
+	DB           DB          `yaml:"db"`
+	Repositorios string      `yaml:"repositorios"`
+	Tokens       Tokens      `yaml:"tokens"`
+	Timeouts     Timeouts    `yaml:"timeouts"`
+	Log          Log         `yaml:"log"`
+	Clinica      Clinica     `yaml:"clinica"`
+	Turnos       Turnos      `yaml:"turnos"`
+	Adjuntos     Adjuntos    `yaml:"adjuntos"`
+	Notificador  Notificador `yaml:"notificador"`
+	Portal       Portal      `yaml:"portal"`
+}
+
+// Servidor es el puerto en el que escucha la API y, si se quiere HTTPS, el certificado y su clave.
+// Proxies son las IP o redes (separadas por coma) de los proxies de los que se acepta X-Forwarded-For; vacío es que
+// la IP del cliente es la de la conexión, así nadie puede inventarse otra para esquivar los límites por IP.
+type Servidor struct {
+	Puerto   int    `yaml:"puerto"`
+	TLSCert  string `yaml:"tls_cert"`
+	TLSClave string `yaml:"tls_clave"`
+	Proxies  string `yaml:"proxies"`
+}
+
+// DB es el motor de la base, los datos de conexión y el tamaño del pool. Con SQLite sólo hace falta el archivo.
+type DB struct {
+	Motor    string `yaml:"motor"`
+	Archivo  string `yaml:"archivo"`
+	Usuario  string `yaml:"usuario"`
+	Password string `yaml:"password"`
+	Host     string `yaml:"host"`
+	Puerto   int    `yaml:"puerto"`
+	Nombre   string `yaml:"nombre"`
+	// TLS es el modo del driver de MySQL: vacío (sin TLS), true, false, skip-verify o preferred. Con PostgreSQL se
+	// traduce al sslmode equivalente.
+	TLS          string `yaml:"tls"`
+	MaxAbiertas  int    `yaml:"max_abiertas"`
+	MaxInactivas int    `yaml:"max_inactivas"`
+	VidaMaxMin   int    `yaml:"vida_max_min"`
+}
+
+// Tokens son las claves y vigencias de los JWT del personal, y el usuario que se crea si no hay ninguno.
+// Claves lleva pares "kid:secreto" separados por coma y ClaveActual el kid con el que se firma.
+type Tokens struct {
+	Claves        string `yaml:"claves"`
+	ClaveActual   string `yaml:"clave_actual"`
+	Emisor        string `yaml:"emisor"`
+	VigenciaMin   int    `yaml:"vigencia_min"`
+	RefreshDias   int    `yaml:"refresh_dias"`
+	AdminEmail    string `yaml:"admin_email"`
+	AdminPassword string `yaml:"admin_password"`
+}
+
+// Timeouts son los tiempos máximos, en segundos, de las lecturas, las escrituras y los procesos pesados. En cero no
+// hay límite.
+type Timeouts struct {
+	LecturaSeg   int `yaml:"lectura_seg"`
+	EscrituraSeg int `yaml:"escritura_seg"`
+	ProcesosSeg  int `yaml:"procesos_seg"`
+}
+
+// Log es el nivel de detalle de los logs
+type Log struct {
+	Nivel string `yaml:"nivel"`
+}
+
+// Clinica son los datos que salen en los documentos impresos y, si se quieren reemplazar, el directorio de sus
+// plantillas
+type Clinica struct {
+	Nombre     string `yaml:"nombre"`
+	Direccion  string `yaml:"direccion"`
+	Telefono   string `yaml:"telefono"`
+	Plantillas string `yaml:"plantillas"`
+}
+
+// Turnos es el horario de atención, la política de cancelaciones y ausencias, qué hacer al atender sin consentimiento
+// informado (advertir o bloquear) y con los turnos futuros al dar de baja a alguien (borrar o bloquear). Van como
+// texto porque los interpreta el paquete turno, que toma su valor por defecto para lo que quede vacío.
+type Turnos struct {
+	HoraInicio           string `yaml:"hora_inicio"`
+	HoraFin              string `yaml:"hora_fin"`
+	DuracionMin          string `yaml:"duracion_min"`
+	Dias                 string `yaml:"dias"`
+	CancelacionHoras     string `yaml:"cancelacion_horas"`
+	MaxAusencias         string `yaml:"max_ausencias"`
+	AusenciasMeses       string `yaml:"ausencias_meses"`
+	RestriccionAusencias string `yaml:"restriccion_ausencias"`
+	Penalidad            string `yaml:"penalidad"`
+	Consentimiento       string `yaml:"consentimiento"`
+	BajaFuturos          string `yaml:"baja_futuros"`
+}
+
+// Adjuntos es dónde se guardan los archivos de los pacientes (local o s3) y su tamaño máximo en MB
+type Adjuntos struct {
+	Almacenamiento string `yaml:"almacenamiento"`
+	Directorio     string `yaml:"directorio"`
+	MaxMB          int    `yaml:"max_mb"`
+	S3             S3     `yaml:"s3"`
+}
+
+// S3 son los datos de cualquier servicio compatible con S3
+type S3 struct {
+	Endpoint  string `yaml:"endpoint"`
+	Region    string `yaml:"region"`
+	Bucket    string `yaml:"bucket"`
+	AccessKey string `yaml:"access_key"`
+	SecretKey string `yaml:"secret_key"`
+}
+
+// Notificador es cómo se mandan los mensajes a los pacientes: sólo al log, o a la URL de una pasarela por webhook
+type Notificador struct {
+	Tipo string `yaml:"tipo"`
+	URL  string `yaml:"url"`
+}
+
+// Portal es el secreto que firma las sesiones del portal de pacientes (el mismo en todas las instancias, de al menos
+// 32 bytes) y desde cuántas horas y hasta cuántos días hacia adelante se reservan turnos
+type Portal struct {
+	Secreto           string `yaml:"secreto"`
+	AnticipacionHoras int    `yaml:"anticipacion_horas"`
+	VentanaDias       int    `yaml:"ventana_dias"`
+}
+
+// porDefecto devuelve la configuración con la que corre la API en desarrollo. El usuario y la contraseña de la base
+// no tienen valor por defecto: hay que indicarlos siempre.
+func porDefecto() Config {
+	return Config{
+		Servidor: Servidor{Puerto: 8080},
+		DB: DB{
+			Motor:        MotorMySQL,
+			Archivo:      "clinica.db",
+			Host:         "localhost",
+			Puerto:       3306,
+			Nombre:       "my_db",
+			MaxAbiertas:  20,
+			MaxInactivas: 10,
+			VidaMaxMin:   30,
+		},
+		Repositorios: RepositoriosDB,
+		Tokens:       Tokens{Emisor: "finalgo", VigenciaMin: 15, RefreshDias: 30},
+		Timeouts:     Timeouts{LecturaSeg: 5, EscrituraSeg: 10, ProcesosSeg: 60},
+		Log:          Log{Nivel: LogInfo},
+		Clinica:      Clinica{Nombre: "Clinica Odontologica"},
+		Adjuntos:     Adjuntos{Almacenamiento: AdjuntosLocal, Directorio: "adjuntos", MaxMB: 10},
+		Notificador:  Notificador{Tipo: NotificadorLog},
+		Portal:       Portal{AnticipacionHoras: 2, VentanaDias: 60},
+	}
+}
+
+// Cargar lee el .env, el archivo YAML de CONFIG_ARCHIVO (o config.yaml si existe) y las variables de entorno, y
+// valida el resultado
+func Cargar() (Config, error) {
+	// el .env es opcional y no pisa variables ya definidas
+	if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return Config{}, fmt.Errorf("al leer el .env: %w", err)
+	}
+
+	cfg := porDefecto()
+	archivo := os.Getenv("CONFIG_ARCHIVO")
+	if archivo == "" {
+		if _, err := os.Stat(archivoPorDefecto); err == nil {
+			archivo = archivoPorDefecto
+		}
+	}
+	if archivo != "" {
+		contenido, err := os.ReadFile(archivo)
+		if err != nil {
+			return Config{}, fmt.Errorf("al leer %s: %w", archivo, err)
+		}
+		if err := yaml.Unmarshal(contenido, &cfg); err != nil {
+			return Config{}, fmt.Errorf("al leer %s: %w", archivo, err)
+		}
+	}
+
+	if err := cfg.leerEntorno(); err != nil {
+		return Config{}, err
+	}
+	if err := cfg.Validar(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// leerEntorno pisa cada campo con su variable de entorno, si está definida
+func (c *Config) leerEntorno() error {
+	variables := []struct {
+		nombre  string
+		destino interface{}
+	}{
+		{"PUERTO", &c.Servidor.Puerto},
+		{"TLS_CERT", &c.Servidor.TLSCert},
+		{"TLS_CLAVE", &c.Servidor.TLSClave},
+		{"PROXIES_CONFIABLES", &c.Servidor.Proxies},
+		{"DB_MOTOR", &c.DB.Motor},
+		{"DB_ARCHIVO", &c.DB.Archivo},
+		{"DB_USUARIO", &c.DB.Usuario},
+		{"DB_PASSWORD", &c.DB.Password},
+		{"DB_HOST", &c.DB.Host},
+		{"DB_PUERTO", &c.DB.Puerto},
+		{"DB_NOMBRE", &c.DB.Nombre},
+		{"DB_TLS", &c.DB.TLS},
+		{"DB_MAX_ABIERTAS", &c.DB.MaxAbiertas},
+		{"DB_MAX_INACTIVAS", &c.DB.MaxInactivas},
+		{"DB_VIDA_MAX_MIN", &c.DB.VidaMaxMin},
+		{"REPOSITORIOS", &c.Repositorios},
+		{"JWT_CLAVES", &c.Tokens.Claves},
+		{"JWT_CLAVE_ACTUAL", &c.Tokens.ClaveActual},
+		{"JWT_EMISOR", &c.Tokens.Emisor},
+		{"JWT_VIGENCIA_MIN", &c.Tokens.VigenciaMin},
+		{"JWT_REFRESH_DIAS", &c.Tokens.RefreshDias},
+		{"ADMIN_EMAIL", &c.Tokens.AdminEmail},
+		{"ADMIN_PASSWORD", &c.Tokens.AdminPassword},
+		{"DB_TIMEOUT_LECTURA_SEG", &c.Timeouts.LecturaSeg},
+		{"DB_TIMEOUT_ESCRITURA_SEG", &c.Timeouts.EscrituraSeg},
+		{"DB_TIMEOUT_PROCESOS_SEG", &c.Timeouts.ProcesosSeg},
+		{"LOG_NIVEL", &c.Log.Nivel},
+		{"CLINICA_NOMBRE", &c.Clinica.Nombre},
+		{"CLINICA_DIRECCION", &c.Clinica.Direccion},
+		{"CLINICA_TELEFONO", &c.Clinica.Telefono},
+		{"DOCUMENTOS_PLANTILLAS", &c.Clinica.Plantillas},
+		{"TURNOS_HORA_INICIO", &c.Turnos.HoraInicio},
+		{"TURNOS_HORA_FIN", &c.Turnos.HoraFin},
+		{"TURNOS_DURACION_MIN", &c.Turnos.DuracionMin},
+		{"TURNOS_DIAS", &c.Turnos.Dias},
+		{"TURNOS_CANCELACION_HORAS", &c.Turnos.CancelacionHoras},
+		{"TURNOS_MAX_AUSENCIAS", &c.Turnos.MaxAusencias},
+		{"TURNOS_AUSENCIAS_MESES", &c.Turnos.AusenciasMeses},
+		{"TURNOS_RESTRICCION_AUSENCIAS", &c.Turnos.RestriccionAusencias},
+		{"TURNOS_PENALIDAD", &c.Turnos.Penalidad},
+		{"CONSENTIMIENTO_MODO", &c.Turnos.Consentimiento},
+		{"BAJA_TURNOS_FUTUROS", &c.Turnos.BajaFuturos},
+		{"ADJUNTOS_STORAGE", &c.Adjuntos.Almacenamiento},
+		{"ADJUNTOS_DIR", &c.Adjuntos.Directorio},
+		{"ADJUNTOS_MAX_MB", &c.Adjuntos.MaxMB},
+		{"S3_ENDPOINT", &c.Adjuntos.S3.Endpoint},
+		{"S3_REGION", &c.Adjuntos.S3.Region},
+		{"S3_BUCKET", &c.Adjuntos.S3.Bucket},
+		{"S3_ACCESS_KEY", &c.Adjuntos.S3.AccessKey},
+		{"S3_SECRET_KEY", &c.Adjuntos.S3.SecretKey},
+		{"NOTIFICADOR", &c.Notificador.Tipo},
+		{"NOTIFICADOR_URL", &c.Notificador.URL},
+		{"PORTAL_SECRETO", &c.Portal.Secreto},
+		{"PORTAL_ANTICIPACION_HORAS", &c.Portal.AnticipacionHoras},
+		{"PORTAL_VENTANA_DIAS", &c.Portal.VentanaDias},
+	}
+
+	for _, v := range variables {
+		valor, ok := os.LookupEnv(v.nombre)
+		if !ok || valor == "" {
+			continue
+		}
+		switch destino := v.destino.(type) {
+		case *string:
+			*destino = valor
+		case *int:
+			numero, err := strconv.Atoi(valor)
+			if err != nil {
+				return fmt.Errorf("%s debe ser un número entero: %q", v.nombre, valor)
+			}
+			*destino = numero
+		}
+	}
+	return nil
+}
+
+// Validar revisa que la configuración sea usable y devuelve el primer problema que encuentra
+func (c Config) Validar() error {
+	switch {
+	case c.Servidor.Puerto < 1 || c.Servidor.Puerto > 65535:
+		return fmt.Errorf("puerto inválido: %d", c.Servidor.Puerto)
+	case (c.Servidor.TLSCert == "") != (c.Servidor.TLSClave == ""):
+		return errors.New("para HTTPS hacen falta el certificado y la clave (TLS_CERT y TLS_CLAVE)")
+	case c.DB.Motor != MotorMySQL && c.DB.Motor != MotorPostgres && c.DB.Motor != MotorSQLite:
+		return fmt.Errorf("motor de base inválido: %q (mysql, postgres o sqlite)", c.DB.Motor)
+	case c.DB.Motor == MotorSQLite && c.DB.Archivo == "":
+		return errors.New("falta el archivo de la base SQLite (DB_ARCHIVO)")
+	case c.DB.Motor != MotorSQLite && (c.DB.Usuario == "" || c.DB.Host == "" || c.DB.Nombre == ""):
+		return errors.New("faltan datos de conexión a la base (DB_USUARIO, DB_HOST o DB_NOMBRE)")
+	case c.DB.Motor != MotorSQLite && c.DB.Password == "":
+		return errors.New("falta la contraseña de la base (DB_PASSWORD)")
+	case c.DB.Motor != MotorSQLite && strings.HasPrefix(c.DB.Password, marcaEjemplo):
+		return errors.New("DB_PASSWORD tiene la contraseña de ejemplo: hay que poner la de la base")
+	case c.DB.Motor != MotorSQLite && (c.DB.Puerto < 1 || c.DB.Puerto > 65535):
+		return fmt.Errorf("puerto de la base inválido: %d", c.DB.Puerto)
+	case c.DB.Motor == MotorPostgres && sslmode(c.DB.TLS) == "":
+		return fmt.Errorf("modo TLS inválido para PostgreSQL: %q (vacío, true, false, skip-verify o preferred)", c.DB.TLS)
+	case c.DB.MaxAbiertas < 0 || c.DB.MaxInactivas < 0 || c.DB.VidaMaxMin < 0:
+		return errors.New("el tamaño y la vida del pool de conexiones no pueden ser negativos")
+	case c.DB.MaxAbiertas > 0 && c.DB.MaxInactivas > c.DB.MaxAbiertas:
+		return fmt.Errorf("DB_MAX_INACTIVAS (%d) no puede superar a DB_MAX_ABIERTAS (%d)", c.DB.MaxInactivas, c.DB.MaxAbiertas)
+	case c.Repositorios != RepositoriosDB && c.Repositorios != RepositoriosMemoria:
+		return fmt.Errorf("repositorios inválidos: %q (db o memoria)", c.Repositorios)
+	case c.Tokens.Claves == "" || c.Tokens.ClaveActual == "":
+		return errors.New("faltan las claves de JWT (JWT_CLAVES y JWT_CLAVE_ACTUAL)")
+	case strings.Contains(c.Tokens.Claves, marcaEjemplo):
+		return errors.New("JWT_CLAVES tiene la clave de ejemplo: hay que generar una propia")
+	case strings.HasPrefix(c.Tokens.AdminPassword, marcaEjemplo):
+		return errors.New("ADMIN_PASSWORD tiene la contraseña de ejemplo: hay que elegir otra")
+	case c.Tokens.VigenciaMin < 1 || c.Tokens.RefreshDias < 1:
+		return errors.New("las vigencias de los tokens tienen que ser positivas")
+	case c.Timeouts.LecturaSeg < 0 || c.Timeouts.EscrituraSeg < 0 || c.Timeouts.ProcesosSeg < 0:
+		return errors.New("los timeouts no pueden ser negativos")
+	case c.Log.Nivel != LogDebug && c.Log.Nivel != LogInfo && c.Log.Nivel != LogError:
+		return fmt.Errorf("nivel de log inválido: %q (debug, info o error)", c.Log.Nivel)
+	case c.Turnos.Consentimiento != "" && c.Turnos.Consentimiento != "advertir" && c.Turnos.Consentimiento != "bloquear":
+		return fmt.Errorf("CONSENTIMIENTO_MODO inválido: %q (advertir o bloquear)", c.Turnos.Consentimiento)
+	case c.Turnos.BajaFuturos != "" && c.Turnos.BajaFuturos != "borrar" && c.Turnos.BajaFuturos != "bloquear":
+		return fmt.Errorf("BAJA_TURNOS_FUTUROS inválido: %q (borrar o bloquear)", c.Turnos.BajaFuturos)
+	case c.Adjuntos.Almacenamiento != AdjuntosLocal && c.Adjuntos.Almacenamiento != AdjuntosS3:
+		return fmt.Errorf("ADJUNTOS_STORAGE inválido: %q (local o s3)", c.Adjuntos.Almacenamiento)
+	case c.Adjuntos.Almacenamiento == AdjuntosLocal && c.Adjuntos.Directorio == "":
+		return errors.New("falta el directorio de los adjuntos (ADJUNTOS_DIR)")
+	case c.Adjuntos.MaxMB < 1:
+		return fmt.Errorf("ADJUNTOS_MAX_MB tiene que ser positivo: %d", c.Adjuntos.MaxMB)
+	case c.Notificador.Tipo != NotificadorLog && c.Notificador.Tipo != NotificadorWebhook:
+		return fmt.Errorf("NOTIFICADOR inválido: %q (log o webhook)", c.Notificador.Tipo)
+	case c.Notificador.Tipo == NotificadorWebhook && c.Notificador.URL == "":
+		return errors.New("falta la URL del notificador (NOTIFICADOR_URL)")
+	case strings.HasPrefix(c.Portal.Secreto, marcaEjemplo):
+		return errors.New("PORTAL_SECRETO tiene el secreto de ejemplo: hay que generar uno propio")
+	case len(c.Portal.Secreto) < largoMinimoSecretos:
+		return fmt.Errorf("PORTAL_SECRETO tiene que tener al menos %d bytes", largoMinimoSecretos)
+	case c.Portal.AnticipacionHoras < 0 || c.Portal.VentanaDias < 1:
+		return errors.New("la anticipación del portal no puede ser negativa y la ventana tiene que ser de al menos un día")
+	}
+	return nil
+}
+
+// DSN arma la cadena de conexión del driver de MySQL. La base elegida es la que usan todas las consultas.
+func (d DB) DSN() string {
+	dsn := mysql.NewConfig()
+	dsn.User = d.Usuario
+	dsn.Passwd = d.Password
+	dsn.Net = "tcp"
+	dsn.Addr = fmt.Sprintf("%s:%d", d.Host, d.Puerto)
+	dsn.DBName = d.Nombre
+	dsn.ParseTime = true
+	dsn.TLSConfig = d.TLS
+	return dsn.FormatDSN()
+}
+
+// DSNPostgres arma la cadena de conexión del driver de PostgreSQL. La sesión trabaja en UTC, así las fechas se leen
+// igual que con MySQL.
+func (d DB) DSNPostgres() string {
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(d.Usuario, d.Password),
+		Host:     fmt.Sprintf("%s:%d", d.Host, d.Puerto),
+		Path:     "/" + d.Nombre,
+		RawQuery: url.Values{"sslmode": {sslmode(d.TLS)}, "timezone": {"UTC"}}.Encode(),
+	}
+	return dsn.String()
+}
+
+// sslmode traduce el modo TLS de MySQL al de PostgreSQL; vacío si no tiene equivalente
+func sslmode(tls string) string {
+	switch tls {
+	case "", "false":
+		return "disable"
+	case "true":
+		return "verify-full"
+	case "skip-verify":
+		return "require"
+	case "preferred":
+		return "prefer"
+	}
+	return ""
+}
+
+// DSNSqlite arma la cadena de conexión del driver de SQLite: foreign keys activas, WAL para que las lecturas no
+// esperen a las escrituras, espera de hasta 5 segundos si la base está bloqueada y transacciones que toman el bloqueo
+// de escritura al empezar (como no hay SELECT ... FOR UPDATE, así dos transacciones no leen lo mismo para escribir).
+func (d DB) DSNSqlite() string {
+	parametros := url.Values{}
+	parametros.Add("_pragma", "foreign_keys(1)")
+	parametros.Add("_pragma", "journal_mode(WAL)")
+	parametros.Add("_pragma", "busy_timeout(5000)")
+	parametros.Set("_txlock", "immediate")
+	return d.Archivo + "?" + parametros.Encode()
+}
+
+// VidaMaxima es cuánto puede durar abierta una conexión del pool
+func (d DB) VidaMaxima() time.Duration {
+	return time.Duration(d.VidaMaxMin) * time.Minute
+}
+
+// Direccion es la dirección en la que escucha el servidor, por ejemplo ":8080"
+func (s Servidor) Direccion() string {
+	return fmt.Sprintf(":%d", s.Puerto)
+}
+
+// TLS indica si el servidor tiene que atender por HTTPS
+func (s Servidor) TLS() bool {
+	return s.TLSCert != ""
+}
+
+// ProxiesConfiables devuelve la lista de proxies, o nil si no se confía en ninguno
+func (s Servidor) ProxiesConfiables() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(s.Proxies, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
+// Vigencia es cuánto dura un token de acceso
+func (t Tokens) Vigencia() time.Duration {
+	return time.Duration(t.VigenciaMin) * time.Minute
+}
+
+// VigenciaRefresh es cuánto dura un refresh token
+func (t Tokens) VigenciaRefresh() time.Duration {
+	return time.Duration(t.RefreshDias) * 24 * time.Hour
+}
+
+// Lectura, Escritura y Procesos devuelven cada timeout como duración
+func (t Timeouts) Lectura() time.Duration   { return time.Duration(t.LecturaSeg) * time.Second }
+func (t Timeouts) Escritura() time.Duration { return time.Duration(t.EscrituraSeg) * time.Second }
+func (t Timeouts) Procesos() time.Duration  { return time.Duration(t.ProcesosSeg) * time.Second }
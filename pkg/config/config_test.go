@@ -0,0 +1,109 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const yamlPrueba = `
+db:
+  usuario: clinica
+  password: "una-password-de-prueba"
+tokens:
+  claves: "k1:una-clave-de-prueba-de-al-menos-32-bytes"
+  clave_actual: k1
+clinica:
+  nombre: Consultorio del YAML
+turnos:
+  duracion_min: "45"
+adjuntos:
+  max_mb: 25
+portal:
+  secreto: "un-secreto-de-prueba-de-al-menos-32-bytes"
+  ventana_dias: 30
+`
+
+func TestCargarTomaElYAML(t *testing.T) {
+	t.Setenv("CONFIG_ARCHIVO", escribirYAML(t, yamlPrueba))
+
+	cfg, err := Cargar()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Clinica.Nombre != "Consultorio del YAML" || cfg.Turnos.DuracionMin != "45" || cfg.Adjuntos.MaxMB != 25 || cfg.Portal.VentanaDias != 30 {
+		t.Fatalf("no se tomaron los valores del YAML: %+v", cfg)
+	}
+	// lo que el YAML no dice queda con el valor por defecto
+	if cfg.Portal.AnticipacionHoras != 2 || cfg.Adjuntos.Almacenamiento != AdjuntosLocal {
+		t.Fatalf("se perdieron los valores por defecto: %+v", cfg)
+	}
+}
+
+func TestCargarEntornoPisaAlYAML(t *testing.T) {
+	t.Setenv("CONFIG_ARCHIVO", escribirYAML(t, yamlPrueba))
+	t.Setenv("CLINICA_NOMBRE", "Consultorio del entorno")
+	t.Setenv("ADJUNTOS_MAX_MB", "5")
+
+	cfg, err := Cargar()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Clinica.Nombre != "Consultorio del entorno" || cfg.Adjuntos.MaxMB != 5 {
+		t.Fatalf("el entorno no pisó al YAML: %+v", cfg)
+	}
+}
+
+func TestCargarRechazaLosValoresDeEjemplo(t *testing.T) {
+	casos := []struct {
+		nombre   string
+		variable string
+		valor    string
+	}{
+		{"clave JWT", "JWT_CLAVES", "2026-10:cambiar-esta-clave-jwt-de-al-menos-32-bytes"},
+		{"contraseña del admin", "ADMIN_PASSWORD", "cambiar-en-el-primer-ingreso"},
+		{"secreto del portal", "PORTAL_SECRETO", "cambiar-por-un-secreto-de-al-menos-32-bytes"},
+		{"secreto del portal corto", "PORTAL_SECRETO", "corto"},
+		{"contraseña de la base", "DB_PASSWORD", "cambiar-por-la-password-de-la-base"},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			t.Setenv("CONFIG_ARCHIVO", escribirYAML(t, yamlPrueba))
+			t.Setenv("JWT_CLAVE_ACTUAL", "2026-10")
+			t.Setenv(caso.variable, caso.valor)
+
+			_, err := Cargar()
+			if err == nil {
+				t.Fatalf("se aceptó %s=%q", caso.variable, caso.valor)
+			}
+			if !strings.Contains(err.Error(), caso.variable) {
+				t.Fatalf("el error no nombra a %s: %v", caso.variable, err)
+			}
+		})
+	}
+}
+
+func escribirYAML(t *testing.T, contenido string) string {
+	archivo := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(archivo, []byte(contenido), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return archivo
+}
+
+// la contraseña de la base no tiene valor por defecto: sin ella sólo arranca con sqlite
+func TestCargarExigePasswordDeLaBase(t *testing.T) {
+	sinBase := strings.Replace(yamlPrueba, "  password: \"una-password-de-prueba\"\n", "", 1)
+	t.Setenv("CONFIG_ARCHIVO", escribirYAML(t, sinBase))
+
+	if _, err := Cargar(); err == nil || !strings.Contains(err.Error(), "DB_PASSWORD") {
+		t.Fatalf("se aceptó la base mysql sin DB_PASSWORD: %v", err)
+	}
+
+	t.Setenv("DB_MOTOR", MotorSQLite)
+	if _, err := Cargar(); err != nil {
+		t.Fatal(err)
+	}
+}
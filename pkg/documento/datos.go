@@ -0,0 +1,82 @@
+package documento
+
+import "time"
+
+// Clinica son los datos del encabezado de todos los documentos.
+type Clinica struct {
+	Nombre    string
+	Direccion string
+	Telefono  string
+}
+
+// Persona identifica al paciente en el documento.
+type Persona struct {
+	Nombre   string
+	Apellido string
+	DNI      string
+}
+
+// Profesional es el odontólogo que emite o firma el documento.
+type Profesional struct {
+	Nombre    string
+	Apellido  string
+	Matricula string
+}
+
+// ConfirmacionTurno son los datos del comprobante de turno.
+type ConfirmacionTurno struct {
+	Clinica     Clinica
+	Numero      int
+	FechaHora   time.Time
+	Paciente    Persona
+	Profesional Profesional
+	Prestacion  string
+	Descripcion string
+	Emitido     time.Time
+}
+
+// ItemPresupuesto es una línea del presupuesto de tratamiento.
+type ItemPresupuesto struct {
+	Codigo         string
+	Descripcion    string
+	Cantidad       int
+	PrecioUnitario float64
+	Subtotal       float64
+	ObraSocial     float64
+	Paciente       float64
+}
+
+// Presupuesto son los datos del presupuesto de tratamiento.
+type Presupuesto struct {
+	Clinica         Clinica
+	Fecha           time.Time
+	ValidoHasta     time.Time
+	Paciente        Persona
+	Profesional     Profesional
+	ObraSocial      string
+	Items           []ItemPresupuesto
+	Total           float64
+	TotalObraSocial float64
+	TotalPaciente   float64
+	Observaciones   string
+}
+
+// Medicamento es una línea de la receta.
+type Medicamento struct {
+	Nombre       string
+	Presentacion string
+	Posologia    string
+	Cantidad     int
+}
+
+// Receta son los datos de la receta firmada por el odontólogo.
+type Receta struct {
+	Clinica        Clinica
+	Fecha          time.Time
+	Paciente       Persona
+	ObraSocial     string
+	NumeroAfiliado string
+	Profesional    Profesional
+	Medicamentos   []Medicamento
+	Indicaciones   string
+}
@@ -0,0 +1,119 @@
+// Package documento genera los PDF imprimibles de la clínica (comprobantes de turno, presupuestos y recetas)
+// a partir de plantillas de texto. Las plantillas vienen embebidas en el binario y se pueden reemplazar
+// desde un directorio propio sin recompilar.
+package documento
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// plantillas disponibles
+const (
+	PlantillaConfirmacionTurno = "confirmacion_turno"
+	PlantillaPresupuesto       = "presupuesto"
+	PlantillaReceta            = "receta"
+)
+
+// Errores
+var (
+	ErrPlantilla = errors.New("plantilla de documento inexistente")
+	ErrRender    = errors.New("error al generar el documento")
+)
+
+//go:embed plantillas/*.tmpl
+var plantillasEmbebidas embed.FS
+
+// Renderer genera un PDF a partir de una plantilla y sus datos.
+type Renderer interface {
+	Render(plantilla string, datos interface{}) ([]byte, error)
+}
+
+// estructura renderer con las plantillas ya parseadas
+type renderer struct {
+	plantillas *template.Template
+}
+
+// NewRenderer carga las plantillas embebidas y, si se indica un directorio, las que haya ahí con el mismo nombre las reemplazan.
+func NewRenderer(dirPlantillas string) (Renderer, error) {
+	plantillas, err := template.New("documento").Funcs(funciones).ParseFS(plantillasEmbebidas, "plantillas/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+
+	if dirPlantillas != "" {
+		archivos, err := filepath.Glob(filepath.Join(dirPlantillas, "*.tmpl"))
+		if err != nil {
+			return nil, err
+		}
+		for _, archivo := range archivos {
+			contenido, err := os.ReadFile(archivo)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := plantillas.New(filepath.Base(archivo)).Parse(string(contenido)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &renderer{plantillas: plantillas}, nil
+}
+
+// Render ejecuta la plantilla y arma el PDF con el texto resultante.
+func (r *renderer) Render(plantilla string, datos interface{}) ([]byte, error) {
+	t := r.plantillas.Lookup(plantilla + ".tmpl")
+	if t == nil {
+		return nil, ErrPlantilla
+	}
+
+	var texto bytes.Buffer
+	if err := t.Execute(&texto, datos); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRender, err)
+	}
+
+	pdf, err := componerPDF(texto.String())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRender, err)
+	}
+	return pdf, nil
+}
+
+// funciones disponibles dentro de las plantillas
+var funciones = template.FuncMap{
+	"fecha": func(t time.Time) string {
+		return t.Format("02/01/2006")
+	},
+	"fechaHora": func(t time.Time) string {
+		return t.Format("02/01/2006 15:04")
+	},
+	"importe": formatearImporte,
+}
+
+// formatearImporte muestra el importe con separador de miles y coma decimal ($ 12.345,60)
+func formatearImporte(importe float64) string {
+	signo := ""
+	if importe < 0 {
+		signo = "-"
+		importe = -importe
+	}
+	centavos := int64(math.Round(importe * 100))
+	entero := fmt.Sprintf("%d", centavos/100)
+
+	var miles []string
+	for len(entero) > 3 {
+		miles = append([]string{entero[len(entero)-3:]}, miles...)
+		entero = entero[:len(entero)-3]
+	}
+	miles = append([]string{entero}, miles...)
+
+	return fmt.Sprintf("%s$ %s,%02d", signo, strings.Join(miles, "."), centavos%100)
+}
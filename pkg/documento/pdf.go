@@ -0,0 +1,202 @@
+package documento
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/signintech/gopdf"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// medidas de la hoja A4 en milímetros
+const (
+	margen       = 10.0
+	anchoUtil    = 190.0
+	altoHoja     = 297.0
+	altoLinea    = 6.0
+	anchoFirma   = 70.0
+	espacioFirma = 25.0
+)
+
+// familia con la que se registran las fuentes Go embebidas. Son TrueType, así que los acentos y la ñ salen sin traducir.
+const fuente = "go"
+
+// componerPDF interpreta el texto de la plantilla línea por línea:
+//
+//	# título          ## subtítulo          --- separador
+//	| a | b | c       fila de tabla (la primera de cada tabla es el encabezado)
+//	- texto           ítem de lista
+//	@firma l1|l2      bloque de firma con una línea por cada parte
+//
+// Cualquier otra línea es texto común; las líneas vacías dejan un espacio.
+func componerPDF(texto string) ([]byte, error) {
+	h, err := nuevaHoja()
+	if err != nil {
+		return nil, err
+	}
+
+	enTabla := false
+	for _, linea := range strings.Split(strings.ReplaceAll(texto, "\r\n", "\n"), "\n") {
+		esFila := strings.HasPrefix(linea, "|")
+		if !esFila {
+			enTabla = false
+		}
+
+		switch {
+		case strings.HasPrefix(linea, "## "):
+			h.fuente("B", 12)
+			h.renglon(2)
+			h.celda(anchoUtil, altoLinea+1, strings.TrimPrefix(linea, "## "), gopdf.Left, 0)
+			h.renglon(altoLinea + 1)
+		case strings.HasPrefix(linea, "# "):
+			h.fuente("B", 16)
+			h.celda(anchoUtil, altoLinea+2, strings.TrimPrefix(linea, "# "), gopdf.Left, 0)
+			h.renglon(altoLinea + 2)
+		case strings.TrimSpace(linea) == "---":
+			h.renglon(1)
+			y := h.pdf.GetY()
+			h.pdf.Line(margen, y, margen+anchoUtil, y)
+			h.renglon(2)
+		case esFila:
+			celdas := strings.Split(strings.Trim(linea, "| "), "|")
+			estilo := ""
+			if !enTabla {
+				estilo = "B"
+			}
+			h.fuente(estilo, 9)
+			anchos := anchosColumnas(len(celdas))
+			for i, celda := range celdas {
+				alineacion := gopdf.Left
+				if i >= 2 {
+					alineacion = gopdf.Right
+				}
+				h.celda(anchos[i], altoLinea, strings.TrimSpace(celda), alineacion, gopdf.AllBorders)
+			}
+			h.renglon(altoLinea)
+			enTabla = true
+		case strings.HasPrefix(linea, "@firma "):
+			h.firmar(strings.Split(strings.TrimPrefix(linea, "@firma "), "|"))
+		case strings.TrimSpace(linea) == "":
+			h.renglon(altoLinea / 2)
+		default:
+			h.fuente("", 11)
+			h.parrafo(linea)
+		}
+	}
+	if h.err != nil {
+		return nil, h.err
+	}
+
+	var buf bytes.Buffer
+	if _, err := h.pdf.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// hoja envuelve al documento y se queda con el primer error, así el armado no tiene que chequear cada llamada.
+// Después de un error las demás operaciones no hacen nada.
+type hoja struct {
+	pdf *gopdf.GoPdf
+	err error
+}
+
+// nuevaHoja arranca un A4 en milímetros con las fuentes registradas y la primera página agregada
+func nuevaHoja() (*hoja, error) {
+	pdf := &gopdf.GoPdf{}
+	pdf.Start(gopdf.Config{PageSize: *gopdf.PageSizeA4, Unit: gopdf.UnitMM})
+	pdf.SetMargins(margen, margen, margen, margen)
+	if err := pdf.AddTTFFontData(fuente, goregular.TTF); err != nil {
+		return nil, err
+	}
+	if err := pdf.AddTTFFontDataWithOption(fuente, gobold.TTF, gopdf.TtfOption{Style: gopdf.Bold}); err != nil {
+		return nil, err
+	}
+	pdf.AddPage()
+	return &hoja{pdf: pdf}, nil
+}
+
+func (h *hoja) fuente(estilo string, tamano float64) {
+	if h.err == nil {
+		h.err = h.pdf.SetFont(fuente, estilo, tamano)
+	}
+}
+
+// celda escribe el texto en la posición actual y deja el cursor a su derecha
+func (h *hoja) celda(ancho, alto float64, texto string, alineacion int, borde int) {
+	if h.err != nil {
+		return
+	}
+	h.saltoDePagina(alto)
+	h.err = h.pdf.CellWithOption(&gopdf.Rect{W: ancho, H: alto}, texto, gopdf.CellOption{
+		Align:  alineacion | gopdf.Middle,
+		Border: borde,
+		Float:  gopdf.Right,
+	})
+}
+
+// renglon baja el cursor y lo vuelve al margen izquierdo
+func (h *hoja) renglon(alto float64) {
+	h.pdf.Br(alto)
+}
+
+// parrafo corta el texto por palabras al ancho útil y escribe un renglón por parte
+func (h *hoja) parrafo(texto string) {
+	if h.err != nil {
+		return
+	}
+	partes, err := h.pdf.SplitTextWithWordWrap(texto, anchoUtil)
+	if err != nil {
+		h.err = err
+		return
+	}
+	for _, parte := range partes {
+		h.celda(anchoUtil, altoLinea, parte, gopdf.Left, 0)
+		h.renglon(altoLinea)
+	}
+}
+
+// saltoDePagina agrega una página si lo que sigue ya no entra antes del margen inferior
+func (h *hoja) saltoDePagina(alto float64) {
+	if h.pdf.GetY()+alto > altoHoja-margen {
+		x := h.pdf.GetX()
+		h.pdf.AddPage()
+		h.pdf.SetX(x)
+	}
+}
+
+// anchosColumnas reparte el ancho útil dándole el doble de lugar a la segunda columna (la descripción)
+func anchosColumnas(cantidad int) []float64 {
+	partes := float64(cantidad)
+	if cantidad > 2 {
+		partes++
+	}
+	unidad := anchoUtil / partes
+
+	anchos := make([]float64, cantidad)
+	for i := range anchos {
+		anchos[i] = unidad
+		if i == 1 && cantidad > 2 {
+			anchos[i] = unidad * 2
+		}
+	}
+	return anchos
+}
+
+// firmar dibuja la línea de firma a la derecha con el nombre y la matrícula del profesional debajo
+func (h *hoja) firmar(lineas []string) {
+	h.saltoDePagina(espacioFirma + altoLinea*float64(len(lineas)))
+	h.renglon(espacioFirma)
+	x := margen + anchoUtil - anchoFirma
+	y := h.pdf.GetY()
+	h.pdf.Line(x, y, x+anchoFirma, y)
+	h.renglon(1)
+
+	h.fuente("", 10)
+	for _, linea := range lineas {
+		h.pdf.SetX(x)
+		h.celda(anchoFirma, altoLinea-1, strings.TrimSpace(linea), gopdf.Center, 0)
+		h.renglon(altoLinea - 1)
+	}
+}
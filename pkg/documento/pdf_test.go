@@ -0,0 +1,42 @@
+package documento
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestComponerPDF(t *testing.T) {
+	plantilla := strings.Join([]string{
+		"# Presupuesto Nº 12",
+		"## Paciente: Íñigo Muñoz",
+		"---",
+		"| Código | Descripción | Cantidad | Subtotal |",
+		"| 0101 | Consulta odontológica | 1 | $ 15.000,00 |",
+		"",
+		"- ítem de lista con acentos: ñandú, pingüino",
+		strings.Repeat("texto largo que tiene que cortarse en varios renglones ", 20),
+		"@firma Dra. Ana Pérez|M.N. 1234",
+	}, "\n")
+
+	pdf, err := componerPDF(plantilla)
+	if err != nil {
+		t.Fatalf("componerPDF: %v", err)
+	}
+	if !bytes.HasPrefix(pdf, []byte("%PDF-")) {
+		t.Fatal("no es un PDF")
+	}
+}
+
+func TestComponerPDFAgregaPaginas(t *testing.T) {
+	// con cien renglones no entra en una sola hoja
+	plantilla := strings.Repeat("renglón\n", 100)
+
+	pdf, err := componerPDF(plantilla)
+	if err != nil {
+		t.Fatalf("componerPDF: %v", err)
+	}
+	if paginas := bytes.Count(pdf, []byte("/Type /Page\n")) + bytes.Count(pdf, []byte("/Type /Page ")); paginas < 2 {
+		t.Fatalf("se esperaban al menos 2 páginas, hay %d", paginas)
+	}
+}
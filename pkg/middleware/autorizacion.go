@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+
+	"finalgo/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	sinPermisoMsg = "No tiene permiso para esta operación"
+)
+
+// RelacionPaciente indica si el odontólogo atiende al paciente o si el turno es suyo. Lo implementa turno.Service.
+type RelacionPaciente interface {
+	AtiendeAPaciente(ctx context.Context, idOdontologo int, idPaciente int) (bool, error)
+	AtiendeTurno(ctx context.Context, idOdontologo int, idTurno int) (bool, error)
+}
+
+// Autorizar exige que el usuario autenticado tenga el permiso por su rol. Va siempre después de Authenticate.
+func Autorizar(p auth.Permiso) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if _, ok := autorizado(ctx, p); !ok {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": sinPermisoMsg,
+			})
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// AutorizarPaciente es Autorizar para rutas /pacientes/:id: si el rol está limitado a sus pacientes, además exige que
+// el odontólogo del usuario atienda al paciente de la ruta.
+func AutorizarPaciente(p auth.Permiso, rel RelacionPaciente) gin.HandlerFunc {
+	return autorizarConAlcance(p, rel.AtiendeAPaciente)
+}
+
+// AutorizarTurno es Autorizar para rutas /turnos/:id: si el rol está limitado a sus pacientes, además exige que el
+// turno de la ruta sea del odontólogo del usuario.
+func AutorizarTurno(p auth.Permiso, rel RelacionPaciente) gin.HandlerFunc {
+	return autorizarConAlcance(p, rel.AtiendeTurno)
+}
+
+// autorizarConAlcance exige el permiso y, para los roles limitados a sus pacientes, que el odontólogo del usuario
+// tenga relación con el :id de la ruta según verificar
+func autorizarConAlcance(p auth.Permiso, verificar func(ctx context.Context, idOdontologo int, id int) (bool, error)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		usuario, ok := autorizado(ctx, p)
+		if ok && usuario.AlcancePaciente() {
+			id, err := strconv.Atoi(ctx.Param("id"))
+			if err != nil {
+				ok = false
+			} else {
+				ok, err = verificar(ctx, usuario.IdOdontologo, id)
+				if err != nil {
+					log.Println("log de error al verificar el alcance del odontólogo", err.Error())
+					ok = false
+				}
+			}
+		}
+		if !ok {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": sinPermisoMsg,
+			})
+			return
+		}
+		ctx.Next()
+	}
+}
+
+func autorizado(ctx *gin.Context, p auth.Permiso) (auth.Identidad, bool) {
+	usuario, ok := auth.UsuarioDesde(ctx)
+	return usuario, ok && usuario.Puede(p)
+}
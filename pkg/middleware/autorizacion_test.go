@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"finalgo/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// relacionFalsa: el odontólogo 1 atiende al paciente 10 y tiene el turno 100; el turno 200 es del odontólogo 2
+type relacionFalsa struct{}
+
+func (relacionFalsa) AtiendeAPaciente(_ context.Context, idOdontologo int, idPaciente int) (bool, error) {
+	return idOdontologo == 1 && idPaciente == 10, nil
+}
+
+func (relacionFalsa) AtiendeTurno(_ context.Context, idOdontologo int, idTurno int) (bool, error) {
+	return (idOdontologo == 1 && idTurno == 100) || (idOdontologo == 2 && idTurno == 200), nil
+}
+
+func TestAutorizarTurno(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	odontologo1 := auth.Identidad{ID: 5, Rol: auth.RolOdontologo, IdOdontologo: 1}
+	casos := []struct {
+		nombre  string
+		usuario auth.Identidad
+		ruta    string
+		espera  int
+	}{
+		{"odontólogo con su turno", odontologo1, "/turnos/100/atender", http.StatusOK},
+		{"odontólogo con el turno de otro", odontologo1, "/turnos/200/atender", http.StatusForbidden},
+		{"odontólogo con un turno inexistente", odontologo1, "/turnos/300/atender", http.StatusForbidden},
+		{"odontólogo sin odontólogo asociado", auth.Identidad{ID: 6, Rol: auth.RolOdontologo}, "/turnos/100/atender", http.StatusForbidden},
+		{"id inválido", odontologo1, "/turnos/abc/atender", http.StatusForbidden},
+		{"admin con cualquier turno", auth.Identidad{ID: 1, Rol: auth.RolAdmin}, "/turnos/200/atender", http.StatusOK},
+		{"recepción sin el permiso", auth.Identidad{ID: 2, Rol: auth.RolRecepcionista}, "/turnos/100/atender", http.StatusForbidden},
+	}
+
+	for _, caso := range casos {
+		router := gin.New()
+		usuario := caso.usuario
+		router.Use(func(c *gin.Context) { c.Set(auth.ClaveUsuario, usuario) })
+		router.POST("/turnos/:id/atender", AutorizarTurno(auth.PermisoTurnosAtender, relacionFalsa{}), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, caso.ruta, nil))
+		if w.Code != caso.espera {
+			t.Fatalf("%s: status %d, se esperaba %d", caso.nombre, w.Code, caso.espera)
+		}
+	}
+}
+
+func TestAutorizarPaciente(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	odontologo1 := auth.Identidad{ID: 5, Rol: auth.RolOdontologo, IdOdontologo: 1}
+	casos := []struct {
+		nombre string
+		ruta   string
+		espera int
+	}{
+		{"paciente que atiende", "/pacientes/10/alertas", http.StatusOK},
+		{"paciente de otro", "/pacientes/11/alertas", http.StatusForbidden},
+	}
+
+	for _, caso := range casos {
+		router := gin.New()
+		router.Use(func(c *gin.Context) { c.Set(auth.ClaveUsuario, odontologo1) })
+		router.POST("/pacientes/:id/alertas", AutorizarPaciente(auth.PermisoHistoriaEditar, relacionFalsa{}), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, caso.ruta, nil))
+		if w.Code != caso.espera {
+			t.Fatalf("%s: status %d, se esperaba %d", caso.nombre, w.Code, caso.espera)
+		}
+	}
+}
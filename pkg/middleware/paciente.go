@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"finalgo/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClavePaciente es la clave del contexto de gin donde queda el paciente autenticado en el portal.
+const ClavePaciente = auth.ClavePaciente
+
+// VerificadorPaciente valida el token de sesión del portal y devuelve el paciente. Lo implementa portal.Service.
+type VerificadorPaciente interface {
+	Autenticar(token string) (int, error)
+}
+
+// AutenticarPaciente es el middleware del portal de pacientes: exige "Authorization: Bearer <token>" y deja el ID del
+// paciente en el contexto, para que cada ruta trabaje sólo con sus datos.
+func AutenticarPaciente(v VerificadorPaciente) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token, ok := strings.CutPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+		if !ok {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": invalidUserMsg,
+			})
+			return
+		}
+
+		idPaciente, err := v.Autenticar(token)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": invalidUserMsg,
+			})
+			return
+		}
+		ctx.Set(ClavePaciente, idPaciente)
+		ctx.Next()
+	}
+}
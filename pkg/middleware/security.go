@@ -2,35 +2,47 @@ package middleware
 
 import (
 	"net/http"
-	"os"
+	"strings"
+
+	"finalgo/pkg/auth"
 
 	"github.com/gin-gonic/gin"
 )
 
 const (
-	tokenHeaderName = "tokenPostman"
-	envTokenKey     = "TOKEN"
-	invalidUserMsg  = "Credenciales incorrectas"
+	invalidUserMsg = "Credenciales incorrectas"
 )
 
-// Authenticate es un middleware que verifica si se proporciona el token correcto en la cabecera.
-func Authenticate() gin.HandlerFunc {
-	return func(ctx *gin.Context) {
-		// Obtener el token desde las variables de entorno.
-		token := os.Getenv(envTokenKey)
+// Verificador valida el token de acceso del personal y devuelve el usuario. Lo implementa auth.Tokens.
+type Verificador interface {
+	Verificar(token string) (auth.Identidad, error)
+}
 
+// Authenticate es un middleware que exige "Authorization: Bearer <token>" con un JWT válido y deja al usuario
+// autenticado en el contexto de gin (auth.ClaveUsuario) y en el del request, para que lo lean los services.
+func Authenticate(v Verificador) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
 		// Obtener el token de la cabecera de la solicitud.
-		tokenHeader := ctx.GetHeader(tokenHeaderName)
+		token, ok := strings.CutPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+		if !ok {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": invalidUserMsg,
+			})
+			return
+		}
 
-		// Verificar si el token coincide.
-		if tokenHeader != token {
+		// Verificar firma y vencimiento.
+		usuario, err := v.Verificar(token)
+		if err != nil {
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 				"error": invalidUserMsg,
 			})
 			return
-		} else {
-			// Continuar con la solicitud si el token es válido.
-			ctx.Next()
 		}
+
+		// Continuar con la solicitud si el token es válido.
+		ctx.Set(auth.ClaveUsuario, usuario)
+		ctx.Request = ctx.Request.WithContext(auth.ConUsuario(ctx.Request.Context(), usuario))
+		ctx.Next()
 	}
 }
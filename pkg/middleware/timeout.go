@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Timeouts son los tiempos máximos de cada tipo de operación; en cero no hay límite. Las consultas a la base usan el
+// contexto del pedido, así que al vencer el tiempo (o si el cliente se desconecta) se cortan en lugar de seguir
+// ocupando una conexión.
+type Timeouts struct {
+	Lectura   time.Duration
+	Escritura time.Duration
+	// PorRuta pisa los anteriores para operaciones puntuales (lotes, exportaciones, archivos). La clave es el método
+	// y la ruta como la registra gin, por ejemplo "POST /api/v1/liquidaciones".
+	PorRuta map[string]time.Duration
+}
+
+// limite devuelve el tiempo máximo del pedido según su ruta o su método
+func (t Timeouts) limite(c *gin.Context) time.Duration {
+	if limite, ok := t.PorRuta[c.Request.Method+" "+c.FullPath()]; ok {
+		return limite
+	}
+	switch c.Request.Method {
+	case "GET", "HEAD", "OPTIONS":
+		return t.Lectura
+	default:
+		return t.Escritura
+	}
+}
+
+// Timeout le pone al contexto del pedido el tiempo máximo de su operación. Para que los handlers, que pasan el
+// *gin.Context como contexto, lo respeten, el engine tiene que tener ContextWithFallback activado.
+func Timeout(t Timeouts) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limite := t.limite(c)
+		if limite <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), limite)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
@@ -0,0 +1,399 @@
+package migraciones
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"finalgo/pkg/config"
+)
+
+// archivos de cada migración: NNNN_nombre.up.sql aplica el cambio y NNNN_nombre.down.sql lo deshace. Cada motor tiene
+// su directorio y su propia numeración.
+//
+//go:embed mysql/*.sql postgres/*.sql sqlite/*.sql
+var archivos embed.FS
+
+// Errores
+var (
+	ErrArchivo       = errors.New("archivo de migración inválido")
+	ErrIncompleta    = errors.New("la migración no tiene up y down")
+	ErrDesconocida   = errors.New("la base tiene aplicada una migración que este binario no conoce")
+	ErrMotor         = errors.New("no hay migraciones para el motor de base")
+	ErrEsquemaPrevio = errors.New("la base tiene sólo algunas de las tablas de script.sql y no tiene tabla de control")
+)
+
+// Queries de la tabla de control
+var (
+	QueryCrearTabla = `CREATE TABLE IF NOT EXISTS schema_migrations (
+  version INT NOT NULL,
+  nombre VARCHAR(100) NOT NULL,
+  aplicada DATETIME NOT NULL,
+  PRIMARY KEY (version)
+)`
+	QueryCrearTablaPostgres = `CREATE TABLE IF NOT EXISTS schema_migrations (
+  version INT NOT NULL,
+  nombre VARCHAR(100) NOT NULL,
+  aplicada TIMESTAMPTZ NOT NULL,
+  PRIMARY KEY (version)
+)`
+	QueryGetAplicadas = `SELECT version, aplicada FROM schema_migrations ORDER BY version`
+	QueryInsert       = `INSERT INTO schema_migrations(version, nombre, aplicada) VALUES(?,?,?)`
+	QueryDelete       = `DELETE FROM schema_migrations WHERE version = ?`
+
+	QueryInsertPostgres = `INSERT INTO schema_migrations(version, nombre, aplicada) VALUES($1, $2, $3)`
+	QueryDeletePostgres = `DELETE FROM schema_migrations WHERE version = $1`
+
+	// una base creada con el script.sql original tiene sus tres tablas (y nada más del esquema), aunque no tenga la
+	// tabla de control
+	QueryEsquemaPrevio = `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name IN ('odontologo', 'paciente', 'turno')`
+)
+
+// tablasEsquemaPrevio es cuántas tablas creaba script.sql
+const tablasEsquemaPrevio = 3
+
+// dialecto es lo que cambia entre motores para el migrador
+type dialecto struct {
+	dir        string
+	crearTabla string
+	// alta y baja de una versión en la tabla de control
+	insert string
+	delete string
+	// consulta que cuenta las tablas del esquema inicial en una base anterior a las migraciones; vacía si el motor
+	// nunca tuvo bases así
+	esquemaPrevio string
+	// el motor puede deshacer los cambios de esquema: cada migración corre en una transacción
+	transaccional bool
+}
+
+var dialectos = map[string]dialecto{
+	config.MotorMySQL: {
+		dir: "mysql", crearTabla: QueryCrearTabla, insert: QueryInsert, delete: QueryDelete,
+		esquemaPrevio: QueryEsquemaPrevio,
+	},
+	config.MotorPostgres: {
+		dir: "postgres", crearTabla: QueryCrearTablaPostgres, insert: QueryInsertPostgres, delete: QueryDeletePostgres,
+		transaccional: true,
+	},
+	config.MotorSQLite: {
+		dir: "sqlite", crearTabla: QueryCrearTabla, insert: QueryInsert, delete: QueryDelete,
+		transaccional: true,
+	},
+}
+
+// patrón del nombre de los archivos
+var nombreArchivo = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migracion es un cambio del esquema con su vuelta atrás
+type Migracion struct {
+	Version int
+	Nombre  string
+	Up      string
+	Down    string
+}
+
+// Estado es una migración y si está aplicada en la base
+type Estado struct {
+	Version  int        `json:"version"`
+	Nombre   string     `json:"nombre"`
+	Aplicada *time.Time `json:"aplicada,omitempty"`
+}
+
+// Migrador aplica y deshace las migraciones embebidas en el binario, registrándolas en schema_migrations
+type Migrador struct {
+	db          *sql.DB
+	dialecto    dialecto
+	migraciones []Migracion
+}
+
+// NewMigrador lee las migraciones embebidas del motor y las ordena por versión
+func NewMigrador(db *sql.DB, motor string) (*Migrador, error) {
+	d, ok := dialectos[motor]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrMotor, motor)
+	}
+	migraciones, err := cargar(archivos, d.dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrador{db, d, migraciones}, nil
+}
+
+// cargar arma las migraciones a partir de los pares de archivos up y down del directorio
+func cargar(fsys fs.FS, dir string) ([]Migracion, error) {
+	entradas, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	porVersion := map[int]*Migracion{}
+	for _, e := range entradas {
+		partes := nombreArchivo.FindStringSubmatch(e.Name())
+		if partes == nil {
+			return nil, fmt.Errorf("%w: %s", ErrArchivo, e.Name())
+		}
+		version, _ := strconv.Atoi(partes[1])
+		contenido, err := fs.ReadFile(fsys, dir+"/"+e.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := porVersion[version]
+		if !ok {
+			m = &Migracion{Version: version, Nombre: partes[2]}
+			porVersion[version] = m
+		}
+		if m.Nombre != partes[2] {
+			return nil, fmt.Errorf("%w: la versión %d tiene dos nombres", ErrArchivo, version)
+		}
+		if partes[3] == "up" {
+			m.Up = string(contenido)
+		} else {
+			m.Down = string(contenido)
+		}
+	}
+
+	migraciones := []Migracion{}
+	for _, m := range porVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("%w: %04d_%s", ErrIncompleta, m.Version, m.Nombre)
+		}
+		migraciones = append(migraciones, *m)
+	}
+	sort.Slice(migraciones, func(i, j int) bool { return migraciones[i].Version < migraciones[j].Version })
+	return migraciones, nil
+}
+
+// Status devuelve todas las migraciones conocidas, con la fecha en que se aplicó cada una
+func (m *Migrador) Status(ctx context.Context) ([]Estado, error) {
+	if _, err := m.db.ExecContext(ctx, m.dialecto.crearTabla); err != nil {
+		return nil, err
+	}
+	aplicadas, err := m.aplicadas(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	estados := []Estado{}
+	for _, mig := range m.migraciones {
+		estado := Estado{Version: mig.Version, Nombre: mig.Nombre}
+		if fecha, ok := aplicadas[mig.Version]; ok {
+			estado.Aplicada = &fecha
+		}
+		estados = append(estados, estado)
+	}
+	return estados, nil
+}
+
+// Up aplica en orden todas las migraciones pendientes y devuelve las que aplicó. Si una falla se detiene ahí: las
+// anteriores quedan aplicadas. MySQL confirma solo cada cambio de esquema, así que una migración que falla a la
+// mitad puede dejar sus primeras sentencias hechas; en PostgreSQL y SQLite se deshace entera.
+func (m *Migrador) Up(ctx context.Context) ([]Migracion, error) {
+	conn, err := m.conexion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	aplicadas, err := m.aplicadas(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.adoptarEsquemaPrevio(ctx, conn, aplicadas); err != nil {
+		return nil, err
+	}
+
+	hechas := []Migracion{}
+	for _, mig := range m.migraciones {
+		if _, ok := aplicadas[mig.Version]; ok {
+			continue
+		}
+		err := m.aplicar(ctx, conn, mig.Up, func(e ejecutor) error {
+			_, err := e.ExecContext(ctx, m.dialecto.insert, mig.Version, mig.Nombre, time.Now())
+			return err
+		})
+		if err != nil {
+			return hechas, fmt.Errorf("migración %04d_%s: %w", mig.Version, mig.Nombre, err)
+		}
+		hechas = append(hechas, mig)
+	}
+	return hechas, nil
+}
+
+// Down deshace las últimas migraciones aplicadas, de la más nueva a la más vieja, y devuelve las que deshizo
+func (m *Migrador) Down(ctx context.Context, pasos int) ([]Migracion, error) {
+	conn, err := m.conexion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	aplicadas, err := m.aplicadas(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	porVersion := map[int]Migracion{}
+	for _, mig := range m.migraciones {
+		porVersion[mig.Version] = mig
+	}
+	versiones := []int{}
+	for version := range aplicadas {
+		if _, ok := porVersion[version]; !ok {
+			return nil, fmt.Errorf("%w: %d", ErrDesconocida, version)
+		}
+		versiones = append(versiones, version)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(versiones)))
+
+	hechas := []Migracion{}
+	for i := 0; i < pasos && i < len(versiones); i++ {
+		mig := porVersion[versiones[i]]
+		err := m.aplicar(ctx, conn, mig.Down, func(e ejecutor) error {
+			_, err := e.ExecContext(ctx, m.dialecto.delete, mig.Version)
+			return err
+		})
+		if err != nil {
+			return hechas, fmt.Errorf("migración %04d_%s: %w", mig.Version, mig.Nombre, err)
+		}
+		hechas = append(hechas, mig)
+	}
+	return hechas, nil
+}
+
+// conexion reserva una sola conexión para toda la corrida (las migraciones usan variables de sesión como
+// FOREIGN_KEY_CHECKS) y se asegura de que exista la tabla de control
+func (m *Migrador) conexion(ctx context.Context) (*sql.Conn, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.ExecContext(ctx, m.dialecto.crearTabla); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// consultor es lo que comparten *sql.DB y *sql.Conn para leer la tabla de control
+type consultor interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// aplicadas devuelve las versiones registradas en schema_migrations con su fecha
+func (m *Migrador) aplicadas(ctx context.Context, db consultor) (map[int]time.Time, error) {
+	rows, err := db.QueryContext(ctx, QueryGetAplicadas)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aplicadas := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var fecha time.Time
+		if err := rows.Scan(&version, &fecha); err != nil {
+			return nil, err
+		}
+		aplicadas[version] = fecha
+	}
+	return aplicadas, rows.Err()
+}
+
+// adoptarEsquemaPrevio marca como aplicada la primera migración en las bases creadas con el script.sql original:
+// tienen las tres tablas pero nunca tuvieron la tabla de control. Así las siguientes migraciones las corrigen y
+// completan el esquema. Si están sólo algunas no se sabe qué tiene la base y no se toca nada.
+func (m *Migrador) adoptarEsquemaPrevio(ctx context.Context, conn *sql.Conn, aplicadas map[int]time.Time) error {
+	if len(aplicadas) > 0 || len(m.migraciones) == 0 || m.dialecto.esquemaPrevio == "" {
+		return nil
+	}
+	var tablas int
+	if err := conn.QueryRowContext(ctx, m.dialecto.esquemaPrevio).Scan(&tablas); err != nil {
+		return err
+	}
+	switch {
+	case tablas == 0:
+		return nil
+	case tablas < tablasEsquemaPrevio:
+		return fmt.Errorf("%w: hay %d de %d", ErrEsquemaPrevio, tablas, tablasEsquemaPrevio)
+	}
+
+	inicial := m.migraciones[0]
+	log.Printf("la base ya tiene el esquema de script.sql: se marca %04d_%s como aplicada", inicial.Version, inicial.Nombre)
+	fecha := time.Now()
+	if _, err := conn.ExecContext(ctx, m.dialecto.insert, inicial.Version, inicial.Nombre, fecha); err != nil {
+		return err
+	}
+	aplicadas[inicial.Version] = fecha
+	return nil
+}
+
+// ejecutor es lo que comparten *sql.Conn y *sql.Tx para correr las sentencias
+type ejecutor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// aplicar corre el script y después registrar, que anota el cambio en la tabla de control. Si el motor lo permite,
+// todo va en una transacción.
+func (m *Migrador) aplicar(ctx context.Context, conn *sql.Conn, script string, registrar func(e ejecutor) error) error {
+	if !m.dialecto.transaccional {
+		if err := ejecutar(ctx, conn, script); err != nil {
+			return err
+		}
+		return registrar(conn)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := ejecutar(ctx, tx, script); err != nil {
+		return err
+	}
+	if err := registrar(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ejecutar corre una por una las sentencias del archivo
+func ejecutar(ctx context.Context, conn ejecutor, script string) error {
+	for i, sentencia := range Sentencias(script) {
+		if _, err := conn.ExecContext(ctx, sentencia); err != nil {
+			return fmt.Errorf("sentencia %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// Sentencias separa un script SQL en sentencias: cada una termina con ";" al final de una línea. Las líneas de
+// comentario ("--") se descartan.
+func Sentencias(script string) []string {
+	sentencias := []string{}
+	var actual strings.Builder
+	for _, linea := range strings.Split(script, "\n") {
+		recortada := strings.TrimSpace(linea)
+		if recortada == "" || strings.HasPrefix(recortada, "--") {
+			continue
+		}
+		actual.WriteString(linea)
+		actual.WriteString("\n")
+		if strings.HasSuffix(recortada, ";") {
+			sentencias = append(sentencias, strings.TrimSuffix(strings.TrimSpace(actual.String()), ";"))
+			actual.Reset()
+		}
+	}
+	if resto := strings.TrimSpace(actual.String()); resto != "" {
+		sentencias = append(sentencias, resto)
+	}
+	return sentencias
+}
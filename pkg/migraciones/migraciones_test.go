@@ -0,0 +1,38 @@
+package migraciones
+
+import (
+	"regexp"
+	"testing"
+
+	"finalgo/pkg/config"
+)
+
+func TestMigracionesConsecutivas(t *testing.T) {
+	for _, motor := range []string{config.MotorMySQL, config.MotorPostgres, config.MotorSQLite} {
+		m, err := NewMigrador(nil, motor)
+		if err != nil {
+			t.Fatalf("%s: %v", motor, err)
+		}
+		for i, mig := range m.migraciones {
+			if mig.Version != i+1 {
+				t.Fatalf("%s: la migración %d es la %04d_%s, se esperaba la versión %d", motor, i+1, mig.Version, mig.Nombre, i+1)
+			}
+		}
+	}
+}
+
+// una base creada con script.sql se adopta marcando la 0001 como aplicada: tiene que crear sólo esas tres tablas
+func TestEsquemaInicialMySQLEsElDeScriptSQL(t *testing.T) {
+	m, err := NewMigrador(nil, config.MotorMySQL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tablas := regexp.MustCompile("CREATE TABLE IF NOT EXISTS `(\\w+)`").FindAllStringSubmatch(m.migraciones[0].Up, -1)
+	nombres := []string{}
+	for _, tabla := range tablas {
+		nombres = append(nombres, tabla[1])
+	}
+	if len(nombres) != tablasEsquemaPrevio || nombres[0] != "odontologo" || nombres[1] != "paciente" || nombres[2] != "turno" {
+		t.Fatalf("la 0001 crea %v, se esperaban odontologo, paciente y turno", nombres)
+	}
+}
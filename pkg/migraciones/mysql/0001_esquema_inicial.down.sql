@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS `turno`;
+DROP TABLE IF EXISTS `paciente`;
+DROP TABLE IF EXISTS `odontologo`;
@@ -1,6 +1,6 @@
--- Creación de la base de datos 'clinic'
-CREATE DATABASE IF NOT EXISTS `my_db` /*!40100 DEFAULT CHARACTER SET utf8 */ /*!80016 DEFAULT ENCRYPTION='N' */;
-USE `my_db`;
+-- Esquema inicial: el mismo que creaba script.sql, con sus errores, para que las bases ya creadas con ese script
+-- queden en esta versión. Lo corrigen 0002 y 0003 y el resto del esquema llega en 0004. Los datos de ejemplo se
+-- cargan con clinicctl seed.
 
 CREATE TABLE IF NOT EXISTS `odontologo` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Identificador del odontologo en el sistema',
@@ -36,25 +36,3 @@ CREATE TABLE IF NOT EXISTS `turno` (
     FOREIGN KEY (`id`)
     REFERENCES `paciente` (`id`)
 ) ENGINE = InnoDB AUTO_INCREMENT = 1 DEFAULT CHARACTER SET = utf8mb3;
-
--- Inserciones en la tabla 'odontologo'
-INSERT INTO `odontologo` (`apellido`, `nombre`, `matricula`)
-VALUES
-('Pérez', 'Juan', '12345'),
-('Gómez', 'María', '67890'),
-('López', 'Carlos', '54321');
-
--- Inserciones en la tabla 'paciente'
-INSERT INTO `paciente` (`nombre`, `apellido`, `domicilio`, `dni`, `fecha_alta`)
-VALUES
-('Ana', 'Martínez', 'Calle 123', 12345678, '2023-09-18'),
-('Pedro', 'González', 'Avenida XYZ', 87654321, '2023-09-19'),
-('Laura', 'Díaz', 'Calle ABC', 98765432, '2023-09-20');
-
-
--- Inserciones en la tabla 'turno'
-INSERT INTO `turno` (`id_odontologo`, `id_paciente`, `fecha_hora`, `descripcion`)
-VALUES
-(1, 1, '2023-09-22 10:00:00.000', 'Limpieza dental'),
-(2, 2, '2023-09-23 15:30:00.000', 'Extracción de muelas'),
-(3, 3, '2023-09-24 09:15:00.000', 'Consulta de rutina');
\ No newline at end of file
@@ -0,0 +1,11 @@
+-- Vuelve a las foreign keys originales, sobre el id del turno
+ALTER TABLE `turno` DROP FOREIGN KEY `turno_FK`;
+ALTER TABLE `turno` DROP FOREIGN KEY `turno_FK_1`;
+
+ALTER TABLE `turno`
+  ADD CONSTRAINT `turno_FK`
+    FOREIGN KEY (`id`)
+    REFERENCES `odontologo` (`id`),
+  ADD CONSTRAINT `turno_FK_1`
+    FOREIGN KEY (`id`)
+    REFERENCES `paciente` (`id`);
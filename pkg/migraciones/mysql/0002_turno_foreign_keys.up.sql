@@ -0,0 +1,11 @@
+-- Las foreign keys de turno apuntaban el id del turno (y no id_odontologo e id_paciente) al odontólogo y al paciente.
+ALTER TABLE `turno` DROP FOREIGN KEY `turno_FK`;
+ALTER TABLE `turno` DROP FOREIGN KEY `turno_FK_1`;
+
+ALTER TABLE `turno`
+  ADD CONSTRAINT `turno_FK`
+    FOREIGN KEY (`id_odontologo`)
+    REFERENCES `odontologo` (`id`),
+  ADD CONSTRAINT `turno_FK_1`
+    FOREIGN KEY (`id_paciente`)
+    REFERENCES `paciente` (`id`);
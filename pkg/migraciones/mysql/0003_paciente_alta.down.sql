@@ -0,0 +1 @@
+ALTER TABLE `paciente` RENAME COLUMN `alta` TO `fecha_alta`;
@@ -0,0 +1,2 @@
+-- El código usa la columna alta; el script original la creaba como fecha_alta
+ALTER TABLE `paciente` RENAME COLUMN `fecha_alta` TO `alta`;
@@ -0,0 +1,56 @@
+-- Vuelve a las tres tablas del script original. Las foreign keys se desactivan porque odontologo y usuario se apuntan
+-- entre sí.
+SET FOREIGN_KEY_CHECKS = 0;
+
+ALTER TABLE `odontologo` DROP FOREIGN KEY `odontologo_deleted_by_FK`;
+ALTER TABLE `paciente` DROP FOREIGN KEY `paciente_deleted_by_FK`;
+ALTER TABLE `turno` DROP FOREIGN KEY `turno_deleted_by_FK`;
+
+DROP TABLE IF EXISTS `auditoria`;
+DROP TABLE IF EXISTS `refresh_token`;
+DROP TABLE IF EXISTS `usuario`;
+DROP TABLE IF EXISTS `incidencia_turno`;
+DROP TABLE IF EXISTS `codigo_portal`;
+DROP TABLE IF EXISTS `odontologo_especialidad`;
+DROP TABLE IF EXISTS `especialidad`;
+DROP TABLE IF EXISTS `responsable_paciente`;
+DROP TABLE IF EXISTS `contacto_emergencia`;
+DROP TABLE IF EXISTS `telefono_paciente`;
+DROP TABLE IF EXISTS `alerta_medica`;
+DROP TABLE IF EXISTS `consentimiento`;
+DROP TABLE IF EXISTS `plantilla_consentimiento`;
+DROP TABLE IF EXISTS `adjunto`;
+DROP TABLE IF EXISTS `layout_liquidacion`;
+DROP TABLE IF EXISTS `pago_liquidacion`;
+DROP TABLE IF EXISTS `item_liquidacion`;
+DROP TABLE IF EXISTS `lote_liquidacion`;
+DROP TABLE IF EXISTS `pago`;
+DROP TABLE IF EXISTS `cargo`;
+DROP TABLE IF EXISTS `prestacion`;
+DROP TABLE IF EXISTS `regla_cobertura`;
+DROP TABLE IF EXISTS `cobertura_paciente`;
+DROP TABLE IF EXISTS `obra_social`;
+
+SET FOREIGN_KEY_CHECKS = 1;
+
+ALTER TABLE `turno`
+  DROP INDEX `turno_deleted_at`,
+  DROP COLUMN `codigo_prestacion`,
+  DROP COLUMN `estado`,
+  DROP COLUMN `deleted_at`,
+  DROP COLUMN `deleted_by`;
+
+ALTER TABLE `paciente`
+  DROP INDEX `paciente_deleted_at`,
+  DROP COLUMN `fecha_nacimiento`,
+  DROP COLUMN `email`,
+  DROP COLUMN `canal_preferido`,
+  DROP COLUMN `acepta_recordatorios`,
+  DROP COLUMN `acepta_marketing`,
+  DROP COLUMN `deleted_at`,
+  DROP COLUMN `deleted_by`;
+
+ALTER TABLE `odontologo`
+  DROP INDEX `odontologo_deleted_at`,
+  DROP COLUMN `deleted_at`,
+  DROP COLUMN `deleted_by`;
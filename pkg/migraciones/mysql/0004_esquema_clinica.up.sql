@@ -0,0 +1,439 @@
+-- Esquema de la clínica sobre las tres tablas del script original: bajas lógicas y datos de contacto en las tablas
+-- principales, y todas las tablas nuevas
+
+ALTER TABLE `odontologo`
+  ADD COLUMN `deleted_at` DATETIME NULL DEFAULT NULL COMMENT 'Fecha de la baja lógica, NULL si está activo',
+  ADD COLUMN `deleted_by` INT NULL DEFAULT NULL COMMENT 'Usuario que hizo la baja',
+  ADD INDEX `odontologo_deleted_at` (`deleted_at` ASC) VISIBLE;
+
+ALTER TABLE `paciente`
+  ADD COLUMN `fecha_nacimiento` DATE NULL DEFAULT NULL COMMENT 'Fecha de nacimiento, para calcular la edad',
+  ADD COLUMN `email` VARCHAR(254) NOT NULL DEFAULT '' COMMENT 'Correo electrónico normalizado en minúsculas',
+  ADD COLUMN `canal_preferido` VARCHAR(10) NOT NULL DEFAULT '' COMMENT 'telefono, sms, whatsapp o email',
+  ADD COLUMN `acepta_recordatorios` TINYINT(1) NOT NULL DEFAULT 0 COMMENT 'Consiente recibir recordatorios de turnos',
+  ADD COLUMN `acepta_marketing` TINYINT(1) NOT NULL DEFAULT 0 COMMENT 'Consiente recibir comunicaciones comerciales',
+  ADD COLUMN `deleted_at` DATETIME NULL DEFAULT NULL COMMENT 'Fecha de la baja lógica, NULL si está activo',
+  ADD COLUMN `deleted_by` INT NULL DEFAULT NULL COMMENT 'Usuario que hizo la baja',
+  ADD INDEX `paciente_deleted_at` (`deleted_at` ASC) VISIBLE;
+
+ALTER TABLE `turno`
+  ADD COLUMN `codigo_prestacion` VARCHAR(20) NOT NULL DEFAULT '' COMMENT 'Prestación del catálogo a realizar',
+  ADD COLUMN `estado` VARCHAR(20) NOT NULL DEFAULT 'pendiente' COMMENT 'pendiente, confirmado, atendido, cancelado o ausente',
+  ADD COLUMN `deleted_at` DATETIME NULL DEFAULT NULL COMMENT 'Fecha de la baja lógica, NULL si está activo',
+  ADD COLUMN `deleted_by` INT NULL DEFAULT NULL COMMENT 'Usuario que hizo la baja',
+  ADD INDEX `turno_deleted_at` (`deleted_at` ASC) VISIBLE;
+
+-- Obras sociales y prepagas
+CREATE TABLE IF NOT EXISTS `obra_social` (
+  `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Identificador de la obra social',
+  `nombre` VARCHAR(150) NOT NULL COMMENT 'Razón social o nombre de fantasía',
+  `sigla` VARCHAR(30) NOT NULL COMMENT 'Sigla con la que se la conoce (OSDE, IOMA, ...)',
+  `cuit` VARCHAR(13) NULL DEFAULT NULL COMMENT 'CUIT del financiador',
+  `tipo` VARCHAR(20) NOT NULL DEFAULT 'obra_social' COMMENT 'obra_social o prepaga',
+  PRIMARY KEY (`id`)
+) ENGINE = InnoDB AUTO_INCREMENT = 1 DEFAULT CHARACTER SET = utf8mb3;
+
+CREATE TABLE IF NOT EXISTS `cobertura_paciente` (
+  `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Identificador de la cobertura',
+  `id_paciente` INT NOT NULL COMMENT 'Paciente afiliado',
+  `id_obra_social` INT NOT NULL COMMENT 'Obra social que lo cubre',
+  `plan` VARCHAR(50) NOT NULL DEFAULT '' COMMENT 'Plan contratado',
+  `numero_afiliado` VARCHAR(50) NOT NULL COMMENT 'Número de afiliado',
+  `vigencia_desde` DATE NOT NULL COMMENT 'Inicio de la vigencia',
+  `vigencia_hasta` DATE NULL DEFAULT NULL COMMENT 'Fin de la vigencia, NULL si no vence',
+  PRIMARY KEY (`id`),
+  INDEX `cobertura_paciente_FK` (`id_paciente` ASC) VISIBLE,
+  INDEX `cobertura_paciente_FK_1` (`id_obra_social` ASC) VISIBLE,
+  CONSTRAINT `cobertura_paciente_FK`
+    FOREIGN KEY (`id_paciente`)
+    REFERENCES `paciente` (`id`),
+  CONSTRAINT `cobertura_paciente_FK_1`
+    FOREIGN KEY (`id_obra_social`)
+    REFERENCES `obra_social` (`id`)
+) ENGINE = InnoDB AUTO_INCREMENT = 1 DEFAULT CHARACTER SET = utf8mb3;
+
+CREATE TABLE IF NOT EXISTS `regla_cobertura` (
+  `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Identificador de la regla',
+  `id_obra_social` INT NOT NULL COMMENT 'Obra social a la que aplica',
+  `plan` VARCHAR(50) NOT NULL DEFAULT '' COMMENT 'Plan al que aplica, vacío para todos',
+  `codigo_prestacion` VARCHAR(20) NOT NULL COMMENT 'Código de la prestación (nomenclador)',
+  `porcentaje_cubierto` DECIMAL(5,2) NOT NULL DEFAULT 0 COMMENT 'Porcentaje que cubre la obra social',
+  `copago` DECIMAL(10,2) NOT NULL DEFAULT 0 COMMENT 'Monto fijo a cargo del paciente',
+  `requiere_autorizacion` TINYINT(1) NOT NULL DEFAULT 0 COMMENT 'Si requiere autorización previa',
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `regla_cobertura_UN` (`id_obra_social`, `plan`, `codigo_prestacion`),
+  CONSTRAINT `regla_cobertura_FK`
+    FOREIGN KEY (`id_obra_social`)
+    REFERENCES `obra_social` (`id`)
+) ENGINE = InnoDB AUTO_INCREMENT = 1 DEFAULT CHARACTER SET = utf8mb3;
+
+-- Catálogo de prestaciones con su precio
+CREATE TABLE IF NOT EXISTS `prestacion` (
+  `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Identificador de la prestación',
+  `codigo` VARCHAR(20) NOT NULL COMMENT 'Código del nomenclador',
+  `descripcion` VARCHAR(200) NOT NULL COMMENT 'Descripción de la prestación',
+  `precio` DECIMAL(10,2) NOT NULL DEFAULT 0 COMMENT 'Precio de lista',
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `prestacion_UN` (`codigo`)
+) ENGINE = InnoDB AUTO_INCREMENT = 1 DEFAULT CHARACTER SET = utf8mb3;
+
+-- Facturación: cargos por turno atendido y pagos de pacientes
+CREATE TABLE IF NOT EXISTS `cargo` (
+  `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Identificador del cargo',
+  `id_turno` INT NOT NULL COMMENT 'Turno facturado',
+  `id_paciente` INT NOT NULL COMMENT 'Paciente al que se le carga',
+  `id_obra_social` INT NULL DEFAULT NULL COMMENT 'Obra social que cubre una parte, NULL si no tiene',
+  `codigo_prestacion` VARCHAR(20) NOT NULL COMMENT 'Prestación facturada',
+  `fecha` DATETIME NOT NULL COMMENT 'Fecha de la prestación',
+  `importe` DECIMAL(10,2) NOT NULL COMMENT 'Precio total',
+  `importe_obra_social` DECIMAL(10,2) NOT NULL DEFAULT 0 COMMENT 'Parte a cargo de la obra social',
+  `importe_paciente` DECIMAL(10,2) NOT NULL COMMENT 'Parte a cargo del paciente (incluye copago)',
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `cargo_UN` (`id_turno`),
+  INDEX `cargo_FK_1` (`id_paciente` ASC) VISIBLE,
+  CONSTRAINT `cargo_FK`
+    FOREIGN KEY (`id_turno`)
+    REFERENCES `turno` (`id`),
+  CONSTRAINT `cargo_FK_1`
+    FOREIGN KEY (`id_paciente`)
+    REFERENCES `paciente` (`id`),
+  CONSTRAINT `cargo_FK_2`
+    FOREIGN KEY (`id_obra_social`)
+    REFERENCES `obra_social` (`id`)
+) ENGINE = InnoDB AUTO_INCREMENT = 1 DEFAULT CHARACTER SET = utf8mb3;
+
+CREATE TABLE IF NOT EXISTS `pago` (
+  `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Identificador del pago',
+  `id_paciente` INT NOT NULL COMMENT 'Paciente que paga',
+  `id_cargo` INT NULL DEFAULT NULL COMMENT 'Cargo al que se imputa, NULL si es a cuenta',
+  `medio` VARCHAR(20) NOT NULL COMMENT 'efectivo, tarjeta o transferencia',
+  `importe` DECIMAL(10,2) NOT NULL COMMENT 'Importe pagado',
+  `fecha` DATETIME NOT NULL COMMENT 'Fecha del pago',
+  `referencia` VARCHAR(100) NOT NULL DEFAULT '' COMMENT 'Comprobante, cupón de tarjeta o número de operación',
+  PRIMARY KEY (`id`),
+  INDEX `pago_FK` (`id_paciente` ASC) VISIBLE,
+  CONSTRAINT `pago_FK`
+    FOREIGN KEY (`id_paciente`)
+    REFERENCES `paciente` (`id`),
+  CONSTRAINT `pago_FK_1`
+    FOREIGN KEY (`id_cargo`)
+    REFERENCES `cargo` (`id`)
+) ENGINE = InnoDB AUTO_INCREMENT = 1 DEFAULT CHARACTER SET = utf8mb3;
+
+-- Liquidación a obras sociales: lotes por período, ítems reclamados y pagos recibidos
+CREATE TABLE IF NOT EXISTS `lote_liquidacion` (
+  `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Identificador del lote',
+  `id_obra_social` INT NOT NULL COMMENT 'Obra social a la que se presenta',
+  `periodo` CHAR(7) NOT NULL COMMENT 'Período liquidado (YYYY-MM)',
+  `estado` VARCHAR(20) NOT NULL DEFAULT 'abierto' COMMENT 'abierto, enviado, pagado_parcial, pagado o rechazado',
+  `fecha_creacion` DATETIME NOT NULL COMMENT 'Fecha de generación del lote',
+  `fecha_envio` DATETIME NULL DEFAULT NULL COMMENT 'Fecha de presentación a la obra social',
+  `total` DECIMAL(12,2) NOT NULL DEFAULT 0 COMMENT 'Total reclamado',
+  PRIMARY KEY (`id`),
+  INDEX `lote_liquidacion_FK` (`id_obra_social` ASC) VISIBLE,
+  CONSTRAINT `lote_liquidacion_FK`
+    FOREIGN KEY (`id_obra_social`)
+    REFERENCES `obra_social` (`id`)
+) ENGINE = InnoDB AUTO_INCREMENT = 1 DEFAULT CHARACTER SET = utf8mb3;
+
+CREATE TABLE IF NOT EXISTS `item_liquidacion` (
+  `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Identificador del ítem',
+  `id_lote` INT NOT NULL COMMENT 'Lote al que pertenece',
+  `id_cargo` INT NOT NULL COMMENT 'Cargo del que sale el importe reclamado',
+  `id_paciente` INT NOT NULL COMMENT 'Paciente atendido',
+  `numero_afiliado` VARCHAR(50) NOT NULL DEFAULT '' COMMENT 'Número de afiliado a la fecha de la prestación',
+  `plan` VARCHAR(50) NOT NULL DEFAULT '' COMMENT 'Plan a la fecha de la prestación',
+  `codigo_prestacion` VARCHAR(20) NOT NULL COMMENT 'Prestación reclamada',
+  `fecha` DATETIME NOT NULL COMMENT 'Fecha de la prestación',
+  `importe` DECIMAL(10,2) NOT NULL COMMENT 'Importe reclamado',
+  `importe_pagado` DECIMAL(10,2) NOT NULL DEFAULT 0 COMMENT 'Importe liquidado por la obra social',
+  `estado` VARCHAR(20) NOT NULL DEFAULT 'pendiente' COMMENT 'pendiente, pagado o rechazado',
+  `motivo_rechazo` VARCHAR(200) NOT NULL DEFAULT '' COMMENT 'Motivo informado por la obra social',
+  PRIMARY KEY (`id`),
+  INDEX `item_liquidacion_FK` (`id_lote` ASC) VISIBLE,
+  INDEX `item_liquidacion_FK_1` (`id_cargo` ASC) VISIBLE,
+  CONSTRAINT `item_liquidacion_FK`
+    FOREIGN KEY (`id_lote`)
+    REFERENCES `lote_liquidacion` (`id`),
+  CONSTRAINT `item_liquidacion_FK_1`
+    FOREIGN KEY (`id_cargo`)
+    REFERENCES `cargo` (`id`)
+) ENGINE = InnoDB AUTO_INCREMENT = 1 DEFAULT CHARACTER SET = utf8mb3;
+
+CREATE TABLE IF NOT EXISTS `pago_liquidacion` (
+  `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Identificador del pago',
+  `id_lote` INT NOT NULL COMMENT 'Lote pagado',
+  `fecha` DATETIME NOT NULL COMMENT 'Fecha de registro del pago',
+  `importe` DECIMAL(12,2) NOT NULL COMMENT 'Importe recibido',
+  `referencia` VARCHAR(100) NOT NULL DEFAULT '' COMMENT 'Número de transferencia u orden de pago',
+  PRIMARY KEY (`id`),
+  CONSTRAINT `pago_liquidacion_FK`
+    FOREIGN KEY (`id_lote`)
+    REFERENCES `lote_liquidacion` (`id`)
+) ENGINE = InnoDB AUTO_INCREMENT = 1 DEFAULT CHARACTER SET = utf8mb3;
+
+CREATE TABLE IF NOT EXISTS `layout_liquidacion` (
+  `id_obra_social` INT NOT NULL COMMENT 'Obra social dueña del layout',
+  `definicion` TEXT NOT NULL COMMENT 'Definición JSON del layout de exportación',
+  PRIMARY KEY (`id_obra_social`),
+  CONSTRAINT `layout_liquidacion_FK`
+    FOREIGN KEY (`id_obra_social`)
+    REFERENCES `obra_social` (`id`)
+) ENGINE = InnoDB DEFAULT CHARACTER SET = utf8mb3;
+
+CREATE TABLE IF NOT EXISTS `adjunto` (
+  `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Identificador del adjunto',
+  `id_paciente` INT NOT NULL COMMENT 'Paciente dueño del archivo',
+  `tipo` VARCHAR(20) NOT NULL COMMENT 'radiografia, consentimiento, foto u otro',
+  `nombre` VARCHAR(255) NOT NULL COMMENT 'Nombre original del archivo',
+  `content_type` VARCHAR(100) NOT NULL COMMENT 'Tipo de contenido detectado',
+  `tamanio` BIGINT NOT NULL COMMENT 'Tamaño en bytes',
+  `descripcion` VARCHAR(255) NOT NULL DEFAULT '' COMMENT 'Descripción libre',
+  `clave` VARCHAR(255) NOT NULL COMMENT 'Clave del archivo en el almacenamiento',
+  `fecha_carga` DATETIME NOT NULL COMMENT 'Fecha de subida',
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `adjunto_UN` (`clave`),
+  CONSTRAINT `adjunto_FK`
+    FOREIGN KEY (`id_paciente`)
+    REFERENCES `paciente` (`id`)
+) ENGINE = InnoDB AUTO_INCREMENT = 1 DEFAULT CHARACTER SET = utf8mb3;
+
+CREATE TABLE IF NOT EXISTS `plantilla_consentimiento` (
+  `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Identificador de la plantilla',
+  `codigo_prestacion` VARCHAR(20) NOT NULL COMMENT 'Prestación que exige el consentimiento',
+  `version` INT NOT NULL COMMENT 'Versión correlativa por prestación',
+  `titulo` VARCHAR(150) NOT NULL COMMENT 'Título del consentimiento',
+  `texto` TEXT NOT NULL COMMENT 'Texto legal que firma el paciente',
+  `vigencia_dias` INT NOT NULL DEFAULT 0 COMMENT 'Días de validez de la firma, 0 si no vence',
+  `activa` TINYINT(1) NOT NULL DEFAULT 1 COMMENT 'Sólo la última versión de cada prestación está activa',
+  `fecha_creacion` DATETIME NOT NULL COMMENT 'Fecha de carga de la versión',
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `plantilla_consentimiento_UN` (`codigo_prestacion`, `version`)
+) ENGINE = InnoDB AUTO_INCREMENT = 1 DEFAULT CHARACTER SET = utf8mb3;
+
+CREATE TABLE IF NOT EXISTS `consentimiento` (
+  `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Identificador de la firma',
+  `id_paciente` INT NOT NULL COMMENT 'Paciente que consiente',
+  `id_plantilla` INT NOT NULL COMMENT 'Versión de la plantilla firmada',
+  `firmado_por` VARCHAR(150) NOT NULL COMMENT 'Nombre de quien firmó (paciente o responsable)',
+  `id_responsable` INT NULL DEFAULT NULL COMMENT 'Responsable que firmó por el menor (sin FK para conservar la firma si se lo desvincula)',
+  `fecha_firma` DATETIME NOT NULL COMMENT 'Fecha de la firma',
+  `id_adjunto` INT NOT NULL COMMENT 'Imagen de la firma o escaneo del consentimiento',
+  PRIMARY KEY (`id`),
+  CONSTRAINT `consentimiento_FK`
+    FOREIGN KEY (`id_paciente`)
+    REFERENCES `paciente` (`id`),
+  CONSTRAINT `consentimiento_FK_1`
+    FOREIGN KEY (`id_plantilla`)
+    REFERENCES `plantilla_consentimiento` (`id`),
+  CONSTRAINT `consentimiento_FK_2`
+    FOREIGN KEY (`id_adjunto`)
+    REFERENCES `adjunto` (`id`)
+) ENGINE = InnoDB AUTO_INCREMENT = 1 DEFAULT CHARACTER SET = utf8mb3;
+
+CREATE TABLE IF NOT EXISTS `alerta_medica` (
+  `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Identificador de la alerta',
+  `id_paciente` INT NOT NULL COMMENT 'Paciente',
+  `tipo` VARCHAR(20) NOT NULL COMMENT 'alergia, condicion_cronica, medicacion o embarazo',
+  `descripcion` VARCHAR(255) NOT NULL COMMENT 'Detalle (droga, dosis, condición)',
+  `severidad` VARCHAR(10) NOT NULL COMMENT 'baja, media o alta',
+  `activa` TINYINT(1) NOT NULL DEFAULT 1 COMMENT 'Se desactiva cuando deja de aplicar',
+  `fecha_desde` DATETIME NOT NULL COMMENT 'Desde cuándo aplica',
+  `fecha_hasta` DATETIME NULL COMMENT 'Hasta cuándo aplica, NULL si no tiene fin previsto',
+  PRIMARY KEY (`id`),
+  CONSTRAINT `alerta_medica_FK`
+    FOREIGN KEY (`id_paciente`)
+    REFERENCES `paciente` (`id`)
+) ENGINE = InnoDB AUTO_INCREMENT = 1 DEFAULT CHARACTER SET = utf8mb3;
+
+CREATE TABLE IF NOT EXISTS `telefono_paciente` (
+  `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Identificador del teléfono',
+  `id_paciente` INT NOT NULL COMMENT 'Paciente',
+  `numero` VARCHAR(16) NOT NULL COMMENT 'Número en formato E.164',
+  `tipo` VARCHAR(10) NOT NULL COMMENT 'movil, fijo o trabajo',
+  `principal` TINYINT(1) NOT NULL DEFAULT 0 COMMENT 'Número a usar por defecto',
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `telefono_paciente_UN` (`id_paciente` ASC, `numero` ASC) VISIBLE,
+  CONSTRAINT `telefono_paciente_FK`
+    FOREIGN KEY (`id_paciente`)
+    REFERENCES `paciente` (`id`)
+    ON DELETE CASCADE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 DEFAULT CHARACTER SET = utf8mb3;
+
+CREATE TABLE IF NOT EXISTS `contacto_emergencia` (
+  `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Identificador del contacto',
+  `id_paciente` INT NOT NULL COMMENT 'Paciente',
+  `nombre` VARCHAR(100) NOT NULL COMMENT 'Nombre y apellido del contacto',
+  `relacion` VARCHAR(50) NOT NULL DEFAULT '' COMMENT 'Vínculo con el paciente',
+  `telefono` VARCHAR(16) NOT NULL COMMENT 'Número en formato E.164',
+  PRIMARY KEY (`id`),
+  CONSTRAINT `contacto_emergencia_FK`
+    FOREIGN KEY (`id_paciente`)
+    REFERENCES `paciente` (`id`)
+    ON DELETE CASCADE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 DEFAULT CHARACTER SET = utf8mb3;
+
+CREATE TABLE IF NOT EXISTS `responsable_paciente` (
+  `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Identificador del responsable',
+  `id_paciente` INT NOT NULL COMMENT 'Paciente a cargo',
+  `id_responsable` INT NULL DEFAULT NULL COMMENT 'Paciente que es responsable, NULL si es externo',
+  `nombre` VARCHAR(150) NOT NULL COMMENT 'Nombre del responsable',
+  `dni` VARCHAR(12) NOT NULL COMMENT 'Identificación del responsable',
+  `relacion` VARCHAR(50) NOT NULL COMMENT 'Vínculo con el paciente (madre, padre, tutor)',
+  `telefono` VARCHAR(16) NOT NULL DEFAULT '' COMMENT 'Número en formato E.164',
+  `email` VARCHAR(254) NOT NULL DEFAULT '' COMMENT 'Correo electrónico',
+  PRIMARY KEY (`id`),
+  INDEX `responsable_paciente_FK` (`id_paciente` ASC) VISIBLE,
+  INDEX `responsable_paciente_FK_1` (`id_responsable` ASC) VISIBLE,
+  CONSTRAINT `responsable_paciente_FK`
+    FOREIGN KEY (`id_paciente`)
+    REFERENCES `paciente` (`id`)
+    ON DELETE CASCADE,
+  CONSTRAINT `responsable_paciente_FK_1`
+    FOREIGN KEY (`id_responsable`)
+    REFERENCES `paciente` (`id`)
+) ENGINE = InnoDB AUTO_INCREMENT = 1 DEFAULT CHARACTER SET = utf8mb3;
+
+-- Especialidades de los odontólogos
+CREATE TABLE IF NOT EXISTS `especialidad` (
+  `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Identificador de la especialidad',
+  `codigo` VARCHAR(30) NOT NULL COMMENT 'Código con el que se pide la especialidad (endodoncia, ortodoncia, ...)',
+  `nombre` VARCHAR(100) NOT NULL COMMENT 'Nombre para mostrar',
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `especialidad_UN` (`codigo` ASC) VISIBLE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 DEFAULT CHARACTER SET = utf8mb3;
+
+CREATE TABLE IF NOT EXISTS `odontologo_especialidad` (
+  `id_odontologo` INT NOT NULL COMMENT 'Odontólogo',
+  `id_especialidad` INT NOT NULL COMMENT 'Especialidad que ejerce',
+  PRIMARY KEY (`id_odontologo`, `id_especialidad`),
+  INDEX `odontologo_especialidad_FK_1` (`id_especialidad` ASC) VISIBLE,
+  CONSTRAINT `odontologo_especialidad_FK`
+    FOREIGN KEY (`id_odontologo`)
+    REFERENCES `odontologo` (`id`)
+    ON DELETE CASCADE,
+  CONSTRAINT `odontologo_especialidad_FK_1`
+    FOREIGN KEY (`id_especialidad`)
+    REFERENCES `especialidad` (`id`)
+) ENGINE = InnoDB DEFAULT CHARACTER SET = utf8mb3;
+
+-- Códigos de un solo uso para entrar al portal de pacientes
+CREATE TABLE IF NOT EXISTS `codigo_portal` (
+  `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Identificador del código',
+  `id_paciente` INT NOT NULL COMMENT 'Paciente al que se le envió',
+  `hash` VARCHAR(64) NOT NULL COMMENT 'HMAC-SHA256 del código, nunca el código en claro',
+  `vence` DATETIME NOT NULL COMMENT 'Hasta cuándo se puede usar',
+  `intentos` INT NOT NULL DEFAULT 0 COMMENT 'Intentos fallidos',
+  `usado` TINYINT(1) NOT NULL DEFAULT 0 COMMENT 'Ya usado o reemplazado por uno nuevo',
+  PRIMARY KEY (`id`),
+  INDEX `codigo_portal_FK` (`id_paciente` ASC) VISIBLE,
+  CONSTRAINT `codigo_portal_FK`
+    FOREIGN KEY (`id_paciente`)
+    REFERENCES `paciente` (`id`)
+    ON DELETE CASCADE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 DEFAULT CHARACTER SET = utf8mb3;
+
+-- Ausencias y cancelaciones tardías, para la política de cancelación
+CREATE TABLE IF NOT EXISTS `incidencia_turno` (
+  `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Identificador de la incidencia',
+  `id_turno` INT NOT NULL COMMENT 'Turno al que faltó o que canceló tarde',
+  `id_paciente` INT NOT NULL COMMENT 'Paciente del turno',
+  `tipo` VARCHAR(20) NOT NULL COMMENT 'ausencia o cancelacion_tardia',
+  `fecha` DATETIME NOT NULL COMMENT 'Fecha y hora del turno',
+  `penalidad` DECIMAL(10,2) NOT NULL DEFAULT 0 COMMENT 'Importe a cobrar según la política vigente',
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `incidencia_turno_UN` (`id_turno`),
+  INDEX `incidencia_turno_FK_1` (`id_paciente` ASC) VISIBLE,
+  CONSTRAINT `incidencia_turno_FK`
+    FOREIGN KEY (`id_turno`)
+    REFERENCES `turno` (`id`)
+    ON DELETE CASCADE,
+  CONSTRAINT `incidencia_turno_FK_1`
+    FOREIGN KEY (`id_paciente`)
+    REFERENCES `paciente` (`id`)
+    ON DELETE CASCADE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 DEFAULT CHARACTER SET = utf8mb3;
+
+-- Usuarios del personal y sus sesiones
+CREATE TABLE IF NOT EXISTS `usuario` (
+  `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Identificador del usuario',
+  `email` VARCHAR(254) NOT NULL COMMENT 'Email con el que inicia sesión',
+  `nombre` VARCHAR(150) NOT NULL COMMENT 'Nombre para mostrar',
+  `password_hash` VARCHAR(60) NOT NULL COMMENT 'Hash bcrypt de la contraseña',
+  `activo` TINYINT(1) NOT NULL DEFAULT 1 COMMENT 'Un usuario inactivo no puede iniciar sesión',
+  `rol` VARCHAR(20) NOT NULL DEFAULT 'recepcionista' COMMENT 'admin, recepcionista u odontologo',
+  `id_odontologo` INT NULL COMMENT 'Odontólogo que corresponde al usuario con rol odontologo',
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `usuario_UN` (`email` ASC) VISIBLE,
+  INDEX `usuario_odontologo_FK` (`id_odontologo` ASC) VISIBLE,
+  CONSTRAINT `usuario_odontologo_FK`
+    FOREIGN KEY (`id_odontologo`)
+    REFERENCES `odontologo` (`id`)
+) ENGINE = InnoDB AUTO_INCREMENT = 1 DEFAULT CHARACTER SET = utf8mb3;
+
+CREATE TABLE IF NOT EXISTS `refresh_token` (
+  `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Identificador del refresh token',
+  `id_usuario` INT NOT NULL COMMENT 'Usuario de la sesión',
+  `hash` VARCHAR(64) NOT NULL COMMENT 'SHA-256 del token, nunca el token en claro',
+  `vence` DATETIME NOT NULL COMMENT 'Hasta cuándo se puede usar',
+  `revocado` TINYINT(1) NOT NULL DEFAULT 0 COMMENT 'Ya usado, cerrado o revocado',
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `refresh_token_UN` (`hash` ASC) VISIBLE,
+  INDEX `refresh_token_FK` (`id_usuario` ASC) VISIBLE,
+  CONSTRAINT `refresh_token_FK`
+    FOREIGN KEY (`id_usuario`)
+    REFERENCES `usuario` (`id`)
+    ON DELETE CASCADE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 DEFAULT CHARACTER SET = utf8mb3;
+
+-- Usuario que hizo cada baja lógica; se agrega acá porque usuario se crea después de las demás tablas
+ALTER TABLE `odontologo`
+  ADD CONSTRAINT `odontologo_deleted_by_FK`
+    FOREIGN KEY (`deleted_by`)
+    REFERENCES `usuario` (`id`);
+
+ALTER TABLE `paciente`
+  ADD CONSTRAINT `paciente_deleted_by_FK`
+    FOREIGN KEY (`deleted_by`)
+    REFERENCES `usuario` (`id`);
+
+ALTER TABLE `turno`
+  ADD CONSTRAINT `turno_deleted_by_FK`
+    FOREIGN KEY (`deleted_by`)
+    REFERENCES `usuario` (`id`);
+
+-- Registro de cambios: sólo se inserta. Sin FK a las entidades para que sobreviva a las bajas.
+CREATE TABLE IF NOT EXISTS `auditoria` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT 'Identificador del registro',
+  `fecha_hora` DATETIME(3) NOT NULL COMMENT 'Cuándo se hizo el cambio',
+  `tipo_actor` VARCHAR(20) NOT NULL COMMENT 'usuario, paciente (portal) o sistema',
+  `id_actor` INT NULL DEFAULT NULL COMMENT 'ID del usuario o del paciente que hizo el cambio',
+  `actor` VARCHAR(254) NOT NULL DEFAULT '' COMMENT 'Email del usuario al momento del cambio',
+  `accion` VARCHAR(20) NOT NULL COMMENT 'alta, modificacion, baja o restauracion',
+  `entidad` VARCHAR(40) NOT NULL COMMENT 'paciente, alerta_medica, responsable, odontologo, turno o incidencia_turno',
+  `id_entidad` INT NOT NULL COMMENT 'ID de la entidad modificada',
+  `antes` JSON NULL DEFAULT NULL COMMENT 'Cómo estaba antes del cambio, NULL en las altas',
+  `despues` JSON NULL DEFAULT NULL COMMENT 'Cómo quedó, NULL en las bajas',
+  PRIMARY KEY (`id`),
+  INDEX `auditoria_entidad` (`entidad` ASC, `id_entidad` ASC) VISIBLE,
+  INDEX `auditoria_actor` (`tipo_actor` ASC, `id_actor` ASC) VISIBLE,
+  INDEX `auditoria_fecha` (`fecha_hora` ASC) VISIBLE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 DEFAULT CHARACTER SET = utf8mb3;
+
+CREATE TRIGGER `auditoria_sin_update` BEFORE UPDATE ON `auditoria` FOR EACH ROW
+  SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'la auditoría no se puede modificar';
+
+CREATE TRIGGER `auditoria_sin_delete` BEFORE DELETE ON `auditoria` FOR EACH ROW
+  SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'la auditoría no se puede borrar';
+
+-- Inserciones en la tabla 'especialidad'
+INSERT INTO `especialidad` (`codigo`, `nombre`)
+VALUES
+('general', 'Odontología general'),
+('ortodoncia', 'Ortodoncia'),
+('endodoncia', 'Endodoncia'),
+('periodoncia', 'Periodoncia'),
+('odontopediatria', 'Odontopediatría'),
+('cirugia', 'Cirugía bucomaxilofacial'),
+('implantes', 'Implantología'),
+('protesis', 'Prótesis');
@@ -0,0 +1,7 @@
+ALTER TABLE `paciente` DROP COLUMN `version`;
+ALTER TABLE `odontologo` DROP COLUMN `version`;
+ALTER TABLE `turno` DROP COLUMN `version`;
+ALTER TABLE `obra_social` DROP COLUMN `version`;
+ALTER TABLE `prestacion` DROP COLUMN `version`;
+ALTER TABLE `alerta_medica` DROP COLUMN `version`;
+ALTER TABLE `usuario` DROP COLUMN `version`;
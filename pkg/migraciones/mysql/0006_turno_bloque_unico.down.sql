@@ -0,0 +1 @@
+ALTER TABLE `turno` DROP INDEX `turno_bloque_UN`, DROP COLUMN `bloque_ocupado`;
@@ -0,0 +1,7 @@
+-- Un odontólogo no puede tener dos turnos vigentes en el mismo horario, aunque dos pedidos lo vean libre a la vez.
+-- MySQL no tiene índices parciales: la columna calculada tiene el horario sólo en los turnos que lo ocupan (ni
+-- cancelados ni dados de baja) y NULL en el resto, que el índice único no compara entre sí.
+-- Si la base ya tiene turnos superpuestos, la migración falla y hay que cancelar uno de cada par antes de aplicarla.
+ALTER TABLE `turno`
+  ADD COLUMN `bloque_ocupado` DATETIME GENERATED ALWAYS AS (CASE WHEN `estado` <> 'cancelado' AND `deleted_at` IS NULL THEN `fecha_hora` END) STORED COMMENT 'Horario que ocupa el turno vigente, NULL si no ocupa ninguno',
+  ADD UNIQUE INDEX `turno_bloque_UN` (`id_odontologo`, `bloque_ocupado`);
@@ -0,0 +1,3 @@
+ALTER TABLE `codigo_portal` ADD COLUMN `intentos` INT NOT NULL DEFAULT 0 COMMENT 'Intentos fallidos' AFTER `vence`;
+
+DROP TABLE IF EXISTS `intento_portal`;
@@ -0,0 +1,15 @@
+-- Pedidos de código e intentos de ingreso al portal, para limitarlos por DNI y por IP dentro de una ventana de tiempo.
+-- Reemplaza al contador de intentos de cada código, que se esquivaba pidiendo un código nuevo.
+CREATE TABLE IF NOT EXISTS `intento_portal` (
+  `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Identificador del intento',
+  `accion` VARCHAR(20) NOT NULL COMMENT 'codigo o ingreso',
+  `dni` VARCHAR(20) NOT NULL COMMENT 'DNI informado, exista o no el paciente',
+  `ip` VARCHAR(45) NOT NULL COMMENT 'IP del cliente',
+  `fecha` DATETIME NOT NULL COMMENT 'Momento del intento',
+  PRIMARY KEY (`id`),
+  INDEX `intento_portal_dni` (`accion` ASC, `dni` ASC, `fecha` ASC) VISIBLE,
+  INDEX `intento_portal_ip` (`accion` ASC, `ip` ASC, `fecha` ASC) VISIBLE,
+  INDEX `intento_portal_fecha` (`fecha` ASC) VISIBLE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 DEFAULT CHARACTER SET = utf8mb3;
+
+ALTER TABLE `codigo_portal` DROP COLUMN `intentos`;
@@ -0,0 +1,30 @@
+-- Borra todo el esquema. CASCADE se lleva las foreign keys entre odontologo y usuario.
+DROP TABLE IF EXISTS auditoria CASCADE;
+DROP TABLE IF EXISTS refresh_token CASCADE;
+DROP TABLE IF EXISTS usuario CASCADE;
+DROP TABLE IF EXISTS incidencia_turno CASCADE;
+DROP TABLE IF EXISTS codigo_portal CASCADE;
+DROP TABLE IF EXISTS odontologo_especialidad CASCADE;
+DROP TABLE IF EXISTS especialidad CASCADE;
+DROP TABLE IF EXISTS responsable_paciente CASCADE;
+DROP TABLE IF EXISTS contacto_emergencia CASCADE;
+DROP TABLE IF EXISTS telefono_paciente CASCADE;
+DROP TABLE IF EXISTS alerta_medica CASCADE;
+DROP TABLE IF EXISTS consentimiento CASCADE;
+DROP TABLE IF EXISTS plantilla_consentimiento CASCADE;
+DROP TABLE IF EXISTS adjunto CASCADE;
+DROP TABLE IF EXISTS layout_liquidacion CASCADE;
+DROP TABLE IF EXISTS pago_liquidacion CASCADE;
+DROP TABLE IF EXISTS item_liquidacion CASCADE;
+DROP TABLE IF EXISTS lote_liquidacion CASCADE;
+DROP TABLE IF EXISTS pago CASCADE;
+DROP TABLE IF EXISTS cargo CASCADE;
+DROP TABLE IF EXISTS prestacion CASCADE;
+DROP TABLE IF EXISTS regla_cobertura CASCADE;
+DROP TABLE IF EXISTS cobertura_paciente CASCADE;
+DROP TABLE IF EXISTS obra_social CASCADE;
+DROP TABLE IF EXISTS turno CASCADE;
+DROP TABLE IF EXISTS paciente CASCADE;
+DROP TABLE IF EXISTS odontologo CASCADE;
+
+DROP FUNCTION IF EXISTS auditoria_inmutable;
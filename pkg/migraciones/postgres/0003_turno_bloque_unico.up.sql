@@ -0,0 +1,3 @@
+-- Un odontólogo no puede tener dos turnos vigentes (ni cancelados ni dados de baja) en el mismo horario, aunque dos
+-- pedidos lo vean libre a la vez. Si la base ya tiene turnos superpuestos, hay que cancelar uno de cada par antes.
+CREATE UNIQUE INDEX turno_bloque_UN ON turno (id_odontologo, fecha_hora) WHERE estado <> 'cancelado' AND deleted_at IS NULL;
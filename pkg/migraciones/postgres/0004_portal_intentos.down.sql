@@ -0,0 +1,3 @@
+ALTER TABLE codigo_portal ADD COLUMN intentos INTEGER NOT NULL DEFAULT 0;
+
+DROP TABLE IF EXISTS intento_portal;
@@ -0,0 +1,14 @@
+-- Pedidos de código e intentos de ingreso al portal, para limitarlos por DNI y por IP dentro de una ventana de tiempo.
+-- Reemplaza al contador de intentos de cada código, que se esquivaba pidiendo un código nuevo.
+CREATE TABLE IF NOT EXISTS intento_portal (
+  id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  accion VARCHAR(20) NOT NULL,
+  dni VARCHAR(20) NOT NULL,
+  ip VARCHAR(45) NOT NULL,
+  fecha TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX intento_portal_dni ON intento_portal (accion, dni, fecha);
+CREATE INDEX intento_portal_ip ON intento_portal (accion, ip, fecha);
+CREATE INDEX intento_portal_fecha ON intento_portal (fecha);
+
+ALTER TABLE codigo_portal DROP COLUMN intentos;
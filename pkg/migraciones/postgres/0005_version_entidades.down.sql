@@ -0,0 +1,17 @@
+ALTER TABLE cobertura_paciente DROP COLUMN version;
+ALTER TABLE regla_cobertura DROP COLUMN version;
+ALTER TABLE cargo DROP COLUMN version;
+ALTER TABLE pago DROP COLUMN version;
+ALTER TABLE lote_liquidacion DROP COLUMN version;
+ALTER TABLE item_liquidacion DROP COLUMN version;
+ALTER TABLE pago_liquidacion DROP COLUMN version;
+ALTER TABLE layout_liquidacion DROP COLUMN version;
+ALTER TABLE adjunto DROP COLUMN version;
+ALTER TABLE consentimiento DROP COLUMN version;
+ALTER TABLE telefono_paciente DROP COLUMN version;
+ALTER TABLE contacto_emergencia DROP COLUMN version;
+ALTER TABLE responsable_paciente DROP COLUMN version;
+ALTER TABLE especialidad DROP COLUMN version;
+ALTER TABLE codigo_portal DROP COLUMN version;
+ALTER TABLE incidencia_turno DROP COLUMN version;
+ALTER TABLE refresh_token DROP COLUMN version;
@@ -0,0 +1,21 @@
+-- Versión en el resto de las entidades, con el mismo control que las de la 0002: cada modificación la incrementa y
+-- las bajas y cambios que piden los usuarios sólo se aplican sobre la versión leída (If-Match). Quedan afuera la
+-- auditoría y los intentos del portal, que sólo se insertan, y las plantillas de consentimiento, que no se modifican
+-- (cada cambio es una plantilla nueva) y ya tienen su propia columna version.
+ALTER TABLE cobertura_paciente ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE regla_cobertura ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE cargo ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE pago ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE lote_liquidacion ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE item_liquidacion ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE pago_liquidacion ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE layout_liquidacion ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE adjunto ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE consentimiento ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE telefono_paciente ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE contacto_emergencia ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE responsable_paciente ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE especialidad ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE codigo_portal ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE incidencia_turno ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE refresh_token ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,30 @@
+-- Borra todo el esquema. Las foreign keys se controlan recién al confirmar porque odontologo y usuario se apuntan entre sí.
+PRAGMA defer_foreign_keys = ON;
+
+DROP TABLE IF EXISTS auditoria;
+DROP TABLE IF EXISTS refresh_token;
+DROP TABLE IF EXISTS usuario;
+DROP TABLE IF EXISTS incidencia_turno;
+DROP TABLE IF EXISTS codigo_portal;
+DROP TABLE IF EXISTS odontologo_especialidad;
+DROP TABLE IF EXISTS especialidad;
+DROP TABLE IF EXISTS responsable_paciente;
+DROP TABLE IF EXISTS contacto_emergencia;
+DROP TABLE IF EXISTS telefono_paciente;
+DROP TABLE IF EXISTS alerta_medica;
+DROP TABLE IF EXISTS consentimiento;
+DROP TABLE IF EXISTS plantilla_consentimiento;
+DROP TABLE IF EXISTS adjunto;
+DROP TABLE IF EXISTS layout_liquidacion;
+DROP TABLE IF EXISTS pago_liquidacion;
+DROP TABLE IF EXISTS item_liquidacion;
+DROP TABLE IF EXISTS lote_liquidacion;
+DROP TABLE IF EXISTS pago;
+DROP TABLE IF EXISTS cargo;
+DROP TABLE IF EXISTS prestacion;
+DROP TABLE IF EXISTS regla_cobertura;
+DROP TABLE IF EXISTS cobertura_paciente;
+DROP TABLE IF EXISTS obra_social;
+DROP TABLE IF EXISTS turno;
+DROP TABLE IF EXISTS paciente;
+DROP TABLE IF EXISTS odontologo;
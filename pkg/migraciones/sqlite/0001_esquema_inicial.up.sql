@@ -0,0 +1,325 @@
+-- Esquema inicial para SQLite: el mismo que deja la última migración de MySQL. Las fechas se guardan como texto en
+-- UTC y las columnas se declaran DATE o DATETIME para que el driver las lea como fechas.
+
+CREATE TABLE IF NOT EXISTS odontologo (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  apellido VARCHAR(100) NOT NULL,
+  nombre VARCHAR(100) NOT NULL,
+  matricula VARCHAR(100) NOT NULL,
+  deleted_at DATETIME NULL DEFAULT NULL,
+  deleted_by INTEGER NULL DEFAULT NULL REFERENCES usuario (id)
+);
+CREATE INDEX odontologo_deleted_at ON odontologo (deleted_at);
+
+CREATE TABLE IF NOT EXISTS paciente (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  nombre VARCHAR(100) NOT NULL,
+  apellido VARCHAR(100) NOT NULL,
+  domicilio VARCHAR(100) NULL DEFAULT NULL,
+  dni VARCHAR(12) NOT NULL,
+  alta DATE NOT NULL,
+  fecha_nacimiento DATE NULL DEFAULT NULL,
+  email VARCHAR(254) NOT NULL DEFAULT '',
+  canal_preferido VARCHAR(10) NOT NULL DEFAULT '',
+  acepta_recordatorios INTEGER NOT NULL DEFAULT 0,
+  acepta_marketing INTEGER NOT NULL DEFAULT 0,
+  deleted_at DATETIME NULL DEFAULT NULL,
+  deleted_by INTEGER NULL DEFAULT NULL REFERENCES usuario (id)
+);
+CREATE INDEX paciente_deleted_at ON paciente (deleted_at);
+
+CREATE TABLE IF NOT EXISTS turno (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  id_odontologo INTEGER NULL DEFAULT NULL REFERENCES odontologo (id),
+  id_paciente INTEGER NOT NULL REFERENCES paciente (id),
+  fecha_hora DATETIME NULL DEFAULT NULL,
+  descripcion VARCHAR(300) NULL DEFAULT NULL,
+  codigo_prestacion VARCHAR(20) NOT NULL DEFAULT '',
+  estado VARCHAR(20) NOT NULL DEFAULT 'pendiente',
+  deleted_at DATETIME NULL DEFAULT NULL,
+  deleted_by INTEGER NULL DEFAULT NULL REFERENCES usuario (id)
+);
+CREATE INDEX turno_FK ON turno (id_odontologo);
+CREATE INDEX turno_FK_1 ON turno (id_paciente);
+CREATE INDEX turno_deleted_at ON turno (deleted_at);
+
+-- Obras sociales y prepagas
+CREATE TABLE IF NOT EXISTS obra_social (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  nombre VARCHAR(150) NOT NULL,
+  sigla VARCHAR(30) NOT NULL,
+  cuit VARCHAR(13) NULL DEFAULT NULL,
+  tipo VARCHAR(20) NOT NULL DEFAULT 'obra_social'
+);
+
+CREATE TABLE IF NOT EXISTS cobertura_paciente (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  id_paciente INTEGER NOT NULL REFERENCES paciente (id),
+  id_obra_social INTEGER NOT NULL REFERENCES obra_social (id),
+  plan VARCHAR(50) NOT NULL DEFAULT '',
+  numero_afiliado VARCHAR(50) NOT NULL,
+  vigencia_desde DATE NOT NULL,
+  vigencia_hasta DATE NULL DEFAULT NULL
+);
+CREATE INDEX cobertura_paciente_FK ON cobertura_paciente (id_paciente);
+CREATE INDEX cobertura_paciente_FK_1 ON cobertura_paciente (id_obra_social);
+
+CREATE TABLE IF NOT EXISTS regla_cobertura (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  id_obra_social INTEGER NOT NULL REFERENCES obra_social (id),
+  plan VARCHAR(50) NOT NULL DEFAULT '',
+  codigo_prestacion VARCHAR(20) NOT NULL,
+  porcentaje_cubierto DECIMAL(5,2) NOT NULL DEFAULT 0,
+  copago DECIMAL(10,2) NOT NULL DEFAULT 0,
+  requiere_autorizacion INTEGER NOT NULL DEFAULT 0
+);
+CREATE UNIQUE INDEX regla_cobertura_UN ON regla_cobertura (id_obra_social, plan, codigo_prestacion);
+
+-- Catálogo de prestaciones con su precio
+CREATE TABLE IF NOT EXISTS prestacion (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  codigo VARCHAR(20) NOT NULL,
+  descripcion VARCHAR(200) NOT NULL,
+  precio DECIMAL(10,2) NOT NULL DEFAULT 0
+);
+CREATE UNIQUE INDEX prestacion_UN ON prestacion (codigo);
+
+-- Facturación: cargos por turno atendido y pagos de pacientes
+CREATE TABLE IF NOT EXISTS cargo (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  id_turno INTEGER NOT NULL REFERENCES turno (id),
+  id_paciente INTEGER NOT NULL REFERENCES paciente (id),
+  id_obra_social INTEGER NULL DEFAULT NULL REFERENCES obra_social (id),
+  codigo_prestacion VARCHAR(20) NOT NULL,
+  fecha DATETIME NOT NULL,
+  importe DECIMAL(10,2) NOT NULL,
+  importe_obra_social DECIMAL(10,2) NOT NULL DEFAULT 0,
+  importe_paciente DECIMAL(10,2) NOT NULL
+);
+CREATE UNIQUE INDEX cargo_UN ON cargo (id_turno);
+CREATE INDEX cargo_FK_1 ON cargo (id_paciente);
+
+CREATE TABLE IF NOT EXISTS pago (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  id_paciente INTEGER NOT NULL REFERENCES paciente (id),
+  id_cargo INTEGER NULL DEFAULT NULL REFERENCES cargo (id),
+  medio VARCHAR(20) NOT NULL,
+  importe DECIMAL(10,2) NOT NULL,
+  fecha DATETIME NOT NULL,
+  referencia VARCHAR(100) NOT NULL DEFAULT ''
+);
+CREATE INDEX pago_FK ON pago (id_paciente);
+
+-- Liquidación a obras sociales: lotes por período, ítems reclamados y pagos recibidos
+CREATE TABLE IF NOT EXISTS lote_liquidacion (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  id_obra_social INTEGER NOT NULL REFERENCES obra_social (id),
+  periodo CHAR(7) NOT NULL,
+  estado VARCHAR(20) NOT NULL DEFAULT 'abierto',
+  fecha_creacion DATETIME NOT NULL,
+  fecha_envio DATETIME NULL DEFAULT NULL,
+  total DECIMAL(12,2) NOT NULL DEFAULT 0
+);
+CREATE INDEX lote_liquidacion_FK ON lote_liquidacion (id_obra_social);
+
+CREATE TABLE IF NOT EXISTS item_liquidacion (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  id_lote INTEGER NOT NULL REFERENCES lote_liquidacion (id),
+  id_cargo INTEGER NOT NULL REFERENCES cargo (id),
+  id_paciente INTEGER NOT NULL,
+  numero_afiliado VARCHAR(50) NOT NULL DEFAULT '',
+  plan VARCHAR(50) NOT NULL DEFAULT '',
+  codigo_prestacion VARCHAR(20) NOT NULL,
+  fecha DATETIME NOT NULL,
+  importe DECIMAL(10,2) NOT NULL,
+  importe_pagado DECIMAL(10,2) NOT NULL DEFAULT 0,
+  estado VARCHAR(20) NOT NULL DEFAULT 'pendiente',
+  motivo_rechazo VARCHAR(200) NOT NULL DEFAULT ''
+);
+CREATE INDEX item_liquidacion_FK ON item_liquidacion (id_lote);
+CREATE INDEX item_liquidacion_FK_1 ON item_liquidacion (id_cargo);
+
+CREATE TABLE IF NOT EXISTS pago_liquidacion (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  id_lote INTEGER NOT NULL REFERENCES lote_liquidacion (id),
+  fecha DATETIME NOT NULL,
+  importe DECIMAL(12,2) NOT NULL,
+  referencia VARCHAR(100) NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS layout_liquidacion (
+  id_obra_social INTEGER NOT NULL PRIMARY KEY REFERENCES obra_social (id),
+  definicion TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS adjunto (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  id_paciente INTEGER NOT NULL REFERENCES paciente (id),
+  tipo VARCHAR(20) NOT NULL,
+  nombre VARCHAR(255) NOT NULL,
+  content_type VARCHAR(100) NOT NULL,
+  tamanio BIGINT NOT NULL,
+  descripcion VARCHAR(255) NOT NULL DEFAULT '',
+  clave VARCHAR(255) NOT NULL,
+  fecha_carga DATETIME NOT NULL
+);
+CREATE UNIQUE INDEX adjunto_UN ON adjunto (clave);
+
+CREATE TABLE IF NOT EXISTS plantilla_consentimiento (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  codigo_prestacion VARCHAR(20) NOT NULL,
+  version INTEGER NOT NULL,
+  titulo VARCHAR(150) NOT NULL,
+  texto TEXT NOT NULL,
+  vigencia_dias INTEGER NOT NULL DEFAULT 0,
+  activa INTEGER NOT NULL DEFAULT 1,
+  fecha_creacion DATETIME NOT NULL
+);
+CREATE UNIQUE INDEX plantilla_consentimiento_UN ON plantilla_consentimiento (codigo_prestacion, version);
+
+-- id_responsable sin FK para conservar la firma si se desvincula al responsable
+CREATE TABLE IF NOT EXISTS consentimiento (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  id_paciente INTEGER NOT NULL REFERENCES paciente (id),
+  id_plantilla INTEGER NOT NULL REFERENCES plantilla_consentimiento (id),
+  firmado_por VARCHAR(150) NOT NULL,
+  id_responsable INTEGER NULL DEFAULT NULL,
+  fecha_firma DATETIME NOT NULL,
+  id_adjunto INTEGER NOT NULL REFERENCES adjunto (id)
+);
+
+CREATE TABLE IF NOT EXISTS alerta_medica (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  id_paciente INTEGER NOT NULL REFERENCES paciente (id),
+  tipo VARCHAR(20) NOT NULL,
+  descripcion VARCHAR(255) NOT NULL,
+  severidad VARCHAR(10) NOT NULL,
+  activa INTEGER NOT NULL DEFAULT 1,
+  fecha_desde DATETIME NOT NULL,
+  fecha_hasta DATETIME NULL
+);
+
+CREATE TABLE IF NOT EXISTS telefono_paciente (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  id_paciente INTEGER NOT NULL REFERENCES paciente (id) ON DELETE CASCADE,
+  numero VARCHAR(16) NOT NULL,
+  tipo VARCHAR(10) NOT NULL,
+  principal INTEGER NOT NULL DEFAULT 0
+);
+CREATE UNIQUE INDEX telefono_paciente_UN ON telefono_paciente (id_paciente, numero);
+
+CREATE TABLE IF NOT EXISTS contacto_emergencia (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  id_paciente INTEGER NOT NULL REFERENCES paciente (id) ON DELETE CASCADE,
+  nombre VARCHAR(100) NOT NULL,
+  relacion VARCHAR(50) NOT NULL DEFAULT '',
+  telefono VARCHAR(16) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS responsable_paciente (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  id_paciente INTEGER NOT NULL REFERENCES paciente (id) ON DELETE CASCADE,
+  id_responsable INTEGER NULL DEFAULT NULL REFERENCES paciente (id),
+  nombre VARCHAR(150) NOT NULL,
+  dni VARCHAR(12) NOT NULL,
+  relacion VARCHAR(50) NOT NULL,
+  telefono VARCHAR(16) NOT NULL DEFAULT '',
+  email VARCHAR(254) NOT NULL DEFAULT ''
+);
+CREATE INDEX responsable_paciente_FK ON responsable_paciente (id_paciente);
+CREATE INDEX responsable_paciente_FK_1 ON responsable_paciente (id_responsable);
+
+-- Especialidades de los odontólogos
+CREATE TABLE IF NOT EXISTS especialidad (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  codigo VARCHAR(30) NOT NULL,
+  nombre VARCHAR(100) NOT NULL
+);
+CREATE UNIQUE INDEX especialidad_UN ON especialidad (codigo);
+
+CREATE TABLE IF NOT EXISTS odontologo_especialidad (
+  id_odontologo INTEGER NOT NULL REFERENCES odontologo (id) ON DELETE CASCADE,
+  id_especialidad INTEGER NOT NULL REFERENCES especialidad (id),
+  PRIMARY KEY (id_odontologo, id_especialidad)
+);
+CREATE INDEX odontologo_especialidad_FK_1 ON odontologo_especialidad (id_especialidad);
+
+-- Códigos de un solo uso para entrar al portal de pacientes
+CREATE TABLE IF NOT EXISTS codigo_portal (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  id_paciente INTEGER NOT NULL REFERENCES paciente (id) ON DELETE CASCADE,
+  hash VARCHAR(64) NOT NULL,
+  vence DATETIME NOT NULL,
+  intentos INTEGER NOT NULL DEFAULT 0,
+  usado INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX codigo_portal_FK ON codigo_portal (id_paciente);
+
+-- Ausencias y cancelaciones tardías, para la política de cancelación
+CREATE TABLE IF NOT EXISTS incidencia_turno (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  id_turno INTEGER NOT NULL REFERENCES turno (id) ON DELETE CASCADE,
+  id_paciente INTEGER NOT NULL REFERENCES paciente (id) ON DELETE CASCADE,
+  tipo VARCHAR(20) NOT NULL,
+  fecha DATETIME NOT NULL,
+  penalidad DECIMAL(10,2) NOT NULL DEFAULT 0
+);
+CREATE UNIQUE INDEX incidencia_turno_UN ON incidencia_turno (id_turno);
+CREATE INDEX incidencia_turno_FK_1 ON incidencia_turno (id_paciente);
+
+-- Usuarios del personal y sus sesiones. SQLite acepta que las foreign keys de deleted_by apunten a usuario antes
+-- de crearlo.
+CREATE TABLE IF NOT EXISTS usuario (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  email VARCHAR(254) NOT NULL,
+  nombre VARCHAR(150) NOT NULL,
+  password_hash VARCHAR(60) NOT NULL,
+  activo INTEGER NOT NULL DEFAULT 1,
+  rol VARCHAR(20) NOT NULL DEFAULT 'recepcionista',
+  id_odontologo INTEGER NULL REFERENCES odontologo (id)
+);
+CREATE UNIQUE INDEX usuario_UN ON usuario (email);
+CREATE INDEX usuario_odontologo_FK ON usuario (id_odontologo);
+
+CREATE TABLE IF NOT EXISTS refresh_token (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  id_usuario INTEGER NOT NULL REFERENCES usuario (id) ON DELETE CASCADE,
+  hash VARCHAR(64) NOT NULL,
+  vence DATETIME NOT NULL,
+  revocado INTEGER NOT NULL DEFAULT 0
+);
+CREATE UNIQUE INDEX refresh_token_UN ON refresh_token (hash);
+CREATE INDEX refresh_token_FK ON refresh_token (id_usuario);
+
+-- Registro de cambios: sólo se inserta. Sin FK a las entidades para que sobreviva a las bajas.
+CREATE TABLE IF NOT EXISTS auditoria (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  fecha_hora DATETIME NOT NULL,
+  tipo_actor VARCHAR(20) NOT NULL,
+  id_actor INTEGER NULL DEFAULT NULL,
+  actor VARCHAR(254) NOT NULL DEFAULT '',
+  accion VARCHAR(20) NOT NULL,
+  entidad VARCHAR(40) NOT NULL,
+  id_entidad INTEGER NOT NULL,
+  antes TEXT NULL DEFAULT NULL,
+  despues TEXT NULL DEFAULT NULL
+);
+CREATE INDEX auditoria_entidad ON auditoria (entidad, id_entidad);
+CREATE INDEX auditoria_actor ON auditoria (tipo_actor, id_actor);
+CREATE INDEX auditoria_fecha ON auditoria (fecha_hora);
+
+-- Cada trigger va en una sola línea: el migrador corta las sentencias en el punto y coma al final de la línea
+CREATE TRIGGER auditoria_sin_update BEFORE UPDATE ON auditoria BEGIN SELECT RAISE(ABORT, 'la auditoría no se puede modificar'); END;
+
+CREATE TRIGGER auditoria_sin_delete BEFORE DELETE ON auditoria BEGIN SELECT RAISE(ABORT, 'la auditoría no se puede borrar'); END;
+
+INSERT INTO especialidad (codigo, nombre)
+VALUES
+('general', 'Odontología general'),
+('ortodoncia', 'Ortodoncia'),
+('endodoncia', 'Endodoncia'),
+('periodoncia', 'Periodoncia'),
+('odontopediatria', 'Odontopediatría'),
+('cirugia', 'Cirugía bucomaxilofacial'),
+('implantes', 'Implantología'),
+('protesis', 'Prótesis');
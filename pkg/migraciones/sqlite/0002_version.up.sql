@@ -0,0 +1,9 @@
+-- Versión de cada fila para el control de concurrencia: cada modificación la incrementa y los cambios que mandan
+-- una versión vieja se rechazan (If-Match).
+ALTER TABLE paciente ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE odontologo ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE turno ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE obra_social ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE prestacion ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE alerta_medica ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE usuario ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1 @@
+DROP INDEX turno_bloque_UN;
@@ -0,0 +1,88 @@
+package notificador
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Errores
+var (
+	ErrDestino = errors.New("mensaje sin destinatario")
+	ErrEnvio   = errors.New("no se pudo enviar el mensaje")
+)
+
+// Mensaje es lo que se le manda a un paciente. Canal es el de paciente.Canal* (sms, whatsapp, email) y Destino el
+// teléfono en E.164 o el correo según el canal.
+type Mensaje struct {
+	Canal   string `json:"canal"`
+	Destino string `json:"destino"`
+	Asunto  string `json:"asunto"`
+	Texto   string `json:"texto"`
+}
+
+// Notifier envía mensajes a los pacientes. Las implementaciones no reintentan; eso queda a cargo de quien llama.
+type Notifier interface {
+	Enviar(ctx context.Context, m Mensaje) error
+}
+
+// notificadorLog sólo escribe el mensaje en el log, para desarrollo
+type notificadorLog struct{}
+
+// NewLog devuelve un Notifier que no envía nada y deja el mensaje en el log.
+func NewLog() Notifier {
+	return notificadorLog{}
+}
+
+func (notificadorLog) Enviar(ctx context.Context, m Mensaje) error {
+	if m.Destino == "" {
+		return ErrDestino
+	}
+	log.Printf("notificación por %s a %s: %s", m.Canal, m.Destino, m.Texto)
+	return nil
+}
+
+// notificadorWebhook publica el mensaje como JSON en la URL de la pasarela de SMS, WhatsApp o correo
+type notificadorWebhook struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhook devuelve un Notifier que hace POST del Mensaje en JSON a la url. Cualquier respuesta 2xx es un envío
+// exitoso. Si client es nil se usa uno con timeout de 10 segundos.
+func NewWebhook(url string, client *http.Client) Notifier {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &notificadorWebhook{url, client}
+}
+
+func (n *notificadorWebhook) Enviar(ctx context.Context, m Mensaje) error {
+	if m.Destino == "" {
+		return ErrDestino
+	}
+	cuerpo, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrEnvio, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(cuerpo))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrEnvio, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrEnvio, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%w: la pasarela respondió %d", ErrEnvio, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package transaccion
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Ejecutor son las operaciones que usan los repositorios y que tienen tanto *sql.DB como *sql.Tx. Son las variantes
+// con contexto, para que una consulta se corte cuando vence el timeout o el cliente se desconecta.
+type Ejecutor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// Tx es una transacción abierta por un repositorio
+type Tx interface {
+	Ejecutor
+	Commit() error
+	Rollback() error
+}
+
+// UnitOfWork agrupa operaciones de varios repositorios en una sola transacción.
+type UnitOfWork interface {
+	// Ejecutar corre fn con un contexto que lleva la transacción: los repositorios que reciben ese contexto trabajan
+	// dentro de ella. Si fn devuelve error se deshace todo; si no, se confirma.
+	Ejecutar(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// clave propia para guardar la unidad de trabajo en un context.Context
+type claveUnidad struct{}
+
+// unidad es la unidad de trabajo en curso: su transacción y el momento en que empezó
+type unidad struct {
+	tx      *sql.Tx
+	momento time.Time
+}
+
+// estructura de la unidad de trabajo sobre la base
+type unitOfWork struct {
+	db *sql.DB
+}
+
+// NewUnitOfWork instancia la unidad de trabajo
+func NewUnitOfWork(db *sql.DB) UnitOfWork {
+	return &unitOfWork{
+		db: db,
+	}
+}
+
+func (u *unitOfWork) Ejecutar(ctx context.Context, fn func(ctx context.Context) error) error {
+	// si ya hay una unidad de trabajo en curso, esta se suma a la de afuera
+	if _, ok := enCurso(ctx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// el momento se redondea al segundo, que es lo que guardan las columnas DATETIME
+	en := unidad{tx: tx, momento: time.Now().Truncate(time.Second)}
+	if err := fn(context.WithValue(ctx, claveUnidad{}, en)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// enCurso devuelve la unidad de trabajo del contexto, si hay una
+func enCurso(ctx context.Context) (unidad, bool) {
+	en, ok := ctx.Value(claveUnidad{}).(unidad)
+	return en, ok
+}
+
+// Conexion devuelve la transacción de la unidad de trabajo en curso o, si no hay ninguna, la base.
+func Conexion(ctx context.Context, db *sql.DB) Ejecutor {
+	if en, ok := enCurso(ctx); ok {
+		return en.tx
+	}
+	return db
+}
+
+// Momento devuelve cuándo empezó la unidad de trabajo en curso, o ahora si no hay ninguna. Todo lo que se marca con
+// fecha dentro de una misma unidad (por ejemplo, las bajas en cascada) queda con la misma.
+func Momento(ctx context.Context) time.Time {
+	if en, ok := enCurso(ctx); ok {
+		return en.momento
+	}
+	return time.Now().Truncate(time.Second)
+}
+
+// Begin abre la transacción propia de un repositorio. Dentro de una unidad de trabajo no abre otra: usa la de la
+// unidad, y Commit y Rollback no hacen nada porque eso lo decide la unidad al terminar.
+func Begin(ctx context.Context, db *sql.DB) (Tx, error) {
+	if en, ok := enCurso(ctx); ok {
+		return incluida{en.tx}, nil
+	}
+	return db.BeginTx(ctx, nil)
+}
+
+// incluida es una transacción de repositorio que forma parte de una unidad de trabajo
+type incluida struct {
+	*sql.Tx
+}
+
+func (incluida) Commit() error   { return nil }
+func (incluida) Rollback() error { return nil }
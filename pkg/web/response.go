@@ -1,6 +1,11 @@
 package web
 
-import "github.com/gin-gonic/gin"
+import (
+	"context"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
 
 type errorResponse struct {
 	Status  int    `json:"status"`
@@ -17,13 +22,30 @@ type response struct {
 var error400 = "error de datos enviados"
 var error403 = "error de credenciales"
 var error404 = "no encuentra elemento por error de datos enviados"
+var error409 = "la operación no es compatible con el estado actual del elemento"
+var error412 = "el elemento cambió desde que se leyó, hay que volver a consultarlo"
+var error413 = "el archivo supera el tamaño permitido"
+var error415 = "tipo de archivo no admitido"
+var error428 = "falta el encabezado If-Match con la versión (ETag) del elemento"
+var error429 = "demasiados intentos, hay que esperar antes de volver a probar"
 var error500 =  "problemas de servidor"
+var error503 = "el pedido se canceló antes de terminar"
+var error504 = "la operación tardó más de lo permitido"
 var errorDefault = "Internal Server Error"
 
 
 // dos funciones: una de responseError (solo necesito pasarle el código de error porque el mensaje ya fue seteado) y otra de caso de exito
 func ErrorResponse(c *gin.Context, status int){
 
+	// si el pedido se quedó sin tiempo o se canceló, el error de las capas de abajo viene de eso y no de los datos
+	// (una consulta cortada a mitad de camino suele terminar como "no encontrado")
+	if c.Request != nil {
+		switch err := c.Request.Context().Err(); {
+			case errors.Is(err, context.DeadlineExceeded): status = 504
+			case errors.Is(err, context.Canceled): status = 503
+		}
+	}
+
 	respuesta := errorResponse{
 		Status: status,
 	}
@@ -34,7 +56,15 @@ func ErrorResponse(c *gin.Context, status int){
 		case 400: respuesta.Message = error400
 		case 403: respuesta.Message = error403
 		case 404: respuesta.Message = error404
+		case 409: respuesta.Message = error409
+		case 412: respuesta.Message = error412
+		case 413: respuesta.Message = error413
+		case 415: respuesta.Message = error415
+		case 428: respuesta.Message = error428
+		case 429: respuesta.Message = error429
 		case 500: respuesta.Message = error500
+		case 503: respuesta.Message = error503
+		case 504: respuesta.Message = error504
 		default: respuesta.Message = error500
 	}
 	
@@ -0,0 +1,31 @@
+package web
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ETag informa la versión del elemento, que el cliente tiene que devolver en If-Match para modificarlo o darlo de baja
+func ETag(c *gin.Context, version int) {
+	c.Header("ETag", `"`+strconv.Itoa(version)+`"`)
+}
+
+// IfMatch lee la versión que el cliente quiere modificar. Sin encabezado responde 428 y, si no es un ETag de los que
+// devolvemos, 412: ninguna versión puede coincidir con él. En los dos casos ok es false y el handler termina ahí.
+// "0" es el ETag de lo que todavía no se guardó (el layout por defecto de una obra social), así que se acepta.
+func IfMatch(c *gin.Context) (version int, ok bool) {
+	valor := strings.TrimSpace(c.GetHeader("If-Match"))
+	if valor == "" {
+		ErrorResponse(c, http.StatusPreconditionRequired)
+		return 0, false
+	}
+	version, err := strconv.Atoi(strings.Trim(valor, `"`))
+	if err != nil || version < 0 {
+		ErrorResponse(c, http.StatusPreconditionFailed)
+		return 0, false
+	}
+	return version, true
+}